@@ -6,26 +6,50 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/catalog"
 	"github.com/postgresql-ha-dr/api-go/internal/config"
 	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/grpcapi"
 	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
+	"github.com/postgresql-ha-dr/api-go/internal/logging"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	"github.com/postgresql-ha-dr/api-go/internal/migrations"
+	"github.com/postgresql-ha-dr/api-go/internal/notify"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
 )
 
 func main() {
+	startup := lifecycle.NewTracker(
+		lifecycle.PhaseConfigLoaded,
+		lifecycle.PhasePoolConnected,
+		lifecycle.PhaseMonitorsRunning,
+	)
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
+	}
+	logger := logging.Setup(cfg.Log)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
 	}
+	startup.Complete(lifecycle.PhaseConfigLoaded)
 
 	// Set Gin mode
 	if !cfg.App.Debug {
@@ -33,79 +57,446 @@ func main() {
 	}
 
 	// Initialize database pool
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
 	defer cancel()
 
 	var pool *db.Pool
 	pool, err = db.NewPool(ctx, &cfg.Database)
 	if err != nil {
-		log.Printf("Warning: Failed to initialize database pool: %v", err)
-		log.Printf("API will start but database features will be unavailable")
+		slog.Warn("Failed to initialize database pool; API will start but database features will be unavailable", "error", err)
+		startup.Fail(lifecycle.PhasePoolConnected, err)
 	} else {
 		defer pool.Close()
-		log.Println("Database connection pool initialized")
+		slog.Info("Database connection pool initialized")
+		startup.Complete(lifecycle.PhasePoolConnected)
+	}
+
+	// Connect read replicas used while the primary is unreachable. One
+	// that is down joins once the health checker reaches it
+	var replicas []*db.Pool
+	for _, entry := range cfg.Database.ReplicaHosts {
+		host, port, err := cfg.Database.ParseHostPort(entry)
+		if err != nil {
+			fatal("Invalid replica host", err)
+		}
+		replica, err := db.NewPoolForHost(ctx, &cfg.Database, host, port)
+		if err != nil {
+			slog.Warn("Failed to connect to replica", "replica", entry, "error", err)
+			if replica, err = db.NewLazyPoolForHost(&cfg.Database, host, port); err != nil {
+				fatal("Invalid replica host", err)
+			}
+		} else {
+			slog.Info("Read replica connected", "replica", entry)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	// Background workers stop when the server shuts down
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	cluster := db.NewCluster(pool, replicas, cfg.Database.HealthCheckInterval)
+	defer cluster.Close()
+	if cfg.Database.ReadPreference == config.ReadPreferenceReplica {
+		cluster.PreferReplicas()
+	}
+
+	// Bring the schema of the items and orders tables up to date
+	migrator, err := migrations.New(cfg.Items.KeyType)
+	if err != nil {
+		fatal("Invalid migrations", err)
+	}
+	// A schema from other migration files, e.g. a DR copy of another
+	// branch, is refused rather than served
+	var diverged *migrations.ChecksumError
+	if !cfg.Database.MigrateOnStart && pool != nil {
+		verifyCtx, cancelVerify := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
+		err := migrator.Verify(verifyCtx, pool)
+		cancelVerify()
+		if errors.As(err, &diverged) {
+			fatal("Refusing to start", err)
+		}
+		if err != nil {
+			slog.Warn("Failed to verify the applied migrations", "error", err)
+		}
+	}
+	if cfg.Database.MigrateOnStart && pool != nil {
+		migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
+		applied, err := migrator.Up(migrateCtx, pool)
+		cancelMigrate()
+		if errors.As(err, &diverged) {
+			fatal("Refusing to start", err)
+		}
+		if err != nil {
+			slog.Warn("Failed to migrate the schema; item, order, demo, job history, write probe and heartbeat requests may fail until POST /admin/migrate succeeds", "error", err)
+			startup.Fail(lifecycle.PhaseSchemaMigrated, err)
+		} else {
+			for _, migration := range applied {
+				slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+			}
+			startup.Complete(lifecycle.PhaseSchemaMigrated)
+		}
+	}
+	cluster.Start(bgCtx)
+
+	jobManager := jobs.NewManager(cfg.Jobs.Workers, cfg.Jobs.QueueSize, cfg.Jobs.HistorySize, cfg.Jobs.LogDir)
+	jobManager.Start(bgCtx)
+
+	// Connect further monitored clusters; one that is down is still listed
+	targets, err := cfg.Clusters.Targets(&cfg.Database)
+	if err != nil {
+		fatal("Invalid cluster", err)
+	}
+	var extraClusters []*handlers.MonitoredCluster
+	for _, target := range targets {
+		clusterCfg := cfg.ForCluster(target)
+		clusterCtx, cancel := context.WithTimeout(context.Background(), cfg.Database.ConnectTimeout)
+		clusterPool, err := db.NewPool(clusterCtx, &clusterCfg.Database)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to connect to cluster", "cluster", target.Name, "error", err)
+		} else {
+			defer clusterPool.Close()
+			slog.Info("Cluster connected", "cluster", target.Name)
+		}
+		extraClusters = append(extraClusters, handlers.NewMonitoredCluster(target.Name, clusterCfg, clusterPool, jobManager))
 	}
 
 	// Create router
 	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	httpMetrics := middleware.NewHTTPMetrics()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing())
+	router.Use(httpMetrics.Handler())
+	router.Use(middleware.RequestLog(logger, cfg.Log.RequestSampleRate))
+	router.Use(middleware.Recovery(logger))
+	router.Use(middleware.SecurityHeaders(cfg.Server))
 	router.Use(corsMiddleware())
 
-	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler(cfg, pool)
-	itemsHandler := handlers.NewItemsHandler(pool)
-	metricsHandler := handlers.NewMetricsHandler(pool)
-	backupsHandler := handlers.NewBackupsHandler(cfg)
+	// Initialize handlers. The monitoring handlers of the local cluster
+	// are also served under /clusters/:name
+	local := handlers.NewMonitoredCluster(cfg.Clusters.Name, cfg, pool, jobManager)
+	healthHandler := handlers.NewHealthHandler(cfg, pool, local.Backups)
+	startupHandler := handlers.NewStartupHandler(startup)
+	itemsHandler := handlers.NewItemsHandler(cfg, cluster)
+	ordersHandler := handlers.NewOrdersHandler(itemsHandler)
+	demoHandler := handlers.NewDemoHandler(itemsHandler)
+	migrationsHandler := handlers.NewMigrationsHandler(migrator, cluster, startup)
+	metricsHandler := local.Metrics
+	backupsHandler := local.Backups
+	summaryHandler := handlers.NewSummaryHandler(cfg, cluster, backupsHandler)
+	walHandler := local.WAL
+	locksHandler := local.Locks
+	sessionsHandler := local.Sessions
+	tablesHandler := local.Tables
+	indexesHandler := local.Indexes
+	maintenanceHandler := local.Maintenance
+	connectionsHandler := handlers.NewConnectionsHandler(pool)
+	settingsHandler := local.Settings
+	alertsHandler := local.Alerts
+	jobsHandler := handlers.NewJobsHandler(jobManager)
+	var jobCatalog catalog.Store = catalog.NewMemory(cfg.Jobs.CatalogMemorySize)
+	if cfg.Jobs.CatalogStore == config.CatalogStorePostgres {
+		jobCatalog = catalog.NewFallback(catalog.New(cluster), cfg.Jobs.CatalogMemorySize)
+	}
+	jobHistoryHandler := handlers.NewJobHistoryHandler(jobCatalog, jobManager)
+	restoreHandler := handlers.NewRestoreHandler(cfg, pool, jobManager)
+	watcherHandler := handlers.NewWatcherHandler(cfg, pool)
+	promoteHandler := handlers.NewPromoteHandler(cfg, pool, watcherHandler)
+	topologyHandler := handlers.NewTopologyHandler(cfg)
+	drHandler := handlers.NewDRHandler(cfg, pool)
+	sloHandler := handlers.NewSLOHandler(cfg, cluster)
+	probeHandler := handlers.NewProbeHandler(cluster)
+	roleHandler := handlers.NewRoleHandler(cfg)
+	defer roleHandler.Close()
+	identityHandler := local.Identity
+	replicationHandler := local.Replication
+	recoveryHandler := local.Recovery
+	clusterHandler := handlers.NewClusterHandler(cfg, backupsHandler, jobManager)
+	drillHandler := handlers.NewDrillHandler(clusterHandler, probeHandler, jobManager)
+	clustersHandler := handlers.NewClustersHandler(append([]*handlers.MonitoredCluster{local}, extraClusters...)...)
+	healthHandler.UseMaintenance(clusterHandler.Maintenance())
+	if cfg.Database.Retarget && pool != nil {
+		// Patroni knows the leader first; discovery covers clusters without it
+		locators := []db.Locator{{Name: "topology", Locate: topologyHandler.LocatePrimary}}
+		if len(cfg.Patroni.URLs) > 0 {
+			locators = append([]db.Locator{{Name: "patroni", Locate: clusterHandler.LocatePrimary}}, locators...)
+		}
+		retargeter := db.NewRetargeter(pool, cfg.Database.RetargetReadOnlyErrors, cfg.Database.HealthCheckInterval, locators...)
+		watcherHandler.UseRetargeter(retargeter)
+		retargeter.Start(bgCtx)
+	}
+	alertsHandler.UseEvents(watcherHandler.Events())
+	summaryHandler.UseAlerts(alertsHandler)
+	dispatcher, err := notify.NewDispatcher(cfg.App.Name,
+		notify.New(cfg.Notify.WebhookURLs, cfg.Notify.WebhookSecret, cfg.Notify.WebhookTimeout),
+		[]notify.Chat{
+			{Kind: notify.ChatSlack, URL: cfg.Notify.SlackWebhookURL},
+			{Kind: notify.ChatTeams, URL: cfg.Notify.TeamsWebhookURL},
+		},
+		cfg.Notify.MessageTemplate, cfg.Notify.Events)
+	if err != nil {
+		fatal("Invalid notification settings", err)
+	}
+	watcherHandler.UseDispatcher(dispatcher)
+
+	// Record the runs of the local cluster's background workers
+	workerRegistry := workers.NewRegistry()
+	backupsHandler.UseWorkers(workerRegistry)
+	metricsHandler.UseWorkers(workerRegistry)
+	alertsHandler.UseWorkers(workerRegistry)
+	watcherHandler.UseWorkers(workerRegistry)
+	sloHandler.UseWorkers(workerRegistry)
+	dispatcher.UseWorkers(workerRegistry)
+	workersHandler := handlers.NewWorkersHandler(workerRegistry)
+	prometheusHandler := handlers.NewPrometheusHandler(backupsHandler.Collector(), workersHandler.Collector(), httpMetrics)
+
+	// Operational endpoints, and the monitoring ones that show queries,
+	// locks or settings, take an API key with the role of the first
+	// matching rule; the other routes are open
+	apiKeys, err := cfg.Admin.Keys()
+	if err != nil {
+		fatal("Invalid API keys", err)
+	}
+	accessRules := []middleware.AccessRule{
+		{Method: http.MethodGet, Path: "/admin/", Role: config.RoleViewer},
+		{Method: http.MethodGet, Path: "/locks", Role: config.RoleViewer},
+		{Method: http.MethodGet, Path: "/sessions/", Role: config.RoleViewer},
+		{Method: http.MethodGet, Path: "/settings", Role: config.RoleViewer},
+		{Method: http.MethodGet, Path: "/recovery/", Role: config.RoleViewer},
+		{Method: http.MethodGet, Path: "/clusters/", Role: config.RoleViewer},
+		{Method: http.MethodPost, Path: "/admin/connections/", Role: config.RoleOperator},
+		{Path: "/admin/", Role: config.RoleAdmin},
+		{Method: http.MethodPost, Path: "/backups/", Role: config.RoleOperator},
+		{Method: http.MethodDelete, Path: "/jobs/", Role: config.RoleOperator},
+		{Method: http.MethodPost, Path: "/cluster/maintenance", Role: config.RoleOperator},
+		{Method: http.MethodPost, Path: "/cluster/", Role: config.RoleAdmin},
+		{Method: http.MethodPost, Path: "/restore", Role: config.RoleAdmin},
+		{Method: http.MethodPost, Path: "/drills", Role: config.RoleAdmin},
+	}
+	router.Use(middleware.Authorize(apiKeys, accessRules))
+	requiredRole := func(method, path string) string {
+		return middleware.RequiredRole(accessRules, method, path)
+	}
+	docsHandler := handlers.NewDocsHandler(cfg.App, router.Routes, requiredRole)
 
 	// Register routes
 	router.GET("/", healthHandler.Root)
-	router.GET("/health", healthHandler.Health)
-	router.GET("/ready", healthHandler.Ready)
-	router.GET("/metrics", metricsHandler.Metrics)
-	router.GET("/backups", backupsHandler.Backups)
+	router.GET("/docs", docsHandler.Docs)
+	router.GET("/openapi.json", docsHandler.OpenAPI)
+
+	// Every API version serves the same routes; handlers branch on the
+	// version of a request where response shapes differ.
+	for _, api := range []*gin.RouterGroup{
+		router.Group("", middleware.APIVersion(apiversion.Legacy)),
+		router.Group(apiversion.V1.Prefix(), middleware.APIVersion(apiversion.V1)),
+	} {
+		api.GET("/health", healthHandler.Health)
+		api.GET("/health/deep", healthHandler.Deep)
+		api.GET("/ready", healthHandler.Ready)
+		api.GET("/probe/write", probeHandler.Write)
+		api.GET("/role", roleHandler.Role)
+		api.GET("/startup", startupHandler.Startup)
+		api.GET("/metrics", metricsHandler.Metrics)
+		api.GET("/metrics/history", metricsHandler.History)
+		api.GET("/metrics/databases", metricsHandler.Databases)
+		api.GET("/metrics/prometheus", prometheusHandler.Metrics)
+		api.GET("/backups", backupsHandler.Backups)
+		api.GET("/backups/schedule", backupsHandler.Schedule)
+		api.GET("/backups/trends", backupsHandler.Trends)
+		api.GET("/backups/history", jobHistoryHandler.History)
+		api.GET("/backups/:stanza", backupsHandler.Stanza)
+		api.POST("/backups/stanza", backupsHandler.StanzaCreate)
+		api.POST("/backups/stanza/upgrade", backupsHandler.StanzaUpgrade)
+		api.GET("/summary", summaryHandler.Summary)
+		api.GET("/alerts", alertsHandler.Alerts)
+		api.GET("/wal/archiver", walHandler.Archiver)
+		api.GET("/replication/sync", replicationHandler.Sync)
+		api.GET("/locks", locksHandler.Locks)
+		api.GET("/sessions/problematic", sessionsHandler.Problematic)
+		api.GET("/tables", tablesHandler.Tables)
+		api.GET("/indexes", indexesHandler.Indexes)
+		api.GET("/maintenance/vacuum", maintenanceHandler.Vacuum)
+		api.GET("/settings", settingsHandler.Settings)
+
+		// Items CRUD
+		items := api.Group("/items")
+		{
+			items.POST("", itemsHandler.Create)
+			items.POST("/bulk", itemsHandler.BulkCreate)
+			items.POST("/bulk-delete", itemsHandler.BulkDelete)
+			items.POST("/bulk-update", itemsHandler.BulkUpdate)
+			items.POST("/batch-get", itemsHandler.BatchGet)
+			items.POST("/import", itemsHandler.Import)
+			items.GET("", itemsHandler.List)
+			items.GET("/export", itemsHandler.Export)
+			items.GET("/trash", itemsHandler.Trash)
+			items.GET("/:id", itemsHandler.Get)
+			items.GET("/:id/versions", itemsHandler.Versions)
+			items.GET("/:id/versions/:n", itemsHandler.Version)
+			items.PUT("", itemsHandler.Upsert)
+			items.PUT("/:id", itemsHandler.Update)
+			items.PATCH("/:id", itemsHandler.Patch)
+			items.DELETE("/:id", itemsHandler.Delete)
+			items.POST("/:id/restore", itemsHandler.Restore)
+		}
+
+		orders := api.Group("/orders")
+		{
+			orders.POST("", ordersHandler.Create)
+			orders.GET("", ordersHandler.List)
+			orders.GET("/integrity", ordersHandler.Integrity)
+			orders.GET("/:id", ordersHandler.Get)
+			orders.POST("/:id/cancel", ordersHandler.Cancel)
+		}
+
+		// Transfer demo of atomic multi-statement writes
+		demo := api.Group("/demo")
+		{
+			demo.POST("/transfer", demoHandler.Transfer)
+			demo.GET("/accounts", demoHandler.Accounts)
+		}
+
+		// Async jobs
+		api.GET("/jobs", jobsHandler.List)
+		api.GET("/jobs/:id", jobsHandler.Get)
+		api.GET("/jobs/:id/logs", jobsHandler.Logs)
+		api.DELETE("/jobs/:id", jobsHandler.Delete)
+
+		// Disaster recovery
+		api.POST("/restore", restoreHandler.Restore)
+		api.GET("/restore/plan", restoreHandler.Plan)
+		api.GET("/recovery/config", recoveryHandler.Config)
+		api.GET("/dr/status", drHandler.Status)
+		api.GET("/dr/position", drHandler.Position)
+		api.GET("/slo", sloHandler.SLO)
+
+		// HA cluster management
+		api.GET("/cluster", clusterHandler.Status)
+		api.POST("/cluster/switchover", clusterHandler.Switchover)
+		api.POST("/cluster/failover", clusterHandler.Failover)
+		api.POST("/drills", drillHandler.Run)
+		api.GET("/cluster/identity", identityHandler.Identity)
+		api.GET("/cluster/maintenance", clusterHandler.GetMaintenance)
+		api.POST("/cluster/maintenance", clusterHandler.SetMaintenance)
+		api.GET("/events", watcherHandler.List)
+		api.GET("/topology", topologyHandler.Topology)
+		api.GET("/topology/graph", topologyHandler.Graph)
 
-	// Items CRUD
-	items := router.Group("/items")
-	{
-		items.POST("", itemsHandler.Create)
-		items.GET("", itemsHandler.List)
-		items.GET("/:id", itemsHandler.Get)
-		items.PUT("/:id", itemsHandler.Update)
-		items.DELETE("/:id", itemsHandler.Delete)
+		// Monitoring of every configured cluster
+		api.GET("/clusters", clustersHandler.List)
+		clustersHandler.Routes(api.Group("/clusters/:name"))
+
+		// Admin operations
+		admin := api.Group("/admin")
+		{
+			admin.GET("/connections", connectionsHandler.List)
+			admin.POST("/connections/:pid/terminate", connectionsHandler.Terminate)
+			admin.POST("/promote", promoteHandler.Promote)
+			admin.GET("/migrations", migrationsHandler.List)
+			admin.POST("/migrate", migrationsHandler.Migrate)
+			admin.GET("/workers", workersHandler.List)
+		}
+	}
+
+	// Start background monitors
+	summaryHandler.Start(bgCtx)
+	backupsHandler.Start(bgCtx)
+	metricsHandler.Start(bgCtx)
+	alertsHandler.Start(bgCtx)
+	watcherHandler.Start(bgCtx)
+	sloHandler.Start(bgCtx)
+	for _, m := range extraClusters {
+		m.Start(bgCtx)
 	}
+	startup.Complete(lifecycle.PhaseMonitorsRunning)
 
 	// Create HTTP server
 	addr := fmt.Sprintf(":%d", cfg.App.Port)
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: router,
+		Addr:              addr,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Starting %s v%s on %s", cfg.App.Name, cfg.App.Version, addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+		if cfg.App.Profile != "" {
+			slog.Info("Using configuration profile", "profile", cfg.App.Profile)
+		}
+		slog.Info("Starting server", "name", cfg.App.Name, "version", cfg.App.Version, "addr", addr)
+
+		var err error
+		if cfg.Server.TLSEnabled() {
+			err = srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Failed to start server", err)
 		}
 	}()
 
+	// Serve the gRPC API from the same handlers, under the same access
+	// rules
+	var grpcServer *grpcapi.Server
+	if cfg.GRPC.Port != 0 {
+		grpcServer, err = grpcapi.NewServer(cfg, logger, apiKeys, requiredRole, grpcapi.Services{
+			Health:  healthHandler,
+			Metrics: metricsHandler,
+			Items:   itemsHandler,
+			Backups: backupsHandler,
+			Cluster: clusterHandler,
+			Jobs:    jobsHandler,
+			Events:  watcherHandler.Events(),
+		})
+		if err != nil {
+			fatal("Failed to create gRPC server", err)
+		}
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+		if err != nil {
+			fatal("Failed to start gRPC server", err)
+		}
+		go func() {
+			slog.Info("Starting gRPC server", "addr", lis.Addr().String())
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("Failed to start gRPC server", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	// Graceful shutdown with timeout
-	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", err)
+	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(ctx)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server exited")
+}
 
-	log.Println("Server exited")
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 // corsMiddleware adds CORS headers to responses.
@@ -113,8 +504,9 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With, X-Consistency-Token, X-Request-ID, If-Match, If-None-Match, traceparent, tracestate")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, X-Consistency-Token, X-Request-ID, Link, X-Database-Role, X-Replica-Lag-Bytes, X-Replica-Lag-Seconds, X-Replica-Last-Replay")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,101 @@
+// Package alerts tracks threshold breaches over time so that active
+// alerts can report how long they have been firing.
+package alerts
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Severity levels.
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Breach is a threshold violation observed during one evaluation. Value
+// is nil when the condition has no measurable value, such as a missing
+// backup.
+type Breach struct {
+	Rule      string
+	Severity  string
+	Message   string
+	Value     *float64
+	Threshold float64
+}
+
+// Alert is a breach that has been observed continuously since Since.
+type Alert struct {
+	Breach
+	Since    time.Time
+	LastSeen time.Time
+}
+
+// Tracker keeps the set of active alerts across evaluations.
+type Tracker struct {
+	mu        sync.RWMutex
+	active    map[string]*Alert
+	evaluated time.Time
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{active: make(map[string]*Alert)}
+}
+
+// Update records the breaches of one evaluation. Rules still breached keep
+// their original start time; rules no longer breached are resolved. It
+// returns the alerts that started firing and those that were resolved.
+func (t *Tracker) Update(breaches []Breach) (fired, resolved []Alert) {
+	now := time.Now().UTC()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]bool, len(breaches))
+	for _, b := range breaches {
+		seen[b.Rule] = true
+		if a, ok := t.active[b.Rule]; ok {
+			a.Breach = b
+			a.LastSeen = now
+			continue
+		}
+		t.active[b.Rule] = &Alert{Breach: b, Since: now, LastSeen: now}
+		fired = append(fired, *t.active[b.Rule])
+	}
+
+	for rule, a := range t.active {
+		if !seen[rule] {
+			resolved = append(resolved, *a)
+			delete(t.active, rule)
+		}
+	}
+	t.evaluated = now
+	return fired, resolved
+}
+
+// Active returns the active alerts ordered by start time.
+func (t *Tracker) Active() []Alert {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]Alert, 0, len(t.active))
+	for _, a := range t.active {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Since.Equal(out[j].Since) {
+			return out[i].Rule < out[j].Rule
+		}
+		return out[i].Since.Before(out[j].Since)
+	})
+	return out
+}
+
+// LastEvaluated returns when Update was last called.
+func (t *Tracker) LastEvaluated() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.evaluated
+}
@@ -0,0 +1,63 @@
+// Package apiversion defines the versions of the HTTP API. Every version
+// serves the same routes: the unversioned paths keep the response shapes
+// existing consumers were written against, and /v1 and later versions
+// may change them. Handlers read the version of a request with Get and
+// branch on it only where shapes differ.
+package apiversion
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is an API version; Legacy is the unversioned paths.
+type Version int
+
+// API versions.
+const (
+	Legacy Version = 0
+	V1     Version = 1
+
+	// Latest is the version new consumers should use.
+	Latest = V1
+)
+
+// contextKey is the gin context key holding the version of a request.
+const contextKey = "api_version"
+
+// Prefix returns the path prefix of v, e.g. /v1, or "" for Legacy.
+func (v Version) Prefix() string {
+	if v == Legacy {
+		return ""
+	}
+	return "/v" + strconv.Itoa(int(v))
+}
+
+// Set records the version of a request.
+func Set(c *gin.Context, v Version) {
+	c.Set(contextKey, v)
+}
+
+// Get returns the version of a request, Legacy when none was set.
+func Get(c *gin.Context) Version {
+	v, _ := c.Get(contextKey)
+	version, _ := v.(Version)
+	return version
+}
+
+var prefixPattern = regexp.MustCompile(`^/v[1-9][0-9]*(/.*)?$`)
+
+// StripPrefix returns path without its version prefix, so /v1/items and
+// /items compare equal.
+func StripPrefix(path string) string {
+	m := prefixPattern.FindStringSubmatch(path)
+	switch {
+	case m == nil:
+		return path
+	case m[1] == "":
+		return "/"
+	}
+	return m[1]
+}
@@ -0,0 +1,225 @@
+// Package autofailover reads the state pg_auto_failover keeps on its
+// monitor node.
+package autofailover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// ErrNotConfigured is returned when no monitor URI is configured.
+var ErrNotConfigured = errors.New("no pg_auto_failover monitor URI is configured")
+
+// Node roles derived from the reported state.
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Node health as checked by the monitor.
+const (
+	HealthUnknown = "unknown"
+	HealthBad     = "bad"
+	HealthGood    = "good"
+)
+
+// primaryStates are the replication states of a node accepting writes.
+var primaryStates = map[string]bool{
+	"single":         true,
+	"wait_primary":   true,
+	"primary":        true,
+	"join_primary":   true,
+	"apply_settings": true,
+}
+
+// Formation is a row of pgautofailover.formation.
+type Formation struct {
+	ID                 string
+	Kind               string
+	DBName             string
+	Secondary          bool
+	NumberSyncStandbys int
+	Nodes              []Node
+}
+
+// Node is a row of pgautofailover.node. AssignedState is the goal state
+// the monitor assigned; ReportedState is the state the node's keeper last
+// reported reaching.
+type Node struct {
+	ID                int64
+	GroupID           int
+	Name              string
+	Host              string
+	Port              int
+	AssignedState     string
+	ReportedState     string
+	PgIsRunning       bool
+	ReplicationState  string
+	ReportedAt        *time.Time
+	Timeline          int
+	LSN               string
+	Health            string
+	CandidatePriority int
+	ReplicationQuorum bool
+}
+
+// Role returns RolePrimary when the node reports a state accepting
+// writes and RoleReplica otherwise.
+func (n Node) Role() string {
+	if primaryStates[n.ReportedState] {
+		return RolePrimary
+	}
+	return RoleReplica
+}
+
+// Transitioning reports whether the node has not reached its assigned
+// state yet.
+func (n Node) Transitioning() bool {
+	return n.AssignedState != n.ReportedState
+}
+
+// Primary returns the primary of a group, if any.
+func (f *Formation) Primary(groupID int) (Node, bool) {
+	for _, n := range f.Nodes {
+		if n.GroupID == groupID && n.Role() == RolePrimary {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// Groups returns the group IDs of the formation in order.
+func (f *Formation) Groups() []int {
+	var groups []int
+	seen := map[int]bool{}
+	for _, n := range f.Nodes {
+		if !seen[n.GroupID] {
+			seen[n.GroupID] = true
+			groups = append(groups, n.GroupID)
+		}
+	}
+	return groups
+}
+
+// LagBytes returns how far the last LSN reported by n is behind the one
+// reported by the primary of its group. Keepers report every few seconds,
+// so this is an estimate.
+func (f *Formation) LagBytes(n Node) (int64, bool) {
+	primary, ok := f.Primary(n.GroupID)
+	if !ok || primary.ID == n.ID {
+		return 0, false
+	}
+	primaryLSN, err := wal.ParseLSN(primary.LSN)
+	if err != nil {
+		return 0, false
+	}
+	lsn, err := wal.ParseLSN(n.LSN)
+	if err != nil {
+		return 0, false
+	}
+	if lsn >= primaryLSN {
+		return 0, true
+	}
+	return int64(primaryLSN - lsn), true
+}
+
+// Client reads one formation from the monitor. A connection is opened
+// for each read; the monitor is only queried on demand.
+type Client struct {
+	uri       string
+	formation string
+	timeout   time.Duration
+}
+
+// New creates a client for the monitor at uri, e.g.
+// postgres://autoctl_node@monitor:5432/pg_auto_failover, reading the
+// given formation.
+func New(uri, formation string, timeout time.Duration) *Client {
+	return &Client{uri: uri, formation: formation, timeout: timeout}
+}
+
+// Enabled reports whether a monitor URI is configured.
+func (c *Client) Enabled() bool {
+	return c != nil && c.uri != ""
+}
+
+// Formation returns the formation and its nodes, ordered by group and
+// node ID.
+func (c *Client) Formation(ctx context.Context) (*Formation, error) {
+	if !c.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	conn, err := pgx.Connect(ctx, c.uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the monitor: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	f := &Formation{}
+	err = conn.QueryRow(ctx, `
+		SELECT formationid, kind::text, dbname, opt_secondary, number_sync_standbys
+		FROM pgautofailover.formation
+		WHERE formationid = $1`, c.formation).
+		Scan(&f.ID, &f.Kind, &f.DBName, &f.Secondary, &f.NumberSyncStandbys)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("formation %q does not exist on the monitor", c.formation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read formation %q: %w", c.formation, err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT nodeid, groupid, nodename, nodehost, nodeport,
+		       goalstate::text, reportedstate::text, reportedpgisrunning,
+		       coalesce(reportedrepstate, ''), reporttime, reportedtli,
+		       coalesce(reportedlsn::text, ''), health, candidatepriority,
+		       replicationquorum
+		FROM pgautofailover.node
+		WHERE formationid = $1
+		ORDER BY groupid, nodeid`, c.formation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n Node
+		var health int
+		if err := rows.Scan(&n.ID, &n.GroupID, &n.Name, &n.Host, &n.Port,
+			&n.AssignedState, &n.ReportedState, &n.PgIsRunning,
+			&n.ReplicationState, &n.ReportedAt, &n.Timeline,
+			&n.LSN, &health, &n.CandidatePriority,
+			&n.ReplicationQuorum); err != nil {
+			return nil, fmt.Errorf("failed to read nodes: %w", err)
+		}
+		n.Health = healthName(health)
+		f.Nodes = append(f.Nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nodes: %w", err)
+	}
+	return f, nil
+}
+
+// healthName maps the monitor's health check result: -1 before the first
+// check, 0 when it failed and 1 when it succeeded.
+func healthName(health int) string {
+	switch health {
+	case 0:
+		return HealthBad
+	case 1:
+		return HealthGood
+	}
+	return HealthUnknown
+}
@@ -0,0 +1,164 @@
+// Package backup abstracts the tools used to take, list and restore
+// backups behind a common Provider interface.
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Provider names accepted in BACKUP_PROVIDER.
+const (
+	ProviderPgBackRest = "pgbackrest"
+	ProviderBarman     = "barman"
+	ProviderWALG       = "wal-g"
+	ProviderPgDump     = "pg_dump"
+)
+
+// Provider takes, lists and restores backups. A target is a pgbackrest
+// stanza, a Barman server, the single WAL-G storage or, for logical dumps,
+// a database.
+type Provider interface {
+	// Name returns the provider name.
+	Name() string
+	// Targets returns the configured targets, the default one first.
+	Targets() []string
+	// BackupTypes returns the backup types Backup accepts.
+	BackupTypes() []string
+	// Info lists the backups of target. Failures are reported through
+	// the Status field.
+	Info(ctx context.Context, target string) models.BackupResponse
+	// Backup takes a backup of target, writing the tool's output to out.
+	Backup(ctx context.Context, target, backupType string, out io.Writer) error
+	// RestoreCommand validates req and returns the command that restores
+	// target.
+	RestoreCommand(target string, req models.RestoreRequest) (Command, error)
+	// RequiresStoppedCluster reports whether PostgreSQL must be stopped
+	// before restoring.
+	RequiresStoppedCluster() bool
+}
+
+// StanzaManager is implemented by providers whose targets must be set up
+// in the repository before the first backup.
+type StanzaManager interface {
+	// StanzaCreate initializes target in the repository.
+	StanzaCreate(ctx context.Context, target string, out io.Writer) error
+	// StanzaUpgrade updates target after a PostgreSQL major upgrade.
+	StanzaUpgrade(ctx context.Context, target string, out io.Writer) error
+}
+
+// New returns the provider selected by cfg.Backup.Provider.
+func New(cfg *config.Config) Provider {
+	switch cfg.Backup.Provider {
+	case ProviderPgDump:
+		return &PgDump{cfg: cfg.Backup, db: cfg.Database}
+	case ProviderBarman:
+		return &Barman{cfg: cfg.Backup}
+	case ProviderWALG:
+		return &WALG{cfg: cfg.Backup}
+	}
+	return &PgBackRest{cfg: cfg.Backup}
+}
+
+// Command is a prepared external command. Argv is safe to display; secrets
+// are only passed through Env.
+type Command struct {
+	Argv []string
+	Env  []string
+}
+
+// Exec builds the exec.Cmd for the command.
+func (c Command) Exec(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.Argv[0], c.Argv[1:]...)
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	return cmd
+}
+
+// Run executes the command with stdout and stderr written to out.
+func (c Command) Run(ctx context.Context, out io.Writer) (err error) {
+	ctx, span := c.startSpan(ctx)
+	defer func() { tracing.End(span, err) }()
+
+	cmd := c.Exec(ctx)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// Output executes the command and returns its stdout.
+func (c Command) Output(ctx context.Context) (output []byte, err error) {
+	ctx, span := c.startSpan(ctx)
+	defer func() { tracing.End(span, err) }()
+
+	return c.Exec(ctx).Output()
+}
+
+// startSpan starts the span of a run of the command, named after the
+// backup tool rather than ssh when it runs on another machine.
+func (c Command) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	tool := filepath.Base(c.Argv[0])
+	if tool == "ssh" {
+		if fields := strings.Fields(c.Argv[len(c.Argv)-1]); len(fields) > 0 {
+			tool = filepath.Base(strings.Trim(fields[0], "'"))
+		}
+	}
+	return tracing.Start(ctx, "exec "+tool, trace.SpanKindInternal,
+		semconv.ProcessExecutableName(tool),
+		semconv.ProcessCommandArgs(c.Argv...),
+	)
+}
+
+// remoteArgv wraps argv in ssh when the backup tool runs on another
+// machine (cfg.SSHHost) and returns it unchanged otherwise.
+func remoteArgv(cfg config.BackupConfig, argv []string) []string {
+	if cfg.SSHHost == "" {
+		return argv
+	}
+
+	target := cfg.SSHHost
+	if cfg.SSHUser != "" {
+		target = cfg.SSHUser + "@" + target
+	}
+	ssh := []string{"ssh", "-o", "BatchMode=yes"}
+	if cfg.SSHPort > 0 && cfg.SSHPort != 22 {
+		ssh = append(ssh, "-p", strconv.Itoa(cfg.SSHPort))
+	}
+	if cfg.SSHKeyFile != "" {
+		ssh = append(ssh, "-i", cfg.SSHKeyFile)
+	}
+
+	// ssh joins the remote command into a single shell string, so each
+	// argument is quoted individually.
+	quoted := make([]string, len(argv))
+	for i, a := range argv {
+		quoted[i] = shellQuote(a)
+	}
+	return append(ssh, target, "--", strings.Join(quoted, " "))
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=/.:+@,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func strPtr(s string) *string {
+	return &s
+}
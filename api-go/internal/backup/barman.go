@@ -0,0 +1,225 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// barmanBackupIDPattern matches Barman backup IDs such as 20240101T120000.
+var barmanBackupIDPattern = regexp.MustCompile(`^\d{8}T\d{6}$`)
+
+// walFileNamePattern matches a WAL segment file name.
+var walFileNamePattern = regexp.MustCompile(`^[0-9A-F]{24}`)
+
+// barmanBackup is one entry of `barman -f json list-backup`.
+type barmanBackup struct {
+	BackupID         string `json:"backup_id"`
+	BackupName       string `json:"backup_name"`
+	Status           string `json:"status"`
+	EndTimeTimestamp string `json:"end_time_timestamp"`
+	SizeBytes        int64  `json:"size_bytes"`
+	WALSizeBytes     int64  `json:"wal_size_bytes"`
+}
+
+// barmanStatusItem is one entry of `barman -f json status`.
+type barmanStatusItem struct {
+	Description string `json:"description"`
+	Message     string `json:"message"`
+}
+
+// Barman is the physical backup provider backed by a Barman server. A
+// target is a server name from barman.conf.
+type Barman struct {
+	cfg config.BackupConfig
+}
+
+// Name returns the provider name.
+func (p *Barman) Name() string { return ProviderBarman }
+
+// Targets returns the configured Barman servers.
+func (p *Barman) Targets() []string {
+	list := []string{}
+	for _, s := range p.cfg.BarmanServers {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// BackupTypes returns "full"; Barman base backups are always complete.
+func (p *Barman) BackupTypes() []string { return []string{"full"} }
+
+// RequiresStoppedCluster is true; barman recover overwrites the data
+// directory.
+func (p *Barman) RequiresStoppedCluster() bool { return true }
+
+// argv returns the barman command line for args.
+func (p *Barman) argv(args ...string) []string {
+	return remoteArgv(p.cfg, append([]string{"barman"}, args...))
+}
+
+// Backup runs barman backup and waits for the WAL needed to make it
+// consistent.
+func (p *Barman) Backup(ctx context.Context, server, backupType string, out io.Writer) error {
+	if backupType != "full" {
+		return fmt.Errorf("barman does not support %s backups", backupType)
+	}
+	if err := (Command{Argv: p.argv("backup", "--wait", server)}).Run(ctx, out); err != nil {
+		return fmt.Errorf("barman backup failed: %w", err)
+	}
+	return nil
+}
+
+// Info lists the backups of server with barman list-backup and reads the
+// last archived WAL from barman status.
+func (p *Barman) Info(ctx context.Context, server string) models.BackupResponse {
+	response := models.BackupResponse{
+		Provider:  ProviderBarman,
+		Stanza:    server,
+		Backups:   []models.BackupInfo{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	cmd := Command{Argv: p.argv("-f", "json", "list-backup", server)}
+	output, err := cmd.Output(ctx)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			response.Status = "not_installed"
+			response.StatusMessage = strPtr(filepath.Base(cmd.Argv[0]) + " is not installed on this system")
+		} else {
+			response.Status = "unavailable"
+			response.StatusMessage = strPtr("Barman error: " + err.Error())
+		}
+		return response
+	}
+
+	var listed map[string][]barmanBackup
+	if err := json.Unmarshal(output, &listed); err != nil {
+		response.Status = "parse_error"
+		response.StatusMessage = strPtr("Failed to parse Barman output: " + err.Error())
+		return response
+	}
+
+	for _, b := range listed[server] {
+		if b.Status != "DONE" {
+			continue
+		}
+		backup := models.BackupInfo{Label: b.BackupID, Type: "full"}
+		if b.BackupName != "" {
+			backup.Annotation = map[string]string{"backup_name": b.BackupName}
+		}
+		if ts, err := strconv.ParseFloat(b.EndTimeTimestamp, 64); err == nil {
+			stop := time.Unix(int64(ts), 0).UTC()
+			backup.StopTime = &stop
+		}
+		if size := b.SizeBytes; size > 0 {
+			backup.SizeBytes = &size
+		}
+		response.Backups = append(response.Backups, backup)
+	}
+
+	// Barman lists the newest backup first; IDs sort chronologically.
+	sort.Slice(response.Backups, func(i, j int) bool {
+		return response.Backups[i].Label < response.Backups[j].Label
+	})
+	if n := len(response.Backups); n > 0 {
+		response.LastFullBackup = response.Backups[n-1].StopTime
+		response.Status = "ok"
+	} else {
+		response.Status = "no_backup"
+		response.StatusMessage = strPtr("No completed backups for server " + server)
+	}
+
+	if last := p.lastArchivedWAL(ctx, server); last != "" {
+		response.WALArchive = &models.WALArchiveInfo{MaxWAL: strPtr(last)}
+	}
+	return response
+}
+
+// lastArchivedWAL returns the newest WAL segment Barman has received for
+// server, or an empty string when unknown.
+func (p *Barman) lastArchivedWAL(ctx context.Context, server string) string {
+	output, err := Command{Argv: p.argv("-f", "json", "status", server)}.Output(ctx)
+	if err != nil {
+		return ""
+	}
+
+	var status map[string]map[string]barmanStatusItem
+	if err := json.Unmarshal(output, &status); err != nil {
+		return ""
+	}
+
+	// The message reads "<wal file>, at <time>" once WAL has arrived.
+	return walFileNamePattern.FindString(status[server]["last_archived_wal"].Message)
+}
+
+// RestoreCommand returns the barman recover command. Time and LSN targets
+// recover from backup_label when given and from the latest backup
+// otherwise; Barman has no delta restore.
+func (p *Barman) RestoreCommand(server string, req models.RestoreRequest) (Command, error) {
+	if req.Delta {
+		return Command{}, errors.New("delta restore requires the pgbackrest provider")
+	}
+	if req.BackupLabel != "" && !barmanBackupIDPattern.MatchString(req.BackupLabel) {
+		return Command{}, fmt.Errorf("invalid backup_label %q", req.BackupLabel)
+	}
+
+	action := req.TargetAction
+	if action == "" {
+		action = "promote"
+	}
+
+	backupID := "latest"
+	if req.BackupLabel != "" {
+		backupID = req.BackupLabel
+	}
+
+	args := []string{"recover"}
+	switch req.Target {
+	case models.RestoreTargetLatest:
+	case models.RestoreTargetBackup:
+		if req.BackupLabel == "" {
+			return Command{}, errors.New("backup_label is required for target 'backup'")
+		}
+		args = append(args, "--target-immediate", "--target-action", action)
+	case models.RestoreTargetTime:
+		if req.Timestamp == nil {
+			return Command{}, errors.New("timestamp is required for target 'time'")
+		}
+		if req.Timestamp.After(time.Now()) {
+			return Command{}, errors.New("timestamp is in the future")
+		}
+		target := req.Timestamp.UTC().Format("2006-01-02 15:04:05.000000+00")
+		args = append(args, "--target-time", target, "--target-action", action)
+	case models.RestoreTargetLSN:
+		lsn, err := wal.ParseLSN(req.LSN)
+		if err != nil {
+			return Command{}, err
+		}
+		args = append(args, "--target-lsn", lsn.String(), "--target-action", action)
+	default:
+		return Command{}, fmt.Errorf("unknown target %q", req.Target)
+	}
+
+	if p.cfg.BarmanRemoteSSHCommand != "" {
+		args = append(args, "--remote-ssh-command", p.cfg.BarmanRemoteSSHCommand)
+	}
+	args = append(args, server, backupID, p.cfg.DataDir)
+	return Command{Argv: p.argv(args...)}, nil
+}
@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// History records completed backups per target so size and duration
+// trends outlive repository retention. It is persisted as a JSON file.
+type History struct {
+	path      string
+	retention time.Duration
+
+	mu      sync.RWMutex
+	targets map[string][]models.BackupTrendPoint
+}
+
+// NewHistory loads the history stored at path. An empty path keeps the
+// history in memory only. Points older than retention are dropped; zero
+// keeps them forever.
+func NewHistory(path string, retention time.Duration) (*History, error) {
+	h := &History{
+		path:      path,
+		retention: retention,
+		targets:   make(map[string][]models.BackupTrendPoint),
+	}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, &h.targets); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// Record adds the completed backups of target that are not yet in the
+// history and saves the file when anything changed.
+func (h *History) Record(target string, backups []models.BackupInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	points := h.targets[target]
+	seen := make(map[string]bool, len(points))
+	for _, p := range points {
+		seen[p.Label] = true
+	}
+
+	var cutoff time.Time
+	if h.retention > 0 {
+		cutoff = time.Now().Add(-h.retention)
+	}
+
+	kept := make([]models.BackupTrendPoint, 0, len(points))
+	for _, p := range points {
+		if p.StartTime.After(cutoff) {
+			kept = append(kept, p)
+		}
+	}
+	changed := len(kept) != len(points)
+
+	for _, b := range backups {
+		if seen[b.Label] || b.StartTime == nil || b.StopTime == nil ||
+			!b.StartTime.After(cutoff) || (b.Error != nil && *b.Error) {
+			continue
+		}
+		kept = append(kept, trendPoint(b))
+		changed = true
+	}
+	points = kept
+
+	if !changed {
+		return nil
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].StartTime.Before(points[j].StartTime) })
+	h.targets[target] = points
+	return h.save()
+}
+
+// Points returns the recorded backups of target, oldest first. An empty
+// backupType returns every type.
+func (h *History) Points(target, backupType string) []models.BackupTrendPoint {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := []models.BackupTrendPoint{}
+	for _, p := range h.targets[target] {
+		if backupType == "" || p.Type == backupType {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// save writes the history through a temporary file so a crash never
+// leaves a truncated file behind. The caller holds the lock.
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(h.targets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// trendPoint converts a completed backup into a history point.
+func trendPoint(b models.BackupInfo) models.BackupTrendPoint {
+	p := models.BackupTrendPoint{
+		Label:               b.Label,
+		Type:                b.Type,
+		StartTime:           b.StartTime.UTC(),
+		StopTime:            b.StopTime.UTC(),
+		DurationSeconds:     b.StopTime.Sub(*b.StartTime).Seconds(),
+		SizeBytes:           b.SizeBytes,
+		RepositorySizeBytes: b.DatabaseSizeBytes,
+	}
+	if b.SizeBytes != nil && b.DatabaseSizeBytes != nil && *b.DatabaseSizeBytes > 0 {
+		ratio := float64(*b.SizeBytes) / float64(*b.DatabaseSizeBytes)
+		p.CompressionRatio = &ratio
+	}
+	return p
+}
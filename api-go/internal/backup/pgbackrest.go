@@ -0,0 +1,607 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// backupLabelPattern matches pgBackRest backup labels, e.g.
+// 20240101-120000F or 20240101-120000F_20240102-120000D.
+var backupLabelPattern = regexp.MustCompile(`^\d{8}-\d{6}F(_\d{8}-\d{6}[DI])?$`)
+
+// pgBackRestInfo represents the JSON output from pgbackrest info.
+type pgBackRestInfo struct {
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+	Backup []struct {
+		Label     string   `json:"label"`
+		Type      string   `json:"type"`
+		Prior     *string  `json:"prior"`
+		Reference []string `json:"reference"`
+		Error     *bool    `json:"error"`
+		Timestamp struct {
+			Start int64 `json:"start"`
+			Stop  int64 `json:"stop"`
+		} `json:"timestamp"`
+		Archive struct {
+			Start string `json:"start"`
+			Stop  string `json:"stop"`
+		} `json:"archive"`
+		LSN struct {
+			Start string `json:"start"`
+			Stop  string `json:"stop"`
+		} `json:"lsn"`
+		Backrest struct {
+			Version string `json:"version"`
+		} `json:"backrest"`
+		Annotation map[string]string `json:"annotation"`
+		Database   struct {
+			RepoKey int `json:"repo-key"`
+		} `json:"database"`
+		Info struct {
+			Size       int64 `json:"size"`
+			Delta      int64 `json:"delta"`
+			Repository struct {
+				Size  int64 `json:"size"`
+				Delta int64 `json:"delta"`
+			} `json:"repository"`
+		} `json:"info"`
+	} `json:"backup"`
+	Archive []struct {
+		Min string `json:"min"`
+		Max string `json:"max"`
+	} `json:"archive"`
+	Repo []struct {
+		Key    int    `json:"key"`
+		Cipher string `json:"cipher"`
+		Status struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"repo"`
+}
+
+// PgBackRest is the physical backup provider backed by pgbackrest.
+type PgBackRest struct {
+	cfg config.BackupConfig
+}
+
+// Name returns the provider name.
+func (p *PgBackRest) Name() string { return ProviderPgBackRest }
+
+// Targets returns the configured stanzas.
+func (p *PgBackRest) Targets() []string { return p.cfg.StanzaList() }
+
+// BackupTypes returns the pgbackrest backup types.
+func (p *PgBackRest) BackupTypes() []string { return []string{"full", "diff", "incr"} }
+
+// RequiresStoppedCluster is true; pgbackrest restores into the data
+// directory.
+func (p *PgBackRest) RequiresStoppedCluster() bool { return true }
+
+// Backup runs pgbackrest backup of the given type.
+func (p *PgBackRest) Backup(ctx context.Context, stanza, backupType string, out io.Writer) error {
+	args := append(repoArgs(p.cfg), "--stanza="+stanza, "--type="+backupType, "backup")
+	if err := (Command{Argv: Argv(p.cfg, args...)}).Run(ctx, out); err != nil {
+		return fmt.Errorf("pgbackrest backup failed: %w", err)
+	}
+	return nil
+}
+
+// StanzaCreate runs pgbackrest stanza-create. It succeeds without changes
+// when the stanza already exists and matches the cluster.
+func (p *PgBackRest) StanzaCreate(ctx context.Context, stanza string, out io.Writer) error {
+	return p.stanzaCommand(ctx, stanza, "stanza-create", out)
+}
+
+// StanzaUpgrade runs pgbackrest stanza-upgrade.
+func (p *PgBackRest) StanzaUpgrade(ctx context.Context, stanza string, out io.Writer) error {
+	return p.stanzaCommand(ctx, stanza, "stanza-upgrade", out)
+}
+
+// stanzaCommand runs a stanza command on all repositories; unlike backup
+// and restore it does not accept --repo.
+func (p *PgBackRest) stanzaCommand(ctx context.Context, stanza, command string, out io.Writer) error {
+	if err := (Command{Argv: Argv(p.cfg, "--stanza="+stanza, command)}).Run(ctx, out); err != nil {
+		return fmt.Errorf("pgbackrest %s failed: %w", command, err)
+	}
+	return nil
+}
+
+// RestoreCommand validates req and returns the pgbackrest restore command.
+func (p *PgBackRest) RestoreCommand(stanza string, req models.RestoreRequest) (Command, error) {
+	args, err := restoreArgs(stanza, req)
+	if err != nil {
+		return Command{}, err
+	}
+	return Command{Argv: Argv(p.cfg, append(repoArgs(p.cfg), args...)...)}, nil
+}
+
+// Argv returns the full command line for running pgbackrest with
+// args, adding the configured binary, config paths, repository host
+// options and extra arguments, and wrapping the command in ssh when a
+// remote host is configured.
+//
+// The TLS server protocol is private to pgbackrest, so even with a TLS repo
+// host the local binary acts as the client; only the repository access
+// moves off SSH.
+func Argv(cfg config.BackupConfig, args ...string) []string {
+	binary := cfg.Binary
+	if binary == "" {
+		binary = "pgbackrest"
+	}
+	argv := []string{binary}
+	if cfg.ConfigFile != "" {
+		argv = append(argv, "--config="+cfg.ConfigFile)
+	}
+	if cfg.ConfigIncludePath != "" {
+		argv = append(argv, "--config-include-path="+cfg.ConfigIncludePath)
+	}
+	if cfg.RepoHost != "" {
+		argv = append(argv, "--repo1-host="+cfg.RepoHost)
+		if cfg.RepoHostUser != "" {
+			argv = append(argv, "--repo1-host-user="+cfg.RepoHostUser)
+		}
+		if cfg.RepoHostType != "" {
+			argv = append(argv, "--repo1-host-type="+cfg.RepoHostType)
+		}
+		if cfg.RepoHostPort > 0 {
+			argv = append(argv, "--repo1-host-port="+strconv.Itoa(cfg.RepoHostPort))
+		}
+		if cfg.RepoHostType == "tls" {
+			argv = append(argv,
+				"--repo1-host-cert-file="+cfg.RepoHostCertFile,
+				"--repo1-host-key-file="+cfg.RepoHostKeyFile,
+			)
+			if cfg.RepoHostCAFile != "" {
+				argv = append(argv, "--repo1-host-ca-file="+cfg.RepoHostCAFile)
+			}
+		}
+	}
+	for _, arg := range cfg.ExtraArgs {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			argv = append(argv, arg)
+		}
+	}
+	argv = append(argv, args...)
+
+	return remoteArgv(cfg, argv)
+}
+
+// Info runs pgbackrest info for the stanza and maps the result to a
+// BackupResponse. Failures are reported through the Status field.
+func (p *PgBackRest) Info(ctx context.Context, stanza string) models.BackupResponse {
+	cfg := p.cfg
+
+	// Run pgbackrest info command
+	args := append(repoArgs(cfg), "--stanza", stanza, "info", "--output=json")
+	cmd := Command{Argv: Argv(cfg, args...)}
+	output, err := cmd.Output(ctx)
+
+	if err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			// pgBackRest (or ssh in remote mode) not installed
+			return models.BackupResponse{
+				Provider:      ProviderPgBackRest,
+				Stanza:        stanza,
+				Status:        "not_installed",
+				StatusMessage: strPtr(filepath.Base(cmd.Argv[0]) + " is not installed on this system"),
+				Backups:       []models.BackupInfo{},
+				Timestamp:     time.Now().UTC(),
+			}
+		}
+
+		// Other error
+		return models.BackupResponse{
+			Provider:      ProviderPgBackRest,
+			Stanza:        stanza,
+			Status:        "unavailable",
+			StatusMessage: strPtr("pgBackRest error: " + err.Error()),
+			Backups:       []models.BackupInfo{},
+			Timestamp:     time.Now().UTC(),
+		}
+	}
+
+	// Parse JSON output
+	var infos []pgBackRestInfo
+	if err := json.Unmarshal(output, &infos); err != nil {
+		return models.BackupResponse{
+			Provider:      ProviderPgBackRest,
+			Stanza:        stanza,
+			Status:        "parse_error",
+			StatusMessage: strPtr("Failed to parse pgBackRest output: " + err.Error()),
+			Backups:       []models.BackupInfo{},
+			Timestamp:     time.Now().UTC(),
+		}
+	}
+
+	if len(infos) == 0 {
+		return models.BackupResponse{
+			Provider:      ProviderPgBackRest,
+			Stanza:        stanza,
+			Status:        "no_stanza",
+			StatusMessage: strPtr("No stanza information available"),
+			Backups:       []models.BackupInfo{},
+			Timestamp:     time.Now().UTC(),
+		}
+	}
+
+	info := infos[0]
+
+	// Map status code to string
+	var status string
+	switch info.Status.Code {
+	case 0:
+		status = "ok"
+	case 1:
+		status = "missing_stanza"
+	case 2:
+		status = "no_backup"
+	default:
+		status = "error"
+	}
+
+	// Parse backups
+	backups := make([]models.BackupInfo, 0, len(info.Backup))
+	var lastFull, lastDiff, lastIncr *time.Time
+
+	for _, b := range info.Backup {
+		backup := models.BackupInfo{
+			Label:      b.Label,
+			Type:       b.Type,
+			Prior:      b.Prior,
+			Reference:  b.Reference,
+			Error:      b.Error,
+			Annotation: b.Annotation,
+		}
+		if b.Database.RepoKey > 0 {
+			repoKey := b.Database.RepoKey
+			backup.RepoKey = &repoKey
+		}
+		if b.LSN.Start != "" {
+			backup.StartLSN = strPtr(b.LSN.Start)
+		}
+		if b.LSN.Stop != "" {
+			backup.StopLSN = strPtr(b.LSN.Stop)
+		}
+		if b.Archive.Start != "" {
+			backup.StartWAL = strPtr(b.Archive.Start)
+		}
+		// The timeline is the first eight hex digits of the WAL file name
+		if len(b.Archive.Start) >= 8 {
+			if tli, err := strconv.ParseUint(b.Archive.Start[:8], 16, 32); err == nil {
+				timeline := uint32(tli)
+				backup.Timeline = &timeline
+			}
+		}
+		if b.Archive.Stop != "" {
+			backup.StopWAL = strPtr(b.Archive.Stop)
+		}
+		if b.Backrest.Version != "" {
+			backup.PgBackRestVersion = strPtr(b.Backrest.Version)
+		}
+
+		if b.Timestamp.Start > 0 {
+			t := time.Unix(b.Timestamp.Start, 0).UTC()
+			backup.StartTime = &t
+		}
+		if b.Timestamp.Stop > 0 {
+			t := time.Unix(b.Timestamp.Stop, 0).UTC()
+			backup.StopTime = &t
+
+			// Track latest by type
+			if b.Type == "full" {
+				if lastFull == nil || t.After(*lastFull) {
+					lastFull = &t
+				}
+			} else if b.Type == "diff" {
+				if lastDiff == nil || t.After(*lastDiff) {
+					lastDiff = &t
+				}
+			} else if b.Type == "incr" {
+				if lastIncr == nil || t.After(*lastIncr) {
+					lastIncr = &t
+				}
+			}
+		}
+		// Copy the sizes; b is reused across iterations
+		if size := b.Info.Size; size > 0 {
+			backup.SizeBytes = &size
+		}
+		if size := b.Info.Repository.Size; size > 0 {
+			backup.DatabaseSizeBytes = &size
+		}
+		if delta := b.Info.Delta; delta > 0 {
+			backup.DeltaBytes = &delta
+		}
+		if delta := b.Info.Repository.Delta; delta > 0 {
+			backup.RepositoryDeltaBytes = &delta
+		}
+
+		backups = append(backups, backup)
+	}
+
+	// Parse WAL archive info
+	var walArchive *models.WALArchiveInfo
+	if len(info.Archive) > 0 {
+		walArchive = &models.WALArchiveInfo{}
+		if info.Archive[0].Min != "" {
+			walArchive.MinWAL = &info.Archive[0].Min
+		}
+		if info.Archive[0].Max != "" {
+			walArchive.MaxWAL = &info.Archive[0].Max
+		}
+	}
+
+	var statusMessage *string
+	if status != "ok" {
+		statusMessage = &info.Status.Message
+	}
+
+	// Parse repositories; pgbackrest info does not report the storage
+	// type or compression, so they are taken from the local
+	// configuration when available.
+	opts, known := pgBackRestOptions(cfg, stanza)
+	types := repoTypes(opts, known)
+	repositories := make([]models.RepositoryInfo, 0, len(info.Repo))
+	for _, r := range info.Repo {
+		repo := models.RepositoryInfo{
+			Key:        r.Key,
+			Cipher:     r.Cipher,
+			Encrypted:  r.Cipher != "" && r.Cipher != "none",
+			Status:     repoStatusName(r.Status.Code),
+			StatusCode: r.Status.Code,
+		}
+		applyRepoOptions(&repo, opts, known)
+		if r.Status.Code != 0 {
+			repo.StatusMessage = strPtr(r.Status.Message)
+		}
+		if t, ok := types[r.Key]; ok {
+			repo.Type = strPtr(t)
+		}
+		repositories = append(repositories, repo)
+	}
+
+	return models.BackupResponse{
+		Provider:       ProviderPgBackRest,
+		Stanza:         stanza,
+		Status:         status,
+		StatusMessage:  statusMessage,
+		Repositories:   repositories,
+		Backups:        backups,
+		WALArchive:     walArchive,
+		LastFullBackup: lastFull,
+		LastDiffBackup: lastDiff,
+		LastIncrBackup: lastIncr,
+		Timestamp:      time.Now().UTC(),
+	}
+}
+
+// repoStatusName maps a pgbackrest repository status code to a name.
+func repoStatusName(code int) string {
+	switch code {
+	case 0:
+		return "ok"
+	case 1:
+		return "missing_stanza_path"
+	case 2:
+		return "no_backup"
+	case 3:
+		return "missing_stanza_data"
+	case 4:
+		return "cipher_mismatch"
+	case 5:
+		return "database_mismatch"
+	default:
+		return "error"
+	}
+}
+
+// defaultCompressLevel is pgbackrest's default level per compress-type.
+var defaultCompressLevel = map[string]int{"gz": 6, "bz2": 9, "lz4": 1, "zst": 3}
+
+// pgBackRestOptions returns the options that apply to backups of stanza,
+// keyed by option name such as repo1-type. They are read from the
+// configuration file and the *.conf files of the include path, then the
+// PGBACKREST_* environment variables and finally the extra arguments,
+// each overriding the last. Within a file, stanza sections override
+// global ones and :backup sections override plain ones. known reports
+// whether any configuration file could be read.
+func pgBackRestOptions(cfg config.BackupConfig, stanza string) (opts map[string]string, known bool) {
+	opts = make(map[string]string)
+
+	files := []string{cfg.ConfigFile}
+	if cfg.ConfigIncludePath != "" {
+		if matches, err := filepath.Glob(filepath.Join(cfg.ConfigIncludePath, "*.conf")); err == nil {
+			files = append(files, matches...)
+		}
+	}
+	sections := []string{"global", "global:backup", stanza, stanza + ":backup"}
+	bySection := make([]map[string]string, len(sections))
+	for i := range bySection {
+		bySection[i] = make(map[string]string)
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		known = true
+		current := -1
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+				current = -1
+				for i, name := range sections {
+					if line[1:len(line)-1] == name {
+						current = i
+					}
+				}
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || current < 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+			bySection[current][strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		f.Close()
+	}
+	for _, section := range bySection {
+		for k, v := range section {
+			opts[k] = v
+		}
+	}
+
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if name, ok := strings.CutPrefix(key, "PGBACKREST_"); ok {
+			opts[strings.ReplaceAll(strings.ToLower(name), "_", "-")] = value
+		}
+	}
+
+	for _, arg := range cfg.ExtraArgs {
+		key, value, ok := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !ok {
+			// Negated flags such as --no-repo1-bundle
+			if name, neg := strings.CutPrefix(key, "no-"); neg {
+				key, value = name, "n"
+			} else {
+				value = "y"
+			}
+		}
+		opts[key] = value
+	}
+	return opts, known
+}
+
+// repoTypes returns the storage type of each repository from opts.
+// Repositories without an explicit type use pgbackrest's default, posix,
+// but only when the configuration could be read or a type was set;
+// otherwise nothing is known and the map is empty.
+func repoTypes(opts map[string]string, known bool) map[int]string {
+	types := make(map[int]string)
+	for key, value := range opts {
+		if n, ok := repoTypeKey(key, "repo", "-type"); ok {
+			types[n] = value
+			known = true
+		}
+	}
+
+	if known {
+		for n := 1; n <= 4; n++ {
+			if _, ok := types[n]; !ok {
+				types[n] = "posix"
+			}
+		}
+	}
+	return types
+}
+
+// repoTypeKey extracts n from keys such as repo2-type.
+func repoTypeKey(key, prefix, suffix string) (int, bool) {
+	if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(key[len(prefix) : len(key)-len(suffix)])
+	return n, err == nil && n > 0
+}
+
+// applyRepoOptions fills in the compression, block incremental and
+// bundling settings of repo from opts. pgbackrest defaults are only
+// assumed when the configuration could be read.
+func applyRepoOptions(repo *models.RepositoryInfo, opts map[string]string, known bool) {
+	compressType, ok := opts["compress-type"]
+	if !ok && known {
+		compressType, ok = "gz", true
+	}
+	if ok {
+		repo.CompressType = strPtr(compressType)
+		if level, err := strconv.Atoi(opts["compress-level"]); err == nil {
+			repo.CompressLevel = &level
+		} else if level, ok := defaultCompressLevel[compressType]; ok {
+			repo.CompressLevel = &level
+		}
+	}
+
+	prefix := "repo" + strconv.Itoa(repo.Key) + "-"
+	flag := func(name string) *bool {
+		value, ok := opts[prefix+name]
+		if !ok && !known {
+			return nil
+		}
+		enabled := value == "y"
+		return &enabled
+	}
+	repo.BlockIncremental = flag("block")
+	repo.Bundle = flag("bundle")
+}
+
+// repoArgs returns the --repo option when a single repository is
+// selected. It is only valid for info, backup and restore.
+func repoArgs(cfg config.BackupConfig) []string {
+	if cfg.Repo > 0 {
+		return []string{"--repo=" + strconv.Itoa(cfg.Repo)}
+	}
+	return nil
+}
+
+// restoreArgs validates the request and builds the pgbackrest arguments.
+func restoreArgs(stanza string, req models.RestoreRequest) ([]string, error) {
+	args := []string{"--stanza=" + stanza}
+
+	action := req.TargetAction
+	if action == "" {
+		action = "promote"
+	}
+
+	switch req.Target {
+	case models.RestoreTargetLatest:
+	case models.RestoreTargetBackup:
+		if !backupLabelPattern.MatchString(req.BackupLabel) {
+			return nil, fmt.Errorf("invalid backup_label %q", req.BackupLabel)
+		}
+		args = append(args, "--set="+req.BackupLabel, "--type=immediate", "--target-action="+action)
+	case models.RestoreTargetTime:
+		if req.Timestamp == nil {
+			return nil, errors.New("timestamp is required for target 'time'")
+		}
+		if req.Timestamp.After(time.Now()) {
+			return nil, errors.New("timestamp is in the future")
+		}
+		target := req.Timestamp.UTC().Format("2006-01-02 15:04:05.000000+00")
+		args = append(args, "--type=time", "--target="+target, "--target-action="+action)
+	case models.RestoreTargetLSN:
+		lsn, err := wal.ParseLSN(req.LSN)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--type=lsn", "--target="+lsn.String(), "--target-action="+action)
+	default:
+		return nil, fmt.Errorf("unknown target %q", req.Target)
+	}
+
+	if req.Delta {
+		args = append(args, "--delta")
+	}
+
+	return append(args, "restore"), nil
+}
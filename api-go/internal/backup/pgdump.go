@@ -0,0 +1,218 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// dumpLabelFormat names dump files after their start time in UTC.
+const dumpLabelFormat = "20060102T150405Z"
+
+// dumpLabelPattern matches dump labels such as 20240101T120000Z.
+var dumpLabelPattern = regexp.MustCompile(`^\d{8}T\d{6}Z$`)
+
+// PgDump is the logical backup provider backed by pg_dump and pg_restore.
+// Each database is dumped in custom format to its own directory.
+type PgDump struct {
+	cfg config.BackupConfig
+	db  config.DatabaseConfig
+}
+
+// Name returns the provider name.
+func (p *PgDump) Name() string { return ProviderPgDump }
+
+// Targets returns the databases to dump, defaulting to the application
+// database.
+func (p *PgDump) Targets() []string {
+	list := []string{}
+	for _, d := range p.cfg.DumpDatabases {
+		if d = strings.TrimSpace(d); d != "" {
+			list = append(list, d)
+		}
+	}
+	if len(list) == 0 {
+		list = append(list, p.db.Name)
+	}
+	return list
+}
+
+// BackupTypes returns "full"; dumps are always complete.
+func (p *PgDump) BackupTypes() []string { return []string{"full"} }
+
+// RequiresStoppedCluster is false; pg_restore needs a running server.
+func (p *PgDump) RequiresStoppedCluster() bool { return false }
+
+// dir returns the dump directory of database.
+func (p *PgDump) dir(database string) string {
+	return filepath.Join(p.cfg.DumpDir, database)
+}
+
+// connArgs returns the connection flags shared by pg_dump and pg_restore.
+// The password is passed through the environment.
+func (p *PgDump) connArgs(database string) ([]string, []string) {
+	args := []string{
+		"--host=" + p.db.Host,
+		"--port=" + strconv.Itoa(p.db.Port),
+		"--username=" + p.db.User,
+		"--dbname=" + database,
+	}
+	return args, []string{"PGPASSWORD=" + p.db.Password}
+}
+
+// Info lists the dumps of database.
+func (p *PgDump) Info(ctx context.Context, database string) models.BackupResponse {
+	response := models.BackupResponse{
+		Provider:  ProviderPgDump,
+		Stanza:    database,
+		Backups:   []models.BackupInfo{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	if _, err := exec.LookPath("pg_dump"); err != nil {
+		response.Status = "not_installed"
+		response.StatusMessage = strPtr("pg_dump is not installed on this system")
+		return response
+	}
+
+	dumps, err := p.dumps(database)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		response.Status = "unavailable"
+		response.StatusMessage = strPtr("Failed to list dumps: " + err.Error())
+		return response
+	}
+	if len(dumps) == 0 {
+		response.Status = "no_backup"
+		response.StatusMessage = strPtr("No dumps found in " + p.dir(database))
+		return response
+	}
+
+	response.Status = "ok"
+	for _, d := range dumps {
+		response.Backups = append(response.Backups, d)
+		if d.StopTime != nil {
+			response.LastFullBackup = d.StopTime
+		}
+	}
+	return response
+}
+
+// dumps returns the completed dumps of database, oldest first.
+func (p *PgDump) dumps(database string) ([]models.BackupInfo, error) {
+	entries, err := os.ReadDir(p.dir(database))
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []models.BackupInfo
+	for _, e := range entries {
+		label, ok := strings.CutSuffix(e.Name(), ".dump")
+		if !ok || e.IsDir() || !dumpLabelPattern.MatchString(label) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		d := models.BackupInfo{Label: label, Type: "full"}
+		if start, err := time.Parse(dumpLabelFormat, label); err == nil {
+			d.StartTime = &start
+		}
+		stop := fi.ModTime().UTC()
+		size := fi.Size()
+		d.StopTime = &stop
+		d.SizeBytes = &size
+		dumps = append(dumps, d)
+	}
+
+	// Labels sort chronologically
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].Label < dumps[j].Label })
+	return dumps, nil
+}
+
+// Backup dumps database in custom format and prunes old dumps. The dump is
+// written under a temporary name and renamed once complete.
+func (p *PgDump) Backup(ctx context.Context, database, backupType string, out io.Writer) error {
+	if backupType != "full" {
+		return fmt.Errorf("pg_dump does not support %s backups", backupType)
+	}
+
+	dir := p.dir(database)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	label := time.Now().UTC().Format(dumpLabelFormat)
+	final := filepath.Join(dir, label+".dump")
+	partial := final + ".partial"
+
+	conn, env := p.connArgs(database)
+	argv := append([]string{"pg_dump", "--format=custom", "--file=" + partial}, conn...)
+	if err := (Command{Argv: argv, Env: env}).Run(ctx, out); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("pg_dump failed: %w", err)
+	}
+	if err := os.Rename(partial, final); err != nil {
+		return err
+	}
+
+	p.prune(database)
+	return nil
+}
+
+// prune removes all but the newest DumpRetain dumps of database.
+func (p *PgDump) prune(database string) {
+	if p.cfg.DumpRetain <= 0 {
+		return
+	}
+	dumps, err := p.dumps(database)
+	if err != nil || len(dumps) <= p.cfg.DumpRetain {
+		return
+	}
+	for _, d := range dumps[:len(dumps)-p.cfg.DumpRetain] {
+		os.Remove(filepath.Join(p.dir(database), d.Label+".dump"))
+	}
+}
+
+// RestoreCommand returns the pg_restore command for the newest dump or a
+// specific label. Point-in-time targets need WAL and are not supported.
+func (p *PgDump) RestoreCommand(database string, req models.RestoreRequest) (Command, error) {
+	if req.Delta {
+		return Command{}, errors.New("delta restore requires the pgbackrest provider")
+	}
+
+	var label string
+	switch req.Target {
+	case models.RestoreTargetLatest:
+		dumps, err := p.dumps(database)
+		if err != nil || len(dumps) == 0 {
+			return Command{}, fmt.Errorf("no dumps found for database %s", database)
+		}
+		label = dumps[len(dumps)-1].Label
+	case models.RestoreTargetBackup:
+		if !dumpLabelPattern.MatchString(req.BackupLabel) {
+			return Command{}, fmt.Errorf("invalid backup_label %q", req.BackupLabel)
+		}
+		label = req.BackupLabel
+	default:
+		return Command{}, fmt.Errorf("target %q requires the pgbackrest provider", req.Target)
+	}
+
+	conn, env := p.connArgs(database)
+	argv := append([]string{"pg_restore", "--clean", "--if-exists", "--no-owner"}, conn...)
+	argv = append(argv, filepath.Join(p.dir(database), label+".dump"))
+	return Command{Argv: argv, Env: env}, nil
+}
@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// PlanRestore works out which backup set and archived WAL a restore of req
+// would use, mirroring pgbackrest's backup selection: the newest backup for
+// latest, the named set for backup, and the newest backup that stopped
+// before the target for time and lsn. status must list the backups oldest
+// first. segmentSize is the cluster's wal_segment_size.
+func PlanRestore(status models.BackupResponse, req models.RestoreRequest, segmentSize int64) models.RestorePlan {
+	plan := models.RestorePlan{BackupChain: []string{}, Warnings: []string{}}
+
+	backup, warning := selectBackup(status.Backups, req)
+	if backup == nil {
+		plan.Warnings = append(plan.Warnings, warning)
+		return plan
+	}
+	plan.Backup = backup
+	plan.Feasible = true
+	if backup.Error != nil && *backup.Error {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("backup %s has page checksum errors", backup.Label))
+	}
+
+	// Incremental and differential backups need every backup they reference.
+	seen := map[string]bool{}
+	for _, label := range append(append([]string{}, backup.Reference...), backup.Label) {
+		if !seen[label] {
+			seen[label] = true
+			plan.BackupChain = append(plan.BackupChain, label)
+		}
+	}
+
+	if backup.StartWAL == nil {
+		// Logical dumps and backups without archive information.
+		return plan
+	}
+	plan.WALStart = backup.StartWAL
+	plan.BackupTimeline = backup.Timeline
+	plan.RecoveryTargetTimeline = "latest"
+
+	var archiveMin, archiveMax *wal.Segment
+	if status.WALArchive != nil {
+		if status.WALArchive.MinWAL != nil {
+			if seg, err := wal.ParseFileName(*status.WALArchive.MinWAL, segmentSize); err == nil {
+				archiveMin = &seg
+			}
+		}
+		if status.WALArchive.MaxWAL != nil {
+			if seg, err := wal.ParseFileName(*status.WALArchive.MaxWAL, segmentSize); err == nil {
+				archiveMax = &seg
+				timeline := seg.Timeline
+				plan.ArchiveTimeline = &timeline
+			}
+		}
+	}
+
+	start, err := wal.ParseFileName(*backup.StartWAL, segmentSize)
+	if err != nil {
+		return plan
+	}
+	if archiveMin != nil && archiveMin.Number > start.Number {
+		plan.Feasible = false
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"WAL from %s needed by backup %s has expired from the archive (oldest is %s)",
+			*backup.StartWAL, backup.Label, *status.WALArchive.MinWAL))
+	}
+
+	switch req.Target {
+	case models.RestoreTargetBackup:
+		// Recovery stops as soon as the backup is consistent.
+		plan.WALStop = backup.StopWAL
+		return plan
+	case models.RestoreTargetLSN:
+		lsn, err := wal.ParseLSN(req.LSN)
+		if err != nil {
+			return plan
+		}
+		timeline := start.Timeline
+		if archiveMax != nil {
+			timeline = archiveMax.Timeline
+		}
+		stop := wal.SegmentOf(lsn, timeline, segmentSize).FileName(segmentSize)
+		plan.WALStop = &stop
+		if archiveMax != nil && lsn >= archiveMax.EndLSN(segmentSize) {
+			plan.Feasible = false
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"target LSN %s is beyond the newest archived WAL %s", lsn, *status.WALArchive.MaxWAL))
+		}
+	default:
+		if status.WALArchive != nil {
+			plan.WALStop = status.WALArchive.MaxWAL
+		}
+	}
+
+	if archiveMax != nil && backup.Timeline != nil && archiveMax.Timeline != *backup.Timeline {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"the archive continues on timeline %d after the backup's timeline %d; recovery follows the latest timeline, so targets on timeline %d after the switch cannot be reached",
+			archiveMax.Timeline, *backup.Timeline, *backup.Timeline))
+	}
+	return plan
+}
+
+// selectBackup returns the backup pgbackrest would restore from, or a
+// reason why none qualifies.
+func selectBackup(backups []models.BackupInfo, req models.RestoreRequest) (*models.BackupInfo, string) {
+	if len(backups) == 0 {
+		return nil, "the repository holds no backups"
+	}
+
+	switch req.Target {
+	case models.RestoreTargetBackup:
+		for i := range backups {
+			if backups[i].Label == req.BackupLabel {
+				return &backups[i], ""
+			}
+		}
+		return nil, fmt.Sprintf("backup %q not found", req.BackupLabel)
+	case models.RestoreTargetTime:
+		for i := len(backups) - 1; i >= 0; i-- {
+			if stop := backups[i].StopTime; stop != nil && req.Timestamp != nil && !stop.After(*req.Timestamp) {
+				return &backups[i], ""
+			}
+		}
+		return nil, "no backup completed before the target time"
+	case models.RestoreTargetLSN:
+		target, err := wal.ParseLSN(req.LSN)
+		if err != nil {
+			return nil, err.Error()
+		}
+		for i := len(backups) - 1; i >= 0; i-- {
+			if backups[i].StopLSN == nil {
+				continue
+			}
+			if stop, err := wal.ParseLSN(*backups[i].StopLSN); err == nil && stop <= target {
+				return &backups[i], ""
+			}
+		}
+		return nil, "no backup completed before the target LSN"
+	}
+	return &backups[len(backups)-1], ""
+}
@@ -0,0 +1,247 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// WALGTarget is the single target of the WAL-G provider; WAL-G keeps one
+// cluster per storage prefix and has no stanza concept.
+const WALGTarget = "default"
+
+// walgBackupNamePattern matches WAL-G backup names such as
+// base_000000010000000000000002 and delta backups with a _D_ suffix.
+var walgBackupNamePattern = regexp.MustCompile(`^base_[0-9A-F]{24}(_D_[0-9A-F]{24})?$`)
+
+// walgBackup is one entry of `wal-g backup-list --json --detail`.
+type walgBackup struct {
+	BackupName       string    `json:"backup_name"`
+	WALFileName      string    `json:"wal_file_name"`
+	StartTime        time.Time `json:"start_time"`
+	FinishTime       time.Time `json:"finish_time"`
+	StartLSN         uint64    `json:"start_lsn"`
+	FinishLSN        uint64    `json:"finish_lsn"`
+	IsPermanent      bool      `json:"is_permanent"`
+	UncompressedSize int64     `json:"uncompressed_size"`
+	CompressedSize   int64     `json:"compressed_size"`
+}
+
+// walgTimeline is one entry of `wal-g wal-show --detailed-json`.
+type walgTimeline struct {
+	ID              uint32   `json:"id"`
+	StartSegment    string   `json:"start_segment"`
+	EndSegment      string   `json:"end_segment"`
+	MissingSegments []string `json:"missing_segments"`
+	Status          string   `json:"status"`
+}
+
+// WALG is the physical backup provider backed by WAL-G. Storage and
+// credentials come from WAL-G's own environment or config file.
+type WALG struct {
+	cfg config.BackupConfig
+}
+
+// Name returns the provider name.
+func (p *WALG) Name() string { return ProviderWALG }
+
+// Targets returns the single WAL-G target.
+func (p *WALG) Targets() []string { return []string{WALGTarget} }
+
+// BackupTypes returns full and incr; an incr backup is a WAL-G delta
+// backup when WALG_DELTA_MAX_STEPS allows it.
+func (p *WALG) BackupTypes() []string { return []string{"full", "incr"} }
+
+// RequiresStoppedCluster is true; backup-fetch writes the data directory.
+func (p *WALG) RequiresStoppedCluster() bool { return true }
+
+// argv returns the wal-g command line for args.
+func (p *WALG) argv(args ...string) []string {
+	return remoteArgv(p.cfg, append([]string{"wal-g"}, args...))
+}
+
+// Backup runs wal-g backup-push on the data directory.
+func (p *WALG) Backup(ctx context.Context, _ string, backupType string, out io.Writer) error {
+	args := []string{"backup-push"}
+	switch backupType {
+	case "full":
+		args = append(args, "--full")
+	case "incr":
+	default:
+		return fmt.Errorf("wal-g does not support %s backups", backupType)
+	}
+	args = append(args, p.cfg.DataDir)
+
+	if err := (Command{Argv: p.argv(args...)}).Run(ctx, out); err != nil {
+		return fmt.Errorf("wal-g backup-push failed: %w", err)
+	}
+	return nil
+}
+
+// Info lists the backups with wal-g backup-list and the archived WAL
+// range with wal-g wal-show.
+func (p *WALG) Info(ctx context.Context, target string) models.BackupResponse {
+	response := models.BackupResponse{
+		Provider:  ProviderWALG,
+		Stanza:    target,
+		Backups:   []models.BackupInfo{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	cmd := Command{Argv: p.argv("backup-list", "--json", "--detail")}
+	output, err := cmd.Output(ctx)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			response.Status = "not_installed"
+			response.StatusMessage = strPtr(filepath.Base(cmd.Argv[0]) + " is not installed on this system")
+		} else {
+			response.Status = "unavailable"
+			response.StatusMessage = strPtr("WAL-G error: " + err.Error())
+		}
+		return response
+	}
+
+	// backup-list prints a plain message instead of JSON when the storage
+	// holds no backups yet.
+	var listed []walgBackup
+	if trimmed := strings.TrimSpace(string(output)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(output, &listed); err != nil {
+			response.Status = "parse_error"
+			response.StatusMessage = strPtr("Failed to parse WAL-G output: " + err.Error())
+			return response
+		}
+	}
+
+	sort.Slice(listed, func(i, j int) bool { return listed[i].StartTime.Before(listed[j].StartTime) })
+	for _, b := range listed {
+		response.Backups = append(response.Backups, walgBackupInfo(b))
+		if stop := response.Backups[len(response.Backups)-1].StopTime; stop != nil {
+			if strings.Contains(b.BackupName, "_D_") {
+				response.LastIncrBackup = stop
+			} else {
+				response.LastFullBackup = stop
+			}
+		}
+	}
+
+	if len(response.Backups) == 0 {
+		response.Status = "no_backup"
+		response.StatusMessage = strPtr("No backups in WAL-G storage")
+	} else {
+		response.Status = "ok"
+	}
+
+	response.WALArchive = p.walArchive(ctx)
+	return response
+}
+
+// walgBackupInfo maps a backup-list entry to a BackupInfo. Delta backups
+// are reported as incr and reference the backup they are based on.
+func walgBackupInfo(b walgBackup) models.BackupInfo {
+	info := models.BackupInfo{Label: b.BackupName, Type: "full"}
+	// Delta names end in _D_ and the WAL file of the backup they build on.
+	if _, from, ok := strings.Cut(b.BackupName, "_D_"); ok {
+		info.Type = "incr"
+		info.Prior = strPtr("base_" + from)
+	}
+	if b.WALFileName != "" {
+		info.StartWAL = strPtr(b.WALFileName)
+		if seg, err := wal.ParseFileName(b.WALFileName, wal.DefaultSegmentSize); err == nil {
+			timeline := seg.Timeline
+			info.Timeline = &timeline
+		}
+	}
+	if !b.StartTime.IsZero() {
+		start := b.StartTime.UTC()
+		info.StartTime = &start
+	}
+	if !b.FinishTime.IsZero() {
+		stop := b.FinishTime.UTC()
+		info.StopTime = &stop
+	}
+	if b.StartLSN > 0 {
+		info.StartLSN = strPtr(wal.LSN(b.StartLSN).String())
+	}
+	if b.FinishLSN > 0 {
+		info.StopLSN = strPtr(wal.LSN(b.FinishLSN).String())
+	}
+	if size := b.UncompressedSize; size > 0 {
+		info.SizeBytes = &size
+	}
+	if size := b.CompressedSize; size > 0 {
+		info.DatabaseSizeBytes = &size
+	}
+	if b.IsPermanent {
+		info.Annotation = map[string]string{"permanent": "true"}
+	}
+	return info
+}
+
+// walArchive returns the archived WAL range of the newest timeline from
+// wal-g wal-show, or nil when it cannot be read.
+func (p *WALG) walArchive(ctx context.Context) *models.WALArchiveInfo {
+	output, err := Command{Argv: p.argv("wal-show", "--detailed-json")}.Output(ctx)
+	if err != nil {
+		return nil
+	}
+
+	var timelines []walgTimeline
+	if err := json.Unmarshal(output, &timelines); err != nil || len(timelines) == 0 {
+		return nil
+	}
+
+	first, latest := timelines[0], timelines[0]
+	for _, t := range timelines {
+		if t.ID < first.ID {
+			first = t
+		}
+		if t.ID > latest.ID {
+			latest = t
+		}
+	}
+
+	archive := &models.WALArchiveInfo{}
+	if first.StartSegment != "" {
+		archive.MinWAL = strPtr(first.StartSegment)
+	}
+	if latest.EndSegment != "" {
+		archive.MaxWAL = strPtr(latest.EndSegment)
+	}
+	return archive
+}
+
+// RestoreCommand returns the wal-g backup-fetch command for the newest or
+// a named backup. WAL replay is driven by PostgreSQL's restore_command
+// (wal-g wal-fetch), so time and LSN targets are not supported here.
+func (p *WALG) RestoreCommand(_ string, req models.RestoreRequest) (Command, error) {
+	if req.Delta {
+		return Command{}, errors.New("delta restore requires the pgbackrest provider")
+	}
+
+	name := "LATEST"
+	switch req.Target {
+	case models.RestoreTargetLatest:
+	case models.RestoreTargetBackup:
+		if !walgBackupNamePattern.MatchString(req.BackupLabel) {
+			return Command{}, fmt.Errorf("invalid backup_label %q", req.BackupLabel)
+		}
+		name = req.BackupLabel
+	default:
+		return Command{}, fmt.Errorf("target %q is not supported by the wal-g provider", req.Target)
+	}
+
+	return Command{Argv: p.argv("backup-fetch", p.cfg.DataDir, name)}, nil
+}
@@ -0,0 +1,193 @@
+// Package catalog keeps a durable record of finished jobs in PostgreSQL,
+// so backup and restore history survives restarts and outlives the
+// in-memory job history. Entries are kept in memory while PostgreSQL is
+// down, so jobs that restore it are recorded too.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+)
+
+// Trigger sources.
+const (
+	TriggerAPI      = "api"
+	TriggerSchedule = "schedule"
+)
+
+// Entry is one finished job.
+type Entry struct {
+	ID              string
+	Type            string
+	Trigger         string
+	Status          string
+	Params          map[string]interface{}
+	Result          interface{}
+	Error           string
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	DurationSeconds *float64
+	LogFile         string
+}
+
+// Filter selects entries. Empty fields match everything.
+type Filter struct {
+	Type    string
+	Status  string
+	Trigger string
+	Stanza  string
+	Since   *time.Time
+	Until   *time.Time
+	Limit   int
+	Offset  int
+}
+
+// Catalog is the Store that keeps entries in the job_history table of the
+// primary and reads them from any reachable node.
+type Catalog struct {
+	cluster *db.Cluster
+}
+
+// New creates a catalog. The table is created by the schema migrations.
+func New(cluster *db.Cluster) *Catalog {
+	return &Catalog{cluster: cluster}
+}
+
+// Record inserts or replaces an entry. It fails while the primary is
+// unreachable.
+func (c *Catalog) Record(ctx context.Context, e Entry) error {
+	pool, err := c.cluster.Writer()
+	if err != nil {
+		return err
+	}
+
+	params, err := json.Marshal(e.Params)
+	if err != nil {
+		return err
+	}
+	var result []byte
+	if e.Result != nil {
+		if result, err = json.Marshal(e.Result); err != nil {
+			return err
+		}
+	}
+
+	_, err = pool.Exec(ctx, `
+		INSERT INTO job_history
+			(id, type, trigger, status, params, result, error,
+			 created_at, started_at, finished_at, duration_seconds, log_file)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, NULLIF($12, ''))
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			result = EXCLUDED.result,
+			error = EXCLUDED.error,
+			started_at = EXCLUDED.started_at,
+			finished_at = EXCLUDED.finished_at,
+			duration_seconds = EXCLUDED.duration_seconds,
+			log_file = EXCLUDED.log_file
+	`, e.ID, e.Type, e.Trigger, e.Status, params, result, e.Error,
+		e.CreatedAt, e.StartedAt, e.FinishedAt, e.DurationSeconds, e.LogFile)
+	return err
+}
+
+// List returns the entries matching f, newest first, and the total number
+// of matches ignoring Limit and Offset.
+func (c *Catalog) List(ctx context.Context, f Filter) ([]Entry, int, error) {
+	pool, _, err := c.cluster.Reader()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := f.where()
+
+	var total int
+	err = pool.QueryRow(ctx, `SELECT COUNT(*) FROM job_history`+where, args...).Scan(&total)
+	if err != nil {
+		if isUndefinedTable(err) {
+			return []Entry{}, 0, nil
+		}
+		return nil, 0, err
+	}
+
+	args = append(args, f.Limit, f.Offset)
+	rows, err := pool.Query(ctx, fmt.Sprintf(`
+		SELECT id, type, trigger, status, params, result, COALESCE(error, ''),
+			created_at, started_at, finished_at, duration_seconds, COALESCE(log_file, '')
+		FROM job_history%s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var params, result []byte
+		if err := rows.Scan(
+			&e.ID, &e.Type, &e.Trigger, &e.Status, &params, &result, &e.Error,
+			&e.CreatedAt, &e.StartedAt, &e.FinishedAt, &e.DurationSeconds, &e.LogFile,
+		); err != nil {
+			return nil, 0, err
+		}
+		if len(params) > 0 {
+			_ = json.Unmarshal(params, &e.Params)
+		}
+		if len(result) > 0 {
+			_ = json.Unmarshal(result, &e.Result)
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// where builds the WHERE clause and arguments of f.
+func (f Filter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Type != "" {
+		add("type = $%d", f.Type)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.Trigger != "" {
+		add("trigger = $%d", f.Trigger)
+	}
+	if f.Stanza != "" {
+		add("params->>'stanza' = $%d", f.Stanza)
+	}
+	if f.Since != nil {
+		add("created_at >= $%d", *f.Since)
+	}
+	if f.Until != nil {
+		add("created_at < $%d", *f.Until)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// isUndefinedTable reports whether err is PostgreSQL's undefined_table
+// error, returned before the first job has been recorded.
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
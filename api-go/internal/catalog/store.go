@@ -0,0 +1,189 @@
+package catalog
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Store records finished jobs and lists them. Catalog keeps them in
+// PostgreSQL, Memory in the API process, and Fallback in PostgreSQL with
+// a copy in memory for while the database is down.
+type Store interface {
+	Record(ctx context.Context, e Entry) error
+	List(ctx context.Context, f Filter) ([]Entry, int, error)
+}
+
+// Memory keeps the most recently recorded entries in memory. It never
+// fails, so jobs such as a restore of the very database the catalog
+// normally lives in are still recorded.
+type Memory struct {
+	mu       sync.RWMutex
+	capacity int
+	entries  []Entry
+}
+
+// NewMemory creates an in-memory store of at most capacity entries,
+// dropping the oldest recorded ones when full.
+func NewMemory(capacity int) *Memory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Memory{capacity: capacity}
+}
+
+// Record inserts or replaces an entry.
+func (m *Memory) Record(ctx context.Context, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.entries {
+		if m.entries[i].ID == e.ID {
+			m.entries[i] = e
+			return nil
+		}
+	}
+	if len(m.entries) == m.capacity {
+		m.entries = m.entries[1:]
+	}
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+// List returns the entries matching f, newest first, and the total number
+// of matches ignoring Limit and Offset, like Catalog.List.
+func (m *Memory) List(ctx context.Context, f Filter) ([]Entry, int, error) {
+	m.mu.RLock()
+	matched := []Entry{}
+	for _, e := range m.entries {
+		if f.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := len(matched)
+	if f.Offset >= total {
+		return []Entry{}, total, nil
+	}
+	matched = matched[f.Offset:]
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+	}
+	return matched, total, nil
+}
+
+// get returns the entry with the given ID.
+func (m *Memory) get(id string) (Entry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, e := range m.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// matches reports whether e is selected by f, as where does in SQL.
+func (f Filter) matches(e Entry) bool {
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if f.Status != "" && e.Status != f.Status {
+		return false
+	}
+	if f.Trigger != "" && e.Trigger != f.Trigger {
+		return false
+	}
+	if f.Stanza != "" {
+		if stanza, _ := e.Params["stanza"].(string); stanza != f.Stanza {
+			return false
+		}
+	}
+	if f.Since != nil && e.CreatedAt.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !e.CreatedAt.Before(*f.Until) {
+		return false
+	}
+	return true
+}
+
+// Fallback records entries in a primary store and keeps the recent ones
+// in memory. While the primary fails, entries are only kept in memory
+// and listed from there; they are written to the primary with the next
+// entry it accepts.
+type Fallback struct {
+	primary Store
+	memory  *Memory
+
+	mu      sync.Mutex
+	pending []string
+}
+
+// NewFallback creates a store that falls back to the most recent capacity
+// entries in memory while primary fails.
+func NewFallback(primary Store, capacity int) *Fallback {
+	return &Fallback{primary: primary, memory: NewMemory(capacity)}
+}
+
+// Record stores an entry in memory and in the primary store. It only
+// fails when the primary does; the entry is then written with the next
+// one the primary accepts, unless it has left memory by then.
+func (s *Fallback) Record(ctx context.Context, e Entry) error {
+	s.memory.Record(ctx, e)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.primary.Record(ctx, e); err != nil {
+		s.pending = append(s.pending, e.ID)
+		return err
+	}
+	return s.flush(ctx)
+}
+
+// flush writes the entries the primary missed, oldest first, stopping at
+// the first failure. The caller holds the lock.
+func (s *Fallback) flush(ctx context.Context) error {
+	for len(s.pending) > 0 {
+		if e, ok := s.memory.get(s.pending[0]); ok {
+			if err := s.primary.Record(ctx, e); err != nil {
+				return err
+			}
+		}
+		s.pending = s.pending[1:]
+	}
+	return nil
+}
+
+// Pending returns how many entries have not reached the primary yet.
+func (s *Fallback) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// List returns the entries of the primary store, or those in memory when
+// it fails. Entries the primary missed are written to it first, when it
+// accepts them again.
+func (s *Fallback) List(ctx context.Context, f Filter) ([]Entry, int, error) {
+	s.mu.Lock()
+	s.flush(ctx)
+	s.mu.Unlock()
+
+	entries, total, err := s.primary.List(ctx, f)
+	if err != nil {
+		return s.memory.List(ctx, f)
+	}
+	return entries, total, nil
+}
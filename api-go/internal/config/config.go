@@ -3,41 +3,605 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/spf13/viper"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	Backup   BackupConfig
+	App          AppConfig
+	Server       ServerConfig
+	Log          LogConfig
+	Tracing      TracingConfig
+	GRPC         GRPCConfig
+	Database     DatabaseConfig
+	Items        ItemsConfig
+	Backup       BackupConfig
+	Health       HealthConfig
+	Summary      SummaryConfig
+	Sessions     SessionsConfig
+	Admin        AdminConfig
+	Metrics      MetricsConfig
+	Alerts       AlertsConfig
+	Jobs         JobsConfig
+	Notify       NotifyConfig
+	Patroni      PatroniConfig
+	AutoFailover AutoFailoverConfig `mapstructure:"autofailover"`
+	Watcher      WatcherConfig
+	DR           DRConfig
+	SLO          SLOConfig
+	Clusters     ClustersConfig `mapstructure:"monitored"`
 }
 
 // AppConfig holds application-level settings.
 type AppConfig struct {
-	Name    string `mapstructure:"name"`
-	Version string `mapstructure:"version"`
-	Port    int    `mapstructure:"port"`
-	Debug   bool   `mapstructure:"debug"`
+	Name            string        `mapstructure:"name"`
+	Version         string        `mapstructure:"version"`
+	Profile         string        `mapstructure:"profile"`
+	Port            int           `mapstructure:"port"`
+	Debug           bool          `mapstructure:"debug"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+}
+
+// ServerConfig holds HTTP server hardening settings.
+type ServerConfig struct {
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`
+	TLSCertFile       string        `mapstructure:"tls_cert_file"`
+	TLSKeyFile        string        `mapstructure:"tls_key_file"`
+	HSTSMaxAge        time.Duration `mapstructure:"hsts_max_age"`
+	FrameOptions      string        `mapstructure:"frame_options"`
+}
+
+// TLSEnabled reports whether the server should terminate TLS itself.
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// Log levels and formats.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// LogConfig holds logging settings. RequestSampleRate is the share of
+// successful requests logged, from 0 to 1; failed ones always are.
+type LogConfig struct {
+	Level             string  `mapstructure:"level"`
+	Format            string  `mapstructure:"format"`
+	RequestSampleRate float64 `mapstructure:"request_sample_rate"`
+}
+
+// TracingConfig holds OpenTelemetry tracing settings. Spans are exported
+// over OTLP/HTTP to Endpoint, a URL such as http://otel-collector:4318;
+// SampleRatio is the share of traces started here that are recorded.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// GRPCConfig holds settings of the gRPC API, served on Port alongside
+// the REST API when it is not 0. It uses the TLS certificate and API keys
+// of the REST API.
+type GRPCConfig struct {
+	Port int `mapstructure:"port"`
 }
 
 // DatabaseConfig holds database connection settings.
 type DatabaseConfig struct {
-	Host        string `mapstructure:"host"`
-	Port        int    `mapstructure:"port"`
-	Name        string `mapstructure:"name"`
-	User        string `mapstructure:"user"`
-	Password    string `mapstructure:"password"`
-	PoolMinSize int    `mapstructure:"pool_min_size"`
-	PoolMaxSize int    `mapstructure:"pool_max_size"`
+	Host                string        `mapstructure:"host"`
+	Port                int           `mapstructure:"port"`
+	Name                string        `mapstructure:"name"`
+	User                string        `mapstructure:"user"`
+	Password            string        `mapstructure:"password"`
+	PoolMinSize         int           `mapstructure:"pool_min_size"`
+	PoolMaxSize         int           `mapstructure:"pool_max_size"`
+	ConnectTimeout      time.Duration `mapstructure:"connect_timeout"`
+	ReplicaHosts        []string      `mapstructure:"replica_hosts"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	FailoverEstimate    time.Duration `mapstructure:"failover_estimate"`
+
+	// Retarget moves the write pool to the new primary after a failover.
+	// RetargetReadOnlyErrors read-only errors also trigger it; 0 disables
+	// that trigger.
+	Retarget               bool `mapstructure:"retarget"`
+	RetargetReadOnlyErrors int  `mapstructure:"retarget_read_only_errors"`
+
+	// ReadPreference picks the node serving reads while the primary is up.
+	// ReadYourWrites decides how a read carrying a consistency token that
+	// lands on a replica sees the write: from the primary, or by waiting
+	// up to ReadYourWritesTimeout for the replica to replay it.
+	ReadPreference        string        `mapstructure:"read_preference"`
+	ReadYourWrites        string        `mapstructure:"read_your_writes"`
+	ReadYourWritesTimeout time.Duration `mapstructure:"read_your_writes_timeout"`
+
+	// MigrateOnStart applies pending schema migrations at startup;
+	// otherwise they are applied with POST /admin/migrate.
+	MigrateOnStart bool `mapstructure:"migrate_on_start"`
+
+	// ApplicationName is the application_name of the sessions of the
+	// API, followed by the request ID while one serves a request.
+	ApplicationName string `mapstructure:"application_name"`
 }
 
+// Read preferences.
+const (
+	ReadPreferencePrimary = "primary"
+	ReadPreferenceReplica = "replica"
+)
+
+// Read-your-writes modes.
+const (
+	ReadYourWritesPrimary = "primary"
+	ReadYourWritesWait    = "wait"
+)
+
+// ItemsConfig holds settings of the items demo table.
+type ItemsConfig struct {
+	// KeyType is the key of newly created items tables: a SERIAL, or a
+	// UUIDv7 that stays unique across sites, so keys written on a
+	// promoted DR site cannot collide with those of the old primary.
+	KeyType string `mapstructure:"key_type"`
+}
+
+// Item key types.
+const (
+	ItemKeySerial = "serial"
+	ItemKeyUUIDv7 = "uuidv7"
+)
+
 // BackupConfig holds pgBackRest settings.
 type BackupConfig struct {
-	Stanza string `mapstructure:"stanza"`
+	Provider       string        `mapstructure:"provider"`
+	Stanza         string        `mapstructure:"stanza"`
+	Stanzas        []string      `mapstructure:"stanzas"`
+	ConfigFile     string        `mapstructure:"config_file"`
+	CommandTimeout time.Duration `mapstructure:"command_timeout"`
+	DataDir        string        `mapstructure:"data_dir"`
+	RestoreTimeout time.Duration `mapstructure:"restore_timeout"`
+
+	// Binary, ConfigIncludePath, Repo and ExtraArgs shape every pgbackrest
+	// invocation. Repo 0 uses all repositories; ExtraArgs are appended as
+	// given, e.g. --process-max=4.
+	Binary            string   `mapstructure:"binary"`
+	ConfigIncludePath string   `mapstructure:"config_include_path"`
+	Repo              int      `mapstructure:"repo"`
+	ExtraArgs         []string `mapstructure:"extra_args"`
+
+	// RefreshInterval is how often pgbackrest info is refreshed in the
+	// background; results older than CacheMaxAge are fetched on demand.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	CacheMaxAge     time.Duration `mapstructure:"cache_max_age"`
+
+	// HistoryFile keeps completed backups seen in backup info so trends
+	// survive repository retention; entries older than HistoryRetention
+	// are dropped. An empty path keeps the history in memory only.
+	HistoryFile      string        `mapstructure:"history_file"`
+	HistoryRetention time.Duration `mapstructure:"history_retention"`
+
+	// RepoHost points pgbackrest at a remote repository host. With
+	// RepoHostType "tls" the host runs `pgbackrest server` and is reached
+	// with the client certificate instead of SSH.
+	RepoHost         string `mapstructure:"repo_host"`
+	RepoHostUser     string `mapstructure:"repo_host_user"`
+	RepoHostType     string `mapstructure:"repo_host_type"`
+	RepoHostPort     int    `mapstructure:"repo_host_port"`
+	RepoHostCertFile string `mapstructure:"repo_host_cert_file"`
+	RepoHostKeyFile  string `mapstructure:"repo_host_key_file"`
+	RepoHostCAFile   string `mapstructure:"repo_host_ca_file"`
+
+	// SSHHost runs pgbackrest on another machine over SSH instead of
+	// invoking the local binary.
+	SSHHost    string `mapstructure:"ssh_host"`
+	SSHUser    string `mapstructure:"ssh_user"`
+	SSHPort    int    `mapstructure:"ssh_port"`
+	SSHKeyFile string `mapstructure:"ssh_key_file"`
+
+	// Dump settings apply to the pg_dump provider. Dumps are written to
+	// DumpDir/<database>/ and only the newest DumpRetain are kept.
+	DumpDir       string   `mapstructure:"dump_dir"`
+	DumpDatabases []string `mapstructure:"dump_databases"`
+	DumpRetain    int      `mapstructure:"dump_retain"`
+
+	// Barman settings apply to the barman provider. The first server is
+	// the default target; recover copies into DataDir on the host reached
+	// by BarmanRemoteSSHCommand, or locally when it is empty.
+	BarmanServers          []string `mapstructure:"barman_servers"`
+	BarmanRemoteSSHCommand string   `mapstructure:"barman_remote_ssh_command"`
+
+	Schedule BackupScheduleConfig `mapstructure:"schedule"`
+	SLA      BackupSLAConfig      `mapstructure:"sla"`
+}
+
+// BackupSLAConfig holds the maximum acceptable age of the newest backup of
+// each type and of the newest archived WAL segment. Zero disables a check.
+type BackupSLAConfig struct {
+	MaxFullAge       time.Duration `mapstructure:"max_full_age"`
+	MaxDiffAge       time.Duration `mapstructure:"max_diff_age"`
+	MaxIncrAge       time.Duration `mapstructure:"max_incr_age"`
+	MaxWALArchiveAge time.Duration `mapstructure:"max_wal_archive_age"`
+}
+
+// BackupScheduleConfig holds cron expressions (UTC) for automatic backups
+// of the default stanza. An empty expression disables that backup type.
+type BackupScheduleConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Full    string `mapstructure:"full"`
+	Diff    string `mapstructure:"diff"`
+	Incr    string `mapstructure:"incr"`
+}
+
+// Readiness role policies.
+const (
+	RolePolicyAny            = "any"
+	RolePolicyRequirePrimary = "require-primary"
+	RolePolicyRequireReplica = "require-replica"
+)
+
+// HealthConfig holds thresholds for the health and readiness checks.
+type HealthConfig struct {
+	ReadyRolePolicy        string  `mapstructure:"ready_role_policy"`
+	DiskPath               string  `mapstructure:"disk_path"`
+	DiskWarnPercent        float64 `mapstructure:"disk_warn_percent"`
+	DiskCriticalPercent    float64 `mapstructure:"disk_critical_percent"`
+	MaxReplicationLagBytes int64   `mapstructure:"max_replication_lag_bytes"`
+}
+
+// SummaryConfig holds refresh intervals for the cached /summary document.
+type SummaryConfig struct {
+	RefreshInterval       time.Duration `mapstructure:"refresh_interval"`
+	BackupRefreshInterval time.Duration `mapstructure:"backup_refresh_interval"`
+}
+
+// SessionsConfig holds thresholds for the problematic session report.
+type SessionsConfig struct {
+	MaxQueryAge             time.Duration `mapstructure:"max_query_age"`
+	MaxIdleInTransactionAge time.Duration `mapstructure:"max_idle_in_transaction_age"`
+}
+
+// MetricsConfig holds settings for metrics caching and the in-memory
+// history. A zero CacheTTL disables caching.
+type MetricsConfig struct {
+	CacheTTL        time.Duration `mapstructure:"cache_ttl"`
+	CacheStaleTTL   time.Duration `mapstructure:"cache_stale_ttl"`
+	HistoryInterval time.Duration `mapstructure:"history_interval"`
+	HistoryWindow   time.Duration `mapstructure:"history_window"`
+}
+
+// AlertsConfig holds the thresholds evaluated by the alerts monitor. A
+// zero threshold disables the corresponding rule.
+type AlertsConfig struct {
+	EvaluationInterval        time.Duration `mapstructure:"evaluation_interval"`
+	MaxReplicationLagBytes    int64         `mapstructure:"max_replication_lag_bytes"`
+	MaxReplicationLagSeconds  float64       `mapstructure:"max_replication_lag_seconds"`
+	MinCacheHitRatio          float64       `mapstructure:"min_cache_hit_ratio"`
+	MaxConnectionUsagePercent float64       `mapstructure:"max_connection_usage_percent"`
+	MaxBackupAge              time.Duration `mapstructure:"max_backup_age"`
+	MaxWALArchiveGapFiles     int64         `mapstructure:"max_wal_archive_gap_files"`
+
+	// ProblematicSessions flags the sessions of the problematic session
+	// report, past the SESSIONS_MAX_* ages.
+	ProblematicSessions bool `mapstructure:"problematic_sessions"`
+}
+
+// JobsConfig holds settings for the async job runner.
+type JobsConfig struct {
+	Workers     int `mapstructure:"workers"`
+	QueueSize   int `mapstructure:"queue_size"`
+	HistorySize int `mapstructure:"history_size"`
+
+	// LogDir keeps the full output of every job; empty keeps logs in
+	// memory only, for as long as the job is in the history.
+	LogDir string `mapstructure:"log_dir"`
+
+	// CatalogStore keeps the finished jobs of /backups/history in
+	// PostgreSQL, falling back to the newest CatalogMemorySize in memory
+	// while it is down, or only in memory.
+	CatalogStore      string `mapstructure:"catalog_store"`
+	CatalogMemorySize int    `mapstructure:"catalog_memory_size"`
+}
+
+// Job catalog stores.
+const (
+	CatalogStorePostgres = "postgres"
+	CatalogStoreMemory   = "memory"
+)
+
+// NotifyConfig holds webhook notification settings. Payloads are signed
+// with WebhookSecret when it is set. Cluster events of the types in Events
+// are also posted to the Slack and Teams incoming webhooks, rendered with
+// MessageTemplate.
+type NotifyConfig struct {
+	WebhookURLs    []string      `mapstructure:"webhook_urls"`
+	WebhookSecret  string        `mapstructure:"webhook_secret"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+
+	SlackWebhookURL string   `mapstructure:"slack_webhook_url"`
+	TeamsWebhookURL string   `mapstructure:"teams_webhook_url"`
+	Events          []string `mapstructure:"events"`
+	MessageTemplate string   `mapstructure:"message_template"`
+}
+
+// PatroniConfig holds the Patroni REST API endpoints and the safety checks
+// applied to switchovers and failovers. A zero MaxLagBytes or MaxBackupAge
+// disables that check. DCSType and DCSURLs point at the etcd or Consul
+// cluster Patroni elects its leader in.
+type PatroniConfig struct {
+	URLs     []string      `mapstructure:"urls"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+
+	DCSType string   `mapstructure:"dcs_type"`
+	DCSURLs []string `mapstructure:"dcs_urls"`
+
+	MaxLagBytes       int64         `mapstructure:"max_lag_bytes"`
+	MaxBackupAge      time.Duration `mapstructure:"max_backup_age"`
+	SwitchoverTimeout time.Duration `mapstructure:"switchover_timeout"`
+}
+
+// AutoFailoverConfig points at the pg_auto_failover monitor, read with
+// the monitor URI pg_autoctl itself uses, and the formation of this
+// cluster on it.
+type AutoFailoverConfig struct {
+	MonitorURI string `mapstructure:"monitor_uri"`
+	Formation  string `mapstructure:"formation"`
+}
+
+// WatcherConfig holds settings for the role and timeline watcher.
+type WatcherConfig struct {
+	Interval     time.Duration `mapstructure:"interval"`
+	EventHistory int           `mapstructure:"event_history"`
+}
+
+// DRConfig points at the standby of the remote DR site. Host is reached
+// with the database credentials; APIURL is another instance of this API
+// running at the DR site, used when the database is not reachable
+// directly. Host wins when both are set.
+type DRConfig struct {
+	Site    string        `mapstructure:"site"`
+	Host    string        `mapstructure:"host"`
+	APIURL  string        `mapstructure:"api_url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Enabled reports whether a DR site is configured.
+func (c *DRConfig) Enabled() bool {
+	return c.Host != "" || c.APIURL != ""
+}
+
+// SLOConfig holds settings for the heartbeat writer measuring achieved RPO
+// and RTO. A zero target is not evaluated.
+type SLOConfig struct {
+	Heartbeat bool          `mapstructure:"heartbeat"`
+	Interval  time.Duration `mapstructure:"interval"`
+	RPOTarget time.Duration `mapstructure:"rpo_target"`
+	RTOTarget time.Duration `mapstructure:"rto_target"`
+}
+
+// ClustersConfig names the cluster configured by DB_HOST and BACKUP_STANZA
+// and lists further clusters monitored from the same instance. Entries are
+// name=host[:port][/stanza]; they share the DB_* credentials and backup
+// settings, and the stanza defaults to the cluster name.
+type ClustersConfig struct {
+	Name    string   `mapstructure:"name"`
+	Entries []string `mapstructure:"entries"`
+}
+
+// ClusterTarget is one monitored cluster parsed from CLUSTERS.
+type ClusterTarget struct {
+	Name   string
+	Host   string
+	Port   int
+	Stanza string
+}
+
+// clusterNamePattern restricts cluster names to what reads well in a URL.
+var clusterNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Targets parses the further clusters, defaulting ports to db's.
+func (c *ClustersConfig) Targets(db *DatabaseConfig) ([]ClusterTarget, error) {
+	seen := map[string]bool{c.Name: true}
+	targets := make([]ClusterTarget, 0, len(c.Entries))
+	for _, entry := range c.Entries {
+		name, addr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || addr == "" {
+			return nil, fmt.Errorf("invalid CLUSTERS entry %q, expected name=host[:port][/stanza]", entry)
+		}
+		if !clusterNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid cluster name %q: use lowercase letters, digits, - and _", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate cluster name %q", name)
+		}
+		seen[name] = true
+
+		addr, stanza, _ := strings.Cut(addr, "/")
+		if stanza == "" {
+			stanza = name
+		}
+		host, port, err := db.ParseHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, ClusterTarget{Name: name, Host: host, Port: port, Stanza: stanza})
+	}
+	return targets, nil
+}
+
+// ForCluster returns a copy of the configuration pointing at t. Replica
+// hosts, extra stanzas, the backup schedule and the persistent backup
+// history stay with the default cluster.
+func (c *Config) ForCluster(t ClusterTarget) *Config {
+	clone := *c
+	clone.Database.Host, clone.Database.Port = t.Host, t.Port
+	clone.Database.ReplicaHosts = nil
+	clone.Backup.Stanza = t.Stanza
+	clone.Backup.Stanzas = nil
+	clone.Backup.BarmanServers = []string{t.Stanza}
+	clone.Backup.HistoryFile = ""
+	clone.Backup.Schedule.Enabled = false
+	return &clone
+}
+
+// AdminConfig holds the API keys of the operational endpoints: /admin,
+// backup and cluster operations. APIKey is a key named admin. APIKeys
+// lists further keys as name=key, and APIKeysFile names a file of them,
+// one per line, such as a mounted secret; it is read at startup.
+// APIKeyRoles gives keys a role as name=role; the others are admins.
+type AdminConfig struct {
+	APIKey      string   `mapstructure:"api_key"`
+	APIKeys     []string `mapstructure:"api_keys"`
+	APIKeysFile string   `mapstructure:"api_keys_file"`
+	APIKeyRoles []string `mapstructure:"api_key_roles"`
+}
+
+// Roles of API keys, from least to most privileged. Viewers read the
+// guarded monitoring endpoints, operators also run backups and
+// maintenance, and admins also restore, fail over and promote.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// roleRanks orders the roles.
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// RoleAtLeast reports whether role grants everything min does.
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[min]
+}
+
+// APIKey is one named key of the operational endpoints. The name is what
+// the logs show for the requests made with it.
+type APIKey struct {
+	Name string
+	Key  string
+	Role string
+}
+
+// Keys returns every configured API key, reading APIKeysFile, where blank
+// lines and lines starting with # are skipped. Names and keys must be
+// unique.
+func (c *AdminConfig) Keys() ([]APIKey, error) {
+	var entries []string
+	if c.APIKey != "" {
+		entries = append(entries, "admin="+c.APIKey)
+	}
+	entries = append(entries, c.APIKeys...)
+	if c.APIKeysFile != "" {
+		data, err := os.ReadFile(c.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ADMIN_API_KEYS_FILE: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	values := map[string]bool{}
+	keys := make([]APIKey, 0, len(entries))
+	for _, entry := range entries {
+		name, key, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" {
+			// Never echo what may be a key
+			return nil, fmt.Errorf("invalid API key entry for %q, expected name=key", name)
+		}
+		if !clusterNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid API key name %q: use lowercase letters, digits, - and _", name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate API key name %q", name)
+		}
+		if values[key] {
+			return nil, fmt.Errorf("API key %q reuses the key of another", name)
+		}
+		names[name], values[key] = true, true
+		keys = append(keys, APIKey{Name: name, Key: key, Role: RoleAdmin})
+	}
+
+	for _, entry := range c.APIKeyRoles {
+		name, role, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if roleRanks[role] == 0 {
+			return nil, fmt.Errorf("invalid ADMIN_API_KEY_ROLES entry %q, expected name=viewer|operator|admin", entry)
+		}
+		if !names[name] {
+			return nil, fmt.Errorf("ADMIN_API_KEY_ROLES names unknown API key %q", name)
+		}
+		for i := range keys {
+			if keys[i].Name == name {
+				keys[i].Role = role
+			}
+		}
+	}
+	return keys, nil
+}
+
+// profiles bundle defaults for a deployment environment. A profile only
+// overrides defaults; explicit environment variables still win.
+var profiles = map[string]map[string]interface{}{
+	"dev": {
+		"app.debug":                true,
+		"log.level":                LogLevelDebug,
+		"log.format":               LogFormatText,
+		"database.pool_min_size":   1,
+		"database.pool_max_size":   5,
+		"database.connect_timeout": 5 * time.Second,
+		"backup.command_timeout":   15 * time.Second,
+		"health.ready_role_policy": RolePolicyAny,
+	},
+	"staging": {
+		"app.debug":                false,
+		"database.pool_min_size":   2,
+		"database.pool_max_size":   10,
+		"database.connect_timeout": 15 * time.Second,
+		"backup.command_timeout":   30 * time.Second,
+		"health.ready_role_policy": RolePolicyAny,
+	},
+	"prod-dr": {
+		"app.debug":                false,
+		"app.shutdown_timeout":     30 * time.Second,
+		"database.pool_min_size":   5,
+		"database.pool_max_size":   50,
+		"database.connect_timeout": 30 * time.Second,
+		"backup.command_timeout":   60 * time.Second,
+		"health.ready_role_policy": RolePolicyRequirePrimary,
+	},
+}
+
+// Profiles returns the names of the available configuration profiles.
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Load loads configuration from environment variables.
@@ -47,8 +611,30 @@ func Load() (*Config, error) {
 	// Set defaults
 	v.SetDefault("app.name", "PostgreSQL HA/DR Demo API (Go)")
 	v.SetDefault("app.version", "1.0.0")
+	v.SetDefault("app.profile", "")
 	v.SetDefault("app.port", 8000)
 	v.SetDefault("app.debug", false)
+	v.SetDefault("app.shutdown_timeout", 10*time.Second)
+
+	v.SetDefault("server.read_timeout", 15*time.Second)
+	v.SetDefault("server.read_header_timeout", 5*time.Second)
+	v.SetDefault("server.write_timeout", 60*time.Second)
+	v.SetDefault("server.idle_timeout", 120*time.Second)
+	v.SetDefault("server.max_header_bytes", 1<<20)
+	v.SetDefault("server.tls_cert_file", "")
+	v.SetDefault("server.tls_key_file", "")
+	v.SetDefault("server.hsts_max_age", 365*24*time.Hour)
+	v.SetDefault("server.frame_options", "DENY")
+
+	v.SetDefault("log.level", LogLevelInfo)
+	v.SetDefault("log.format", LogFormatJSON)
+	v.SetDefault("log.request_sample_rate", 1.0)
+
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.endpoint", "http://localhost:4318")
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	v.SetDefault("grpc.port", 0)
 
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
@@ -57,8 +643,137 @@ func Load() (*Config, error) {
 	v.SetDefault("database.password", "")
 	v.SetDefault("database.pool_min_size", 5)
 	v.SetDefault("database.pool_max_size", 20)
+	v.SetDefault("database.connect_timeout", 30*time.Second)
+	v.SetDefault("database.replica_hosts", []string{})
+	v.SetDefault("database.health_check_interval", 5*time.Second)
+	v.SetDefault("database.failover_estimate", 30*time.Second)
+	v.SetDefault("database.retarget", true)
+	v.SetDefault("database.retarget_read_only_errors", 5)
+	v.SetDefault("database.read_preference", ReadPreferencePrimary)
+	v.SetDefault("database.read_your_writes", ReadYourWritesPrimary)
+	v.SetDefault("database.read_your_writes_timeout", 2*time.Second)
+	v.SetDefault("database.migrate_on_start", true)
+	v.SetDefault("database.application_name", "pgha-api")
+
+	v.SetDefault("items.key_type", ItemKeySerial)
 
+	v.SetDefault("backup.provider", "pgbackrest")
 	v.SetDefault("backup.stanza", "pgha-dev-postgres")
+	v.SetDefault("backup.command_timeout", 30*time.Second)
+	v.SetDefault("backup.stanzas", []string{})
+	v.SetDefault("backup.config_file", "/etc/pgbackrest/pgbackrest.conf")
+	v.SetDefault("backup.binary", "pgbackrest")
+	v.SetDefault("backup.config_include_path", "")
+	v.SetDefault("backup.repo", 0)
+	v.SetDefault("backup.extra_args", []string{})
+	v.SetDefault("backup.data_dir", "/var/lib/postgresql/data")
+	v.SetDefault("backup.restore_timeout", 6*time.Hour)
+	v.SetDefault("backup.refresh_interval", time.Minute)
+	v.SetDefault("backup.cache_max_age", 5*time.Minute)
+	v.SetDefault("backup.history_file", "/var/lib/pgha/backup-history.json")
+	v.SetDefault("backup.history_retention", 365*24*time.Hour)
+	v.SetDefault("backup.repo_host", "")
+	v.SetDefault("backup.repo_host_user", "")
+	v.SetDefault("backup.repo_host_type", "")
+	v.SetDefault("backup.repo_host_port", 0)
+	v.SetDefault("backup.repo_host_cert_file", "")
+	v.SetDefault("backup.repo_host_key_file", "")
+	v.SetDefault("backup.repo_host_ca_file", "")
+	v.SetDefault("backup.ssh_host", "")
+	v.SetDefault("backup.ssh_user", "")
+	v.SetDefault("backup.ssh_port", 22)
+	v.SetDefault("backup.ssh_key_file", "")
+	v.SetDefault("backup.dump_dir", "/var/lib/pgha/dumps")
+	v.SetDefault("backup.dump_databases", []string{})
+	v.SetDefault("backup.dump_retain", 7)
+	v.SetDefault("backup.barman_servers", []string{})
+	v.SetDefault("backup.barman_remote_ssh_command", "")
+	v.SetDefault("backup.schedule.enabled", false)
+	v.SetDefault("backup.schedule.full", "0 1 * * 0")
+	v.SetDefault("backup.schedule.diff", "0 1 * * 1-6")
+	v.SetDefault("backup.schedule.incr", "30 * * * *")
+	v.SetDefault("backup.sla.max_full_age", 8*24*time.Hour)
+	v.SetDefault("backup.sla.max_diff_age", 0)
+	v.SetDefault("backup.sla.max_incr_age", 0)
+	v.SetDefault("backup.sla.max_wal_archive_age", time.Hour)
+
+	v.SetDefault("health.ready_role_policy", RolePolicyAny)
+	v.SetDefault("health.disk_path", "/")
+	v.SetDefault("health.disk_warn_percent", 80.0)
+	v.SetDefault("health.disk_critical_percent", 95.0)
+	v.SetDefault("health.max_replication_lag_bytes", 16*1024*1024)
+
+	v.SetDefault("summary.refresh_interval", 5*time.Second)
+	v.SetDefault("summary.backup_refresh_interval", 5*time.Minute)
+
+	v.SetDefault("sessions.max_query_age", 5*time.Minute)
+	v.SetDefault("sessions.max_idle_in_transaction_age", time.Minute)
+
+	v.SetDefault("admin.api_key", "")
+	v.SetDefault("admin.api_keys", []string{})
+	v.SetDefault("admin.api_keys_file", "")
+	v.SetDefault("admin.api_key_roles", []string{})
+
+	v.SetDefault("metrics.cache_ttl", 5*time.Second)
+	v.SetDefault("metrics.cache_stale_ttl", 30*time.Second)
+	v.SetDefault("metrics.history_interval", 10*time.Second)
+	v.SetDefault("metrics.history_window", time.Hour)
+
+	v.SetDefault("alerts.evaluation_interval", 15*time.Second)
+	v.SetDefault("alerts.max_replication_lag_bytes", 64*1024*1024)
+	v.SetDefault("alerts.max_replication_lag_seconds", 30)
+	v.SetDefault("alerts.min_cache_hit_ratio", 90)
+	v.SetDefault("alerts.max_connection_usage_percent", 80)
+	v.SetDefault("alerts.max_backup_age", 8*24*time.Hour)
+	v.SetDefault("alerts.max_wal_archive_gap_files", 8)
+	v.SetDefault("alerts.problematic_sessions", false)
+
+	v.SetDefault("jobs.workers", 2)
+	v.SetDefault("jobs.queue_size", 32)
+	v.SetDefault("jobs.history_size", 100)
+	v.SetDefault("jobs.log_dir", "/var/lib/pgha/jobs")
+	v.SetDefault("jobs.catalog_store", CatalogStorePostgres)
+	v.SetDefault("jobs.catalog_memory_size", 1000)
+
+	v.SetDefault("notify.webhook_urls", []string{})
+	v.SetDefault("notify.webhook_secret", "")
+	v.SetDefault("notify.webhook_timeout", 5*time.Second)
+	v.SetDefault("notify.slack_webhook_url", "")
+	v.SetDefault("notify.teams_webhook_url", "")
+	v.SetDefault("notify.events", []string{
+		"role_changed", "primary_retargeted", "system_identifier_changed",
+		"node_unreachable", "lag_threshold_exceeded", "lag_threshold_recovered",
+	})
+	v.SetDefault("notify.message_template", "[{{.App}}] {{.Message}}")
+
+	v.SetDefault("dr.site", "dr")
+	v.SetDefault("dr.host", "")
+	v.SetDefault("dr.api_url", "")
+	v.SetDefault("dr.timeout", 5*time.Second)
+
+	v.SetDefault("slo.heartbeat", false)
+	v.SetDefault("slo.interval", 5*time.Second)
+	v.SetDefault("slo.rpo_target", time.Minute)
+	v.SetDefault("slo.rto_target", 5*time.Minute)
+
+	v.SetDefault("patroni.urls", []string{})
+	v.SetDefault("patroni.username", "")
+	v.SetDefault("patroni.password", "")
+	v.SetDefault("patroni.timeout", 5*time.Second)
+	v.SetDefault("patroni.dcs_type", "")
+	v.SetDefault("patroni.dcs_urls", []string{})
+	v.SetDefault("patroni.max_lag_bytes", 1024*1024)
+	v.SetDefault("patroni.max_backup_age", 26*time.Hour)
+	v.SetDefault("patroni.switchover_timeout", 2*time.Minute)
+	v.SetDefault("autofailover.monitor_uri", "")
+	v.SetDefault("autofailover.formation", "default")
+
+	// Not under "clusters", which the CLUSTERS variable would shadow
+	v.SetDefault("monitored.name", "default")
+	v.SetDefault("monitored.entries", []string{})
+
+	v.SetDefault("watcher.interval", 5*time.Second)
+	v.SetDefault("watcher.event_history", 500)
 
 	// Environment variable bindings
 	v.SetEnvPrefix("")
@@ -68,8 +783,27 @@ func Load() (*Config, error) {
 	// Map flat environment variables to nested config
 	v.BindEnv("app.name", "APP_NAME")
 	v.BindEnv("app.version", "APP_VERSION")
+	v.BindEnv("app.profile", "APP_PROFILE")
 	v.BindEnv("app.port", "PORT")
 	v.BindEnv("app.debug", "DEBUG")
+	v.BindEnv("app.shutdown_timeout", "SHUTDOWN_TIMEOUT")
+
+	v.BindEnv("server.read_timeout", "SERVER_READ_TIMEOUT")
+	v.BindEnv("server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT")
+	v.BindEnv("server.write_timeout", "SERVER_WRITE_TIMEOUT")
+	v.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
+	v.BindEnv("server.max_header_bytes", "SERVER_MAX_HEADER_BYTES")
+	v.BindEnv("server.tls_cert_file", "TLS_CERT_FILE")
+	v.BindEnv("server.tls_key_file", "TLS_KEY_FILE")
+	v.BindEnv("server.hsts_max_age", "HSTS_MAX_AGE")
+	v.BindEnv("server.frame_options", "FRAME_OPTIONS")
+	v.BindEnv("log.level", "LOG_LEVEL")
+	v.BindEnv("log.format", "LOG_FORMAT")
+	v.BindEnv("log.request_sample_rate", "LOG_REQUEST_SAMPLE_RATE")
+	v.BindEnv("tracing.enabled", "TRACING_ENABLED")
+	v.BindEnv("tracing.endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	v.BindEnv("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
+	v.BindEnv("grpc.port", "GRPC_PORT")
 
 	v.BindEnv("database.host", "DB_HOST")
 	v.BindEnv("database.port", "DB_PORT")
@@ -78,25 +812,398 @@ func Load() (*Config, error) {
 	v.BindEnv("database.password", "DB_PASSWORD")
 	v.BindEnv("database.pool_min_size", "DB_POOL_MIN_SIZE")
 	v.BindEnv("database.pool_max_size", "DB_POOL_MAX_SIZE")
+	v.BindEnv("database.connect_timeout", "DB_CONNECT_TIMEOUT")
+	v.BindEnv("database.replica_hosts", "DB_REPLICA_HOSTS")
+	v.BindEnv("database.health_check_interval", "DB_HEALTH_CHECK_INTERVAL")
+	v.BindEnv("database.failover_estimate", "DB_FAILOVER_ESTIMATE")
+	v.BindEnv("database.retarget", "DB_RETARGET")
+	v.BindEnv("database.retarget_read_only_errors", "DB_RETARGET_READ_ONLY_ERRORS")
+	v.BindEnv("database.read_preference", "DB_READ_PREFERENCE")
+	v.BindEnv("database.read_your_writes", "DB_READ_YOUR_WRITES")
+	v.BindEnv("database.read_your_writes_timeout", "DB_READ_YOUR_WRITES_TIMEOUT")
+	v.BindEnv("database.migrate_on_start", "DB_MIGRATE_ON_START")
+	v.BindEnv("database.application_name", "DB_APPLICATION_NAME")
 
+	v.BindEnv("items.key_type", "ITEMS_KEY_TYPE")
+
+	v.BindEnv("backup.provider", "BACKUP_PROVIDER")
 	v.BindEnv("backup.stanza", "PGBACKREST_STANZA")
+	v.BindEnv("backup.command_timeout", "PGBACKREST_COMMAND_TIMEOUT")
+	v.BindEnv("backup.stanzas", "PGBACKREST_STANZAS")
+	v.BindEnv("backup.config_file", "PGBACKREST_CONFIG")
+	v.BindEnv("backup.binary", "PGBACKREST_BIN")
+	v.BindEnv("backup.config_include_path", "PGBACKREST_CONFIG_INCLUDE_PATH")
+	v.BindEnv("backup.repo", "PGBACKREST_REPO")
+	v.BindEnv("backup.extra_args", "PGBACKREST_EXTRA_ARGS")
+	v.BindEnv("backup.data_dir", "PGBACKREST_PG1_PATH")
+	v.BindEnv("backup.restore_timeout", "PGBACKREST_RESTORE_TIMEOUT")
+	v.BindEnv("backup.refresh_interval", "BACKUP_REFRESH_INTERVAL")
+	v.BindEnv("backup.cache_max_age", "BACKUP_CACHE_MAX_AGE")
+	v.BindEnv("backup.history_file", "BACKUP_HISTORY_FILE")
+	v.BindEnv("backup.history_retention", "BACKUP_HISTORY_RETENTION")
+	v.BindEnv("backup.repo_host", "PGBACKREST_REPO1_HOST")
+	v.BindEnv("backup.repo_host_user", "PGBACKREST_REPO1_HOST_USER")
+	v.BindEnv("backup.repo_host_type", "PGBACKREST_REPO1_HOST_TYPE")
+	v.BindEnv("backup.repo_host_port", "PGBACKREST_REPO1_HOST_PORT")
+	v.BindEnv("backup.repo_host_cert_file", "PGBACKREST_REPO1_HOST_CERT_FILE")
+	v.BindEnv("backup.repo_host_key_file", "PGBACKREST_REPO1_HOST_KEY_FILE")
+	v.BindEnv("backup.repo_host_ca_file", "PGBACKREST_REPO1_HOST_CA_FILE")
+	v.BindEnv("backup.ssh_host", "PGBACKREST_SSH_HOST")
+	v.BindEnv("backup.ssh_user", "PGBACKREST_SSH_USER")
+	v.BindEnv("backup.ssh_port", "PGBACKREST_SSH_PORT")
+	v.BindEnv("backup.ssh_key_file", "PGBACKREST_SSH_KEY_FILE")
+	v.BindEnv("backup.dump_dir", "BACKUP_DUMP_DIR")
+	v.BindEnv("backup.dump_databases", "BACKUP_DUMP_DATABASES")
+	v.BindEnv("backup.dump_retain", "BACKUP_DUMP_RETAIN")
+	v.BindEnv("backup.barman_servers", "BARMAN_SERVERS")
+	v.BindEnv("backup.barman_remote_ssh_command", "BARMAN_REMOTE_SSH_COMMAND")
+	v.BindEnv("backup.schedule.enabled", "BACKUP_SCHEDULE_ENABLED")
+	v.BindEnv("backup.schedule.full", "BACKUP_SCHEDULE_FULL")
+	v.BindEnv("backup.schedule.diff", "BACKUP_SCHEDULE_DIFF")
+	v.BindEnv("backup.schedule.incr", "BACKUP_SCHEDULE_INCR")
+	v.BindEnv("backup.sla.max_full_age", "BACKUP_SLA_MAX_FULL_AGE")
+	v.BindEnv("backup.sla.max_diff_age", "BACKUP_SLA_MAX_DIFF_AGE")
+	v.BindEnv("backup.sla.max_incr_age", "BACKUP_SLA_MAX_INCR_AGE")
+	v.BindEnv("backup.sla.max_wal_archive_age", "BACKUP_SLA_MAX_WAL_ARCHIVE_AGE")
+
+	v.BindEnv("health.ready_role_policy", "READY_ROLE_POLICY")
+	v.BindEnv("health.disk_path", "HEALTH_DISK_PATH")
+	v.BindEnv("health.disk_warn_percent", "HEALTH_DISK_WARN_PERCENT")
+	v.BindEnv("health.disk_critical_percent", "HEALTH_DISK_CRITICAL_PERCENT")
+	v.BindEnv("health.max_replication_lag_bytes", "HEALTH_MAX_REPLICATION_LAG_BYTES")
+
+	v.BindEnv("summary.refresh_interval", "SUMMARY_REFRESH_INTERVAL")
+	v.BindEnv("summary.backup_refresh_interval", "SUMMARY_BACKUP_REFRESH_INTERVAL")
+
+	v.BindEnv("sessions.max_query_age", "SESSIONS_MAX_QUERY_AGE")
+	v.BindEnv("sessions.max_idle_in_transaction_age", "SESSIONS_MAX_IDLE_IN_TRANSACTION_AGE")
+
+	v.BindEnv("admin.api_key", "ADMIN_API_KEY")
+	v.BindEnv("admin.api_keys", "ADMIN_API_KEYS")
+	v.BindEnv("admin.api_keys_file", "ADMIN_API_KEYS_FILE")
+	v.BindEnv("admin.api_key_roles", "ADMIN_API_KEY_ROLES")
+
+	v.BindEnv("metrics.cache_ttl", "METRICS_CACHE_TTL")
+	v.BindEnv("metrics.cache_stale_ttl", "METRICS_CACHE_STALE_TTL")
+	v.BindEnv("metrics.history_interval", "METRICS_HISTORY_INTERVAL")
+	v.BindEnv("metrics.history_window", "METRICS_HISTORY_WINDOW")
+
+	v.BindEnv("alerts.evaluation_interval", "ALERTS_EVALUATION_INTERVAL")
+	v.BindEnv("alerts.max_replication_lag_bytes", "ALERTS_MAX_REPLICATION_LAG_BYTES")
+	v.BindEnv("alerts.max_replication_lag_seconds", "ALERTS_MAX_REPLICATION_LAG_SECONDS")
+	v.BindEnv("alerts.min_cache_hit_ratio", "ALERTS_MIN_CACHE_HIT_RATIO")
+	v.BindEnv("alerts.max_connection_usage_percent", "ALERTS_MAX_CONNECTION_USAGE_PERCENT")
+	v.BindEnv("alerts.max_backup_age", "ALERTS_MAX_BACKUP_AGE")
+	v.BindEnv("alerts.max_wal_archive_gap_files", "ALERTS_MAX_WAL_ARCHIVE_GAP_FILES")
+	v.BindEnv("alerts.problematic_sessions", "ALERTS_PROBLEMATIC_SESSIONS")
+
+	v.BindEnv("jobs.workers", "JOBS_WORKERS")
+	v.BindEnv("jobs.queue_size", "JOBS_QUEUE_SIZE")
+	v.BindEnv("jobs.history_size", "JOBS_HISTORY_SIZE")
+	v.BindEnv("jobs.log_dir", "JOBS_LOG_DIR")
+	v.BindEnv("jobs.catalog_store", "JOBS_CATALOG_STORE")
+	v.BindEnv("jobs.catalog_memory_size", "JOBS_CATALOG_MEMORY_SIZE")
+
+	v.BindEnv("notify.webhook_urls", "WEBHOOK_URLS")
+	v.BindEnv("notify.webhook_secret", "WEBHOOK_SECRET")
+	v.BindEnv("notify.webhook_timeout", "WEBHOOK_TIMEOUT")
+	v.BindEnv("notify.slack_webhook_url", "SLACK_WEBHOOK_URL")
+	v.BindEnv("notify.teams_webhook_url", "TEAMS_WEBHOOK_URL")
+	v.BindEnv("notify.events", "NOTIFY_EVENTS")
+	v.BindEnv("notify.message_template", "NOTIFY_MESSAGE_TEMPLATE")
+
+	v.BindEnv("dr.site", "DR_SITE")
+	v.BindEnv("dr.host", "DR_HOST")
+	v.BindEnv("dr.api_url", "DR_API_URL")
+	v.BindEnv("dr.timeout", "DR_TIMEOUT")
+
+	v.BindEnv("slo.heartbeat", "SLO_HEARTBEAT")
+	v.BindEnv("slo.interval", "SLO_HEARTBEAT_INTERVAL")
+	v.BindEnv("slo.rpo_target", "SLO_RPO_TARGET")
+	v.BindEnv("slo.rto_target", "SLO_RTO_TARGET")
+
+	v.BindEnv("patroni.urls", "PATRONI_URLS")
+	v.BindEnv("patroni.username", "PATRONI_USERNAME")
+	v.BindEnv("patroni.password", "PATRONI_PASSWORD")
+	v.BindEnv("patroni.timeout", "PATRONI_TIMEOUT")
+	v.BindEnv("patroni.dcs_type", "DCS_TYPE")
+	v.BindEnv("patroni.dcs_urls", "DCS_URLS")
+	v.BindEnv("patroni.max_lag_bytes", "SWITCHOVER_MAX_LAG_BYTES")
+	v.BindEnv("patroni.max_backup_age", "SWITCHOVER_MAX_BACKUP_AGE")
+	v.BindEnv("patroni.switchover_timeout", "SWITCHOVER_TIMEOUT")
+	v.BindEnv("autofailover.monitor_uri", "PG_AUTOCTL_MONITOR")
+	v.BindEnv("autofailover.formation", "PG_AUTOCTL_FORMATION")
+
+	v.BindEnv("monitored.name", "CLUSTER_NAME")
+	v.BindEnv("monitored.entries", "CLUSTERS")
+
+	v.BindEnv("watcher.interval", "WATCHER_INTERVAL")
+	v.BindEnv("watcher.event_history", "WATCHER_EVENT_HISTORY")
+
+	// Apply profile defaults on top of the base defaults
+	if profile := v.GetString("app.profile"); profile != "" {
+		overrides, ok := profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown APP_PROFILE %q (available: %s)", profile, strings.Join(Profiles(), ", "))
+		}
+		for key, value := range overrides {
+			v.SetDefault(key, value)
+		}
+	}
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// validate checks settings that would otherwise fail at runtime.
+func (c *Config) validate() error {
+	switch c.Log.Level {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return fmt.Errorf("invalid LOG_LEVEL %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case LogFormatJSON, LogFormatText:
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q", c.Log.Format)
+	}
+	if c.Log.RequestSampleRate < 0 || c.Log.RequestSampleRate > 1 {
+		return fmt.Errorf("LOG_REQUEST_SAMPLE_RATE must be between 0 and 1, got %g", c.Log.RequestSampleRate)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Enabled {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be an http or https URL, got %q", c.Tracing.Endpoint)
+		}
+	}
+	if c.GRPC.Port < 0 || c.GRPC.Port > 65535 {
+		return fmt.Errorf("GRPC_PORT must be between 0 and 65535, got %d", c.GRPC.Port)
+	}
+	if c.GRPC.Port != 0 && c.GRPC.Port == c.App.Port {
+		return fmt.Errorf("GRPC_PORT must differ from PORT (%d)", c.App.Port)
+	}
+	if !ValidRolePolicy(c.Health.ReadyRolePolicy) {
+		return fmt.Errorf("invalid READY_ROLE_POLICY %q", c.Health.ReadyRolePolicy)
+	}
+	switch c.Database.ReadPreference {
+	case ReadPreferencePrimary, ReadPreferenceReplica:
+	default:
+		return fmt.Errorf("invalid DB_READ_PREFERENCE %q", c.Database.ReadPreference)
+	}
+	switch c.Database.ReadYourWrites {
+	case ReadYourWritesPrimary, ReadYourWritesWait:
+	default:
+		return fmt.Errorf("invalid DB_READ_YOUR_WRITES %q", c.Database.ReadYourWrites)
+	}
+	switch c.Items.KeyType {
+	case ItemKeySerial, ItemKeyUUIDv7:
+	default:
+		return fmt.Errorf("invalid ITEMS_KEY_TYPE %q", c.Items.KeyType)
+	}
+	switch c.Jobs.CatalogStore {
+	case CatalogStorePostgres, CatalogStoreMemory:
+	default:
+		return fmt.Errorf("invalid JOBS_CATALOG_STORE %q", c.Jobs.CatalogStore)
+	}
+
+	switch c.Backup.Provider {
+	case "pgbackrest", "pg_dump", "wal-g":
+	case "barman":
+		if len(c.Backup.BarmanServers) == 0 {
+			return fmt.Errorf("BARMAN_SERVERS is required when BACKUP_PROVIDER is barman")
+		}
+	default:
+		return fmt.Errorf("invalid BACKUP_PROVIDER %q", c.Backup.Provider)
+	}
+
+	if c.Backup.Provider == "pgbackrest" {
+		if err := c.Backup.validatePgBackRest(); err != nil {
+			return err
+		}
+	}
+
+	if c.Database.RetargetReadOnlyErrors < 0 {
+		return fmt.Errorf("DB_RETARGET_READ_ONLY_ERRORS must not be negative, got %d", c.Database.RetargetReadOnlyErrors)
+	}
+
+	switch c.Patroni.DCSType {
+	case "":
+		if len(c.Patroni.DCSURLs) > 0 {
+			return fmt.Errorf("DCS_TYPE is required when DCS_URLS is set")
+		}
+	case "etcd", "consul":
+	default:
+		return fmt.Errorf("invalid DCS_TYPE %q", c.Patroni.DCSType)
+	}
+
+	if c.AutoFailover.MonitorURI != "" {
+		if len(c.Patroni.URLs) > 0 {
+			return fmt.Errorf("PATRONI_URLS and PG_AUTOCTL_MONITOR are mutually exclusive")
+		}
+		if c.AutoFailover.Formation == "" {
+			return fmt.Errorf("PG_AUTOCTL_FORMATION is required when PG_AUTOCTL_MONITOR is set")
+		}
+	}
+
+	if !clusterNamePattern.MatchString(c.Clusters.Name) {
+		return fmt.Errorf("invalid CLUSTER_NAME %q: use lowercase letters, digits, - and _", c.Clusters.Name)
+	}
+	if _, err := c.Clusters.Targets(&c.Database); err != nil {
+		return err
+	}
+	if _, err := c.Admin.Keys(); err != nil {
+		return err
+	}
+
+	if _, err := template.New("message").Parse(c.Notify.MessageTemplate); err != nil {
+		return fmt.Errorf("invalid NOTIFY_MESSAGE_TEMPLATE: %w", err)
+	}
+
+	switch c.Backup.RepoHostType {
+	case "", "ssh":
+	case "tls":
+		if c.Backup.RepoHost == "" || c.Backup.RepoHostCertFile == "" || c.Backup.RepoHostKeyFile == "" {
+			return fmt.Errorf("PGBACKREST_REPO1_HOST_TYPE=tls requires PGBACKREST_REPO1_HOST, PGBACKREST_REPO1_HOST_CERT_FILE and PGBACKREST_REPO1_HOST_KEY_FILE")
+		}
+	default:
+		return fmt.Errorf("invalid PGBACKREST_REPO1_HOST_TYPE %q", c.Backup.RepoHostType)
+	}
+
+	if c.Backup.Schedule.Enabled {
+		for name, spec := range map[string]string{
+			"BACKUP_SCHEDULE_FULL": c.Backup.Schedule.Full,
+			"BACKUP_SCHEDULE_DIFF": c.Backup.Schedule.Diff,
+			"BACKUP_SCHEDULE_INCR": c.Backup.Schedule.Incr,
+		} {
+			if spec == "" {
+				continue
+			}
+			if _, err := cron.ParseStandard(spec); err != nil {
+				return fmt.Errorf("invalid %s %q: %w", name, spec, err)
+			}
+		}
+	}
+
+	intervals := map[string]time.Duration{
+		"DB_HEALTH_CHECK_INTERVAL":        c.Database.HealthCheckInterval,
+		"DB_READ_YOUR_WRITES_TIMEOUT":     c.Database.ReadYourWritesTimeout,
+		"SUMMARY_REFRESH_INTERVAL":        c.Summary.RefreshInterval,
+		"SUMMARY_BACKUP_REFRESH_INTERVAL": c.Summary.BackupRefreshInterval,
+		"METRICS_HISTORY_INTERVAL":        c.Metrics.HistoryInterval,
+		"METRICS_HISTORY_WINDOW":          c.Metrics.HistoryWindow,
+		"ALERTS_EVALUATION_INTERVAL":      c.Alerts.EvaluationInterval,
+		"BACKUP_REFRESH_INTERVAL":         c.Backup.RefreshInterval,
+		"PATRONI_TIMEOUT":                 c.Patroni.Timeout,
+		"SWITCHOVER_TIMEOUT":              c.Patroni.SwitchoverTimeout,
+		"WATCHER_INTERVAL":                c.Watcher.Interval,
+		"DR_TIMEOUT":                      c.DR.Timeout,
+		"SLO_HEARTBEAT_INTERVAL":          c.SLO.Interval,
+	}
+	for name, d := range intervals {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, d)
+		}
+	}
+
+	return nil
+}
+
+// validatePgBackRest checks the pgbackrest invocation settings. Paths are
+// only checked when pgbackrest runs locally.
+func (b BackupConfig) validatePgBackRest() error {
+	if b.Binary == "" {
+		return fmt.Errorf("PGBACKREST_BIN must not be empty")
+	}
+	if b.Repo < 0 || b.Repo > 256 {
+		return fmt.Errorf("PGBACKREST_REPO must be between 0 and 256, got %d", b.Repo)
+	}
+	for _, arg := range b.ExtraArgs {
+		if arg = strings.TrimSpace(arg); arg != "" && !strings.HasPrefix(arg, "--") {
+			return fmt.Errorf("invalid PGBACKREST_EXTRA_ARGS entry %q: options must start with --", arg)
+		}
+	}
+
+	if b.SSHHost != "" {
+		return nil
+	}
+	if strings.Contains(b.Binary, "/") {
+		if info, err := os.Stat(b.Binary); err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+			return fmt.Errorf("PGBACKREST_BIN %q is not an executable file", b.Binary)
+		}
+	}
+	if b.ConfigIncludePath != "" {
+		if info, err := os.Stat(b.ConfigIncludePath); err != nil || !info.IsDir() {
+			return fmt.Errorf("PGBACKREST_CONFIG_INCLUDE_PATH %q is not a directory", b.ConfigIncludePath)
+		}
+	}
+	return nil
+}
+
+// ValidRolePolicy reports whether policy is a known readiness role policy.
+func ValidRolePolicy(policy string) bool {
+	switch policy {
+	case RolePolicyAny, RolePolicyRequirePrimary, RolePolicyRequireReplica:
+		return true
+	}
+	return false
+}
+
+// StanzaList returns the stanzas reported by /backups. The default stanza
+// always comes first, followed by any additional configured stanzas.
+func (b BackupConfig) StanzaList() []string {
+	list := []string{b.Stanza}
+	for _, s := range b.Stanzas {
+		s = strings.TrimSpace(s)
+		if s != "" && !slices.Contains(list, s) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// HasStanza reports whether stanza is one of the configured stanzas.
+func (b BackupConfig) HasStanza(stanza string) bool {
+	return slices.Contains(b.StanzaList(), stanza)
+}
+
 // DSN returns the PostgreSQL connection string.
 func (c *DatabaseConfig) DSN() string {
+	return c.DSNForHost(c.Host, c.Port)
+}
+
+// DSNForHost returns the connection string for another node of the same
+// cluster, reusing the credentials and database name.
+func (c *DatabaseConfig) DSNForHost(host string, port int) string {
 	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		"postgres://%s:%s@%s/%s?sslmode=disable",
 		url.QueryEscape(c.User),
 		url.QueryEscape(c.Password),
-		c.Host,
-		c.Port,
+		net.JoinHostPort(host, strconv.Itoa(port)),
 		c.Name,
 	)
 }
+
+// ParseHostPort splits a "host" or "host:port" entry, defaulting to the
+// configured database port.
+func (c *DatabaseConfig) ParseHostPort(entry string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(entry)
+	if err != nil {
+		// No port given
+		return entry, c.Port, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in %q: %w", entry, err)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPrimaryUnavailable is returned when a write is attempted while the
+// primary is unreachable.
+var ErrPrimaryUnavailable = errors.New("primary database is unavailable")
+
+// ErrNoReadableNode is returned when neither the primary nor any replica
+// is reachable.
+var ErrNoReadableNode = errors.New("no database node is available for reads")
+
+// Cluster routes queries between the primary and read replicas. While the
+// primary is unreachable, reads fall back to healthy replicas and writes
+// fail fast with ErrPrimaryUnavailable.
+type Cluster struct {
+	primary  *Pool
+	replicas []*Pool
+	interval time.Duration
+
+	// preferReplicas sends reads to a healthy replica even while the
+	// primary is up.
+	preferReplicas bool
+
+	mu        sync.RWMutex
+	primaryUp bool
+	replicaUp []bool
+	downSince time.Time
+	next      int
+}
+
+// NewCluster creates a cluster router. primary may be nil when the
+// primary could not be reached at startup.
+func NewCluster(primary *Pool, replicas []*Pool, interval time.Duration) *Cluster {
+	c := &Cluster{
+		primary:   primary,
+		replicas:  replicas,
+		interval:  interval,
+		primaryUp: primary != nil,
+		replicaUp: make([]bool, len(replicas)),
+	}
+	for i := range c.replicaUp {
+		c.replicaUp[i] = true
+	}
+	if primary == nil {
+		c.downSince = time.Now().UTC()
+	}
+	return c
+}
+
+// Start checks every node immediately and then on the interval until ctx
+// is done.
+func (c *Cluster) Start(ctx context.Context) {
+	go func() {
+		c.check(ctx)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.check(ctx)
+			}
+		}
+	}()
+}
+
+// check pings every node and records its availability.
+func (c *Cluster) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.interval)
+	defer cancel()
+
+	primaryUp := c.primary != nil && c.primary.HealthCheck(ctx) == nil
+
+	replicaUp := make([]bool, len(c.replicas))
+	for i, replica := range c.replicas {
+		replicaUp[i] = replica.HealthCheck(ctx) == nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.primaryUp && !primaryUp {
+		c.downSince = time.Now().UTC()
+	}
+	c.primaryUp = primaryUp
+	c.replicaUp = replicaUp
+}
+
+// Primary returns the primary pool, which may be nil.
+func (c *Cluster) Primary() *Pool {
+	return c.primary
+}
+
+// Replicas returns the replica pools.
+func (c *Cluster) Replicas() []*Pool {
+	return c.replicas
+}
+
+// Writer returns the primary pool if it is reachable.
+func (c *Cluster) Writer() (*Pool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.primaryUp {
+		return nil, ErrPrimaryUnavailable
+	}
+	return c.primary, nil
+}
+
+// PreferReplicas makes Reader choose a healthy replica even while the
+// primary is up, which takes the read load off the primary. Call it
+// before Start.
+func (c *Cluster) PreferReplicas() {
+	c.preferReplicas = true
+}
+
+// Reader returns the primary pool when it is reachable and otherwise a
+// healthy replica; with PreferReplicas the other way round. fromReplica
+// reports whether a replica was chosen.
+func (c *Cluster) Reader() (pool *Pool, fromReplica bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.primaryUp && !c.preferReplicas {
+		return c.primary, false, nil
+	}
+
+	// Round-robin across healthy replicas
+	for i := 0; i < len(c.replicas); i++ {
+		idx := (c.next + i) % len(c.replicas)
+		if c.replicaUp[idx] {
+			c.next = idx + 1
+			return c.replicas[idx], true, nil
+		}
+	}
+	if c.primaryUp {
+		return c.primary, false, nil
+	}
+	return nil, false, ErrNoReadableNode
+}
+
+// PrimaryDownSince returns when the primary became unreachable, and false
+// if it is currently up.
+func (c *Cluster) PrimaryDownSince() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.primaryUp {
+		return time.Time{}, false
+	}
+	return c.downSince, true
+}
+
+// ClusterStatus is a snapshot of node availability.
+type ClusterStatus struct {
+	PrimaryUp         bool
+	PrimaryDownSince  *time.Time
+	ReplicasTotal     int
+	ReplicasAvailable int
+}
+
+// Status returns the availability recorded by the last health check.
+func (c *Cluster) Status() ClusterStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status := ClusterStatus{
+		PrimaryUp:     c.primaryUp,
+		ReplicasTotal: len(c.replicas),
+	}
+	if !c.primaryUp {
+		since := c.downSince
+		status.PrimaryDownSince = &since
+	}
+	for _, up := range c.replicaUp {
+		if up {
+			status.ReplicasAvailable++
+		}
+	}
+	return status
+}
+
+// Close closes the replica pools. The primary pool is owned by the caller.
+func (c *Cluster) Close() {
+	for _, replica := range c.replicas {
+		replica.Close()
+	}
+}
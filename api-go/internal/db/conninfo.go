@@ -0,0 +1,108 @@
+package db
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in sanitized connection strings.
+const redacted = "********"
+
+// secretConninfoKeys are libpq parameters whose values must not be shown.
+var secretConninfoKeys = map[string]bool{
+	"password":    true,
+	"sslpassword": true,
+}
+
+// SanitizeConninfo returns a libpq connection string, in keyword=value or
+// URI form, with its passwords redacted.
+func SanitizeConninfo(conninfo string) string {
+	trimmed := strings.TrimSpace(conninfo)
+	if strings.HasPrefix(trimmed, "postgres://") || strings.HasPrefix(trimmed, "postgresql://") {
+		return sanitizeConninfoURI(trimmed)
+	}
+	return sanitizeConninfoKeywords(conninfo)
+}
+
+func sanitizeConninfoURI(conninfo string) string {
+	u, err := url.Parse(conninfo)
+	if err != nil {
+		// Never echo what could not be parsed; it may hold a password
+		return redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+	}
+	query := u.Query()
+	for key := range query {
+		if secretConninfoKeys[strings.ToLower(key)] {
+			query.Set(key, redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// sanitizeConninfoKeywords walks keyword=value pairs, where values may be
+// single-quoted with backslash escapes, and redacts secret values.
+func sanitizeConninfoKeywords(conninfo string) string {
+	var b strings.Builder
+	s := conninfo
+	for len(s) > 0 {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			b.WriteString(s)
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		b.WriteString(s[:eq+1])
+		s = s[eq+1:]
+
+		// Keep the spaces before the value
+		i := 0
+		for i < len(s) && isConninfoSpace(s[i]) {
+			i++
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		// The value ends at the closing quote or at unquoted whitespace
+		end := 0
+		quoted := len(s) > 0 && s[0] == '\''
+		if quoted {
+			end++
+		}
+		for end < len(s) {
+			c := s[end]
+			if c == '\\' {
+				end += 2
+				continue
+			}
+			if quoted && c == '\'' {
+				end++
+				break
+			}
+			if !quoted && isConninfoSpace(c) {
+				break
+			}
+			end++
+		}
+		if end > len(s) {
+			end = len(s)
+		}
+
+		if secretConninfoKeys[strings.ToLower(key)] {
+			b.WriteString("'" + redacted + "'")
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+	return b.String()
+}
+
+func isConninfoSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrReplicaBehind is returned when a replica has not replayed a client's
+// earlier write within the wait allowed for it.
+var ErrReplicaBehind = errors.New("the replica has not caught up with the write yet")
+
+// replayPollInterval is how often ReaderAfter checks replay progress.
+const replayPollInterval = 20 * time.Millisecond
+
+// CurrentLSN returns the WAL insert position of the primary. Once a write
+// has committed its LSN is at or below it, so a node that has replayed
+// past it sees the write.
+func (p *Pool) CurrentLSN(ctx context.Context) (string, error) {
+	var lsn string
+	err := p.QueryRow(ctx, "SELECT pg_current_wal_insert_lsn()::text").Scan(&lsn)
+	return lsn, err
+}
+
+// ReplayedPast reports whether the node has replayed the WAL up to lsn. A
+// node that is not in recovery always has.
+func (p *Pool) ReplayedPast(ctx context.Context, lsn string) (bool, error) {
+	var replayed bool
+	err := p.QueryRow(ctx, `
+		SELECT NOT pg_is_in_recovery()
+			OR COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, FALSE)`, lsn).Scan(&replayed)
+	return replayed, err
+}
+
+// ReplayLag is how far a replica is behind in applying the WAL. Fields are
+// nil when PostgreSQL does not know them, e.g. before the first replay.
+type ReplayLag struct {
+	// Bytes is the WAL received but not replayed yet.
+	Bytes *int64
+	// Seconds is the age of the last replayed transaction, or 0 when
+	// everything received has been replayed.
+	Seconds    *float64
+	LastReplay *time.Time
+}
+
+// ReplayLag returns the replay lag of a replica.
+func (p *Pool) ReplayLag(ctx context.Context) (ReplayLag, error) {
+	var lag ReplayLag
+	err := p.QueryRow(ctx, `
+		SELECT
+			pg_wal_lsn_diff(pg_last_wal_receive_lsn(), pg_last_wal_replay_lsn())::bigint,
+			CASE
+				WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+			END::float8,
+			pg_last_xact_replay_timestamp()
+	`).Scan(&lag.Bytes, &lag.Seconds, &lag.LastReplay)
+	return lag, err
+}
+
+// ReaderAfter returns a pool for reads that must see the WAL up to lsn,
+// written earlier by the same client. When Reader picks a replica and
+// preferPrimary is set, the primary is used instead if it is up;
+// otherwise the replica is polled until it has replayed past lsn, for at
+// most timeout, after which ErrReplicaBehind is returned.
+func (c *Cluster) ReaderAfter(ctx context.Context, lsn string, preferPrimary bool, timeout time.Duration) (pool *Pool, fromReplica bool, err error) {
+	pool, fromReplica, err = c.Reader()
+	if err != nil || !fromReplica {
+		return pool, fromReplica, err
+	}
+	if preferPrimary {
+		if primary, err := c.Writer(); err == nil {
+			return primary, false, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(replayPollInterval)
+	defer ticker.Stop()
+	for {
+		replayed, err := pool.ReplayedPast(ctx, lsn)
+		if replayed {
+			return pool, true, nil
+		}
+		if err != nil && ctx.Err() == nil {
+			return nil, false, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, ErrReplicaBehind
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,21 +3,81 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
 )
 
-// Pool wraps a pgx connection pool.
+// ErrNotPrimary is returned when a pool is pointed at a node in recovery.
+var ErrNotPrimary = errors.New("node is in recovery")
+
+// Pool wraps a pgx connection pool. The node it connects to can be
+// changed with Retarget while queries are running.
 type Pool struct {
-	*pgxpool.Pool
+	cfg *config.DatabaseConfig
+
+	mu   sync.RWMutex
+	pool *pgxpool.Pool
+	host string
+	port int
+
+	readOnlyErrors atomic.Int64
 }
 
 // NewPool creates a new database connection pool.
 func NewPool(ctx context.Context, cfg *config.DatabaseConfig) (*Pool, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
+	return NewPoolForHost(ctx, cfg, cfg.Host, cfg.Port)
+}
+
+// NewPoolForHost creates a connection pool to another node of the cluster
+// using the same credentials and pool settings.
+func NewPoolForHost(ctx context.Context, cfg *config.DatabaseConfig, host string, port int) (*Pool, error) {
+	pool, err := newPool(ctx, cfg, cfg.DSNForHost(host, port))
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{cfg: cfg, pool: pool, host: host, port: port}, nil
+}
+
+// NewLazyPoolForHost creates a connection pool to another node of the
+// cluster without connecting to it, for a node that is down at startup.
+// Queries fail until the node is reachable.
+func NewLazyPoolForHost(cfg *config.DatabaseConfig, host string, port int) (*Pool, error) {
+	pool, err := openPool(cfg, cfg.DSNForHost(host, port))
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{cfg: cfg, pool: pool, host: host, port: port}, nil
+}
+
+// newPool opens a pool and checks that it can connect.
+func newPool(ctx context.Context, cfg *config.DatabaseConfig, dsn string) (*pgxpool.Pool, error) {
+	pool, err := openPool(cfg, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return pool, nil
+}
+
+// openPool creates a pool with the configured settings. Connections are
+// made as queries need them.
+func openPool(cfg *config.DatabaseConfig, dsn string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database config: %w", err)
 	}
@@ -28,25 +88,261 @@ func NewPool(ctx context.Context, cfg *config.DatabaseConfig) (*Pool, error) {
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = 30 * time.Minute
 	poolConfig.HealthCheckPeriod = 30 * time.Second
+	poolConfig.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
+	poolConfig.ConnConfig.Tracer = queryTracer{}
 
-	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
+	return pool, nil
+}
 
-	// Test connection
-	if err := pool.Ping(ctx); err != nil {
+// maxApplicationName is the length PostgreSQL truncates application_name
+// to.
+const maxApplicationName = 63
+
+// sessionName returns the application_name of a session serving the
+// request of ctx: base followed by the request ID, so that
+// pg_stat_activity and server log lines with %a name the request behind
+// a slow query. It is "" outside a request.
+func sessionName(ctx context.Context, base string) string {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return ""
+	}
+	name := base + " " + id
+	if len(name) > maxApplicationName {
+		name = name[:maxApplicationName]
+	}
+	return name
+}
+
+// setSessionName sets application_name until the end of the current
+// transaction, after which the session is back to its own name.
+const setSessionName = "SELECT set_config('application_name', $1, true)"
+
+// tagged sends sql in a batch behind setSessionName, or returns nil
+// outside a request. A batch is sent in one round trip and runs as one
+// implicit transaction, so naming the session costs no extra round trip
+// and needs no reset. The first result of the batch is that of
+// setSessionName.
+func (p *Pool) tagged(ctx context.Context, sql string, args []any) pgx.BatchResults {
+	name := sessionName(ctx, p.cfg.ApplicationName)
+	if name == "" {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	batch.Queue(setSessionName, name)
+	batch.Queue(sql, args...)
+	return p.current().SendBatch(ctx, batch)
+}
+
+func (p *Pool) current() *pgxpool.Pool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pool
+}
+
+// Addr returns the host and port the pool currently connects to.
+func (p *Pool) Addr() (string, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.host, p.port
+}
+
+// Retarget points the pool at the primary running on host:port. The new
+// node must accept writes; otherwise ErrNotPrimary is returned and the
+// pool is left unchanged. Connections of the old pool are closed once
+// their queries finish.
+func (p *Pool) Retarget(ctx context.Context, host string, port int) error {
+	pool, err := newPool(ctx, p.cfg, p.cfg.DSNForHost(host, port))
+	if err != nil {
+		return err
+	}
+	var inRecovery bool
+	if err := pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
 		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return err
 	}
+	if inRecovery {
+		pool.Close()
+		return ErrNotPrimary
+	}
+
+	p.mu.Lock()
+	old := p.pool
+	p.pool, p.host, p.port = pool, host, port
+	p.mu.Unlock()
+
+	p.readOnlyErrors.Store(0)
+	go old.Close()
+	return nil
+}
 
-	return &Pool{Pool: pool}, nil
+// ReadOnlyErrors returns how many statements failed because the node
+// refused writes since the pool was created or last retargeted.
+func (p *Pool) ReadOnlyErrors() int64 {
+	return p.readOnlyErrors.Load()
+}
+
+// observe counts errors showing the node has become read-only.
+func (p *Pool) observe(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "25006" {
+		p.readOnlyErrors.Add(1)
+	}
+	return err
+}
+
+// Query runs a query returning rows.
+func (p *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if results := p.tagged(ctx, sql, args); results != nil {
+		rows, err := batchQuery(results)
+		return rows, p.observe(err)
+	}
+	rows, err := p.current().Query(ctx, sql, args...)
+	return rows, p.observe(err)
+}
+
+// QueryRow runs a query returning at most one row.
+func (p *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if results := p.tagged(ctx, sql, args); results != nil {
+		rows, err := batchQuery(results)
+		return row{Row: batchRow{rows: rows, err: err}, pool: p}
+	}
+	return row{Row: p.current().QueryRow(ctx, sql, args...), pool: p}
+}
+
+// Exec runs a statement.
+func (p *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if results := p.tagged(ctx, sql, args); results != nil {
+		_, err := results.Exec()
+		var tag pgconn.CommandTag
+		if err == nil {
+			tag, err = results.Exec()
+		}
+		if closeErr := results.Close(); err == nil {
+			err = closeErr
+		}
+		return tag, p.observe(err)
+	}
+	tag, err := p.current().Exec(ctx, sql, args...)
+	return tag, p.observe(err)
+}
+
+// BeginTx starts a transaction on a connection of the current pool. The
+// session is named after the request for the whole transaction, which
+// costs a round trip.
+func (p *Pool) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := p.current().BeginTx(ctx, opts)
+	if err != nil {
+		return nil, p.observe(err)
+	}
+	if name := sessionName(ctx, p.cfg.ApplicationName); name != "" {
+		if _, err := tx.Exec(ctx, setSessionName, name); err != nil {
+			_ = tx.Rollback(ctx)
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// CopyFrom bulk-loads rows into a table with the COPY protocol.
+func (p *Pool) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	n, err := p.current().CopyFrom(ctx, table, columns, src)
+	return n, p.observe(err)
+}
+
+// Ping checks that a connection can be acquired and used.
+func (p *Pool) Ping(ctx context.Context) error {
+	return p.current().Ping(ctx)
+}
+
+// Stat returns the statistics of the current pool.
+func (p *Pool) Stat() *pgxpool.Stat {
+	return p.current().Stat()
+}
+
+type row struct {
+	pgx.Row
+	pool *Pool
+}
+
+func (r row) Scan(dest ...any) error {
+	return r.pool.observe(r.Row.Scan(dest...))
+}
+
+// batchQuery returns the rows of the query sent by tagged. Closing them
+// ends the batch and releases its connection.
+func batchQuery(results pgx.BatchResults) (pgx.Rows, error) {
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return nil, err
+	}
+	rows, err := results.Query()
+	if err != nil {
+		results.Close()
+		return nil, err
+	}
+	return &batchRows{Rows: rows, results: results}, nil
+}
+
+// batchRows are the rows of the last query of a batch.
+type batchRows struct {
+	pgx.Rows
+	results pgx.BatchResults
+	closed  bool
+}
+
+func (r *batchRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// As with the rows of a pool, reading past the last row releases
+	// the connection
+	r.Close()
+	return false
+}
+
+func (r *batchRows) Close() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.Rows.Close()
+	r.results.Close()
+}
+
+// batchRow is the row of the last query of a batch, read as QueryRow
+// reads one: pgx.ErrNoRows without rows, the first one otherwise.
+type batchRow struct {
+	rows pgx.Rows
+	err  error
+}
+
+func (r batchRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	r.rows.Close()
+	return r.rows.Err()
 }
 
 // Close closes the connection pool.
 func (p *Pool) Close() {
-	if p.Pool != nil {
-		p.Pool.Close()
+	if pool := p.current(); pool != nil {
+		pool.Close()
 	}
 }
 
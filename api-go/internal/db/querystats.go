@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryStats adds up the queries made with a context, such as those of
+// one HTTP request, for its log line.
+type QueryStats struct {
+	count atomic.Int64
+	nanos atomic.Int64
+}
+
+// Count returns the number of queries made.
+func (s *QueryStats) Count() int64 {
+	return s.count.Load()
+}
+
+// Duration returns the time spent in them.
+func (s *QueryStats) Duration() time.Duration {
+	return time.Duration(s.nanos.Load())
+}
+
+type queryStatsKey struct{}
+
+type queryTraceKey struct{}
+
+// WithQueryStats returns a copy of ctx whose queries add up in the
+// returned stats.
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// queryTracer times the queries of contexts made by WithQueryStats and
+// records a client span for every query, named after its SQL command.
+type queryTracer struct{}
+
+// queryTrace is what TraceQueryStart hands to TraceQueryEnd.
+type queryTrace struct {
+	start time.Time
+	span  trace.Span
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	config := conn.Config()
+	ctx, span := tracing.Start(ctx, "postgresql "+sqlCommand(data.SQL), trace.SpanKindClient,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBNamespace(config.Database),
+		semconv.DBQueryText(data.SQL),
+		semconv.ServerAddress(config.Host),
+		semconv.ServerPort(int(config.Port)),
+	)
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{start: time.Now(), span: span})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+	tracing.End(qt.span, data.Err)
+	if stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats); stats != nil {
+		stats.count.Add(1)
+		stats.nanos.Add(int64(time.Since(qt.start)))
+	}
+}
+
+// sqlCommand returns the first keyword of sql in upper case, e.g. SELECT.
+func sqlCommand(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(strings.TrimRight(fields[0], ";("))
+}
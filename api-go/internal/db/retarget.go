@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locator finds the address of the current primary, e.g. from Patroni or
+// by discovering the replication topology.
+type Locator struct {
+	Name   string
+	Locate func(ctx context.Context) (host string, port int, err error)
+}
+
+// Retargetable is the pool a Retargeter moves; *Pool implements it.
+type Retargetable interface {
+	Addr() (string, int)
+	Retarget(ctx context.Context, host string, port int) error
+	ReadOnlyErrors() int64
+}
+
+// Retargeter moves the write pool to the new primary after a failover. It
+// runs when triggered, typically by the role watcher, and when the pool
+// has seen at least threshold read-only errors since the previous check.
+type Retargeter struct {
+	pool      Retargetable
+	locators  []Locator
+	threshold int64
+	interval  time.Duration
+
+	trigger chan string
+
+	mu        sync.Mutex
+	listeners []func(from, to, reason string)
+}
+
+// NewRetargeter creates a retargeter for pool. Locators are tried in
+// order until one finds a primary. A threshold of 0 disables retargeting
+// on read-only errors.
+func NewRetargeter(pool Retargetable, threshold int, interval time.Duration, locators ...Locator) *Retargeter {
+	return &Retargeter{
+		pool:      pool,
+		locators:  locators,
+		threshold: int64(threshold),
+		interval:  interval,
+		trigger:   make(chan string, 1),
+	}
+}
+
+// OnRetarget registers fn to be called after the pool moved to a new
+// primary, with both addresses as host:port.
+func (r *Retargeter) OnRetarget(fn func(from, to, reason string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Trigger asks for the primary to be located again. It does not block;
+// a trigger while one is pending is dropped.
+func (r *Retargeter) Trigger(reason string) {
+	select {
+	case r.trigger <- reason:
+	default:
+	}
+}
+
+// Start runs the retargeter until ctx is done. Without a pool or
+// locators there is nothing to do.
+func (r *Retargeter) Start(ctx context.Context) {
+	if r.pool == nil || len(r.locators) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		// The count is reset when the pool moves; errors seen before
+		// a failed attempt are not counted again
+		var seen int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case reason := <-r.trigger:
+				r.retarget(ctx, reason)
+			case <-ticker.C:
+				total := r.pool.ReadOnlyErrors()
+				if total < seen {
+					seen = 0
+				}
+				failed := total - seen
+				seen = total
+				if r.threshold > 0 && failed >= r.threshold {
+					r.retarget(ctx, fmt.Sprintf("%d statements failed with read-only errors", failed))
+				}
+			}
+		}
+	}()
+}
+
+// retarget locates the primary and moves the pool to it when it is not
+// already there.
+func (r *Retargeter) retarget(ctx context.Context, reason string) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval*time.Duration(len(r.locators)+1))
+	defer cancel()
+
+	host, port, err := r.locate(ctx)
+	if err != nil {
+		slog.Warn("Cannot locate the new primary", "reason", reason, "error", err)
+		return
+	}
+
+	oldHost, oldPort := r.pool.Addr()
+	from, to := joinHostPort(oldHost, oldPort), joinHostPort(host, port)
+	if from == to {
+		return
+	}
+
+	if err := r.pool.Retarget(ctx, host, port); err != nil {
+		slog.Warn("Failed to move the write pool", "from", from, "to", to, "error", err)
+		return
+	}
+	slog.Info("Write pool moved to the new primary", "from", from, "to", to, "reason", reason)
+
+	r.mu.Lock()
+	listeners := r.listeners
+	r.mu.Unlock()
+	for _, fn := range listeners {
+		fn(from, to, reason)
+	}
+}
+
+// locate asks each locator in turn.
+func (r *Retargeter) locate(ctx context.Context) (string, int, error) {
+	var errs []string
+	for _, l := range r.locators {
+		host, port, err := l.Locate(ctx)
+		if err == nil {
+			return host, port, nil
+		}
+		errs = append(errs, l.Name+": "+err.Error())
+	}
+	return "", 0, errors.New(strings.Join(errs, "; "))
+}
+
+func joinHostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,176 @@
+// Package dcs checks the health of the distributed configuration store
+// (etcd or Consul) that Patroni uses for leader election.
+package dcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported DCS types.
+const (
+	TypeEtcd   = "etcd"
+	TypeConsul = "consul"
+)
+
+// Endpoint is the result of checking one configured endpoint.
+type Endpoint struct {
+	URL     string
+	Healthy bool
+	Leader  string
+	Error   string
+	Latency time.Duration
+}
+
+// Status is the health of the whole store.
+//
+// Quorum is true when at least one endpoint reports a leader: etcd only
+// answers /health with true when the member can reach a quorum, and
+// Consul only knows a leader while a majority of servers agree. Healthy
+// additionally requires every configured endpoint to answer.
+type Status struct {
+	Type      string
+	Healthy   bool
+	Quorum    bool
+	Leader    string
+	Endpoints []Endpoint
+}
+
+// Client checks a set of DCS endpoints.
+type Client struct {
+	kind   string
+	urls   []string
+	client *http.Client
+}
+
+// New creates a client for the endpoints of a DCS of the given type, e.g.
+// http://10.0.1.10:2379 for etcd or http://10.0.1.10:8500 for Consul.
+func New(kind string, urls []string, timeout time.Duration) *Client {
+	var list []string
+	for _, u := range urls {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			list = append(list, u)
+		}
+	}
+	return &Client{
+		kind:   kind,
+		urls:   list,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Enabled reports whether any endpoint is configured.
+func (c *Client) Enabled() bool {
+	return c != nil && c.kind != "" && len(c.urls) > 0
+}
+
+// Check queries all endpoints concurrently.
+func (c *Client) Check(ctx context.Context) Status {
+	status := Status{Type: c.kind, Endpoints: make([]Endpoint, len(c.urls))}
+
+	var wg sync.WaitGroup
+	for i, u := range c.urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			status.Endpoints[i] = c.checkEndpoint(ctx, u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	status.Healthy = len(status.Endpoints) > 0
+	for _, e := range status.Endpoints {
+		if !e.Healthy {
+			status.Healthy = false
+			continue
+		}
+		status.Quorum = true
+		if status.Leader == "" {
+			status.Leader = e.Leader
+		}
+	}
+	return status
+}
+
+func (c *Client) checkEndpoint(ctx context.Context, base string) Endpoint {
+	endpoint := Endpoint{URL: base}
+	start := time.Now()
+	var err error
+	switch c.kind {
+	case TypeEtcd:
+		err = c.checkEtcd(ctx, base)
+	case TypeConsul:
+		endpoint.Leader, err = c.consulLeader(ctx, base)
+	default:
+		err = fmt.Errorf("unsupported DCS type %q", c.kind)
+	}
+	endpoint.Latency = time.Since(start)
+	if err != nil {
+		endpoint.Error = err.Error()
+		return endpoint
+	}
+	endpoint.Healthy = true
+	return endpoint
+}
+
+// checkEtcd reads /health, which reports false while the member has no
+// leader.
+func (c *Client) checkEtcd(ctx context.Context, base string) error {
+	var health struct {
+		Health string `json:"health"`
+		Reason string `json:"reason"`
+	}
+	if err := c.get(ctx, base+"/health", &health); err != nil {
+		return err
+	}
+	if health.Health != "true" {
+		if health.Reason != "" {
+			return fmt.Errorf("unhealthy: %s", health.Reason)
+		}
+		return fmt.Errorf("unhealthy")
+	}
+	return nil
+}
+
+// consulLeader reads /v1/status/leader, which is empty without quorum.
+func (c *Client) consulLeader(ctx context.Context, base string) (string, error) {
+	var leader string
+	if err := c.get(ctx, base+"/v1/status/leader", &leader); err != nil {
+		return "", err
+	}
+	if leader == "" {
+		return "", fmt.Errorf("no leader elected")
+	}
+	return leader, nil
+}
+
+func (c *Client) get(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// etcd answers /health with 503 and a JSON body when unhealthy
+	if jsonErr := json.Unmarshal(data, out); jsonErr != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		return jsonErr
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package etag formats and parses the entity tags of versioned resources.
+// A tag is the version of the resource, a counter bumped on every write,
+// in quotes.
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format returns the strong tag of version.
+func Format(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Versions returns the versions listed in an If-Match or If-None-Match
+// header. Unparsable tags are ignored, as are weak ones unless weak is
+// set: If-Match only compares strong tags.
+func Versions(header string, weak bool) []int64 {
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// Matches reports whether an If-None-Match header lists version or is *.
+func Matches(header string, version int64) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, v := range Versions(header, true) {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
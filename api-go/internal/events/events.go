@@ -0,0 +1,82 @@
+// Package events keeps a bounded, in-memory log of cluster events that
+// readers can page through or follow as new events are published.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/history"
+)
+
+// Event is one published event. IDs increase by one per event.
+type Event struct {
+	ID        int64
+	Type      string
+	Message   string
+	Data      map[string]interface{}
+	Timestamp time.Time
+}
+
+// Log keeps the most recent events and wakes up followers on publish.
+type Log struct {
+	buffer *history.Buffer[Event]
+
+	mu        sync.Mutex
+	next      int64
+	changed   chan struct{}
+	listeners []func(Event)
+}
+
+// NewLog creates a log keeping at most capacity events.
+func NewLog(capacity int) *Log {
+	return &Log{
+		buffer:  history.NewBuffer[Event](capacity),
+		next:    1,
+		changed: make(chan struct{}),
+	}
+}
+
+// OnPublish registers fn to be called with every published event. fn
+// runs on the publishing goroutine and must not block.
+func (l *Log) OnPublish(fn func(Event)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Publish records an event and returns it with its ID and timestamp.
+func (l *Log) Publish(eventType, message string, data map[string]interface{}) Event {
+	l.mu.Lock()
+	e := Event{
+		ID:        l.next,
+		Type:      eventType,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+	l.next++
+	l.buffer.Add(e)
+	close(l.changed)
+	l.changed = make(chan struct{})
+	listeners := l.listeners
+	l.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(e)
+	}
+	return e
+}
+
+// Since returns the retained events with an ID greater than after, oldest
+// first.
+func (l *Log) Since(after int64) []Event {
+	return l.buffer.Filter(func(e Event) bool { return e.ID > after })
+}
+
+// Wait returns a channel that is closed when the next event is published.
+func (l *Log) Wait() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.changed
+}
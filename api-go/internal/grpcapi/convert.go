@@ -0,0 +1,297 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	pghav1 "github.com/postgresql-ha-dr/api-go/proto/pgha/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The functions below convert the models of the REST API to their
+// messages, field by field.
+
+func healthMessage(h models.HealthResponse) *pghav1.Health {
+	return &pghav1.Health{
+		Status:        h.Status,
+		Version:       h.Version,
+		Commit:        h.Commit,
+		StartedAt:     timestamp(h.StartedAt),
+		UptimeSeconds: h.UptimeSeconds,
+		ServerVersion: h.ServerVersion,
+		Runtime: &pghav1.RuntimeInfo{
+			GoVersion:      h.Runtime.GoVersion,
+			Gomaxprocs:     int32(h.Runtime.GOMAXPROCS),
+			Goroutines:     int32(h.Runtime.Goroutines),
+			HeapAllocBytes: h.Runtime.HeapAllocBytes,
+			HeapSysBytes:   h.Runtime.HeapSysBytes,
+			NumGc:          h.Runtime.NumGC,
+		},
+		Timestamp: timestamp(h.Timestamp),
+	}
+}
+
+func metricsMessage(m *models.MetricsResponse) *pghav1.Metrics {
+	out := &pghav1.Metrics{
+		DatabaseSizeBytes:       m.DatabaseSizeBytes,
+		ActiveConnections:       int32(m.ActiveConnections),
+		MaxConnections:          int32(m.MaxConnections),
+		ConnectionUsagePercent:  m.ConnectionUsagePercent,
+		TransactionsCommitted:   m.TransactionsCommitted,
+		TransactionsRolledBack:  m.TransactionsRolledBack,
+		BlocksRead:              m.BlocksRead,
+		BlocksHit:               m.BlocksHit,
+		CacheHitRatio:           m.CacheHitRatio,
+		TempFiles:               m.TempFiles,
+		TempBytes:               m.TempBytes,
+		Deadlocks:               m.Deadlocks,
+		ChecksumFailures:        m.ChecksumFailures,
+		ReplicationLagBytes:     m.ReplicationLagBytes,
+		ReplicationDelaySeconds: m.ReplicationDelaySecs,
+		LastReplayTimestamp:     timestampPtr(m.LastReplayTimestamp),
+		IsInRecovery:            m.IsInRecovery,
+		Timestamp:               timestamp(m.Timestamp),
+	}
+	for _, r := range m.Replicas {
+		out.Replicas = append(out.Replicas, &pghav1.ReplicaLag{
+			ApplicationName:  r.ApplicationName,
+			ClientAddr:       r.ClientAddr,
+			State:            r.State,
+			SyncState:        r.SyncState,
+			ReplayLagBytes:   r.ReplayLagBytes,
+			WriteLagSeconds:  r.WriteLagSeconds,
+			FlushLagSeconds:  r.FlushLagSeconds,
+			ReplayLagSeconds: r.ReplayLagSeconds,
+		})
+	}
+	if p := m.Pool; p != nil {
+		out.Pool = &pghav1.PoolStats{
+			AcquiredConns:     p.AcquiredConns,
+			IdleConns:         p.IdleConns,
+			TotalConns:        p.TotalConns,
+			MaxConns:          p.MaxConns,
+			AcquireCount:      p.AcquireCount,
+			EmptyAcquireCount: p.EmptyAcquireCount,
+			AvgAcquireWaitMs:  p.AvgAcquireWaitMs,
+		}
+	}
+	return out
+}
+
+func itemMessage(item models.Item) *pghav1.Item {
+	return &pghav1.Item{
+		Id:          string(item.ID),
+		Name:        item.Name,
+		Description: item.Description,
+		Price:       string(item.Price),
+		IsActive:    item.IsActive,
+		CreatedAt:   timestamp(item.CreatedAt),
+		UpdatedAt:   timestamp(item.UpdatedAt),
+	}
+}
+
+func backupsMessage(b models.BackupsResponse) *pghav1.ListBackupsResponse {
+	out := &pghav1.ListBackupsResponse{
+		Status:    b.Status,
+		Timestamp: timestamp(b.Timestamp),
+	}
+	for _, s := range b.Stanzas {
+		out.Stanzas = append(out.Stanzas, backupStatusMessage(s))
+	}
+	return out
+}
+
+func backupStatusMessage(b models.BackupResponse) *pghav1.BackupStatus {
+	out := &pghav1.BackupStatus{
+		Provider:       b.Provider,
+		Stanza:         b.Stanza,
+		Status:         b.Status,
+		StatusMessage:  b.StatusMessage,
+		LastFullBackup: timestampPtr(b.LastFullBackup),
+		LastDiffBackup: timestampPtr(b.LastDiffBackup),
+		LastIncrBackup: timestampPtr(b.LastIncrBackup),
+		AgeSeconds:     b.AgeSeconds,
+		Timestamp:      timestamp(b.Timestamp),
+	}
+	for _, r := range b.Repositories {
+		out.Repositories = append(out.Repositories, &pghav1.Repository{
+			Key:              int32(r.Key),
+			Type:             r.Type,
+			Cipher:           r.Cipher,
+			Encrypted:        r.Encrypted,
+			Status:           r.Status,
+			StatusCode:       int32(r.StatusCode),
+			StatusMessage:    r.StatusMessage,
+			CompressType:     r.CompressType,
+			CompressLevel:    int32Ptr(r.CompressLevel),
+			BlockIncremental: r.BlockIncremental,
+			Bundle:           r.Bundle,
+		})
+	}
+	for _, backup := range b.Backups {
+		out.Backups = append(out.Backups, &pghav1.Backup{
+			Label:                backup.Label,
+			Type:                 backup.Type,
+			StartTime:            timestampPtr(backup.StartTime),
+			StopTime:             timestampPtr(backup.StopTime),
+			SizeBytes:            backup.SizeBytes,
+			DatabaseSizeBytes:    backup.DatabaseSizeBytes,
+			DeltaBytes:           backup.DeltaBytes,
+			RepositoryDeltaBytes: backup.RepositoryDeltaBytes,
+			StartLsn:             backup.StartLSN,
+			StopLsn:              backup.StopLSN,
+			StartWal:             backup.StartWAL,
+			StopWal:              backup.StopWAL,
+			Timeline:             backup.Timeline,
+			Prior:                backup.Prior,
+			Reference:            backup.Reference,
+			Error:                backup.Error,
+			Annotation:           backup.Annotation,
+			PgbackrestVersion:    backup.PgBackRestVersion,
+			RepoKey:              int32Ptr(backup.RepoKey),
+		})
+	}
+	if w := b.WALArchive; w != nil {
+		out.WalArchive = &pghav1.WALArchive{
+			MinWal:         w.MinWAL,
+			MaxWal:         w.MaxWAL,
+			CurrentWalFile: w.CurrentWALFile,
+			GapFiles:       w.GapFiles,
+			GapBytes:       w.GapBytes,
+		}
+	}
+	return out
+}
+
+func clusterMessage(c models.ClusterResponse) *pghav1.ClusterStatus {
+	out := &pghav1.ClusterStatus{
+		Manager:   c.Manager,
+		Status:    c.Status,
+		Leader:    c.Leader,
+		Paused:    c.Paused,
+		Timestamp: timestamp(c.Timestamp),
+	}
+	for _, m := range c.Members {
+		out.Members = append(out.Members, &pghav1.ClusterMember{
+			Name:          m.Name,
+			Role:          m.Role,
+			State:         m.State,
+			Host:          m.Host,
+			Port:          int32(m.Port),
+			ApiUrl:        m.APIURL,
+			Timeline:      int32(m.Timeline),
+			LagBytes:      m.LagBytes,
+			GroupId:       int32Ptr(m.GroupID),
+			AssignedState: m.AssignedState,
+			ReportedState: m.ReportedState,
+			Health:        m.Health,
+			ReportedAt:    timestampPtr(m.ReportedAt),
+		})
+	}
+	if d := c.DCS; d != nil {
+		out.Dcs = &pghav1.DCSStatus{
+			Type:    d.Type,
+			Healthy: d.Healthy,
+			Quorum:  d.Quorum,
+			Leader:  d.Leader,
+		}
+		for _, e := range d.Endpoints {
+			out.Dcs.Endpoints = append(out.Dcs.Endpoints, &pghav1.DCSEndpoint{
+				Url:       e.URL,
+				Healthy:   e.Healthy,
+				Error:     e.Error,
+				LatencyMs: e.LatencyMs,
+			})
+		}
+	}
+	if f := c.Formation; f != nil {
+		out.Formation = &pghav1.ClusterFormation{
+			Id:                 f.ID,
+			Kind:               f.Kind,
+			Dbname:             f.DBName,
+			Secondary:          f.Secondary,
+			NumberSyncStandbys: int32(f.NumberSyncStandbys),
+		}
+	}
+	return out
+}
+
+func jobMessage(j models.Job) (*pghav1.Job, error) {
+	out := &pghav1.Job{
+		Id:              j.ID,
+		Type:            j.Type,
+		Status:          j.Status,
+		Message:         j.Message,
+		Error:           j.Error,
+		CreatedAt:       timestamp(j.CreatedAt),
+		StartedAt:       timestampPtr(j.StartedAt),
+		FinishedAt:      timestampPtr(j.FinishedAt),
+		DurationSeconds: j.DurationSeconds,
+	}
+	if j.Params != nil {
+		out.Params = &structpb.Struct{}
+		if err := jsonMessage(j.Params, out.Params); err != nil {
+			return nil, err
+		}
+	}
+	if j.Result != nil {
+		out.Result = &structpb.Value{}
+		if err := jsonMessage(j.Result, out.Result); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func eventMessage(e events.Event) (*pghav1.ClusterEvent, error) {
+	out := &pghav1.ClusterEvent{
+		Id:        e.ID,
+		Type:      e.Type,
+		Message:   e.Message,
+		Timestamp: timestamp(e.Timestamp),
+	}
+	if e.Data != nil {
+		out.Data = &structpb.Struct{}
+		if err := jsonMessage(e.Data, out.Data); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// jsonMessage sets m, a Struct or Value, to v as the REST API encodes it,
+// so results of any type come out as they do in JSON.
+func jsonMessage(v interface{}, m proto.Message) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(b, m)
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
+
+func int32Ptr(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	v := int32(*n)
+	return &v
+}
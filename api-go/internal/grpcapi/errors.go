@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain qualifies the reasons of ErrorInfo details.
+const errorDomain = "pgha.v1"
+
+// httpCodes maps the statuses of handler errors to gRPC codes.
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusBadGateway:          codes.Unavailable,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// toStatus converts an error of a handler to a gRPC status. The error
+// code of the REST API is the reason of an ErrorInfo detail, and the
+// failures of a 412 are the violations of a PreconditionFailure.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	var e *handlers.Error
+	if !errors.As(err, &e) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := httpCodes[e.Status]
+	if !ok {
+		code = codes.Unknown
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: e.Code, Domain: errorDomain}}
+	if e.Failures != nil {
+		failure := &errdetails.PreconditionFailure{}
+		for _, f := range e.Failures {
+			failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        e.Code,
+				Description: f,
+			})
+		}
+		details = append(details, failure)
+	}
+	st := status.New(code, e.Message)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
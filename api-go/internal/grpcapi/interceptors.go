@@ -0,0 +1,243 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	pghav1 "github.com/postgresql-ha-dr/api-go/proto/pgha/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// restRoute is a method and path of the REST API.
+type restRoute struct {
+	method string
+	path   string
+}
+
+// apiServicePrefix starts the full method of every call of the API.
+const apiServicePrefix = "/pgha.v1."
+
+// routes maps each call to the REST route it mirrors, whose access rule
+// applies to it. Calls of other services, such as health checks and
+// reflection, are open; calls of the API missing here are denied, so a
+// new one is not left open by mistake.
+var routes = map[string]restRoute{
+	pghav1.MonitoringService_GetHealth_FullMethodName:    {http.MethodGet, "/health"},
+	pghav1.MonitoringService_GetMetrics_FullMethodName:   {http.MethodGet, "/metrics"},
+	pghav1.MonitoringService_WatchMetrics_FullMethodName: {http.MethodGet, "/metrics"},
+	pghav1.ItemsService_GetItem_FullMethodName:           {http.MethodGet, "/items/:id"},
+	pghav1.ItemsService_ListItems_FullMethodName:         {http.MethodGet, "/items/export"},
+	pghav1.BackupsService_ListBackups_FullMethodName:     {http.MethodGet, "/backups"},
+	pghav1.BackupsService_GetBackupStatus_FullMethodName: {http.MethodGet, "/backups/:stanza"},
+	pghav1.ClusterService_GetCluster_FullMethodName:      {http.MethodGet, "/cluster"},
+	pghav1.ClusterService_Switchover_FullMethodName:      {http.MethodPost, "/cluster/switchover"},
+	pghav1.ClusterService_Failover_FullMethodName:        {http.MethodPost, "/cluster/failover"},
+	pghav1.ClusterService_GetJob_FullMethodName:          {http.MethodGet, "/jobs/:id"},
+	pghav1.ClusterService_WatchEvents_FullMethodName:     {http.MethodGet, "/events"},
+}
+
+// interceptors do for each call what the middleware of the REST API does
+// for each request: give it an ID, trace it, authorize it, log it and
+// recover from a panic of its handler.
+type interceptors struct {
+	logger *slog.Logger
+	auth   *middleware.Authenticator
+	role   func(method, path string) string
+}
+
+func (i *interceptors) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ctx, done := i.start(ctx, info.FullMethod)
+	defer func() { done(err) }()
+	defer i.recoverPanic(ctx, info.FullMethod, &err)
+
+	if err := i.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	resp, err = handler(ctx, req)
+	return resp, toStatus(err)
+}
+
+func (i *interceptors) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, done := i.start(ss.Context(), info.FullMethod)
+	defer func() { done(err) }()
+	defer i.recoverPanic(ctx, info.FullMethod, &err)
+
+	if err := i.authorize(ctx, info.FullMethod); err != nil {
+		return err
+	}
+	return toStatus(handler(srv, &serverStream{ServerStream: ss, ctx: ctx}))
+}
+
+// start sets up the context of a call: its request ID, taken from the
+// x-request-id metadata when valid and sent back in the header, its
+// server span, continuing the trace of the caller, and the count of its
+// queries. The returned function ends the span and logs the call.
+func (i *interceptors) start(ctx context.Context, fullMethod string) (context.Context, func(error)) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md, strings.ToLower(requestid.Header))
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	ctx = requestid.NewContext(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestid.Header), id))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, span := tracing.Start(ctx, service+"/"+method, trace.SpanKindServer,
+		semconv.RPCSystemGRPC,
+		semconv.RPCService(service),
+		semconv.RPCMethod(method),
+		attribute.String("request_id", id),
+	)
+	ctx, stats := db.WithQueryStats(ctx)
+
+	return ctx, func(err error) {
+		code := status.Code(err)
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		// As for HTTP, only server errors mark the span failed
+		level := slog.LevelInfo
+		if serverError(code) {
+			level = slog.LevelError
+			tracing.End(span, err)
+		} else {
+			if code != codes.OK && code != codes.Canceled {
+				level = slog.LevelWarn
+			}
+			tracing.End(span, nil)
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", fullMethod),
+			slog.String("code", code.String()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("db_queries", stats.Count()),
+			slog.Float64("db_ms", float64(stats.Duration().Microseconds())/1000),
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			attrs = append(attrs, slog.String("client_addr", p.Addr.String()))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+		}
+		i.logger.LogAttrs(ctx, level, "grpc call", attrs...)
+	}
+}
+
+// serverError reports whether code blames the server rather than the
+// caller.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// authorize applies the access rule of the REST route a call mirrors.
+// The API key is read from the x-api-key metadata or a Bearer token in
+// authorization, as the headers of a request are.
+func (i *interceptors) authorize(ctx context.Context, fullMethod string) error {
+	route, ok := routes[fullMethod]
+	if !ok {
+		if strings.HasPrefix(fullMethod, apiServicePrefix) {
+			return status.Errorf(codes.PermissionDenied, "%s has no access rule", fullMethod)
+		}
+		return nil
+	}
+	role := i.role(route.method, route.path)
+	if role == "" {
+		return nil
+	}
+
+	if !i.auth.Enabled() {
+		return status.Error(codes.PermissionDenied,
+			"Admin API is disabled; set ADMIN_API_KEY, ADMIN_API_KEYS or ADMIN_API_KEYS_FILE to enable it")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	provided := first(md, "x-api-key")
+	if provided == "" {
+		if auth := first(md, "authorization"); strings.HasPrefix(auth, "Bearer ") {
+			provided = strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	key, ok := i.auth.Match(provided)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "A valid API key is required")
+	}
+	if !config.RoleAtLeast(key.Role, role) {
+		return status.Errorf(codes.PermissionDenied, "API key %q has the %s role; %s requires %s",
+			key.Name, key.Role, fullMethod, role)
+	}
+
+	if route.method != http.MethodGet {
+		slog.InfoContext(ctx, "API key authorized", "method", fullMethod, "key", key.Name, "role", key.Role)
+	}
+	return nil
+}
+
+// recoverPanic turns a panic of the handler into an Internal error and
+// logs it with its stack.
+func (i *interceptors) recoverPanic(ctx context.Context, fullMethod string, err *error) {
+	if r := recover(); r != nil {
+		i.logger.ErrorContext(ctx, "panic serving grpc call",
+			"error", r, "method", fullMethod, "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, fmt.Sprint(r))
+	}
+}
+
+// serverStream is a stream with the context set up for its call.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// first returns the first value of key in md, or "".
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// metadataCarrier reads the trace context from incoming metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return first(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
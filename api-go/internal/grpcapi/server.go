@@ -0,0 +1,113 @@
+// Package grpcapi serves the gRPC API defined in proto/pgha/v1: health,
+// metrics, items, backups and cluster operations for clients that prefer
+// typed calls and streams to JSON. Calls are answered by the handlers of
+// the REST API and follow the access rules of the routes they mirror.
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	pghav1 "github.com/postgresql-ha-dr/api-go/proto/pgha/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Services are the handlers the calls are answered by.
+type Services struct {
+	Health  *handlers.HealthHandler
+	Metrics *handlers.MetricsHandler
+	Items   *handlers.ItemsHandler
+	Backups *handlers.BackupsHandler
+	Cluster *handlers.ClusterHandler
+	Jobs    *handlers.JobsHandler
+	Events  *events.Log
+}
+
+// Server is the gRPC server. Besides the API it serves the standard
+// grpc.health.v1 service, for load balancer and Kubernetes probes, and
+// reflection, for tools such as grpcurl.
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	// stopping is closed on Shutdown to end the streams
+	stopping chan struct{}
+}
+
+// NewServer creates the server. keys and role authorize calls as the REST
+// API authorizes the routes they mirror: role returns the API key role a
+// route requires, or "" when it is open. The server uses the TLS
+// certificate of the REST API when one is configured.
+func NewServer(cfg *config.Config, logger *slog.Logger, keys []config.APIKey, role func(method, path string) string, services Services) (*Server, error) {
+	interceptors := &interceptors{
+		logger: logger,
+		auth:   middleware.NewAuthenticator(keys),
+		role:   role,
+	}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptors.unary),
+		grpc.ChainStreamInterceptor(interceptors.stream),
+	}
+	if cfg.Server.TLSEnabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	s := &Server{
+		grpc:     grpc.NewServer(opts...),
+		health:   health.NewServer(),
+		stopping: make(chan struct{}),
+	}
+	pghav1.RegisterMonitoringServiceServer(s.grpc, &monitoringService{
+		health:          services.Health,
+		metrics:         services.Metrics,
+		defaultInterval: cfg.Metrics.HistoryInterval,
+		stopping:        s.stopping,
+	})
+	pghav1.RegisterItemsServiceServer(s.grpc, &itemsService{items: services.Items})
+	pghav1.RegisterBackupsServiceServer(s.grpc, &backupsService{backups: services.Backups})
+	pghav1.RegisterClusterServiceServer(s.grpc, &clusterService{
+		cluster:  services.Cluster,
+		jobs:     services.Jobs,
+		events:   services.Events,
+		stopping: s.stopping,
+	})
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	return s, nil
+}
+
+// Serve accepts connections on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown reports the server as not serving, ends the streams and waits
+// for the calls in progress to finish, cancelling those still running
+// when ctx is done.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+	close(s.stopping)
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
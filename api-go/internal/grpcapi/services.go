@@ -0,0 +1,196 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	pghav1 "github.com/postgresql-ha-dr/api-go/proto/pgha/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// minWatchInterval bounds how often WatchMetrics sends a snapshot.
+const minWatchInterval = time.Second
+
+// errStopping ends the streams of a server shutting down, for clients to
+// reconnect elsewhere.
+var errStopping = status.Error(codes.Unavailable, "server is shutting down")
+
+type monitoringService struct {
+	pghav1.UnimplementedMonitoringServiceServer
+	health          *handlers.HealthHandler
+	metrics         *handlers.MetricsHandler
+	defaultInterval time.Duration
+	stopping        <-chan struct{}
+}
+
+func (s *monitoringService) GetHealth(ctx context.Context, _ *pghav1.GetHealthRequest) (*pghav1.Health, error) {
+	return healthMessage(s.health.Check(ctx)), nil
+}
+
+func (s *monitoringService) GetMetrics(ctx context.Context, _ *pghav1.GetMetricsRequest) (*pghav1.Metrics, error) {
+	metrics, err := s.metrics.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return metricsMessage(metrics), nil
+}
+
+// WatchMetrics sends a snapshot on every interval. A failed collection
+// ends the stream, as the client would otherwise keep waiting without
+// knowing why.
+func (s *monitoringService) WatchMetrics(req *pghav1.WatchMetricsRequest, stream pghav1.MonitoringService_WatchMetricsServer) error {
+	interval := s.defaultInterval
+	if req.Interval != nil {
+		if err := req.Interval.CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "interval: %v", err)
+		}
+		interval = req.Interval.AsDuration()
+	}
+	if interval < minWatchInterval {
+		interval = minWatchInterval
+	}
+
+	ctx := stream.Context()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		metrics, err := s.metrics.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(metricsMessage(metrics)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stopping:
+			return errStopping
+		case <-ticker.C:
+		}
+	}
+}
+
+type itemsService struct {
+	pghav1.UnimplementedItemsServiceServer
+	items *handlers.ItemsHandler
+}
+
+func (s *itemsService) GetItem(ctx context.Context, req *pghav1.GetItemRequest) (*pghav1.Item, error) {
+	item, err := s.items.Find(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return itemMessage(item), nil
+}
+
+func (s *itemsService) ListItems(req *pghav1.ListItemsRequest, stream pghav1.ItemsService_ListItemsServer) error {
+	if req.Limit < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	return s.items.Each(stream.Context(), req.AfterId, int(req.Limit), req.ActiveOnly, func(item models.Item) error {
+		return stream.Send(itemMessage(item))
+	})
+}
+
+type backupsService struct {
+	pghav1.UnimplementedBackupsServiceServer
+	backups *handlers.BackupsHandler
+}
+
+func (s *backupsService) ListBackups(ctx context.Context, _ *pghav1.ListBackupsRequest) (*pghav1.ListBackupsResponse, error) {
+	report, _ := s.backups.Report(ctx)
+	return backupsMessage(report), nil
+}
+
+func (s *backupsService) GetBackupStatus(ctx context.Context, req *pghav1.GetBackupStatusRequest) (*pghav1.BackupStatus, error) {
+	report, _, err := s.backups.StanzaReport(ctx, req.Stanza)
+	if err != nil {
+		return nil, err
+	}
+	return backupStatusMessage(report), nil
+}
+
+type clusterService struct {
+	pghav1.UnimplementedClusterServiceServer
+	cluster  *handlers.ClusterHandler
+	jobs     *handlers.JobsHandler
+	events   *events.Log
+	stopping <-chan struct{}
+}
+
+func (s *clusterService) GetCluster(ctx context.Context, _ *pghav1.GetClusterRequest) (*pghav1.ClusterStatus, error) {
+	state, err := s.cluster.State(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return clusterMessage(state), nil
+}
+
+func (s *clusterService) Switchover(ctx context.Context, req *pghav1.SwitchoverRequest) (*pghav1.Job, error) {
+	job, err := s.cluster.RequestSwitchover(ctx, models.SwitchoverRequest{
+		Leader:    req.Leader,
+		Candidate: req.Candidate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Switchover requested", "leader", job.Params["leader"], "job_id", job.ID)
+	return jobMessage(job)
+}
+
+func (s *clusterService) Failover(ctx context.Context, req *pghav1.FailoverRequest) (*pghav1.Job, error) {
+	job, err := s.cluster.RequestFailover(ctx, models.FailoverRequest{
+		Candidate: req.Candidate,
+		Force:     req.Force,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Failover requested", "candidate", req.Candidate, "force", req.Force, "job_id", job.ID)
+	return jobMessage(job)
+}
+
+func (s *clusterService) GetJob(_ context.Context, req *pghav1.GetJobRequest) (*pghav1.Job, error) {
+	job, err := s.jobs.Job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return jobMessage(job)
+}
+
+// WatchEvents follows the event log as GET /events?follow=true does.
+func (s *clusterService) WatchEvents(req *pghav1.WatchEventsRequest, stream pghav1.ClusterService_WatchEventsServer) error {
+	ctx := stream.Context()
+	after := req.AfterId
+	for {
+		wait := s.events.Wait()
+		for _, e := range s.events.Since(after) {
+			after = e.ID
+			if req.Type != "" && e.Type != req.Type {
+				continue
+			}
+			msg, err := eventMessage(e)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stopping:
+			return errStopping
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/alerts"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+// Alert rule names.
+const (
+	RuleMetricsUnavailable    = "metrics_unavailable"
+	RuleReplicationLagBytes   = "replication_lag_bytes"
+	RuleReplicationLagSeconds = "replication_lag_seconds"
+	RuleCacheHitRatio         = "cache_hit_ratio"
+	RuleConnectionUsage       = "connection_usage"
+	RuleBackupAge             = "backup_age"
+	RuleWALArchiveGap         = "wal_archive_gap"
+	RuleSyncStandbys          = "sync_standbys"
+	RuleProblematicSessions   = "problematic_sessions"
+	RuleWorkerStalled         = "worker_stalled"
+)
+
+// Events published when a replication lag rule starts or stops firing.
+const (
+	EventLagThresholdExceeded  = "lag_threshold_exceeded"
+	EventLagThresholdRecovered = "lag_threshold_recovered"
+)
+
+// AlertsHandler evaluates the configured thresholds in the background and
+// serves the currently breached conditions.
+type AlertsHandler struct {
+	cfg     *config.Config
+	metrics *MetricsHandler
+	backups *BackupsHandler
+	tracker *alerts.Tracker
+	events  *events.Log
+	workers *workers.Registry
+	worker  *workers.Worker
+}
+
+// NewAlertsHandler creates a new alerts handler. Metrics are collected
+// through the metrics handler and backup status is taken from the backups
+// handler's cache.
+func NewAlertsHandler(cfg *config.Config, metrics *MetricsHandler, backups *BackupsHandler) *AlertsHandler {
+	return &AlertsHandler{
+		cfg:     cfg,
+		metrics: metrics,
+		backups: backups,
+		tracker: alerts.NewTracker(),
+	}
+}
+
+// UseEvents has replication lag breaches and their recovery published to
+// log alongside the watcher's cluster events.
+func (h *AlertsHandler) UseEvents(log *events.Log) {
+	h.events = log
+}
+
+// UseWorkers records the evaluations as runs of the alerts worker in
+// registry and raises an alert while any worker in it is stalled. Call it
+// before Start.
+func (h *AlertsHandler) UseWorkers(registry *workers.Registry) {
+	h.workers = registry
+}
+
+// Start evaluates the thresholds immediately and then on the configured
+// interval until ctx is done.
+func (h *AlertsHandler) Start(ctx context.Context) {
+	h.worker = h.workers.Register(WorkerAlerts, h.cfg.Alerts.EvaluationInterval)
+	go func() {
+		h.evaluate(ctx)
+
+		ticker := time.NewTicker(h.cfg.Alerts.EvaluationInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.evaluate(ctx)
+			}
+		}
+	}()
+}
+
+// evaluate checks every rule once and updates the tracker.
+func (h *AlertsHandler) evaluate(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Alerts.EvaluationInterval)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { h.worker.Record(start, nil) }()
+
+	var breaches []alerts.Breach
+
+	if h.metrics.pool == nil {
+		breaches = append(breaches, alerts.Breach{
+			Rule:     RuleMetricsUnavailable,
+			Severity: alerts.SeverityCritical,
+			Message:  "Database connection not available",
+		})
+	} else if metrics, err := h.metrics.collect(ctx); err != nil {
+		breaches = append(breaches, alerts.Breach{
+			Rule:     RuleMetricsUnavailable,
+			Severity: alerts.SeverityCritical,
+			Message:  fmt.Sprintf("Failed to collect metrics: %v", err),
+		})
+	} else {
+		breaches = append(breaches, evaluateMetrics(h.cfg.Alerts, metrics)...)
+		if sync, err := querySyncReplication(ctx, h.metrics.pool); err == nil {
+			if b := evaluateSyncStandbys(sync); b != nil {
+				breaches = append(breaches, *b)
+			}
+		}
+		if h.cfg.Alerts.ProblematicSessions {
+			sessions, err := queryProblematicSessions(ctx, h.metrics.pool,
+				h.cfg.Sessions.MaxQueryAge, h.cfg.Sessions.MaxIdleInTransactionAge)
+			if err == nil {
+				if b := evaluateSessions(sessions); b != nil {
+					breaches = append(breaches, *b)
+				}
+			}
+		}
+	}
+
+	backups := h.backups.Status(ctx, h.backups.DefaultTarget())
+	h.backups.addArchiveGap(ctx, &backups)
+	if b := evaluateBackups(h.cfg.Alerts, backups, time.Now()); b != nil {
+		breaches = append(breaches, *b)
+	}
+	if b := evaluateArchiveGap(h.cfg.Alerts, backups); b != nil {
+		breaches = append(breaches, *b)
+	}
+	if backups.Status != "not_installed" {
+		times := backupTimes{
+			Full: backups.LastFullBackup,
+			Diff: backups.LastDiffBackup,
+			Incr: backups.LastIncrBackup,
+		}
+		if h.metrics.pool != nil {
+			if last, err := lastArchivedTime(ctx, h.metrics.pool); err == nil {
+				times.WALArchived = last
+			}
+		}
+		breaches = append(breaches, evaluateBackupSLA(h.cfg.Backup.SLA, times, time.Now())...)
+	}
+
+	if b := evaluateWorkers(h.workers.Status(time.Now())); b != nil {
+		breaches = append(breaches, *b)
+	}
+
+	fired, resolved := h.tracker.Update(breaches)
+	h.publishLag(fired, resolved)
+}
+
+// publishLag publishes the replication lag alerts that changed state.
+func (h *AlertsHandler) publishLag(fired, resolved []alerts.Alert) {
+	if h.events == nil {
+		return
+	}
+	isLag := func(rule string) bool {
+		return rule == RuleReplicationLagBytes || rule == RuleReplicationLagSeconds
+	}
+	for _, a := range fired {
+		if isLag(a.Rule) {
+			h.events.Publish(EventLagThresholdExceeded, a.Message, alertData(a))
+		}
+	}
+	for _, a := range resolved {
+		if isLag(a.Rule) {
+			h.events.Publish(EventLagThresholdRecovered, "Replication lag is back within threshold ("+a.Rule+")", alertData(a))
+		}
+	}
+}
+
+func alertData(a alerts.Alert) map[string]interface{} {
+	data := map[string]interface{}{
+		"rule":      a.Rule,
+		"severity":  a.Severity,
+		"threshold": a.Threshold,
+		"since":     a.Since,
+	}
+	if a.Value != nil {
+		data["value"] = *a.Value
+	}
+	return data
+}
+
+// evaluateMetrics checks the metric-based thresholds. On a standby the
+// local replay lag is used; on a primary the worst standby is used.
+func evaluateMetrics(cfg config.AlertsConfig, m *models.MetricsResponse) []alerts.Breach {
+	var breaches []alerts.Breach
+
+	lagBytes, lagSeconds := m.ReplicationLagBytes, m.ReplicationDelaySecs
+	for _, r := range m.Replicas {
+		if r.ReplayLagBytes != nil && (lagBytes == nil || *r.ReplayLagBytes > *lagBytes) {
+			lagBytes = r.ReplayLagBytes
+		}
+		if r.ReplayLagSeconds != nil && (lagSeconds == nil || *r.ReplayLagSeconds > *lagSeconds) {
+			lagSeconds = r.ReplayLagSeconds
+		}
+	}
+
+	if cfg.MaxReplicationLagBytes > 0 && lagBytes != nil && *lagBytes > cfg.MaxReplicationLagBytes {
+		breaches = append(breaches, alerts.Breach{
+			Rule:      RuleReplicationLagBytes,
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Replication lag %d bytes exceeds %d", *lagBytes, cfg.MaxReplicationLagBytes),
+			Value:     floatPtr(float64(*lagBytes)),
+			Threshold: float64(cfg.MaxReplicationLagBytes),
+		})
+	}
+
+	if cfg.MaxReplicationLagSeconds > 0 && lagSeconds != nil && *lagSeconds > cfg.MaxReplicationLagSeconds {
+		breaches = append(breaches, alerts.Breach{
+			Rule:      RuleReplicationLagSeconds,
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Replication lag %.1fs exceeds %.1fs", *lagSeconds, cfg.MaxReplicationLagSeconds),
+			Value:     floatPtr(*lagSeconds),
+			Threshold: cfg.MaxReplicationLagSeconds,
+		})
+	}
+
+	if cfg.MinCacheHitRatio > 0 && m.CacheHitRatio < cfg.MinCacheHitRatio {
+		breaches = append(breaches, alerts.Breach{
+			Rule:      RuleCacheHitRatio,
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Cache hit ratio %.2f%% is below %.2f%%", m.CacheHitRatio, cfg.MinCacheHitRatio),
+			Value:     floatPtr(m.CacheHitRatio),
+			Threshold: cfg.MinCacheHitRatio,
+		})
+	}
+
+	if cfg.MaxConnectionUsagePercent > 0 && m.ConnectionUsagePercent > cfg.MaxConnectionUsagePercent {
+		breaches = append(breaches, alerts.Breach{
+			Rule:      RuleConnectionUsage,
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Connection usage %.2f%% exceeds %.2f%%", m.ConnectionUsagePercent, cfg.MaxConnectionUsagePercent),
+			Value:     floatPtr(m.ConnectionUsagePercent),
+			Threshold: cfg.MaxConnectionUsagePercent,
+		})
+	}
+
+	return breaches
+}
+
+// evaluateBackups checks the age of the most recent full or differential
+// backup. Nothing is reported when pgbackrest is not installed.
+func evaluateBackups(cfg config.AlertsConfig, backups models.BackupResponse, now time.Time) *alerts.Breach {
+	if cfg.MaxBackupAge <= 0 || backups.Status == "not_installed" {
+		return nil
+	}
+
+	latest := backups.LastFullBackup
+	if backups.LastDiffBackup != nil && (latest == nil || backups.LastDiffBackup.After(*latest)) {
+		latest = backups.LastDiffBackup
+	}
+
+	threshold := cfg.MaxBackupAge.Seconds()
+	if latest == nil {
+		return &alerts.Breach{
+			Rule:      RuleBackupAge,
+			Severity:  alerts.SeverityCritical,
+			Message:   fmt.Sprintf("No completed backup found (status %s)", backups.Status),
+			Threshold: threshold,
+		}
+	}
+
+	age := now.Sub(*latest)
+	if age <= cfg.MaxBackupAge {
+		return nil
+	}
+	return &alerts.Breach{
+		Rule:      RuleBackupAge,
+		Severity:  alerts.SeverityWarning,
+		Message:   fmt.Sprintf("Last backup is %s old, exceeds %s", age.Round(time.Minute), cfg.MaxBackupAge),
+		Value:     floatPtr(age.Seconds()),
+		Threshold: threshold,
+	}
+}
+
+// evaluateSyncStandbys checks that the primary has as many synchronous
+// standbys streaming as synchronous_standby_names requires. It is critical
+// when commits hang as a result.
+func evaluateSyncStandbys(sync *models.SyncReplicationResponse) *alerts.Breach {
+	if sync.Status != SyncStatusUnsatisfied {
+		return nil
+	}
+	severity := alerts.SeverityWarning
+	if sync.WritesMayHang {
+		severity = alerts.SeverityCritical
+	}
+	return &alerts.Breach{
+		Rule:      RuleSyncStandbys,
+		Severity:  severity,
+		Message:   "Synchronous replication unsatisfied: " + sync.Message,
+		Value:     floatPtr(float64(sync.Connected)),
+		Threshold: float64(sync.Required),
+	}
+}
+
+// evaluateSessions flags the sessions of the problematic session report.
+// Each one holds back vacuum and may hold locks, so a single one breaches.
+func evaluateSessions(sessions []models.ProblematicSession) *alerts.Breach {
+	if len(sessions) == 0 {
+		return nil
+	}
+	queries, idle := 0, 0
+	for _, s := range sessions {
+		if s.Reason == "long_running_query" {
+			queries++
+		} else {
+			idle++
+		}
+	}
+	return &alerts.Breach{
+		Rule:     RuleProblematicSessions,
+		Severity: alerts.SeverityWarning,
+		Message: fmt.Sprintf("%d long-running queries and %d sessions idle in transaction; see /sessions/problematic",
+			queries, idle),
+		Value: floatPtr(float64(len(sessions))),
+	}
+}
+
+// evaluateWorkers breaches when a background worker has not finished a
+// run in time, which would otherwise leave its data silently stale.
+func evaluateWorkers(statuses []workers.Status) *alerts.Breach {
+	var stalled []string
+	for _, s := range statuses {
+		if s.Stalled {
+			stalled = append(stalled, s.Name)
+		}
+	}
+	if len(stalled) == 0 {
+		return nil
+	}
+	return &alerts.Breach{
+		Rule:     RuleWorkerStalled,
+		Severity: alerts.SeverityCritical,
+		Message:  fmt.Sprintf("Background workers stalled: %s; see /admin/workers", strings.Join(stalled, ", ")),
+		Value:    floatPtr(float64(len(stalled))),
+	}
+}
+
+// evaluateArchiveGap checks how many WAL segments the primary has written
+// beyond the newest segment in the backup repository.
+func evaluateArchiveGap(cfg config.AlertsConfig, backups models.BackupResponse) *alerts.Breach {
+	if cfg.MaxWALArchiveGapFiles <= 0 || backups.WALArchive == nil || backups.WALArchive.GapFiles == nil {
+		return nil
+	}
+
+	gap := *backups.WALArchive.GapFiles
+	if gap <= cfg.MaxWALArchiveGapFiles {
+		return nil
+	}
+	return &alerts.Breach{
+		Rule:      RuleWALArchiveGap,
+		Severity:  alerts.SeverityCritical,
+		Message:   fmt.Sprintf("WAL archive is %d segments behind the primary, exceeds %d", gap, cfg.MaxWALArchiveGapFiles),
+		Value:     floatPtr(float64(gap)),
+		Threshold: float64(cfg.MaxWALArchiveGapFiles),
+	}
+}
+
+// Alerts handles GET /alerts - currently breached thresholds.
+func (h *AlertsHandler) Alerts(c *gin.Context) {
+	now := time.Now().UTC()
+	active := h.tracker.Active()
+
+	response := models.AlertsResponse{
+		Alerts:    make([]models.Alert, 0, len(active)),
+		Count:     len(active),
+		Timestamp: now,
+	}
+	for _, a := range active {
+		response.Alerts = append(response.Alerts, models.Alert{
+			Rule:            a.Rule,
+			Severity:        a.Severity,
+			Message:         a.Message,
+			Value:           a.Value,
+			Threshold:       a.Threshold,
+			Since:           a.Since,
+			DurationSeconds: now.Sub(a.Since).Seconds(),
+		})
+	}
+	if evaluated := h.tracker.LastEvaluated(); !evaluated.IsZero() {
+		response.LastEvaluated = &evaluated
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
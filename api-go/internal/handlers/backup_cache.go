@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// backupCache holds the last backup info result per stanza.
+type backupCache struct {
+	cfg      config.BackupConfig
+	provider backup.Provider
+	history  *backup.History
+
+	mu      sync.RWMutex
+	entries map[string]models.BackupResponse
+
+	// fetchMu lets only one on-demand fetch per stanza run at a time.
+	fetchMu sync.Map
+}
+
+func newBackupCache(cfg config.BackupConfig, provider backup.Provider, history *backup.History) *backupCache {
+	return &backupCache{cfg: cfg, provider: provider, history: history, entries: make(map[string]models.BackupResponse)}
+}
+
+// get returns the cached status for stanza if it is younger than maxAge.
+func (bc *backupCache) get(stanza string, maxAge time.Duration) (models.BackupResponse, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	status, ok := bc.entries[stanza]
+	if !ok || time.Since(status.Timestamp) > maxAge {
+		return models.BackupResponse{}, false
+	}
+	return status, true
+}
+
+// lock serializes fetches of stanza so pgbackrest info never runs twice
+// concurrently for the same stanza.
+func (bc *backupCache) lock(stanza string) func() {
+	mu, _ := bc.fetchMu.LoadOrStore(stanza, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// refresh runs pgbackrest info for stanza and stores the result.
+func (bc *backupCache) refresh(ctx context.Context, stanza string) models.BackupResponse {
+	defer bc.lock(stanza)()
+	return bc.fetch(ctx, stanza)
+}
+
+// fetch runs backup info, stores the result and records new backups in
+// the history. The caller holds the stanza lock.
+func (bc *backupCache) fetch(ctx context.Context, stanza string) models.BackupResponse {
+	status := bc.provider.Info(ctx, stanza)
+	if status.Status == "ok" {
+		if err := bc.history.Record(stanza, status.Backups); err != nil {
+			slog.WarnContext(ctx, "Failed to save backup history", "stanza", stanza, "error", err)
+		}
+	}
+
+	bc.mu.Lock()
+	bc.entries[stanza] = status
+	bc.mu.Unlock()
+
+	return status
+}
+
+// status returns the cached status when fresh, otherwise fetches it. The
+// second return value reports whether the cache was used.
+func (bc *backupCache) status(ctx context.Context, stanza string) (models.BackupResponse, bool) {
+	maxAge := bc.cfg.CacheMaxAge
+	if status, ok := bc.get(stanza, maxAge); ok {
+		return status, true
+	}
+
+	defer bc.lock(stanza)()
+
+	// Another request may have refreshed the stanza while we waited.
+	if status, ok := bc.get(stanza, maxAge); ok {
+		return status, true
+	}
+	return bc.fetch(ctx, stanza), false
+}
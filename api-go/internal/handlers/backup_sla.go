@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/alerts"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Backup SLA alert rule names.
+const (
+	RuleBackupSLAFull       = "backup_sla_full"
+	RuleBackupSLADiff       = "backup_sla_diff"
+	RuleBackupSLAIncr       = "backup_sla_incr"
+	RuleBackupSLAWALArchive = "backup_sla_wal_archive"
+)
+
+// backupTimes holds the completion time of the newest backup of each type
+// and the time the newest WAL segment was archived.
+type backupTimes struct {
+	Full, Diff, Incr *time.Time
+	WALArchived      *time.Time
+}
+
+// evaluateBackupSLA returns a breach for every configured maximum age that
+// is exceeded. A missing backup counts as a breach; a missing WAL archive
+// time is ignored since the archiver may not be reachable.
+func evaluateBackupSLA(sla config.BackupSLAConfig, t backupTimes, now time.Time) []alerts.Breach {
+	var breaches []alerts.Breach
+
+	check := func(rule, what string, maxAge time.Duration, last *time.Time, required bool) {
+		if maxAge <= 0 {
+			return
+		}
+		if last == nil {
+			if required {
+				breaches = append(breaches, alerts.Breach{
+					Rule:      rule,
+					Severity:  alerts.SeverityCritical,
+					Message:   fmt.Sprintf("No %s found", what),
+					Threshold: maxAge.Seconds(),
+				})
+			}
+			return
+		}
+		age := now.Sub(*last)
+		if age <= maxAge {
+			return
+		}
+		breaches = append(breaches, alerts.Breach{
+			Rule:      rule,
+			Severity:  alerts.SeverityWarning,
+			Message:   fmt.Sprintf("Newest %s is %s old, exceeds %s", what, age.Round(time.Second), maxAge),
+			Value:     floatPtr(age.Seconds()),
+			Threshold: maxAge.Seconds(),
+		})
+	}
+
+	check(RuleBackupSLAFull, "full backup", sla.MaxFullAge, t.Full, true)
+	check(RuleBackupSLADiff, "differential backup", sla.MaxDiffAge, t.Diff, true)
+	check(RuleBackupSLAIncr, "incremental backup", sla.MaxIncrAge, t.Incr, true)
+	check(RuleBackupSLAWALArchive, "archived WAL segment", sla.MaxWALArchiveAge, t.WALArchived, false)
+
+	return breaches
+}
+
+// lastArchivedTime returns when the newest WAL segment was archived
+// according to pg_stat_archiver.
+func lastArchivedTime(ctx context.Context, pool *db.Pool) (*time.Time, error) {
+	var last *time.Time
+	err := pool.QueryRow(ctx, "SELECT last_archived_time FROM pg_stat_archiver").Scan(&last)
+	return last, err
+}
+
+// checkBackupSLA reports whether the newest backups of every stanza are
+// within the configured maximum ages, and the archived WAL of the default
+// stanza, which is the one of this cluster.
+func (h *HealthHandler) checkBackupSLA(ctx context.Context, report models.BackupsResponse) models.ComponentHealth {
+	if report.Status == "not_installed" {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnknown,
+			Message: "pgBackRest is not installed",
+		}
+	}
+
+	var walArchived *time.Time
+	if h.pool != nil {
+		if last, err := lastArchivedTime(ctx, h.pool); err == nil {
+			walArchived = last
+		}
+	}
+
+	var messages, violations []string
+	seen := map[string]bool{}
+	now := time.Now()
+	for i, backups := range report.Stanzas {
+		if backups.Status == "not_installed" {
+			continue
+		}
+		times := backupTimes{
+			Full: backups.LastFullBackup,
+			Diff: backups.LastDiffBackup,
+			Incr: backups.LastIncrBackup,
+		}
+		if i == 0 {
+			times.WALArchived = walArchived
+		}
+		for _, b := range evaluateBackupSLA(h.cfg.Backup.SLA, times, now) {
+			messages = append(messages, backups.Stanza+": "+b.Message)
+			if !seen[b.Rule] {
+				seen[b.Rule] = true
+				violations = append(violations, b.Rule)
+			}
+		}
+	}
+	if len(messages) == 0 {
+		return models.ComponentHealth{Status: models.ComponentHealthy}
+	}
+
+	return models.ComponentHealth{
+		Status:  models.ComponentDegraded,
+		Message: strings.Join(messages, "; "),
+		Details: map[string]interface{}{"violations": violations},
+	}
+}
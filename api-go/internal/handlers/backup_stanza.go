@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Job types used for stanza management.
+const (
+	JobTypeStanzaCreate  = "stanza-create"
+	JobTypeStanzaUpgrade = "stanza-upgrade"
+)
+
+// StanzaCreate handles POST /backups/stanza - initialize a stanza in the
+// repository as an async job, so a fresh cluster can be backed up.
+//
+// The body may name the stanza; it defaults to the default stanza.
+func (h *BackupsHandler) StanzaCreate(c *gin.Context) {
+	h.stanzaJob(c, JobTypeStanzaCreate, backup.StanzaManager.StanzaCreate)
+}
+
+// StanzaUpgrade handles POST /backups/stanza/upgrade - update a stanza
+// after a PostgreSQL major version upgrade as an async job.
+func (h *BackupsHandler) StanzaUpgrade(c *gin.Context) {
+	h.stanzaJob(c, JobTypeStanzaUpgrade, backup.StanzaManager.StanzaUpgrade)
+}
+
+// stanzaJob validates the request and submits run as a job of jobType.
+func (h *BackupsHandler) stanzaJob(c *gin.Context, jobType string, run func(backup.StanzaManager, context.Context, string, io.Writer) error) {
+	manager, ok := h.provider.(backup.StanzaManager)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "unsupported",
+			Message: fmt.Sprintf("The %s provider has no stanzas to manage", h.provider.Name()),
+		})
+		return
+	}
+
+	var req models.StanzaRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if req.Stanza == "" {
+		req.Stanza = h.DefaultTarget()
+	}
+	if !contains(h.provider.Targets(), req.Stanza) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Stanza is not configured",
+		})
+		return
+	}
+
+	// pgbackrest holds a stanza lock for backups and stanza commands
+	for _, t := range []string{JobTypeBackup, JobTypeStanzaCreate, JobTypeStanzaUpgrade} {
+		if h.jobs.Active(t) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "job_in_progress",
+				Message: fmt.Sprintf("A %s job is already queued or running", t),
+			})
+			return
+		}
+	}
+
+	stanza := req.Stanza
+	params := map[string]interface{}{
+		"stanza":   stanza,
+		"provider": h.provider.Name(),
+	}
+
+	slog.InfoContext(c.Request.Context(), "Stanza job requested", "job_type", jobType, "stanza", stanza, "client_ip", c.ClientIP())
+	submitJob(c, h.jobs, jobType, params, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		out.Report(fmt.Sprintf("running %s for stanza %s", jobType, stanza))
+		err := run(manager, ctx, stanza, out)
+		result := map[string]interface{}{"output": out.Tail(20)}
+		if err != nil {
+			return result, err
+		}
+
+		// The stanza may have gone from missing to empty.
+		refreshCtx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+		h.cache.refresh(refreshCtx, stanza)
+		cancel()
+		return result, nil
+	})
+}
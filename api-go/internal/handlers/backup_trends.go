@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Trends handles GET /backups/trends - size, duration and compression of
+// recorded backups of one type for capacity planning.
+//
+// Query parameters: stanza (default stanza) and type (default full).
+func (h *BackupsHandler) Trends(c *gin.Context) {
+	stanza := c.DefaultQuery("stanza", h.DefaultTarget())
+	if !contains(h.provider.Targets(), stanza) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Stanza is not configured",
+		})
+		return
+	}
+
+	backupType := c.DefaultQuery("type", "full")
+	if !contains(h.provider.BackupTypes(), backupType) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Unsupported backup type for " + h.provider.Name(),
+		})
+		return
+	}
+
+	points := h.history.Points(stanza, backupType)
+	c.JSON(http.StatusOK, models.BackupTrendsResponse{
+		Stanza:    stanza,
+		Type:      backupType,
+		Summary:   summarizeTrend(points),
+		Points:    points,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// summarizeTrend computes averages and per-day growth rates over points,
+// which are ordered oldest first.
+func summarizeTrend(points []models.BackupTrendPoint) models.BackupTrendSummary {
+	summary := models.BackupTrendSummary{Count: len(points)}
+	if len(points) == 0 {
+		return summary
+	}
+
+	var days, sizes, durations []float64
+	var sizeDays []float64
+	var ratioSum float64
+	ratios := 0
+	for _, p := range points {
+		day := p.StartTime.Sub(points[0].StartTime).Hours() / 24
+		days = append(days, day)
+		durations = append(durations, p.DurationSeconds)
+		if p.SizeBytes != nil {
+			sizeDays = append(sizeDays, day)
+			sizes = append(sizes, float64(*p.SizeBytes))
+			summary.LatestSizeBytes = p.SizeBytes
+		}
+		if p.CompressionRatio != nil {
+			ratioSum += *p.CompressionRatio
+			ratios++
+		}
+	}
+
+	summary.AvgDurationSeconds = floatPtr(mean(durations))
+	summary.DurationGrowthPerDay = slope(days, durations)
+	summary.SizeGrowthBytesPerDay = slope(sizeDays, sizes)
+	if ratios > 0 {
+		summary.AvgCompressionRatio = floatPtr(ratioSum / float64(ratios))
+	}
+	return summary
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// slope returns the least-squares slope of y over x, or nil when x does
+// not vary (fewer than two distinct points).
+func slope(x, y []float64) *float64 {
+	if len(x) < 2 {
+		return nil
+	}
+	mx, my := mean(x), mean(y)
+	var num, den float64
+	for i := range x {
+		num += (x[i] - mx) * (y[i] - my)
+		den += (x[i] - mx) * (x[i] - mx)
+	}
+	if den == 0 {
+		return nil
+	}
+	return floatPtr(num / den)
+}
+
+var (
+	backupSizeDesc = prometheus.NewDesc("pgha_backup_size_bytes",
+		"Size of the database in the newest recorded backup.",
+		[]string{"stanza", "type"}, nil)
+	backupRepoSizeDesc = prometheus.NewDesc("pgha_backup_repository_size_bytes",
+		"Size of the newest recorded backup in the repository.",
+		[]string{"stanza", "type"}, nil)
+	backupDurationDesc = prometheus.NewDesc("pgha_backup_duration_seconds",
+		"Duration of the newest recorded backup.",
+		[]string{"stanza", "type"}, nil)
+	backupCompressionDesc = prometheus.NewDesc("pgha_backup_compression_ratio",
+		"Database size divided by repository size of the newest recorded backup.",
+		[]string{"stanza", "type"}, nil)
+	backupSizeGrowthDesc = prometheus.NewDesc("pgha_backup_size_growth_bytes_per_day",
+		"Least-squares growth of backup size over the recorded history.",
+		[]string{"stanza", "type"}, nil)
+	backupHistoryDesc = prometheus.NewDesc("pgha_backup_history_count",
+		"Number of backups in the recorded history.",
+		[]string{"stanza", "type"}, nil)
+)
+
+// backupTrendCollector exports the backup history as Prometheus metrics.
+type backupTrendCollector struct {
+	h *BackupsHandler
+}
+
+// Collector returns a Prometheus collector for the recorded backup trends.
+func (h *BackupsHandler) Collector() prometheus.Collector {
+	return backupTrendCollector{h: h}
+}
+
+// Describe implements prometheus.Collector.
+func (c backupTrendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backupSizeDesc
+	ch <- backupRepoSizeDesc
+	ch <- backupDurationDesc
+	ch <- backupCompressionDesc
+	ch <- backupSizeGrowthDesc
+	ch <- backupHistoryDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c backupTrendCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stanza := range c.h.provider.Targets() {
+		for _, backupType := range c.h.provider.BackupTypes() {
+			points := c.h.history.Points(stanza, backupType)
+			ch <- prometheus.MustNewConstMetric(backupHistoryDesc, prometheus.GaugeValue,
+				float64(len(points)), stanza, backupType)
+			if len(points) == 0 {
+				continue
+			}
+
+			latest := points[len(points)-1]
+			ch <- prometheus.MustNewConstMetric(backupDurationDesc, prometheus.GaugeValue,
+				latest.DurationSeconds, stanza, backupType)
+			if latest.SizeBytes != nil {
+				ch <- prometheus.MustNewConstMetric(backupSizeDesc, prometheus.GaugeValue,
+					float64(*latest.SizeBytes), stanza, backupType)
+			}
+			if latest.RepositorySizeBytes != nil {
+				ch <- prometheus.MustNewConstMetric(backupRepoSizeDesc, prometheus.GaugeValue,
+					float64(*latest.RepositorySizeBytes), stanza, backupType)
+			}
+			if latest.CompressionRatio != nil {
+				ch <- prometheus.MustNewConstMetric(backupCompressionDesc, prometheus.GaugeValue,
+					*latest.CompressionRatio, stanza, backupType)
+			}
+			if growth := summarizeTrend(points).SizeGrowthBytesPerDay; growth != nil {
+				ch <- prometheus.MustNewConstMetric(backupSizeGrowthDesc, prometheus.GaugeValue,
+					*growth, stanza, backupType)
+			}
+		}
+	}
+}
@@ -2,195 +2,418 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
 	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
 	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/notify"
+	"github.com/postgresql-ha-dr/api-go/internal/scheduler"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
 )
 
-// BackupsHandler handles backup status endpoints.
+// JobTypeBackup is the job type used for backups.
+const JobTypeBackup = "backup"
+
+// scheduleGrace is how late the scheduler may be for the next scheduled
+// backup before it counts as stalled.
+const scheduleGrace = time.Minute
+
+// BackupsHandler handles backup status and scheduling endpoints.
 type BackupsHandler struct {
-	cfg *config.Config
-}
-
-// NewBackupsHandler creates a new backups handler.
-func NewBackupsHandler(cfg *config.Config) *BackupsHandler {
-	return &BackupsHandler{cfg: cfg}
-}
-
-// pgBackRestInfo represents the JSON output from pgbackrest info.
-type pgBackRestInfo struct {
-	Status struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-	} `json:"status"`
-	Backup []struct {
-		Label     string `json:"label"`
-		Type      string `json:"type"`
-		Timestamp struct {
-			Start int64 `json:"start"`
-			Stop  int64 `json:"stop"`
-		} `json:"timestamp"`
-		Info struct {
-			Size       int64 `json:"size"`
-			Repository struct {
-				Size int64 `json:"size"`
-			} `json:"repository"`
-		} `json:"info"`
-	} `json:"backup"`
-	Archive []struct {
-		Min string `json:"min"`
-		Max string `json:"max"`
-	} `json:"archive"`
-}
-
-// Backups handles GET /backups - get backup status.
+	cfg       *config.Config
+	pool      *db.Pool
+	provider  backup.Provider
+	jobs      *jobs.Manager
+	history   *backup.History
+	scheduler *scheduler.Scheduler
+	cache     *backupCache
+	notifier  *notify.Notifier
+	workers   *workers.Registry
+	worker    *workers.Worker
+}
+
+// NewBackupsHandler creates a new backups handler. pool is the cluster of
+// the default stanza and is used to compare the archive with the current
+// WAL position. When the backup schedule is enabled, scheduled backups are
+// submitted to manager.
+func NewBackupsHandler(cfg *config.Config, pool *db.Pool, manager *jobs.Manager) *BackupsHandler {
+	provider := backup.New(cfg)
+	history, err := backup.NewHistory(cfg.Backup.HistoryFile, cfg.Backup.HistoryRetention)
+	if err != nil {
+		slog.Warn("Failed to load backup history", "error", err)
+	}
+
+	h := &BackupsHandler{
+		cfg:      cfg,
+		pool:     pool,
+		provider: provider,
+		jobs:     manager,
+		history:  history,
+		cache:    newBackupCache(cfg.Backup, provider, history),
+		notifier: notify.New(cfg.Notify.WebhookURLs, cfg.Notify.WebhookSecret, cfg.Notify.WebhookTimeout),
+	}
+
+	if cfg.Backup.Schedule.Enabled {
+		specs := map[string]string{
+			"full": cfg.Backup.Schedule.Full,
+			"diff": cfg.Backup.Schedule.Diff,
+			"incr": cfg.Backup.Schedule.Incr,
+		}
+		// Drop types the provider cannot take, e.g. diff and incr for
+		// logical dumps.
+		for backupType := range specs {
+			if !contains(provider.BackupTypes(), backupType) {
+				delete(specs, backupType)
+			}
+		}
+		s, err := scheduler.New(specs, h.runScheduled)
+		if err != nil {
+			slog.Warn("Backup schedule disabled", "error", err)
+		} else {
+			h.scheduler = s
+		}
+	}
+
+	return h
+}
+
+// UseWorkers records the scheduled backups as runs of the scheduler
+// worker and the backup notifications as runs of the notifier worker in
+// registry. Call it before Start.
+func (h *BackupsHandler) UseWorkers(registry *workers.Registry) {
+	h.workers = registry
+	h.notifier.UseWorkers(registry)
+}
+
+// Start refreshes the cached backup status on the configured interval and
+// runs the backup scheduler, if enabled, until ctx is done.
+func (h *BackupsHandler) Start(ctx context.Context) {
+	go func() {
+		h.refreshAll(ctx)
+
+		ticker := time.NewTicker(h.cfg.Backup.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.refreshAll(ctx)
+			}
+		}
+	}()
+
+	if h.scheduler == nil {
+		return
+	}
+
+	h.worker = h.workers.Register(WorkerBackupScheduler, 0)
+	h.worker.Expect(h.scheduler.Next().Add(scheduleGrace))
+	h.scheduler.Start()
+	go func() {
+		<-ctx.Done()
+		h.scheduler.Stop()
+	}()
+}
+
+// DefaultTarget returns the stanza (or, for logical dumps, the database)
+// that scheduled backups, restores and the archive gap apply to.
+func (h *BackupsHandler) DefaultTarget() string {
+	return h.provider.Targets()[0]
+}
+
+// refreshAll refreshes the cached status of every configured stanza.
+func (h *BackupsHandler) refreshAll(ctx context.Context) {
+	for _, stanza := range h.provider.Targets() {
+		fetchCtx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+		h.cache.refresh(fetchCtx, stanza)
+		cancel()
+	}
+}
+
+// Status returns the backup status of stanza from the cache, running
+// the backup tool only when the cached result is missing or too old.
+func (h *BackupsHandler) Status(ctx context.Context, stanza string) models.BackupResponse {
+	status, _ := h.cache.status(ctx, stanza)
+	return status
+}
+
+// Backups handles GET /backups - backup status for every configured
+// stanza.
+//
+// Results come from the background refresher; stanzas whose cached result
+// is older than the configured maximum age are fetched on demand. X-Cache
+// is HIT only when every stanza was served from the cache.
 func (h *BackupsHandler) Backups(c *gin.Context) {
-	stanza := h.cfg.Backup.Stanza
+	response, hit := h.Report(c.Request.Context())
+	if hit {
+		c.Header("X-Cache", "HIT")
+	} else {
+		c.Header("X-Cache", "MISS")
+	}
+	c.JSON(http.StatusOK, response)
+}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+// Report returns the backup status of every configured stanza, and
+// whether all of them came from the cache.
+func (h *BackupsHandler) Report(ctx context.Context) (models.BackupsResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
 	defer cancel()
 
-	// Run pgbackrest info command
-	cmd := exec.CommandContext(ctx, "pgbackrest", "--stanza", stanza, "info", "--output=json")
-	output, err := cmd.Output()
+	stanzas := h.provider.Targets()
+	results := make([]models.BackupResponse, len(stanzas))
+	hits := make([]bool, len(stanzas))
 
-	if err != nil {
-		if _, ok := err.(*exec.Error); ok {
-			// pgBackRest not installed
-			c.JSON(http.StatusOK, models.BackupResponse{
-				Stanza:        stanza,
-				Status:        "not_installed",
-				StatusMessage: strPtr("pgBackRest is not installed on this system"),
-				Backups:       []models.BackupInfo{},
-				Timestamp:     time.Now().UTC(),
-			})
-			return
+	var wg sync.WaitGroup
+	for i, stanza := range stanzas {
+		wg.Add(1)
+		go func(i int, stanza string) {
+			defer wg.Done()
+			results[i], hits[i] = h.cache.status(ctx, stanza)
+			setBackupAge(&results[i])
+			h.addArchiveGap(ctx, &results[i])
+		}(i, stanza)
+	}
+	wg.Wait()
+
+	allHits := true
+	for _, hit := range hits {
+		if !hit {
+			allHits = false
 		}
+	}
 
-		// Other error
-		c.JSON(http.StatusOK, models.BackupResponse{
-			Stanza:        stanza,
-			Status:        "unavailable",
-			StatusMessage: strPtr("pgBackRest error: " + err.Error()),
-			Backups:       []models.BackupInfo{},
-			Timestamp:     time.Now().UTC(),
-		})
+	return models.BackupsResponse{
+		Status:    overallBackupStatus(results),
+		Stanzas:   results,
+		Timestamp: time.Now().UTC(),
+	}, allHits
+}
+
+// Stanza handles GET /backups/:stanza - backup details for one configured
+// stanza.
+func (h *BackupsHandler) Stanza(c *gin.Context) {
+	status, hit, err := h.StanzaReport(c.Request.Context(), c.Param("stanza"))
+	if err != nil {
+		writeError(c, err)
 		return
 	}
+	if hit {
+		c.Header("X-Cache", "HIT")
+	} else {
+		c.Header("X-Cache", "MISS")
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// StanzaReport returns the backup details of one configured stanza, and
+// whether they came from the cache.
+func (h *BackupsHandler) StanzaReport(ctx context.Context, stanza string) (models.BackupResponse, bool, error) {
+	if !contains(h.provider.Targets(), stanza) {
+		return models.BackupResponse{}, false, &Error{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "Stanza is not configured",
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+	defer cancel()
 
-	// Parse JSON output
-	var infos []pgBackRestInfo
-	if err := json.Unmarshal(output, &infos); err != nil {
-		c.JSON(http.StatusOK, models.BackupResponse{
-			Stanza:        stanza,
-			Status:        "parse_error",
-			StatusMessage: strPtr("Failed to parse pgBackRest output: " + err.Error()),
-			Backups:       []models.BackupInfo{},
-			Timestamp:     time.Now().UTC(),
-		})
+	status, hit := h.cache.status(ctx, stanza)
+	setBackupAge(&status)
+	h.addArchiveGap(ctx, &status)
+	return status, hit, nil
+}
+
+// addArchiveGap compares the newest WAL segment in the repository with the
+// primary's current WAL position. It only applies to the default stanza,
+// and only when connected to a primary.
+func (h *BackupsHandler) addArchiveGap(ctx context.Context, status *models.BackupResponse) {
+	if h.pool == nil || status.Stanza != h.DefaultTarget() ||
+		status.WALArchive == nil || status.WALArchive.MaxWAL == nil {
 		return
 	}
 
-	if len(infos) == 0 {
-		c.JSON(http.StatusOK, models.BackupResponse{
-			Stanza:        stanza,
-			Status:        "no_stanza",
-			StatusMessage: strPtr("No stanza information available"),
-			Backups:       []models.BackupInfo{},
-			Timestamp:     time.Now().UTC(),
-		})
+	currentLSN, currentFile, segmentSize, err := currentWAL(ctx, h.pool)
+	if err != nil {
+		return
+	}
+	files, bytes, err := archiveLag(*status.WALArchive.MaxWAL, currentFile, currentLSN, segmentSize)
+	if err != nil {
 		return
 	}
 
-	info := infos[0]
+	// The status may be shared with the cache, so modify a copy.
+	archive := *status.WALArchive
+	archive.CurrentWALFile = &currentFile
+	archive.GapFiles = &files
+	archive.GapBytes = &bytes
+	status.WALArchive = &archive
+}
+
+// setBackupAge fills in how long ago the status was fetched.
+func setBackupAge(status *models.BackupResponse) {
+	age := time.Since(status.Timestamp).Seconds()
+	status.AgeSeconds = &age
+}
 
-	// Map status code to string
-	var status string
-	switch info.Status.Code {
-	case 0:
-		status = "ok"
-	case 1:
-		status = "missing_stanza"
-	case 2:
-		status = "no_backup"
-	default:
-		status = "error"
+// overallBackupStatus is "ok" when every stanza is ok, "partial" when only
+// some are, and otherwise the shared status of all stanzas or "error".
+func overallBackupStatus(results []models.BackupResponse) string {
+	ok := 0
+	for _, r := range results {
+		if r.Status == "ok" {
+			ok++
+		}
 	}
 
-	// Parse backups
-	backups := make([]models.BackupInfo, 0, len(info.Backup))
-	var lastFull, lastDiff *time.Time
+	switch {
+	case ok == len(results):
+		return "ok"
+	case ok > 0:
+		return "partial"
+	}
 
-	for _, b := range info.Backup {
-		backup := models.BackupInfo{
-			Label: b.Label,
-			Type:  b.Type,
+	status := results[0].Status
+	for _, r := range results[1:] {
+		if r.Status != status {
+			return "error"
 		}
+	}
+	return status
+}
 
-		if b.Timestamp.Start > 0 {
-			t := time.Unix(b.Timestamp.Start, 0).UTC()
-			backup.StartTime = &t
-		}
-		if b.Timestamp.Stop > 0 {
-			t := time.Unix(b.Timestamp.Stop, 0).UTC()
-			backup.StopTime = &t
-
-			// Track latest by type
-			if b.Type == "full" {
-				if lastFull == nil || t.After(*lastFull) {
-					lastFull = &t
-				}
-			} else if b.Type == "diff" {
-				if lastDiff == nil || t.After(*lastDiff) {
-					lastDiff = &t
+// Schedule handles GET /backups/schedule - next run times and the outcome
+// of the last scheduled backup of each type.
+func (h *BackupsHandler) Schedule(c *gin.Context) {
+	response := models.BackupScheduleResponse{
+		Enabled:   h.scheduler != nil,
+		Stanza:    h.DefaultTarget(),
+		Entries:   []models.BackupScheduleEntry{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	if h.scheduler != nil {
+		for _, e := range h.scheduler.Status() {
+			entry := models.BackupScheduleEntry{
+				Type:      e.Name,
+				Schedule:  e.Spec,
+				NextRun:   e.Next,
+				LastRun:   e.LastRun,
+				LastJobID: e.LastRef,
+				LastError: e.LastError,
+			}
+			if e.LastRef != "" {
+				if job, err := h.jobs.Get(e.LastRef); err == nil {
+					entry.LastJobStatus = job.Status
+					if entry.LastError == "" {
+						entry.LastError = job.Error
+					}
 				}
 			}
+			response.Entries = append(response.Entries, entry)
 		}
-		if b.Info.Size > 0 {
-			backup.SizeBytes = &b.Info.Size
-		}
-		if b.Info.Repository.Size > 0 {
-			backup.DatabaseSizeBytes = &b.Info.Repository.Size
-		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// runScheduled submits a scheduled backup and records it as a run of the
+// scheduler worker, which is then due shortly after the next one.
+func (h *BackupsHandler) runScheduled(backupType string) (string, error) {
+	start := time.Now()
+	ref, err := h.scheduledBackup(backupType)
+	h.worker.Record(start, err)
+	h.worker.Expect(h.scheduler.Next().Add(scheduleGrace))
+	return ref, err
+}
+
+// scheduledBackup submits a backup job for the default stanza. It is
+// skipped while another backup is queued or running, since pgbackrest
+// would refuse it anyway.
+func (h *BackupsHandler) scheduledBackup(backupType string) (string, error) {
+	if h.jobs.Active(JobTypeBackup) {
+		return "", errors.New("skipped: a backup is already queued or running")
+	}
 
-		backups = append(backups, backup)
+	stanza := h.DefaultTarget()
+	job, err := h.jobs.Submit(JobTypeBackup, map[string]interface{}{
+		"stanza":    stanza,
+		"type":      backupType,
+		"scheduled": true,
+	}, h.backupJob(stanza, backupType, true))
+	if err != nil {
+		return "", err
 	}
 
-	// Parse WAL archive info
-	var walArchive *models.WALArchiveInfo
-	if len(info.Archive) > 0 {
-		walArchive = &models.WALArchiveInfo{}
-		if info.Archive[0].Min != "" {
-			walArchive.MinWAL = &info.Archive[0].Min
+	slog.Info("Scheduled backup queued", "type", backupType, "stanza", stanza, "job_id", job.ID)
+	return job.ID, nil
+}
+
+// backupJob returns a job that takes a backup of the given type,
+// refreshes the cached status afterwards and sends lifecycle
+// notifications.
+func (h *BackupsHandler) backupJob(stanza, backupType string, scheduled bool) jobs.Func {
+	return func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		event := models.BackupEvent{
+			Stanza:    stanza,
+			Type:      backupType,
+			Scheduled: scheduled,
+			StartedAt: time.Now().UTC(),
 		}
-		if info.Archive[0].Max != "" {
-			walArchive.MaxWAL = &info.Archive[0].Max
+		h.notifier.Send("backup.started", event)
+
+		out.Report(fmt.Sprintf("running %s %s backup", h.provider.Name(), backupType))
+		err := h.provider.Backup(ctx, stanza, backupType, out)
+		tail := out.Tail(20)
+
+		finished := time.Now().UTC()
+		duration := finished.Sub(event.StartedAt).Seconds()
+		event.FinishedAt = &finished
+		event.DurationSeconds = &duration
+
+		if err != nil {
+			event.Error = err.Error()
+			h.notifier.Send("backup.failed", event)
+			return map[string]interface{}{"output": tail}, err
 		}
-	}
 
-	var statusMessage *string
-	if status != "ok" {
-		statusMessage = &info.Status.Message
-	}
+		// Pick up the new backup for the response and the notification.
+		refreshCtx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+		status := h.cache.refresh(refreshCtx, stanza)
+		cancel()
+		if n := len(status.Backups); n > 0 {
+			latest := status.Backups[n-1]
+			event.Label = latest.Label
+			event.SizeBytes = latest.SizeBytes
+		}
+		h.notifier.Send("backup.succeeded", event)
 
-	c.JSON(http.StatusOK, models.BackupResponse{
-		Stanza:         stanza,
-		Status:         status,
-		StatusMessage:  statusMessage,
-		Backups:        backups,
-		WALArchive:     walArchive,
-		LastFullBackup: lastFull,
-		LastDiffBackup: lastDiff,
-		Timestamp:      time.Now().UTC(),
-	})
+		return map[string]interface{}{"label": event.Label, "output": tail}, nil
+	}
 }
 
 func strPtr(s string) *string {
 	return &s
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
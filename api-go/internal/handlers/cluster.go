@@ -0,0 +1,647 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/autofailover"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/dcs"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/patroni"
+)
+
+// Job types used for leadership changes.
+const (
+	JobTypeSwitchover = "switchover"
+	JobTypeFailover   = "failover"
+	JobTypeDrill      = "drill"
+)
+
+// leaderPollInterval is how often the cluster is checked while waiting
+// for a new leader.
+const leaderPollInterval = 2 * time.Second
+
+// ClusterHandler handles HA cluster status and leadership changes through
+// Patroni. With pg_auto_failover instead, only the status is available.
+type ClusterHandler struct {
+	cfg          *config.Config
+	patroni      *patroni.Client
+	autoFailover *autofailover.Client
+	dcs          *dcs.Client
+	backups      *BackupsHandler
+	jobs         *jobs.Manager
+
+	maintenance *lifecycle.Maintenance
+}
+
+// NewClusterHandler creates a new cluster handler. backups is consulted
+// for the backup freshness check.
+func NewClusterHandler(cfg *config.Config, backups *BackupsHandler, manager *jobs.Manager) *ClusterHandler {
+	return &ClusterHandler{
+		cfg:          cfg,
+		patroni:      patroni.New(cfg.Patroni.URLs, cfg.Patroni.Username, cfg.Patroni.Password, cfg.Patroni.Timeout),
+		autoFailover: autofailover.New(cfg.AutoFailover.MonitorURI, cfg.AutoFailover.Formation, cfg.Database.ConnectTimeout),
+		dcs:          dcs.New(cfg.Patroni.DCSType, cfg.Patroni.DCSURLs, cfg.Patroni.Timeout),
+		backups:      backups,
+		jobs:         manager,
+
+		maintenance: lifecycle.NewMaintenance(),
+	}
+}
+
+// Maintenance returns the maintenance flag, for readiness checks to
+// consult.
+func (h *ClusterHandler) Maintenance() *lifecycle.Maintenance {
+	return h.maintenance
+}
+
+// Status handles GET /cluster - members, roles and lag as reported by
+// Patroni, and the health of the DCS when DCS_URLS is set.
+//
+// status is no_leader without a leader, dcs_no_quorum when no DCS
+// endpoint has a leader (Patroni cannot fail over), dcs_degraded when
+// some endpoints are down, and ok otherwise.
+//
+// With PG_AUTOCTL_MONITOR set instead of PATRONI_URLS, the formation is
+// read from the pg_auto_failover monitor; see formationStatus.
+func (h *ClusterHandler) Status(c *gin.Context) {
+	response, err := h.State(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// State returns the cluster status served by GET /cluster.
+func (h *ClusterHandler) State(ctx context.Context) (models.ClusterResponse, error) {
+	if !h.patroni.Enabled() && h.autoFailover.Enabled() {
+		return h.formationStatus(ctx)
+	}
+
+	cluster, err := h.patroniCluster(ctx)
+	if err != nil {
+		return models.ClusterResponse{}, err
+	}
+
+	response := models.ClusterResponse{
+		Manager:   "patroni",
+		Status:    "ok",
+		Paused:    cluster.Pause,
+		Members:   make([]models.ClusterMember, 0, len(cluster.Members)),
+		Timestamp: time.Now().UTC(),
+	}
+	if leader, ok := cluster.Leader(); ok {
+		response.Leader = &leader.Name
+	} else {
+		response.Status = "no_leader"
+	}
+	for _, m := range cluster.Members {
+		member := models.ClusterMember{
+			Name:     m.Name,
+			Role:     m.Role,
+			State:    m.State,
+			Host:     m.Host,
+			Port:     m.Port,
+			APIURL:   m.APIURL,
+			Timeline: m.Timeline,
+		}
+		if lag, ok := m.LagBytes(); ok {
+			member.LagBytes = &lag
+		}
+		response.Members = append(response.Members, member)
+	}
+
+	if h.dcs.Enabled() {
+		response.DCS = dcsStatus(h.dcs.Check(ctx))
+		if response.Status == "ok" {
+			if !response.DCS.Quorum {
+				response.Status = "dcs_no_quorum"
+			} else if !response.DCS.Healthy {
+				response.Status = "dcs_degraded"
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// formationStatus returns the nodes of the pg_auto_failover formation with
+// the state the monitor assigned each one and the state it last reported.
+// The leader is the primary of the first group.
+//
+// status is no_leader when a group has no primary, transitioning while a
+// node has not reached its assigned state (e.g. during a failover), and ok
+// otherwise.
+func (h *ClusterHandler) formationStatus(ctx context.Context) (models.ClusterResponse, error) {
+	formation, err := h.autoFailover.Formation(ctx)
+	if err != nil {
+		return models.ClusterResponse{}, &Error{
+			Status:  http.StatusBadGateway,
+			Code:    "monitor_unavailable",
+			Message: err.Error(),
+		}
+	}
+
+	response := models.ClusterResponse{
+		Manager: "pg_auto_failover",
+		Status:  "ok",
+		Members: make([]models.ClusterMember, 0, len(formation.Nodes)),
+		Formation: &models.ClusterFormation{
+			ID:                 formation.ID,
+			Kind:               formation.Kind,
+			DBName:             formation.DBName,
+			Secondary:          formation.Secondary,
+			NumberSyncStandbys: formation.NumberSyncStandbys,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+	for i, group := range formation.Groups() {
+		primary, ok := formation.Primary(group)
+		switch {
+		case !ok:
+			response.Status = "no_leader"
+		case i == 0:
+			response.Leader = &primary.Name
+		}
+	}
+	for _, n := range formation.Nodes {
+		group := n.GroupID
+		member := models.ClusterMember{
+			Name:          n.Name,
+			Role:          n.Role(),
+			State:         "stopped",
+			Host:          n.Host,
+			Port:          n.Port,
+			Timeline:      n.Timeline,
+			GroupID:       &group,
+			AssignedState: n.AssignedState,
+			ReportedState: n.ReportedState,
+			Health:        n.Health,
+			ReportedAt:    n.ReportedAt,
+		}
+		if n.PgIsRunning {
+			member.State = "running"
+		}
+		if lag, ok := formation.LagBytes(n); ok {
+			member.LagBytes = &lag
+		}
+		if n.Transitioning() && response.Status == "ok" {
+			response.Status = "transitioning"
+		}
+		response.Members = append(response.Members, member)
+	}
+
+	return response, nil
+}
+
+func dcsStatus(status dcs.Status) *models.DCSStatus {
+	out := &models.DCSStatus{
+		Type:      status.Type,
+		Healthy:   status.Healthy,
+		Quorum:    status.Quorum,
+		Leader:    status.Leader,
+		Endpoints: make([]models.DCSEndpoint, 0, len(status.Endpoints)),
+	}
+	for _, e := range status.Endpoints {
+		out.Endpoints = append(out.Endpoints, models.DCSEndpoint{
+			URL:       e.URL,
+			Healthy:   e.Healthy,
+			Error:     e.Error,
+			LatencyMs: float64(e.Latency.Microseconds()) / 1000,
+		})
+	}
+	return out
+}
+
+// Switchover handles POST /cluster/switchover - a planned change of leader
+// run as an async job.
+//
+// Refused with 412 unless the candidate (or, without one, some replica)
+// is streaming within SWITCHOVER_MAX_LAG_BYTES and the newest backup is
+// younger than SWITCHOVER_MAX_BACKUP_AGE.
+func (h *ClusterHandler) Switchover(c *gin.Context) {
+	var req models.SwitchoverRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	job, err := h.RequestSwitchover(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Switchover requested", "leader", job.Params["leader"], "client_ip", c.ClientIP())
+	writeJobAccepted(c, job)
+}
+
+// RequestSwitchover checks the preconditions of a switchover and submits
+// its job.
+func (h *ClusterHandler) RequestSwitchover(ctx context.Context, req models.SwitchoverRequest) (models.Job, error) {
+	if err := h.idle(); err != nil {
+		return models.Job{}, err
+	}
+	cluster, err := h.patroniCluster(ctx)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	var failures []string
+	leader, hasLeader := cluster.Leader()
+	switch {
+	case !hasLeader:
+		failures = append(failures, "the cluster has no leader; use a failover instead")
+	case req.Leader == "":
+		req.Leader = leader.Name
+	case req.Leader != leader.Name:
+		failures = append(failures, fmt.Sprintf("%s is not the current leader (%s is)", req.Leader, leader.Name))
+	}
+	failures = append(failures, h.candidateFailures(cluster, req.Candidate)...)
+	failures = append(failures, h.backupFailures(ctx)...)
+	if len(failures) > 0 {
+		return models.Job{}, preconditionFailure("switchover", failures)
+	}
+
+	params := map[string]interface{}{"leader": req.Leader}
+	if req.Candidate != "" {
+		params["candidate"] = req.Candidate
+	}
+
+	return h.submitLeaderChange(JobTypeSwitchover, params, h.leaderChangeJob(req.Leader, req.Candidate,
+		func(ctx context.Context) error {
+			return h.patroni.Switchover(ctx, req.Leader, req.Candidate)
+		}))
+}
+
+// Failover handles POST /cluster/failover - promote a candidate, with or
+// without a healthy leader, as an async job.
+//
+// The lag and backup checks of a switchover apply unless force is set;
+// the candidate must always be a known replica.
+func (h *ClusterHandler) Failover(c *gin.Context) {
+	var req models.FailoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	job, err := h.RequestFailover(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Failover requested", "candidate", req.Candidate, "force", req.Force, "client_ip", c.ClientIP())
+	writeJobAccepted(c, job)
+}
+
+// RequestFailover checks the preconditions of a failover and submits its
+// job.
+func (h *ClusterHandler) RequestFailover(ctx context.Context, req models.FailoverRequest) (models.Job, error) {
+	if req.Candidate == "" {
+		return models.Job{}, &Error{
+			Status:  http.StatusBadRequest,
+			Code:    "validation_error",
+			Message: "candidate is required",
+		}
+	}
+	if err := h.idle(); err != nil {
+		return models.Job{}, err
+	}
+	cluster, err := h.patroniCluster(ctx)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	var failures []string
+	if req.Force {
+		if m, ok := cluster.Member(req.Candidate); !ok || m.Leader() {
+			failures = append(failures, fmt.Sprintf("%s is not a replica of this cluster", req.Candidate))
+		}
+	} else {
+		failures = append(failures, h.candidateFailures(cluster, req.Candidate)...)
+		failures = append(failures, h.backupFailures(ctx)...)
+	}
+	if len(failures) > 0 {
+		return models.Job{}, preconditionFailure("failover", failures)
+	}
+
+	var oldLeader string
+	if leader, ok := cluster.Leader(); ok {
+		oldLeader = leader.Name
+	}
+	params := map[string]interface{}{"candidate": req.Candidate, "force": req.Force}
+	if oldLeader != "" {
+		params["leader"] = oldLeader
+	}
+
+	return h.submitLeaderChange(JobTypeFailover, params, h.leaderChangeJob(oldLeader, req.Candidate,
+		func(ctx context.Context) error {
+			return h.patroni.Failover(ctx, req.Candidate)
+		}))
+}
+
+// GetMaintenance handles GET /cluster/maintenance - whether maintenance
+// mode is on and, with Patroni, whether automatic failover is paused.
+func (h *ClusterHandler) GetMaintenance(c *gin.Context) {
+	response := maintenanceResponse(h.maintenance.State())
+	if h.patroni.Enabled() {
+		if cluster, err := h.patroni.Cluster(c.Request.Context()); err == nil {
+			response.PatroniPaused = &cluster.Pause
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetMaintenance handles POST /cluster/maintenance - turn maintenance mode
+// on or off.
+//
+// With Patroni, automatic failover is paused or resumed first; the local
+// flag, which makes /ready answer 503, is only changed once Patroni has
+// accepted. Without Patroni only the local flag is set.
+func (h *ClusterHandler) SetMaintenance(c *gin.Context) {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	enabled := *req.Enabled
+
+	var paused *bool
+	if h.patroni.Enabled() {
+		if err := h.patroni.Pause(c.Request.Context(), enabled); err != nil {
+			c.JSON(http.StatusBadGateway, models.ErrorResponse{
+				Error:   "patroni_unavailable",
+				Message: "Failed to change Patroni maintenance mode: " + err.Error(),
+			})
+			return
+		}
+		paused = &enabled
+	}
+
+	slog.InfoContext(c.Request.Context(), "Maintenance mode set", "enabled", enabled, "reason", req.Reason, "client_ip", c.ClientIP())
+
+	response := maintenanceResponse(h.maintenance.Set(enabled, req.Reason))
+	response.PatroniPaused = paused
+	c.JSON(http.StatusOK, response)
+}
+
+func maintenanceResponse(state lifecycle.MaintenanceState) models.MaintenanceResponse {
+	return models.MaintenanceResponse{
+		Enabled:   state.Enabled,
+		Reason:    state.Reason,
+		Since:     state.Since,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// LocatePrimary returns the address of the running Patroni leader, for
+// retargeting the write pool after a failover.
+func (h *ClusterHandler) LocatePrimary(ctx context.Context) (string, int, error) {
+	cluster, err := h.patroni.Cluster(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	leader, ok := cluster.Leader()
+	if !ok || leader.State != "running" {
+		return "", 0, errors.New("the cluster has no running leader")
+	}
+	if leader.Host == "" {
+		return "", 0, fmt.Errorf("Patroni does not report the host of %s", leader.Name)
+	}
+	port := leader.Port
+	if port == 0 {
+		port = h.cfg.Database.Port
+	}
+	return leader.Host, port, nil
+}
+
+// cluster fetches the cluster state, writing a 503 or 502 response when
+// Patroni is not configured or cannot be reached.
+func (h *ClusterHandler) cluster(c *gin.Context) (*patroni.Cluster, bool) {
+	cluster, err := h.patroniCluster(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return nil, false
+	}
+	return cluster, true
+}
+
+// patroniCluster returns the cluster as reported by Patroni, failing with
+// a 503 when Patroni is not configured and a 502 when it cannot be
+// reached.
+func (h *ClusterHandler) patroniCluster(ctx context.Context) (*patroni.Cluster, error) {
+	cluster, err := h.patroni.Cluster(ctx)
+	if err != nil {
+		if errors.Is(err, patroni.ErrNotConfigured) {
+			message := "Set PATRONI_URLS to enable cluster management"
+			if h.autoFailover.Enabled() {
+				message = "Leadership changes are only supported with Patroni; use pg_autoctl perform switchover or failover"
+			}
+			return nil, &Error{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "not_configured",
+				Message: message,
+			}
+		}
+		return nil, &Error{
+			Status:  http.StatusBadGateway,
+			Code:    "patroni_unavailable",
+			Message: err.Error(),
+		}
+	}
+	return cluster, nil
+}
+
+// checkIdle writes a 409 response and returns false while a switchover,
+// failover or drill is queued or running.
+func (h *ClusterHandler) checkIdle(c *gin.Context) bool {
+	if err := h.idle(); err != nil {
+		writeError(c, err)
+		return false
+	}
+	return true
+}
+
+// leaderChangeJobs are the types of the jobs changing the leader, of
+// which only one may be queued or running at a time.
+var leaderChangeJobs = []string{JobTypeSwitchover, JobTypeFailover, JobTypeDrill}
+
+var errLeaderChangeInProgress = &Error{
+	Status:  http.StatusConflict,
+	Code:    "leader_change_in_progress",
+	Message: "Another switchover, failover or drill is already queued or running",
+}
+
+// idle fails with a 409 while a switchover, failover or drill is queued
+// or running.
+func (h *ClusterHandler) idle() error {
+	for _, t := range leaderChangeJobs {
+		if h.jobs.Active(t) {
+			return errLeaderChangeInProgress
+		}
+	}
+	return nil
+}
+
+// submitLeaderChange queues a job changing the leader, failing with a 409
+// when another one was queued since idle was checked.
+func (h *ClusterHandler) submitLeaderChange(jobType string, params map[string]interface{}, fn jobs.Func) (models.Job, error) {
+	return submitExclusive(h.jobs, leaderChangeJobs, errLeaderChangeInProgress, jobType, params, fn)
+}
+
+// candidateFailures checks that candidate, or any replica when candidate
+// is empty, is streaming within the allowed lag.
+func (h *ClusterHandler) candidateFailures(cluster *patroni.Cluster, candidate string) []string {
+	if candidate != "" {
+		m, ok := cluster.Member(candidate)
+		if !ok {
+			return []string{fmt.Sprintf("%s is not a member of this cluster", candidate)}
+		}
+		if m.Leader() {
+			return []string{fmt.Sprintf("%s is already the leader", candidate)}
+		}
+		if reason := h.replicaProblem(m); reason != "" {
+			return []string{reason}
+		}
+		return nil
+	}
+
+	var reasons []string
+	for _, m := range cluster.Members {
+		if m.Leader() {
+			continue
+		}
+		reason := h.replicaProblem(m)
+		if reason == "" {
+			return nil
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		return []string{"the cluster has no replicas"}
+	}
+	return []string{"no replica is eligible: " + strings.Join(reasons, "; ")}
+}
+
+// replicaProblem describes why m cannot take over, or returns "".
+func (h *ClusterHandler) replicaProblem(m patroni.Member) string {
+	if m.State != "running" && m.State != "streaming" {
+		return fmt.Sprintf("%s is %s", m.Name, m.State)
+	}
+	if max := h.cfg.Patroni.MaxLagBytes; max > 0 {
+		lag, ok := m.LagBytes()
+		if !ok {
+			return fmt.Sprintf("%s has unknown replication lag", m.Name)
+		}
+		if lag > max {
+			return fmt.Sprintf("%s lags %d bytes behind (max %d)", m.Name, lag, max)
+		}
+	}
+	return ""
+}
+
+// backupFailures checks that the newest backup of the default stanza is
+// recent enough to fall back on should the new leader misbehave.
+func (h *ClusterHandler) backupFailures(ctx context.Context) []string {
+	maxAge := h.cfg.Patroni.MaxBackupAge
+	if maxAge <= 0 {
+		return nil
+	}
+
+	status := h.backups.Status(ctx, h.backups.DefaultTarget())
+	var newest *time.Time
+	for _, t := range []*time.Time{status.LastFullBackup, status.LastDiffBackup, status.LastIncrBackup} {
+		if t != nil && (newest == nil || t.After(*newest)) {
+			newest = t
+		}
+	}
+	if newest == nil {
+		return []string{fmt.Sprintf("no backup of %s is available (status %s)", status.Stanza, status.Status)}
+	}
+	if age := time.Since(*newest); age > maxAge {
+		return []string{fmt.Sprintf("the newest backup of %s is %s old (max %s)",
+			status.Stanza, age.Round(time.Minute), maxAge)}
+	}
+	return nil
+}
+
+// leaderChangeJob returns a job that runs request and waits until a
+// running leader other than oldLeader, and candidate when set, is
+// reported.
+func (h *ClusterHandler) leaderChangeJob(oldLeader, candidate string, request func(ctx context.Context) error) jobs.Func {
+	return func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		out.Report("requesting leader change from Patroni")
+		if err := request(ctx); err != nil {
+			return nil, err
+		}
+
+		leader, err := h.waitForLeader(ctx, out, oldLeader, candidate)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"old_leader": oldLeader,
+			"new_leader": leader.Name,
+			"timeline":   leader.Timeline,
+		}, nil
+	}
+}
+
+// waitForLeader polls Patroni until a running leader other than
+// oldLeader, and candidate when set, is reported or SWITCHOVER_TIMEOUT
+// passes.
+func (h *ClusterHandler) waitForLeader(ctx context.Context, out *jobs.Output, oldLeader, candidate string) (patroni.Member, error) {
+	out.Report("waiting for the new leader")
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Patroni.SwitchoverTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(leaderPollInterval)
+	defer ticker.Stop()
+	for {
+		cluster, err := h.patroni.Cluster(ctx)
+		if err != nil {
+			fmt.Fprintf(out, "cluster state unavailable: %v\n", err)
+		} else if leader, ok := cluster.Leader(); ok && leader.State == "running" &&
+			leader.Name != oldLeader && (candidate == "" || leader.Name == candidate) {
+			out.Report(fmt.Sprintf("%s is the new leader on timeline %d", leader.Name, leader.Timeline))
+			return leader, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return patroni.Member{}, fmt.Errorf("no new leader after %s", h.cfg.Patroni.SwitchoverTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// preconditionFailed writes the 412 response listing why operation was
+// refused.
+func preconditionFailed(c *gin.Context, operation string, failures []string) {
+	writeError(c, preconditionFailure(operation, failures))
+}
+
+// preconditionFailure is the 412 error listing why operation was refused.
+func preconditionFailure(operation string, failures []string) *Error {
+	return &Error{
+		Status:   http.StatusPreconditionFailed,
+		Code:     "precondition_failed",
+		Message:  fmt.Sprintf("The cluster is not ready for a %s", operation),
+		Failures: failures,
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// clusterCheckTimeout bounds the reachability check of each cluster in
+// GET /clusters.
+const clusterCheckTimeout = 2 * time.Second
+
+// MonitoredCluster groups the monitoring handlers of one cluster, served
+// under /clusters/:name.
+type MonitoredCluster struct {
+	Name   string
+	Host   string
+	Port   int
+	Stanza string
+	Pool   *db.Pool
+
+	Metrics     *MetricsHandler
+	Backups     *BackupsHandler
+	Alerts      *AlertsHandler
+	WAL         *WALHandler
+	Replication *ReplicationHandler
+	Recovery    *RecoveryHandler
+	Identity    *IdentityHandler
+	Locks       *LocksHandler
+	Sessions    *SessionsHandler
+	Tables      *TablesHandler
+	Indexes     *IndexesHandler
+	Maintenance *MaintenanceHandler
+	Settings    *SettingsHandler
+}
+
+// NewMonitoredCluster creates the monitoring handlers of the cluster cfg
+// points at, reached through pool. pool may be nil when the cluster could
+// not be connected at startup.
+func NewMonitoredCluster(name string, cfg *config.Config, pool *db.Pool, manager *jobs.Manager) *MonitoredCluster {
+	metrics := NewMetricsHandler(cfg, pool)
+	backups := NewBackupsHandler(cfg, pool, manager)
+	return &MonitoredCluster{
+		Name:        name,
+		Host:        cfg.Database.Host,
+		Port:        cfg.Database.Port,
+		Stanza:      backups.DefaultTarget(),
+		Pool:        pool,
+		Metrics:     metrics,
+		Backups:     backups,
+		Alerts:      NewAlertsHandler(cfg, metrics, backups),
+		WAL:         NewWALHandler(pool),
+		Replication: NewReplicationHandler(pool),
+		Recovery:    NewRecoveryHandler(pool),
+		Identity:    NewIdentityHandler(pool),
+		Locks:       NewLocksHandler(pool),
+		Sessions:    NewSessionsHandler(cfg, pool),
+		Tables:      NewTablesHandler(pool),
+		Indexes:     NewIndexesHandler(pool),
+		Maintenance: NewMaintenanceHandler(pool),
+		Settings:    NewSettingsHandler(pool),
+	}
+}
+
+// Start runs the background collection of metrics, backup status and
+// alerts until ctx is done.
+func (m *MonitoredCluster) Start(ctx context.Context) {
+	m.Backups.Start(ctx)
+	m.Metrics.Start(ctx)
+	m.Alerts.Start(ctx)
+}
+
+// ClustersHandler serves the monitoring endpoints of every configured
+// cluster under /clusters/:name.
+type ClustersHandler struct {
+	clusters []*MonitoredCluster
+	byName   map[string]*MonitoredCluster
+}
+
+// NewClustersHandler creates a clusters handler. The first cluster is the
+// default one, also served at the top-level endpoints.
+func NewClustersHandler(clusters ...*MonitoredCluster) *ClustersHandler {
+	h := &ClustersHandler{clusters: clusters, byName: make(map[string]*MonitoredCluster, len(clusters))}
+	for _, m := range clusters {
+		h.byName[m.Name] = m
+	}
+	return h
+}
+
+// Routes registers the per-cluster endpoints on g, whose path must hold
+// the :name parameter. They mirror the top-level monitoring endpoints.
+func (h *ClustersHandler) Routes(g *gin.RouterGroup) {
+	routes := map[string]func(m *MonitoredCluster) gin.HandlerFunc{
+		"/metrics":              func(m *MonitoredCluster) gin.HandlerFunc { return m.Metrics.Metrics },
+		"/metrics/history":      func(m *MonitoredCluster) gin.HandlerFunc { return m.Metrics.History },
+		"/metrics/databases":    func(m *MonitoredCluster) gin.HandlerFunc { return m.Metrics.Databases },
+		"/backups":              func(m *MonitoredCluster) gin.HandlerFunc { return m.Backups.Backups },
+		"/backups/trends":       func(m *MonitoredCluster) gin.HandlerFunc { return m.Backups.Trends },
+		"/backups/:stanza":      func(m *MonitoredCluster) gin.HandlerFunc { return m.Backups.Stanza },
+		"/alerts":               func(m *MonitoredCluster) gin.HandlerFunc { return m.Alerts.Alerts },
+		"/wal/archiver":         func(m *MonitoredCluster) gin.HandlerFunc { return m.WAL.Archiver },
+		"/replication/sync":     func(m *MonitoredCluster) gin.HandlerFunc { return m.Replication.Sync },
+		"/recovery/config":      func(m *MonitoredCluster) gin.HandlerFunc { return m.Recovery.Config },
+		"/identity":             func(m *MonitoredCluster) gin.HandlerFunc { return m.Identity.Identity },
+		"/locks":                func(m *MonitoredCluster) gin.HandlerFunc { return m.Locks.Locks },
+		"/sessions/problematic": func(m *MonitoredCluster) gin.HandlerFunc { return m.Sessions.Problematic },
+		"/tables":               func(m *MonitoredCluster) gin.HandlerFunc { return m.Tables.Tables },
+		"/indexes":              func(m *MonitoredCluster) gin.HandlerFunc { return m.Indexes.Indexes },
+		"/maintenance/vacuum":   func(m *MonitoredCluster) gin.HandlerFunc { return m.Maintenance.Vacuum },
+		"/settings":             func(m *MonitoredCluster) gin.HandlerFunc { return m.Settings.Settings },
+	}
+	for path, handler := range routes {
+		g.GET(path, h.serve(handler))
+	}
+}
+
+// serve dispatches to the handler of the cluster named in the path.
+func (h *ClustersHandler) serve(handler func(m *MonitoredCluster) gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m, ok := h.byName[c.Param("name")]
+		if !ok {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "cluster_not_found",
+				Message: "No cluster named " + c.Param("name") + " is configured",
+			})
+			return
+		}
+		handler(m)(c)
+	}
+}
+
+// List handles GET /clusters - the monitored clusters and whether each
+// can be reached now, checked concurrently.
+func (h *ClustersHandler) List(c *gin.Context) {
+	response := models.ClustersResponse{
+		Clusters:  make([]models.MonitoredCluster, len(h.clusters)),
+		Count:     len(h.clusters),
+		Timestamp: time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	for i, m := range h.clusters {
+		wg.Add(1)
+		go func(i int, m *MonitoredCluster) {
+			defer wg.Done()
+			response.Clusters[i] = h.check(c.Request.Context(), m)
+		}(i, m)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, response)
+}
+
+// check reports one cluster and the role of the node it is connected to.
+func (h *ClustersHandler) check(ctx context.Context, m *MonitoredCluster) models.MonitoredCluster {
+	info := models.MonitoredCluster{
+		Name:    m.Name,
+		Host:    m.Host,
+		Port:    m.Port,
+		Stanza:  m.Stanza,
+		Default: m == h.clusters[0],
+	}
+	if m.Pool == nil {
+		info.Error = "not connected"
+		return info
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterCheckTimeout)
+	defer cancel()
+	state, err := queryNodeState(ctx, m.Pool)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Reachable = true
+	info.Role = state.Role
+	info.Timeline = state.Timeline
+	return info
+}
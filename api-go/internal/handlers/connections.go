@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// ConnectionsHandler handles connection admin endpoints.
+type ConnectionsHandler struct {
+	pool *db.Pool
+}
+
+// NewConnectionsHandler creates a new connections handler.
+func NewConnectionsHandler(pool *db.Pool) *ConnectionsHandler {
+	return &ConnectionsHandler{pool: pool}
+}
+
+// List handles GET /admin/connections - list backends, optionally filtered
+// by ?state=, ?database= and ?user=.
+func (h *ConnectionsHandler) List(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	rows, err := h.pool.Query(c.Request.Context(), `
+		SELECT
+			pid,
+			COALESCE(usename, ''),
+			COALESCE(application_name, ''),
+			client_addr::text,
+			COALESCE(datname, ''),
+			COALESCE(backend_type, ''),
+			COALESCE(state, ''),
+			backend_start, xact_start, query_start, state_change,
+			wait_event_type, wait_event,
+			COALESCE(query, '')
+		FROM pg_stat_activity
+		WHERE ($1 = '' OR state = $1)
+			AND ($2 = '' OR datname = $2)
+			AND ($3 = '' OR usename = $3)
+		ORDER BY backend_start
+	`, c.Query("state"), c.Query("database"), c.Query("user"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list connections",
+		})
+		return
+	}
+	defer rows.Close()
+
+	connections := []models.Connection{}
+	for rows.Next() {
+		var conn models.Connection
+		if err := rows.Scan(
+			&conn.PID, &conn.User, &conn.Application, &conn.ClientAddr, &conn.Database,
+			&conn.BackendType, &conn.State,
+			&conn.BackendStart, &conn.XactStart, &conn.QueryStart, &conn.StateChange,
+			&conn.WaitEventType, &conn.WaitEvent, &conn.Query,
+		); err != nil {
+			continue
+		}
+		connections = append(connections, conn)
+	}
+
+	c.JSON(http.StatusOK, models.ConnectionsResponse{
+		Connections: connections,
+		Total:       len(connections),
+		Timestamp:   time.Now().UTC(),
+	})
+}
+
+// Terminate handles POST /admin/connections/:pid/terminate - terminate a
+// backend with pg_terminate_backend.
+func (h *ConnectionsHandler) Terminate(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil || pid <= 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_pid",
+			Message: "PID must be a positive number",
+		})
+		return
+	}
+
+	var terminated bool
+	err = h.pool.QueryRow(c.Request.Context(), `
+		SELECT pg_terminate_backend(pid)
+		FROM pg_stat_activity
+		WHERE pid = $1 AND pid <> pg_backend_pid()
+	`, pid).Scan(&terminated)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No terminable backend with that PID",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to terminate backend: " + err.Error(),
+		})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Backend terminate requested", "pid", pid, "terminated", terminated, "client_ip", c.ClientIP())
+
+	c.JSON(http.StatusOK, models.TerminateResponse{
+		PID:        pid,
+		Terminated: terminated,
+		Timestamp:  time.Now().UTC(),
+	})
+}
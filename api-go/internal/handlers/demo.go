@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// DemoHandler handles the transfer demo: money moved between two accounts
+// in one transaction, which either happens completely or not at all, also
+// when the primary crashes or fails over in the middle of it.
+type DemoHandler struct {
+	items *ItemsHandler
+}
+
+// NewDemoHandler creates a new demo handler. The primary and replica
+// pools are those of items.
+func NewDemoHandler(items *ItemsHandler) *DemoHandler {
+	return &DemoHandler{items: items}
+}
+
+// Transfer handles POST /demo/transfer - debit one account and credit the
+// other in one transaction, recording the transfer.
+//
+// fail_at aborts the transaction after the debit, after the credit or
+// right before the commit, answering 500 injected_failure; the balances
+// are unchanged. pause_ms keeps the transaction open after the debit, so
+// the primary can be stopped or switched over meanwhile: the commit then
+// fails and, again, nothing was transferred.
+func (h *DemoHandler) Transfer(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pool, ok := h.items.writer(c)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// Both rows are locked in a fixed order, so opposite transfers cannot
+	// deadlock
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM demo_accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE
+	`, []string{req.From, req.To})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to lock accounts",
+		})
+		return
+	}
+	locked, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to lock accounts",
+		})
+		return
+	}
+	if len(locked) != 2 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Both accounts must exist",
+		})
+		return
+	}
+
+	response := models.TransferResponse{From: req.From, To: req.To, Amount: req.Amount}
+	err = tx.QueryRow(ctx, `
+		UPDATE demo_accounts SET balance = balance - $2, updated_at = NOW()
+		WHERE id = $1 AND balance >= $2
+		RETURNING balance
+	`, req.From, req.Amount).Scan(&response.FromBalance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "insufficient_funds",
+			Message: fmt.Sprintf("Account %s cannot cover %s", req.From, req.Amount),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to debit account",
+		})
+		return
+	}
+	if h.fail(c, req, models.TransferFailAfterDebit) {
+		return
+	}
+
+	if req.PauseMs > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(req.PauseMs) * time.Millisecond):
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE demo_accounts SET balance = balance + $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING balance
+	`, req.To, req.Amount).Scan(&response.ToBalance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to credit account",
+		})
+		return
+	}
+	if h.fail(c, req, models.TransferFailAfterCredit) {
+		return
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO demo_transfers (from_account, to_account, amount) VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.From, req.To, req.Amount).Scan(&response.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to record transfer",
+		})
+		return
+	}
+	if h.fail(c, req, models.TransferFailBeforeCommit) {
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit transfer; nothing was transferred: " + err.Error(),
+		})
+		return
+	}
+
+	response.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Timestamp = time.Now().UTC()
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, response)
+}
+
+// fail answers the injected failure when the transfer reached its failure
+// point. The deferred rollback undoes what was written so far.
+func (h *DemoHandler) fail(c *gin.Context, req models.TransferRequest, point string) bool {
+	if req.FailAt != point {
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "injected_failure",
+		Message: fmt.Sprintf("Injected failure at %s; the transaction was rolled back", point),
+	})
+	return true
+}
+
+// Accounts handles GET /demo/accounts - the balances, their total and the
+// number of transfers, read in one snapshot.
+func (h *DemoHandler) Accounts(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.items.reader(c)
+	if !ok {
+		return
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	rows, err := tx.Query(ctx, "SELECT id, balance, updated_at FROM demo_accounts ORDER BY id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list accounts",
+		})
+		return
+	}
+	response := models.DemoAccountsResponse{Accounts: []models.DemoAccount{}}
+	for rows.Next() {
+		var account models.DemoAccount
+		if err := rows.Scan(&account.ID, &account.Balance, &account.UpdatedAt); err != nil {
+			continue
+		}
+		response.Accounts = append(response.Accounts, account)
+	}
+	rows.Close()
+
+	// Summed by the database, exactly
+	err = tx.QueryRow(ctx, `
+		SELECT (SELECT coalesce(sum(balance), 0) FROM demo_accounts),
+		       (SELECT count(*) FROM demo_transfers)
+	`).Scan(&response.Total, &response.Transfers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to total accounts",
+		})
+		return
+	}
+
+	response.Timestamp = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package handlers
+
+import "errors"
+
+// diskUsage is not supported on this platform.
+func diskUsage(path string) (total, available uint64, err error) {
+	return 0, 0, errors.New("disk usage is not supported on this platform")
+}
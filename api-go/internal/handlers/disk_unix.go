@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package handlers
+
+import "syscall"
+
+// diskUsage returns the total and available bytes of the filesystem
+// containing path.
+func diskUsage(path string) (total, available uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Blocks * uint64(st.Bsize), st.Bavail * uint64(st.Bsize), nil
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/openapi"
+)
+
+// DocsHandler serves the OpenAPI document of the API and Swagger UI. The
+// document is generated from the routes of the router on first request,
+// so it lists every endpoint, including those added after this handler.
+type DocsHandler struct {
+	cfg    config.AppConfig
+	routes func() gin.RoutesInfo
+	role   func(method, path string) string
+
+	once sync.Once
+	spec openapi.Schema
+}
+
+// NewDocsHandler creates a docs handler. routes is usually the Routes
+// method of the router; role returns the API key role a route requires.
+func NewDocsHandler(cfg config.AppConfig, routes func() gin.RoutesInfo, role func(method, path string) string) *DocsHandler {
+	return &DocsHandler{cfg: cfg, routes: routes, role: role}
+}
+
+// OpenAPI handles GET /openapi.json - the OpenAPI 3 document.
+func (h *DocsHandler) OpenAPI(c *gin.Context) {
+	h.once.Do(func() {
+		var routes []openapi.Route
+		for _, r := range h.routes() {
+			routes = append(routes, openapi.Route{Method: r.Method, Path: r.Path})
+		}
+		generator := openapi.Generator{
+			Title:       "PostgreSQL HA/DR Demo API",
+			Version:     h.cfg.Version,
+			Description: "Monitoring, backup, restore and failover of a PostgreSQL HA/DR cluster, and a demo workload to exercise it.",
+			Types: map[reflect.Type]openapi.Schema{
+				reflect.TypeOf(models.Decimal("")): {"type": "number", "description": "Exact decimal; requests may also send it as a string"},
+				reflect.TypeOf(models.ItemID("")): {"oneOf": []openapi.Schema{
+					{"type": "integer", "format": "int64"},
+					{"type": "string", "format": "uuid"},
+				}},
+			},
+			Error: models.ErrorResponse{},
+		}
+		h.spec = generator.Build(routes, routeDoc, func(r openapi.Route) string { return h.role(r.Method, r.Path) })
+	})
+	c.JSON(http.StatusOK, h.spec)
+}
+
+// swaggerUI loads Swagger UI from a CDN and points it at the document.
+var swaggerUI = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.ui = SwaggerUIBundle({url: {{.URL}}, dom_id: "#swagger-ui", persistAuthorization: true});
+</script>
+</body>
+</html>
+`))
+
+// Docs handles GET /docs - Swagger UI for the OpenAPI document.
+func (h *DocsHandler) Docs(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	swaggerUI.Execute(c.Writer, map[string]string{
+		"Title": h.cfg.Name + " - API docs",
+		"URL":   "/openapi.json",
+	})
+}
+
+// routeDoc describes a route. Versioned routes share the description of
+// the unversioned one, except where v1Operations changes their shape.
+// Routes under /clusters/:name mirror the top-level monitoring endpoints
+// and share their descriptions.
+func routeDoc(r openapi.Route) openapi.Operation {
+	path := apiversion.StripPrefix(r.Path)
+	if path != r.Path {
+		if op, ok := v1Operations[r.Method+" "+path]; ok {
+			return op
+		}
+	}
+	if op, ok := operations[r.Method+" "+path]; ok {
+		return op
+	}
+	if rest, ok := strings.CutPrefix(path, "/clusters/:name/"); ok {
+		if op, ok := operations[r.Method+" /"+rest]; ok {
+			op.Tag = "clusters"
+			op.Summary += " of a monitored cluster"
+			return op
+		}
+	}
+	return openapi.Operation{Summary: r.Method + " " + r.Path}
+}
+
+// Query parameters shared by several routes.
+var (
+	limitParam     = openapi.Param{Name: "limit", Type: "integer", Description: "Maximum number of entries"}
+	skipParam      = openapi.Param{Name: "skip", Type: "integer", Description: "Number of entries to skip"}
+	fieldsParam    = openapi.Param{Name: "fields", Type: "string", Description: "Comma-separated fields to return; id is always included"}
+	followParam    = openapi.Param{Name: "follow", Type: "boolean", Description: "Stream as server-sent events, like Accept: text/event-stream"}
+	permanentParam = openapi.Param{Name: "permanent", Type: "boolean", Description: "Delete instead of moving to the trash"}
+	itemFilters    = []openapi.Param{
+		{Name: "ids", Type: "string", Description: "Comma-separated item IDs"},
+		{Name: "name", Type: "string", Description: "Case-insensitive substring of the name"},
+		{Name: "price_min", Type: "number"},
+		{Name: "price_max", Type: "number"},
+		{Name: "created_after", Type: "string", Description: "RFC 3339 timestamp"},
+		{Name: "created_before", Type: "string", Description: "RFC 3339 timestamp"},
+		{Name: "active_only", Type: "boolean"},
+	}
+)
+
+// operations documents the routes, by method and gin path.
+var operations = map[string]openapi.Operation{
+	"GET /":             {Tag: "health", Summary: "API information and links"},
+	"GET /docs":         {Tag: "docs", Summary: "Swagger UI", ContentType: "text/html"},
+	"GET /openapi.json": {Tag: "docs", Summary: "OpenAPI document of the API"},
+	"GET /health":       {Tag: "health", Summary: "Liveness and database connectivity", Response: models.HealthResponse{}},
+	"GET /health/deep":  {Tag: "health", Summary: "Health of each component: database, replicas, backups, disk", Response: models.DeepHealthResponse{}},
+	"GET /ready":        {Tag: "health", Summary: "Readiness for traffic, by database role", Query: []openapi.Param{{Name: "policy", Type: "string", Description: "any, require-primary or require-replica"}}, Response: models.ReadyResponse{}},
+	"GET /probe/write":  {Tag: "health", Summary: "Write probe: a round trip to the primary", Response: models.WriteProbeResponse{}},
+	"GET /role":         {Tag: "health", Summary: "Role of the connected database node", Response: models.RoleResponse{}},
+	"GET /startup":      {Tag: "health", Summary: "Startup phases of the API", Response: models.StartupResponse{}},
+	"GET /summary":      {Tag: "health", Summary: "Cluster summary for dashboards", Response: models.SummaryResponse{}},
+	"GET /alerts":       {Tag: "monitoring", Summary: "Active alerts", Response: models.AlertsResponse{}},
+	"GET /slo":          {Tag: "monitoring", Summary: "RPO and RTO objectives and outages", Response: models.SLOResponse{}},
+	"GET /metrics":      {Tag: "monitoring", Summary: "Database metrics", Response: models.MetricsResponse{}},
+	"GET /metrics/history": {Tag: "monitoring", Summary: "Sampled metrics over a window",
+		Query: []openapi.Param{{Name: "since", Type: "string", Description: "Window as a duration, e.g. 1h"}}, Response: models.MetricsHistoryResponse{}},
+	"GET /metrics/databases":  {Tag: "monitoring", Summary: "Per-database metrics", Response: models.DatabasesMetricsResponse{}},
+	"GET /metrics/prometheus": {Tag: "monitoring", Summary: "Metrics in the Prometheus exposition format", ContentType: "text/plain"},
+	"GET /wal/archiver":       {Tag: "monitoring", Summary: "WAL archiver status", Response: models.WALArchiverResponse{}},
+	"GET /replication/sync":   {Tag: "monitoring", Summary: "Synchronous replication status", Response: models.SyncReplicationResponse{}},
+	"GET /recovery/config":    {Tag: "monitoring", Summary: "Recovery settings of a standby", Response: models.RecoveryConfigResponse{}},
+	// Only served as /clusters/:name/identity; locally it is /cluster/identity
+	"GET /identity": {Tag: "monitoring", Summary: "System identifier and timeline history", Response: models.IdentityResponse{}},
+	"GET /locks":    {Tag: "monitoring", Summary: "Blocked locks and their blockers", Response: models.LocksResponse{}},
+	"GET /sessions/problematic": {Tag: "monitoring", Summary: "Long-running queries and idle transactions",
+		Query: []openapi.Param{
+			{Name: "max_query_age", Type: "string", Description: "Duration, e.g. 5m"},
+			{Name: "max_idle_age", Type: "string", Description: "Duration, e.g. 1m"},
+		}, Response: models.ProblematicSessionsResponse{}},
+	"GET /tables": {Tag: "monitoring", Summary: "Table statistics",
+		Query: []openapi.Param{{Name: "sort", Type: "string"}, limitParam}, Response: models.TablesResponse{}},
+	"GET /indexes": {Tag: "monitoring", Summary: "Index statistics",
+		Query: []openapi.Param{{Name: "sort", Type: "string"}, {Name: "unused_only", Type: "boolean"}, limitParam}, Response: models.IndexesResponse{}},
+	"GET /maintenance/vacuum": {Tag: "monitoring", Summary: "Vacuum progress and activity", Query: []openapi.Param{limitParam}, Response: models.VacuumResponse{}},
+	"GET /settings": {Tag: "monitoring", Summary: "PostgreSQL settings",
+		Query: []openapi.Param{
+			{Name: "category", Type: "string"},
+			{Name: "name", Type: "string"},
+			{Name: "non_default", Type: "boolean"},
+			{Name: "pending_restart", Type: "boolean"},
+		}, Response: models.SettingsResponse{}},
+
+	"GET /backups":          {Tag: "backups", Summary: "Backups of every stanza", Response: models.BackupsResponse{}},
+	"GET /backups/:stanza":  {Tag: "backups", Summary: "Backups of a stanza", Response: models.BackupResponse{}},
+	"GET /backups/schedule": {Tag: "backups", Summary: "Backup schedule and next runs", Response: models.BackupScheduleResponse{}},
+	"GET /backups/trends": {Tag: "backups", Summary: "Backup size and duration trends",
+		Query: []openapi.Param{{Name: "stanza", Type: "string"}, {Name: "type", Type: "string", Description: "full, diff or incr"}}, Response: models.BackupTrendsResponse{}},
+	"GET /backups/history": {Tag: "backups", Summary: "Catalog of finished jobs",
+		Query: []openapi.Param{
+			{Name: "type", Type: "string"}, {Name: "status", Type: "string"}, {Name: "trigger", Type: "string"}, {Name: "stanza", Type: "string"},
+			{Name: "since", Type: "string", Description: "RFC 3339 timestamp"}, {Name: "until", Type: "string", Description: "RFC 3339 timestamp"},
+			limitParam, {Name: "offset", Type: "integer"},
+		}, Response: models.JobHistoryResponse{}},
+	"POST /backups/stanza":         {Tag: "backups", Summary: "Create a stanza", Request: models.StanzaRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+	"POST /backups/stanza/upgrade": {Tag: "backups", Summary: "Upgrade a stanza after a major upgrade", Request: models.StanzaRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+	"POST /restore": {Tag: "backups", Summary: "Restore from a backup, or plan it with dry_run",
+		Request: models.RestoreRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+	"GET /restore/plan": {Tag: "backups", Summary: "Plan a restore without running it",
+		Query: []openapi.Param{
+			{Name: "target", Type: "string", Description: "latest, backup, time or lsn", Required: true},
+			{Name: "backup_label", Type: "string"}, {Name: "timestamp", Type: "string"}, {Name: "lsn", Type: "string"},
+			{Name: "target_action", Type: "string"}, {Name: "delta", Type: "boolean"},
+		}, Response: models.RestorePlanResponse{}},
+
+	"GET /items": {Tag: "items", Summary: "List items",
+		Description: "Returns an array, or a page with the total and the next URL with envelope=true or Accept: application/vnd.items-page+json.",
+		Query: append([]openapi.Param{
+			skipParam, limitParam,
+			{Name: "after_id", Type: "string", Description: "Keyset pagination: items after this ID"},
+			{Name: "sort", Type: "string", Description: "Comma-separated fields, - for descending"},
+			{Name: "envelope", Type: "boolean"}, fieldsParam,
+		}, itemFilters...), Response: []models.Item{}},
+	"POST /items":                {Tag: "items", Summary: "Create an item", Request: models.ItemCreate{}, Response: models.Item{}, Status: http.StatusCreated},
+	"PUT /items":                 {Tag: "items", Summary: "Insert or update items by ID", Request: models.ItemsUpsertRequest{}, Response: models.ItemsUpsertResponse{}},
+	"GET /items/:id":             {Tag: "items", Summary: "Get an item", Query: []openapi.Param{fieldsParam}, Response: models.Item{}},
+	"PUT /items/:id":             {Tag: "items", Summary: "Replace an item; requires If-Match", Request: models.ItemUpdate{}, Response: models.Item{}},
+	"PATCH /items/:id":           {Tag: "items", Summary: "Update the supplied fields of an item; requires If-Match", Request: models.ItemUpdate{}, Response: models.Item{}},
+	"DELETE /items/:id":          {Tag: "items", Summary: "Move an item to the trash", Query: []openapi.Param{permanentParam}, Status: http.StatusNoContent},
+	"POST /items/:id/restore":    {Tag: "items", Summary: "Restore an item from the trash", Response: models.Item{}},
+	"GET /items/:id/versions":    {Tag: "items", Summary: "Previous versions of an item", Response: []models.ItemVersion{}},
+	"GET /items/:id/versions/:n": {Tag: "items", Summary: "One version of an item", Response: models.ItemVersion{}},
+	"GET /items/trash":           {Tag: "items", Summary: "Items in the trash", Query: []openapi.Param{skipParam, limitParam}, Response: []models.Item{}},
+	"POST /items/batch-get":      {Tag: "items", Summary: "Get several items by ID", Request: models.ItemsBatchGetRequest{}, Response: models.ItemsBatchResponse{}},
+	"POST /items/bulk-update":    {Tag: "items", Summary: "Update several items", Request: models.ItemsBulkUpdateRequest{}, Response: models.ItemsBulkResult{}},
+	"POST /items/bulk-delete": {Tag: "items", Summary: "Delete several items",
+		Query: []openapi.Param{permanentParam}, Request: models.ItemsBulkDeleteRequest{}, Response: models.ItemsBulkResult{}},
+	"POST /items/bulk": {Tag: "items", Summary: "Create items in batches from a JSON array or NDJSON",
+		Query: []openapi.Param{{Name: "batch_size", Type: "integer"}}, Request: []models.ItemCreate{}, Response: models.ItemsBulkResponse{}},
+	"POST /items/import": {Tag: "items", Summary: "Import items from an uploaded CSV or NDJSON file",
+		Query:    []openapi.Param{{Name: "format", Type: "string", Description: "csv or ndjson; taken from the upload by default"}},
+		Response: models.ItemsImportResponse{}, Status: http.StatusCreated},
+	"GET /items/export": {Tag: "items", Summary: "Stream items as CSV or NDJSON",
+		Query:       append([]openapi.Param{{Name: "format", Type: "string", Description: "csv or ndjson"}, fieldsParam}, itemFilters...),
+		ContentType: "text/csv"},
+
+	"GET /orders":             {Tag: "orders", Summary: "List orders", Query: []openapi.Param{skipParam, limitParam}, Response: []models.Order{}},
+	"POST /orders":            {Tag: "orders", Summary: "Place an order", Request: models.OrderCreate{}, Response: models.Order{}, Status: http.StatusCreated},
+	"GET /orders/:id":         {Tag: "orders", Summary: "Get an order", Response: models.Order{}},
+	"POST /orders/:id/cancel": {Tag: "orders", Summary: "Cancel an order", Response: models.Order{}},
+	"GET /orders/integrity":   {Tag: "orders", Summary: "Check order totals against their lines", Response: models.OrdersIntegrityResponse{}},
+	"POST /demo/transfer":     {Tag: "demo", Summary: "Transfer between demo accounts in one transaction", Request: models.TransferRequest{}, Response: models.TransferResponse{}},
+	"GET /demo/accounts":      {Tag: "demo", Summary: "Demo account balances", Response: models.DemoAccountsResponse{}},
+
+	"GET /jobs": {Tag: "jobs", Summary: "Background jobs",
+		Query: []openapi.Param{{Name: "status", Type: "string"}, {Name: "type", Type: "string"}}, Response: models.JobsResponse{}},
+	"GET /jobs/:id":      {Tag: "jobs", Summary: "Get a job", Response: models.Job{}},
+	"DELETE /jobs/:id":   {Tag: "jobs", Summary: "Cancel a job", Response: models.Job{}, Status: http.StatusAccepted},
+	"GET /jobs/:id/logs": {Tag: "jobs", Summary: "Output of a job, or a stream of it", Query: []openapi.Param{followParam}, ContentType: "text/plain"},
+
+	"GET /dr/status":   {Tag: "dr", Summary: "Replication to the DR site", Response: models.DRStatusResponse{}},
+	"GET /dr/position": {Tag: "dr", Summary: "WAL position of this site", Response: models.DRPosition{}},
+	"POST /drills":     {Tag: "dr", Summary: "Run a DR drill: switch over and back", Request: models.DrillRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+
+	"GET /cluster":              {Tag: "cluster", Summary: "Patroni cluster members", Response: models.ClusterResponse{}},
+	"GET /cluster/identity":     {Tag: "cluster", Summary: "System identifier and timeline history", Response: models.IdentityResponse{}},
+	"POST /cluster/switchover":  {Tag: "cluster", Summary: "Hand leadership to a replica", Request: models.SwitchoverRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+	"POST /cluster/failover":    {Tag: "cluster", Summary: "Promote a replica without a healthy leader", Request: models.FailoverRequest{}, Response: models.Job{}, Status: http.StatusAccepted},
+	"GET /cluster/maintenance":  {Tag: "cluster", Summary: "Maintenance mode", Response: models.MaintenanceResponse{}},
+	"POST /cluster/maintenance": {Tag: "cluster", Summary: "Turn maintenance mode on or off", Request: models.MaintenanceRequest{}, Response: models.MaintenanceResponse{}},
+	"GET /events":               {Tag: "cluster", Summary: "Cluster events", Query: []openapi.Param{{Name: "after", Type: "integer"}, {Name: "type", Type: "string"}, limitParam, followParam}, Response: models.EventsResponse{}},
+	"GET /topology":             {Tag: "cluster", Summary: "Discovered replication topology", Response: models.TopologyResponse{}},
+	"GET /topology/graph":       {Tag: "cluster", Summary: "Topology as a graph, or DOT with format=dot", Query: []openapi.Param{{Name: "format", Type: "string"}}, Response: models.TopologyGraphResponse{}},
+	"GET /clusters":             {Tag: "clusters", Summary: "Monitored clusters", Response: models.ClustersResponse{}},
+
+	"GET /admin/connections": {Tag: "admin", Summary: "Backend connections",
+		Query: []openapi.Param{{Name: "database", Type: "string"}, {Name: "user", Type: "string"}, {Name: "state", Type: "string"}}, Response: models.ConnectionsResponse{}},
+	"POST /admin/connections/:pid/terminate": {Tag: "admin", Summary: "Terminate a backend", Response: models.TerminateResponse{}},
+	"POST /admin/promote":                    {Tag: "admin", Summary: "Promote a standby outside Patroni", Request: models.PromoteRequest{}, Response: models.PromoteResponse{}},
+	"GET /admin/migrations":                  {Tag: "admin", Summary: "Schema migrations and whether they are applied", Response: models.MigrationsResponse{}},
+	"POST /admin/migrate":                    {Tag: "admin", Summary: "Apply pending schema migrations", Response: models.MigrationsResponse{}},
+}
+
+// v1Operations documents the routes whose shape changed in /v1.
+var v1Operations = map[string]openapi.Operation{
+	"GET /items": {Tag: "items", Summary: "List items, one page at a time",
+		Query: append([]openapi.Param{
+			skipParam, limitParam,
+			{Name: "after_id", Type: "string", Description: "Keyset pagination: items after this ID"},
+			{Name: "sort", Type: "string", Description: "Comma-separated fields, - for descending"},
+			fieldsParam,
+		}, itemFilters...), Response: models.ItemsPage{}},
+}
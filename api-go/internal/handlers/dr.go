@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// positionQuery reads how far the node has written (primary) or replayed
+// (standby) WAL.
+const positionQuery = `
+	SELECT pg_is_in_recovery(),
+		(SELECT system_identifier::text FROM pg_control_system()),
+		(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END)::text,
+		pg_last_wal_receive_lsn()::text,
+		pg_last_xact_replay_timestamp()
+`
+
+// DR status values.
+const (
+	DRStatusOK                 = "ok"
+	DRStatusPrimaryUnavailable = "primary_unavailable"
+	DRStatusUnreachable        = "dr_unreachable"
+	DRStatusNotStandby         = "dr_not_standby"
+	DRStatusMismatch           = "identifier_mismatch"
+)
+
+// DRHandler compares this site with the standby of the remote DR site.
+type DRHandler struct {
+	cfg    *config.Config
+	pool   *db.Pool
+	client *http.Client
+}
+
+// NewDRHandler creates a new DR handler.
+func NewDRHandler(cfg *config.Config, pool *db.Pool) *DRHandler {
+	return &DRHandler{
+		cfg:    cfg,
+		pool:   pool,
+		client: &http.Client{Timeout: cfg.DR.Timeout},
+	}
+}
+
+// Position handles GET /dr/position - this node's WAL position, which the
+// API at the other site reads when DR_API_URL points here.
+func (h *DRHandler) Position(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	position, err := queryPosition(c.Request.Context(), h.pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read WAL position: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, position)
+}
+
+// Status handles GET /dr/status - the replication lag between the local
+// primary and the DR standby in bytes and seconds.
+func (h *DRHandler) Status(c *gin.Context) {
+	if !h.cfg.DR.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "not_configured",
+			Message: "Set DR_HOST or DR_API_URL to enable DR monitoring",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	response := models.DRStatusResponse{
+		Site:      h.cfg.DR.Site,
+		Source:    "host",
+		Status:    DRStatusOK,
+		Timestamp: time.Now().UTC(),
+	}
+	if h.cfg.DR.Host == "" {
+		response.Source = "api"
+	}
+
+	if h.pool == nil {
+		response.Status = DRStatusPrimaryUnavailable
+		response.Message = "Database pool is not initialized"
+	} else if primary, err := queryPosition(ctx, h.pool); err != nil {
+		response.Status = DRStatusPrimaryUnavailable
+		response.Message = err.Error()
+	} else {
+		response.Primary = &primary
+	}
+
+	standby, err := h.remotePosition(ctx)
+	if err != nil {
+		if response.Status == DRStatusOK {
+			response.Status = DRStatusUnreachable
+			response.Message = err.Error()
+		}
+	} else {
+		response.Standby = &standby
+	}
+
+	if response.Primary == nil || response.Standby == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	switch {
+	case response.Primary.SystemIdentifier != response.Standby.SystemIdentifier:
+		response.Status = DRStatusMismatch
+		response.Message = fmt.Sprintf("The DR node belongs to system %s, not %s",
+			response.Standby.SystemIdentifier, response.Primary.SystemIdentifier)
+	case !response.Standby.InRecovery:
+		response.Status = DRStatusNotStandby
+		response.Message = "The DR node is not in recovery; it was promoted or is not a standby"
+	default:
+		response.LagBytes, response.LagSeconds = drLag(*response.Primary, *response.Standby)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// drLag is the WAL the standby has not replayed yet and, when it is
+// behind, the age of its last replayed commit.
+func drLag(primary, standby models.DRPosition) (*int64, *float64) {
+	if primary.LSN == nil || standby.LSN == nil {
+		return nil, nil
+	}
+	p, err := wal.ParseLSN(*primary.LSN)
+	if err != nil {
+		return nil, nil
+	}
+	s, err := wal.ParseLSN(*standby.LSN)
+	if err != nil {
+		return nil, nil
+	}
+
+	bytes := int64(p) - int64(s)
+	if bytes < 0 {
+		bytes = 0
+	}
+	var seconds float64
+	if bytes > 0 {
+		if standby.LastReplayTime == nil {
+			return &bytes, nil
+		}
+		seconds = primary.Timestamp.Sub(*standby.LastReplayTime).Seconds()
+	}
+	return &bytes, &seconds
+}
+
+// remotePosition reads the DR standby's position from the database when
+// DR_HOST is set, and from the DR site's API otherwise.
+func (h *DRHandler) remotePosition(ctx context.Context) (models.DRPosition, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.DR.Timeout)
+	defer cancel()
+
+	if h.cfg.DR.Host != "" {
+		host, port, err := h.cfg.Database.ParseHostPort(h.cfg.DR.Host)
+		if err != nil {
+			return models.DRPosition{}, err
+		}
+		conn, err := pgx.Connect(ctx, h.cfg.Database.DSNForHost(host, port))
+		if err != nil {
+			return models.DRPosition{}, err
+		}
+		defer conn.Close(context.Background())
+		return queryPosition(ctx, conn)
+	}
+
+	var position models.DRPosition
+	url := strings.TrimRight(h.cfg.DR.APIURL, "/") + "/dr/position"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return position, err
+	}
+	requestid.Propagate(req)
+	tracing.Inject(req)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return position, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return position, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return position, fmt.Errorf("DR API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	err = json.Unmarshal(data, &position)
+	return position, err
+}
+
+// queryPosition reads the WAL position of the node behind q.
+func queryPosition(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) (models.DRPosition, error) {
+	position := models.DRPosition{Timestamp: time.Now().UTC()}
+	err := q.QueryRow(ctx, positionQuery).Scan(
+		&position.InRecovery, &position.SystemIdentifier,
+		&position.LSN, &position.ReceiveLSN, &position.LastReplayTime,
+	)
+	return position, err
+}
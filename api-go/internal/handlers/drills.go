@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Drill steps, in the order they run.
+const (
+	DrillStepVerifyBackups = "verify_backups"
+	DrillStepSwitchover    = "switchover"
+	DrillStepWorkload      = "workload"
+	DrillStepSwitchBack    = "switch_back"
+)
+
+// defaultDrillWrites is how many synthetic writes the workload step
+// makes unless the request says otherwise.
+const defaultDrillWrites = 20
+
+// DrillHandler runs DR rehearsals: a switchover, a synthetic workload on
+// the new leader and a switch back, timed step by step.
+type DrillHandler struct {
+	cluster *ClusterHandler
+	probe   *ProbeHandler
+	jobs    *jobs.Manager
+}
+
+// NewDrillHandler creates a new drill handler. Leadership changes go
+// through cluster and the workload through probe.
+func NewDrillHandler(cluster *ClusterHandler, probe *ProbeHandler, manager *jobs.Manager) *DrillHandler {
+	return &DrillHandler{cluster: cluster, probe: probe, jobs: manager}
+}
+
+// Run handles POST /drills - start a drill as an async job whose result
+// is a models.DrillReport.
+//
+// The switchover preconditions are checked up front and refused with 412
+// like POST /cluster/switchover. Once the switchover has happened, the
+// switch back is attempted even when the workload fails.
+func (h *DrillHandler) Run(c *gin.Context) {
+	var req models.DrillRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if req.WorkloadWrites == 0 {
+		req.WorkloadWrites = defaultDrillWrites
+	}
+
+	if !h.cluster.checkIdle(c) {
+		return
+	}
+	cluster, ok := h.cluster.cluster(c)
+	if !ok {
+		return
+	}
+	leader, hasLeader := cluster.Leader()
+	var failures []string
+	if !hasLeader {
+		failures = append(failures, "the cluster has no leader")
+	}
+	failures = append(failures, h.cluster.candidateFailures(cluster, req.Candidate)...)
+	if len(failures) > 0 {
+		preconditionFailed(c, "drill", failures)
+		return
+	}
+
+	params := map[string]interface{}{
+		"leader":           leader.Name,
+		"workload_writes":  req.WorkloadWrites,
+		"skip_switch_back": req.SkipSwitchBack,
+	}
+	if req.Candidate != "" {
+		params["candidate"] = req.Candidate
+	}
+
+	job, err := h.cluster.submitLeaderChange(JobTypeDrill, params, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		report := h.run(ctx, out, leader.Name, req)
+		if !report.Succeeded {
+			return report, errors.New(drillFailure(report))
+		}
+		return report, nil
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "DR drill requested", "leader", leader.Name, "client_ip", c.ClientIP())
+	writeJobAccepted(c, job)
+}
+
+// run executes the drill steps and returns the report.
+func (h *DrillHandler) run(ctx context.Context, out *jobs.Output, original string, req models.DrillRequest) *models.DrillReport {
+	report := &models.DrillReport{
+		OriginalLeader: original,
+		StartedAt:      time.Now().UTC(),
+		Steps:          []models.DrillStep{},
+	}
+	failed := false
+
+	step := func(name string, skip bool, fn func(details map[string]interface{}) error) bool {
+		s := models.DrillStep{Name: name, Status: models.DrillStepSkipped, Details: map[string]interface{}{}}
+		if !skip {
+			out.Report("drill step " + name)
+			start := time.Now().UTC()
+			err := fn(s.Details)
+			end := time.Now().UTC()
+			s.StartedAt, s.FinishedAt = &start, &end
+			s.DurationSeconds = end.Sub(start).Seconds()
+			s.Status = models.DrillStepSucceeded
+			if err != nil {
+				s.Status = models.DrillStepFailed
+				s.Error = err.Error()
+				fmt.Fprintf(out, "step %s failed: %v\n", name, err)
+				failed = true
+			}
+		}
+		if len(s.Details) == 0 {
+			s.Details = nil
+		}
+		report.Steps = append(report.Steps, s)
+		return !skip && s.Status == models.DrillStepSucceeded
+	}
+
+	backupsOK := step(DrillStepVerifyBackups, false, func(details map[string]interface{}) error {
+		details["max_backup_age_seconds"] = h.cluster.cfg.Patroni.MaxBackupAge.Seconds()
+		if failures := h.cluster.backupFailures(ctx); len(failures) > 0 {
+			return errors.New(strings.Join(failures, "; "))
+		}
+		return nil
+	})
+
+	switched := step(DrillStepSwitchover, !backupsOK, func(details map[string]interface{}) error {
+		details["from"] = original
+		if err := h.cluster.patroni.Switchover(ctx, original, req.Candidate); err != nil {
+			return err
+		}
+		leader, err := h.cluster.waitForLeader(ctx, out, original, req.Candidate)
+		if err != nil {
+			return err
+		}
+		report.DrillLeader = leader.Name
+		details["to"] = leader.Name
+		details["timeline"] = leader.Timeline
+		return nil
+	})
+
+	step(DrillStepWorkload, !switched, func(details map[string]interface{}) error {
+		return h.workload(ctx, req.WorkloadWrites, details)
+	})
+
+	step(DrillStepSwitchBack, !switched || req.SkipSwitchBack, func(details map[string]interface{}) error {
+		details["from"] = report.DrillLeader
+		details["to"] = original
+		if err := h.cluster.patroni.Switchover(ctx, report.DrillLeader, original); err != nil {
+			return err
+		}
+		leader, err := h.cluster.waitForLeader(ctx, out, report.DrillLeader, original)
+		if err != nil {
+			return err
+		}
+		details["timeline"] = leader.Timeline
+		return nil
+	})
+
+	report.FinishedAt = time.Now().UTC()
+	report.DurationSeconds = report.FinishedAt.Sub(report.StartedAt).Seconds()
+	report.Succeeded = !failed && switched
+	return report
+}
+
+// workload makes the requested number of synthetic writes through the
+// write probe, retrying until SWITCHOVER_TIMEOUT while the write pool
+// catches up with the new leader. The time to the first successful write
+// is what clients would have experienced as downtime.
+func (h *DrillHandler) workload(ctx context.Context, writes int, details map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cluster.cfg.Patroni.SwitchoverTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var succeeded, failed int
+	var total, slowest time.Duration
+	var lastErr error
+	for succeeded < writes && ctx.Err() == nil {
+		var probe models.WriteProbeResponse
+		began := time.Now()
+		err := h.probe.write(ctx, &probe)
+		took := time.Since(began)
+		if err != nil {
+			failed++
+			lastErr = err
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		if succeeded == 0 {
+			details["first_write_after_seconds"] = time.Since(start).Seconds()
+			details["node"] = probe.Node
+		}
+		succeeded++
+		total += took
+		if took > slowest {
+			slowest = took
+		}
+	}
+
+	details["writes"] = succeeded
+	details["failed_writes"] = failed
+	if succeeded > 0 {
+		details["avg_latency_ms"] = float64(total.Microseconds()) / float64(succeeded) / 1000
+		details["max_latency_ms"] = float64(slowest.Microseconds()) / 1000
+	}
+	if succeeded < writes {
+		return fmt.Errorf("only %d of %d writes succeeded: %v", succeeded, writes, lastErr)
+	}
+	return nil
+}
+
+// drillFailure names the failed steps of a report.
+func drillFailure(report *models.DrillReport) string {
+	var failed []string
+	for _, s := range report.Steps {
+		if s.Status == models.DrillStepFailed {
+			failed = append(failed, s.Name+": "+s.Error)
+		}
+	}
+	return "drill failed: " + strings.Join(failed, "; ")
+}
@@ -2,40 +2,156 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/postgresql-ha-dr/api-go/internal/config"
 	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
 	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/version"
 )
 
 // HealthHandler handles health check endpoints.
 type HealthHandler struct {
-	cfg  *config.Config
-	pool *db.Pool
+	cfg       *config.Config
+	pool      *db.Pool
+	backups   *BackupsHandler
+	startedAt time.Time
+
+	maintenance *lifecycle.Maintenance
+
+	// server_version is cached and refreshed in the background so
+	// liveness checks never wait on the database
+	mu                   sync.Mutex
+	serverVersion        *string
+	serverVersionChecked time.Time
+	refreshingVersion    bool
 }
 
-// NewHealthHandler creates a new health handler.
-func NewHealthHandler(cfg *config.Config, pool *db.Pool) *HealthHandler {
+// NewHealthHandler creates a new health handler. Backup health comes from
+// the cached status of backups.
+func NewHealthHandler(cfg *config.Config, pool *db.Pool, backups *BackupsHandler) *HealthHandler {
 	return &HealthHandler{
-		cfg:  cfg,
-		pool: pool,
+		cfg:       cfg,
+		pool:      pool,
+		backups:   backups,
+		startedAt: time.Now().UTC(),
 	}
 }
 
-// Health handles GET /health - basic liveness check.
+// UseMaintenance makes /ready answer 503 while m is enabled, taking the
+// node out of load balancer rotation.
+func (h *HealthHandler) UseMaintenance(m *lifecycle.Maintenance) {
+	h.maintenance = m
+}
+
+// Health handles GET /health - basic liveness check with process details.
 func (h *HealthHandler) Health(c *gin.Context) {
-	c.JSON(http.StatusOK, models.HealthResponse{
-		Status:    "healthy",
-		Version:   h.cfg.App.Version,
+	c.JSON(http.StatusOK, h.Check(c.Request.Context()))
+}
+
+// Check returns the liveness and process details served by GET /health.
+func (h *HealthHandler) Check(ctx context.Context) models.HealthResponse {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return models.HealthResponse{
+		Status:        "healthy",
+		Version:       h.cfg.App.Version,
+		Commit:        version.GetCommit(),
+		StartedAt:     h.startedAt,
+		UptimeSeconds: time.Since(h.startedAt).Seconds(),
+		ServerVersion: h.cachedServerVersion(),
+		Runtime: models.RuntimeInfo{
+			GoVersion:      runtime.Version(),
+			GOMAXPROCS:     runtime.GOMAXPROCS(0),
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapSysBytes:   mem.HeapSys,
+			NumGC:          mem.NumGC,
+		},
 		Timestamp: time.Now().UTC(),
-	})
+	}
+}
+
+// cachedServerVersion returns the connected server's version without
+// waiting: a stale value, checked over a minute ago, is refreshed in the
+// background. It returns nil until the database has been reached once.
+func (h *HealthHandler) cachedServerVersion() *string {
+	if h.pool == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.refreshingVersion && time.Since(h.serverVersionChecked) >= time.Minute {
+		h.refreshingVersion = true
+		go h.refreshServerVersion()
+	}
+	return h.serverVersion
+}
+
+// refreshServerVersion queries the server version with a short timeout.
+// A failed check keeps the last version and counts as a check, so an
+// outage does not turn every probe into a query.
+func (h *HealthHandler) refreshServerVersion() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var v string
+	err := h.pool.QueryRow(ctx, "SHOW server_version").Scan(&v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.serverVersion = &v
+	}
+	h.serverVersionChecked = time.Now()
+	h.refreshingVersion = false
 }
 
 // Ready handles GET /ready - readiness check with database connectivity.
+//
+// When a role policy is configured (or passed as ?policy=), the node must
+// also have the expected role, so load balancers can route on it during
+// failover.
 func (h *HealthHandler) Ready(c *gin.Context) {
+	policy := c.DefaultQuery("policy", h.cfg.Health.ReadyRolePolicy)
+	if policy == "" {
+		policy = config.RolePolicyAny
+	}
+	if !config.ValidRolePolicy(policy) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_policy",
+			Message: "policy must be one of: any, require-primary, require-replica",
+		})
+		return
+	}
+
+	if h.maintenance != nil {
+		if state := h.maintenance.State(); state.Enabled {
+			reason := "maintenance mode"
+			if state.Reason != "" {
+				reason += ": " + state.Reason
+			}
+			c.JSON(http.StatusServiceUnavailable, models.ReadyResponse{
+				Status:    "maintenance",
+				Database:  "unknown",
+				Reason:    reason,
+				Timestamp: time.Now().UTC(),
+			})
+			return
+		}
+	}
+
 	dbStatus := "unknown"
 
 	if h.pool != nil {
@@ -59,7 +175,15 @@ func (h *HealthHandler) Ready(c *gin.Context) {
 		Timestamp: time.Now().UTC(),
 	}
 
-	if status == "not_ready" {
+	if status == "ready" && policy != config.RolePolicyAny {
+		response.Policy = policy
+		if err := h.checkRole(c.Request.Context(), policy, &response); err != nil {
+			response.Status = "not_ready"
+			response.Reason = "role check failed: " + err.Error()
+		}
+	}
+
+	if response.Status == "not_ready" {
 		c.JSON(http.StatusServiceUnavailable, response)
 		return
 	}
@@ -67,12 +191,294 @@ func (h *HealthHandler) Ready(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// checkRole fills in the node role and marks the response not ready when
+// it does not satisfy policy.
+func (h *HealthHandler) checkRole(ctx context.Context, policy string, response *models.ReadyResponse) error {
+	var isInRecovery, readOnly bool
+	err := h.pool.QueryRow(ctx, `
+		SELECT pg_is_in_recovery(), current_setting('transaction_read_only') = 'on'
+	`).Scan(&isInRecovery, &readOnly)
+	if err != nil {
+		return err
+	}
+
+	response.Role = "primary"
+	if isInRecovery {
+		response.Role = "replica"
+	}
+	response.ReadOnly = &readOnly
+
+	switch policy {
+	case config.RolePolicyRequirePrimary:
+		if isInRecovery || readOnly {
+			response.Status = "not_ready"
+			response.Reason = "node is not a writable primary"
+		}
+	case config.RolePolicyRequireReplica:
+		if !isInRecovery {
+			response.Status = "not_ready"
+			response.Reason = "node is not a replica"
+		}
+	}
+	return nil
+}
+
+// Deep handles GET /health/deep - per-component health breakdown.
+func (h *HealthHandler) Deep(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	// The backups and backup_sla checks share one cached backup report
+	backupReport := sync.OnceValue(func() models.BackupsResponse {
+		report, _ := h.backups.Report(ctx)
+		return report
+	})
+
+	checks := map[string]func(context.Context) models.ComponentHealth{
+		"database":    h.checkDatabase,
+		"replication": h.checkReplication,
+		"backups": func(ctx context.Context) models.ComponentHealth {
+			return h.checkBackups(backupReport())
+		},
+		"backup_sla": func(ctx context.Context) models.ComponentHealth {
+			return h.checkBackupSLA(ctx, backupReport())
+		},
+		"disk": h.checkDisk,
+	}
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		components = make(map[string]models.ComponentHealth, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) models.ComponentHealth) {
+			defer wg.Done()
+			start := time.Now()
+			result := check(ctx)
+			result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+			mu.Lock()
+			components[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := models.ComponentHealthy
+	for _, component := range components {
+		switch component.Status {
+		case models.ComponentUnhealthy:
+			status = models.ComponentUnhealthy
+		case models.ComponentDegraded:
+			if status != models.ComponentUnhealthy {
+				status = models.ComponentDegraded
+			}
+		}
+	}
+
+	code := http.StatusOK
+	if status == models.ComponentUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, models.DeepHealthResponse{
+		Status:     status,
+		Version:    h.cfg.App.Version,
+		Components: components,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// checkDatabase verifies the database answers a trivial query.
+func (h *HealthHandler) checkDatabase(ctx context.Context) models.ComponentHealth {
+	if h.pool == nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnhealthy,
+			Message: "database pool not initialized",
+		}
+	}
+	if err := h.pool.HealthCheck(ctx); err != nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnhealthy,
+			Message: err.Error(),
+		}
+	}
+	return models.ComponentHealth{Status: models.ComponentHealthy}
+}
+
+// checkReplication reports replay lag on a replica, or the number of
+// streaming standbys on a primary.
+func (h *HealthHandler) checkReplication(ctx context.Context) models.ComponentHealth {
+	if h.pool == nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnknown,
+			Message: "database pool not initialized",
+		}
+	}
+
+	var isInRecovery bool
+	if err := h.pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&isInRecovery); err != nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnknown,
+			Message: "failed to check recovery status: " + err.Error(),
+		}
+	}
+
+	if !isInRecovery {
+		var streaming int
+		err := h.pool.QueryRow(ctx,
+			"SELECT count(*) FROM pg_stat_replication WHERE state = 'streaming'",
+		).Scan(&streaming)
+		if err != nil {
+			return models.ComponentHealth{
+				Status:  models.ComponentUnknown,
+				Message: "failed to query pg_stat_replication: " + err.Error(),
+			}
+		}
+
+		result := models.ComponentHealth{
+			Status: models.ComponentHealthy,
+			Details: map[string]interface{}{
+				"role":               "primary",
+				"streaming_replicas": streaming,
+			},
+		}
+		if streaming == 0 {
+			result.Status = models.ComponentDegraded
+			result.Message = "no streaming replicas connected"
+		}
+		return result
+	}
+
+	var lag *int64
+	err := h.pool.QueryRow(ctx, `
+		SELECT pg_wal_lsn_diff(pg_last_wal_receive_lsn(), pg_last_wal_replay_lsn())::bigint
+	`).Scan(&lag)
+	if err != nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnknown,
+			Message: "failed to compute replication lag: " + err.Error(),
+		}
+	}
+
+	result := models.ComponentHealth{
+		Status: models.ComponentHealthy,
+		Details: map[string]interface{}{
+			"role":             "replica",
+			"replay_lag_bytes": lag,
+		},
+	}
+	switch {
+	case lag == nil:
+		result.Status = models.ComponentDegraded
+		result.Message = "replica is not receiving WAL"
+	case *lag > h.cfg.Health.MaxReplicationLagBytes:
+		result.Status = models.ComponentDegraded
+		result.Message = fmt.Sprintf("replay lag %d bytes exceeds %d", *lag, h.cfg.Health.MaxReplicationLagBytes)
+	}
+	return result
+}
+
+// checkBackups summarizes the status of every stanza; the component is as
+// healthy as the worst of them.
+func (h *HealthHandler) checkBackups(report models.BackupsResponse) models.ComponentHealth {
+	result := models.ComponentHealth{
+		Status:  models.ComponentHealthy,
+		Details: map[string]interface{}{"status": report.Status},
+	}
+	stanzas := make(map[string]interface{}, len(report.Stanzas))
+	var messages []string
+	for i, backups := range report.Stanzas {
+		status := backupComponentStatus(backups.Status)
+		details := map[string]interface{}{
+			"status":       backups.Status,
+			"backup_count": len(backups.Backups),
+		}
+		if backups.LastFullBackup != nil {
+			details["last_full_backup"] = backups.LastFullBackup
+		}
+		if backups.StatusMessage != nil {
+			messages = append(messages, backups.Stanza+": "+*backups.StatusMessage)
+		}
+		stanzas[backups.Stanza] = details
+		if i == 0 || componentRank[status] > componentRank[result.Status] {
+			result.Status = status
+		}
+	}
+	result.Details["stanzas"] = stanzas
+	result.Message = strings.Join(messages, "; ")
+	return result
+}
+
+// componentRank orders component states from best to worst.
+var componentRank = map[string]int{
+	models.ComponentHealthy:   0,
+	models.ComponentUnknown:   1,
+	models.ComponentDegraded:  2,
+	models.ComponentUnhealthy: 3,
+}
+
+// backupComponentStatus maps a backup status to a component health state.
+func backupComponentStatus(status string) string {
+	switch status {
+	case "ok":
+		return models.ComponentHealthy
+	case "not_installed":
+		return models.ComponentUnknown
+	case "no_backup":
+		return models.ComponentDegraded
+	default:
+		return models.ComponentUnhealthy
+	}
+}
+
+// checkDisk reports filesystem usage for the configured path.
+func (h *HealthHandler) checkDisk(ctx context.Context) models.ComponentHealth {
+	path := h.cfg.Health.DiskPath
+	total, available, err := diskUsage(path)
+	if err != nil {
+		return models.ComponentHealth{
+			Status:  models.ComponentUnknown,
+			Message: err.Error(),
+		}
+	}
+
+	var usedPercent float64
+	if total > 0 {
+		usedPercent = float64(total-available) / float64(total) * 100
+	}
+
+	result := models.ComponentHealth{
+		Status: models.ComponentHealthy,
+		Details: map[string]interface{}{
+			"path":            path,
+			"total_bytes":     total,
+			"available_bytes": available,
+			"used_percent":    usedPercent,
+		},
+	}
+	switch {
+	case usedPercent >= h.cfg.Health.DiskCriticalPercent:
+		result.Status = models.ComponentUnhealthy
+		result.Message = fmt.Sprintf("disk usage %.1f%% exceeds %.1f%%", usedPercent, h.cfg.Health.DiskCriticalPercent)
+	case usedPercent >= h.cfg.Health.DiskWarnPercent:
+		result.Status = models.ComponentDegraded
+		result.Message = fmt.Sprintf("disk usage %.1f%% exceeds %.1f%%", usedPercent, h.cfg.Health.DiskWarnPercent)
+	}
+	return result
+}
+
 // Root handles GET / - API info.
 func (h *HealthHandler) Root(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "PostgreSQL HA/DR Demo API (Go)",
 		"docs":    "/docs",
+		"openapi": "/openapi.json",
 		"health":  "/health",
+		"deep":    "/health/deep",
 		"ready":   "/ready",
+		"startup": "/startup",
 	})
 }
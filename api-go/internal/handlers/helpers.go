@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func init() {
+	// Binding tags such as gte=0 on a decimal compare its value, not the
+	// length of its text
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			return field.Interface().(models.Decimal).Float64()
+		}, models.Decimal(""))
+	}
+}
+
+// Error is a failed request with the status and error code of its
+// response. Methods shared by the REST and gRPC APIs return it instead of
+// writing a response, for each API to map in its own way.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Failures lists the unmet preconditions of a 412.
+	Failures []string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// writeError writes err as the JSON response: with its status and code
+// when it is an *Error, as a 500 otherwise.
+func writeError(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if e.Failures != nil {
+		c.JSON(e.Status, models.PreconditionFailedResponse{
+			Error:    e.Code,
+			Message:  e.Message,
+			Failures: e.Failures,
+		})
+		return
+	}
+	c.JSON(e.Status, models.ErrorResponse{
+		Error:   e.Code,
+		Message: e.Message,
+	})
+}
+
+// errNoPool is returned when the database pool was not initialized at
+// startup.
+var errNoPool = &Error{
+	Status:  http.StatusServiceUnavailable,
+	Code:    "database_unavailable",
+	Message: "Database pool is not initialized",
+}
+
+// requirePool writes a 503 response and returns false when the database
+// pool was not initialized at startup.
+func requirePool(c *gin.Context, pool *db.Pool) bool {
+	if pool == nil {
+		writeError(c, errNoPool)
+		return false
+	}
+	return true
+}
+
+// bindOptionalJSON binds the JSON body into obj when there is one, writing
+// a 400 response and returning false when it is invalid.
+func bindOptionalJSON(c *gin.Context, obj interface{}) bool {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return true
+	}
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// wantsStream reports whether the client asked for server-sent events,
+// with Accept: text/event-stream or ?follow=true.
+func wantsStream(c *gin.Context) bool {
+	return c.Query("follow") == "true" || strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+// startStream sends the headers of a server-sent event stream right away,
+// so clients of a quiet stream are not left waiting for the first event.
+func startStream(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", "text/event-stream")
+	c.Writer.Flush()
+}
+
+// clearWriteDeadline lifts the WriteTimeout of the server for a response
+// that lasts as long as the client follows it, which would otherwise be
+// cut when the timeout expires. Writers without deadlines, such as those
+// of tests, are left as they are.
+func clearWriteDeadline(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+}
+
+// clearReadDeadline lifts the ReadTimeout of the server for a request
+// whose body is processed as it is read, such as a bulk load, which would
+// otherwise be cut when the timeout expires.
+func clearReadDeadline(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetReadDeadline(time.Time{})
+}
+
+// durationQuery parses an optional duration query parameter such as
+// "30s", writing a 400 response when it is invalid.
+func durationQuery(c *gin.Context, name string, fallback time.Duration) (time.Duration, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("Invalid %s: %v", name, err),
+		})
+		return 0, err
+	}
+	return d, nil
+}
+
+// timeQuery parses an optional RFC 3339 query parameter, writing a 400
+// response when it is invalid.
+func timeQuery(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("Invalid %s: expected an RFC 3339 timestamp", name),
+		})
+		return nil, err
+	}
+	return &t, nil
+}
+
+// limitQuery parses the ?limit= parameter, falling back to def when it is
+// missing or invalid and capping it at max.
+func limitQuery(c *gin.Context, def, max int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// IdentityHandler reports the system identity and timeline history of the
+// connected node.
+type IdentityHandler struct {
+	pool *db.Pool
+}
+
+// NewIdentityHandler creates a new identity handler.
+func NewIdentityHandler(pool *db.Pool) *IdentityHandler {
+	return &IdentityHandler{pool: pool}
+}
+
+// Identity handles GET /cluster/identity - system identifier, current
+// timeline, latest checkpoint and the current timeline's history file.
+//
+// Nodes that diverged after a failover share a system identifier but
+// disagree on where their histories switched timelines. Reading the
+// history file needs superuser or pg_read_server_files; without it
+// history_error says why and the rest is still returned.
+func (h *IdentityHandler) Identity(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	state, err := queryNodeState(ctx, h.pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read node state: " + err.Error(),
+		})
+		return
+	}
+
+	response := models.IdentityResponse{
+		SystemIdentifier: state.SystemIdentifier,
+		Role:             state.Role,
+		Timeline:         state.Timeline,
+		History:          []models.TimelineHistoryEntry{},
+		Timestamp:        time.Now().UTC(),
+	}
+	err = h.pool.QueryRow(ctx, `
+		SELECT s.pg_control_version, s.catalog_version_no,
+			c.checkpoint_lsn::text, c.redo_lsn::text,
+			c.timeline_id, c.prev_timeline_id, c.checkpoint_time
+		FROM pg_control_system() s, pg_control_checkpoint() c
+	`).Scan(
+		&response.ControlVersion, &response.CatalogVersion,
+		&response.CheckpointLSN, &response.RedoLSN,
+		&response.CheckpointTimeline, &response.PrevTimeline, &response.CheckpointTime,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read control data: " + err.Error(),
+		})
+		return
+	}
+
+	// Timeline 1 has no history; every later history file lists all
+	// earlier switches
+	if state.Timeline > 1 {
+		response.HistoryFile = wal.HistoryFileName(uint32(state.Timeline))
+		var contents string
+		err := h.pool.QueryRow(ctx, "SELECT pg_read_file('pg_wal/' || $1)", response.HistoryFile).Scan(&contents)
+		if err != nil {
+			response.HistoryError = err.Error()
+		} else {
+			response.HistoryContents = contents
+			switches, err := wal.ParseTimelineHistory(contents)
+			if err != nil {
+				response.HistoryError = err.Error()
+			}
+			for _, s := range switches {
+				response.History = append(response.History, models.TimelineHistoryEntry{
+					Timeline:  s.Timeline,
+					SwitchLSN: s.SwitchLSN.String(),
+					Reason:    s.Reason,
+				})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
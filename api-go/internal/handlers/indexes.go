@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// indexSortColumns maps the accepted ?sort= values to ORDER BY clauses.
+var indexSortColumns = map[string]string{
+	"size":  "pg_relation_size(s.indexrelid) DESC",
+	"scans": "s.idx_scan ASC",
+	"name":  "s.schemaname, s.relname, s.indexrelname",
+}
+
+// IndexesHandler handles index statistics endpoints.
+type IndexesHandler struct {
+	pool *db.Pool
+}
+
+// NewIndexesHandler creates a new indexes handler.
+func NewIndexesHandler(pool *db.Pool) *IndexesHandler {
+	return &IndexesHandler{pool: pool}
+}
+
+// Indexes handles GET /indexes - index usage statistics.
+//
+// An index is flagged possibly_unused when it has not been scanned since
+// the statistics were reset and does not back a unique or primary key
+// constraint. ?unused_only=true limits the listing to those.
+func (h *IndexesHandler) Indexes(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	orderBy, ok := indexSortColumns[c.DefaultQuery("sort", "size")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "sort must be one of: size, scans, name",
+		})
+		return
+	}
+	limit := limitQuery(c, 100, 1000)
+	unusedOnly := c.DefaultQuery("unused_only", "false") == "true"
+
+	var statsReset *time.Time
+	err := h.pool.QueryRow(ctx, `
+		SELECT stats_reset FROM pg_stat_database WHERE datname = current_database()
+	`).Scan(&statsReset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to get statistics reset time",
+		})
+		return
+	}
+
+	rows, err := h.pool.Query(ctx, `
+		SELECT
+			s.schemaname, s.relname, s.indexrelname,
+			pg_relation_size(s.indexrelid),
+			s.idx_scan, s.idx_tup_read, s.idx_tup_fetch,
+			COALESCE(io.idx_blks_read, 0), COALESCE(io.idx_blks_hit, 0),
+			i.indisunique, i.indisprimary,
+			s.idx_scan = 0 AND NOT i.indisunique AND NOT i.indisprimary,
+			pg_get_indexdef(s.indexrelid)
+		FROM pg_stat_user_indexes s
+		JOIN pg_statio_user_indexes io ON io.indexrelid = s.indexrelid
+		JOIN pg_index i ON i.indexrelid = s.indexrelid
+		WHERE NOT $1 OR (s.idx_scan = 0 AND NOT i.indisunique AND NOT i.indisprimary)
+		ORDER BY `+orderBy+`
+		LIMIT $2
+	`, unusedOnly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query index statistics",
+		})
+		return
+	}
+	defer rows.Close()
+
+	response := models.IndexesResponse{
+		StatsReset: statsReset,
+		Indexes:    []models.IndexStats{},
+	}
+	for rows.Next() {
+		var idx models.IndexStats
+		if err := rows.Scan(
+			&idx.Schema, &idx.Table, &idx.Name,
+			&idx.SizeBytes,
+			&idx.Scans, &idx.TuplesRead, &idx.TuplesFetched,
+			&idx.BlocksRead, &idx.BlocksHit,
+			&idx.IsUnique, &idx.IsPrimary,
+			&idx.PossiblyUnused,
+			&idx.Definition,
+		); err != nil {
+			continue
+		}
+		if idx.PossiblyUnused {
+			response.UnusedCount++
+			response.UnusedBytes += idx.SizeBytes
+		}
+		response.Indexes = append(response.Indexes, idx)
+	}
+
+	response.Timestamp = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
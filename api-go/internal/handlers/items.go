@@ -2,46 +2,175 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
 	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/etag"
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+	"github.com/postgresql-ha-dr/api-go/internal/migrations"
 	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
 )
 
 // ItemsHandler handles item CRUD operations.
 type ItemsHandler struct {
-	pool *db.Pool
+	cfg     *config.Config
+	cluster *db.Cluster
 }
 
 // NewItemsHandler creates a new items handler.
-func NewItemsHandler(pool *db.Pool) *ItemsHandler {
-	return &ItemsHandler{pool: pool}
+func NewItemsHandler(cfg *config.Config, cluster *db.Cluster) *ItemsHandler {
+	return &ItemsHandler{
+		cfg:     cfg,
+		cluster: cluster,
+	}
 }
 
-// ensureTableExists creates the items table if it doesn't exist.
-func (h *ItemsHandler) ensureTableExists(ctx context.Context) error {
-	_, err := h.pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS items (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			description TEXT,
-			price DECIMAL(10, 2) NOT NULL,
-			is_active BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-		)
-	`)
+// writer returns the primary pool, or writes a 503 describing the outage
+// when the primary is unreachable.
+func (h *ItemsHandler) writer(c *gin.Context) (*db.Pool, bool) {
+	pool, err := h.cluster.Writer()
 	if err != nil {
-		return err
+		h.primaryUnavailable(c)
+		return nil, false
 	}
+	return pool, true
+}
 
-	_, err = h.pool.Exec(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_items_is_active ON items(is_active)
-	`)
-	return err
+// consistencyTokenHeader carries the WAL position after a write. A read
+// sending it back is served by a node that has replayed the write.
+const consistencyTokenHeader = "X-Consistency-Token"
+
+// reader returns a pool for read queries, falling back to a replica when
+// the primary is unreachable. With a consistency token the replica must
+// have replayed it, per DB_READ_YOUR_WRITES.
+func (h *ItemsHandler) reader(c *gin.Context) (*db.Pool, bool, bool) {
+	pool, fromReplica, err := h.cluster.Reader()
+	if token := c.GetHeader(consistencyTokenHeader); token != "" {
+		lsn, parseErr := wal.ParseLSN(token)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_consistency_token",
+				Message: consistencyTokenHeader + " must be a token returned by a write",
+			})
+			return nil, false, false
+		}
+		pool, fromReplica, err = h.cluster.ReaderAfter(c.Request.Context(), lsn.String(),
+			h.cfg.Database.ReadYourWrites == config.ReadYourWritesPrimary, h.cfg.Database.ReadYourWritesTimeout)
+	}
+	if errors.Is(err, db.ErrReplicaBehind) {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "replica_behind",
+			Message: err.Error(),
+		})
+		return nil, false, false
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "database_unavailable",
+			Message: err.Error(),
+		})
+		return nil, false, false
+	}
+	if fromReplica {
+		c.Header("X-Database-Role", "replica")
+		setStalenessHeaders(c, pool)
+	}
+	return pool, fromReplica, true
+}
+
+// setStalenessHeaders tells the client how fresh the data of a replica
+// is: the WAL it has received but not replayed, the age of the last
+// replayed transaction and when it was committed. Headers PostgreSQL has
+// no value for are left out, as are all of them if the lag cannot be read.
+func setStalenessHeaders(c *gin.Context, pool *db.Pool) {
+	lag, err := pool.ReplayLag(c.Request.Context())
+	if err != nil {
+		return
+	}
+	if lag.Bytes != nil {
+		c.Header("X-Replica-Lag-Bytes", strconv.FormatInt(*lag.Bytes, 10))
+	}
+	if lag.Seconds != nil {
+		c.Header("X-Replica-Lag-Seconds", strconv.FormatFloat(*lag.Seconds, 'f', 3, 64))
+	}
+	if lag.LastReplay != nil {
+		c.Header("X-Replica-Last-Replay", lag.LastReplay.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// setConsistencyToken sends the WAL position after a successful write, for
+// reads that must see it. The write has succeeded either way, so a failure
+// to read the position only leaves the header out.
+func setConsistencyToken(c *gin.Context, pool *db.Pool) {
+	lsn, err := pool.CurrentLSN(c.Request.Context())
+	if err != nil {
+		return
+	}
+	c.Header(consistencyTokenHeader, lsn)
+}
+
+// primaryUnavailable writes a structured 503 for rejected writes, with an
+// estimate of when the primary should be back based on the configured
+// failover time.
+func (h *ItemsHandler) primaryUnavailable(c *gin.Context) {
+	response := models.PrimaryUnavailableResponse{
+		Error:    "primary_unavailable",
+		Message:  db.ErrPrimaryUnavailable.Error() + "; the API is serving reads only",
+		ReadOnly: true,
+	}
+
+	retryAfter := h.cfg.Database.FailoverEstimate
+	if since, down := h.cluster.PrimaryDownSince(); down && !since.IsZero() {
+		response.PrimaryDownSince = &since
+		if remaining := h.cfg.Database.FailoverEstimate - time.Since(since); remaining > 0 {
+			retryAfter = remaining
+		} else {
+			retryAfter = h.cfg.Database.HealthCheckInterval
+		}
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	response.EstimatedRecoverySeconds = seconds
+
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusServiceUnavailable, response)
+}
+
+// keyType returns the SQL type of item keys.
+func (h *ItemsHandler) keyType() string {
+	return migrations.ItemKeyType(h.cfg.Items.KeyType)
+}
+
+// parseItemID checks that s is an item key of the configured type and
+// returns it in canonical form. The error completes "Item ID ...".
+func (h *ItemsHandler) parseItemID(s string) (models.ItemID, error) {
+	if h.cfg.Items.KeyType == config.ItemKeyUUIDv7 {
+		var u pgtype.UUID
+		if err := u.Scan(s); err != nil {
+			return "", errors.New("must be a UUID")
+		}
+		value, _ := u.Value()
+		return models.ItemID(value.(string)), nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return "", errors.New("must be a non-negative number")
+	}
+	return models.ItemID(strconv.FormatInt(n, 10)), nil
 }
 
 // Create handles POST /items - create a new item.
@@ -56,11 +185,8 @@ func (h *ItemsHandler) Create(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	if err := h.ensureTableExists(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to ensure table exists",
-		})
+	pool, ok := h.writer(c)
+	if !ok {
 		return
 	}
 
@@ -72,13 +198,13 @@ func (h *ItemsHandler) Create(c *gin.Context) {
 	now := time.Now().UTC()
 	var item models.Item
 
-	err := h.pool.QueryRow(ctx, `
+	err := pool.QueryRow(ctx, `
 		INSERT INTO items (name, description, price, is_active, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $5)
-		RETURNING id, name, description, price, is_active, created_at, updated_at
+		RETURNING id, name, description, price, is_active, created_at, updated_at, version
 	`, req.Name, req.Description, req.Price, isActive, now).Scan(
 		&item.ID, &item.Name, &item.Description, &item.Price,
-		&item.IsActive, &item.CreatedAt, &item.UpdatedAt,
+		&item.IsActive, &item.CreatedAt, &item.UpdatedAt, &item.Version,
 	)
 
 	if err != nil {
@@ -89,47 +215,121 @@ func (h *ItemsHandler) Create(c *gin.Context) {
 		return
 	}
 
+	setItemETag(c, item)
+	setConsistencyToken(c, pool)
 	c.JSON(http.StatusCreated, item)
 }
 
 // List handles GET /items - list all items.
+//
+// name, price_min, price_max, created_after and created_before filter the
+// items; see itemquery.ParseFilter.
+//
+// ?sort=price,-created_at orders by the listed columns, descending with a
+// leading -, then by id; see itemquery.ParseSort. The default is by id.
+//
+// ?after_id= pages by key instead of ?skip=: the items after the given one
+// in the sort order, which stays fast on large tables and neither skips
+// nor repeats items when others are inserted or deleted between pages.
+// When sorting by other columns than id, the given item must still exist.
+//
+// With ?envelope=true or Accept: application/vnd.items.page+json the items
+// come wrapped with the total count and the URL of the next page, null on
+// the last one. Without either, the bare array is kept for existing
+// callers.
+//
+// ?ids=1,2,3 instead gets the listed items, as POST /items/batch-get does.
+//
+// ?fields=id,name,price selects and writes only those fields of each item,
+// as it does for a single item, a batch get and an export.
 func (h *ItemsHandler) List(c *gin.Context) {
+	if value, ok := c.GetQuery("ids"); ok {
+		var ids []models.ItemID
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, models.ItemID(id))
+			}
+		}
+		h.batchGet(c, ids)
+		return
+	}
+
 	ctx := c.Request.Context()
-	if err := h.ensureTableExists(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to ensure table exists",
-		})
+	pool, _, ok := h.reader(c)
+	if !ok {
 		return
 	}
 
-	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	skip, limit := itemquery.ParsePaging(c.Request.URL.Query())
 	activeOnly := c.DefaultQuery("active_only", "false") == "true"
 
-	if limit > 1000 {
-		limit = 1000
+	var afterID *models.ItemID
+	if value, ok := c.GetQuery("after_id"); ok {
+		id, err := h.parseItemID(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "after_id: " + err.Error(),
+			})
+			return
+		}
+		if _, ok := c.GetQuery("skip"); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "after_id and skip cannot be combined",
+			})
+			return
+		}
+		afterID = &id
+		skip = 0
 	}
 
-	var rows interface{}
-	var err error
-
+	order, err := itemquery.ParseSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	filter, err := itemquery.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	fields, err := parseItemFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
 	if activeOnly {
-		rows, err = h.pool.Query(ctx, `
-			SELECT id, name, description, price, is_active, created_at, updated_at
-			FROM items
-			WHERE is_active = TRUE
-			ORDER BY id
-			OFFSET $1 LIMIT $2
-		`, skip, limit)
+		filter.Where("is_active = TRUE")
+	}
+	// The total counts every page; the key only bounds this one
+	countWhere, countArgs := filter.Clause()
+
+	var paging string
+	if afterID != nil {
+		// One extra row tells whether this is the last page
+		filter.After(order, *afterID)
+		paging = "LIMIT " + filter.Arg(limit+1)
 	} else {
-		rows, err = h.pool.Query(ctx, `
-			SELECT id, name, description, price, is_active, created_at, updated_at
-			FROM items
-			ORDER BY id
-			OFFSET $1 LIMIT $2
-		`, skip, limit)
+		paging = "OFFSET " + filter.Arg(skip) + " LIMIT " + filter.Arg(limit)
 	}
+	where, args := filter.Clause()
+
+	rows, err := pool.Query(ctx, `
+		SELECT `+itemSelect(fields)+`
+		FROM items
+		`+where+`
+		ORDER BY `+order.String()+`
+		`+paging, args...)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -139,85 +339,158 @@ func (h *ItemsHandler) List(c *gin.Context) {
 		return
 	}
 
-	pgRows := rows.(interface{ Close(); Next() bool; Scan(dest ...any) error })
-	defer pgRows.Close()
+	defer rows.Close()
 
 	var items []models.Item
-	for pgRows.Next() {
+	for rows.Next() {
 		var item models.Item
-		if err := pgRows.Scan(
-			&item.ID, &item.Name, &item.Description, &item.Price,
-			&item.IsActive, &item.CreatedAt, &item.UpdatedAt,
-		); err != nil {
+		if err := rows.Scan(itemScanTargets(&item, fields)...); err != nil {
 			continue
 		}
+		item.Fields = fields
 		items = append(items, item)
 	}
 
 	if items == nil {
 		items = []models.Item{}
 	}
+	more := false
+	if afterID != nil && len(items) > limit {
+		items = items[:limit]
+		more = true
+	}
+
+	if !itemquery.WantsPage(apiversion.Get(c), c.Request.URL.Query(), c.GetHeader("Accept")) {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	var total int64
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM items "+countWhere, countArgs...).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to count items",
+		})
+		return
+	}
 
-	c.JSON(http.StatusOK, items)
+	page := models.ItemsPage{
+		Items:   items,
+		Total:   total,
+		Skip:    skip,
+		Limit:   limit,
+		AfterID: afterID,
+	}
+	u := *c.Request.URL
+	values := u.Query()
+	values.Set("limit", strconv.Itoa(limit))
+	switch {
+	case afterID != nil && more && len(items) > 0:
+		values.Set("after_id", string(items[len(items)-1].ID))
+	case afterID == nil && len(items) > 0 && int64(skip)+int64(len(items)) < total:
+		values.Set("skip", strconv.Itoa(skip+len(items)))
+	default:
+		values = nil
+	}
+	if values != nil {
+		u.RawQuery = values.Encode()
+		link := u.RequestURI()
+		page.Next = &link
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // Get handles GET /items/:id - get a specific item.
 func (h *ItemsHandler) Get(c *gin.Context) {
 	ctx := c.Request.Context()
-	if err := h.ensureTableExists(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to ensure table exists",
-		})
+	pool, _, ok := h.reader(c)
+	if !ok {
 		return
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := h.parseItemID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "invalid_id",
-			Message: "Item ID must be a number",
+			Message: "Item ID " + err.Error(),
 		})
 		return
 	}
 
-	var item models.Item
-	err = h.pool.QueryRow(ctx, `
-		SELECT id, name, description, price, is_active, created_at, updated_at
-		FROM items
-		WHERE id = $1
-	`, id).Scan(
-		&item.ID, &item.Name, &item.Description, &item.Price,
-		&item.IsActive, &item.CreatedAt, &item.UpdatedAt,
-	)
-
+	fields, err := parseItemFields(c)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	item, err := findItem(ctx, pool, id, fields)
+	if errors.Is(err, pgx.ErrNoRows) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "not_found",
 			Message: "Item not found",
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to fetch item",
+		})
+		return
+	}
 
+	setItemETag(c, item)
+	if etag.Matches(c.GetHeader("If-None-Match"), item.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, item)
 }
 
-// Update handles PUT /items/:id - update an item.
+// findItem reads fields of the item id, and its version, unless it is in
+// the trash.
+func findItem(ctx context.Context, pool *db.Pool, id models.ItemID, fields []string) (models.Item, error) {
+	var item models.Item
+	err := pool.QueryRow(ctx, `
+		SELECT `+itemSelect(fields)+`, version
+		FROM items
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id).Scan(append(itemScanTargets(&item, fields), &item.Version)...)
+	item.Fields = fields
+	return item, err
+}
+
+// Update handles PUT /items/:id - update an item. Like PATCH, only the
+// supplied fields change.
+//
+// Both require If-Match with the ETag of the item as last read: 428
+// without it, 412 when the item changed since. If-Match: * skips the
+// check.
 func (h *ItemsHandler) Update(c *gin.Context) {
+	h.update(c)
+}
+
+// Patch handles PATCH /items/:id - update the supplied fields of an item
+// in a single UPDATE ... RETURNING.
+func (h *ItemsHandler) Patch(c *gin.Context) {
+	h.update(c)
+}
+
+func (h *ItemsHandler) update(c *gin.Context) {
 	ctx := c.Request.Context()
-	if err := h.ensureTableExists(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to ensure table exists",
-		})
+	pool, ok := h.writer(c)
+	if !ok {
 		return
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := h.parseItemID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "invalid_id",
-			Message: "Item ID must be a number",
+			Message: "Item ID " + err.Error(),
 		})
 		return
 	}
@@ -231,78 +504,123 @@ func (h *ItemsHandler) Update(c *gin.Context) {
 		return
 	}
 
-	// Get current item
-	var current models.Item
-	err = h.pool.QueryRow(ctx, `
-		SELECT id, name, description, price, is_active, created_at, updated_at
-		FROM items WHERE id = $1
-	`, id).Scan(
-		&current.ID, &current.Name, &current.Description, &current.Price,
-		&current.IsActive, &current.CreatedAt, &current.UpdatedAt,
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, models.ErrorResponse{
+			Error:   "precondition_required",
+			Message: "Send If-Match with the ETag of the item as last read",
+		})
+		return
+	}
+
+	// Only the supplied fields are set, in one statement, so concurrent
+	// updates of other fields are not overwritten
+	set, args := itemUpdateSet(req, nil)
+	args = append(args, id)
+	where := "id = $" + strconv.Itoa(len(args)) + " AND deleted_at IS NULL"
+	if strings.TrimSpace(ifMatch) != "*" {
+		args = append(args, etag.Versions(ifMatch, false))
+		where += " AND version = ANY($" + strconv.Itoa(len(args)) + ")"
+	}
+
+	var item models.Item
+	err = pool.QueryRow(ctx, `
+		UPDATE items
+		SET `+strings.Join(set, ", ")+`
+		WHERE `+where+`
+		RETURNING id, name, description, price, is_active, created_at, updated_at, version
+	`, args...).Scan(
+		&item.ID, &item.Name, &item.Description, &item.Price,
+		&item.IsActive, &item.CreatedAt, &item.UpdatedAt, &item.Version,
 	)
 
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either the item is gone or it changed since it was read
+		var current models.Item
+		if pool.QueryRow(ctx, "SELECT version FROM items WHERE id = $1 AND deleted_at IS NULL", id).Scan(&current.Version) == nil {
+			setItemETag(c, current)
+			c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+				Error:   "precondition_failed",
+				Message: "The item was modified since it was read; fetch it again and retry",
+			})
+			return
+		}
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error:   "not_found",
 			Message: "Item not found",
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to update item",
+		})
+		return
+	}
 
-	// Apply updates
+	setItemETag(c, item)
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, item)
+}
+
+// itemUpdateSet returns the assignments of an UPDATE setting the supplied
+// fields of req and updated_at, and bumping the version, with their
+// values appended to args.
+func itemUpdateSet(req models.ItemUpdate, args []interface{}) ([]string, []interface{}) {
+	var set []string
+	assign := func(column string, value interface{}) {
+		args = append(args, value)
+		set = append(set, column+" = $"+strconv.Itoa(len(args)))
+	}
 	if req.Name != nil {
-		current.Name = *req.Name
+		assign("name", *req.Name)
 	}
 	if req.Description != nil {
-		current.Description = req.Description
+		assign("description", *req.Description)
 	}
 	if req.Price != nil {
-		current.Price = *req.Price
+		assign("price", *req.Price)
 	}
 	if req.IsActive != nil {
-		current.IsActive = *req.IsActive
-	}
-	current.UpdatedAt = time.Now().UTC()
-
-	// Save
-	_, err = h.pool.Exec(ctx, `
-		UPDATE items
-		SET name = $1, description = $2, price = $3, is_active = $4, updated_at = $5
-		WHERE id = $6
-	`, current.Name, current.Description, current.Price, current.IsActive, current.UpdatedAt, id)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to update item",
-		})
-		return
+		assign("is_active", *req.IsActive)
 	}
-
-	c.JSON(http.StatusOK, current)
+	assign("updated_at", time.Now().UTC())
+	set = append(set, "version = version + 1")
+	return set, args
 }
 
-// Delete handles DELETE /items/:id - delete an item.
+// Delete handles DELETE /items/:id - move an item to the trash, from where
+// POST /items/:id/restore brings it back. With ?permanent=true the item
+// is deleted for good, also from the trash, unless it is on an order.
 func (h *ItemsHandler) Delete(c *gin.Context) {
 	ctx := c.Request.Context()
-	if err := h.ensureTableExists(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to ensure table exists",
-		})
+	pool, ok := h.writer(c)
+	if !ok {
 		return
 	}
 
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := h.parseItemID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "invalid_id",
-			Message: "Item ID must be a number",
+			Message: "Item ID " + err.Error(),
 		})
 		return
 	}
 
-	result, err := h.pool.Exec(ctx, "DELETE FROM items WHERE id = $1", id)
+	query := "UPDATE items SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND deleted_at IS NULL"
+	if c.Query("permanent") == "true" {
+		query = "DELETE FROM items WHERE id = $1"
+	}
+	result, err := pool.Exec(ctx, query, id)
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "item_referenced",
+			Message: "The item is on orders and cannot be deleted permanently",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
@@ -319,5 +637,6 @@ func (h *ItemsHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	setConsistencyToken(c, pool)
 	c.Status(http.StatusNoContent)
 }
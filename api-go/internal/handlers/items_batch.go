@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// maxItemsBatchGet is the most items fetched by ID at once.
+const maxItemsBatchGet = 1000
+
+// BatchGet handles POST /items/batch-get - the listed items in one round
+// trip, keyed by ID, and the IDs no item has. GET /items?ids= does the
+// same for shorter lists.
+func (h *ItemsHandler) BatchGet(c *gin.Context) {
+	var req models.ItemsBatchGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	h.batchGet(c, req.IDs)
+}
+
+// batchGet writes the items with the given IDs and those missing.
+func (h *ItemsHandler) batchGet(c *gin.Context, ids []models.ItemID) {
+	if len(ids) == 0 || len(ids) > maxItemsBatchGet {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: fmt.Sprintf("between 1 and %d item IDs are required", maxItemsBatchGet),
+		})
+		return
+	}
+	ids, err := h.uniqueIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	fields, err := parseItemFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pool, _, ok := h.reader(c)
+	if !ok {
+		return
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT `+itemSelect(fields)+`
+		FROM items
+		WHERE id = ANY($1) AND deleted_at IS NULL
+	`, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to get items",
+		})
+		return
+	}
+	defer rows.Close()
+
+	response := models.ItemsBatchResponse{
+		Items:   make(map[models.ItemID]models.Item, len(ids)),
+		Missing: []models.ItemID{},
+	}
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(itemScanTargets(&item, fields)...); err != nil {
+			continue
+		}
+		item.Fields = fields
+		response.Items[item.ID] = item
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to get items",
+		})
+		return
+	}
+	for _, id := range ids {
+		if _, ok := response.Items[id]; !ok {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/itemload"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Batch sizes of POST /items/bulk.
+const (
+	defaultItemsBulkBatchSize = 1000
+	maxItemsBulkBatchSize     = 10000
+)
+
+// BulkCreate handles POST /items/bulk - load items with COPY, in batches
+// of ?batch_size= (default 1000, max 10000).
+//
+// The body is a JSON array of items, or with Content-Type
+// application/x-ndjson one item per line; either is read as a stream, so
+// only one batch is held in memory, and past the read timeout of the
+// server. A batch holding an invalid item is
+// skipped and the others are still loaded. The response is 201 when every
+// batch was loaded, 207 when some failed and 400 when the body could not
+// be read to the end.
+func (h *ItemsHandler) BulkCreate(c *gin.Context) {
+	batchSize := defaultItemsBulkBatchSize
+	if value := c.Query("batch_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxItemsBulkBatchSize {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: fmt.Sprintf("batch_size must be between 1 and %d", maxItemsBulkBatchSize),
+			})
+			return
+		}
+		batchSize = n
+	}
+
+	// The body is read for as long as the load lasts
+	clearReadDeadline(c)
+	clearWriteDeadline(c)
+	next, err := itemload.NewDecoder(c.Request.Body, itemload.IsNDJSON(c.ContentType()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pool, ok := h.writer(c)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	response := itemload.Bulk(next, batchSize, func(rows [][]interface{}) (int64, error) {
+		return pool.CopyFrom(ctx, pgx.Identifier{"items"}, itemload.Columns, pgx.CopyFromRows(rows))
+	})
+	response.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Timestamp = time.Now().UTC()
+	if response.Inserted > 0 {
+		setConsistencyToken(c, pool)
+	}
+	c.JSON(itemload.BulkStatus(response), response)
+}
+
+// BulkDelete handles POST /items/bulk-delete - move the listed items to
+// the trash in one statement, hence one transaction. With
+// ?permanent=true they are deleted for good, as with DELETE /items/:id.
+func (h *ItemsHandler) BulkDelete(c *gin.Context) {
+	var req models.ItemsBulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ids, err := h.uniqueIDs(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	query := "UPDATE items SET deleted_at = NOW(), version = version + 1 WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id"
+	if c.Query("permanent") == "true" {
+		query = "DELETE FROM items WHERE id = ANY($1) RETURNING id"
+	}
+	h.bulkModify(c, ids, "Failed to delete items", query, ids)
+}
+
+// BulkUpdate handles POST /items/bulk-update - set the fields of patch on
+// every listed item in one statement, hence one transaction.
+func (h *ItemsHandler) BulkUpdate(c *gin.Context) {
+	var req models.ItemsBulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ids, err := h.uniqueIDs(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	set, args := itemUpdateSet(req.Patch, []interface{}{ids})
+	h.bulkModify(c, ids, "Failed to update items",
+		"UPDATE items SET "+strings.Join(set, ", ")+" WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id", args...)
+}
+
+// bulkModify runs a statement returning the IDs of the affected items and
+// reports which of ids were not found.
+func (h *ItemsHandler) bulkModify(c *gin.Context, ids []models.ItemID, failure, sql string, args ...interface{}) {
+	ctx := c.Request.Context()
+	pool, ok := h.writer(c)
+	if !ok {
+		return
+	}
+
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: failure,
+		})
+		return
+	}
+	affected, err := pgx.CollectRows(rows, pgx.RowTo[models.ItemID])
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "item_referenced",
+			Message: "Some of the items are on orders and cannot be deleted permanently",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: failure,
+		})
+		return
+	}
+
+	found := make(map[models.ItemID]bool, len(affected))
+	for _, id := range affected {
+		found[id] = true
+	}
+	response := models.ItemsBulkResult{
+		Requested: len(ids),
+		Affected:  len(affected),
+		Missing:   []models.ItemID{},
+		Timestamp: time.Now().UTC(),
+	}
+	for _, id := range ids {
+		if !found[id] {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, response)
+}
+
+// uniqueIDs returns ids in canonical form without duplicates, in their
+// first order, or an error naming the first ID that is not a key.
+func (h *ItemsHandler) uniqueIDs(ids []models.ItemID) ([]models.ItemID, error) {
+	seen := make(map[models.ItemID]bool, len(ids))
+	unique := make([]models.ItemID, 0, len(ids))
+	for _, raw := range ids {
+		id, err := h.parseItemID(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("item ID %q %w", string(raw), err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/etag"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// setItemETag sends the version of item as its ETag.
+func setItemETag(c *gin.Context, item models.Item) {
+	c.Header("ETag", etag.Format(item.Version))
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemsExportFlushRows is how many rows are written between flushes of an
+// export.
+const itemsExportFlushRows = 1000
+
+// Export handles GET /items/export?format=csv|ndjson - every item ordered
+// by id, streamed with chunked transfer encoding. The filters of GET
+// /items apply. Rows are read from the database only as fast as the
+// client takes them, so memory stays flat on large tables. Like other
+// reads it falls back to a replica, which makes it suitable for comparing
+// the data on both sides after a restore. ?fields= limits the columns
+// selected and written.
+//
+// An error after the first row can no longer change the status; the
+// stream then ends early and the error is logged. The write timeout of the
+// server does not apply.
+func (h *ItemsHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "format must be csv or ndjson",
+		})
+		return
+	}
+	filter, err := itemquery.ParseFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	fields, err := parseItemFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if c.Query("active_only") == "true" {
+		filter.Where("is_active = TRUE")
+	}
+
+	ctx := c.Request.Context()
+	pool, _, ok := h.reader(c)
+	if !ok {
+		return
+	}
+
+	where, args := filter.Clause()
+	rows, err := pool.Query(ctx, `
+		SELECT `+itemSelect(fields)+`
+		FROM items
+		`+where+`
+		ORDER BY id`, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to export items",
+		})
+		return
+	}
+	defer rows.Close()
+
+	// A whole table may take longer than the write timeout, and a cut
+	// export would look complete to a client comparing row counts
+	clearWriteDeadline(c)
+	c.Header("Content-Disposition", `attachment; filename="items.`+format+`"`)
+	c.Header("X-Accel-Buffering", "no")
+	var write func(item models.Item) error
+	var flush func() error
+	switch format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		columns := fields
+		if columns == nil {
+			columns = itemFields
+		}
+		write = func(item models.Item) error {
+			return w.Write(itemRecord(item, columns))
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		if err := w.Write(columns); err != nil {
+			return
+		}
+	default:
+		c.Header("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(c.Writer)
+		write = func(item models.Item) error {
+			return enc.Encode(item)
+		}
+		flush = func() error { return nil }
+	}
+	c.Status(http.StatusOK)
+
+	count := 0
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(itemScanTargets(&item, fields)...); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Item export stopped", "rows", count, "error", err)
+			return
+		}
+		item.Fields = fields
+		if err := write(item); err != nil {
+			// The client went away
+			return
+		}
+		count++
+		if count%itemsExportFlushRows == 0 {
+			if err := flush(); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Item export stopped", "rows", count, "error", err)
+	}
+	if err := flush(); err == nil {
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemFields are the item fields ?fields= chooses from, in the order they
+// are written. They are also the columns of a CSV export.
+var itemFields = []string{"id", "name", "description", "price", "is_active", "created_at", "updated_at"}
+
+// parseItemFields reads ?fields=, a comma-separated list of the item
+// fields a response is limited to. The fields come back in the order of
+// itemFields, or nil without the parameter.
+func parseItemFields(c *gin.Context) ([]string, error) {
+	value, ok := c.GetQuery("fields")
+	if !ok {
+		return nil, nil
+	}
+	chosen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !contains(itemFields, field) {
+			return nil, fmt.Errorf("fields: unknown field %q, expected any of %s", field, strings.Join(itemFields, ","))
+		}
+		chosen[field] = true
+	}
+	fields := make([]string, 0, len(chosen))
+	for _, field := range itemFields {
+		if chosen[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// itemColumns returns the columns to select for fields, all of them when
+// nil. id is always selected: paging and the batch response key on it.
+func itemColumns(fields []string) []string {
+	if fields == nil {
+		return itemFields
+	}
+	columns := []string{"id"}
+	for _, field := range fields {
+		if field != "id" {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// itemSelect returns the select list of itemColumns(fields).
+func itemSelect(fields []string) string {
+	return strings.Join(itemColumns(fields), ", ")
+}
+
+// itemScanTargets returns where in item to scan the columns of
+// itemColumns(fields).
+func itemScanTargets(item *models.Item, fields []string) []interface{} {
+	columns := itemColumns(fields)
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "id":
+			targets[i] = &item.ID
+		case "name":
+			targets[i] = &item.Name
+		case "description":
+			targets[i] = &item.Description
+		case "price":
+			targets[i] = &item.Price
+		case "is_active":
+			targets[i] = &item.IsActive
+		case "created_at":
+			targets[i] = &item.CreatedAt
+		case "updated_at":
+			targets[i] = &item.UpdatedAt
+		}
+	}
+	return targets
+}
+
+// itemRecord returns the CSV values of fields of item.
+func itemRecord(item models.Item, fields []string) []string {
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "id":
+			record[i] = string(item.ID)
+		case "name":
+			record[i] = item.Name
+		case "description":
+			if item.Description != nil {
+				record[i] = *item.Description
+			}
+		case "price":
+			record[i] = string(item.Price)
+		case "is_active":
+			record[i] = strconv.FormatBool(item.IsActive)
+		case "created_at":
+			record[i] = item.CreatedAt.UTC().Format(time.RFC3339Nano)
+		case "updated_at":
+			record[i] = item.UpdatedAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return record
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/itemload"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Import handles POST /items/import - load a CSV or NDJSON upload, as
+// the body or as the file field of a multipart form.
+//
+// The format is ?format=, or else taken from the Content-Type or the file
+// extension. CSV needs a header naming its columns, as GET /items/export
+// writes; id, created_at and updated_at are ignored. Every row is
+// validated like POST /items. The valid rows are loaded with a single
+// COPY, so either all of them are imported or none is, and the report
+// lists the rejected rows by line with the reason.
+func (h *ItemsHandler) Import(c *gin.Context) {
+	// COPY reads the upload for as long as the load lasts
+	clearReadDeadline(c)
+	clearWriteDeadline(c)
+	body, name, err := itemsImportUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer body.Close()
+
+	format := itemload.Format(c.Query("format"), c.ContentType(), name)
+	var read itemload.Reader
+	switch format {
+	case "csv":
+		read, err = itemload.CSVReader(body)
+	case "ndjson":
+		read = itemload.NDJSONReader(body)
+	default:
+		err = errors.New("format must be csv or ndjson; set ?format=, the Content-Type or the file extension")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pool, ok := h.writer(c)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	response, err := itemload.Import(read, func(src pgx.CopyFromSource) (int64, error) {
+		return pool.CopyFrom(ctx, pgx.Identifier{"items"}, itemload.Columns, src)
+	})
+	var readErr *itemload.ReadError
+	if errors.As(err, &readErr) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "Nothing was imported: " + readErr.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Nothing was imported: " + err.Error(),
+		})
+		return
+	}
+
+	response.Format = format
+	response.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Timestamp = time.Now().UTC()
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusCreated, response)
+}
+
+// itemsImportUpload returns the uploaded file and its name, or the body.
+func itemsImportUpload(c *gin.Context) (io.ReadCloser, string, error) {
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, "", nil
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, "", fmt.Errorf("the form must hold the upload in a file field: %w", err)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	return file, header.Filename, nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Find returns the item id, read as GET /items/:id reads it: from a
+// replica while the primary is unreachable, and never from the trash.
+func (h *ItemsHandler) Find(ctx context.Context, id string) (models.Item, error) {
+	itemID, err := h.parseItemID(id)
+	if err != nil {
+		return models.Item{}, &Error{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_id",
+			Message: "Item ID " + err.Error(),
+		}
+	}
+	pool, _, err := h.cluster.Reader()
+	if err != nil {
+		return models.Item{}, &Error{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "database_unavailable",
+			Message: err.Error(),
+		}
+	}
+
+	item, err := findItem(ctx, pool, itemID, nil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Item{}, &Error{
+			Status:  http.StatusNotFound,
+			Code:    "not_found",
+			Message: "Item not found",
+		}
+	}
+	if err != nil {
+		return models.Item{}, &Error{
+			Status:  http.StatusInternalServerError,
+			Code:    "database_error",
+			Message: "Failed to fetch item",
+		}
+	}
+	return item, nil
+}
+
+// Each calls fn with the items after afterID, or all of them when it is
+// empty, in order of id and up to limit when it is positive. Like GET
+// /items/export it reads rows only as fast as fn takes them, and stops at
+// the first error fn returns.
+func (h *ItemsHandler) Each(ctx context.Context, afterID string, limit int, activeOnly bool, fn func(models.Item) error) error {
+	filter := &itemquery.Filter{}
+	filter.Where("deleted_at IS NULL")
+	if activeOnly {
+		filter.Where("is_active = TRUE")
+	}
+	if afterID != "" {
+		id, err := h.parseItemID(afterID)
+		if err != nil {
+			return &Error{
+				Status:  http.StatusBadRequest,
+				Code:    "validation_error",
+				Message: "after_id: " + err.Error(),
+			}
+		}
+		filter.Where("id > " + filter.Arg(id))
+	}
+	paging := ""
+	if limit > 0 {
+		paging = "LIMIT " + filter.Arg(limit)
+	}
+
+	pool, _, err := h.cluster.Reader()
+	if err != nil {
+		return &Error{
+			Status:  http.StatusServiceUnavailable,
+			Code:    "database_unavailable",
+			Message: err.Error(),
+		}
+	}
+
+	where, args := filter.Clause()
+	rows, err := pool.Query(ctx, `
+		SELECT `+itemSelect(nil)+`
+		FROM items
+		`+where+`
+		ORDER BY id
+		`+paging, args...)
+	if err != nil {
+		return &Error{
+			Status:  http.StatusInternalServerError,
+			Code:    "database_error",
+			Message: "Failed to list items",
+		}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(itemScanTargets(&item, nil)...); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
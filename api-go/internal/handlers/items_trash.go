@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Trash handles GET /items/trash - the deleted items that can still be
+// restored, most recently deleted first, with skip and limit as in GET
+// /items.
+func (h *ItemsHandler) Trash(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.reader(c)
+	if !ok {
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT id, name, description, price, is_active, created_at, updated_at, deleted_at
+		FROM items
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		OFFSET $1 LIMIT $2
+	`, skip, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list deleted items",
+		})
+		return
+	}
+	defer rows.Close()
+
+	items := []models.Item{}
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(
+			&item.ID, &item.Name, &item.Description, &item.Price,
+			&item.IsActive, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt,
+		); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list deleted items",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// Restore handles POST /items/:id/restore - take an item out of the trash.
+func (h *ItemsHandler) Restore(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, ok := h.writer(c)
+	if !ok {
+		return
+	}
+
+	id, err := h.parseItemID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID " + err.Error(),
+		})
+		return
+	}
+
+	var item models.Item
+	err = pool.QueryRow(ctx, `
+		UPDATE items
+		SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, name, description, price, is_active, created_at, updated_at, version
+	`, id).Scan(
+		&item.ID, &item.Name, &item.Description, &item.Price,
+		&item.IsActive, &item.CreatedAt, &item.UpdatedAt, &item.Version,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Item not found in the trash",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to restore item",
+		})
+		return
+	}
+
+	setItemETag(c, item)
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemsUpsertConflicts are the ON CONFLICT clauses of the upsert
+// strategies. Overwriting an item in the trash brings it back.
+var itemsUpsertConflicts = map[string]string{
+	models.UpsertOverwrite: itemsUpsertSet,
+	models.UpsertSkip:      "DO NOTHING",
+	models.UpsertNewer:     itemsUpsertSet + " WHERE EXCLUDED.updated_at > items.updated_at",
+}
+
+const itemsUpsertSet = `DO UPDATE SET
+		name = EXCLUDED.name,
+		description = EXCLUDED.description,
+		price = EXCLUDED.price,
+		is_active = EXCLUDED.is_active,
+		updated_at = EXCLUDED.updated_at,
+		deleted_at = NULL,
+		version = items.version + 1`
+
+// Upsert handles PUT /items - insert the listed items, or update those
+// whose ID exists, in one statement, hence one transaction. It serves to
+// reconcile the items of the primary with a copy, such as one restored on
+// the DR site.
+//
+// on_conflict decides what becomes of an existing item: overwrite, the
+// default, replaces its fields; skip leaves it as it is; newer replaces it
+// only when the given updated_at is later than its own, so that the last
+// write wins on either side. The response lists the IDs inserted, updated
+// and skipped.
+func (h *ItemsHandler) Upsert(c *gin.Context) {
+	var req models.ItemsUpsertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.OnConflict == "" {
+		req.OnConflict = models.UpsertOverwrite
+	}
+
+	now := time.Now().UTC()
+	n := len(req.Items)
+	ids := make([]models.ItemID, n)
+	names := make([]string, n)
+	descriptions := make([]*string, n)
+	prices := make([]models.Decimal, n)
+	active := make([]bool, n)
+	created := make([]time.Time, n)
+	updated := make([]time.Time, n)
+	seen := make(map[models.ItemID]bool, n)
+	for i, item := range req.Items {
+		id, err := h.parseItemID(string(item.ID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: fmt.Sprintf("item ID %q %v", string(item.ID), err),
+			})
+			return
+		}
+		// A statement cannot update the same row twice
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: fmt.Sprintf("item ID %q is listed more than once", string(item.ID)),
+			})
+			return
+		}
+		seen[id] = true
+		ids[i] = id
+		names[i] = item.Name
+		descriptions[i] = item.Description
+		prices[i] = item.Price
+		active[i] = item.IsActive == nil || *item.IsActive
+		created[i], updated[i] = now, now
+		if item.CreatedAt != nil {
+			created[i] = *item.CreatedAt
+		}
+		if item.UpdatedAt != nil {
+			updated[i] = *item.UpdatedAt
+		}
+	}
+
+	ctx := c.Request.Context()
+	pool, ok := h.writer(c)
+	if !ok {
+		return
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// xmax is only zero on a row version this statement inserted
+	rows, err := tx.Query(ctx, `
+		INSERT INTO items (id, name, description, price, is_active, created_at, updated_at)
+		SELECT * FROM unnest($1::`+h.keyType()+`[], $2::text[], $3::text[], $4::numeric[],
+			$5::boolean[], $6::timestamptz[], $7::timestamptz[])
+		ON CONFLICT (id) `+itemsUpsertConflicts[req.OnConflict]+`
+		RETURNING id, xmax = 0
+	`, ids, names, descriptions, prices, active, created, updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to upsert items",
+		})
+		return
+	}
+	// Whether each item written was inserted; the others were updated
+	inserted := make(map[models.ItemID]bool, n)
+	for err == nil && rows.Next() {
+		var id models.ItemID
+		var isInsert bool
+		if err = rows.Scan(&id, &isInsert); err == nil {
+			inserted[id] = isInsert
+		}
+	}
+	rows.Close()
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to upsert items",
+		})
+		return
+	}
+
+	response := models.ItemsUpsertResponse{
+		OnConflict: req.OnConflict,
+		Inserted:   []models.ItemID{},
+		Updated:    []models.ItemID{},
+		Skipped:    []models.ItemID{},
+	}
+	for _, id := range ids {
+		isInsert, written := inserted[id]
+		switch {
+		case !written:
+			response.Skipped = append(response.Skipped, id)
+		case isInsert:
+			response.Inserted = append(response.Inserted, id)
+		default:
+			response.Updated = append(response.Updated, id)
+		}
+	}
+
+	if h.cfg.Items.KeyType != config.ItemKeyUUIDv7 && len(response.Inserted) > 0 {
+		// Given keys leave the sequence behind; move it past them so that
+		// POST /items does not hand them out again
+		_, err = tx.Exec(ctx, `
+			SELECT setval(seq::regclass, max(id))
+			FROM items, pg_get_serial_sequence('items', 'id') AS seq
+			GROUP BY seq
+			HAVING max(id) > coalesce(pg_sequence_last_value(seq::regclass), 0)
+		`)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to advance the item ID sequence",
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit upsert: " + err.Error(),
+		})
+		return
+	}
+
+	response.Timestamp = time.Now().UTC()
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemVersionColumns are the columns of an item version, in the order of
+// scanItemVersion.
+const itemVersionColumns = `item_id, version, operation, name, description, price,
+	COALESCE(is_active, TRUE), deleted_at, recorded_at, xid`
+
+// scanItemVersion reads a row of itemVersionColumns.
+func scanItemVersion(row pgx.Row, v *models.ItemVersion) error {
+	return row.Scan(
+		&v.ItemID, &v.Version, &v.Operation, &v.Name, &v.Description, &v.Price,
+		&v.IsActive, &v.DeletedAt, &v.RecordedAt, &v.XID,
+	)
+}
+
+// Versions handles GET /items/:id/versions - every recorded version of an
+// item, oldest first. Versions are kept after the item is deleted, also
+// permanently.
+func (h *ItemsHandler) Versions(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.reader(c)
+	if !ok {
+		return
+	}
+
+	id, err := h.parseItemID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID " + err.Error(),
+		})
+		return
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT `+itemVersionColumns+`
+		FROM item_versions
+		WHERE item_id = $1
+		ORDER BY version
+	`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list item versions",
+		})
+		return
+	}
+	defer rows.Close()
+
+	versions := []models.ItemVersion{}
+	for rows.Next() {
+		var v models.ItemVersion
+		if err := scanItemVersion(rows, &v); err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list item versions",
+		})
+		return
+	}
+	if len(versions) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No versions of this item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// Version handles GET /items/:id/versions/:n - one recorded version of an
+// item.
+func (h *ItemsHandler) Version(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.reader(c)
+	if !ok {
+		return
+	}
+
+	id, err := h.parseItemID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Item ID " + err.Error(),
+		})
+		return
+	}
+	n, err := strconv.Atoi(c.Param("n"))
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_version",
+			Message: "Version must be a positive number",
+		})
+		return
+	}
+
+	var v models.ItemVersion
+	err = scanItemVersion(pool.QueryRow(ctx, `
+		SELECT `+itemVersionColumns+`
+		FROM item_versions
+		WHERE item_id = $1 AND version = $2
+	`, id, n), &v)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Item version not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to get item version",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, v)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/catalog"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// catalogWriteTimeout bounds how long a worker waits to record a job.
+const catalogWriteTimeout = 10 * time.Second
+
+// JobHistoryHandler records finished jobs in the catalog and serves them.
+type JobHistoryHandler struct {
+	catalog catalog.Store
+	jobs    *jobs.Manager
+}
+
+// NewJobHistoryHandler creates a job history handler and registers it to
+// record every job the manager finishes in store.
+func NewJobHistoryHandler(store catalog.Store, manager *jobs.Manager) *JobHistoryHandler {
+	h := &JobHistoryHandler{catalog: store, jobs: manager}
+	manager.OnFinish(h.record)
+	return h
+}
+
+// record stores a finished job. Failures are logged; the job itself is
+// unaffected.
+func (h *JobHistoryHandler) record(job jobs.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogWriteTimeout)
+	defer cancel()
+
+	entry := catalog.Entry{
+		ID:         job.ID,
+		Type:       job.Type,
+		Trigger:    catalog.TriggerAPI,
+		Status:     job.Status,
+		Params:     job.Params,
+		Result:     job.Result,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if scheduled, _ := job.Params["scheduled"].(bool); scheduled {
+		entry.Trigger = catalog.TriggerSchedule
+	}
+	if job.StartedAt != nil && job.FinishedAt != nil {
+		d := job.FinishedAt.Sub(*job.StartedAt).Seconds()
+		entry.DurationSeconds = &d
+	}
+	if path, err := h.jobs.LogFile(job.ID); err == nil {
+		entry.LogFile = path
+	}
+
+	if err := h.catalog.Record(ctx, entry); err != nil {
+		slog.WarnContext(ctx, "Failed to record job in the catalog", "job_type", job.Type, "job_id", job.ID, "error", err)
+	}
+}
+
+// History handles GET /backups/history - finished backup, restore and
+// other jobs from the persistent catalog, newest first.
+//
+// Query parameters: type, status, trigger (api or schedule), stanza,
+// since and until (RFC 3339), limit (default 50, max 500) and offset.
+func (h *JobHistoryHandler) History(c *gin.Context) {
+	filter := catalog.Filter{
+		Type:    c.Query("type"),
+		Status:  c.Query("status"),
+		Trigger: c.Query("trigger"),
+		Stanza:  c.Query("stanza"),
+		Limit:   limitQuery(c, 50, 500),
+	}
+
+	var err error
+	if filter.Since, err = timeQuery(c, "since"); err != nil {
+		return
+	}
+	if filter.Until, err = timeQuery(c, "until"); err != nil {
+		return
+	}
+	if raw := c.Query("offset"); raw != "" {
+		filter.Offset, err = strconv.Atoi(raw)
+		if err != nil || filter.Offset < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: "Invalid offset: must be a non-negative integer",
+			})
+			return
+		}
+	}
+
+	entries, total, err := h.catalog.List(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, db.ErrNoReadableNode) {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "database_unavailable",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read job history",
+		})
+		return
+	}
+
+	response := models.JobHistoryResponse{
+		Entries:   make([]models.JobHistoryEntry, 0, len(entries)),
+		Count:     len(entries),
+		Total:     total,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+		Timestamp: time.Now().UTC(),
+	}
+	for _, e := range entries {
+		entry := models.JobHistoryEntry{
+			Job: models.Job{
+				ID:              e.ID,
+				Type:            e.Type,
+				Status:          e.Status,
+				Error:           e.Error,
+				Params:          e.Params,
+				Result:          e.Result,
+				CreatedAt:       e.CreatedAt,
+				StartedAt:       e.StartedAt,
+				FinishedAt:      e.FinishedAt,
+				DurationSeconds: e.DurationSeconds,
+			},
+			Trigger: e.Trigger,
+		}
+		if e.LogFile != "" {
+			entry.LogURL = "/jobs/" + e.ID + "/logs"
+		}
+		response.Entries = append(response.Entries, entry)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
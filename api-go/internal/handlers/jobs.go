@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// JobsHandler handles async job endpoints.
+type JobsHandler struct {
+	jobs *jobs.Manager
+}
+
+// NewJobsHandler creates a new jobs handler.
+func NewJobsHandler(manager *jobs.Manager) *JobsHandler {
+	return &JobsHandler{jobs: manager}
+}
+
+// List handles GET /jobs - list jobs newest first, optionally filtered by
+// ?type= and ?status=.
+func (h *JobsHandler) List(c *gin.Context) {
+	list := h.jobs.List(c.Query("type"), c.Query("status"))
+
+	response := models.JobsResponse{
+		Jobs:      make([]models.Job, 0, len(list)),
+		Count:     len(list),
+		Timestamp: time.Now().UTC(),
+	}
+	for _, j := range list {
+		response.Jobs = append(response.Jobs, jobResponse(j))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Get handles GET /jobs/:id - a single job.
+func (h *JobsHandler) Get(c *gin.Context) {
+	job, err := h.Job(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Job returns the job with the given ID.
+func (h *JobsHandler) Job(id string) (models.Job, error) {
+	job, err := h.jobs.Get(id)
+	if err != nil {
+		return models.Job{}, errJobNotFound
+	}
+	return jobResponse(job), nil
+}
+
+// Delete handles DELETE /jobs/:id - cancel a queued or running job, or
+// remove a finished job from the history.
+func (h *JobsHandler) Delete(c *gin.Context) {
+	job, err := h.jobs.Cancel(c.Param("id"))
+	if err != nil {
+		jobNotFound(c)
+		return
+	}
+
+	if job.Done() {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Job cancel requested", "job_type", job.Type, "job_id", job.ID, "client_ip", c.ClientIP())
+	c.JSON(http.StatusAccepted, jobResponse(job))
+}
+
+// Logs handles GET /jobs/:id/logs - the output of a job.
+//
+// With Accept: text/event-stream or ?follow=true the log is streamed as
+// server-sent "log" events, resuming after Last-Event-ID, and a final
+// "done" event carries the finished job. Otherwise the log so far is
+// returned as plain text. Logs of jobs that have left the history are
+// served from the log directory.
+func (h *JobsHandler) Logs(c *gin.Context) {
+	id := c.Param("id")
+	if !wantsStream(c) {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		if path, err := h.jobs.LogFile(id); err == nil {
+			c.File(path)
+			return
+		}
+		if out, err := h.jobs.Output(id); err == nil {
+			lines, _ := out.Lines(0)
+			c.String(http.StatusOK, "%s", strings.Join(append(lines, ""), "\n"))
+			return
+		}
+		if _, err := h.jobs.Get(id); err == nil {
+			// Queued; nothing logged yet.
+			c.String(http.StatusOK, "")
+			return
+		}
+		jobNotFound(c)
+		return
+	}
+
+	from := 0
+	if last, err := strconv.Atoi(c.GetHeader("Last-Event-ID")); err == nil {
+		from = last + 1
+	}
+
+	out, err := h.waitOutput(c, id)
+	if err != nil {
+		if path, err := h.jobs.LogFile(id); err == nil {
+			h.streamLogFile(c, id, path, from)
+			return
+		}
+		jobNotFound(c)
+		return
+	}
+
+	clearWriteDeadline(c)
+	startStream(c)
+	c.Stream(func(w io.Writer) bool {
+		// Take the wait channel first so no write between reading the
+		// lines and waiting is missed.
+		wait, closed := out.Wait()
+		lines, next := out.Lines(from)
+		for i, line := range lines {
+			c.Render(-1, sse.Event{Id: strconv.Itoa(next - len(lines) + i), Event: "log", Data: line})
+		}
+		from = next
+
+		if closed {
+			h.sendDone(c, id)
+			return false
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-wait:
+			return true
+		case <-time.After(15 * time.Second):
+			io.WriteString(w, ": keepalive\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// waitOutput returns the output of a job, waiting while it is queued.
+func (h *JobsHandler) waitOutput(c *gin.Context, id string) (*jobs.Output, error) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if out, err := h.jobs.Output(id); err == nil {
+			return out, nil
+		}
+		job, err := h.jobs.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() {
+			// Canceled before it started.
+			return nil, jobs.ErrNotFound
+		}
+
+		select {
+		case <-ticker.C:
+		case <-c.Request.Context().Done():
+			return nil, c.Request.Context().Err()
+		}
+	}
+}
+
+// streamLogFile sends a persisted log as events followed by "done".
+func (h *JobsHandler) streamLogFile(c *gin.Context, id, path string, from int) {
+	f, err := os.Open(path)
+	if err != nil {
+		jobNotFound(c)
+		return
+	}
+	defer f.Close()
+
+	clearWriteDeadline(c)
+	c.Header("Cache-Control", "no-cache")
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 0; scanner.Scan(); n++ {
+		if n >= from {
+			c.Render(-1, sse.Event{Id: strconv.Itoa(n), Event: "log", Data: scanner.Text()})
+		}
+	}
+	h.sendDone(c, id)
+}
+
+// sendDone sends the final "done" event with the job, when it is still
+// in the history.
+func (h *JobsHandler) sendDone(c *gin.Context, id string) {
+	var data interface{} = gin.H{"id": id}
+	if job, err := h.jobs.Get(id); err == nil {
+		data = jobResponse(job)
+	}
+	c.Render(-1, sse.Event{Event: "done", Data: data})
+	c.Writer.Flush()
+}
+
+// submitJob queues a job and writes the 202 response, or a 503 when the
+// queue is full.
+func submitJob(c *gin.Context, manager *jobs.Manager, jobType string, params map[string]interface{}, fn jobs.Func) {
+	job, err := submit(manager, jobType, params, fn)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeJobAccepted(c, job)
+}
+
+// submit queues a job, failing with a 503 when the queue is full or the
+// manager has stopped.
+func submit(manager *jobs.Manager, jobType string, params map[string]interface{}, fn jobs.Func) (models.Job, error) {
+	return submitted(manager.Submit(jobType, params, fn))
+}
+
+// submitExclusive queues a job unless a job of one of the conflicting
+// types is queued or running, failing with busy then.
+func submitExclusive(manager *jobs.Manager, conflicting []string, busy *Error, jobType string, params map[string]interface{}, fn jobs.Func) (models.Job, error) {
+	job, err := manager.SubmitExclusive(conflicting, jobType, params, fn)
+	if errors.Is(err, jobs.ErrBusy) {
+		return models.Job{}, busy
+	}
+	return submitted(job, err)
+}
+
+// submitted converts the result of queueing a job.
+func submitted(job jobs.Job, err error) (models.Job, error) {
+	if err != nil {
+		code := "queue_full"
+		if errors.Is(err, jobs.ErrStopped) {
+			code = "shutting_down"
+		}
+		return models.Job{}, &Error{
+			Status:  http.StatusServiceUnavailable,
+			Code:    code,
+			Message: err.Error(),
+		}
+	}
+	return jobResponse(job), nil
+}
+
+// writeJobAccepted writes the 202 response of a submitted job, pointing
+// at the job in its Location header.
+func writeJobAccepted(c *gin.Context, job models.Job) {
+	c.Header("Location", "/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+var errJobNotFound = &Error{
+	Status:  http.StatusNotFound,
+	Code:    "not_found",
+	Message: "Job not found",
+}
+
+func jobNotFound(c *gin.Context) {
+	writeError(c, errJobNotFound)
+}
+
+func jobResponse(j jobs.Job) models.Job {
+	job := models.Job{
+		ID:         j.ID,
+		Type:       j.Type,
+		Status:     j.Status,
+		Message:    j.Message,
+		Error:      j.Error,
+		Params:     j.Params,
+		Result:     j.Result,
+		CreatedAt:  j.CreatedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+	}
+	if j.StartedAt != nil {
+		end := time.Now()
+		if j.FinishedAt != nil {
+			end = *j.FinishedAt
+		}
+		d := end.Sub(*j.StartedAt).Seconds()
+		job.DurationSeconds = &d
+	}
+	return job
+}
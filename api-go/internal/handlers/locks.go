@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// LocksHandler handles lock visibility endpoints.
+type LocksHandler struct {
+	pool *db.Pool
+}
+
+// NewLocksHandler creates a new locks handler.
+func NewLocksHandler(pool *db.Pool) *LocksHandler {
+	return &LocksHandler{pool: pool}
+}
+
+// Locks handles GET /locks - who blocks whom, for how long, on what.
+func (h *LocksHandler) Locks(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	rows, err := h.pool.Query(c.Request.Context(), `
+		SELECT
+			blocked.pid,
+			COALESCE(blocked.usename, ''),
+			COALESCE(blocked.application_name, ''),
+			COALESCE(blocked.query, ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - blocked.query_start), 0)::float8,
+			waiting.locktype,
+			waiting.mode,
+			waiting.relation,
+			blocking.pid,
+			COALESCE(blocking.usename, ''),
+			COALESCE(blocking.application_name, ''),
+			COALESCE(blocking.state, ''),
+			COALESCE(blocking.query, ''),
+			EXTRACT(EPOCH FROM now() - blocking.xact_start)::float8
+		FROM pg_stat_activity blocked
+		CROSS JOIN LATERAL unnest(pg_blocking_pids(blocked.pid)) AS b(pid)
+		JOIN pg_stat_activity blocking ON blocking.pid = b.pid
+		LEFT JOIN LATERAL (
+			SELECT l.locktype, l.mode, l.relation::regclass::text AS relation
+			FROM pg_locks l
+			WHERE l.pid = blocked.pid AND NOT l.granted
+			LIMIT 1
+		) waiting ON TRUE
+		ORDER BY blocked.query_start
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query locks",
+		})
+		return
+	}
+	defer rows.Close()
+
+	blocked := []models.BlockedLock{}
+	for rows.Next() {
+		var l models.BlockedLock
+		if err := rows.Scan(
+			&l.BlockedPID, &l.BlockedUser, &l.BlockedApplication, &l.BlockedQuery, &l.WaitSeconds,
+			&l.LockType, &l.LockMode, &l.Relation,
+			&l.BlockingPID, &l.BlockingUser, &l.BlockingApplication, &l.BlockingState, &l.BlockingQuery,
+			&l.BlockingXactSeconds,
+		); err != nil {
+			continue
+		}
+		blocked = append(blocked, l)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read locks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LocksResponse{
+		Blocked:      blocked,
+		RootBlockers: rootBlockers(blocked),
+		Timestamp:    time.Now().UTC(),
+	})
+}
+
+// rootBlockers returns the PIDs at the head of each blocking chain: those
+// that block others without being blocked themselves.
+func rootBlockers(blocked []models.BlockedLock) []int {
+	waiting := make(map[int]bool, len(blocked))
+	for _, l := range blocked {
+		waiting[l.BlockedPID] = true
+	}
+
+	seen := make(map[int]bool)
+	roots := []int{}
+	for _, l := range blocked {
+		if !waiting[l.BlockingPID] && !seen[l.BlockingPID] {
+			seen[l.BlockingPID] = true
+			roots = append(roots, l.BlockingPID)
+		}
+	}
+	sort.Ints(roots)
+	return roots
+}
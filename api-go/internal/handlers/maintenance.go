@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// MaintenanceHandler handles vacuum and maintenance endpoints.
+type MaintenanceHandler struct {
+	pool *db.Pool
+}
+
+// NewMaintenanceHandler creates a new maintenance handler.
+func NewMaintenanceHandler(pool *db.Pool) *MaintenanceHandler {
+	return &MaintenanceHandler{pool: pool}
+}
+
+// Vacuum handles GET /maintenance/vacuum - running vacuums and recent
+// per-table vacuum activity.
+//
+// autovacuum_due uses the global autovacuum threshold and scale factor;
+// per-table storage parameters are not taken into account.
+func (h *MaintenanceHandler) Vacuum(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	rows, err := h.pool.Query(ctx, `
+		SELECT
+			p.pid,
+			COALESCE(p.datname, ''),
+			p.relid::regclass::text,
+			p.phase,
+			COALESCE(a.query LIKE 'autovacuum:%', false),
+			p.heap_blks_total,
+			p.heap_blks_scanned,
+			p.heap_blks_vacuumed,
+			p.index_vacuum_count,
+			EXTRACT(EPOCH FROM now() - a.xact_start)::float8
+		FROM pg_stat_progress_vacuum p
+		LEFT JOIN pg_stat_activity a ON a.pid = p.pid
+		ORDER BY a.xact_start
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query vacuum progress",
+		})
+		return
+	}
+
+	inProgress := []models.VacuumProgress{}
+	for rows.Next() {
+		var p models.VacuumProgress
+		if err := rows.Scan(
+			&p.PID, &p.Database, &p.Table, &p.Phase, &p.IsAutovacuum,
+			&p.HeapBlocksTotal, &p.HeapBlocksScanned, &p.HeapBlocksVacuumed,
+			&p.IndexVacuumCount, &p.RunningSeconds,
+		); err != nil {
+			continue
+		}
+		if p.HeapBlocksTotal > 0 {
+			p.PercentScanned = float64(p.HeapBlocksScanned) / float64(p.HeapBlocksTotal) * 100
+		}
+		inProgress = append(inProgress, p)
+	}
+	rows.Close()
+
+	rows, err = h.pool.Query(ctx, `
+		SELECT
+			s.schemaname, s.relname,
+			s.n_dead_tup, s.n_mod_since_analyze,
+			s.vacuum_count, s.autovacuum_count,
+			s.last_vacuum, s.last_autovacuum,
+			s.n_dead_tup > current_setting('autovacuum_vacuum_threshold')::float8
+				+ current_setting('autovacuum_vacuum_scale_factor')::float8 * GREATEST(c.reltuples, 0)
+		FROM pg_stat_user_tables s
+		JOIN pg_class c ON c.oid = s.relid
+		ORDER BY GREATEST(s.last_vacuum, s.last_autovacuum) DESC NULLS LAST, s.n_dead_tup DESC
+		LIMIT $1
+	`, limitQuery(c, 50, 1000))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query vacuum activity",
+		})
+		return
+	}
+	defer rows.Close()
+
+	recent := []models.TableVacuumActivity{}
+	for rows.Next() {
+		var t models.TableVacuumActivity
+		if err := rows.Scan(
+			&t.Schema, &t.Name,
+			&t.DeadTuples, &t.ModifiedSinceAnalyze,
+			&t.VacuumCount, &t.AutovacuumCount,
+			&t.LastVacuum, &t.LastAutovacuum,
+			&t.AutovacuumDue,
+		); err != nil {
+			continue
+		}
+		recent = append(recent, t)
+	}
+
+	c.JSON(http.StatusOK, models.VacuumResponse{
+		InProgress: inProgress,
+		Recent:     recent,
+		Timestamp:  time.Now().UTC(),
+	})
+}
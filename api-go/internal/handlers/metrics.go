@@ -1,89 +1,353 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
 	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/history"
 	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
 )
 
 // MetricsHandler handles database metrics endpoints.
 type MetricsHandler struct {
-	pool *db.Pool
+	cfg     *config.Config
+	pool    *db.Pool
+	history *history.Buffer[models.MetricsResponse]
+	workers *workers.Registry
+	worker  *workers.Worker
+
+	// Cached snapshot served by Metrics
+	mu         sync.Mutex
+	cached     *models.MetricsResponse
+	fetchedAt  time.Time
+	refreshing bool
+	collectMu  sync.Mutex
 }
 
 // NewMetricsHandler creates a new metrics handler.
-func NewMetricsHandler(pool *db.Pool) *MetricsHandler {
-	return &MetricsHandler{pool: pool}
+func NewMetricsHandler(cfg *config.Config, pool *db.Pool) *MetricsHandler {
+	capacity := int(cfg.Metrics.HistoryWindow / cfg.Metrics.HistoryInterval)
+	return &MetricsHandler{
+		cfg:     cfg,
+		pool:    pool,
+		history: history.NewBuffer[models.MetricsResponse](capacity),
+	}
+}
+
+// UseWorkers records the samples as runs of the metrics sampler worker in
+// registry. Call it before Start.
+func (h *MetricsHandler) UseWorkers(registry *workers.Registry) {
+	h.workers = registry
+}
+
+// Start samples metrics into the history buffer on the configured
+// interval until ctx is done.
+func (h *MetricsHandler) Start(ctx context.Context) {
+	if h.pool == nil {
+		return
+	}
+	h.worker = h.workers.Register(WorkerMetricsSampler, h.cfg.Metrics.HistoryInterval)
+
+	go func() {
+		ticker := time.NewTicker(h.cfg.Metrics.HistoryInterval)
+		defer ticker.Stop()
+
+		for {
+			h.sample(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sample records one metrics snapshot. Failed samples are skipped, which
+// shows up as a gap in the history.
+func (h *MetricsHandler) sample(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Metrics.HistoryInterval)
+	defer cancel()
+
+	start := time.Now()
+	metrics, err := collectMetrics(ctx, h.pool)
+	h.worker.Record(start, err)
+	if err != nil {
+		return
+	}
+	h.history.Add(*metrics)
+	h.store(metrics)
+}
+
+// store replaces the cached snapshot.
+func (h *MetricsHandler) store(metrics *models.MetricsResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached == nil || metrics.Timestamp.After(h.cached.Timestamp) {
+		h.cached = metrics
+		h.fetchedAt = time.Now()
+	}
+}
+
+// collect runs the metrics queries, letting only one collection hit the
+// database at a time; callers that waited reuse a result fetched while
+// they were blocked.
+func (h *MetricsHandler) collect(ctx context.Context) (*models.MetricsResponse, error) {
+	requested := time.Now()
+
+	h.collectMu.Lock()
+	defer h.collectMu.Unlock()
+
+	h.mu.Lock()
+	if h.cached != nil && h.fetchedAt.After(requested) {
+		cached := h.cached
+		h.mu.Unlock()
+		return cached, nil
+	}
+	h.mu.Unlock()
+
+	metrics, err := collectMetrics(ctx, h.pool)
+	if err != nil {
+		return nil, err
+	}
+	h.store(metrics)
+	return metrics, nil
+}
+
+// cachedMetrics returns a cached snapshot according to the TTL settings,
+// along with its cache state (HIT or STALE). A stale hit triggers a
+// background refresh.
+func (h *MetricsHandler) cachedMetrics() (*models.MetricsResponse, string, time.Duration) {
+	if h.cfg.Metrics.CacheTTL <= 0 {
+		return nil, "", 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached == nil {
+		return nil, "", 0
+	}
+
+	age := time.Since(h.fetchedAt)
+	switch {
+	case age < h.cfg.Metrics.CacheTTL:
+		return h.cached, "HIT", age
+	case age < h.cfg.Metrics.CacheTTL+h.cfg.Metrics.CacheStaleTTL:
+		if !h.refreshing {
+			h.refreshing = true
+			go h.revalidate()
+		}
+		return h.cached, "STALE", age
+	}
+	return nil, "", 0
+}
+
+// revalidate refreshes the cache in the background after a stale hit.
+func (h *MetricsHandler) revalidate() {
+	defer func() {
+		h.mu.Lock()
+		h.refreshing = false
+		h.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	h.collect(ctx)
 }
 
 // Metrics handles GET /metrics - get database metrics.
+//
+// Results are cached for METRICS_CACHE_TTL and then served stale for up to
+// METRICS_CACHE_STALE_TTL while a background refresh runs, so aggressive
+// scraping does not load the database. The X-Cache header reports HIT,
+// STALE or MISS.
 func (h *MetricsHandler) Metrics(c *gin.Context) {
-	ctx := c.Request.Context()
+	metrics, state, age, err := h.snapshot(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
 
-	// Get database size
-	var dbSize int64
-	err := h.pool.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&dbSize)
+	c.Header("X-Cache", state)
+	if state != "MISS" {
+		c.Header("Age", strconv.Itoa(int(age.Seconds())))
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// Snapshot returns the metrics served by GET /metrics, from the cache
+// under the same TTL settings.
+func (h *MetricsHandler) Snapshot(ctx context.Context) (*models.MetricsResponse, error) {
+	metrics, _, _, err := h.snapshot(ctx)
+	return metrics, err
+}
+
+// snapshot returns cached metrics with their cache state and age, or
+// collects them with the state MISS.
+func (h *MetricsHandler) snapshot(ctx context.Context) (*models.MetricsResponse, string, time.Duration, error) {
+	if h.pool == nil {
+		return nil, "", 0, errNoPool
+	}
+
+	if metrics, state, age := h.cachedMetrics(); metrics != nil {
+		return metrics, state, age, nil
+	}
+
+	metrics, err := h.collect(ctx)
+	if err != nil {
+		return nil, "", 0, &Error{
+			Status:  http.StatusInternalServerError,
+			Code:    "database_error",
+			Message: "Failed to collect metrics: " + err.Error(),
+		}
+	}
+	return metrics, "MISS", 0, nil
+}
+
+// History handles GET /metrics/history - sampled metrics for the last
+// window (default: the whole retained window, or ?since=15m).
+func (h *MetricsHandler) History(c *gin.Context) {
+	window, err := durationQuery(c, "since", h.cfg.Metrics.HistoryWindow)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().UTC().Add(-window)
+
+	c.JSON(http.StatusOK, models.MetricsHistoryResponse{
+		IntervalSeconds: h.cfg.Metrics.HistoryInterval.Seconds(),
+		WindowSeconds:   window.Seconds(),
+		Samples: h.history.Filter(func(m models.MetricsResponse) bool {
+			return m.Timestamp.After(cutoff)
+		}),
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// Databases handles GET /metrics/databases - metrics for every
+// non-template database in the cluster.
+func (h *MetricsHandler) Databases(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	rows, err := h.pool.Query(c.Request.Context(), `
+		SELECT
+			d.datname,
+			pg_database_size(d.oid),
+			COALESCE(s.numbackends, 0),
+			d.datconnlimit,
+			COALESCE(s.xact_commit, 0),
+			COALESCE(s.xact_rollback, 0),
+			COALESCE(s.blks_read, 0),
+			COALESCE(s.blks_hit, 0),
+			COALESCE(s.temp_files, 0),
+			COALESCE(s.temp_bytes, 0),
+			COALESCE(s.deadlocks, 0)
+		FROM pg_database d
+		LEFT JOIN pg_stat_database s ON s.datid = d.oid
+		WHERE NOT d.datistemplate AND d.datallowconn
+		ORDER BY d.datname
+	`)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "database_error",
-			Message: "Failed to get database size",
+			Message: "Failed to query database metrics",
 		})
 		return
 	}
+	defer rows.Close()
+
+	databases := []models.DatabaseMetrics{}
+	for rows.Next() {
+		var d models.DatabaseMetrics
+		if err := rows.Scan(
+			&d.Name, &d.SizeBytes, &d.Connections, &d.ConnectionLimit,
+			&d.TransactionsCommitted, &d.TransactionsRolledBack,
+			&d.BlocksRead, &d.BlocksHit,
+			&d.TempFiles, &d.TempBytes, &d.Deadlocks,
+		); err != nil {
+			continue
+		}
+
+		d.CacheHitRatio = 100.0
+		if total := d.BlocksRead + d.BlocksHit; total > 0 {
+			d.CacheHitRatio = float64(d.BlocksHit) / float64(total) * 100
+		}
+		databases = append(databases, d)
+	}
+
+	c.JSON(http.StatusOK, models.DatabasesMetricsResponse{
+		Databases: databases,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// collectMetrics runs the metrics queries against pool and returns a
+// snapshot.
+func collectMetrics(ctx context.Context, pool *db.Pool) (*models.MetricsResponse, error) {
+	// Get database size
+	var dbSize int64
+	err := pool.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&dbSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
 
 	// Get connection info
 	var activeConns, maxConns int
-	err = h.pool.QueryRow(ctx, `
+	err = pool.QueryRow(ctx, `
 		SELECT
 			(SELECT count(*) FROM pg_stat_activity WHERE state = 'active'),
 			(SELECT setting::int FROM pg_settings WHERE name = 'max_connections')
 	`).Scan(&activeConns, &maxConns)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to get connection info",
-		})
-		return
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
 	}
 
-	// Get transaction stats
+	// Get transaction and I/O pressure stats
 	var committed, rolledBack, blocksRead, blocksHit int64
-	err = h.pool.QueryRow(ctx, `
+	var tempFiles, tempBytes, deadlocks int64
+	var checksumFailures *int64
+	err = pool.QueryRow(ctx, `
 		SELECT
 			COALESCE(xact_commit, 0),
 			COALESCE(xact_rollback, 0),
 			COALESCE(blks_read, 0),
-			COALESCE(blks_hit, 0)
+			COALESCE(blks_hit, 0),
+			COALESCE(temp_files, 0),
+			COALESCE(temp_bytes, 0),
+			COALESCE(deadlocks, 0),
+			checksum_failures
 		FROM pg_stat_database
 		WHERE datname = current_database()
-	`).Scan(&committed, &rolledBack, &blocksRead, &blocksHit)
+	`).Scan(&committed, &rolledBack, &blocksRead, &blocksHit,
+		&tempFiles, &tempBytes, &deadlocks, &checksumFailures)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to get transaction stats",
-		})
-		return
+		return nil, fmt.Errorf("failed to get transaction stats: %w", err)
 	}
 
 	// Check if in recovery
 	var isInRecovery bool
-	err = h.pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&isInRecovery)
+	err = pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&isInRecovery)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "database_error",
-			Message: "Failed to check recovery status",
-		})
-		return
+		return nil, fmt.Errorf("failed to check recovery status: %w", err)
 	}
 
 	// Get replication lag if replica
 	var replicationLag *int64
 	if isInRecovery {
 		var lag int64
-		err = h.pool.QueryRow(ctx, `
+		err = pool.QueryRow(ctx, `
 			SELECT CASE
 				WHEN pg_last_wal_receive_lsn() IS NOT NULL
 				THEN pg_wal_lsn_diff(pg_last_wal_receive_lsn(), pg_last_wal_replay_lsn())
@@ -95,6 +359,30 @@ func (h *MetricsHandler) Metrics(c *gin.Context) {
 		}
 	}
 
+	// Get replication lag in time: replay delay on a replica, per-standby
+	// write/flush/replay lag on the primary
+	var replayDelay *float64
+	var lastReplay *time.Time
+	var replicas []models.ReplicaLag
+	if isInRecovery {
+		err = pool.QueryRow(ctx, `
+			SELECT
+				pg_last_xact_replay_timestamp(),
+				CASE
+					WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+					ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+				END::float8
+		`).Scan(&lastReplay, &replayDelay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replay delay: %w", err)
+		}
+	} else {
+		replicas, err = replicaLags(ctx, pool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query pg_stat_replication: %w", err)
+		}
+	}
+
 	// Calculate cache hit ratio
 	totalBlocks := blocksRead + blocksHit
 	var cacheHitRatio float64 = 100.0
@@ -108,7 +396,7 @@ func (h *MetricsHandler) Metrics(c *gin.Context) {
 		connUsage = float64(activeConns) / float64(maxConns) * 100
 	}
 
-	c.JSON(http.StatusOK, models.MetricsResponse{
+	return &models.MetricsResponse{
 		DatabaseSizeBytes:      dbSize,
 		ActiveConnections:      activeConns,
 		MaxConnections:         maxConns,
@@ -118,8 +406,71 @@ func (h *MetricsHandler) Metrics(c *gin.Context) {
 		BlocksRead:             blocksRead,
 		BlocksHit:              blocksHit,
 		CacheHitRatio:          cacheHitRatio,
+		TempFiles:              tempFiles,
+		TempBytes:              tempBytes,
+		Deadlocks:              deadlocks,
+		ChecksumFailures:       checksumFailures,
 		ReplicationLagBytes:    replicationLag,
+		ReplicationDelaySecs:   replayDelay,
+		LastReplayTimestamp:    lastReplay,
+		Replicas:               replicas,
 		IsInRecovery:           isInRecovery,
+		Pool:                   poolStats(pool),
 		Timestamp:              time.Now().UTC(),
-	})
+	}, nil
+}
+
+// poolStats reports the client-side pgx pool utilization.
+func poolStats(pool *db.Pool) *models.PoolStats {
+	stat := pool.Stat()
+
+	var avgWait float64
+	if n := stat.AcquireCount(); n > 0 {
+		avgWait = float64(stat.AcquireDuration().Microseconds()) / float64(n) / 1000
+	}
+
+	return &models.PoolStats{
+		AcquiredConns:     stat.AcquiredConns(),
+		IdleConns:         stat.IdleConns(),
+		TotalConns:        stat.TotalConns(),
+		MaxConns:          stat.MaxConns(),
+		AcquireCount:      stat.AcquireCount(),
+		EmptyAcquireCount: stat.EmptyAcquireCount(),
+		AvgAcquireWaitMs:  avgWait,
+	}
+}
+
+// replicaLags returns per-standby lag from pg_stat_replication on the
+// primary.
+func replicaLags(ctx context.Context, pool *db.Pool) ([]models.ReplicaLag, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT
+			COALESCE(application_name, ''),
+			client_addr::text,
+			COALESCE(state, ''),
+			COALESCE(sync_state, ''),
+			pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn)::bigint,
+			EXTRACT(EPOCH FROM write_lag)::float8,
+			EXTRACT(EPOCH FROM flush_lag)::float8,
+			EXTRACT(EPOCH FROM replay_lag)::float8
+		FROM pg_stat_replication
+		ORDER BY application_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replicas := []models.ReplicaLag{}
+	for rows.Next() {
+		var r models.ReplicaLag
+		if err := rows.Scan(
+			&r.ApplicationName, &r.ClientAddr, &r.State, &r.SyncState,
+			&r.ReplayLagBytes, &r.WriteLagSeconds, &r.FlushLagSeconds, &r.ReplayLagSeconds,
+		); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, r)
+	}
+	return replicas, rows.Err()
 }
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
+	"github.com/postgresql-ha-dr/api-go/internal/migrations"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// MigrationsHandler handles the schema migrations of the API tables.
+type MigrationsHandler struct {
+	migrator *migrations.Migrator
+	cluster  *db.Cluster
+	startup  *lifecycle.Tracker
+}
+
+// NewMigrationsHandler creates a new migrations handler. A successful
+// migration completes the schema phase of startup, which failed if the
+// primary could not be migrated then.
+func NewMigrationsHandler(migrator *migrations.Migrator, cluster *db.Cluster, startup *lifecycle.Tracker) *MigrationsHandler {
+	return &MigrationsHandler{
+		migrator: migrator,
+		cluster:  cluster,
+		startup:  startup,
+	}
+}
+
+// List handles GET /admin/migrations - every migration and when it was
+// applied. Like other reads it falls back to a replica.
+func (h *MigrationsHandler) List(c *gin.Context) {
+	pool, _, err := h.cluster.Reader()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "database_unavailable",
+			Message: err.Error(),
+		})
+		return
+	}
+	h.respond(c, pool, nil)
+}
+
+// Migrate handles POST /admin/migrate - apply the pending migrations on
+// the primary. Refused with 409 when the applied migrations differ from
+// those of this build.
+func (h *MigrationsHandler) Migrate(c *gin.Context) {
+	pool, err := h.cluster.Writer()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "primary_unavailable",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	applied, err := h.migrator.Up(c.Request.Context(), pool)
+	var diverged *migrations.ChecksumError
+	if errors.As(err, &diverged) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "migrations_diverged",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "migration_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	h.startup.Complete(lifecycle.PhaseSchemaMigrated)
+
+	versions := make(map[int]bool, len(applied))
+	for _, migration := range applied {
+		versions[migration.Version] = true
+	}
+	h.respond(c, pool, versions)
+}
+
+// respond writes the status of every migration, listing those in applied
+// as applied by the request.
+func (h *MigrationsHandler) respond(c *gin.Context, pool *db.Pool, applied map[int]bool) {
+	statuses, err := h.migrator.Status(c.Request.Context(), pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read applied migrations",
+		})
+		return
+	}
+
+	response := models.MigrationsResponse{
+		Migrations: make([]models.MigrationStatus, 0, len(statuses)),
+		Timestamp:  time.Now().UTC(),
+	}
+	for _, status := range statuses {
+		migration := models.MigrationStatus{
+			Version:   status.Version,
+			Name:      status.Name,
+			Checksum:  status.Checksum,
+			AppliedAt: status.AppliedAt,
+		}
+		response.Migrations = append(response.Migrations, migration)
+		if status.AppliedAt == nil {
+			response.Pending++
+		} else if status.Version > response.Version {
+			response.Version = status.Version
+		}
+		if applied[status.Version] {
+			response.Applied = append(response.Applied, migration)
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
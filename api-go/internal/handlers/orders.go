@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Order integrity states.
+const (
+	OrdersIntegrityOK     = "ok"
+	OrdersIntegrityBroken = "broken"
+)
+
+// OrdersHandler handles orders of items. Placing an order writes to
+// several tables in one transaction, so failover tests cover more than
+// single-row writes.
+type OrdersHandler struct {
+	items *ItemsHandler
+}
+
+// NewOrdersHandler creates a new orders handler. The primary and replica
+// pools are those of items.
+func NewOrdersHandler(items *ItemsHandler) *OrdersHandler {
+	return &OrdersHandler{items: items}
+}
+
+// Create handles POST /orders - place an order in one transaction: the
+// items are locked against changes, the order and its lines are inserted
+// and the total is computed from the lines. Lines for the same item are
+// merged.
+//
+// Refused with 409 when an item does not exist, is inactive or is in the
+// trash.
+func (h *OrdersHandler) Create(c *gin.Context) {
+	var req models.OrderCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pool, ok := h.items.writer(c)
+	if !ok {
+		return
+	}
+
+	quantities := map[models.ItemID]int{}
+	for _, line := range req.Lines {
+		id, err := h.items.parseItemID(string(line.ItemID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "validation_error",
+				Message: fmt.Sprintf("item ID %q %v", string(line.ItemID), err),
+			})
+			return
+		}
+		quantities[id] += line.Quantity
+	}
+	itemIDs := make([]models.ItemID, 0, len(quantities))
+	for id := range quantities {
+		itemIDs = append(itemIDs, id)
+	}
+	// A fixed lock order keeps concurrent orders from deadlocking
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	prices, err := lockOrderItems(ctx, tx, itemIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read items",
+		})
+		return
+	}
+	var unavailable []models.ItemID
+	for _, id := range itemIDs {
+		if _, ok := prices[id]; !ok {
+			unavailable = append(unavailable, id)
+		}
+	}
+	if len(unavailable) > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "items_unavailable",
+			Message: fmt.Sprintf("Items %v do not exist, are inactive or are in the trash", unavailable),
+		})
+		return
+	}
+
+	order := models.Order{Status: models.OrderStatusPlaced}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO orders (status) VALUES ($1)
+		RETURNING id, created_at, updated_at
+	`, order.Status).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create order",
+		})
+		return
+	}
+
+	qty := make([]int, len(itemIDs))
+	unitPrices := make([]models.Decimal, len(itemIDs))
+	for i, id := range itemIDs {
+		qty[i] = quantities[id]
+		unitPrices[i] = prices[id]
+		order.Lines = append(order.Lines, models.OrderLine{ItemID: id, Quantity: qty[i], UnitPrice: unitPrices[i]})
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO order_lines (order_id, item_id, quantity, unit_price)
+		SELECT $1, item_id, quantity, unit_price
+		FROM unnest($2::`+h.items.keyType()+`[], $3::int[], $4::numeric[]) AS l(item_id, quantity, unit_price)
+	`, order.ID, itemIDs, qty, unitPrices)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to create order lines",
+		})
+		return
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE orders
+		SET total = (SELECT sum(quantity * unit_price) FROM order_lines WHERE order_id = $1)
+		WHERE id = $1
+		RETURNING total
+	`, order.ID).Scan(&order.Total)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to compute order total",
+		})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to commit order: " + err.Error(),
+		})
+		return
+	}
+
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusCreated, order)
+}
+
+// lockOrderItems returns the prices of the orderable items among ids,
+// locked until the end of the transaction so they can neither change nor
+// be deleted under the order.
+func lockOrderItems(ctx context.Context, tx pgx.Tx, ids []models.ItemID) (map[models.ItemID]models.Decimal, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, price
+		FROM items
+		WHERE id = ANY($1) AND is_active AND deleted_at IS NULL
+		ORDER BY id
+		FOR SHARE
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := map[models.ItemID]models.Decimal{}
+	for rows.Next() {
+		var id models.ItemID
+		var price models.Decimal
+		if err := rows.Scan(&id, &price); err != nil {
+			return nil, err
+		}
+		prices[id] = price
+	}
+	return prices, rows.Err()
+}
+
+// isForeignKeyViolation reports whether err is a write refused because
+// rows still reference the row, such as an item on an order.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// List handles GET /orders - list orders, newest first, without their
+// lines.
+func (h *OrdersHandler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.items.reader(c)
+	if !ok {
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT id, status, total, created_at, updated_at
+		FROM orders
+		ORDER BY id DESC
+		OFFSET $1 LIMIT $2
+	`, skip, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list orders",
+		})
+		return
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.ID, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to list orders",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// Get handles GET /orders/:id - an order with its lines.
+func (h *OrdersHandler) Get(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.items.reader(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Order ID must be a number",
+		})
+		return
+	}
+
+	// Both reads see the same snapshot, so the lines match the total
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	var order models.Order
+	err = tx.QueryRow(ctx, `
+		SELECT id, status, total, created_at, updated_at
+		FROM orders
+		WHERE id = $1
+	`, id).Scan(&order.ID, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "Order not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read order",
+		})
+		return
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT item_id, quantity, unit_price
+		FROM order_lines
+		WHERE order_id = $1
+		ORDER BY item_id
+	`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read order lines",
+		})
+		return
+	}
+	defer rows.Close()
+	order.Lines = []models.OrderLine{}
+	for rows.Next() {
+		var line models.OrderLine
+		if err := rows.Scan(&line.ItemID, &line.Quantity, &line.UnitPrice); err != nil {
+			continue
+		}
+		order.Lines = append(order.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read order lines",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Cancel handles POST /orders/:id/cancel - cancel a placed order. Its
+// lines are kept.
+func (h *OrdersHandler) Cancel(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, ok := h.items.writer(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Order ID must be a number",
+		})
+		return
+	}
+
+	var order models.Order
+	err = pool.QueryRow(ctx, `
+		UPDATE orders
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status = $3
+		RETURNING id, status, total, created_at, updated_at
+	`, id, models.OrderStatusCancelled, models.OrderStatusPlaced).Scan(
+		&order.ID, &order.Status, &order.Total, &order.CreatedAt, &order.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "not_found",
+			Message: "No placed order with this ID",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to cancel order",
+		})
+		return
+	}
+
+	setConsistencyToken(c, pool)
+	c.JSON(http.StatusOK, order)
+}
+
+// Integrity handles GET /orders/integrity - check that every order has
+// lines and a total matching them. Run it against the new primary after a
+// failover, or a restored node: a broken order means a transaction was
+// only partly preserved.
+func (h *OrdersHandler) Integrity(c *gin.Context) {
+	ctx := c.Request.Context()
+	pool, _, ok := h.items.reader(c)
+	if !ok {
+		return
+	}
+
+	response := models.OrdersIntegrityResponse{Status: OrdersIntegrityOK}
+	err := pool.QueryRow(ctx, `
+		WITH sums AS (
+			SELECT o.id, o.total, count(l.item_id) AS lines, coalesce(sum(l.quantity * l.unit_price), 0) AS sum
+			FROM orders o
+			LEFT JOIN order_lines l ON l.order_id = o.id
+			GROUP BY o.id, o.total
+		)
+		SELECT count(*),
+		       coalesce(sum(lines), 0),
+		       count(*) FILTER (WHERE total <> sum),
+		       count(*) FILTER (WHERE lines = 0)
+		FROM sums
+	`).Scan(&response.Orders, &response.Lines, &response.TotalMismatches, &response.EmptyOrders)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to check orders",
+		})
+		return
+	}
+	if response.TotalMismatches > 0 || response.EmptyOrders > 0 {
+		response.Status = OrdersIntegrityBroken
+	}
+	response.Timestamp = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
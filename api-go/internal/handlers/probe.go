@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// writeProbeTimeout bounds a write probe, so a primary waiting on a
+// synchronous standby fails the probe instead of hanging the monitor.
+const writeProbeTimeout = 5 * time.Second
+
+// ProbeHandler performs synthetic writes to check the cluster accepts
+// them.
+type ProbeHandler struct {
+	cluster *db.Cluster
+}
+
+// NewProbeHandler creates a new probe handler.
+func NewProbeHandler(cluster *db.Cluster) *ProbeHandler {
+	return &ProbeHandler{cluster: cluster}
+}
+
+// Write handles GET /probe/write - upsert a row in the ha_write_probe
+// table and report how long the commit took. Answers 503 when the write
+// fails, so external monitors can alert on the status code alone.
+func (h *ProbeHandler) Write(c *gin.Context) {
+	response := models.WriteProbeResponse{Status: "failed"}
+
+	start := time.Now()
+	err := h.write(c.Request.Context(), &response)
+	response.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Timestamp = time.Now().UTC()
+
+	if err != nil {
+		response.Error = err.Error()
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "25006" {
+			response.Status = "read_only"
+		}
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	response.Status = "ok"
+	response.Writable = true
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProbeHandler) write(ctx context.Context, response *models.WriteProbeResponse) error {
+	pool, err := h.cluster.Writer()
+	if err != nil {
+		return err
+	}
+	host, port := pool.Addr()
+	response.Node = net.JoinHostPort(host, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(ctx, writeProbeTimeout)
+	defer cancel()
+
+	_, err = pool.Exec(ctx, `
+		INSERT INTO ha_write_probe (id, probes, written_at) VALUES (1, 1, now())
+		ON CONFLICT (id) DO UPDATE SET probes = ha_write_probe.probes + 1, written_at = now()
+	`)
+	return err
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusHandler serves metrics in the Prometheus exposition format.
+type PrometheusHandler struct {
+	registry *prometheus.Registry
+}
+
+// NewPrometheusHandler creates a handler exporting the given collectors.
+func NewPrometheusHandler(collectors ...prometheus.Collector) *PrometheusHandler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors...)
+	return &PrometheusHandler{registry: registry}
+}
+
+// Metrics handles GET /metrics/prometheus.
+func (h *PrometheusHandler) Metrics(c *gin.Context) {
+	promhttp.HandlerFor(h.registry, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// defaultPromoteWait matches the default wait_seconds of pg_promote().
+const defaultPromoteWait = 60
+
+// PromoteHandler promotes a standby that is not managed by Patroni, for
+// manual DR activation.
+type PromoteHandler struct {
+	cfg     *config.Config
+	pool    *db.Pool
+	watcher *WatcherHandler
+}
+
+// NewPromoteHandler creates a new promote handler. The watcher, when set,
+// is told about the new state right away instead of at its next check.
+func NewPromoteHandler(cfg *config.Config, pool *db.Pool, watcher *WatcherHandler) *PromoteHandler {
+	return &PromoteHandler{cfg: cfg, pool: pool, watcher: watcher}
+}
+
+// Promote handles POST /admin/promote - run pg_promote() on the standby and
+// wait until it has left recovery.
+//
+// confirm must equal the node's system identifier. Refused with 409 when
+// the node is already a primary or Patroni manages the cluster, and 504
+// when promotion does not finish within wait_seconds (default 60). The
+// response is held for that long, past the write timeout of the server.
+func (h *PromoteHandler) Promote(c *gin.Context) {
+	var req models.PromoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.WaitSeconds == 0 {
+		req.WaitSeconds = defaultPromoteWait
+	}
+
+	if len(h.cfg.Patroni.URLs) > 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "managed_by_patroni",
+			Message: "Patroni manages this cluster; use POST /cluster/failover instead",
+		})
+		return
+	}
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	before, err := queryNodeState(ctx, h.pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read node state: " + err.Error(),
+		})
+		return
+	}
+	if !before.InRecovery {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "not_in_recovery",
+			Message: "The node is already a primary",
+		})
+		return
+	}
+	if req.Confirm != before.SystemIdentifier {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "confirmation_mismatch",
+			Message: "confirm must be the system identifier of the node to promote, as reported by GET /cluster/identity",
+		})
+		return
+	}
+
+	slog.InfoContext(ctx, "Standby promotion requested",
+		"system_identifier", before.SystemIdentifier, "timeline", before.Timeline, "client_ip", c.ClientIP())
+
+	// The wait may outlast the WriteTimeout of the server; a cut response
+	// would leave the operator unsure whether the node was promoted
+	clearWriteDeadline(c)
+	started := time.Now()
+	promoteCtx, cancel := context.WithTimeout(ctx, time.Duration(req.WaitSeconds+5)*time.Second)
+	defer cancel()
+
+	var promoted bool
+	err = h.pool.QueryRow(promoteCtx, "SELECT pg_promote(true, $1)", req.WaitSeconds).Scan(&promoted)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "promote_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if !promoted {
+		c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{
+			Error:   "promote_timeout",
+			Message: "The node did not leave recovery within the wait period; check the server log",
+		})
+		return
+	}
+
+	after, err := queryNodeState(ctx, h.pool)
+	if h.watcher != nil {
+		h.watcher.Observe(after, err)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Promoted, but failed to read the new timeline: " + err.Error(),
+		})
+		return
+	}
+
+	slog.InfoContext(ctx, "Standby promoted", "system_identifier", after.SystemIdentifier, "from_timeline", before.Timeline, "to_timeline", after.Timeline)
+
+	c.JSON(http.StatusOK, models.PromoteResponse{
+		Promoted:         true,
+		SystemIdentifier: after.SystemIdentifier,
+		PreviousTimeline: before.Timeline,
+		Timeline:         after.Timeline,
+		DurationSeconds:  time.Since(started).Seconds(),
+		Timestamp:        time.Now().UTC(),
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Recovery configuration states.
+const (
+	RecoveryConfigOK            = "ok"
+	RecoveryConfigMisconfigured = "misconfigured"
+	RecoveryConfigNotInRecovery = "not_in_recovery"
+)
+
+// recoverySettings are the settings read from pg_settings. Superuser-only
+// ones such as primary_conninfo are missing from the view for roles
+// without pg_read_all_settings.
+var recoverySettings = []string{
+	"primary_conninfo",
+	"primary_slot_name",
+	"restore_command",
+	"archive_cleanup_command",
+	"recovery_min_apply_delay",
+	"hot_standby",
+	"recovery_target",
+	"recovery_target_lsn",
+	"recovery_target_name",
+	"recovery_target_time",
+	"recovery_target_xid",
+	"recovery_target_inclusive",
+	"recovery_target_timeline",
+	"recovery_target_action",
+}
+
+// RecoveryHandler inspects how the node is configured to recover.
+type RecoveryHandler struct {
+	pool *db.Pool
+}
+
+// NewRecoveryHandler creates a new recovery handler.
+func NewRecoveryHandler(pool *db.Pool) *RecoveryHandler {
+	return &RecoveryHandler{pool: pool}
+}
+
+// Config handles GET /recovery/config - primary_conninfo with passwords
+// redacted, restore_command, recovery target settings and which signal
+// files are present, with the problems they add up to on a standby.
+//
+// Checking the signal files needs superuser or EXECUTE on pg_stat_file;
+// without it they are null and listed as unreadable.
+func (h *RecoveryHandler) Config(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	response := models.RecoveryConfigResponse{
+		RecoveryTargets: map[string]string{},
+		Unreadable:      []string{},
+		Issues:          []string{},
+		Timestamp:       time.Now().UTC(),
+	}
+	if err := h.pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&response.InRecovery); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read recovery state: " + err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.settings(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read recovery settings: " + err.Error(),
+		})
+		return
+	}
+	for _, name := range recoverySettings {
+		value, ok := settings[name]
+		if !ok {
+			response.Unreadable = append(response.Unreadable, name)
+			continue
+		}
+		switch name {
+		case "primary_conninfo":
+			response.PrimaryConninfo = db.SanitizeConninfo(value)
+		case "primary_slot_name":
+			response.PrimarySlotName = value
+		case "restore_command":
+			response.RestoreCommand = value
+		case "archive_cleanup_command":
+			response.ArchiveCleanupCommand = value
+		case "recovery_min_apply_delay":
+			response.RecoveryMinApplyDelay = value
+		case "hot_standby":
+			response.HotStandby = value
+		default:
+			response.RecoveryTargets[name] = value
+		}
+	}
+
+	for _, file := range []struct {
+		name string
+		dest **bool
+	}{
+		{"standby.signal", &response.StandbySignal},
+		{"recovery.signal", &response.RecoverySignal},
+	} {
+		var present bool
+		err := h.pool.QueryRow(ctx, "SELECT (pg_stat_file($1, true)).size IS NOT NULL", file.name).Scan(&present)
+		if err != nil {
+			response.Unreadable = append(response.Unreadable, file.name)
+			continue
+		}
+		*file.dest = &present
+	}
+
+	if !response.InRecovery {
+		response.Status = RecoveryConfigNotInRecovery
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	response.Issues = recoveryIssues(response, settings)
+	response.Status = RecoveryConfigOK
+	if len(response.Issues) > 0 {
+		response.Status = RecoveryConfigMisconfigured
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// settings reads the visible recovery settings by name.
+func (h *RecoveryHandler) settings(ctx context.Context) (map[string]string, error) {
+	rows, err := h.pool.Query(ctx, "SELECT name, setting FROM pg_settings WHERE name = ANY($1)", recoverySettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+	for rows.Next() {
+		var name string
+		var setting *string
+		if err := rows.Scan(&name, &setting); err != nil {
+			return nil, err
+		}
+		if setting != nil {
+			settings[name] = *setting
+		}
+	}
+	return settings, rows.Err()
+}
+
+// recoveryIssues lists what would keep a standby from following the
+// primary or from being ready to take over. Unreadable settings are not
+// reported as missing.
+func recoveryIssues(r models.RecoveryConfigResponse, settings map[string]string) []string {
+	issues := []string{}
+	_, conninfoKnown := settings["primary_conninfo"]
+	_, restoreKnown := settings["restore_command"]
+
+	if r.StandbySignal != nil && !*r.StandbySignal {
+		if r.RecoverySignal != nil && *r.RecoverySignal {
+			issues = append(issues, "recovery.signal without standby.signal: the node performs targeted recovery and will leave recovery instead of following the primary")
+		} else {
+			issues = append(issues, "standby.signal is missing: the node will not stay a standby after a restart")
+		}
+	}
+	switch {
+	case conninfoKnown && restoreKnown && r.PrimaryConninfo == "" && r.RestoreCommand == "":
+		issues = append(issues, "neither primary_conninfo nor restore_command is set: the standby has no source of WAL")
+	case conninfoKnown && r.PrimaryConninfo == "":
+		issues = append(issues, "primary_conninfo is not set: the standby only replays archived WAL and lags behind")
+	case restoreKnown && r.RestoreCommand == "":
+		issues = append(issues, "restore_command is not set: the standby cannot catch up from the archive once the primary has recycled the WAL it needs")
+	}
+	if conninfoKnown && r.PrimaryConninfo != "" && r.PrimarySlotName == "" {
+		issues = append(issues, "primary_slot_name is not set: the primary may remove WAL before the standby received it")
+	}
+	for _, name := range []string{"recovery_target", "recovery_target_lsn", "recovery_target_name", "recovery_target_time", "recovery_target_xid"} {
+		if value := r.RecoveryTargets[name]; value != "" {
+			issues = append(issues, name+" is set: recovery will stop at "+value+" instead of following the primary")
+		}
+	}
+	if timeline, ok := r.RecoveryTargets["recovery_target_timeline"]; ok && timeline != "" && timeline != "latest" {
+		issues = append(issues, "recovery_target_timeline is "+timeline+": the standby will not follow the primary to a new timeline after a failover")
+	}
+	if r.RecoveryMinApplyDelay != "" && r.RecoveryMinApplyDelay != "0" {
+		issues = append(issues, "recovery_min_apply_delay is "+r.RecoveryMinApplyDelay+"ms: the standby is deliberately behind and a poor failover candidate")
+	}
+	return issues
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/syncrep"
+)
+
+// Synchronous replication states.
+const (
+	SyncStatusAsync       = "async"
+	SyncStatusNotPrimary  = "not_primary"
+	SyncStatusSatisfied   = "satisfied"
+	SyncStatusDegraded    = "degraded"
+	SyncStatusUnsatisfied = "unsatisfied"
+	SyncStatusUnknown     = "unknown"
+)
+
+// ReplicationHandler reports on the replication setup of the primary.
+type ReplicationHandler struct {
+	pool *db.Pool
+}
+
+// NewReplicationHandler creates a new replication handler.
+func NewReplicationHandler(pool *db.Pool) *ReplicationHandler {
+	return &ReplicationHandler{pool: pool}
+}
+
+// Sync handles GET /replication/sync - whether enough synchronous
+// standbys are streaming for commits to complete.
+//
+// Status is async without synchronous_standby_names, satisfied when the
+// requirement is met, degraded when it is met but a listed standby is
+// missing, and unsatisfied when commits cannot be confirmed. With
+// synchronous_commit at on, remote_write or remote_apply, writes then hang
+// and writes_may_hang is set.
+func (h *ReplicationHandler) Sync(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	response, err := querySyncReplication(c.Request.Context(), h.pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to read synchronous replication state: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// querySyncReplication reads the synchronous replication settings and the
+// connected standbys, and evaluates them.
+func querySyncReplication(ctx context.Context, pool *db.Pool) (*models.SyncReplicationResponse, error) {
+	response := &models.SyncReplicationResponse{
+		Names:     []string{},
+		Standbys:  []models.ReplicaLag{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	var inRecovery bool
+	err := pool.QueryRow(ctx, `
+		SELECT pg_is_in_recovery(),
+			current_setting('synchronous_standby_names'),
+			current_setting('synchronous_commit')
+	`).Scan(&inRecovery, &response.SynchronousStandbyNames, &response.SynchronousCommit)
+	if err != nil {
+		return nil, err
+	}
+	if inRecovery {
+		response.Status = SyncStatusNotPrimary
+		response.Message = "Synchronous replication is configured on the primary"
+		return response, nil
+	}
+
+	response.Standbys, err = replicaLags(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	evaluateSyncReplication(response)
+	return response, nil
+}
+
+// evaluateSyncReplication sets the status of a response holding the
+// settings and standbys of a primary.
+func evaluateSyncReplication(r *models.SyncReplicationResponse) {
+	cfg, err := syncrep.Parse(r.SynchronousStandbyNames)
+	if err != nil {
+		r.Status = SyncStatusUnknown
+		r.Message = "Cannot parse synchronous_standby_names: " + err.Error()
+		return
+	}
+	if !cfg.Enabled() {
+		r.Status = SyncStatusAsync
+		r.Message = "Replication is asynchronous"
+		return
+	}
+
+	r.Method = cfg.Method
+	r.Required = cfg.Num
+	r.Names = cfg.Names
+
+	standbys := make([]syncrep.Standby, 0, len(r.Standbys))
+	for _, s := range r.Standbys {
+		standbys = append(standbys, syncrep.Standby{ApplicationName: s.ApplicationName, State: s.State, SyncState: s.SyncState})
+	}
+	r.Connected = len(cfg.Candidates(standbys))
+
+	switch {
+	case r.Connected < r.Required:
+		r.Status = SyncStatusUnsatisfied
+		r.Message = fmt.Sprintf("%d of %d required synchronous standbys are streaming", r.Connected, r.Required)
+		switch r.SynchronousCommit {
+		case "on", "remote_write", "remote_apply":
+			r.WritesMayHang = true
+			r.Message += "; commits will wait until enough standbys connect"
+		}
+	case !hasWildcard(cfg.Names) && r.Connected < len(cfg.Names):
+		r.Status = SyncStatusDegraded
+		r.Message = fmt.Sprintf("%d of %d listed standbys are streaming; %d are required", r.Connected, len(cfg.Names), r.Required)
+	default:
+		r.Status = SyncStatusSatisfied
+		r.Message = fmt.Sprintf("%d synchronous candidates are streaming; %d are required", r.Connected, r.Required)
+	}
+}
+
+func hasWildcard(names []string) bool {
+	for _, name := range names {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
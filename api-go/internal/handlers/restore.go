@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// JobTypeRestore is the job type used for restores.
+const JobTypeRestore = "restore"
+
+// RestoreHandler handles restore and point-in-time recovery.
+type RestoreHandler struct {
+	cfg      *config.Config
+	pool     *db.Pool
+	provider backup.Provider
+	jobs     *jobs.Manager
+}
+
+// NewRestoreHandler creates a new restore handler.
+func NewRestoreHandler(cfg *config.Config, pool *db.Pool, manager *jobs.Manager) *RestoreHandler {
+	return &RestoreHandler{cfg: cfg, pool: pool, provider: backup.New(cfg), jobs: manager}
+}
+
+// Restore handles POST /restore - restore the default stanza (or, with the
+// pg_dump provider, database) as an async job.
+//
+// With pgbackrest the restore is refused while PostgreSQL is running on the
+// data directory or answering on the configured connection. Restores are
+// also refused while another restore is in progress. With dry_run the
+// checks are run and the command is returned without executing it.
+func (h *RestoreHandler) Restore(c *gin.Context) {
+	var req models.RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	target := h.provider.Targets()[0]
+	cmd, err := h.provider.RestoreCommand(target, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if h.jobs.Active(JobTypeRestore) {
+		writeError(c, errRestoreInProgress)
+		return
+	}
+
+	if h.provider.RequiresStoppedCluster() {
+		if reason := h.clusterRunning(c.Request.Context()); reason != "" {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "cluster_running",
+				Message: reason + "; stop PostgreSQL before restoring",
+			})
+			return
+		}
+	}
+
+	if req.Target == models.RestoreTargetBackup {
+		if status, err := h.backupExists(c.Request.Context(), target, req.BackupLabel); err != nil {
+			c.JSON(status, models.ErrorResponse{
+				Error:   "invalid_backup",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if req.DryRun {
+		plan := models.RestorePlanResponse{
+			DryRun:    true,
+			Provider:  h.provider.Name(),
+			Stanza:    target,
+			Command:   cmd.Argv,
+			Timestamp: time.Now().UTC(),
+		}
+		if h.provider.RequiresStoppedCluster() {
+			plan.DataDir = h.cfg.Backup.DataDir
+		}
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	params := map[string]interface{}{
+		"target":   req.Target,
+		"provider": h.provider.Name(),
+		"stanza":   target,
+		"delta":    req.Delta,
+	}
+	switch req.Target {
+	case models.RestoreTargetBackup:
+		params["backup_label"] = req.BackupLabel
+	case models.RestoreTargetTime:
+		params["timestamp"] = req.Timestamp.UTC()
+	case models.RestoreTargetLSN:
+		params["lsn"] = req.LSN
+	}
+
+	// Checked again as the job is queued: a concurrent request may have
+	// passed the check above as well
+	job, err := submitExclusive(h.jobs, []string{JobTypeRestore}, errRestoreInProgress, JobTypeRestore, params, h.runRestore(cmd))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Restore requested", "target", req.Target, "client_ip", c.ClientIP())
+	writeJobAccepted(c, job)
+}
+
+var errRestoreInProgress = &Error{
+	Status:  http.StatusConflict,
+	Code:    "restore_in_progress",
+	Message: "Another restore is already queued or running",
+}
+
+// Plan handles GET /restore/plan - the backup set, WAL range and timeline
+// a restore to the given target would use, without running anything.
+//
+// Takes the same parameters as POST /restore as query parameters.
+func (h *RestoreHandler) Plan(c *gin.Context) {
+	var req models.RestoreRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	target := h.provider.Targets()[0]
+	cmd, err := h.provider.RestoreCommand(target, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.cfg.Backup.CommandTimeout)
+	defer cancel()
+
+	status := h.provider.Info(ctx, target)
+	if status.Status != "ok" && status.Status != "no_backup" {
+		msg := status.Status
+		if status.StatusMessage != nil {
+			msg = *status.StatusMessage
+		}
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "backup_info_unavailable",
+			Message: msg,
+		})
+		return
+	}
+
+	plan := backup.PlanRestore(status, req, h.segmentSize(ctx))
+	response := models.RestorePlanResponse{
+		DryRun:    true,
+		Provider:  h.provider.Name(),
+		Stanza:    target,
+		Command:   cmd.Argv,
+		Plan:      &plan,
+		Timestamp: time.Now().UTC(),
+	}
+	if h.provider.RequiresStoppedCluster() {
+		response.DataDir = h.cfg.Backup.DataDir
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// segmentSize returns the server's wal_segment_size, falling back to the
+// PostgreSQL default while the database is unreachable.
+func (h *RestoreHandler) segmentSize(ctx context.Context) int64 {
+	if h.pool == nil {
+		return wal.DefaultSegmentSize
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var size int64
+	err := h.pool.QueryRow(ctx, `SELECT setting::bigint FROM pg_settings WHERE name = 'wal_segment_size'`).Scan(&size)
+	if err != nil || size <= 0 {
+		return wal.DefaultSegmentSize
+	}
+	return size
+}
+
+// runRestore returns the job that executes the restore command.
+func (h *RestoreHandler) runRestore(cmd backup.Command) jobs.Func {
+	return func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, h.cfg.Backup.RestoreTimeout)
+		defer cancel()
+
+		if h.provider.RequiresStoppedCluster() {
+			// Re-check in case PostgreSQL was started while the job was queued.
+			if reason := h.clusterRunning(ctx); reason != "" {
+				return nil, errors.New(reason)
+			}
+		}
+
+		out.Report(fmt.Sprintf("running %s restore", h.provider.Name()))
+		err := cmd.Run(ctx, out)
+		tail := out.Tail(20)
+		if err != nil {
+			return map[string]interface{}{"output": tail}, fmt.Errorf("%s restore failed: %w", cmd.Argv[0], err)
+		}
+
+		if h.provider.RequiresStoppedCluster() {
+			out.Report("restore complete; start PostgreSQL to begin recovery")
+		} else {
+			out.Report("restore complete")
+		}
+		return map[string]interface{}{"output": tail}, nil
+	}
+}
+
+// clusterRunning returns a reason when PostgreSQL appears to be running,
+// or an empty string otherwise. The data directory is only inspected when
+// pgbackrest runs locally; over SSH pgbackrest performs its own check.
+func (h *RestoreHandler) clusterRunning(ctx context.Context) string {
+	if h.cfg.Backup.SSHHost == "" {
+		pidFile := filepath.Join(h.cfg.Backup.DataDir, "postmaster.pid")
+		if _, err := os.Stat(pidFile); err == nil {
+			return fmt.Sprintf("%s exists", pidFile)
+		}
+	}
+
+	if h.pool != nil {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		if err := h.pool.Ping(ctx); err == nil {
+			return "the database is accepting connections"
+		}
+	}
+
+	return ""
+}
+
+// backupExists checks the label against the provider's backup list. It
+// returns the HTTP status to use when the check fails.
+func (h *RestoreHandler) backupExists(ctx context.Context, target, label string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+	defer cancel()
+
+	status := h.provider.Info(ctx, target)
+	if status.Status != "ok" {
+		msg := status.Status
+		if status.StatusMessage != nil {
+			msg = *status.StatusMessage
+		}
+		return http.StatusServiceUnavailable, fmt.Errorf("cannot verify backup: %s", msg)
+	}
+
+	for _, b := range status.Backups {
+		if b.Label == label {
+			return 0, nil
+		}
+	}
+	return http.StatusNotFound, fmt.Errorf("backup %q not found in %s", label, target)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// roleQuery reads the role, WAL position and timeline in one round trip.
+// The timeline is derived as in nodeStateQuery; the position is the
+// current insert location on a primary and the last replayed one on a
+// replica, or the last received one before anything was replayed.
+const roleQuery = `
+	SELECT pg_is_in_recovery(),
+		CASE WHEN pg_is_in_recovery()
+			THEN COALESCE(pg_last_wal_replay_lsn(), pg_last_wal_receive_lsn())::text
+			ELSE pg_current_wal_lsn()::text
+		END,
+		CASE WHEN pg_is_in_recovery()
+			THEN COALESCE(
+				(SELECT received_tli FROM pg_stat_wal_receiver),
+				(SELECT timeline_id FROM pg_control_checkpoint()))
+			ELSE ('x' || substr(pg_walfile_name(pg_current_wal_lsn()), 1, 8))::bit(32)::int
+		END
+`
+
+const (
+	// roleQueryTimeout bounds the query so a stuck node is reported as
+	// unavailable rather than slowing down the router's health checks.
+	roleQueryTimeout = 100 * time.Millisecond
+
+	// roleConnectTimeout bounds reconnecting, for the same reason; the
+	// configured connect timeout is meant for the pool.
+	roleConnectTimeout = 250 * time.Millisecond
+
+	// roleCacheTTL lets checks from several routers share one query, or
+	// one failure.
+	roleCacheTTL = 250 * time.Millisecond
+)
+
+// RoleHandler answers role checks from external routers such as HAProxy,
+// Consul or DNS controllers. It queries the configured node over its own
+// connection, so checks neither wait for nor take connections from the
+// pool, and keep pointing at this node when the pool is retargeted.
+type RoleHandler struct {
+	cfg *config.Config
+
+	mu   sync.Mutex
+	last *roleCheck
+	// running is closed when the check in progress, if any, completes;
+	// only that check uses conn
+	running chan struct{}
+	conn    *pgx.Conn
+}
+
+// roleCheck is the outcome of one check, shared by the checks that
+// arrive within roleCacheTTL.
+type roleCheck struct {
+	response *models.RoleResponse
+	err      error
+	at       time.Time
+}
+
+// NewRoleHandler creates a new role handler. The connection is opened on
+// the first check.
+func NewRoleHandler(cfg *config.Config) *RoleHandler {
+	return &RoleHandler{cfg: cfg}
+}
+
+// Role handles GET /role - whether the node is a primary or a replica,
+// with its WAL position and timeline. Answers 503 when the node cannot be
+// queried within 100ms, or reached within 250ms.
+func (h *RoleHandler) Role(c *gin.Context) {
+	check, err := h.check(c.Request.Context())
+	if err == nil {
+		err = check.err
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.RoleResponse{
+			Role:      "unknown",
+			Error:     err.Error(),
+			Timestamp: time.Now().UTC(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, check.response)
+}
+
+// check returns the last check when recent, waits for the one in progress
+// or runs a new one. The lock is never held while the node is queried.
+func (h *RoleHandler) check(ctx context.Context) (*roleCheck, error) {
+	h.mu.Lock()
+	for h.last == nil || time.Since(h.last.at) >= roleCacheTTL {
+		if h.running == nil {
+			break
+		}
+		running := h.running
+		h.mu.Unlock()
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		h.mu.Lock()
+	}
+	if h.last != nil && time.Since(h.last.at) < roleCacheTTL {
+		last := h.last
+		h.mu.Unlock()
+		return last, nil
+	}
+	running := make(chan struct{})
+	h.running = running
+	conn := h.conn
+	h.mu.Unlock()
+
+	// Other checks share the outcome, so it does not depend on this
+	// request staying around
+	response, conn, err := h.query(context.WithoutCancel(ctx), conn)
+	check := &roleCheck{response: response, err: err, at: time.Now()}
+
+	h.mu.Lock()
+	h.conn, h.last, h.running = conn, check, nil
+	h.mu.Unlock()
+	close(running)
+	return check, nil
+}
+
+// query runs roleQuery on conn, connecting first when it is nil. It
+// returns the connection to use next time: nil after a failure, so the
+// next check reconnects.
+func (h *RoleHandler) query(ctx context.Context, conn *pgx.Conn) (*models.RoleResponse, *pgx.Conn, error) {
+	start := time.Now()
+	if conn == nil {
+		connectCtx, cancel := context.WithTimeout(ctx, roleConnectTimeout)
+		c, err := pgx.Connect(connectCtx, h.cfg.Database.DSN())
+		cancel()
+		if err != nil {
+			return nil, nil, err
+		}
+		conn = c
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, roleQueryTimeout)
+	defer cancel()
+
+	response := &models.RoleResponse{Role: RolePrimary}
+	err := conn.QueryRow(queryCtx, roleQuery).Scan(&response.InRecovery, &response.CurrentLSN, &response.Timeline)
+	if err != nil {
+		conn.Close(context.Background())
+		return nil, nil, err
+	}
+	if response.InRecovery {
+		response.Role = RoleReplica
+	}
+	response.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	response.Timestamp = time.Now().UTC()
+	return response, conn, nil
+}
+
+// Close closes the connection, if open, once the check in progress is
+// done with it.
+func (h *RoleHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for h.running != nil {
+		running := h.running
+		h.mu.Unlock()
+		<-running
+		h.mu.Lock()
+	}
+	if h.conn != nil {
+		h.conn.Close(context.Background())
+		h.conn = nil
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// SessionsHandler handles session report endpoints.
+type SessionsHandler struct {
+	cfg  *config.Config
+	pool *db.Pool
+}
+
+// NewSessionsHandler creates a new sessions handler.
+func NewSessionsHandler(cfg *config.Config, pool *db.Pool) *SessionsHandler {
+	return &SessionsHandler{
+		cfg:  cfg,
+		pool: pool,
+	}
+}
+
+// Problematic handles GET /sessions/problematic - long-running queries and
+// idle-in-transaction sessions. Thresholds default to the configured values
+// and can be overridden with ?max_query_age= and ?max_idle_age= durations.
+func (h *SessionsHandler) Problematic(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	queryAge, err := durationQuery(c, "max_query_age", h.cfg.Sessions.MaxQueryAge)
+	if err != nil {
+		return
+	}
+	idleAge, err := durationQuery(c, "max_idle_age", h.cfg.Sessions.MaxIdleInTransactionAge)
+	if err != nil {
+		return
+	}
+
+	sessions, err := queryProblematicSessions(c.Request.Context(), h.pool, queryAge, idleAge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProblematicSessionsResponse{
+		Thresholds: models.SessionThresholds{
+			MaxQueryAgeSeconds:             queryAge.Seconds(),
+			MaxIdleInTransactionAgeSeconds: idleAge.Seconds(),
+		},
+		Sessions:  sessions,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// queryProblematicSessions lists the queries running for longer than
+// queryAge and the sessions idle in a transaction for longer than idleAge,
+// oldest first. The alerts monitor flags them too.
+func queryProblematicSessions(ctx context.Context, pool *db.Pool, queryAge, idleAge time.Duration) ([]models.ProblematicSession, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT
+			pid,
+			CASE WHEN state = 'active' THEN 'long_running_query' ELSE 'idle_in_transaction' END,
+			COALESCE(usename, ''),
+			COALESCE(application_name, ''),
+			client_addr::text,
+			COALESCE(datname, ''),
+			COALESCE(state, ''),
+			EXTRACT(EPOCH FROM now() - query_start)::float8,
+			EXTRACT(EPOCH FROM now() - xact_start)::float8,
+			EXTRACT(EPOCH FROM now() - state_change)::float8,
+			wait_event_type,
+			wait_event,
+			COALESCE(query, '')
+		FROM pg_stat_activity
+		WHERE pid <> pg_backend_pid()
+			AND backend_type = 'client backend'
+			AND (
+				(state = 'active' AND now() - query_start > make_interval(secs => $1))
+				OR (state LIKE 'idle in transaction%' AND now() - state_change > make_interval(secs => $2))
+			)
+		ORDER BY LEAST(query_start, state_change)
+	`, queryAge.Seconds(), idleAge.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.ProblematicSession{}
+	for rows.Next() {
+		var s models.ProblematicSession
+		if err := rows.Scan(
+			&s.PID, &s.Reason, &s.User, &s.Application, &s.ClientAddr, &s.Database, &s.State,
+			&s.QueryAgeSeconds, &s.XactAgeSeconds, &s.StateAgeSeconds,
+			&s.WaitEventType, &s.WaitEvent, &s.Query,
+		); err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// conninfoPassword matches the password keyword of a libpq connection string.
+var conninfoPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// sanitizeConninfo masks passwords in a libpq connection string.
+func sanitizeConninfo(conninfo string) string {
+	return conninfoPassword.ReplaceAllString(conninfo, "${1}********")
+}
+
+// SettingsHandler handles server settings endpoints.
+type SettingsHandler struct {
+	pool *db.Pool
+}
+
+// NewSettingsHandler creates a new settings handler.
+func NewSettingsHandler(pool *db.Pool) *SettingsHandler {
+	return &SettingsHandler{pool: pool}
+}
+
+// Settings handles GET /settings - server settings from pg_settings.
+//
+// Filters: ?category= (case-insensitive substring), ?name= (substring),
+// ?non_default=true (value differs from the built-in default) and
+// ?pending_restart=true.
+func (h *SettingsHandler) Settings(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	rows, err := h.pool.Query(c.Request.Context(), `
+		SELECT
+			name, COALESCE(setting, ''), unit, category, short_desc, context, vartype, source,
+			boot_val, reset_val,
+			setting IS DISTINCT FROM boot_val,
+			pending_restart
+		FROM pg_settings
+		WHERE ($1 = '' OR category ILIKE '%' || $1 || '%')
+			AND ($2 = '' OR name ILIKE '%' || $2 || '%')
+			AND (NOT $3 OR setting IS DISTINCT FROM boot_val)
+			AND (NOT $4 OR pending_restart)
+		ORDER BY category, name
+	`,
+		c.Query("category"),
+		c.Query("name"),
+		c.DefaultQuery("non_default", "false") == "true",
+		c.DefaultQuery("pending_restart", "false") == "true",
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query settings",
+		})
+		return
+	}
+	defer rows.Close()
+
+	response := models.SettingsResponse{Settings: []models.Setting{}}
+	for rows.Next() {
+		var s models.Setting
+		if err := rows.Scan(
+			&s.Name, &s.Setting, &s.Unit, &s.Category, &s.Description, &s.Context, &s.Type, &s.Source,
+			&s.BootValue, &s.ResetValue,
+			&s.NonDefault,
+			&s.PendingRestart,
+		); err != nil {
+			continue
+		}
+
+		if s.Name == "primary_conninfo" {
+			s.Setting = sanitizeConninfo(s.Setting)
+			s.BootValue = nil
+			s.ResetValue = nil
+		}
+		if s.NonDefault {
+			response.NonDefaultCount++
+		}
+		if s.PendingRestart {
+			response.PendingRestartCount++
+		}
+		response.Settings = append(response.Settings, s)
+	}
+
+	response.Total = len(response.Settings)
+	response.Timestamp = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/heartbeat"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+// heartbeatWriteQuery bumps the single heartbeat row. written_at comes
+// from the API's clock, which also times the reads, so clock skew between
+// nodes does not distort the lag.
+const heartbeatWriteQuery = `
+	INSERT INTO ha_heartbeat (id, seq, written_at) VALUES (1, 1, $1)
+	ON CONFLICT (id) DO UPDATE SET seq = ha_heartbeat.seq + 1, written_at = EXCLUDED.written_at
+	RETURNING seq
+`
+
+// SLOHandler writes heartbeats on the primary, reads them back from the
+// standbys and serves the achieved RPO and RTO.
+type SLOHandler struct {
+	cfg     *config.Config
+	cluster *db.Cluster
+	tracker *heartbeat.Tracker
+	workers *workers.Registry
+	worker  *workers.Worker
+}
+
+// NewSLOHandler creates a new SLO handler.
+func NewSLOHandler(cfg *config.Config, cluster *db.Cluster) *SLOHandler {
+	return &SLOHandler{
+		cfg:     cfg,
+		cluster: cluster,
+		tracker: heartbeat.NewTracker(),
+	}
+}
+
+// Tracker returns the heartbeat tracker.
+func (h *SLOHandler) Tracker() *heartbeat.Tracker {
+	return h.tracker
+}
+
+// UseWorkers records the heartbeats as runs of the heartbeat worker in
+// registry. Call it before Start.
+func (h *SLOHandler) UseWorkers(registry *workers.Registry) {
+	h.workers = registry
+}
+
+// Start writes and checks a heartbeat on the configured interval until
+// ctx is done. Nothing runs unless SLO_HEARTBEAT is enabled.
+func (h *SLOHandler) Start(ctx context.Context) {
+	if !h.cfg.SLO.Heartbeat {
+		return
+	}
+	h.worker = h.workers.Register(WorkerHeartbeat, h.cfg.SLO.Interval)
+	go func() {
+		ticker := time.NewTicker(h.cfg.SLO.Interval)
+		defer ticker.Stop()
+
+		for {
+			h.beat(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// beat writes one heartbeat and reads it back from every standby. A
+// failed write is recorded as a failed run of the worker.
+func (h *SLOHandler) beat(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, h.cfg.SLO.Interval)
+	defer cancel()
+
+	now := time.Now().UTC()
+	seq, err := h.write(ctx, now)
+	if parent.Err() != nil {
+		// Shutting down; the failure says nothing about the primary
+		return
+	}
+	defer h.worker.Record(now, err)
+	if err != nil {
+		h.tracker.WriteFailed(err, now)
+	} else {
+		h.tracker.Written(seq, now)
+	}
+
+	var wg sync.WaitGroup
+	for _, replica := range h.cluster.Replicas() {
+		host, port := replica.Addr()
+		wg.Add(1)
+		go func(name string, replica *db.Pool) {
+			defer wg.Done()
+			h.read(ctx, name, replica)
+		}(net.JoinHostPort(host, strconv.Itoa(port)), replica)
+	}
+	if h.cfg.DR.Host != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.readDR(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// write bumps the heartbeat row.
+func (h *SLOHandler) write(ctx context.Context, now time.Time) (int64, error) {
+	pool, err := h.cluster.Writer()
+	if err != nil {
+		return 0, err
+	}
+
+	var seq int64
+	err = pool.QueryRow(ctx, heartbeatWriteQuery, now).Scan(&seq)
+	return seq, err
+}
+
+// read records the heartbeat visible on one standby.
+func (h *SLOHandler) read(ctx context.Context, name string, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) {
+	var seq int64
+	var writtenAt time.Time
+	err := q.QueryRow(ctx, "SELECT seq, written_at FROM ha_heartbeat WHERE id = 1").Scan(&seq, &writtenAt)
+	h.tracker.Observed(name, seq, writtenAt, time.Now().UTC(), err)
+}
+
+// readDR records the heartbeat visible on the DR standby.
+func (h *SLOHandler) readDR(ctx context.Context) {
+	name := "dr:" + h.cfg.DR.Site
+	host, port, err := h.cfg.Database.ParseHostPort(h.cfg.DR.Host)
+	if err != nil {
+		h.tracker.Observed(name, 0, time.Time{}, time.Now().UTC(), err)
+		return
+	}
+	conn, err := pgx.Connect(ctx, h.cfg.Database.DSNForHost(host, port))
+	if err != nil {
+		h.tracker.Observed(name, 0, time.Time{}, time.Now().UTC(), err)
+		return
+	}
+	defer conn.Close(context.Background())
+	h.read(ctx, name, conn)
+}
+
+// SLO handles GET /slo - RPO measured as the committed time the furthest
+// behind standby is missing, and RTO as the length of the current or last
+// period without a writable primary, against SLO_RPO_TARGET and
+// SLO_RTO_TARGET.
+func (h *SLOHandler) SLO(c *gin.Context) {
+	if !h.cfg.SLO.Heartbeat {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "not_configured",
+			Message: "Set SLO_HEARTBEAT=true to measure RPO and RTO",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	snap := h.tracker.Snapshot()
+	response := models.SLOResponse{
+		Heartbeat: models.HeartbeatStatus{
+			IntervalSeconds: h.cfg.SLO.Interval.Seconds(),
+			Seq:             snap.Seq,
+			LastWrite:       snap.LastWrite,
+			Error:           snap.WriteError,
+		},
+		RPO: models.RPOStatus{
+			TargetSeconds: h.cfg.SLO.RPOTarget.Seconds(),
+			Targets:       make([]models.HeartbeatTarget, 0, len(snap.Targets)),
+		},
+		RTO: models.RTOStatus{
+			TargetSeconds: h.cfg.SLO.RTOTarget.Seconds(),
+			Outages:       snap.Outages,
+		},
+		Timestamp: now,
+	}
+
+	for _, o := range snap.Targets {
+		target := models.HeartbeatTarget{
+			Name:       o.Name,
+			Seq:        o.Seq,
+			CheckedAt:  o.CheckedAt,
+			LagSeconds: o.Lag.Seconds(),
+			Error:      o.Error,
+		}
+		if o.Seq > 0 {
+			writtenAt := o.WrittenAt
+			target.WrittenAt = &writtenAt
+			if response.RPO.Seconds == nil || target.LagSeconds > *response.RPO.Seconds {
+				lag := target.LagSeconds
+				response.RPO.Seconds = &lag
+			}
+		}
+		response.RPO.Targets = append(response.RPO.Targets, target)
+	}
+
+	if snap.LastOutage != nil {
+		end := snap.LastOutage.End
+		response.RTO.LastOutage = &models.Outage{
+			StartedAt: snap.LastOutage.Start,
+			EndedAt:   &end,
+			Seconds:   end.Sub(snap.LastOutage.Start).Seconds(),
+		}
+	}
+	switch {
+	case snap.OutageStart != nil:
+		response.RTO.CurrentOutage = &models.Outage{
+			StartedAt: *snap.OutageStart,
+			Seconds:   now.Sub(*snap.OutageStart).Seconds(),
+		}
+		response.RTO.Seconds = &response.RTO.CurrentOutage.Seconds
+	case response.RTO.LastOutage != nil:
+		response.RTO.Seconds = &response.RTO.LastOutage.Seconds
+	case snap.LastWrite != nil:
+		zero := 0.0
+		response.RTO.Seconds = &zero
+	}
+
+	response.RPO.Met = sloMet(response.RPO.Seconds, h.cfg.SLO.RPOTarget)
+	response.RTO.Met = sloMet(response.RTO.Seconds, h.cfg.SLO.RTOTarget)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func sloMet(seconds *float64, target time.Duration) *bool {
+	if seconds == nil || target <= 0 {
+		return nil
+	}
+	met := *seconds <= target.Seconds()
+	return &met
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// StartupHandler handles the startup probe endpoint.
+type StartupHandler struct {
+	tracker *lifecycle.Tracker
+}
+
+// NewStartupHandler creates a new startup handler.
+func NewStartupHandler(tracker *lifecycle.Tracker) *StartupHandler {
+	return &StartupHandler{tracker: tracker}
+}
+
+// Startup handles GET /startup - initialization progress.
+//
+// Returns 200 once every phase is complete and 503 while booting or after
+// a phase failed, so a startupProbe can tell the two apart from the body.
+func (h *StartupHandler) Startup(c *gin.Context) {
+	status, phases := h.tracker.Snapshot()
+
+	response := models.StartupResponse{
+		Status:    status,
+		Phases:    make([]models.StartupPhase, 0, len(phases)),
+		StartedAt: h.tracker.StartedAt(),
+		Timestamp: time.Now().UTC(),
+	}
+	for _, p := range phases {
+		response.Phases = append(response.Phases, models.StartupPhase{
+			Name:        p.Name,
+			State:       p.State,
+			Error:       p.Error,
+			CompletedAt: p.CompletedAt,
+		})
+	}
+
+	if status != lifecycle.StatusStarted {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/alerts"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// SummaryHandler serves a compact status document for NOC wall boards.
+// The document is rebuilt in the background so polling it never touches
+// the database.
+type SummaryHandler struct {
+	cfg          *config.Config
+	cluster      *db.Cluster
+	health       *HealthHandler
+	backupSource *BackupsHandler
+	alerts       *AlertsHandler
+
+	mu      sync.RWMutex
+	summary *models.SummaryResponse
+	backups *models.SummaryBackups
+}
+
+// NewSummaryHandler creates a new summary handler. Backup status is read
+// through the backups handler's cache.
+func NewSummaryHandler(cfg *config.Config, cluster *db.Cluster, backups *BackupsHandler) *SummaryHandler {
+	return &SummaryHandler{
+		cfg:          cfg,
+		cluster:      cluster,
+		health:       NewHealthHandler(cfg, cluster.Primary(), backups),
+		backupSource: backups,
+	}
+}
+
+// UseAlerts adds the alerts active in handler to the summary.
+func (h *SummaryHandler) UseAlerts(handler *AlertsHandler) {
+	h.alerts = handler
+}
+
+// Start refreshes the summary immediately and then on the configured
+// intervals until ctx is done.
+func (h *SummaryHandler) Start(ctx context.Context) {
+	go func() {
+		h.refreshBackups(ctx)
+		h.refresh(ctx)
+
+		ticker := time.NewTicker(h.cfg.Summary.RefreshInterval)
+		defer ticker.Stop()
+		backupTicker := time.NewTicker(h.cfg.Summary.BackupRefreshInterval)
+		defer backupTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-backupTicker.C:
+				h.refreshBackups(ctx)
+			case <-ticker.C:
+				h.refresh(ctx)
+			}
+		}
+	}()
+}
+
+// refreshBackups updates the cached backup section from the backups
+// handler, which only runs pgbackrest when its own cache is stale.
+func (h *SummaryHandler) refreshBackups(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Backup.CommandTimeout)
+	defer cancel()
+
+	status := h.backupSource.Status(ctx, h.backupSource.DefaultTarget())
+
+	h.mu.Lock()
+	h.backups = &models.SummaryBackups{
+		Status:         status.Status,
+		Count:          len(status.Backups),
+		LastFullBackup: status.LastFullBackup,
+		LastDiffBackup: status.LastDiffBackup,
+		LastIncrBackup: status.LastIncrBackup,
+		RefreshedAt:    status.Timestamp,
+	}
+	h.mu.Unlock()
+}
+
+// refresh rebuilds the summary from the database and cluster state.
+func (h *SummaryHandler) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Summary.RefreshInterval)
+	defer cancel()
+
+	database := h.health.checkDatabase(ctx)
+	replication := h.health.checkReplication(ctx)
+	disk := h.health.checkDisk(ctx)
+	clusterStatus := h.cluster.Status()
+
+	h.mu.RLock()
+	backups := h.backups
+	h.mu.RUnlock()
+
+	components := map[string]string{
+		"database":    database.Status,
+		"replication": replication.Status,
+		"disk":        disk.Status,
+	}
+	if backups != nil {
+		components["backups"] = backupComponentStatus(backups.Status)
+	}
+
+	status := models.ComponentHealthy
+	for _, s := range components {
+		switch s {
+		case models.ComponentUnhealthy:
+			status = models.ComponentUnhealthy
+		case models.ComponentDegraded:
+			if status != models.ComponentUnhealthy {
+				status = models.ComponentDegraded
+			}
+		}
+	}
+
+	summary := &models.SummaryResponse{
+		Status:     status,
+		Version:    h.cfg.App.Version,
+		Components: components,
+		Cluster: models.SummaryCluster{
+			PrimaryAvailable:  clusterStatus.PrimaryUp,
+			PrimaryDownSince:  clusterStatus.PrimaryDownSince,
+			ReplicasTotal:     clusterStatus.ReplicasTotal,
+			ReplicasAvailable: clusterStatus.ReplicasAvailable,
+		},
+		Replication: replication.Details,
+		Backups:     backups,
+		RefreshedAt: time.Now().UTC(),
+	}
+	if h.alerts != nil {
+		summary.Alerts = h.summaryAlerts()
+	}
+
+	h.mu.Lock()
+	h.summary = summary
+	h.mu.Unlock()
+}
+
+// summaryAlerts lists the alerts firing as of the last evaluation.
+func (h *SummaryHandler) summaryAlerts() *models.SummaryAlerts {
+	active := h.alerts.tracker.Active()
+	out := &models.SummaryAlerts{
+		Count:  len(active),
+		Active: make([]models.SummaryAlert, 0, len(active)),
+	}
+	for _, a := range active {
+		if a.Severity == alerts.SeverityCritical {
+			out.Critical++
+		}
+		out.Active = append(out.Active, models.SummaryAlert{
+			Rule:     a.Rule,
+			Severity: a.Severity,
+			Since:    a.Since,
+		})
+	}
+	return out
+}
+
+// Summary handles GET /summary - cached aggregate status.
+func (h *SummaryHandler) Summary(c *gin.Context) {
+	h.mu.RLock()
+	summary := h.summary
+	h.mu.RUnlock()
+
+	if summary == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "not_ready",
+			Message: "Summary has not been computed yet",
+		})
+		return
+	}
+
+	response := *summary
+	response.AgeSeconds = time.Since(summary.RefreshedAt).Seconds()
+	c.JSON(http.StatusOK, response)
+}
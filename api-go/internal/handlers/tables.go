@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// tableSortColumns maps the accepted ?sort= values to ORDER BY clauses.
+var tableSortColumns = map[string]string{
+	"size":         "total_bytes DESC",
+	"dead_tuples":  "n_dead_tup DESC",
+	"dead_ratio":   "dead_ratio DESC",
+	"seq_scans":    "seq_scan DESC",
+	"last_vacuum":  "GREATEST(last_vacuum, last_autovacuum) ASC NULLS FIRST",
+	"last_analyze": "GREATEST(last_analyze, last_autoanalyze) ASC NULLS FIRST",
+	"name":         "schemaname, relname",
+}
+
+// TablesHandler handles table statistics endpoints.
+type TablesHandler struct {
+	pool *db.Pool
+}
+
+// NewTablesHandler creates a new tables handler.
+func NewTablesHandler(pool *db.Pool) *TablesHandler {
+	return &TablesHandler{pool: pool}
+}
+
+// Tables handles GET /tables - per-table size and maintenance statistics.
+//
+// The bloat estimate is the table size scaled by the dead tuple ratio; it
+// is cheap to compute but only approximates what pgstattuple would report.
+func (h *TablesHandler) Tables(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+
+	sort := c.DefaultQuery("sort", "size")
+	orderBy, ok := tableSortColumns[sort]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: "sort must be one of: size, dead_tuples, dead_ratio, seq_scans, last_vacuum, last_analyze, name",
+		})
+		return
+	}
+
+	limit := limitQuery(c, 50, 1000)
+
+	rows, err := h.pool.Query(c.Request.Context(), `
+		SELECT
+			schemaname, relname,
+			total_bytes, table_bytes, total_bytes - table_bytes,
+			n_live_tup, n_dead_tup, dead_ratio,
+			(table_bytes * dead_ratio)::bigint,
+			seq_scan, idx_scan,
+			last_vacuum, last_autovacuum, last_analyze, last_autoanalyze
+		FROM (
+			SELECT
+				s.*,
+				pg_total_relation_size(s.relid) AS total_bytes,
+				pg_relation_size(s.relid) AS table_bytes,
+				CASE WHEN s.n_live_tup + s.n_dead_tup > 0
+					THEN s.n_dead_tup::float8 / (s.n_live_tup + s.n_dead_tup)
+					ELSE 0
+				END AS dead_ratio
+			FROM pg_stat_user_tables s
+		) t
+		ORDER BY `+orderBy+`
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query table statistics",
+		})
+		return
+	}
+	defer rows.Close()
+
+	tables := []models.TableStats{}
+	for rows.Next() {
+		var t models.TableStats
+		if err := rows.Scan(
+			&t.Schema, &t.Name,
+			&t.TotalBytes, &t.TableBytes, &t.IndexBytes,
+			&t.LiveTuples, &t.DeadTuples, &t.DeadTupleRatio,
+			&t.EstimatedBloatBytes,
+			&t.SeqScans, &t.IndexScans,
+			&t.LastVacuum, &t.LastAutovacuum, &t.LastAnalyze, &t.LastAutoanalyze,
+		); err != nil {
+			continue
+		}
+		tables = append(tables, t)
+	}
+
+	c.JSON(http.StatusOK, models.TablesResponse{
+		Sort:      sort,
+		Limit:     limit,
+		Tables:    tables,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/topology"
+)
+
+// maxTopologyNodes bounds how many nodes one discovery probes.
+const maxTopologyNodes = 50
+
+// replicationQuery lists the standbys streaming from a node with their
+// lag behind it.
+const replicationQuery = `
+	SELECT COALESCE(application_name, ''),
+		COALESCE(host(client_addr), ''),
+		COALESCE(state, ''),
+		COALESCE(sync_state, ''),
+		pg_wal_lsn_diff(
+			CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+			replay_lsn)::bigint,
+		EXTRACT(EPOCH FROM replay_lag)::float8
+	FROM pg_stat_replication
+	ORDER BY application_name
+`
+
+// TopologyHandler discovers the replication topology of the cluster.
+type TopologyHandler struct {
+	cfg *config.Config
+}
+
+// NewTopologyHandler creates a new topology handler. Nodes are probed with
+// the API's database credentials.
+func NewTopologyHandler(cfg *config.Config) *TopologyHandler {
+	return &TopologyHandler{cfg: cfg}
+}
+
+// Topology handles GET /topology - the primary, its sync and async
+// standbys and cascading replicas, discovered by following replication
+// connections from DB_HOST and DB_REPLICA_HOSTS.
+func (h *TopologyHandler) Topology(c *gin.Context) {
+	graph := h.Discover(c.Request.Context())
+
+	response := models.TopologyResponse{
+		Nodes:     make([]models.TopologyNode, 0, len(graph.Nodes)),
+		Edges:     make([]models.TopologyEdge, 0, len(graph.Edges)),
+		Warnings:  graph.Warnings,
+		Timestamp: time.Now().UTC(),
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+	if graph.Primary != "" {
+		response.Primary = &graph.Primary
+	}
+	for _, n := range graph.Nodes {
+		response.Nodes = append(response.Nodes, models.TopologyNode{
+			ID:               n.ID,
+			Role:             n.Role,
+			Reachable:        n.Reachable,
+			Error:            n.Error,
+			SystemIdentifier: n.SystemIdentifier,
+			Timeline:         n.Timeline,
+			Upstream:         n.Upstream,
+			UpstreamStatus:   n.UpstreamStatus,
+		})
+	}
+	for _, e := range graph.Edges {
+		response.Edges = append(response.Edges, models.TopologyEdge{
+			From:             e.From,
+			To:               e.To,
+			ApplicationName:  e.ApplicationName,
+			State:            e.State,
+			SyncState:        e.SyncState,
+			LagBytes:         e.LagBytes,
+			ReplayLagSeconds: e.ReplayLagSeconds,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Graph handles GET /topology/graph - the discovered topology for
+// rendering, as nodes and edges with labels or, with ?format=dot or
+// Accept: text/vnd.graphviz, in the Graphviz DOT language.
+func (h *TopologyHandler) Graph(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(c.GetHeader("Accept"), "text/vnd.graphviz") {
+			format = "dot"
+		}
+	}
+	if format != "json" && format != "dot" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_format",
+			Message: "format must be one of: json, dot",
+		})
+		return
+	}
+
+	graph := h.Discover(c.Request.Context())
+	if format == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+		return
+	}
+
+	response := models.TopologyGraphResponse{
+		Nodes:     make([]models.TopologyGraphNode, 0, len(graph.Nodes)),
+		Edges:     make([]models.TopologyGraphEdge, 0, len(graph.Edges)),
+		Warnings:  graph.Warnings,
+		Timestamp: time.Now().UTC(),
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+	if graph.Primary != "" {
+		response.Primary = &graph.Primary
+	}
+	for _, n := range graph.Nodes {
+		response.Nodes = append(response.Nodes, models.TopologyGraphNode{
+			ID:        n.ID,
+			Label:     n.Label(),
+			Role:      n.Role,
+			Reachable: n.Reachable,
+			Timeline:  n.Timeline,
+		})
+	}
+	for _, e := range graph.Edges {
+		response.Edges = append(response.Edges, models.TopologyGraphEdge{
+			ID:          e.From + "->" + e.To,
+			Source:      e.From,
+			Target:      e.To,
+			Label:       e.Label(),
+			State:       e.State,
+			Synchronous: e.Synchronous(),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Discover walks the replication graph from the configured nodes.
+func (h *TopologyHandler) Discover(ctx context.Context) *topology.Graph {
+	seeds := []topology.Address{{Host: h.cfg.Database.Host, Port: h.cfg.Database.Port}}
+	for _, entry := range h.cfg.Database.ReplicaHosts {
+		if host, port, err := h.cfg.Database.ParseHostPort(entry); err == nil {
+			seeds = append(seeds, topology.Address{Host: host, Port: port})
+		}
+	}
+	return topology.Discover(ctx, seeds, h.cfg.Database.Port, maxTopologyNodes, h.probeNode)
+}
+
+// LocatePrimary discovers the topology and returns the address of its
+// only primary, for retargeting the write pool after a failover.
+func (h *TopologyHandler) LocatePrimary(ctx context.Context) (string, int, error) {
+	graph := h.Discover(ctx)
+	if graph.Primary == "" {
+		return "", 0, errors.New(strings.Join(graph.Warnings, "; "))
+	}
+	host, port, err := net.SplitHostPort(graph.Primary)
+	if err != nil {
+		return "", 0, err
+	}
+	n, err := strconv.Atoi(port)
+	return host, n, err
+}
+
+// probeNode connects to one node and reads its role, its upstream from
+// pg_stat_wal_receiver and its downstreams from pg_stat_replication.
+func (h *TopologyHandler) probeNode(ctx context.Context, addr topology.Address) (topology.Report, error) {
+	var report topology.Report
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Database.ConnectTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, h.cfg.Database.DSNForHost(addr.Host, addr.Port))
+	if err != nil {
+		return report, err
+	}
+	defer conn.Close(context.Background())
+
+	if err := conn.QueryRow(ctx, nodeStateQuery).Scan(&report.InRecovery, &report.SystemIdentifier, &report.Timeline); err != nil {
+		return report, err
+	}
+	if err := conn.QueryRow(ctx, "SELECT COALESCE(host(inet_server_addr()), '')").Scan(&report.ServerAddr); err != nil {
+		return report, err
+	}
+
+	var senderHost *string
+	var senderPort *int
+	err = conn.QueryRow(ctx, "SELECT sender_host, sender_port, status FROM pg_stat_wal_receiver").
+		Scan(&senderHost, &senderPort, &report.UpstreamStatus)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return report, err
+	case senderHost != nil && senderPort != nil && !strings.HasPrefix(*senderHost, "/"):
+		report.Upstream = &topology.Address{Host: *senderHost, Port: *senderPort}
+	}
+
+	rows, err := conn.Query(ctx, replicationQuery)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d topology.Downstream
+		if err := rows.Scan(&d.ApplicationName, &d.ClientAddr, &d.State, &d.SyncState, &d.LagBytes, &d.ReplayLagSeconds); err != nil {
+			return report, err
+		}
+		report.Downstreams = append(report.Downstreams, d)
+	}
+	return report, rows.Err()
+}
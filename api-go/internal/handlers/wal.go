@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+// WALHandler handles WAL archiving endpoints.
+type WALHandler struct {
+	pool *db.Pool
+}
+
+// NewWALHandler creates a new WAL handler.
+func NewWALHandler(pool *db.Pool) *WALHandler {
+	return &WALHandler{pool: pool}
+}
+
+// Archiver handles GET /wal/archiver - WAL archiver status and lag.
+func (h *WALHandler) Archiver(c *gin.Context) {
+	if !requirePool(c, h.pool) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var response models.WALArchiverResponse
+	err := h.pool.QueryRow(ctx, `
+		SELECT
+			archived_count, last_archived_wal, last_archived_time,
+			failed_count, last_failed_wal, last_failed_time, stats_reset,
+			current_setting('archive_mode'), pg_is_in_recovery()
+		FROM pg_stat_archiver
+	`).Scan(
+		&response.ArchivedCount, &response.LastArchivedWAL, &response.LastArchivedTime,
+		&response.FailedCount, &response.LastFailedWAL, &response.LastFailedTime, &response.StatsReset,
+		&response.ArchiveMode, &response.IsInRecovery,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "database_error",
+			Message: "Failed to query pg_stat_archiver",
+		})
+		return
+	}
+
+	// The current WAL position is only available on the primary
+	if !response.IsInRecovery {
+		currentLSN, currentFile, segmentSize, err := currentWAL(ctx, h.pool)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "database_error",
+				Message: "Failed to get current WAL position",
+			})
+			return
+		}
+
+		response.CurrentLSN = &currentLSN
+		response.CurrentWALFile = &currentFile
+
+		if response.LastArchivedWAL != nil {
+			files, bytes, err := archiveLag(*response.LastArchivedWAL, currentFile, currentLSN, segmentSize)
+			if err == nil {
+				response.ArchiveLagFiles = &files
+				response.ArchiveLagBytes = &bytes
+			}
+		}
+	}
+
+	response.Timestamp = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
+
+// currentWAL returns the current WAL insert location, the segment file it
+// falls in and the server's wal_segment_size. It fails on a standby.
+func currentWAL(ctx context.Context, pool *db.Pool) (lsn, file string, segmentSize int64, err error) {
+	err = pool.QueryRow(ctx, `
+		SELECT
+			pg_current_wal_lsn()::text,
+			pg_walfile_name(pg_current_wal_lsn()),
+			(SELECT setting::bigint FROM pg_settings WHERE name = 'wal_segment_size')
+	`).Scan(&lsn, &file, &segmentSize)
+	return lsn, file, segmentSize, err
+}
+
+// archiveLag computes how many segments and bytes of WAL have been written
+// since the end of the last archived segment.
+func archiveLag(lastArchived, currentFile, currentLSN string, segmentSize int64) (files, bytes int64, err error) {
+	archived, err := wal.ParseFileName(lastArchived, segmentSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	current, err := wal.ParseFileName(currentFile, segmentSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	lsn, err := wal.ParseLSN(currentLSN)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if current.Number > archived.Number {
+		files = int64(current.Number - archived.Number)
+	}
+	if end := archived.EndLSN(segmentSize); lsn > end {
+		bytes = int64(lsn - end)
+	}
+	return files, bytes, nil
+}
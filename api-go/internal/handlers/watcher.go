@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/notify"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+// Event types published by the watcher.
+const (
+	EventWatchStarted            = "watch_started"
+	EventRoleChanged             = "role_changed"
+	EventTimelineChanged         = "timeline_changed"
+	EventSystemIdentifierChanged = "system_identifier_changed"
+	EventNodeUnreachable         = "node_unreachable"
+	EventNodeReachable           = "node_reachable"
+	EventPrimaryRetargeted       = "primary_retargeted"
+)
+
+// Node roles.
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// nodeStateQuery reads the role, system identifier and timeline. On a
+// primary the timeline comes from the current WAL file, which changes at
+// promotion rather than at the next checkpoint; on a replica it is the
+// timeline being received, or the last restartpoint's when not streaming.
+const nodeStateQuery = `
+	SELECT pg_is_in_recovery(),
+		(SELECT system_identifier::text FROM pg_control_system()),
+		CASE WHEN pg_is_in_recovery()
+			THEN COALESCE(
+				(SELECT received_tli FROM pg_stat_wal_receiver),
+				(SELECT timeline_id FROM pg_control_checkpoint()))
+			ELSE ('x' || substr(pg_walfile_name(pg_current_wal_lsn()), 1, 8))::bit(32)::int
+		END
+`
+
+// WatcherHandler polls the role and identity of the database node and
+// publishes an event whenever they change, so failovers are noticed even
+// when nothing else is watching.
+type WatcherHandler struct {
+	cfg        *config.Config
+	pool       *db.Pool
+	events     *events.Log
+	retargeter *db.Retargeter
+	workers    *workers.Registry
+	worker     *workers.Worker
+
+	mu          sync.RWMutex
+	current     *models.NodeState
+	unreachable bool
+}
+
+// NewWatcherHandler creates a new watcher handler.
+func NewWatcherHandler(cfg *config.Config, pool *db.Pool) *WatcherHandler {
+	return &WatcherHandler{
+		cfg:    cfg,
+		pool:   pool,
+		events: events.NewLog(cfg.Watcher.EventHistory),
+	}
+}
+
+// Events returns the event log, for other components to subscribe to.
+func (h *WatcherHandler) Events() *events.Log {
+	return h.events
+}
+
+// UseRetargeter has the watcher trigger r when the node is demoted or
+// becomes unreachable, and report where r moved the pool.
+func (h *WatcherHandler) UseRetargeter(r *db.Retargeter) {
+	h.mu.Lock()
+	h.retargeter = r
+	h.mu.Unlock()
+	r.OnRetarget(h.retargeted)
+}
+
+// UseDispatcher has d notify about the events published to the log,
+// including those other components publish there.
+func (h *WatcherHandler) UseDispatcher(d *notify.Dispatcher) {
+	h.events.OnPublish(func(e events.Event) {
+		d.Dispatch(e.Type, e.Message, e.Data, e.Timestamp)
+	})
+}
+
+// retargeted records that the pool now points at another node. The next
+// check starts watching it afresh rather than reporting a promotion.
+func (h *WatcherHandler) retargeted(from, to, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.current = nil
+	h.unreachable = false
+	h.publish(EventPrimaryRetargeted, fmt.Sprintf("Write pool moved from %s to %s", from, to),
+		map[string]interface{}{"from": from, "to": to, "reason": reason})
+}
+
+// UseWorkers records the checks as runs of the watcher worker in
+// registry. Call it before Start.
+func (h *WatcherHandler) UseWorkers(registry *workers.Registry) {
+	h.workers = registry
+}
+
+// Current returns the last observed node state, or nil before the first
+// successful check.
+func (h *WatcherHandler) Current() *models.NodeState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.current
+}
+
+// Start checks the node immediately and then on the configured interval
+// until ctx is done. Without a pool there is nothing to watch.
+func (h *WatcherHandler) Start(ctx context.Context) {
+	if h.pool == nil {
+		return
+	}
+	h.worker = h.workers.Register(WorkerWatcher, h.cfg.Watcher.Interval)
+	go func() {
+		h.check(ctx)
+
+		ticker := time.NewTicker(h.cfg.Watcher.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.check(ctx)
+			}
+		}
+	}()
+}
+
+// check queries the node once and records the result.
+func (h *WatcherHandler) check(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, h.cfg.Watcher.Interval)
+	defer cancel()
+
+	start := time.Now()
+	state, err := queryNodeState(checkCtx, h.pool)
+	if ctx.Err() != nil {
+		// Shutting down; the failure says nothing about the node
+		return
+	}
+	h.worker.Record(start, err)
+	h.Observe(state, err)
+}
+
+// queryNodeState reads the current role, system identifier and timeline.
+func queryNodeState(ctx context.Context, pool *db.Pool) (models.NodeState, error) {
+	state := models.NodeState{CheckedAt: time.Now().UTC()}
+	err := pool.QueryRow(ctx, nodeStateQuery).Scan(&state.InRecovery, &state.SystemIdentifier, &state.Timeline)
+	state.Role = RolePrimary
+	if state.InRecovery {
+		state.Role = RoleReplica
+	}
+	return state, err
+}
+
+// Observe records one check of the node and publishes events for what
+// changed since the previous one. err reports a failed check.
+func (h *WatcherHandler) Observe(state models.NodeState, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err != nil {
+		if !h.unreachable {
+			h.unreachable = true
+			h.publish(EventNodeUnreachable, "Database node is unreachable: "+err.Error(), nil)
+			h.triggerRetarget("the node is unreachable")
+		}
+		return
+	}
+	if h.unreachable {
+		h.unreachable = false
+		h.publish(EventNodeReachable, "Database node is reachable again as "+state.Role, stateData(state))
+	}
+
+	prev := h.current
+	h.current = &state
+	if prev == nil {
+		h.publish(EventWatchStarted, fmt.Sprintf("Watching %s on timeline %d", state.Role, state.Timeline), stateData(state))
+		return
+	}
+
+	if prev.SystemIdentifier != state.SystemIdentifier {
+		h.publish(EventSystemIdentifierChanged,
+			fmt.Sprintf("System identifier changed from %s to %s; the node was replaced or restored", prev.SystemIdentifier, state.SystemIdentifier),
+			map[string]interface{}{"from": prev.SystemIdentifier, "to": state.SystemIdentifier})
+	}
+	if prev.Role != state.Role {
+		message := "Node was promoted to primary"
+		if state.Role == RoleReplica {
+			message = "Node was demoted to replica"
+		}
+		h.publish(EventRoleChanged, message,
+			map[string]interface{}{"from": prev.Role, "to": state.Role, "timeline": state.Timeline})
+		if state.Role == RoleReplica {
+			h.triggerRetarget("the node was demoted")
+		}
+	}
+	if prev.Timeline != state.Timeline {
+		h.publish(EventTimelineChanged, fmt.Sprintf("Timeline switched from %d to %d", prev.Timeline, state.Timeline),
+			map[string]interface{}{"from": prev.Timeline, "to": state.Timeline})
+	}
+}
+
+// triggerRetarget asks the retargeter, if any, to look for the new
+// primary. The caller holds the lock.
+func (h *WatcherHandler) triggerRetarget(reason string) {
+	if h.retargeter != nil {
+		h.retargeter.Trigger(reason)
+	}
+}
+
+// publish logs and records an event. The caller holds the lock.
+func (h *WatcherHandler) publish(eventType, message string, data map[string]interface{}) {
+	slog.Info("Cluster event", "event", eventType, "message", message)
+	h.events.Publish(eventType, message, data)
+}
+
+func stateData(state models.NodeState) map[string]interface{} {
+	return map[string]interface{}{
+		"role":              state.Role,
+		"system_identifier": state.SystemIdentifier,
+		"timeline":          state.Timeline,
+	}
+}
+
+// List handles GET /events - retained cluster events, oldest first.
+//
+// Query parameters: type, after (event ID) and limit (newest N, default
+// 100, max 1000). With Accept: text/event-stream or ?follow=true the
+// events are streamed instead, resuming after Last-Event-ID.
+func (h *WatcherHandler) List(c *gin.Context) {
+	after, _ := strconv.ParseInt(c.Query("after"), 10, 64)
+	eventType := c.Query("type")
+
+	if wantsStream(c) {
+		if last, err := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64); err == nil {
+			after = last
+		}
+		h.stream(c, eventType, after)
+		return
+	}
+
+	list := filterEvents(h.events.Since(after), eventType)
+	if limit := limitQuery(c, 100, 1000); len(list) > limit {
+		list = list[len(list)-limit:]
+	}
+
+	response := models.EventsResponse{
+		Events:    make([]models.ClusterEvent, 0, len(list)),
+		Count:     len(list),
+		Current:   h.Current(),
+		Timestamp: time.Now().UTC(),
+	}
+	for _, e := range list {
+		response.Events = append(response.Events, eventResponse(e))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// stream sends events after the given ID as they are published, with a
+// keepalive comment every 15 seconds.
+func (h *WatcherHandler) stream(c *gin.Context, eventType string, after int64) {
+	clearWriteDeadline(c)
+	startStream(c)
+	c.Stream(func(w io.Writer) bool {
+		wait := h.events.Wait()
+		for _, e := range h.events.Since(after) {
+			after = e.ID
+			if eventType != "" && e.Type != eventType {
+				continue
+			}
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(e.ID, 10), Event: e.Type, Data: eventResponse(e)})
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-wait:
+			return true
+		case <-time.After(15 * time.Second):
+			io.WriteString(w, ": keepalive\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func filterEvents(list []events.Event, eventType string) []events.Event {
+	if eventType == "" {
+		return list
+	}
+	out := []events.Event{}
+	for _, e := range list {
+		if e.Type == eventType {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func eventResponse(e events.Event) models.ClusterEvent {
+	return models.ClusterEvent{
+		ID:        e.ID,
+		Type:      e.Type,
+		Message:   e.Message,
+		Data:      e.Data,
+		Timestamp: e.Timestamp,
+	}
+}
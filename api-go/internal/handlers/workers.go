@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Background worker names.
+const (
+	WorkerBackupScheduler = "backup_scheduler"
+	WorkerMetricsSampler  = "metrics_sampler"
+	WorkerWatcher         = "watcher"
+	WorkerHeartbeat       = "heartbeat"
+	WorkerAlerts          = "alerts"
+)
+
+// WorkersHandler serves the state of the background workers.
+type WorkersHandler struct {
+	registry *workers.Registry
+}
+
+// NewWorkersHandler creates a new workers handler.
+func NewWorkersHandler(registry *workers.Registry) *WorkersHandler {
+	return &WorkersHandler{registry: registry}
+}
+
+// List handles GET /admin/workers - when each background worker last
+// ran, how long it took, its last error and whether it stalled.
+func (h *WorkersHandler) List(c *gin.Context) {
+	now := time.Now().UTC()
+	statuses := h.registry.Status(now)
+
+	response := models.WorkersResponse{
+		Workers:   make([]models.WorkerStatus, 0, len(statuses)),
+		Count:     len(statuses),
+		Timestamp: now,
+	}
+	for _, s := range statuses {
+		response.Workers = append(response.Workers, models.WorkerStatus{
+			Name:                s.Name,
+			IntervalSeconds:     s.Interval.Seconds(),
+			LastRun:             s.LastRun,
+			LastDurationSeconds: s.LastDuration.Seconds(),
+			LastError:           s.LastError,
+			Runs:                s.Runs,
+			Failures:            s.Failures,
+			StalledAfter:        s.Due,
+			Stalled:             s.Stalled,
+		})
+		if s.Stalled {
+			response.Stalled++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+var (
+	workerLastRunDesc = prometheus.NewDesc("pgha_worker_last_run_timestamp_seconds",
+		"Start time of the last finished run of the background worker.",
+		[]string{"worker"}, nil)
+	workerDurationDesc = prometheus.NewDesc("pgha_worker_last_duration_seconds",
+		"Duration of the last finished run of the background worker.",
+		[]string{"worker"}, nil)
+	workerRunsDesc = prometheus.NewDesc("pgha_worker_runs_total",
+		"Runs finished by the background worker.",
+		[]string{"worker"}, nil)
+	workerFailuresDesc = prometheus.NewDesc("pgha_worker_failures_total",
+		"Runs of the background worker that failed.",
+		[]string{"worker"}, nil)
+	workerStalledDesc = prometheus.NewDesc("pgha_worker_stalled",
+		"1 when the background worker has not finished a run in time.",
+		[]string{"worker"}, nil)
+)
+
+// workerCollector exports the background workers as Prometheus metrics,
+// so a stall is noticed even when the alerts worker is the one stalled.
+type workerCollector struct {
+	registry *workers.Registry
+}
+
+// Collector returns a Prometheus collector for the background workers.
+func (h *WorkersHandler) Collector() prometheus.Collector {
+	return workerCollector{registry: h.registry}
+}
+
+// Describe implements prometheus.Collector.
+func (c workerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workerLastRunDesc
+	ch <- workerDurationDesc
+	ch <- workerRunsDesc
+	ch <- workerFailuresDesc
+	ch <- workerStalledDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c workerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.registry.Status(time.Now()) {
+		ch <- prometheus.MustNewConstMetric(workerRunsDesc, prometheus.CounterValue, float64(s.Runs), s.Name)
+		ch <- prometheus.MustNewConstMetric(workerFailuresDesc, prometheus.CounterValue, float64(s.Failures), s.Name)
+		stalled := 0.0
+		if s.Stalled {
+			stalled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(workerStalledDesc, prometheus.GaugeValue, stalled, s.Name)
+		if s.LastRun != nil {
+			ch <- prometheus.MustNewConstMetric(workerLastRunDesc, prometheus.GaugeValue,
+				float64(s.LastRun.UnixNano())/1e9, s.Name)
+			ch <- prometheus.MustNewConstMetric(workerDurationDesc, prometheus.GaugeValue,
+				s.LastDuration.Seconds(), s.Name)
+		}
+	}
+}
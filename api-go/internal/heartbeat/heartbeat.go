@@ -0,0 +1,129 @@
+// Package heartbeat measures achieved RPO and RTO from heartbeat rows
+// written on the primary and read back from the standbys.
+package heartbeat
+
+import (
+	"sync"
+	"time"
+)
+
+// Outage is a period during which heartbeats could not be written.
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Observation is the newest heartbeat seen on one standby. Lag is how
+// much committed time the standby is missing: zero when it has the latest
+// heartbeat, and otherwise the age of the one it has.
+type Observation struct {
+	Name      string
+	Seq       int64
+	WrittenAt time.Time
+	CheckedAt time.Time
+	Lag       time.Duration
+	Error     string
+}
+
+// Snapshot is the state of the tracker at one point in time.
+type Snapshot struct {
+	Seq         int64
+	LastWrite   *time.Time
+	WriteError  string
+	OutageStart *time.Time
+	LastOutage  *Outage
+	Outages     int
+	Targets     []Observation
+}
+
+// Tracker records heartbeat writes and reads. Write outages measure the
+// RTO; standby lag measures the RPO.
+type Tracker struct {
+	mu          sync.RWMutex
+	seq         int64
+	lastWrite   *time.Time
+	writeErr    string
+	outageStart *time.Time
+	lastOutage  *Outage
+	outages     int
+	targets     map[string]Observation
+	order       []string
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{targets: map[string]Observation{}}
+}
+
+// Written records a successful heartbeat write, ending any outage.
+func (t *Tracker) Written(seq int64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq = seq
+	t.lastWrite = &at
+	t.writeErr = ""
+	if t.outageStart != nil {
+		t.lastOutage = &Outage{Start: *t.outageStart, End: at}
+		t.outageStart = nil
+	}
+}
+
+// WriteFailed records a failed heartbeat write. The first failure after a
+// successful write starts an outage.
+func (t *Tracker) WriteFailed(err error, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.writeErr = err.Error()
+	if t.outageStart == nil {
+		t.outageStart = &at
+		t.outages++
+	}
+}
+
+// Observed records the heartbeat read from a standby at checkedAt, or the
+// error reading it.
+func (t *Tracker) Observed(name string, seq int64, writtenAt, checkedAt time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.targets[name]; !ok {
+		t.order = append(t.order, name)
+	}
+	o := Observation{Name: name, CheckedAt: checkedAt}
+	if err != nil {
+		o.Error = err.Error()
+		// Keep the last heartbeat seen so the lag keeps growing
+		if prev, ok := t.targets[name]; ok && prev.Seq > 0 {
+			o.Seq, o.WrittenAt = prev.Seq, prev.WrittenAt
+			o.Lag = checkedAt.Sub(prev.WrittenAt)
+		}
+	} else {
+		o.Seq, o.WrittenAt = seq, writtenAt
+		if seq < t.seq {
+			o.Lag = checkedAt.Sub(writtenAt)
+		}
+	}
+	t.targets[name] = o
+}
+
+// Snapshot returns the current state.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s := Snapshot{
+		Seq:         t.seq,
+		LastWrite:   t.lastWrite,
+		WriteError:  t.writeErr,
+		OutageStart: t.outageStart,
+		LastOutage:  t.lastOutage,
+		Outages:     t.outages,
+		Targets:     make([]Observation, 0, len(t.order)),
+	}
+	for _, name := range t.order {
+		s.Targets = append(s.Targets, t.targets[name])
+	}
+	return s
+}
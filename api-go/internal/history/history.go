@@ -0,0 +1,74 @@
+// Package history provides a fixed-size, concurrency-safe ring buffer for
+// keeping recent samples in memory.
+package history
+
+import (
+	"sync"
+)
+
+// Buffer keeps the most recent values up to a fixed capacity, discarding
+// the oldest when full.
+type Buffer[T any] struct {
+	mu    sync.RWMutex
+	items []T
+	start int
+	size  int
+}
+
+// NewBuffer creates a buffer holding at most capacity values.
+func NewBuffer[T any](capacity int) *Buffer[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Buffer[T]{items: make([]T, capacity)}
+}
+
+// Add appends a value, overwriting the oldest one when the buffer is full.
+func (b *Buffer[T]) Add(v T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	end := (b.start + b.size) % len(b.items)
+	b.items[end] = v
+	if b.size < len(b.items) {
+		b.size++
+	} else {
+		b.start = (b.start + 1) % len(b.items)
+	}
+}
+
+// Values returns the buffered values from oldest to newest.
+func (b *Buffer[T]) Values() []T {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := make([]T, b.size)
+	for i := 0; i < b.size; i++ {
+		out[i] = b.items[(b.start+i)%len(b.items)]
+	}
+	return out
+}
+
+// Filter returns the buffered values, oldest first, for which keep
+// returns true.
+func (b *Buffer[T]) Filter(keep func(T) bool) []T {
+	out := []T{}
+	for _, v := range b.Values() {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Len returns the number of buffered values.
+func (b *Buffer[T]) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.size
+}
+
+// Cap returns the buffer capacity.
+func (b *Buffer[T]) Cap() int {
+	return len(b.items)
+}
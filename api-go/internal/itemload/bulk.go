@@ -0,0 +1,148 @@
+// Package itemload reads items uploaded in bulk, as a JSON array, NDJSON
+// or CSV, and loads them in batches through a COPY function supplied by
+// the caller, so only one batch or row is held in memory at a time.
+package itemload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Columns are the columns items are loaded into, in the order of Row.
+var Columns = []string{"name", "description", "price", "is_active", "created_at", "updated_at"}
+
+// Row returns the values of item for Columns. Items are active unless
+// told otherwise and are created now.
+func Row(item models.ItemCreate) []interface{} {
+	isActive := true
+	if item.IsActive != nil {
+		isActive = *item.IsActive
+	}
+	now := time.Now().UTC()
+	return []interface{}{item.Name, item.Description, string(item.Price), isActive, now, now}
+}
+
+// IsNDJSON reports whether contentType announces one JSON value per line.
+func IsNDJSON(contentType string) bool {
+	return contentType == "application/x-ndjson" || contentType == "application/ndjson" || strings.HasSuffix(contentType, "+ndjson")
+}
+
+// Decoder reads the next item of a body, returning io.EOF after the last
+// one.
+type Decoder func(*models.ItemCreate) error
+
+// NewDecoder returns a decoder of the items of body: a JSON array, or
+// with ndjson consecutive JSON values, one per line. The opening bracket
+// of an array is read right away.
+func NewDecoder(body io.Reader, ndjson bool) (Decoder, error) {
+	dec := json.NewDecoder(body)
+	if ndjson {
+		return func(item *models.ItemCreate) error {
+			return dec.Decode(item)
+		}, nil
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("the body must be a JSON array of items, or NDJSON with Content-Type application/x-ndjson")
+	}
+	return func(item *models.ItemCreate) error {
+		if !dec.More() {
+			// More is also false when the body ends before the ]
+			if _, err := dec.Token(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			return io.EOF
+		}
+		return dec.Decode(item)
+	}, nil
+}
+
+// Bulk reads the items of next in batches of batchSize and loads every
+// batch with load, which returns how many rows it inserted. A batch
+// holding an invalid item is not loaded and the others still are. When
+// the body breaks off, the batch being read is dropped and the response
+// carries the error.
+func Bulk(next Decoder, batchSize int, load func(rows [][]interface{}) (int64, error)) models.ItemsBulkResponse {
+	response := models.ItemsBulkResponse{Batches: []models.ItemsBulkBatch{}}
+	rows := make([][]interface{}, 0, batchSize)
+	batch := models.ItemsBulkBatch{}
+	flush := func() {
+		if batch.Count == 0 {
+			return
+		}
+		if batch.Error == "" {
+			n, err := load(rows)
+			batch.Inserted = n
+			if err != nil {
+				batch.Error = "Failed to load batch: " + err.Error()
+			}
+		}
+		if batch.Error != "" {
+			response.Failed++
+		}
+		response.Inserted += batch.Inserted
+		response.Batches = append(response.Batches, batch)
+
+		rows = rows[:0]
+		batch = models.ItemsBulkBatch{Index: len(response.Batches), First: response.Received}
+	}
+
+	for {
+		var item models.ItemCreate
+		err := next(&item)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			response.Error = fmt.Sprintf("item %d: %v", response.Received, err)
+			break
+		}
+
+		// Rows of an invalid batch are still counted but not kept
+		if batch.Error == "" {
+			if err := binding.Validator.ValidateStruct(&item); err != nil {
+				batch.Error = fmt.Sprintf("item %d: %v", response.Received, err)
+			} else {
+				rows = append(rows, Row(item))
+			}
+		}
+		batch.Count++
+		response.Received++
+		if batch.Count == batchSize {
+			flush()
+		}
+	}
+	// A partial batch is loaded unless the body broke off in the middle
+	if response.Error == "" {
+		flush()
+	}
+	return response
+}
+
+// BulkStatus returns the status of a bulk load: 201 when every batch was
+// loaded, 207 when some failed and 400 when the body could not be read to
+// the end.
+func BulkStatus(response models.ItemsBulkResponse) int {
+	switch {
+	case response.Error != "":
+		return http.StatusBadRequest
+	case response.Failed > 0:
+		return http.StatusMultiStatus
+	}
+	return http.StatusCreated
+}
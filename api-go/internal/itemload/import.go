@@ -0,0 +1,223 @@
+package itemload
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// MaxRejections caps the rejected rows listed in an import report; all
+// of them are still counted.
+const MaxRejections = 1000
+
+// maxLine is the longest NDJSON line accepted.
+const maxLine = 1024 * 1024
+
+// ignoredColumns are the export columns an import accepts but does not
+// load: items get new IDs and timestamps.
+var ignoredColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// Rejected marks a row that is reported but not loaded.
+type Rejected struct {
+	Reason string
+}
+
+func (e *Rejected) Error() string {
+	return e.Reason
+}
+
+// ReadError is an error reading an upload, on the given line, after which
+// nothing is loaded.
+type ReadError struct {
+	Line int
+	Err  error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// Reader returns the next row of an upload and its line number, io.EOF
+// after the last one, or *Rejected for a row that cannot be read as an
+// item.
+type Reader func() (models.ItemCreate, int, error)
+
+// Format picks the format of an upload, csv or ndjson: format when set,
+// or else the one of contentType or of the extension of filename. It is
+// "" when none tells.
+func Format(format, contentType, filename string) string {
+	if format != "" {
+		return format
+	}
+	switch {
+	case contentType == "text/csv":
+		return "csv"
+	case IsNDJSON(contentType):
+		return "ndjson"
+	}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	}
+	return ""
+}
+
+// CSVReader reads the header of a CSV upload and returns a reader of its
+// rows. The header names the columns, as GET /items/export writes them;
+// id, created_at and updated_at are ignored.
+func CSVReader(body io.Reader) (Reader, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "name", "description", "price", "is_active":
+			columns[column] = i
+		default:
+			if !ignoredColumns[column] {
+				return nil, fmt.Errorf("unknown CSV column %q", column)
+			}
+		}
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the CSV header has no %s column", required)
+		}
+	}
+
+	line, _ := r.FieldPos(0)
+	return func() (models.ItemCreate, int, error) {
+		var item models.ItemCreate
+		record, err := r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line = parseErr.StartLine
+			return item, line, &Rejected{Reason: parseErr.Err.Error()}
+		}
+		if err != nil {
+			return item, line + 1, err
+		}
+		// FieldPos is only valid after a successful read
+		line, _ = r.FieldPos(0)
+
+		field := func(column string) (string, bool) {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return "", false
+			}
+			return record[i], true
+		}
+		item.Name, _ = field("name")
+		if description, ok := field("description"); ok && description != "" {
+			item.Description = &description
+		}
+		price, _ := field("price")
+		if item.Price, err = models.ParseDecimal(strings.TrimSpace(price)); err != nil {
+			return item, line, &Rejected{Reason: fmt.Sprintf("price %q is not a number", price)}
+		}
+		if value, ok := field("is_active"); ok && strings.TrimSpace(value) != "" {
+			isActive, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return item, line, &Rejected{Reason: fmt.Sprintf("is_active %q is not a boolean", value)}
+			}
+			item.IsActive = &isActive
+		}
+		return item, line, nil
+	}, nil
+}
+
+// NDJSONReader returns a reader of the items of an NDJSON upload, one per
+// line. Blank lines are skipped.
+func NDJSONReader(body io.Reader) Reader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	line := 0
+	return func() (models.ItemCreate, int, error) {
+		var item models.ItemCreate
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(text), &item); err != nil {
+				return item, line, &Rejected{Reason: err.Error()}
+			}
+			return item, line, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return item, line + 1, err
+		}
+		return item, line, io.EOF
+	}
+}
+
+// Import validates the rows of read like POST /items as copy pulls them,
+// in a single COPY, and reports the rejected rows by line with the
+// reason. copy returns how many rows it loaded. An error other than a
+// rejection aborts the COPY and is returned as a *ReadError.
+func Import(read Reader, copy func(pgx.CopyFromSource) (int64, error)) (models.ItemsImportResponse, error) {
+	response := models.ItemsImportResponse{Rejections: []models.ItemImportRejection{}}
+	reject := func(line int, reason string) {
+		response.Rejected++
+		if len(response.Rejections) < MaxRejections {
+			response.Rejections = append(response.Rejections, models.ItemImportRejection{Line: line, Reason: reason})
+		} else {
+			response.Truncated = true
+		}
+	}
+
+	var readErr error
+	imported, err := copy(pgx.CopyFromFunc(func() ([]any, error) {
+		for {
+			item, line, err := read()
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			var rejected *Rejected
+			if errors.As(err, &rejected) {
+				response.Received++
+				reject(line, rejected.Reason)
+				continue
+			}
+			if err != nil {
+				readErr = &ReadError{Line: line, Err: err}
+				return nil, readErr
+			}
+			response.Received++
+			if err := binding.Validator.ValidateStruct(&item); err != nil {
+				reject(line, err.Error())
+				continue
+			}
+			return Row(item), nil
+		}
+	}))
+	if readErr != nil {
+		return response, readErr
+	}
+	response.Imported = imported
+	return response, err
+}
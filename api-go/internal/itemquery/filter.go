@@ -0,0 +1,88 @@
+// Package itemquery builds the SQL of item listings from their query
+// parameters: filters, sort order and keyset pagination. Values are only
+// ever passed as numbered parameters.
+package itemquery
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// Filter builds the WHERE clause of an item listing. The zero value has
+// no conditions.
+type Filter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// ParseFilter reads the filters of GET /items: name (a case-insensitive
+// substring), price_min and price_max, and created_after and
+// created_before (RFC 3339). Bounds are inclusive. Items in the trash are
+// left out.
+func ParseFilter(query url.Values) (*Filter, error) {
+	f := &Filter{}
+	f.Where("deleted_at IS NULL")
+	if name := query.Get("name"); name != "" {
+		f.Where("name ILIKE " + f.Arg("%"+EscapeLike(name)+"%"))
+	}
+	for _, bound := range []struct{ param, op string }{
+		{"price_min", ">="},
+		{"price_max", "<="},
+	} {
+		if !query.Has(bound.param) {
+			continue
+		}
+		value := query.Get(bound.param)
+		price, err := models.ParseDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", bound.param, value)
+		}
+		f.Where("price " + bound.op + " " + f.Arg(price))
+	}
+	for _, bound := range []struct{ param, op string }{
+		{"created_after", ">="},
+		{"created_before", "<="},
+	} {
+		if !query.Has(bound.param) {
+			continue
+		}
+		value := query.Get(bound.param)
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 timestamp, got %q", bound.param, value)
+		}
+		f.Where("created_at " + bound.op + " " + f.Arg(t))
+	}
+	return f, nil
+}
+
+// Arg adds a parameter and returns its placeholder.
+func (f *Filter) Arg(value interface{}) string {
+	f.args = append(f.args, value)
+	return "$" + strconv.Itoa(len(f.args))
+}
+
+// Where adds a condition.
+func (f *Filter) Where(condition string) {
+	f.conditions = append(f.conditions, condition)
+}
+
+// Clause returns the WHERE clause, empty without conditions, and a copy of
+// its parameters.
+func (f *Filter) Clause() (string, []interface{}) {
+	args := append([]interface{}(nil), f.args...)
+	if len(f.conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(f.conditions, " AND "), args
+}
+
+// EscapeLike escapes the LIKE wildcards in s so it matches literally.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,79 @@
+package itemquery
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// PageMediaType is the Accept value asking GET /items for the paginated
+// envelope instead of a bare array.
+const PageMediaType = "application/vnd.items.page+json"
+
+// DefaultLimit and MaxLimit bound the items of one page.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// WantsPage reports whether an item listing answers with the paginated
+// envelope: always from /v1 on, and on the unversioned path only when the
+// client opted in with ?envelope=true or the Accept header.
+func WantsPage(version apiversion.Version, query url.Values, accept string) bool {
+	if version >= apiversion.V1 {
+		return true
+	}
+	return query.Get("envelope") == "true" || strings.Contains(accept, PageMediaType)
+}
+
+// ParsePaging reads ?skip= and ?limit= of an item listing. limit defaults
+// to DefaultLimit and is brought within 1 and MaxLimit; a negative skip
+// counts as none.
+func ParsePaging(query url.Values) (skip, limit int) {
+	skip, _ = strconv.Atoi(query.Get("skip"))
+	limit = DefaultLimit
+	if query.Has("limit") {
+		limit, _ = strconv.Atoi(query.Get("limit"))
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	if skip < 0 {
+		skip = 0
+	}
+	return skip, limit
+}
+
+// After restricts the listing to the items following item id in order:
+// those equal on the first columns and past it on the next one. Columns
+// other than id are compared to the values of the item itself.
+func (f *Filter) After(order Order, id models.ItemID) {
+	ref := f.Arg(id)
+	value := func(column string) string {
+		if column == "id" {
+			return ref
+		}
+		return "(SELECT " + column + " FROM items WHERE id = " + ref + ")"
+	}
+
+	var alternatives []string
+	for i, key := range order {
+		var terms []string
+		for _, prev := range order[:i] {
+			terms = append(terms, prev.Column+" = "+value(prev.Column))
+		}
+		op := " > "
+		if key.Desc {
+			op = " < "
+		}
+		terms = append(terms, key.Column+op+value(key.Column))
+		alternatives = append(alternatives, strings.Join(terms, " AND "))
+	}
+	f.Where("(" + strings.Join(alternatives, " OR ") + ")")
+}
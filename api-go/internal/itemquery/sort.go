@@ -0,0 +1,64 @@
+package itemquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortColumns are the columns item listings can be sorted by.
+var sortColumns = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"price":      true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// SortKey is one column of the sort order.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Order is a total order on items: it always ends with id.
+type Order []SortKey
+
+// ParseSort parses a comma-separated list of columns, each prefixed with -
+// to sort descending. id is appended as a tie-breaker when missing, so
+// pages are stable.
+func ParseSort(sort string) (Order, error) {
+	var order Order
+	seen := map[string]bool{}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := SortKey{Column: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if !sortColumns[key.Column] {
+			return nil, fmt.Errorf("cannot sort by %q; use id, name, price, created_at or updated_at", key.Column)
+		}
+		if seen[key.Column] {
+			return nil, fmt.Errorf("%s is listed twice in sort", key.Column)
+		}
+		seen[key.Column] = true
+		order = append(order, key)
+		if key.Column == "id" {
+			// id is unique; later columns would never be compared
+			return order, nil
+		}
+	}
+	return append(order, SortKey{Column: "id"}), nil
+}
+
+// String returns the ORDER BY list.
+func (o Order) String() string {
+	keys := make([]string, len(o))
+	for i, key := range o {
+		keys[i] = key.Column
+		if key.Desc {
+			keys[i] += " DESC"
+		}
+	}
+	return strings.Join(keys, ", ")
+}
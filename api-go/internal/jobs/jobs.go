@@ -0,0 +1,381 @@
+// Package jobs runs long-running operations asynchronously on a bounded
+// worker pool and keeps their status for polling.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job states.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when no more jobs can be queued.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrNotFound is returned for unknown job IDs.
+	ErrNotFound = errors.New("job not found")
+	// ErrStopped is returned by Submit after the manager has shut down.
+	ErrStopped = errors.New("job manager is stopped")
+	// ErrBusy is returned by SubmitExclusive while a conflicting job is
+	// queued or running.
+	ErrBusy = errors.New("a conflicting job is queued or running")
+)
+
+// Func is the work performed by a job. It should return promptly once ctx
+// is canceled. out receives progress messages and command output.
+type Func func(ctx context.Context, out *Output) (interface{}, error)
+
+// idPattern matches job IDs generated by newID.
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Job is a snapshot of a job's state.
+type Job struct {
+	ID         string
+	Type       string
+	Status     string
+	Message    string
+	Error      string
+	Params     map[string]interface{}
+	Result     interface{}
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// Done reports whether the job has reached a final state.
+func (j Job) Done() bool {
+	switch j.Status {
+	case StatusSucceeded, StatusFailed, StatusCanceled:
+		return true
+	}
+	return false
+}
+
+type entry struct {
+	job    Job
+	fn     Func
+	cancel context.CancelFunc
+	output *Output
+}
+
+// Manager queues jobs and runs them on a fixed number of workers.
+type Manager struct {
+	workers     int
+	historySize int
+	logDir      string
+	queue       chan *entry
+
+	mu       sync.RWMutex
+	jobs     map[string]*entry
+	stopped  bool
+	onFinish []func(Job)
+}
+
+// NewManager creates a manager with the given number of workers, queue
+// capacity and number of finished jobs to retain. Job logs are written to
+// logDir, if set, and remain readable after the job leaves the history.
+func NewManager(workers, queueSize, historySize int, logDir string) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Manager{
+		workers:     workers,
+		historySize: historySize,
+		logDir:      logDir,
+		queue:       make(chan *entry, queueSize),
+		jobs:        make(map[string]*entry),
+	}
+}
+
+// Start launches the workers. Running jobs are canceled when ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		go m.work(ctx)
+	}
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		m.stopped = true
+		m.mu.Unlock()
+	}()
+}
+
+// OnFinish registers fn to be called with the final snapshot of every job
+// once its log is complete. fn runs on the worker that ran the job.
+func (m *Manager) OnFinish(fn func(Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onFinish = append(m.onFinish, fn)
+}
+
+// Submit queues a job of the given type and returns its initial snapshot.
+func (m *Manager) Submit(jobType string, params map[string]interface{}, fn Func) (Job, error) {
+	return m.SubmitExclusive(nil, jobType, params, fn)
+}
+
+// SubmitExclusive queues a job as Submit does unless a job of one of the
+// conflicting types is queued or running, in which case it returns
+// ErrBusy. The check and the queueing happen under one lock, so of
+// concurrent callers only one gets its job queued.
+func (m *Manager) SubmitExclusive(conflicting []string, jobType string, params map[string]interface{}, fn Func) (Job, error) {
+	e := &entry{
+		job: Job{
+			ID:        newID(),
+			Type:      jobType,
+			Status:    StatusQueued,
+			Params:    params,
+			CreatedAt: time.Now().UTC(),
+		},
+		fn: fn,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		return Job{}, ErrStopped
+	}
+	for _, t := range conflicting {
+		if m.activeLocked(t) {
+			return Job{}, ErrBusy
+		}
+	}
+
+	select {
+	case m.queue <- e:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	m.jobs[e.job.ID] = e
+	return e.job, nil
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return e.job, nil
+}
+
+// Output returns the log of the job with the given ID. Jobs that have not
+// started yet have no output.
+func (m *Manager) Output(id string) (*Output, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.jobs[id]
+	if !ok || e.output == nil {
+		return nil, ErrNotFound
+	}
+	return e.output, nil
+}
+
+// LogFile returns the path of the persisted log of the job with the given
+// ID, or ErrNotFound when there is none.
+func (m *Manager) LogFile(id string) (string, error) {
+	if m.logDir == "" || !idPattern.MatchString(id) {
+		return "", ErrNotFound
+	}
+	path := filepath.Join(m.logDir, id+".log")
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// List returns snapshots of all known jobs, newest first. Empty filters
+// match everything.
+func (m *Manager) List(jobType, status string) []Job {
+	m.mu.RLock()
+	out := make([]Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		if jobType != "" && e.job.Type != jobType {
+			continue
+		}
+		if status != "" && e.job.Status != status {
+			continue
+		}
+		out = append(out, e.job)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Active reports whether a job of the given type is queued or running.
+func (m *Manager) Active(jobType string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.activeLocked(jobType)
+}
+
+func (m *Manager) activeLocked(jobType string) bool {
+	for _, e := range m.jobs {
+		if e.job.Type == jobType && !e.job.Done() {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancel cancels a queued or running job. Finished jobs are removed from
+// the history instead. The returned snapshot reflects the job before
+// removal or after cancellation was requested.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	switch e.job.Status {
+	case StatusQueued:
+		now := time.Now().UTC()
+		e.job.Status = StatusCanceled
+		e.job.FinishedAt = &now
+	case StatusRunning:
+		e.cancel()
+	default:
+		delete(m.jobs, id)
+	}
+	return e.job, nil
+}
+
+// work runs queued jobs until ctx is done.
+func (m *Manager) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-m.queue:
+			m.run(ctx, e)
+		}
+	}
+}
+
+// run executes one job and records its outcome.
+func (m *Manager) run(ctx context.Context, e *entry) {
+	m.mu.Lock()
+	if e.job.Status != StatusQueued {
+		// Canceled while queued
+		job, hooks := e.job, m.onFinish
+		m.mu.Unlock()
+		for _, fn := range hooks {
+			fn(job)
+		}
+		return
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	now := time.Now().UTC()
+	e.cancel = cancel
+	e.job.Status = StatusRunning
+	e.job.StartedAt = &now
+	e.output = newOutput(func(message string) {
+		m.mu.Lock()
+		e.job.Message = message
+		m.mu.Unlock()
+	}, m.openLog(e.job.ID))
+	m.mu.Unlock()
+
+	result, err := e.fn(jobCtx, e.output)
+
+	m.mu.Lock()
+	finished := time.Now().UTC()
+	e.job.FinishedAt = &finished
+	e.job.Result = result
+	switch {
+	case jobCtx.Err() != nil:
+		e.job.Status = StatusCanceled
+		if err != nil {
+			e.job.Error = err.Error()
+		}
+	case err != nil:
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = StatusSucceeded
+	}
+	m.pruneLocked()
+	job := e.job
+	hooks := m.onFinish
+	m.mu.Unlock()
+
+	// Close after the final status is recorded so log followers see it.
+	e.output.close()
+
+	for _, fn := range hooks {
+		fn(job)
+	}
+}
+
+// openLog creates the log file of a job, or returns nil when logs are not
+// persisted or the file cannot be created.
+func (m *Manager) openLog(id string) *os.File {
+	if m.logDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.logDir, 0o700); err != nil {
+		slog.Warn("Job logs disabled", "error", err)
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(m.logDir, id+".log"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		slog.Warn("Failed to create job log", "job_id", id, "error", err)
+		return nil
+	}
+	return f
+}
+
+// pruneLocked drops the oldest finished jobs beyond the history size.
+func (m *Manager) pruneLocked() {
+	var finished []*entry
+	for _, e := range m.jobs {
+		if e.job.Done() {
+			finished = append(finished, e)
+		}
+	}
+	if len(finished) <= m.historySize {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].job.FinishedAt.Before(*finished[j].job.FinishedAt)
+	})
+	for _, e := range finished[:len(finished)-m.historySize] {
+		delete(m.jobs, e.job.ID)
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxLogLines bounds the log lines kept in memory per job. Older lines are
+// only available from the log file.
+const maxLogLines = 5000
+
+// Output collects a job's log and progress messages. It implements
+// io.Writer so it can be attached to a command's stdout and stderr; writes
+// are split into lines. Readers follow the log by line number.
+type Output struct {
+	report func(string)
+
+	mu      sync.Mutex
+	lines   []string
+	dropped int // lines discarded from the front of lines
+	partial []byte
+	file    *os.File
+	closed  bool
+	changed chan struct{}
+}
+
+func newOutput(report func(string), file *os.File) *Output {
+	return &Output{report: report, file: file, changed: make(chan struct{})}
+}
+
+// Report publishes a progress message as the job's message and adds it
+// to the log.
+func (o *Output) Report(message string) {
+	if o.report != nil {
+		o.report(message)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.appendLocked("==> " + message)
+}
+
+// Write adds p to the log. Incomplete trailing lines are held until the
+// next write or Close.
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data := append(o.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		o.appendLocked(strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+	o.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// appendLocked stores one line, writes it to the log file and wakes up
+// readers. The caller holds the lock.
+func (o *Output) appendLocked(line string) {
+	if o.closed {
+		return
+	}
+	o.lines = append(o.lines, line)
+	if len(o.lines) > maxLogLines {
+		n := len(o.lines) - maxLogLines
+		o.lines = append([]string(nil), o.lines[n:]...)
+		o.dropped += n
+	}
+	if o.file != nil {
+		o.file.WriteString(line + "\n")
+	}
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
+
+// close flushes any incomplete line, closes the log file and wakes up
+// readers for the last time.
+func (o *Output) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.partial) > 0 {
+		o.appendLocked(string(o.partial))
+		o.partial = nil
+	}
+	if o.file != nil {
+		o.file.Close()
+		o.file = nil
+	}
+	o.closed = true
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
+
+// Lines returns the lines from line number from onwards and the number to
+// continue from. Lines no longer held in memory are skipped.
+func (o *Output) Lines(from int) ([]string, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if from < o.dropped {
+		from = o.dropped
+	}
+	end := o.dropped + len(o.lines)
+	if from >= end {
+		return nil, end
+	}
+	return append([]string(nil), o.lines[from-o.dropped:]...), end
+}
+
+// Tail returns at most n of the most recent lines.
+func (o *Output) Tail(n int) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lines := o.lines
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return append([]string{}, lines...)
+}
+
+// Wait returns a channel that is closed on the next write or when the
+// job finishes, and whether the job has already finished.
+func (o *Output) Wait() (<-chan struct{}, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.changed, o.closed
+}
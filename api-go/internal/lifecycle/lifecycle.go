@@ -0,0 +1,111 @@
+// Package lifecycle tracks the API's initialization phases.
+package lifecycle
+
+import (
+	"sync"
+	"time"
+)
+
+// Initialization phases.
+const (
+	PhaseConfigLoaded    = "config_loaded"
+	PhasePoolConnected   = "pool_connected"
+	PhaseSchemaMigrated  = "schema_migrated"
+	PhaseMonitorsRunning = "monitors_running"
+)
+
+// Phase states.
+const (
+	StatePending  = "pending"
+	StateComplete = "complete"
+	StateFailed   = "failed"
+)
+
+// Overall startup states.
+const (
+	StatusStarting = "starting"
+	StatusStarted  = "started"
+	StatusFailed   = "failed"
+)
+
+// PhaseState is a snapshot of a single initialization phase.
+type PhaseState struct {
+	Name        string
+	State       string
+	Error       string
+	CompletedAt *time.Time
+}
+
+// Tracker records the progress of initialization phases in order.
+type Tracker struct {
+	mu        sync.RWMutex
+	startedAt time.Time
+	phases    []PhaseState
+}
+
+// NewTracker creates a tracker with the given phases pending.
+func NewTracker(phases ...string) *Tracker {
+	t := &Tracker{
+		startedAt: time.Now().UTC(),
+		phases:    make([]PhaseState, 0, len(phases)),
+	}
+	for _, name := range phases {
+		t.phases = append(t.phases, PhaseState{Name: name, State: StatePending})
+	}
+	return t
+}
+
+// Complete marks a phase as complete.
+func (t *Tracker) Complete(name string) {
+	t.set(name, StateComplete, "")
+}
+
+// Fail marks a phase as failed with the given error.
+func (t *Tracker) Fail(name string, err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	t.set(name, StateFailed, msg)
+}
+
+func (t *Tracker) set(name, state, msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	for i := range t.phases {
+		if t.phases[i].Name == name {
+			t.phases[i].State = state
+			t.phases[i].Error = msg
+			t.phases[i].CompletedAt = &now
+			return
+		}
+	}
+	t.phases = append(t.phases, PhaseState{Name: name, State: state, Error: msg, CompletedAt: &now})
+}
+
+// StartedAt returns when the tracker was created.
+func (t *Tracker) StartedAt() time.Time {
+	return t.startedAt
+}
+
+// Snapshot returns the overall status and a copy of every phase.
+func (t *Tracker) Snapshot() (string, []PhaseState) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := StatusStarted
+	phases := make([]PhaseState, len(t.phases))
+	copy(phases, t.phases)
+
+	for _, p := range phases {
+		switch p.State {
+		case StateFailed:
+			return StatusFailed, phases
+		case StatePending:
+			status = StatusStarting
+		}
+	}
+	return status, phases
+}
@@ -0,0 +1,51 @@
+package lifecycle
+
+import (
+	"sync"
+	"time"
+)
+
+// MaintenanceState is a snapshot of the maintenance flag.
+type MaintenanceState struct {
+	Enabled bool
+	Reason  string
+	Since   *time.Time
+}
+
+// Maintenance is the operator-set flag taking the node out of rotation
+// while it is being worked on.
+type Maintenance struct {
+	mu    sync.RWMutex
+	state MaintenanceState
+}
+
+// NewMaintenance creates a maintenance flag that is off.
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+// Set turns maintenance on or off and returns the new state. Turning it
+// on again keeps the original start time but updates the reason.
+func (m *Maintenance) Set(enabled bool, reason string) MaintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !enabled {
+		m.state = MaintenanceState{}
+		return m.state
+	}
+	if !m.state.Enabled {
+		now := time.Now().UTC()
+		m.state.Since = &now
+	}
+	m.state.Enabled = true
+	m.state.Reason = reason
+	return m.state
+}
+
+// State returns the current state.
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
@@ -0,0 +1,69 @@
+// Package logging sets up the structured logger of the API: slog with a
+// configurable level and format, which records the request ID and trace
+// of the context a line is logged with.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// levels maps LOG_LEVEL to slog levels.
+var levels = map[string]slog.Level{
+	config.LogLevelDebug: slog.LevelDebug,
+	config.LogLevelInfo:  slog.LevelInfo,
+	config.LogLevelWarn:  slog.LevelWarn,
+	config.LogLevelError: slog.LevelError,
+}
+
+// New returns a logger writing to w as configured.
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: levels[cfg.Level]}
+	var handler slog.Handler
+	if cfg.Format == config.LogFormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup makes a logger writing to stderr the default of slog and of the
+// log package, and returns it.
+func Setup(cfg config.LogConfig) *slog.Logger {
+	logger := New(cfg, os.Stderr)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// contextHandler adds the request ID and the trace of the context to each
+// record, so a log line leads to its trace.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := requestid.FromContext(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+)
+
+// APIVersion records v as the API version of the requests it serves.
+// Responses on the unversioned paths carry a Link to the same path under
+// the latest version, so consumers can find it before moving over.
+func APIVersion(v apiversion.Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiversion.Set(c, v)
+		if v == apiversion.Legacy {
+			c.Header("Link", "<"+apiversion.Latest.Prefix()+c.Request.URL.Path+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// AccessRule requires Role of the API key of requests whose route starts
+// with Path, for one method or, when Method is empty, all of them.
+type AccessRule struct {
+	Method string
+	Path   string
+	Role   string
+}
+
+// Authorize enforces rules on every route: the first rule matching the
+// route of a request decides the role its API key needs, answering 401
+// without a valid key and 403 when its role is short. Routes no rule
+// matches are open.
+func Authorize(keys []config.APIKey, rules []AccessRule) gin.HandlerFunc {
+	auth := NewAuthenticator(keys)
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		role := RequiredRole(rules, c.Request.Method, route)
+		if route == "" || role == "" {
+			c.Next()
+			return
+		}
+
+		key, ok := auth.authenticate(c)
+		if !ok {
+			return
+		}
+		if !config.RoleAtLeast(key.Role, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "forbidden",
+				Message: fmt.Sprintf("API key %q has the %s role; %s %s requires %s", key.Name, key.Role, c.Request.Method, route, role),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequiredRole returns the role of the first of rules matching a route,
+// or "" when the route is open. Rules apply to every API version: their
+// paths are matched without the version prefix.
+func RequiredRole(rules []AccessRule, method, route string) string {
+	route = apiversion.StripPrefix(route)
+	for _, rule := range rules {
+		if (rule.Method == "" || rule.Method == method) && strings.HasPrefix(route, rule.Path) {
+			return rule.Role
+		}
+	}
+	return ""
+}
+
+// Authenticator finds the API key of a request. The gRPC API uses it too.
+type Authenticator struct {
+	keys    []config.APIKey
+	digests [][sha256.Size]byte
+}
+
+// NewAuthenticator creates an authenticator accepting keys.
+func NewAuthenticator(keys []config.APIKey) *Authenticator {
+	a := &Authenticator{keys: keys, digests: make([][sha256.Size]byte, len(keys))}
+	for i, k := range keys {
+		a.digests[i] = sha256.Sum256([]byte(k.Key))
+	}
+	return a
+}
+
+// Enabled reports whether any key is configured.
+func (a *Authenticator) Enabled() bool {
+	return len(a.keys) > 0
+}
+
+// Match returns the configured key equal to provided. The key is compared
+// with every configured one in constant time, so the response time tells
+// nothing about how close a guess was or which key matched.
+func (a *Authenticator) Match(provided string) (config.APIKey, bool) {
+	// Digests have the same length whatever was sent
+	digest := sha256.Sum256([]byte(provided))
+	match := -1
+	for i := range a.digests {
+		if subtle.ConstantTimeCompare(digest[:], a.digests[i][:]) == 1 {
+			match = i
+		}
+	}
+	if provided == "" || match < 0 {
+		return config.APIKey{}, false
+	}
+	return a.keys[match], true
+}
+
+// authenticate returns the key the request carries in the X-API-Key
+// header or as a Bearer token, or aborts it.
+func (a *Authenticator) authenticate(c *gin.Context) (config.APIKey, bool) {
+	if !a.Enabled() {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: "Admin API is disabled; set ADMIN_API_KEY, ADMIN_API_KEYS or ADMIN_API_KEYS_FILE to enable it",
+		})
+		return config.APIKey{}, false
+	}
+
+	provided := c.GetHeader("X-API-Key")
+	if provided == "" {
+		if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			provided = strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+
+	key, ok := a.Match(provided)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "unauthorized",
+			Message: "A valid API key is required",
+		})
+		return config.APIKey{}, false
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		slog.InfoContext(c.Request.Context(), "API key authorized",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "key", key.Name, "role", key.Role)
+	}
+	return key, true
+}
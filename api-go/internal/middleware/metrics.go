@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests no route matched, so scans of random
+// paths do not add a series each.
+const unmatchedRoute = "unmatched"
+
+// HTTPMetrics counts and times the requests of the API per route. It is a
+// prometheus.Collector, exported by GET /metrics/prometheus with the other
+// metrics.
+type HTTPMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewHTTPMetrics creates the request metrics.
+func NewHTTPMetrics() *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pgha_http_requests_total",
+			Help: "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "pgha_http_request_duration_seconds",
+			Help: "Time to serve HTTP requests, by method, route and status code.",
+			// Up to 30s: during a failover requests wait on the pool
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pgha_http_requests_in_flight",
+			Help: "HTTP requests being served, by method and route.",
+		}, []string{"method", "route"}),
+	}
+}
+
+// Handler records each request in the metrics.
+func (m *HTTPMetrics) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+
+		inFlight := m.inFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		m.requests.WithLabelValues(method, route, status).Inc()
+		m.duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *HTTPMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+	m.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *HTTPMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+	m.inFlight.Collect(ch)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+)
+
+// requestIDKey is the gin context key of the request ID.
+const requestIDKey = "request_id"
+
+// RequestID gives every request an ID: the X-Request-ID of the client
+// when it is valid, otherwise a new one. The ID is sent back in the same
+// header and carried by the request context, down to the database
+// sessions and calls to Patroni and the DR site it makes.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+)
+
+// RequestLog logs each request once it is done, with its latency, status,
+// route and the number and time of its database queries. Failed requests
+// are always logged, at warn or error level; sampleRate is the share of
+// the others logged.
+func RequestLog(logger *slog.Logger, sampleRate float64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx, stats := db.WithQueryStats(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case rand.Float64() >= sampleRate:
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", milliseconds(time.Since(start))),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int64("db_queries", stats.Count()),
+			slog.Float64("db_ms", milliseconds(stats.Duration())),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery answers 500 to a request whose handler panicked and logs the
+// panic with its stack.
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.ErrorContext(c.Request.Context(), "panic serving request",
+			"error", err, "path", c.Request.URL.Path, "stack", string(debug.Stack()))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// milliseconds returns d in fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
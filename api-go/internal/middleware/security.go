@@ -0,0 +1,32 @@
+// Package middleware provides HTTP middleware shared by all routes.
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+)
+
+// SecurityHeaders sets standard security response headers. HSTS is only
+// sent on TLS connections.
+func SecurityHeaders(cfg config.ServerConfig) gin.HandlerFunc {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", int64(cfg.HSTSMaxAge.Seconds()))
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+		if cfg.FrameOptions != "" {
+			header.Set("X-Frame-Options", cfg.FrameOptions)
+		}
+		if hsts != "" && c.Request.TLS != nil {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for each request, continuing the trace of
+// the caller when it sent a traceparent header. The span is named after
+// the route, so /items/1 and /items/2 are grouped, and carries the request
+// ID. Handlers reach it through the request context.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracing.Start(ctx, name, trace.SpanKindServer,
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+		)
+		if id := requestid.FromContext(ctx); id != "" {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// Only server errors mark the span failed; a 4xx is the caller's
+		var err error
+		if status >= http.StatusInternalServerError {
+			err = fmt.Errorf("%d %s", status, http.StatusText(status))
+			if len(c.Errors) > 0 {
+				err = c.Errors.Last()
+			}
+		}
+		tracing.End(span, err)
+	}
+}
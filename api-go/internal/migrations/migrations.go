@@ -0,0 +1,306 @@
+// Package migrations applies the schema of the API's tables, from the demo
+// tables to those of the job catalog, write probe and heartbeat, from SQL
+// files embedded in the binary, in the order of their version numbers.
+// Applied versions are recorded in the schema_migrations table with the
+// checksum of their file, so a database whose schema came from other
+// files, e.g. a DR copy of another branch, is refused.
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID is the advisory lock serializing migrations, so instances
+// starting together apply each of them once.
+const lockID = 0x6d696772
+
+// Migration is one schema change. SQL may hold several statements.
+// Checksum is the SHA-256 of its file, before the item key type is
+// filled in.
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// ChecksumError reports applied migrations that differ from the embedded
+// ones. The API refuses to start on it.
+type ChecksumError struct {
+	Diverged []string
+}
+
+func (e *ChecksumError) Error() string {
+	return "the applied migrations differ from those of this build: " + strings.Join(e.Diverged, "; ")
+}
+
+// Status is a migration and when it was applied, nil while pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations.
+type Migrator struct {
+	itemKeyType string
+	migrations  []Migration
+}
+
+// ItemKeyType returns the SQL type of item keys for ITEMS_KEY_TYPE.
+func ItemKeyType(keyType string) string {
+	if keyType == config.ItemKeyUUIDv7 {
+		return "uuid"
+	}
+	return "integer"
+}
+
+// New loads the migrations. Files are named <version>_<name>.sql and are
+// templates of the item key type, {{.ItemKeyType}}.
+func New(itemKeyType string) (*Migrator, error) {
+	m := &Migrator{itemKeyType: ItemKeyType(itemKeyType)}
+	entries, err := files.ReadDir("sql")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[int]string{}
+	for _, entry := range entries {
+		version, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		n, err := strconv.Atoi(version)
+		if !ok || err != nil || n < 1 {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", entry.Name())
+		}
+		if other, dup := seen[n]; dup {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, entry.Name())
+		}
+		seen[n] = entry.Name()
+
+		source, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(entry.Name()).Parse(string(source))
+		if err != nil {
+			return nil, err
+		}
+		var sql bytes.Buffer
+		if err := tmpl.Execute(&sql, struct{ ItemKeyType string }{m.itemKeyType}); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		sum := sha256.Sum256(source)
+		m.migrations = append(m.migrations, Migration{Version: n, Name: name, SQL: sql.String(), Checksum: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+	return m, nil
+}
+
+// Migrations returns the migrations in order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies the pending migrations on the primary, each in its own
+// transaction, and returns those it applied. It first verifies the
+// applied ones, returning a *ChecksumError when they differ. It then
+// checks that the items table has the configured key type: a table
+// created with the other one cannot be converted, e.g. after changing
+// ITEMS_KEY_TYPE.
+func (m *Migrator) Up(ctx context.Context, pool *db.Pool) ([]Migration, error) {
+	if err := m.Verify(ctx, pool); err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, migration := range m.migrations {
+		done, err := m.apply(ctx, pool, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			applied = append(applied, migration)
+		}
+	}
+
+	var keyType string
+	err := pool.QueryRow(ctx, `
+		SELECT format_type(atttypid, atttypmod)
+		FROM pg_attribute
+		WHERE attrelid = 'items'::regclass AND attname = 'id'
+	`).Scan(&keyType)
+	if err != nil {
+		return applied, err
+	}
+	if keyType != m.itemKeyType {
+		return applied, fmt.Errorf("the items table has %s keys, not %s as ITEMS_KEY_TYPE says", keyType, m.itemKeyType)
+	}
+	return applied, nil
+}
+
+// apply applies a migration unless it already was, and reports whether
+// it did.
+func (m *Migrator) apply(ctx context.Context, pool *db.Pool, migration Migration) (bool, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		return false, err
+	}
+	// Tables created before checksums were recorded get the column
+	_, err = tx.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			checksum TEXT
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT
+	`)
+	if err != nil {
+		return false, err
+	}
+
+	var done bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", migration.Version).Scan(&done)
+	if err != nil {
+		return false, err
+	}
+	if done {
+		// Migrations applied before checksums were recorded are trusted
+		// as they are
+		_, err = tx.Exec(ctx, "UPDATE schema_migrations SET checksum = $2 WHERE version = $1 AND checksum IS NULL",
+			migration.Version, migration.Checksum)
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit(ctx)
+	}
+	if _, err := tx.Exec(ctx, migration.SQL); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, migration.Checksum)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Verify compares the applied migrations with the embedded ones and
+// returns a *ChecksumError when they differ. It only reads, so it also
+// works on a replica.
+func (m *Migrator) Verify(ctx context.Context, pool *db.Pool) error {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	// The checksum column is read through the row so that tables from
+	// before it was added can be read too
+	rows, err := pool.Query(ctx, "SELECT version, COALESCE(to_jsonb(m)->>'checksum', '') FROM schema_migrations m")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	checksums := map[int]string{}
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return err
+		}
+		checksums[version] = checksum
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return m.Compare(checksums)
+}
+
+// Compare checks applied, the checksums of the applied migrations by
+// version, "" when none was recorded, against the embedded migrations. It
+// returns a *ChecksumError listing the versions whose checksum differs and
+// those this build does not know.
+func (m *Migrator) Compare(applied map[int]string) error {
+	known := make(map[int]bool, len(m.migrations))
+	var diverged []string
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		checksum, ok := applied[migration.Version]
+		if ok && checksum != "" && checksum != migration.Checksum {
+			diverged = append(diverged, fmt.Sprintf("%d_%s has checksum %s, not %s", migration.Version, migration.Name, checksum, migration.Checksum))
+		}
+	}
+	var unknown []int
+	for version := range applied {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+	sort.Ints(unknown)
+	for _, version := range unknown {
+		diverged = append(diverged, fmt.Sprintf("version %d is not part of this build", version))
+	}
+	if len(diverged) > 0 {
+		return &ChecksumError{Diverged: diverged}
+	}
+	return nil
+}
+
+// Status returns every migration with when it was applied. It also works
+// on a replica.
+func (m *Migrator) Status(ctx context.Context, pool *db.Pool) ([]Status, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	appliedAt := map[int]time.Time{}
+	if exists {
+		rows, err := pool.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var version int
+			var at time.Time
+			if err := rows.Scan(&version, &at); err != nil {
+				return nil, err
+			}
+			appliedAt[version] = at
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if at, ok := appliedAt[migration.Version]; ok {
+			at = at.UTC()
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
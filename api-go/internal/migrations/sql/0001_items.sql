@@ -0,0 +1,35 @@
+-- Items demo table. Tables created on demand by earlier versions of the
+-- API are brought up to date.
+{{- if eq .ItemKeyType "uuid"}}
+
+-- Default of UUID keys: a UUIDv7 from the clock in milliseconds, set as
+-- the version 7 of a random UUID. PostgreSQL only has uuidv7() from 18.
+CREATE OR REPLACE FUNCTION items_uuidv7() RETURNS uuid AS $$
+    SELECT encode(
+        set_bit(set_bit(
+            overlay(uuid_send(gen_random_uuid())
+                PLACING substring(int8send(floor(extract(epoch FROM clock_timestamp()) * 1000)::bigint) FROM 3)
+                FROM 1 FOR 6),
+            52, 1), 53, 1),
+        'hex')::uuid
+$$ LANGUAGE sql VOLATILE;
+{{- end}}
+
+CREATE TABLE IF NOT EXISTS items (
+    {{if eq .ItemKeyType "uuid"}}id UUID PRIMARY KEY DEFAULT items_uuidv7(){{else}}id SERIAL PRIMARY KEY{{end}},
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price DECIMAL(10, 2) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_items_is_active ON items(is_active);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Orders of items, written across both tables in one transaction.
+
+CREATE TABLE IF NOT EXISTS orders (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'placed',
+    total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_lines (
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    item_id {{.ItemKeyType}} NOT NULL REFERENCES items(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(10, 2) NOT NULL,
+    PRIMARY KEY (order_id, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_lines_item_id ON order_lines(item_id);
@@ -0,0 +1,21 @@
+-- Accounts of the transfer demo. The sum of the balances only stays
+-- constant if every transfer is atomic.
+
+CREATE TABLE IF NOT EXISTS demo_accounts (
+    id VARCHAR(64) PRIMARY KEY,
+    balance DECIMAL(12, 2) NOT NULL CHECK (balance >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS demo_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    from_account VARCHAR(64) NOT NULL REFERENCES demo_accounts(id),
+    to_account VARCHAR(64) NOT NULL REFERENCES demo_accounts(id),
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO demo_accounts (id, balance) VALUES
+    ('alice', 1000),
+    ('bob', 1000)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,55 @@
+-- Every version of every item, kept by a trigger so that COPY loads and
+-- writes from outside the API are recorded too. Versions outlive the item,
+-- and their recording time and transaction ID serve as known data states
+-- for point-in-time recovery targets.
+
+CREATE TABLE IF NOT EXISTS item_versions (
+    item_id {{.ItemKeyType}} NOT NULL,
+    version INTEGER NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price DECIMAL(10, 2) NOT NULL,
+    is_active BOOLEAN,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp(),
+    xid BIGINT NOT NULL DEFAULT txid_current(),
+    PRIMARY KEY (item_id, version)
+);
+
+-- A permanent delete is recorded as the version after the last one. A
+-- write that does not bump the version replaces the recorded one.
+CREATE OR REPLACE FUNCTION record_item_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO item_versions (item_id, version, operation, name, description, price, is_active, deleted_at)
+        VALUES (OLD.id, OLD.version + 1, 'delete', OLD.name, OLD.description, OLD.price, OLD.is_active, clock_timestamp())
+        ON CONFLICT (item_id, version) DO NOTHING;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO item_versions (item_id, version, operation, name, description, price, is_active, deleted_at)
+    VALUES (NEW.id, NEW.version, lower(TG_OP), NEW.name, NEW.description, NEW.price, NEW.is_active, NEW.deleted_at)
+    ON CONFLICT (item_id, version) DO UPDATE SET
+        operation = EXCLUDED.operation,
+        name = EXCLUDED.name,
+        description = EXCLUDED.description,
+        price = EXCLUDED.price,
+        is_active = EXCLUDED.is_active,
+        deleted_at = EXCLUDED.deleted_at,
+        recorded_at = EXCLUDED.recorded_at,
+        xid = EXCLUDED.xid;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS items_record_version ON items;
+CREATE TRIGGER items_record_version
+    AFTER INSERT OR UPDATE OR DELETE ON items
+    FOR EACH ROW EXECUTE FUNCTION record_item_version();
+
+-- Items written before the history existed start with their current state
+INSERT INTO item_versions (item_id, version, operation, name, description, price, is_active, deleted_at, recorded_at)
+SELECT id, version, 'snapshot', name, description, price, is_active, deleted_at, updated_at
+FROM items
+ON CONFLICT (item_id, version) DO NOTHING;
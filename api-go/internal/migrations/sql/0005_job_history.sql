@@ -0,0 +1,19 @@
+-- Catalog of finished jobs, so backup and restore history survives
+-- restarts. Created on first write by earlier versions of the API.
+
+CREATE TABLE IF NOT EXISTS job_history (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    trigger TEXT NOT NULL,
+    status TEXT NOT NULL,
+    params JSONB,
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    duration_seconds DOUBLE PRECISION,
+    log_file TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_history_type_created ON job_history(type, created_at DESC);
@@ -0,0 +1,8 @@
+-- Row bumped by GET /probe/write to check the primary accepts writes.
+-- Created on the first probe by earlier versions of the API.
+
+CREATE TABLE IF NOT EXISTS ha_write_probe (
+    id INT PRIMARY KEY,
+    probes BIGINT NOT NULL,
+    written_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Heartbeat written on the primary and read back from the standbys to
+-- measure the RPO. Created on the first heartbeat by earlier versions of
+-- the API.
+
+CREATE TABLE IF NOT EXISTS ha_heartbeat (
+    id INT PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    written_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+)
+
+// BlockedLock describes one backend waiting on a lock held by another.
+type BlockedLock struct {
+	BlockedPID          int      `json:"blocked_pid"`
+	BlockedUser         string   `json:"blocked_user"`
+	BlockedApplication  string   `json:"blocked_application"`
+	BlockedQuery        string   `json:"blocked_query"`
+	WaitSeconds         float64  `json:"wait_seconds"`
+	LockType            *string  `json:"lock_type,omitempty"`
+	LockMode            *string  `json:"lock_mode,omitempty"`
+	Relation            *string  `json:"relation,omitempty"`
+	BlockingPID         int      `json:"blocking_pid"`
+	BlockingUser        string   `json:"blocking_user"`
+	BlockingApplication string   `json:"blocking_application"`
+	BlockingState       string   `json:"blocking_state"`
+	BlockingQuery       string   `json:"blocking_query"`
+	BlockingXactSeconds *float64 `json:"blocking_xact_seconds,omitempty"`
+}
+
+// LocksResponse represents the current lock blocking chains.
+type LocksResponse struct {
+	Blocked      []BlockedLock `json:"blocked"`
+	RootBlockers []int         `json:"root_blockers"`
+	Timestamp    time.Time     `json:"timestamp"`
+}
+
+// ProblematicSession describes a backend exceeding a session threshold.
+type ProblematicSession struct {
+	PID             int      `json:"pid"`
+	Reason          string   `json:"reason"`
+	User            string   `json:"user"`
+	Application     string   `json:"application"`
+	ClientAddr      *string  `json:"client_addr,omitempty"`
+	Database        string   `json:"database"`
+	State           string   `json:"state"`
+	QueryAgeSeconds *float64 `json:"query_age_seconds,omitempty"`
+	XactAgeSeconds  *float64 `json:"xact_age_seconds,omitempty"`
+	StateAgeSeconds *float64 `json:"state_age_seconds,omitempty"`
+	WaitEventType   *string  `json:"wait_event_type,omitempty"`
+	WaitEvent       *string  `json:"wait_event,omitempty"`
+	Query           string   `json:"query"`
+}
+
+// SessionThresholds are the limits applied by the problematic session report.
+type SessionThresholds struct {
+	MaxQueryAgeSeconds             float64 `json:"max_query_age_seconds"`
+	MaxIdleInTransactionAgeSeconds float64 `json:"max_idle_in_transaction_age_seconds"`
+}
+
+// ProblematicSessionsResponse lists sessions over the configured thresholds.
+type ProblematicSessionsResponse struct {
+	Thresholds SessionThresholds    `json:"thresholds"`
+	Sessions   []ProblematicSession `json:"sessions"`
+	Timestamp  time.Time            `json:"timestamp"`
+}
+
+// Connection represents one backend from pg_stat_activity.
+type Connection struct {
+	PID           int        `json:"pid"`
+	User          string     `json:"user"`
+	Application   string     `json:"application"`
+	ClientAddr    *string    `json:"client_addr,omitempty"`
+	Database      string     `json:"database"`
+	BackendType   string     `json:"backend_type"`
+	State         string     `json:"state"`
+	BackendStart  *time.Time `json:"backend_start,omitempty"`
+	XactStart     *time.Time `json:"xact_start,omitempty"`
+	QueryStart    *time.Time `json:"query_start,omitempty"`
+	StateChange   *time.Time `json:"state_change,omitempty"`
+	WaitEventType *string    `json:"wait_event_type,omitempty"`
+	WaitEvent     *string    `json:"wait_event,omitempty"`
+	Query         string     `json:"query"`
+}
+
+// ConnectionsResponse represents the connection listing.
+type ConnectionsResponse struct {
+	Connections []Connection `json:"connections"`
+	Total       int          `json:"total"`
+	Timestamp   time.Time    `json:"timestamp"`
+}
+
+// TerminateResponse represents the result of terminating a backend.
+type TerminateResponse struct {
+	PID        int       `json:"pid"`
+	Terminated bool      `json:"terminated"`
+	Timestamp  time.Time `json:"timestamp"`
+}
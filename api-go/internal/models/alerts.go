@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Alert represents a threshold that is currently breached.
+type Alert struct {
+	Rule            string    `json:"rule"`
+	Severity        string    `json:"severity"`
+	Message         string    `json:"message"`
+	Value           *float64  `json:"value,omitempty"`
+	Threshold       float64   `json:"threshold"`
+	Since           time.Time `json:"since"`
+	DurationSeconds float64   `json:"duration_seconds"`
+}
+
+// AlertsResponse represents the list of active alerts.
+type AlertsResponse struct {
+	Alerts        []Alert    `json:"alerts"`
+	Count         int        `json:"count"`
+	LastEvaluated *time.Time `json:"last_evaluated,omitempty"`
+	Timestamp     time.Time  `json:"timestamp"`
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"time"
+)
+
+// ClusterMember represents one member of the HA cluster. The group,
+// assigned and reported states and health are only known with
+// pg_auto_failover.
+type ClusterMember struct {
+	Name          string     `json:"name"`
+	Role          string     `json:"role"`
+	State         string     `json:"state"`
+	Host          string     `json:"host,omitempty"`
+	Port          int        `json:"port,omitempty"`
+	APIURL        string     `json:"api_url,omitempty"`
+	Timeline      int        `json:"timeline,omitempty"`
+	LagBytes      *int64     `json:"lag_bytes,omitempty"`
+	GroupID       *int       `json:"group_id,omitempty"`
+	AssignedState string     `json:"assigned_state,omitempty"`
+	ReportedState string     `json:"reported_state,omitempty"`
+	Health        string     `json:"health,omitempty"`
+	ReportedAt    *time.Time `json:"reported_at,omitempty"`
+}
+
+// ClusterResponse represents the state of the HA cluster as reported by
+// the cluster manager.
+type ClusterResponse struct {
+	Manager   string            `json:"manager"`
+	Status    string            `json:"status"`
+	Leader    *string           `json:"leader,omitempty"`
+	Paused    bool              `json:"paused"`
+	Members   []ClusterMember   `json:"members"`
+	DCS       *DCSStatus        `json:"dcs,omitempty"`
+	Formation *ClusterFormation `json:"formation,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// ClusterFormation represents the pg_auto_failover formation the cluster
+// belongs to.
+type ClusterFormation struct {
+	ID                 string `json:"id"`
+	Kind               string `json:"kind"`
+	DBName             string `json:"dbname"`
+	Secondary          bool   `json:"secondary"`
+	NumberSyncStandbys int    `json:"number_sync_standbys"`
+}
+
+// DCSStatus represents the health of the configuration store the cluster
+// manager elects its leader in.
+type DCSStatus struct {
+	Type      string        `json:"type"`
+	Healthy   bool          `json:"healthy"`
+	Quorum    bool          `json:"quorum"`
+	Leader    string        `json:"leader,omitempty"`
+	Endpoints []DCSEndpoint `json:"endpoints"`
+}
+
+// DCSEndpoint represents one configured DCS endpoint.
+type DCSEndpoint struct {
+	URL       string  `json:"url"`
+	Healthy   bool    `json:"healthy"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off.
+type MaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason"`
+}
+
+// MaintenanceResponse represents the maintenance mode of the node and,
+// when Patroni manages the cluster, whether automatic failover is paused.
+type MaintenanceResponse struct {
+	Enabled       bool       `json:"enabled"`
+	Reason        string     `json:"reason,omitempty"`
+	Since         *time.Time `json:"since,omitempty"`
+	PatroniPaused *bool      `json:"patroni_paused,omitempty"`
+	Timestamp     time.Time  `json:"timestamp"`
+}
+
+// SwitchoverRequest asks for a planned change of leader. Leader defaults
+// to the current leader; without a candidate the manager picks one.
+type SwitchoverRequest struct {
+	Leader    string `json:"leader"`
+	Candidate string `json:"candidate"`
+}
+
+// FailoverRequest asks for candidate to be promoted. Force skips the
+// replication lag and backup freshness checks.
+type FailoverRequest struct {
+	Candidate string `json:"candidate" binding:"required"`
+	Force     bool   `json:"force"`
+}
+
+// PreconditionFailedResponse is returned when a cluster operation is
+// refused because the cluster is not in a safe state for it.
+type PreconditionFailedResponse struct {
+	Error    string   `json:"error"`
+	Message  string   `json:"message"`
+	Failures []string `json:"failures"`
+}
+
+// NodeState is the role and identity of the monitored database node.
+type NodeState struct {
+	Role             string    `json:"role"`
+	InRecovery       bool      `json:"in_recovery"`
+	SystemIdentifier string    `json:"system_identifier"`
+	Timeline         int       `json:"timeline"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// ClusterEvent represents a change observed by the watcher.
+type ClusterEvent struct {
+	ID        int64                  `json:"id"`
+	Type      string                 `json:"type"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// EventsResponse represents the retained cluster events.
+type EventsResponse struct {
+	Events    []ClusterEvent `json:"events"`
+	Count     int            `json:"count"`
+	Current   *NodeState     `json:"current,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// PromoteRequest is the body of POST /admin/promote. Confirm must be the
+// system identifier of the node, as GET /cluster/identity reports it, so
+// a promotion cannot be sent to the wrong cluster by accident.
+type PromoteRequest struct {
+	Confirm     string `json:"confirm" binding:"required"`
+	WaitSeconds int    `json:"wait_seconds" binding:"omitempty,min=1,max=3600"`
+}
+
+// PromoteResponse describes a completed promotion.
+type PromoteResponse struct {
+	Promoted         bool      `json:"promoted"`
+	SystemIdentifier string    `json:"system_identifier"`
+	PreviousTimeline int       `json:"previous_timeline"`
+	Timeline         int       `json:"timeline"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// TopologyNode represents one node of the discovered replication graph.
+type TopologyNode struct {
+	ID               string `json:"id"`
+	Role             string `json:"role"`
+	Reachable        bool   `json:"reachable"`
+	Error            string `json:"error,omitempty"`
+	SystemIdentifier string `json:"system_identifier,omitempty"`
+	Timeline         int    `json:"timeline,omitempty"`
+	Upstream         string `json:"upstream,omitempty"`
+	UpstreamStatus   string `json:"upstream_status,omitempty"`
+}
+
+// TopologyEdge represents a replication connection between two nodes.
+type TopologyEdge struct {
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	ApplicationName  string   `json:"application_name,omitempty"`
+	State            string   `json:"state,omitempty"`
+	SyncState        string   `json:"sync_state,omitempty"`
+	LagBytes         *int64   `json:"lag_bytes,omitempty"`
+	ReplayLagSeconds *float64 `json:"replay_lag_seconds,omitempty"`
+}
+
+// TopologyResponse represents the replication graph discovered from the
+// configured nodes.
+type TopologyResponse struct {
+	Primary   *string        `json:"primary,omitempty"`
+	Nodes     []TopologyNode `json:"nodes"`
+	Edges     []TopologyEdge `json:"edges"`
+	Warnings  []string       `json:"warnings"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// DRPosition represents how far a node has written or replayed WAL. LSN
+// is the current write position on a primary and the replay position on
+// a standby.
+type DRPosition struct {
+	InRecovery       bool       `json:"in_recovery"`
+	SystemIdentifier string     `json:"system_identifier"`
+	LSN              *string    `json:"lsn,omitempty"`
+	ReceiveLSN       *string    `json:"receive_lsn,omitempty"`
+	LastReplayTime   *time.Time `json:"last_replay_time,omitempty"`
+	Timestamp        time.Time  `json:"timestamp"`
+}
+
+// DRStatusResponse compares the local primary with the DR standby. The
+// lag is the recovery point objective actually achieved: how much WAL,
+// and how many seconds of commits, would be lost if the DR site took over
+// now.
+type DRStatusResponse struct {
+	Site       string      `json:"site"`
+	Source     string      `json:"source"`
+	Status     string      `json:"status"`
+	Message    string      `json:"message,omitempty"`
+	Primary    *DRPosition `json:"primary,omitempty"`
+	Standby    *DRPosition `json:"standby,omitempty"`
+	LagBytes   *int64      `json:"lag_bytes,omitempty"`
+	LagSeconds *float64    `json:"lag_seconds,omitempty"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// TimelineHistoryEntry is one switch recorded in a timeline history file.
+type TimelineHistoryEntry struct {
+	Timeline  uint32 `json:"timeline"`
+	SwitchLSN string `json:"switch_lsn"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// IdentityResponse represents the system identity and timeline of the
+// connected node, for comparing nodes after a failover.
+type IdentityResponse struct {
+	SystemIdentifier   string                 `json:"system_identifier"`
+	Role               string                 `json:"role"`
+	Timeline           int                    `json:"timeline"`
+	CheckpointLSN      string                 `json:"checkpoint_lsn"`
+	RedoLSN            string                 `json:"redo_lsn"`
+	CheckpointTimeline int                    `json:"checkpoint_timeline"`
+	PrevTimeline       int                    `json:"prev_timeline"`
+	CheckpointTime     *time.Time             `json:"checkpoint_time,omitempty"`
+	ControlVersion     int                    `json:"pg_control_version"`
+	CatalogVersion     int                    `json:"catalog_version"`
+	HistoryFile        string                 `json:"history_file,omitempty"`
+	History            []TimelineHistoryEntry `json:"history"`
+	HistoryContents    string                 `json:"history_contents,omitempty"`
+	HistoryError       string                 `json:"history_error,omitempty"`
+	Timestamp          time.Time              `json:"timestamp"`
+}
+
+// TopologyGraphNode is a vertex of the replication graph prepared for
+// rendering.
+type TopologyGraphNode struct {
+	ID        string `json:"id"`
+	Label     string `json:"label"`
+	Role      string `json:"role"`
+	Reachable bool   `json:"reachable"`
+	Timeline  int    `json:"timeline,omitempty"`
+}
+
+// TopologyGraphEdge is a replication connection prepared for rendering.
+type TopologyGraphEdge struct {
+	ID          string `json:"id"`
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Label       string `json:"label,omitempty"`
+	State       string `json:"state,omitempty"`
+	Synchronous bool   `json:"synchronous"`
+}
+
+// TopologyGraphResponse is the replication graph as nodes and edges for
+// dashboards and diagram generators.
+type TopologyGraphResponse struct {
+	Primary   *string             `json:"primary,omitempty"`
+	Nodes     []TopologyGraphNode `json:"nodes"`
+	Edges     []TopologyGraphEdge `json:"edges"`
+	Warnings  []string            `json:"warnings"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+// MonitoredCluster describes one cluster monitored by this instance.
+type MonitoredCluster struct {
+	Name      string `json:"name"`
+	Default   bool   `json:"default"`
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	Stanza    string `json:"stanza"`
+	Reachable bool   `json:"reachable"`
+	Role      string `json:"role,omitempty"`
+	Timeline  int    `json:"timeline,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ClustersResponse lists the monitored clusters, the default one first.
+type ClustersResponse struct {
+	Clusters  []MonitoredCluster `json:"clusters"`
+	Count     int                `json:"count"`
+	Timestamp time.Time          `json:"timestamp"`
+}
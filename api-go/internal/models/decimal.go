@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Decimal is an exact decimal number in its text form, such as a price or
+// a balance. pgx reads and writes it as text for numeric columns, so a
+// value never passes through a float64 between the client and the
+// database. It is sent as a JSON number with the scale of the column,
+// like 12.50.
+type Decimal string
+
+// decimalPattern matches a JSON number.
+var decimalPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// ParseDecimal returns s as a Decimal when it is a number.
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return "", fmt.Errorf("%q is not a decimal number", s)
+	}
+	return Decimal(s), nil
+}
+
+// Float64 returns the nearest float64, for comparisons that need not be
+// exact. The zero Decimal is 0.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(d), 64)
+	return f
+}
+
+// MarshalJSON writes the number as is. The zero Decimal is 0; NaN and
+// other values no JSON number can hold are written as strings.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d == "" {
+		return []byte("0"), nil
+	}
+	if !decimalPattern.MatchString(string(d)) {
+		return json.Marshal(string(d))
+	}
+	return []byte(d), nil
+}
+
+// UnmarshalJSON accepts a number, or a string holding one, keeping every
+// digit. null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Failure points of a demo transfer.
+const (
+	TransferFailAfterDebit   = "after_debit"
+	TransferFailAfterCredit  = "after_credit"
+	TransferFailBeforeCommit = "before_commit"
+)
+
+// TransferRequest represents a transfer between two demo accounts.
+// FailAt aborts the transaction at that point; PauseMs holds it open
+// after the debit, long enough to stop the primary in the middle of it.
+type TransferRequest struct {
+	From    string  `json:"from" binding:"required"`
+	To      string  `json:"to" binding:"required,nefield=From"`
+	Amount  Decimal `json:"amount" binding:"required,gt=0"`
+	FailAt  string  `json:"fail_at,omitempty" binding:"omitempty,oneof=after_debit after_credit before_commit"`
+	PauseMs int     `json:"pause_ms,omitempty" binding:"gte=0,lte=60000"`
+}
+
+// TransferResponse represents a committed transfer and the balances it
+// left.
+type TransferResponse struct {
+	ID          int64     `json:"id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Amount      Decimal   `json:"amount"`
+	FromBalance Decimal   `json:"from_balance"`
+	ToBalance   Decimal   `json:"to_balance"`
+	DurationMs  float64   `json:"duration_ms"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// DemoAccount represents an account of the transfer demo.
+type DemoAccount struct {
+	ID        string    `json:"id"`
+	Balance   Decimal   `json:"balance"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DemoAccountsResponse represents the demo accounts. Total is the sum of
+// the balances, unchanged by transfers unless one was partly applied.
+type DemoAccountsResponse struct {
+	Accounts  []DemoAccount `json:"accounts"`
+	Total     Decimal       `json:"total"`
+	Transfers int64         `json:"transfers"`
+	Timestamp time.Time     `json:"timestamp"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Drill step statuses.
+const (
+	DrillStepSucceeded = "succeeded"
+	DrillStepFailed    = "failed"
+	DrillStepSkipped   = "skipped"
+)
+
+// DrillRequest starts a DR rehearsal. Without a candidate Patroni picks
+// the replica to switch over to.
+type DrillRequest struct {
+	Candidate      string `json:"candidate"`
+	WorkloadWrites int    `json:"workload_writes" binding:"omitempty,min=1,max=10000"`
+	SkipSwitchBack bool   `json:"skip_switch_back"`
+}
+
+// DrillStep records one step of a drill.
+type DrillStep struct {
+	Name            string                 `json:"name"`
+	Status          string                 `json:"status"`
+	Error           string                 `json:"error,omitempty"`
+	StartedAt       *time.Time             `json:"started_at,omitempty"`
+	FinishedAt      *time.Time             `json:"finished_at,omitempty"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+}
+
+// DrillReport is the machine-readable result of a drill, stored as the
+// drill job's result.
+type DrillReport struct {
+	Succeeded       bool        `json:"succeeded"`
+	OriginalLeader  string      `json:"original_leader"`
+	DrillLeader     string      `json:"drill_leader,omitempty"`
+	StartedAt       time.Time   `json:"started_at"`
+	FinishedAt      time.Time   `json:"finished_at"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	Steps           []DrillStep `json:"steps"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// Component health states, ordered from best to worst.
+const (
+	ComponentHealthy   = "healthy"
+	ComponentDegraded  = "degraded"
+	ComponentUnhealthy = "unhealthy"
+	ComponentUnknown   = "unknown"
+)
+
+// ComponentHealth represents the health of a single dependency.
+type ComponentHealth struct {
+	Status    string                 `json:"status"`
+	LatencyMs float64                `json:"latency_ms"`
+	Message   string                 `json:"message,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// DeepHealthResponse represents a per-component health breakdown.
+type DeepHealthResponse struct {
+	Status     string                     `json:"status"`
+	Version    string                     `json:"version"`
+	Components map[string]ComponentHealth `json:"components"`
+	Timestamp  time.Time                  `json:"timestamp"`
+}
+
+// StartupPhase represents the state of one initialization phase.
+type StartupPhase struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// StartupResponse represents the startup probe response.
+type StartupResponse struct {
+	Status    string         `json:"status"`
+	Phases    []StartupPhase `json:"phases"`
+	StartedAt time.Time      `json:"started_at"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// PrimaryUnavailableResponse is returned for writes rejected while the
+// primary is unreachable and reads are served from replicas.
+type PrimaryUnavailableResponse struct {
+	Error                    string     `json:"error"`
+	Message                  string     `json:"message"`
+	ReadOnly                 bool       `json:"read_only"`
+	PrimaryDownSince         *time.Time `json:"primary_down_since,omitempty"`
+	EstimatedRecoverySeconds int        `json:"estimated_recovery_seconds"`
+}
+
+// WriteProbeResponse represents the result of a synthetic write to the
+// primary.
+type WriteProbeResponse struct {
+	Status    string    `json:"status"`
+	Writable  bool      `json:"writable"`
+	Node      string    `json:"node,omitempty"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RoleResponse reports the role of the node for external routers.
+type RoleResponse struct {
+	Role       string    `json:"role"`
+	InRecovery bool      `json:"in_recovery"`
+	CurrentLSN *string   `json:"current_lsn"`
+	Timeline   int       `json:"timeline"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
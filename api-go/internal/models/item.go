@@ -2,25 +2,109 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
+	"strconv"
 	"time"
 )
 
-// Item represents a demo item in the database.
+// Item represents a demo item in the database. DeletedAt is set on items
+// in the trash. Version is bumped by every write and sent as the ETag.
+// Fields, when set, limits the JSON to those fields.
 type Item struct {
-	ID          int64      `json:"id"`
+	ID          ItemID     `json:"id"`
 	Name        string     `json:"name"`
 	Description *string    `json:"description,omitempty"`
-	Price       float64    `json:"price"`
+	Price       Decimal    `json:"price"`
 	IsActive    bool       `json:"is_active"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int64      `json:"-"`
+	Fields      []string   `json:"-"`
+}
+
+// MarshalJSON writes every field of the item, or only those in Fields, in
+// that order, for responses limited with ?fields=.
+func (i Item) MarshalJSON() ([]byte, error) {
+	type item Item
+	data, err := json.Marshal(item(i))
+	if err != nil || i.Fields == nil {
+		return data, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, field := range i.Fields {
+		value, ok := all[field]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ItemID is the key of an item in its text form: a SERIAL number, or a
+// UUIDv7 with ITEMS_KEY_TYPE=uuidv7. It is sent as a JSON number or string
+// accordingly, and pgx reads and writes it as text for either column type.
+type ItemID string
+
+// MarshalJSON writes numeric IDs as numbers and others as strings.
+func (id ItemID) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseInt(string(id), 10, 64); err == nil {
+		return []byte(id), nil
+	}
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON accepts an ID as a number or a string.
+func (id *ItemID) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ItemID(s)
+		return nil
+	}
+	var n json.Number
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+	*id = ItemID(n)
+	return nil
+}
+
+// ItemsPage represents one page of items with the total number of items
+// matching the filter. Next is the URL of the following page, null on the
+// last one; it continues with after_id when the page was requested by key.
+type ItemsPage struct {
+	Items   []Item  `json:"items"`
+	Total   int64   `json:"total"`
+	Skip    int     `json:"skip"`
+	Limit   int     `json:"limit"`
+	AfterID *ItemID `json:"after_id,omitempty"`
+	Next    *string `json:"next"`
 }
 
 // ItemCreate represents the request body for creating an item.
 type ItemCreate struct {
 	Name        string  `json:"name" binding:"required,min=1,max=255"`
 	Description *string `json:"description,omitempty" binding:"omitempty,max=1000"`
-	Price       float64 `json:"price" binding:"required,gte=0"`
+	Price       Decimal `json:"price" binding:"required,gte=0"`
 	IsActive    *bool   `json:"is_active,omitempty"`
 }
 
@@ -28,38 +112,118 @@ type ItemCreate struct {
 type ItemUpdate struct {
 	Name        *string  `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
 	Description *string  `json:"description,omitempty" binding:"omitempty,max=1000"`
-	Price       *float64 `json:"price,omitempty" binding:"omitempty,gte=0"`
+	Price       *Decimal `json:"price,omitempty" binding:"omitempty,gte=0"`
 	IsActive    *bool    `json:"is_active,omitempty"`
 }
 
 // HealthResponse represents a health check response.
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Version   string    `json:"version"`
-	Timestamp time.Time `json:"timestamp"`
+	Status        string      `json:"status"`
+	Version       string      `json:"version"`
+	Commit        string      `json:"commit"`
+	StartedAt     time.Time   `json:"started_at"`
+	UptimeSeconds float64     `json:"uptime_seconds"`
+	ServerVersion *string     `json:"server_version,omitempty"`
+	Runtime       RuntimeInfo `json:"runtime"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// RuntimeInfo represents Go runtime statistics for the API process.
+type RuntimeInfo struct {
+	GoVersion      string `json:"go_version"`
+	GOMAXPROCS     int    `json:"gomaxprocs"`
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64 `json:"heap_sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
 }
 
 // ReadyResponse represents a readiness check response.
 type ReadyResponse struct {
 	Status    string    `json:"status"`
 	Database  string    `json:"database"`
+	Policy    string    `json:"policy,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	ReadOnly  *bool     `json:"read_only,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
 // MetricsResponse represents database metrics.
 type MetricsResponse struct {
-	DatabaseSizeBytes       int64     `json:"database_size_bytes"`
-	ActiveConnections       int       `json:"active_connections"`
-	MaxConnections          int       `json:"max_connections"`
-	ConnectionUsagePercent  float64   `json:"connection_usage_percent"`
-	TransactionsCommitted   int64     `json:"transactions_committed"`
-	TransactionsRolledBack  int64     `json:"transactions_rolled_back"`
-	BlocksRead              int64     `json:"blocks_read"`
-	BlocksHit               int64     `json:"blocks_hit"`
-	CacheHitRatio           float64   `json:"cache_hit_ratio"`
-	ReplicationLagBytes     *int64    `json:"replication_lag_bytes,omitempty"`
-	IsInRecovery            bool      `json:"is_in_recovery"`
-	Timestamp               time.Time `json:"timestamp"`
+	DatabaseSizeBytes      int64        `json:"database_size_bytes"`
+	ActiveConnections      int          `json:"active_connections"`
+	MaxConnections         int          `json:"max_connections"`
+	ConnectionUsagePercent float64      `json:"connection_usage_percent"`
+	TransactionsCommitted  int64        `json:"transactions_committed"`
+	TransactionsRolledBack int64        `json:"transactions_rolled_back"`
+	BlocksRead             int64        `json:"blocks_read"`
+	BlocksHit              int64        `json:"blocks_hit"`
+	CacheHitRatio          float64      `json:"cache_hit_ratio"`
+	TempFiles              int64        `json:"temp_files"`
+	TempBytes              int64        `json:"temp_bytes"`
+	Deadlocks              int64        `json:"deadlocks"`
+	ChecksumFailures       *int64       `json:"checksum_failures,omitempty"`
+	ReplicationLagBytes    *int64       `json:"replication_lag_bytes,omitempty"`
+	ReplicationDelaySecs   *float64     `json:"replication_delay_seconds,omitempty"`
+	LastReplayTimestamp    *time.Time   `json:"last_replay_timestamp,omitempty"`
+	Replicas               []ReplicaLag `json:"replicas,omitempty"`
+	IsInRecovery           bool         `json:"is_in_recovery"`
+	Pool                   *PoolStats   `json:"pool,omitempty"`
+	Timestamp              time.Time    `json:"timestamp"`
+}
+
+// PoolStats represents client-side connection pool utilization.
+type PoolStats struct {
+	AcquiredConns     int32   `json:"acquired_conns"`
+	IdleConns         int32   `json:"idle_conns"`
+	TotalConns        int32   `json:"total_conns"`
+	MaxConns          int32   `json:"max_conns"`
+	AcquireCount      int64   `json:"acquire_count"`
+	EmptyAcquireCount int64   `json:"empty_acquire_count"`
+	AvgAcquireWaitMs  float64 `json:"avg_acquire_wait_ms"`
+}
+
+// ReplicaLag represents one standby's lag as seen from the primary.
+type ReplicaLag struct {
+	ApplicationName  string   `json:"application_name"`
+	ClientAddr       *string  `json:"client_addr,omitempty"`
+	State            string   `json:"state"`
+	SyncState        string   `json:"sync_state"`
+	ReplayLagBytes   *int64   `json:"replay_lag_bytes,omitempty"`
+	WriteLagSeconds  *float64 `json:"write_lag_seconds,omitempty"`
+	FlushLagSeconds  *float64 `json:"flush_lag_seconds,omitempty"`
+	ReplayLagSeconds *float64 `json:"replay_lag_seconds,omitempty"`
+}
+
+// DatabaseMetrics represents metrics for one database in the cluster.
+type DatabaseMetrics struct {
+	Name                   string  `json:"name"`
+	SizeBytes              int64   `json:"size_bytes"`
+	Connections            int     `json:"connections"`
+	ConnectionLimit        int     `json:"connection_limit"`
+	TransactionsCommitted  int64   `json:"transactions_committed"`
+	TransactionsRolledBack int64   `json:"transactions_rolled_back"`
+	BlocksRead             int64   `json:"blocks_read"`
+	BlocksHit              int64   `json:"blocks_hit"`
+	CacheHitRatio          float64 `json:"cache_hit_ratio"`
+	TempFiles              int64   `json:"temp_files"`
+	TempBytes              int64   `json:"temp_bytes"`
+	Deadlocks              int64   `json:"deadlocks"`
+}
+
+// DatabasesMetricsResponse represents per-database metrics.
+type DatabasesMetricsResponse struct {
+	Databases []DatabaseMetrics `json:"databases"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// MetricsHistoryResponse represents sampled metrics over a time window.
+type MetricsHistoryResponse struct {
+	IntervalSeconds float64           `json:"interval_seconds"`
+	WindowSeconds   float64           `json:"window_seconds"`
+	Samples         []MetricsResponse `json:"samples"`
+	Timestamp       time.Time         `json:"timestamp"`
 }
 
 // BackupInfo represents information about a single backup.
@@ -70,24 +234,72 @@ type BackupInfo struct {
 	StopTime          *time.Time `json:"stop_time,omitempty"`
 	SizeBytes         *int64     `json:"size_bytes,omitempty"`
 	DatabaseSizeBytes *int64     `json:"database_size_bytes,omitempty"`
+
+	// Delta sizes are the bytes copied by this backup, as opposed to the
+	// full size of the restored cluster.
+	DeltaBytes           *int64            `json:"delta_bytes,omitempty"`
+	RepositoryDeltaBytes *int64            `json:"repository_delta_bytes,omitempty"`
+	StartLSN             *string           `json:"start_lsn,omitempty"`
+	StopLSN              *string           `json:"stop_lsn,omitempty"`
+	StartWAL             *string           `json:"start_wal,omitempty"`
+	StopWAL              *string           `json:"stop_wal,omitempty"`
+	Timeline             *uint32           `json:"timeline,omitempty"`
+	Prior                *string           `json:"prior,omitempty"`
+	Reference            []string          `json:"reference,omitempty"`
+	Error                *bool             `json:"error,omitempty"`
+	Annotation           map[string]string `json:"annotation,omitempty"`
+	PgBackRestVersion    *string           `json:"pgbackrest_version,omitempty"`
+	RepoKey              *int              `json:"repo_key,omitempty"`
+}
+
+// RepositoryInfo represents one pgBackRest repository of a stanza.
+type RepositoryInfo struct {
+	Key           int     `json:"key"`
+	Type          *string `json:"type,omitempty"`
+	Cipher        string  `json:"cipher"`
+	Encrypted     bool    `json:"encrypted"`
+	Status        string  `json:"status"`
+	StatusCode    int     `json:"status_code"`
+	StatusMessage *string `json:"status_message,omitempty"`
+
+	// Compression and file layout of new backups, from the local
+	// pgbackrest configuration.
+	CompressType     *string `json:"compress_type,omitempty"`
+	CompressLevel    *int    `json:"compress_level,omitempty"`
+	BlockIncremental *bool   `json:"block_incremental,omitempty"`
+	Bundle           *bool   `json:"bundle,omitempty"`
 }
 
 // WALArchiveInfo represents WAL archive information.
 type WALArchiveInfo struct {
-	MinWAL *string `json:"min_wal,omitempty"`
-	MaxWAL *string `json:"max_wal,omitempty"`
+	MinWAL         *string `json:"min_wal,omitempty"`
+	MaxWAL         *string `json:"max_wal,omitempty"`
+	CurrentWALFile *string `json:"current_wal_file,omitempty"`
+	GapFiles       *int64  `json:"gap_files,omitempty"`
+	GapBytes       *int64  `json:"gap_bytes,omitempty"`
 }
 
 // BackupResponse represents the complete backup status.
 type BackupResponse struct {
-	Stanza         string          `json:"stanza"`
-	Status         string          `json:"status"`
-	StatusMessage  *string         `json:"status_message,omitempty"`
-	Backups        []BackupInfo    `json:"backups"`
-	WALArchive     *WALArchiveInfo `json:"wal_archive,omitempty"`
-	LastFullBackup *time.Time      `json:"last_full_backup,omitempty"`
-	LastDiffBackup *time.Time      `json:"last_diff_backup,omitempty"`
-	Timestamp      time.Time       `json:"timestamp"`
+	Provider       string           `json:"provider"`
+	Stanza         string           `json:"stanza"`
+	Status         string           `json:"status"`
+	StatusMessage  *string          `json:"status_message,omitempty"`
+	Repositories   []RepositoryInfo `json:"repositories,omitempty"`
+	Backups        []BackupInfo     `json:"backups"`
+	WALArchive     *WALArchiveInfo  `json:"wal_archive,omitempty"`
+	LastFullBackup *time.Time       `json:"last_full_backup,omitempty"`
+	LastDiffBackup *time.Time       `json:"last_diff_backup,omitempty"`
+	LastIncrBackup *time.Time       `json:"last_incr_backup,omitempty"`
+	AgeSeconds     *float64         `json:"age_seconds,omitempty"`
+	Timestamp      time.Time        `json:"timestamp"`
+}
+
+// BackupsResponse represents backup status for every configured stanza.
+type BackupsResponse struct {
+	Status    string           `json:"status"`
+	Stanzas   []BackupResponse `json:"stanzas"`
+	Timestamp time.Time        `json:"timestamp"`
 }
 
 // ErrorResponse represents an API error.
@@ -95,3 +307,131 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
 }
+
+// ItemsBulkBatch represents the outcome of loading one batch of items.
+// First is the position of its first item in the request.
+type ItemsBulkBatch struct {
+	Index    int    `json:"index"`
+	First    int    `json:"first"`
+	Count    int    `json:"count"`
+	Inserted int64  `json:"inserted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ItemsBulkResponse represents the outcome of a bulk load. Error is set
+// when the body could not be read to the end; the batches before it are
+// loaded.
+type ItemsBulkResponse struct {
+	Received   int              `json:"received"`
+	Inserted   int64            `json:"inserted"`
+	Failed     int              `json:"failed"`
+	Batches    []ItemsBulkBatch `json:"batches"`
+	Error      string           `json:"error,omitempty"`
+	DurationMs float64          `json:"duration_ms"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// ItemsBulkDeleteRequest represents the items to delete at once.
+type ItemsBulkDeleteRequest struct {
+	IDs []ItemID `json:"ids" binding:"required,min=1,max=10000"`
+}
+
+// ItemsBulkUpdateRequest represents the items to update at once and the
+// fields to set on each.
+type ItemsBulkUpdateRequest struct {
+	IDs   []ItemID   `json:"ids" binding:"required,min=1,max=10000"`
+	Patch ItemUpdate `json:"patch"`
+}
+
+// Conflict strategies of a bulk upsert: what happens to an item whose ID
+// already exists.
+const (
+	UpsertOverwrite = "overwrite"
+	UpsertSkip      = "skip"
+	UpsertNewer     = "newer"
+)
+
+// ItemUpsert represents one item of a bulk upsert. The timestamps default
+// to the time of the request.
+type ItemUpsert struct {
+	ID          ItemID     `json:"id" binding:"required"`
+	Name        string     `json:"name" binding:"required,min=1,max=255"`
+	Description *string    `json:"description,omitempty" binding:"omitempty,max=1000"`
+	Price       Decimal    `json:"price" binding:"required,gte=0"`
+	IsActive    *bool      `json:"is_active,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// ItemsUpsertRequest represents items to insert or update by ID, and the
+// strategy for those that exist, overwrite by default.
+type ItemsUpsertRequest struct {
+	Items      []ItemUpsert `json:"items" binding:"required,min=1,max=1000,dive"`
+	OnConflict string       `json:"on_conflict,omitempty" binding:"omitempty,oneof=overwrite skip newer"`
+}
+
+// ItemsUpsertResponse represents the outcome of a bulk upsert: the IDs of
+// the items inserted, updated, and left as they were by the strategy.
+type ItemsUpsertResponse struct {
+	OnConflict string    `json:"on_conflict"`
+	Inserted   []ItemID  `json:"inserted"`
+	Updated    []ItemID  `json:"updated"`
+	Skipped    []ItemID  `json:"skipped"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ItemsBatchGetRequest represents the items to get at once.
+type ItemsBatchGetRequest struct {
+	IDs []ItemID `json:"ids" binding:"required,min=1,max=1000"`
+}
+
+// ItemsBatchResponse represents items fetched by ID, keyed by ID, and the
+// requested IDs no item has. Items in the trash count as missing.
+type ItemsBatchResponse struct {
+	Items   map[ItemID]Item `json:"items"`
+	Missing []ItemID        `json:"missing"`
+}
+
+// ItemsBulkResult represents the outcome of a bulk update or delete.
+// Missing lists the requested IDs no item has.
+type ItemsBulkResult struct {
+	Requested int       `json:"requested"`
+	Affected  int       `json:"affected"`
+	Missing   []ItemID  `json:"missing"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ItemImportRejection represents a row of an import that was not loaded.
+type ItemImportRejection struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ItemsImportResponse represents the outcome of an import. Truncated is
+// set when more rows were rejected than are listed.
+type ItemsImportResponse struct {
+	Format     string                `json:"format"`
+	Received   int                   `json:"received"`
+	Imported   int64                 `json:"imported"`
+	Rejected   int                   `json:"rejected"`
+	Rejections []ItemImportRejection `json:"rejections"`
+	Truncated  bool                  `json:"truncated"`
+	DurationMs float64               `json:"duration_ms"`
+	Timestamp  time.Time             `json:"timestamp"`
+}
+
+// ItemVersion represents one recorded version of an item: its state after
+// an insert, update or permanent delete. RecordedAt and XID identify the
+// write for point-in-time recovery targets.
+type ItemVersion struct {
+	ItemID      ItemID     `json:"item_id"`
+	Version     int        `json:"version"`
+	Operation   string     `json:"operation"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	Price       Decimal    `json:"price"`
+	IsActive    bool       `json:"is_active"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	RecordedAt  time.Time  `json:"recorded_at"`
+	XID         int64      `json:"xid"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Job represents an asynchronous operation.
+type Job struct {
+	ID              string                 `json:"id"`
+	Type            string                 `json:"type"`
+	Status          string                 `json:"status"`
+	Message         string                 `json:"message,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+	Result          interface{}            `json:"result,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	StartedAt       *time.Time             `json:"started_at,omitempty"`
+	FinishedAt      *time.Time             `json:"finished_at,omitempty"`
+	DurationSeconds *float64               `json:"duration_seconds,omitempty"`
+}
+
+// JobsResponse represents a list of jobs.
+type JobsResponse struct {
+	Jobs      []Job     `json:"jobs"`
+	Count     int       `json:"count"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JobHistoryEntry represents a finished job from the persistent catalog.
+type JobHistoryEntry struct {
+	Job
+	Trigger string `json:"trigger"`
+	LogURL  string `json:"log_url,omitempty"`
+}
+
+// JobHistoryResponse represents a page of the job catalog.
+type JobHistoryResponse struct {
+	Entries   []JobHistoryEntry `json:"entries"`
+	Count     int               `json:"count"`
+	Total     int               `json:"total"`
+	Limit     int               `json:"limit"`
+	Offset    int               `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+}
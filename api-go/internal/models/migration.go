@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// MigrationStatus represents one schema migration. AppliedAt is null while
+// it is pending.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Checksum  string     `json:"checksum"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// MigrationsResponse represents the schema migrations. Version is the
+// highest applied one and Applied lists those applied by the request.
+type MigrationsResponse struct {
+	Version    int               `json:"version"`
+	Pending    int               `json:"pending"`
+	Applied    []MigrationStatus `json:"applied,omitempty"`
+	Migrations []MigrationStatus `json:"migrations"`
+	Timestamp  time.Time         `json:"timestamp"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Order statuses.
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Order represents an order of items. Total is the sum of its lines,
+// written in the same transaction.
+type Order struct {
+	ID        int64       `json:"id"`
+	Status    string      `json:"status"`
+	Total     Decimal     `json:"total"`
+	Lines     []OrderLine `json:"lines,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// OrderLine represents a quantity of one item at its price when ordered.
+type OrderLine struct {
+	ItemID    ItemID  `json:"item_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice Decimal `json:"unit_price"`
+}
+
+// OrderCreate represents the request body for placing an order.
+type OrderCreate struct {
+	Lines []OrderLineCreate `json:"lines" binding:"required,min=1,max=100,dive"`
+}
+
+// OrderLineCreate represents one line of a new order.
+type OrderLineCreate struct {
+	ItemID   ItemID `json:"item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,lte=10000"`
+}
+
+// OrdersIntegrityResponse represents a check of the order tables: orders
+// whose total differs from the sum of their lines, or without lines, can
+// only come from a write that was partly lost.
+type OrdersIntegrityResponse struct {
+	Status          string    `json:"status"`
+	Orders          int64     `json:"orders"`
+	Lines           int64     `json:"lines"`
+	TotalMismatches int64     `json:"total_mismatches"`
+	EmptyOrders     int64     `json:"empty_orders"`
+	Timestamp       time.Time `json:"timestamp"`
+}
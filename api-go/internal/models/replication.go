@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// SyncReplicationResponse reports whether the synchronous standbys the
+// primary waits for are connected. Names lists synchronous_standby_names
+// as parsed; Connected counts the listed standbys currently streaming.
+type SyncReplicationResponse struct {
+	Status                  string       `json:"status"`
+	Message                 string       `json:"message"`
+	SynchronousStandbyNames string       `json:"synchronous_standby_names"`
+	SynchronousCommit       string       `json:"synchronous_commit"`
+	Method                  string       `json:"method,omitempty"`
+	Required                int          `json:"required"`
+	Names                   []string     `json:"names"`
+	Connected               int          `json:"connected"`
+	WritesMayHang           bool         `json:"writes_may_hang"`
+	Standbys                []ReplicaLag `json:"standbys"`
+	Timestamp               time.Time    `json:"timestamp"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// Restore target types.
+const (
+	RestoreTargetLatest = "latest"
+	RestoreTargetBackup = "backup"
+	RestoreTargetTime   = "time"
+	RestoreTargetLSN    = "lsn"
+)
+
+// RestoreRequest represents a restore or point-in-time recovery request.
+// BackupLabel, Timestamp and LSN are used by the matching target type.
+type RestoreRequest struct {
+	Target       string     `json:"target" form:"target" binding:"required,oneof=latest backup time lsn"`
+	BackupLabel  string     `json:"backup_label,omitempty" form:"backup_label"`
+	Timestamp    *time.Time `json:"timestamp,omitempty" form:"timestamp"`
+	LSN          string     `json:"lsn,omitempty" form:"lsn"`
+	TargetAction string     `json:"target_action,omitempty" form:"target_action" binding:"omitempty,oneof=promote pause shutdown"`
+	Delta        bool       `json:"delta" form:"delta"`
+	DryRun       bool       `json:"dry_run" form:"-"`
+}
+
+// RestorePlan describes the backup set and WAL a restore would use.
+//
+// WALStart and WALStop bound the archived WAL replayed after the backup is
+// copied; WALStop is the newest archived segment when the end of recovery
+// is only known at replay time. Feasible is false when the repository
+// cannot reach the target; Warnings explains why and notes timeline
+// switches.
+type RestorePlan struct {
+	Backup                 *BackupInfo `json:"backup,omitempty"`
+	BackupChain            []string    `json:"backup_chain"`
+	WALStart               *string     `json:"wal_start,omitempty"`
+	WALStop                *string     `json:"wal_stop,omitempty"`
+	BackupTimeline         *uint32     `json:"backup_timeline,omitempty"`
+	ArchiveTimeline        *uint32     `json:"archive_timeline,omitempty"`
+	RecoveryTargetTimeline string      `json:"recovery_target_timeline,omitempty"`
+	Feasible               bool        `json:"feasible"`
+	Warnings               []string    `json:"warnings"`
+}
+
+// RestorePlanResponse describes what a restore would do, returned for
+// dry runs and by GET /restore/plan.
+type RestorePlanResponse struct {
+	DryRun    bool         `json:"dry_run"`
+	Provider  string       `json:"provider"`
+	Stanza    string       `json:"stanza"`
+	DataDir   string       `json:"data_dir,omitempty"`
+	Command   []string     `json:"command"`
+	Plan      *RestorePlan `json:"plan,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// RecoveryConfigResponse shows how a standby is set up to follow the
+// primary and what would stop it. Settings the API's role cannot read are
+// listed in unreadable and left empty. PrimaryConninfo has its passwords
+// redacted.
+type RecoveryConfigResponse struct {
+	Status                string            `json:"status"`
+	InRecovery            bool              `json:"in_recovery"`
+	StandbySignal         *bool             `json:"standby_signal"`
+	RecoverySignal        *bool             `json:"recovery_signal"`
+	PrimaryConninfo       string            `json:"primary_conninfo"`
+	PrimarySlotName       string            `json:"primary_slot_name"`
+	RestoreCommand        string            `json:"restore_command"`
+	ArchiveCleanupCommand string            `json:"archive_cleanup_command"`
+	RecoveryMinApplyDelay string            `json:"recovery_min_apply_delay"`
+	HotStandby            string            `json:"hot_standby"`
+	RecoveryTargets       map[string]string `json:"recovery_targets"`
+	Unreadable            []string          `json:"unreadable,omitempty"`
+	Issues                []string          `json:"issues"`
+	Timestamp             time.Time         `json:"timestamp"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// BackupScheduleEntry represents one scheduled backup type.
+type BackupScheduleEntry struct {
+	Type          string     `json:"type"`
+	Schedule      string     `json:"schedule"`
+	NextRun       time.Time  `json:"next_run"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastJobID     string     `json:"last_job_id,omitempty"`
+	LastJobStatus string     `json:"last_job_status,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// BackupScheduleResponse represents the backup scheduler state.
+type BackupScheduleResponse struct {
+	Enabled   bool                  `json:"enabled"`
+	Stanza    string                `json:"stanza"`
+	Entries   []BackupScheduleEntry `json:"entries"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+// BackupEvent is the payload of backup lifecycle notifications.
+type BackupEvent struct {
+	Stanza          string     `json:"stanza"`
+	Type            string     `json:"type"`
+	Scheduled       bool       `json:"scheduled"`
+	Label           string     `json:"label,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	DurationSeconds *float64   `json:"duration_seconds,omitempty"`
+	SizeBytes       *int64     `json:"size_bytes,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// StanzaRequest selects the stanza of a stanza management operation.
+type StanzaRequest struct {
+	Stanza string `json:"stanza"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Setting represents one server configuration parameter from pg_settings.
+type Setting struct {
+	Name           string  `json:"name"`
+	Setting        string  `json:"setting"`
+	Unit           *string `json:"unit,omitempty"`
+	Category       string  `json:"category"`
+	Description    string  `json:"description"`
+	Context        string  `json:"context"`
+	Type           string  `json:"type"`
+	Source         string  `json:"source"`
+	BootValue      *string `json:"boot_value,omitempty"`
+	ResetValue     *string `json:"reset_value,omitempty"`
+	NonDefault     bool    `json:"non_default"`
+	PendingRestart bool    `json:"pending_restart"`
+}
+
+// SettingsResponse represents the settings listing.
+type SettingsResponse struct {
+	Settings            []Setting `json:"settings"`
+	Total               int       `json:"total"`
+	NonDefaultCount     int       `json:"non_default_count"`
+	PendingRestartCount int       `json:"pending_restart_count"`
+	Timestamp           time.Time `json:"timestamp"`
+}
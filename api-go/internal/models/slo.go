@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// HeartbeatTarget represents the newest heartbeat visible on a standby.
+type HeartbeatTarget struct {
+	Name       string     `json:"name"`
+	Seq        int64      `json:"seq"`
+	WrittenAt  *time.Time `json:"written_at,omitempty"`
+	CheckedAt  time.Time  `json:"checked_at"`
+	LagSeconds float64    `json:"lag_seconds"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// HeartbeatStatus represents the heartbeat writer.
+type HeartbeatStatus struct {
+	IntervalSeconds float64    `json:"interval_seconds"`
+	Seq             int64      `json:"seq"`
+	LastWrite       *time.Time `json:"last_write,omitempty"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// RPOStatus is the achieved recovery point: the most committed time any
+// standby is missing.
+type RPOStatus struct {
+	Seconds       *float64          `json:"seconds,omitempty"`
+	TargetSeconds float64           `json:"target_seconds,omitempty"`
+	Met           *bool             `json:"met,omitempty"`
+	Targets       []HeartbeatTarget `json:"targets"`
+}
+
+// Outage represents a period without a writable primary.
+type Outage struct {
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Seconds   float64    `json:"seconds"`
+}
+
+// RTOStatus is the achieved recovery time: how long writes were, or have
+// been, impossible.
+type RTOStatus struct {
+	Seconds       *float64 `json:"seconds,omitempty"`
+	TargetSeconds float64  `json:"target_seconds,omitempty"`
+	Met           *bool    `json:"met,omitempty"`
+	CurrentOutage *Outage  `json:"current_outage,omitempty"`
+	LastOutage    *Outage  `json:"last_outage,omitempty"`
+	Outages       int      `json:"outages"`
+}
+
+// SLOResponse represents the measured RPO and RTO.
+type SLOResponse struct {
+	Heartbeat HeartbeatStatus `json:"heartbeat"`
+	RPO       RPOStatus       `json:"rpo"`
+	RTO       RTOStatus       `json:"rto"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// TableStats represents size and maintenance statistics for one table.
+type TableStats struct {
+	Schema              string     `json:"schema"`
+	Name                string     `json:"name"`
+	TotalBytes          int64      `json:"total_bytes"`
+	TableBytes          int64      `json:"table_bytes"`
+	IndexBytes          int64      `json:"index_bytes"`
+	LiveTuples          int64      `json:"live_tuples"`
+	DeadTuples          int64      `json:"dead_tuples"`
+	DeadTupleRatio      float64    `json:"dead_tuple_ratio"`
+	EstimatedBloatBytes int64      `json:"estimated_bloat_bytes"`
+	SeqScans            int64      `json:"seq_scans"`
+	IndexScans          *int64     `json:"index_scans,omitempty"`
+	LastVacuum          *time.Time `json:"last_vacuum,omitempty"`
+	LastAutovacuum      *time.Time `json:"last_autovacuum,omitempty"`
+	LastAnalyze         *time.Time `json:"last_analyze,omitempty"`
+	LastAutoanalyze     *time.Time `json:"last_autoanalyze,omitempty"`
+}
+
+// TablesResponse represents the table statistics listing.
+type TablesResponse struct {
+	Sort      string       `json:"sort"`
+	Limit     int          `json:"limit"`
+	Tables    []TableStats `json:"tables"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// IndexStats represents usage statistics for one index.
+type IndexStats struct {
+	Schema         string `json:"schema"`
+	Table          string `json:"table"`
+	Name           string `json:"name"`
+	SizeBytes      int64  `json:"size_bytes"`
+	Scans          int64  `json:"scans"`
+	TuplesRead     int64  `json:"tuples_read"`
+	TuplesFetched  int64  `json:"tuples_fetched"`
+	BlocksRead     int64  `json:"blocks_read"`
+	BlocksHit      int64  `json:"blocks_hit"`
+	IsUnique       bool   `json:"is_unique"`
+	IsPrimary      bool   `json:"is_primary"`
+	PossiblyUnused bool   `json:"possibly_unused"`
+	Definition     string `json:"definition"`
+}
+
+// IndexesResponse represents the index usage listing.
+type IndexesResponse struct {
+	StatsReset  *time.Time   `json:"stats_reset,omitempty"`
+	Indexes     []IndexStats `json:"indexes"`
+	UnusedCount int          `json:"unused_count"`
+	UnusedBytes int64        `json:"unused_bytes"`
+	Timestamp   time.Time    `json:"timestamp"`
+}
+
+// VacuumProgress represents a vacuum currently running on a table.
+type VacuumProgress struct {
+	PID                int      `json:"pid"`
+	Database           string   `json:"database"`
+	Table              string   `json:"table"`
+	Phase              string   `json:"phase"`
+	IsAutovacuum       bool     `json:"is_autovacuum"`
+	HeapBlocksTotal    int64    `json:"heap_blocks_total"`
+	HeapBlocksScanned  int64    `json:"heap_blocks_scanned"`
+	HeapBlocksVacuumed int64    `json:"heap_blocks_vacuumed"`
+	IndexVacuumCount   int64    `json:"index_vacuum_count"`
+	PercentScanned     float64  `json:"percent_scanned"`
+	RunningSeconds     *float64 `json:"running_seconds,omitempty"`
+}
+
+// TableVacuumActivity represents recent vacuum activity for a table.
+type TableVacuumActivity struct {
+	Schema               string     `json:"schema"`
+	Name                 string     `json:"name"`
+	DeadTuples           int64      `json:"dead_tuples"`
+	ModifiedSinceAnalyze int64      `json:"modified_since_analyze"`
+	VacuumCount          int64      `json:"vacuum_count"`
+	AutovacuumCount      int64      `json:"autovacuum_count"`
+	LastVacuum           *time.Time `json:"last_vacuum,omitempty"`
+	LastAutovacuum       *time.Time `json:"last_autovacuum,omitempty"`
+	AutovacuumDue        bool       `json:"autovacuum_due"`
+}
+
+// VacuumResponse represents vacuum progress and recent activity.
+type VacuumResponse struct {
+	InProgress []VacuumProgress      `json:"in_progress"`
+	Recent     []TableVacuumActivity `json:"recent"`
+	Timestamp  time.Time             `json:"timestamp"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// SummaryCluster summarizes node availability.
+type SummaryCluster struct {
+	PrimaryAvailable  bool       `json:"primary_available"`
+	PrimaryDownSince  *time.Time `json:"primary_down_since,omitempty"`
+	ReplicasTotal     int        `json:"replicas_total"`
+	ReplicasAvailable int        `json:"replicas_available"`
+}
+
+// SummaryBackups summarizes the latest backup status.
+type SummaryBackups struct {
+	Status         string     `json:"status"`
+	Count          int        `json:"count"`
+	LastFullBackup *time.Time `json:"last_full_backup,omitempty"`
+	LastDiffBackup *time.Time `json:"last_diff_backup,omitempty"`
+	LastIncrBackup *time.Time `json:"last_incr_backup,omitempty"`
+	RefreshedAt    time.Time  `json:"refreshed_at"`
+}
+
+// SummaryAlert is an active alert, without its details.
+type SummaryAlert struct {
+	Rule     string    `json:"rule"`
+	Severity string    `json:"severity"`
+	Since    time.Time `json:"since"`
+}
+
+// SummaryAlerts summarizes the active alerts.
+type SummaryAlerts struct {
+	Count    int            `json:"count"`
+	Critical int            `json:"critical"`
+	Active   []SummaryAlert `json:"active"`
+}
+
+// SummaryResponse is a compact status document for wall-board pollers.
+type SummaryResponse struct {
+	Status      string                 `json:"status"`
+	Version     string                 `json:"version"`
+	Components  map[string]string      `json:"components"`
+	Cluster     SummaryCluster         `json:"cluster"`
+	Replication map[string]interface{} `json:"replication,omitempty"`
+	Backups     *SummaryBackups        `json:"backups,omitempty"`
+	Alerts      *SummaryAlerts         `json:"alerts,omitempty"`
+	RefreshedAt time.Time              `json:"refreshed_at"`
+	AgeSeconds  float64                `json:"age_seconds"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// BackupTrendPoint is one completed backup recorded in the backup history.
+type BackupTrendPoint struct {
+	Label               string    `json:"label"`
+	Type                string    `json:"type"`
+	StartTime           time.Time `json:"start_time"`
+	StopTime            time.Time `json:"stop_time"`
+	DurationSeconds     float64   `json:"duration_seconds"`
+	SizeBytes           *int64    `json:"size_bytes,omitempty"`
+	RepositorySizeBytes *int64    `json:"repository_size_bytes,omitempty"`
+	CompressionRatio    *float64  `json:"compression_ratio,omitempty"`
+}
+
+// BackupTrendSummary summarizes the growth of one backup type.
+type BackupTrendSummary struct {
+	Count                 int      `json:"count"`
+	LatestSizeBytes       *int64   `json:"latest_size_bytes,omitempty"`
+	SizeGrowthBytesPerDay *float64 `json:"size_growth_bytes_per_day,omitempty"`
+	AvgDurationSeconds    *float64 `json:"avg_duration_seconds,omitempty"`
+	DurationGrowthPerDay  *float64 `json:"duration_growth_seconds_per_day,omitempty"`
+	AvgCompressionRatio   *float64 `json:"avg_compression_ratio,omitempty"`
+}
+
+// BackupTrendsResponse represents the recorded history of one backup type.
+type BackupTrendsResponse struct {
+	Stanza    string             `json:"stanza"`
+	Type      string             `json:"type"`
+	Summary   BackupTrendSummary `json:"summary"`
+	Points    []BackupTrendPoint `json:"points"`
+	Timestamp time.Time          `json:"timestamp"`
+}
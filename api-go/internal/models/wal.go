@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// WALArchiverResponse represents WAL archiver status from pg_stat_archiver.
+type WALArchiverResponse struct {
+	ArchiveMode      string     `json:"archive_mode"`
+	ArchivedCount    int64      `json:"archived_count"`
+	LastArchivedWAL  *string    `json:"last_archived_wal,omitempty"`
+	LastArchivedTime *time.Time `json:"last_archived_time,omitempty"`
+	FailedCount      int64      `json:"failed_count"`
+	LastFailedWAL    *string    `json:"last_failed_wal,omitempty"`
+	LastFailedTime   *time.Time `json:"last_failed_time,omitempty"`
+	StatsReset       *time.Time `json:"stats_reset,omitempty"`
+	IsInRecovery     bool       `json:"is_in_recovery"`
+	CurrentLSN       *string    `json:"current_lsn,omitempty"`
+	CurrentWALFile   *string    `json:"current_wal_file,omitempty"`
+	ArchiveLagFiles  *int64     `json:"archive_lag_files,omitempty"`
+	ArchiveLagBytes  *int64     `json:"archive_lag_bytes,omitempty"`
+	Timestamp        time.Time  `json:"timestamp"`
+}
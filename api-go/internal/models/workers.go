@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// WorkerStatus represents one background worker. StalledAfter is when the
+// worker counts as stalled unless it finishes another run; it is omitted
+// for workers driven by events, which never stall.
+type WorkerStatus struct {
+	Name                string     `json:"name"`
+	IntervalSeconds     float64    `json:"interval_seconds"`
+	LastRun             *time.Time `json:"last_run"`
+	LastDurationSeconds float64    `json:"last_duration_seconds"`
+	LastError           string     `json:"last_error,omitempty"`
+	Runs                int64      `json:"runs"`
+	Failures            int64      `json:"failures"`
+	StalledAfter        *time.Time `json:"stalled_after,omitempty"`
+	Stalled             bool       `json:"stalled"`
+}
+
+// WorkersResponse represents the background workers.
+type WorkersResponse struct {
+	Workers   []WorkerStatus `json:"workers"`
+	Count     int            `json:"count"`
+	Stalled   int            `json:"stalled"`
+	Timestamp time.Time      `json:"timestamp"`
+}
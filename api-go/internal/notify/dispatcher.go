@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+// Chat services messages can be posted to through incoming webhooks.
+const (
+	ChatSlack = "slack"
+	ChatTeams = "teams"
+)
+
+// DefaultMessageTemplate renders chat messages when none is configured.
+const DefaultMessageTemplate = "[{{.App}}] {{.Message}}"
+
+// Message is what the message template is rendered with.
+type Message struct {
+	App       string
+	Event     string
+	Message   string
+	Data      map[string]interface{}
+	Timestamp time.Time
+}
+
+// Chat is an incoming webhook of a chat service.
+type Chat struct {
+	Kind string
+	URL  string
+}
+
+// Dispatcher turns cluster events into notifications: a signed JSON
+// payload to every webhook of the notifier and a rendered message to each
+// chat. Only the configured event types are dispatched.
+type Dispatcher struct {
+	app      string
+	webhooks *Notifier
+	chats    []Chat
+	message  *template.Template
+	types    map[string]bool
+}
+
+// NewDispatcher creates a dispatcher for the given event types. Chats are
+// posted to with the timeout of webhooks, which must not be nil. An empty
+// message template uses DefaultMessageTemplate.
+func NewDispatcher(app string, webhooks *Notifier, chats []Chat, messageTemplate string, types []string) (*Dispatcher, error) {
+	tmpl, err := ParseMessageTemplate(messageTemplate)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{
+		app:      app,
+		webhooks: webhooks,
+		message:  tmpl,
+		types:    make(map[string]bool, len(types)),
+	}
+	for _, c := range chats {
+		if c.URL != "" {
+			d.chats = append(d.chats, c)
+		}
+	}
+	for _, t := range types {
+		d.types[t] = true
+	}
+	return d, nil
+}
+
+// ParseMessageTemplate parses a text/template rendered with a Message.
+func ParseMessageTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultMessageTemplate
+	}
+	tmpl, err := template.New("message").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification message template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Enabled reports whether there is anywhere to send notifications to.
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && (d.webhooks.Enabled() || len(d.chats) > 0)
+}
+
+// UseWorkers records every webhook and chat delivery as a run of the
+// notifier worker in registry. Call it before the first Dispatch.
+func (d *Dispatcher) UseWorkers(registry *workers.Registry) {
+	if d.Enabled() {
+		d.webhooks.worker = registry.Register(WorkerName, 0)
+	}
+}
+
+// Dispatch sends one event in the background. Webhooks receive it as
+// "cluster.<event>" with the event data and the rendered message.
+func (d *Dispatcher) Dispatch(event, message string, data map[string]interface{}, timestamp time.Time) {
+	if !d.Enabled() || !d.types[event] {
+		return
+	}
+
+	text, err := d.render(Message{App: d.app, Event: event, Message: message, Data: data, Timestamp: timestamp})
+	if err != nil {
+		slog.Warn("Failed to render notification", "event", event, "error", err)
+		text = message
+	}
+
+	d.webhooks.Send("cluster."+event, map[string]interface{}{
+		"message": text,
+		"data":    data,
+	})
+
+	for _, c := range d.chats {
+		body, err := json.Marshal(chatPayload(c.Kind, event, text))
+		if err != nil {
+			slog.Warn("Failed to encode notification", "event", event, "error", err)
+			continue
+		}
+		go func(c Chat) {
+			if err := d.webhooks.post(c.URL, body); err != nil {
+				slog.Warn("Notification failed", "event", event, "kind", c.Kind, "error", err)
+			}
+		}(c)
+	}
+}
+
+func (d *Dispatcher) render(m Message) (string, error) {
+	var b strings.Builder
+	if err := d.message.Execute(&b, m); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// chatPayload builds the body an incoming webhook of kind expects. Teams
+// gets a MessageCard so the event type shows as the notification summary.
+func chatPayload(kind, event, text string) interface{} {
+	if kind == ChatTeams {
+		return map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  event,
+			"text":     text,
+		}
+	}
+	return map[string]string{"text": text}
+}
@@ -0,0 +1,130 @@
+// Package notify delivers event notifications to webhook endpoints.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+// WorkerName is the worker deliveries are recorded as.
+const WorkerName = "notifier"
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body when a
+// secret is configured.
+const SignatureHeader = "X-Signature-256"
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	Event     string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Notifier posts events to a fixed set of webhook URLs.
+type Notifier struct {
+	urls    []string
+	secret  string
+	client  *http.Client
+	timeout time.Duration
+	worker  *workers.Worker
+}
+
+// New creates a notifier. With no URLs, Send is a no-op.
+func New(urls []string, secret string, timeout time.Duration) *Notifier {
+	return &Notifier{
+		urls:    urls,
+		secret:  secret,
+		client:  &http.Client{},
+		timeout: timeout,
+	}
+}
+
+// Enabled reports whether any webhook is configured.
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.urls) > 0
+}
+
+// UseWorkers records every delivery as a run of the notifier worker in
+// registry. Call it before the first Send.
+func (n *Notifier) UseWorkers(registry *workers.Registry) {
+	if n.Enabled() {
+		n.worker = registry.Register(WorkerName, 0)
+	}
+}
+
+// Send delivers the event to every webhook in the background. Delivery
+// failures are logged and not retried.
+func (n *Notifier) Send(event string, data interface{}) {
+	if !n.Enabled() {
+		return
+	}
+
+	body, err := json.Marshal(Payload{
+		Event:     event,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		slog.Warn("Failed to encode notification", "event", event, "error", err)
+		return
+	}
+
+	for _, url := range n.urls {
+		go func(url string) {
+			if err := n.post(url, body); err != nil {
+				slog.Warn("Notification failed", "event", event, "url", url, "error", err)
+			}
+		}(url)
+	}
+}
+
+// post sends one webhook request and records it.
+func (n *Notifier) post(url string, body []byte) error {
+	start := time.Now()
+	err := n.deliver(url, body)
+	n.worker.Record(start, err)
+	return err
+}
+
+// deliver sends one webhook request.
+func (n *Notifier) deliver(url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body using secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,393 @@
+// Package openapi generates the OpenAPI 3 document of the API from its
+// routes and the Go types of their request and response bodies, so the
+// specification cannot drift from the handlers it describes.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// Schema is a JSON Schema object as used by OpenAPI.
+type Schema map[string]interface{}
+
+// Route is a method and path of the router, with gin's :param and
+// *param segments.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Param is a query parameter of an operation.
+type Param struct {
+	Name        string
+	Type        string // string, integer, number or boolean
+	Description string
+	Required    bool
+}
+
+// Operation documents a route. Request and Response are zero values of
+// the body types, e.g. models.ItemCreate{}; nil means no JSON body.
+type Operation struct {
+	Tag         string
+	Summary     string
+	Description string
+	Query       []Param
+	Request     interface{}
+	Response    interface{}
+	// Status is the success status, 200 when zero.
+	Status int
+	// ContentType is the media type of a Response that is not JSON, such
+	// as text/csv; Response is then ignored.
+	ContentType string
+}
+
+// Generator builds OpenAPI documents.
+type Generator struct {
+	Title       string
+	Version     string
+	Description string
+	// Types overrides the schema of types with their own JSON encoding.
+	Types map[reflect.Type]Schema
+	// Error is the body of error responses.
+	Error interface{}
+}
+
+// Build returns the document of routes. doc describes each route; role
+// returns the API key role a route requires, or "" when it is open.
+func (g Generator) Build(routes []Route, doc func(Route) Operation, role func(Route) string) Schema {
+	s := &schemas{types: g.Types, components: Schema{}}
+	var errorRef Schema
+	if g.Error != nil {
+		errorRef = s.of(reflect.TypeOf(g.Error))
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	paths := Schema{}
+	for _, route := range routes {
+		op := doc(route)
+		path, params := convertPath(route.Path)
+		for _, q := range op.Query {
+			params = append(params, Schema{
+				"name":        q.Name,
+				"in":          "query",
+				"required":    q.Required,
+				"description": q.Description,
+				"schema":      Schema{"type": q.Type},
+			})
+		}
+
+		operation := Schema{
+			"operationId": operationID(route),
+			"summary":     op.Summary,
+			"responses":   g.responses(s, op, errorRef, role(route) != ""),
+		}
+		if op.Tag != "" {
+			operation["tags"] = []string{op.Tag}
+		}
+		description := op.Description
+		if r := role(route); r != "" {
+			operation["security"] = []Schema{{"apiKey": []string{}}, {"bearer": []string{}}}
+			description = strings.TrimSpace(description + "\n\nRequires an API key with the " + r + " role or higher.")
+		}
+		if description != "" {
+			operation["description"] = description
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = Schema{
+				"required": true,
+				"content":  Schema{"application/json": Schema{"schema": s.of(reflect.TypeOf(op.Request))}},
+			}
+		}
+
+		item, _ := paths[path].(Schema)
+		if item == nil {
+			item = Schema{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	info := Schema{"title": g.Title, "version": g.Version}
+	if g.Description != "" {
+		info["description"] = g.Description
+	}
+	return Schema{
+		"openapi": Version,
+		"info":    info,
+		"paths":   paths,
+		"components": Schema{
+			"schemas": s.components,
+			"securitySchemes": Schema{
+				"apiKey": Schema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": Schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// responses returns the responses of op: its success response, and
+// errors described by errorRef.
+func (g Generator) responses(s *schemas, op Operation, errorRef Schema, secured bool) Schema {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Schema{"description": http.StatusText(status)}
+	switch {
+	case op.ContentType != "":
+		success["content"] = Schema{op.ContentType: Schema{"schema": Schema{"type": "string"}}}
+	case op.Response != nil:
+		success["content"] = Schema{"application/json": Schema{"schema": s.of(reflect.TypeOf(op.Response))}}
+	}
+
+	responses := Schema{strconv.Itoa(status): success}
+	if errorRef == nil {
+		return responses
+	}
+	errorResponse := func(description string) Schema {
+		return Schema{
+			"description": description,
+			"content":     Schema{"application/json": Schema{"schema": errorRef}},
+		}
+	}
+	if secured {
+		responses["401"] = errorResponse("Missing or invalid API key")
+		responses["403"] = errorResponse("API key role too low, or no API key configured")
+	}
+	responses["default"] = errorResponse("Error")
+	return responses
+}
+
+// convertPath turns gin's /items/:id into /items/{id} and returns the
+// path parameters.
+func convertPath(path string) (string, []Schema) {
+	segments := strings.Split(path, "/")
+	var params []Schema
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Schema{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   Schema{"type": "string"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a unique ID from the method and path, e.g.
+// getItemsById for GET /items/:id.
+func operationID(route Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.FieldsFunc(route.Path, func(r rune) bool { return r == '/' || r == '-' || r == '_' }) {
+		if segment[0] == ':' || segment[0] == '*' {
+			b.WriteString("By")
+			segment = segment[1:]
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+// schemas derives schemas from Go types. Named structs become components
+// referenced by name.
+type schemas struct {
+	types      map[reflect.Type]Schema
+	components Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (s *schemas) of(t reflect.Type) Schema {
+	if schema, ok := s.types[t]; ok {
+		return schema
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := Schema{}
+		for k, v := range s.of(t.Elem()) {
+			schema[k] = v
+		}
+		if _, ref := schema["$ref"]; ref {
+			// Siblings of $ref are ignored in OpenAPI 3.0
+			return Schema{"allOf": []Schema{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return Schema{"type": "integer", "format": "int64", "description": "nanoseconds"}
+		}
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return Schema{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Placeholder first, for types that refer to themselves
+			s.components[t.Name()] = Schema{}
+			s.components[t.Name()] = s.object(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	// interface{} and anything else: any value
+	return Schema{}
+}
+
+// object returns the schema of the JSON object a struct encodes to.
+func (s *schemas) object(t reflect.Type) Schema {
+	properties := Schema{}
+	var required []string
+	s.fields(t, properties, &required)
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemas) fields(t reflect.Type, properties Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := s.of(f.Type)
+		rules := strings.Split(f.Tag.Get("binding"), ",")
+		if constraints := bindingConstraints(s.of(f.Type), rules); len(constraints) > 0 {
+			merged := Schema{}
+			for k, v := range schema {
+				merged[k] = v
+			}
+			for k, v := range constraints {
+				merged[k] = v
+			}
+			if _, ref := schema["$ref"]; ref {
+				merged = Schema{"allOf": []Schema{schema}}
+				for k, v := range constraints {
+					merged[k] = v
+				}
+			}
+			schema = merged
+		}
+		properties[name] = schema
+		for _, rule := range rules {
+			if rule == "required" {
+				*required = append(*required, name)
+			}
+		}
+	}
+}
+
+// bindingConstraints translates the validator rules of a field with the
+// given schema that have a JSON Schema equivalent. Rules after dive apply
+// to elements and are left out.
+func bindingConstraints(schema Schema, rules []string) Schema {
+	kind, _ := schema["type"].(string)
+	constraints := Schema{}
+	for _, rule := range rules {
+		name, value, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			break
+		}
+		switch name {
+		case "oneof":
+			var enum []interface{}
+			for _, v := range strings.Fields(value) {
+				if kind == "string" {
+					enum = append(enum, v)
+				} else if n, err := strconv.ParseFloat(v, 64); err == nil {
+					enum = append(enum, n)
+				}
+			}
+			constraints["enum"] = enum
+		case "min", "max", "gte", "lte", "gt", "lt":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch kind {
+			case "string":
+				if name == "min" || name == "gte" {
+					constraints["minLength"] = int(n)
+				} else if name == "max" || name == "lte" {
+					constraints["maxLength"] = int(n)
+				}
+			case "array":
+				if name == "min" || name == "gte" {
+					constraints["minItems"] = int(n)
+				} else if name == "max" || name == "lte" {
+					constraints["maxItems"] = int(n)
+				}
+			case "integer", "number":
+				switch name {
+				case "min", "gte":
+					constraints["minimum"] = n
+				case "max", "lte":
+					constraints["maximum"] = n
+				case "gt":
+					constraints["minimum"], constraints["exclusiveMinimum"] = n, true
+				case "lt":
+					constraints["maximum"], constraints["exclusiveMaximum"] = n, true
+				}
+			}
+		}
+	}
+	return constraints
+}
@@ -0,0 +1,223 @@
+// Package patroni is a client for the Patroni REST API.
+package patroni
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNotConfigured is returned when no Patroni URL is configured.
+var ErrNotConfigured = errors.New("no Patroni REST API URL is configured")
+
+// Member roles reported by Patroni.
+const (
+	RoleLeader        = "leader"
+	RoleStandbyLeader = "standby_leader"
+	RoleReplica       = "replica"
+	RoleSyncStandby   = "sync_standby"
+)
+
+// Member is a cluster member as returned by GET /cluster.
+type Member struct {
+	Name     string      `json:"name"`
+	Role     string      `json:"role"`
+	State    string      `json:"state"`
+	APIURL   string      `json:"api_url"`
+	Host     string      `json:"host"`
+	Port     int         `json:"port"`
+	Timeline int         `json:"timeline"`
+	Lag      interface{} `json:"lag,omitempty"`
+	Tags     interface{} `json:"tags,omitempty"`
+}
+
+// LagBytes returns the replication lag of a replica. Patroni reports
+// "unknown" when it cannot be determined.
+func (m Member) LagBytes() (int64, bool) {
+	switch lag := m.Lag.(type) {
+	case float64:
+		return int64(lag), true
+	case int64:
+		return lag, true
+	}
+	return 0, false
+}
+
+// Leader reports whether m is the leader of its cluster.
+func (m Member) Leader() bool {
+	return m.Role == RoleLeader || m.Role == RoleStandbyLeader
+}
+
+// Cluster is the response of GET /cluster.
+type Cluster struct {
+	Members             []Member               `json:"members"`
+	Pause               bool                   `json:"pause,omitempty"`
+	ScheduledSwitchover map[string]interface{} `json:"scheduled_switchover,omitempty"`
+}
+
+// Leader returns the current leader, if any.
+func (c *Cluster) Leader() (Member, bool) {
+	for _, m := range c.Members {
+		if m.Leader() {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// Member returns the member with the given name.
+func (c *Cluster) Member(name string) (Member, bool) {
+	for _, m := range c.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// Error is a non-2xx response from Patroni.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("patroni returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Client talks to the Patroni REST API of any cluster member. Requests go
+// to the first URL that answers.
+type Client struct {
+	urls     []string
+	username string
+	password string
+	client   *http.Client
+}
+
+// New creates a client for the given member URLs, e.g.
+// http://10.0.1.10:8008. username and password are used for endpoints
+// protected by restapi.authentication.
+func New(urls []string, username, password string, timeout time.Duration) *Client {
+	var list []string
+	for _, u := range urls {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			list = append(list, u)
+		}
+	}
+	return &Client{
+		urls:     list,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Enabled reports whether any URL is configured.
+func (c *Client) Enabled() bool {
+	return c != nil && len(c.urls) > 0
+}
+
+// Cluster returns the cluster state.
+func (c *Client) Cluster(ctx context.Context) (*Cluster, error) {
+	var cluster Cluster
+	if err := c.do(ctx, http.MethodGet, "/cluster", nil, &cluster); err != nil {
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+// Switchover asks Patroni to hand leadership from leader to candidate.
+// An empty candidate lets Patroni pick the healthiest replica.
+func (c *Client) Switchover(ctx context.Context, leader, candidate string) error {
+	body := map[string]string{"leader": leader}
+	if candidate != "" {
+		body["candidate"] = candidate
+	}
+	return c.do(ctx, http.MethodPost, "/switchover", body, nil)
+}
+
+// Failover asks Patroni to promote candidate, even without a healthy
+// leader.
+func (c *Client) Failover(ctx context.Context, candidate string) error {
+	return c.do(ctx, http.MethodPost, "/failover", map[string]string{"candidate": candidate}, nil)
+}
+
+// Pause turns Patroni's maintenance mode on or off. While paused, Patroni
+// does not fail over or restart PostgreSQL.
+func (c *Client) Pause(ctx context.Context, paused bool) error {
+	return c.do(ctx, http.MethodPatch, "/config", map[string]bool{"pause": paused}, nil)
+}
+
+// do sends the request to each URL in turn until one answers. Only
+// connection failures move on to the next URL; an error response from a
+// member is returned as is. Each call is a span, so the time spent
+// waiting on Patroni shows in the trace of a switchover or failover.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) (err error) {
+	if !c.Enabled() {
+		return ErrNotConfigured
+	}
+	ctx, span := tracing.Start(ctx, "patroni "+method+" "+path, trace.SpanKindClient,
+		semconv.HTTPRequestMethodKey.String(method),
+		semconv.URLPath(path),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	var lastErr error
+	for _, base := range c.urls {
+		req, err := http.NewRequestWithContext(ctx, method, base+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		requestid.Propagate(req)
+		tracing.Inject(req)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		span.SetAttributes(semconv.URLFull(base+path), semconv.HTTPResponseStatusCode(resp.StatusCode))
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		}
+		if out != nil {
+			return json.Unmarshal(data, out)
+		}
+		return nil
+	}
+	return fmt.Errorf("no Patroni member answered: %w", lastErr)
+}
@@ -0,0 +1,55 @@
+// Package requestid carries the ID of an HTTP request through contexts, so
+// that the log lines, database sessions and calls to other services it
+// causes can be traced back to it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Header is the header a request ID is taken from and sent back in.
+const Header = "X-Request-ID"
+
+// validPattern restricts IDs taken from clients to what is safe in log
+// lines and in an application_name.
+var validPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type contextKey struct{}
+
+// New returns a random ID of 32 hex digits.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether id may be used as given: up to 64 letters,
+// digits, dots, underscores, colons and dashes.
+func Valid(id string) bool {
+	return validPattern.MatchString(id)
+}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Propagate sets the header of req to the request ID of its context, if
+// any, so the service it goes to can log the same ID.
+func Propagate(req *http.Request) {
+	if id := FromContext(req.Context()); id != "" {
+		req.Header.Set(Header, id)
+	}
+}
@@ -0,0 +1,134 @@
+// Package scheduler runs named tasks on cron schedules and remembers the
+// outcome of the last run of each task.
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// RunFunc starts a task and returns a reference to it, such as a job ID.
+type RunFunc func(name string) (string, error)
+
+// EntryStatus is a snapshot of one scheduled task.
+type EntryStatus struct {
+	Name      string
+	Spec      string
+	Next      time.Time
+	LastRun   *time.Time
+	LastRef   string
+	LastError string
+}
+
+type entry struct {
+	spec      string
+	id        cron.EntryID
+	lastRun   *time.Time
+	lastRef   string
+	lastError string
+}
+
+// Scheduler triggers tasks according to standard five-field cron
+// expressions, evaluated in UTC.
+type Scheduler struct {
+	cron *cron.Cron
+	run  RunFunc
+
+	mu      sync.RWMutex
+	entries map[string]*entry
+}
+
+// New creates a scheduler for the given task specs. Tasks with an empty
+// spec are skipped.
+func New(specs map[string]string, run RunFunc) (*Scheduler, error) {
+	s := &Scheduler{
+		cron:    cron.New(cron.WithLocation(time.UTC)),
+		run:     run,
+		entries: make(map[string]*entry),
+	}
+
+	for name, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		name := name
+		id, err := s.cron.AddFunc(spec, func() { s.trigger(name) })
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule for %s: %w", name, err)
+		}
+		s.entries[name] = &entry{spec: spec, id: id}
+	}
+
+	return s, nil
+}
+
+// Start begins triggering tasks.
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop stops triggering tasks. Tasks already started are not affected.
+func (s *Scheduler) Stop() {
+	s.cron.Stop()
+}
+
+// trigger runs a task and records the outcome.
+func (s *Scheduler) trigger(name string) {
+	ref, err := s.run(name)
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[name]
+	e.lastRun = &now
+	e.lastRef = ref
+	e.lastError = ""
+	if err != nil {
+		e.lastError = err.Error()
+	}
+}
+
+// Next returns when the next task runs, or the zero time without tasks.
+func (s *Scheduler) Next() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	now := time.Now().In(time.UTC)
+	for _, e := range s.entries {
+		if at := s.cron.Entry(e.id).Schedule.Next(now); next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// Status returns the scheduled tasks ordered by their next run.
+func (s *Scheduler) Status() []EntryStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]EntryStatus, 0, len(s.entries))
+	for name, e := range s.entries {
+		out = append(out, EntryStatus{
+			Name:      name,
+			Spec:      e.spec,
+			Next:      s.cron.Entry(e.id).Schedule.Next(time.Now().In(time.UTC)),
+			LastRun:   e.lastRun,
+			LastRef:   e.lastRef,
+			LastError: e.lastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Next.Equal(out[j].Next) {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Next.Before(out[j].Next)
+	})
+	return out
+}
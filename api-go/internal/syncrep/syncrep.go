@@ -0,0 +1,234 @@
+// Package syncrep parses synchronous_standby_names and checks whether the
+// connected standbys satisfy it, i.e. whether commits waiting for
+// synchronous replication can complete.
+package syncrep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Methods of choosing the synchronous standbys.
+const (
+	MethodFirst = "first"
+	MethodAny   = "any"
+)
+
+// Config is a parsed synchronous_standby_names. The zero value means
+// replication is asynchronous.
+type Config struct {
+	Method string
+	Num    int
+	Names  []string
+}
+
+// Enabled reports whether commits wait for synchronous standbys.
+func (c Config) Enabled() bool {
+	return c.Num > 0 && len(c.Names) > 0
+}
+
+// Matches reports whether a standby with the given application_name is a
+// candidate. Names are compared case-insensitively, like PostgreSQL does,
+// and * matches any standby.
+func (c Config) Matches(applicationName string) bool {
+	for _, name := range c.Names {
+		if name == "*" || strings.EqualFold(name, applicationName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Standby is one row of pg_stat_replication.
+type Standby struct {
+	ApplicationName string
+	State           string
+	SyncState       string
+}
+
+// Candidates returns the standbys that can confirm synchronous commits:
+// those streaming under a listed name.
+func (c Config) Candidates(standbys []Standby) []Standby {
+	var out []Standby
+	for _, s := range standbys {
+		if s.State == "streaming" && c.Matches(s.ApplicationName) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Parse parses a synchronous_standby_names value:
+//
+//	name [, ...]
+//	[FIRST] num ( name [, ...] )
+//	ANY num ( name [, ...] )
+//
+// A plain list is FIRST 1. An empty value returns the zero Config.
+func Parse(value string) (Config, error) {
+	tokens, err := scan(value)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(tokens) == 0 {
+		return Config{}, nil
+	}
+
+	p := &parser{tokens: tokens}
+	cfg := Config{Method: MethodFirst, Num: 1}
+
+	first := p.peek()
+	switch {
+	case first.kind == tokenName && (strings.EqualFold(first.text, "first") || strings.EqualFold(first.text, "any")) &&
+		p.peekAt(1).kind == tokenNum:
+		p.next()
+		cfg.Method = strings.ToLower(first.text)
+		fallthrough
+	case first.kind == tokenNum && p.peekAt(1).kind == tokenOpen:
+		num, err := strconv.Atoi(p.next().text)
+		if err != nil || num <= 0 {
+			return Config{}, fmt.Errorf("number of synchronous standbys must be greater than zero")
+		}
+		cfg.Num = num
+		if p.next().kind != tokenOpen {
+			return Config{}, fmt.Errorf("expected ( after the number of synchronous standbys")
+		}
+		if cfg.Names, err = p.names(); err != nil {
+			return Config{}, err
+		}
+		if p.next().kind != tokenClose {
+			return Config{}, fmt.Errorf("expected ) after the standby names")
+		}
+	default:
+		if cfg.Names, err = p.names(); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if tok := p.next(); tok.kind != tokenEnd {
+		return Config{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return cfg, nil
+}
+
+const (
+	tokenEnd = iota
+	tokenName
+	tokenNum
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+// scan splits the value into tokens. Double-quoted names may contain any
+// character, with "" standing for a quote.
+func scan(value string) ([]token, error) {
+	var tokens []token
+	runes := []rune(value)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenClose, ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ","})
+			i++
+		case r == '*':
+			tokens = append(tokens, token{tokenName, "*"})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated quoted name")
+				}
+				if runes[i] == '"' {
+					if i+1 < len(runes) && runes[i+1] == '"' {
+						b.WriteRune('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokenName, b.String()})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenNum, string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_' || r >= 0x80:
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenName, string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r >= 0x80
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return token{kind: tokenEnd}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) peek() token {
+	return p.peekAt(0)
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+// names parses a comma-separated list of standby names. Numbers are valid
+// names too.
+func (p *parser) names() ([]string, error) {
+	var names []string
+	for {
+		tok := p.next()
+		if tok.kind != tokenName && tok.kind != tokenNum {
+			return nil, fmt.Errorf("expected a standby name")
+		}
+		names = append(names, tok.text)
+		if p.peek().kind != tokenComma {
+			return names, nil
+		}
+		p.next()
+	}
+}
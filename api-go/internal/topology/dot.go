@@ -0,0 +1,95 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Label describes the node in one or two lines: its ID, then its role and
+// timeline, or why it could not be probed.
+func (n Node) Label() string {
+	switch {
+	case n.Reachable:
+		return fmt.Sprintf("%s\n%s, timeline %d", n.ID, n.Role, n.Timeline)
+	case n.Error != "":
+		return n.ID + "\nunreachable"
+	default:
+		return n.ID
+	}
+}
+
+// Label describes the connection: its sync state, or its state when it is
+// not streaming, and its lag when known.
+func (e Edge) Label() string {
+	var parts []string
+	if e.State != "" && e.State != "streaming" {
+		parts = append(parts, e.State)
+	} else if e.SyncState != "" {
+		parts = append(parts, e.SyncState)
+	}
+	if e.LagBytes != nil {
+		parts = append(parts, fmt.Sprintf("lag %d B", *e.LagBytes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Synchronous reports whether commits on the upstream wait for this
+// connection.
+func (e Edge) Synchronous() bool {
+	return e.SyncState == "sync" || e.SyncState == "quorum"
+}
+
+// DOT renders the graph in the Graphviz DOT language, laid out top-down
+// from the primary. Unreachable nodes and connections that are not
+// streaming are dashed; synchronous connections are bold.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph replication {\n")
+	b.WriteString("\trankdir=TB;\n")
+	b.WriteString("\tnode [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("\tedge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + quoteDOT(n.Label())}
+		switch {
+		case !n.Reachable:
+			attrs = append(attrs, `style="rounded,dashed"`, "color=gray50")
+		case n.Role == RolePrimary:
+			attrs = append(attrs, `style="rounded,filled,bold"`, `fillcolor="#d9ead3"`)
+		case n.Role == RoleCascading:
+			attrs = append(attrs, `style="rounded,filled"`, `fillcolor="#fff2cc"`)
+		default:
+			attrs = append(attrs, `style="rounded,filled"`, `fillcolor="#dae8fc"`)
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", quoteDOT(n.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, e := range g.Edges {
+		var attrs []string
+		if label := e.Label(); label != "" {
+			attrs = append(attrs, "label="+quoteDOT(label))
+		}
+		switch {
+		case e.State != "" && e.State != "streaming":
+			attrs = append(attrs, "style=dashed", "color=red")
+		case e.Synchronous():
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(&b, "\t%s -> %s", quoteDOT(e.From), quoteDOT(e.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// quoteDOT returns s as a double-quoted DOT ID.
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,299 @@
+// Package topology discovers the replication graph of a PostgreSQL
+// cluster by following pg_stat_replication and pg_stat_wal_receiver from
+// node to node.
+package topology
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Node roles in the discovered graph.
+const (
+	RolePrimary   = "primary"
+	RoleStandby   = "standby"
+	RoleCascading = "cascading_standby"
+	RoleUnknown   = "unknown"
+)
+
+// Address is a host and port a node is reached at.
+type Address struct {
+	Host string
+	Port int
+}
+
+func (a Address) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// Report is what a probe learns from one node.
+type Report struct {
+	InRecovery       bool
+	SystemIdentifier string
+	Timeline         int
+
+	// ServerAddr is the address the node sees the probe connect to, used
+	// to recognize it when replicas name it by IP. It may be empty.
+	ServerAddr string
+
+	// Upstream is the sender the node streams from, if any.
+	Upstream       *Address
+	UpstreamStatus string
+
+	Downstreams []Downstream
+}
+
+// Downstream is one row of pg_stat_replication. ClientAddr is empty for
+// replicas connected over a Unix socket.
+type Downstream struct {
+	ApplicationName  string
+	ClientAddr       string
+	State            string
+	SyncState        string
+	LagBytes         *int64
+	ReplayLagSeconds *float64
+}
+
+// ProbeFunc connects to a node and reports its replication state.
+type ProbeFunc func(ctx context.Context, addr Address) (Report, error)
+
+// Node is a vertex of the graph. ID is the address the node was first
+// reached at.
+type Node struct {
+	ID               string
+	Role             string
+	Reachable        bool
+	Error            string
+	SystemIdentifier string
+	Timeline         int
+	Upstream         string
+	UpstreamStatus   string
+}
+
+// Edge is a replication connection from an upstream to a downstream node.
+type Edge struct {
+	From             string
+	To               string
+	ApplicationName  string
+	State            string
+	SyncState        string
+	LagBytes         *int64
+	ReplayLagSeconds *float64
+}
+
+// Graph is the discovered topology.
+type Graph struct {
+	Primary  string
+	Nodes    []Node
+	Edges    []Edge
+	Warnings []string
+}
+
+// Discover probes the seed nodes and every node they replicate from or
+// to, breadth first, until no new node is found or maxNodes nodes were
+// probed. Replicas are probed on defaultPort because pg_stat_replication
+// does not record the port they listen on. Each level of the search is
+// probed concurrently.
+func Discover(ctx context.Context, seeds []Address, defaultPort, maxNodes int, probe ProbeFunc) *Graph {
+	d := &discovery{
+		defaultPort: defaultPort,
+		aliases:     map[string]int{},
+	}
+
+	level := seeds
+	for len(level) > 0 && len(d.nodes) < maxNodes && ctx.Err() == nil {
+		var batch []Address
+		queued := map[string]bool{}
+		for _, addr := range level {
+			key := addr.String()
+			if _, ok := d.aliases[key]; ok || queued[key] || len(d.nodes)+len(batch) >= maxNodes {
+				continue
+			}
+			queued[key] = true
+			batch = append(batch, addr)
+		}
+
+		results := make([]probeResult, len(batch))
+		var wg sync.WaitGroup
+		for i, addr := range batch {
+			wg.Add(1)
+			go func(i int, addr Address) {
+				defer wg.Done()
+				report, err := probe(ctx, addr)
+				results[i] = probeResult{addr: addr, report: report, err: err}
+			}(i, addr)
+		}
+		wg.Wait()
+
+		level = nil
+		for _, r := range results {
+			level = append(level, d.add(r)...)
+		}
+	}
+
+	d.truncated = len(level) > 0 && len(d.nodes) >= maxNodes
+	return d.graph()
+}
+
+type probeResult struct {
+	addr   Address
+	report Report
+	err    error
+}
+
+type discovery struct {
+	defaultPort int
+	nodes       []probeResult
+	aliases     map[string]int
+	truncated   bool
+}
+
+// add records a probed node and returns the neighbours to probe next. A
+// node already known under its server address is merged.
+func (d *discovery) add(r probeResult) []Address {
+	if r.err == nil && r.report.ServerAddr != "" {
+		alias := Address{Host: r.report.ServerAddr, Port: r.addr.Port}.String()
+		if i, ok := d.aliases[alias]; ok {
+			d.aliases[r.addr.String()] = i
+			return nil
+		}
+		d.aliases[alias] = len(d.nodes)
+	}
+	d.aliases[r.addr.String()] = len(d.nodes)
+	d.nodes = append(d.nodes, r)
+
+	if r.err != nil {
+		return nil
+	}
+	var next []Address
+	if up := r.report.Upstream; up != nil {
+		next = append(next, *up)
+	}
+	for _, down := range r.report.Downstreams {
+		if down.ClientAddr != "" {
+			next = append(next, Address{Host: down.ClientAddr, Port: d.defaultPort})
+		}
+	}
+	return next
+}
+
+// id resolves an address to the ID of a known node, or returns the
+// address itself.
+func (d *discovery) id(addr Address) string {
+	if i, ok := d.aliases[addr.String()]; ok {
+		return d.nodes[i].addr.String()
+	}
+	return addr.String()
+}
+
+func (d *discovery) graph() *Graph {
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	index := map[string]int{}
+	for _, r := range d.nodes {
+		node := Node{ID: r.addr.String(), Role: RoleUnknown}
+		if r.err != nil {
+			node.Error = r.err.Error()
+		} else {
+			node.Reachable = true
+			node.SystemIdentifier = r.report.SystemIdentifier
+			node.Timeline = r.report.Timeline
+			node.UpstreamStatus = r.report.UpstreamStatus
+			if !r.report.InRecovery {
+				node.Role = RolePrimary
+			}
+		}
+		index[node.ID] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	// Edges as reported by the upstream side
+	hasEdge := map[string]bool{}
+	for _, r := range d.nodes {
+		if r.err != nil {
+			continue
+		}
+		from := r.addr.String()
+		for _, down := range r.report.Downstreams {
+			to := down.ApplicationName
+			if down.ClientAddr != "" {
+				to = d.id(Address{Host: down.ClientAddr, Port: d.defaultPort})
+			}
+			if _, ok := index[to]; !ok {
+				node := Node{ID: to, Role: RoleUnknown}
+				if down.ClientAddr == "" {
+					node.Error = "connected over a Unix socket"
+				}
+				index[to] = len(g.Nodes)
+				g.Nodes = append(g.Nodes, node)
+			}
+			g.Edges = append(g.Edges, Edge{
+				From:             from,
+				To:               to,
+				ApplicationName:  down.ApplicationName,
+				State:            down.State,
+				SyncState:        down.SyncState,
+				LagBytes:         down.LagBytes,
+				ReplayLagSeconds: down.ReplayLagSeconds,
+			})
+			hasEdge[from+">"+to] = true
+			g.Nodes[index[to]].Upstream = from
+		}
+	}
+
+	// Receivers whose upstream could not be probed
+	for _, r := range d.nodes {
+		if r.err != nil || r.report.Upstream == nil {
+			continue
+		}
+		to := r.addr.String()
+		from := d.id(*r.report.Upstream)
+		g.Nodes[index[to]].Upstream = from
+		if !hasEdge[from+">"+to] {
+			g.Edges = append(g.Edges, Edge{From: from, To: to, State: r.report.UpstreamStatus})
+		}
+	}
+
+	// Standby roles follow from the upstream's role
+	var primaries []string
+	identifiers := map[string]bool{}
+	for i := range g.Nodes {
+		node := &g.Nodes[i]
+		if node.SystemIdentifier != "" {
+			identifiers[node.SystemIdentifier] = true
+		}
+		if node.Role == RolePrimary {
+			primaries = append(primaries, node.ID)
+			continue
+		}
+		if !node.Reachable && node.Upstream == "" {
+			continue
+		}
+		node.Role = RoleStandby
+		if j, ok := index[node.Upstream]; ok && g.Nodes[j].Reachable && g.Nodes[j].Role != RolePrimary {
+			node.Role = RoleCascading
+		}
+	}
+
+	sort.Strings(primaries)
+	switch len(primaries) {
+	case 0:
+		g.Warnings = append(g.Warnings, "no reachable primary was found")
+	case 1:
+		g.Primary = primaries[0]
+	default:
+		g.Warnings = append(g.Warnings, fmt.Sprintf("multiple primaries found (%s); check for split brain",
+			strings.Join(primaries, ", ")))
+	}
+	if len(identifiers) > 1 {
+		g.Warnings = append(g.Warnings, "nodes report different system identifiers; they do not belong to one cluster")
+	}
+	if d.truncated {
+		g.Warnings = append(g.Warnings, fmt.Sprintf("discovery stopped after %d nodes", len(d.nodes)))
+	}
+	return g
+}
@@ -0,0 +1,26 @@
+// Package version exposes build metadata injected at link time.
+package version
+
+import (
+	"runtime/debug"
+)
+
+// Commit is the VCS revision the binary was built from. It is set with
+// -ldflags "-X github.com/postgresql-ha-dr/api-go/internal/version.Commit=..."
+// and falls back to the revision recorded by the Go toolchain.
+var Commit = ""
+
+// GetCommit returns the build commit, or "unknown" when it is not recorded.
+func GetCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return "unknown"
+}
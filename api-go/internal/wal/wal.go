@@ -0,0 +1,141 @@
+// Package wal provides helpers for PostgreSQL WAL locations and segment
+// file names.
+package wal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LSN is a PostgreSQL write-ahead log location.
+type LSN uint64
+
+// ParseLSN parses the textual X/Y form of an LSN.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+// String formats the LSN in the X/Y form used by PostgreSQL.
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint64(l)&0xFFFFFFFF)
+}
+
+// DefaultSegmentSize is the wal_segment_size PostgreSQL is built with
+// unless initdb --wal-segsize says otherwise.
+const DefaultSegmentSize int64 = 16 * 1024 * 1024
+
+// Segment identifies a WAL segment file.
+type Segment struct {
+	Timeline uint32
+	Number   uint64
+}
+
+// ParseFileName parses a 24-character WAL segment file name such as
+// 000000010000000A000000FF. segmentSize is the server's wal_segment_size
+// in bytes.
+func ParseFileName(name string, segmentSize int64) (Segment, error) {
+	if len(name) < 24 || segmentSize <= 0 {
+		return Segment{}, fmt.Errorf("invalid WAL file name %q", name)
+	}
+	tli, err := strconv.ParseUint(name[0:8], 16, 32)
+	if err != nil {
+		return Segment{}, fmt.Errorf("invalid WAL file name %q: %w", name, err)
+	}
+	log, err := strconv.ParseUint(name[8:16], 16, 32)
+	if err != nil {
+		return Segment{}, fmt.Errorf("invalid WAL file name %q: %w", name, err)
+	}
+	seg, err := strconv.ParseUint(name[16:24], 16, 32)
+	if err != nil {
+		return Segment{}, fmt.Errorf("invalid WAL file name %q: %w", name, err)
+	}
+	return Segment{
+		Timeline: uint32(tli),
+		Number:   log*segmentsPerLogID(segmentSize) + seg,
+	}, nil
+}
+
+// SegmentOf returns the segment on timeline that contains lsn.
+func SegmentOf(lsn LSN, timeline uint32, segmentSize int64) Segment {
+	return Segment{Timeline: timeline, Number: uint64(lsn) / uint64(segmentSize)}
+}
+
+// FileName formats the segment as a WAL file name.
+func (s Segment) FileName(segmentSize int64) string {
+	perID := segmentsPerLogID(segmentSize)
+	return fmt.Sprintf("%08X%08X%08X", s.Timeline, s.Number/perID, s.Number%perID)
+}
+
+// StartLSN returns the first location contained in the segment.
+func (s Segment) StartLSN(segmentSize int64) LSN {
+	return LSN(s.Number * uint64(segmentSize))
+}
+
+// EndLSN returns the location just past the end of the segment.
+func (s Segment) EndLSN(segmentSize int64) LSN {
+	return LSN((s.Number + 1) * uint64(segmentSize))
+}
+
+func segmentsPerLogID(segmentSize int64) uint64 {
+	return 0x100000000 / uint64(segmentSize)
+}
+
+// TimelineSwitch is one line of a timeline history file: the server left
+// Timeline at SwitchLSN.
+type TimelineSwitch struct {
+	Timeline  uint32
+	SwitchLSN LSN
+	Reason    string
+}
+
+// HistoryFileName returns the name of the history file of timeline, e.g.
+// 00000003.history.
+func HistoryFileName(timeline uint32) string {
+	return fmt.Sprintf("%08X.history", timeline)
+}
+
+// ParseTimelineHistory parses the contents of a timeline history file.
+// Blank lines and comments are skipped.
+func ParseTimelineHistory(content string) ([]TimelineSwitch, error) {
+	var switches []TimelineSwitch
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 2 {
+			fields = strings.Fields(line)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid timeline history line %q", line)
+		}
+		tli, err := strconv.ParseUint(strings.TrimSpace(fields[0]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeline history line %q: %w", line, err)
+		}
+		lsn, err := ParseLSN(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeline history line %q: %w", line, err)
+		}
+		s := TimelineSwitch{Timeline: uint32(tli), SwitchLSN: lsn}
+		if len(fields) > 2 {
+			s.Reason = strings.TrimSpace(strings.Join(fields[2:], " "))
+		}
+		switches = append(switches, s)
+	}
+	return switches, nil
+}
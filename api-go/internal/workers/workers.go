@@ -0,0 +1,151 @@
+// Package workers records when the background workers last ran, how long
+// they took and how they failed, so a worker that silently died shows up
+// as stalled instead of leaving stale data behind.
+package workers
+
+import (
+	"sync"
+	"time"
+)
+
+// StallIntervals is how many intervals a periodic worker may go without
+// finishing a run before it counts as stalled.
+const StallIntervals = 3
+
+// Status is a snapshot of one worker. Due is when the worker counts as
+// stalled unless it finishes another run; it is nil for workers driven by
+// events, which never stall.
+type Status struct {
+	Name         string
+	Interval     time.Duration
+	LastRun      *time.Time
+	LastDuration time.Duration
+	LastError    string
+	Runs         int64
+	Failures     int64
+	Due          *time.Time
+	Stalled      bool
+}
+
+// Worker records the runs of one background worker. A nil Worker ignores
+// them, so components work the same without a registry.
+type Worker struct {
+	name     string
+	interval time.Duration
+
+	mu           sync.Mutex
+	lastRun      *time.Time
+	lastDuration time.Duration
+	lastError    string
+	runs         int64
+	failures     int64
+	due          *time.Time
+}
+
+// Registry keeps the workers in the order they were registered.
+type Registry struct {
+	mu      sync.RWMutex
+	workers []*Worker
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a worker running every interval, or on events when
+// interval is zero. A periodic worker is due StallIntervals intervals
+// from now. Registering a name again returns the existing worker, so
+// components can share one. A nil registry returns a nil Worker.
+func (r *Registry) Register(name string, interval time.Duration) *Worker {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.workers {
+		if w.name == name {
+			return w
+		}
+	}
+	w := &Worker{name: name, interval: interval}
+	if interval > 0 {
+		w.Expect(time.Now().UTC().Add(StallIntervals * interval))
+	}
+	r.workers = append(r.workers, w)
+	return w
+}
+
+// Status returns every worker as of now.
+func (r *Registry) Status(now time.Time) []Status {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Status, 0, len(r.workers))
+	for _, w := range r.workers {
+		out = append(out, w.Status(now))
+	}
+	return out
+}
+
+// Record records a run that started at start and has just finished with
+// err. A periodic worker is then due StallIntervals intervals from now.
+func (w *Worker) Record(start time.Time, err error) {
+	if w == nil {
+		return
+	}
+	now := time.Now().UTC()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start = start.UTC()
+	w.lastRun = &start
+	w.lastDuration = now.Sub(start)
+	w.lastError = ""
+	w.runs++
+	if err != nil {
+		w.lastError = err.Error()
+		w.failures++
+	}
+	if w.interval > 0 {
+		due := now.Add(StallIntervals * w.interval)
+		w.due = &due
+	}
+}
+
+// Expect sets when the worker counts as stalled unless it finishes
+// another run, for workers whose runs are not evenly spaced.
+func (w *Worker) Expect(due time.Time) {
+	if w == nil {
+		return
+	}
+	due = due.UTC()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.due = &due
+}
+
+// Status returns the worker as of now.
+func (w *Worker) Status(now time.Time) Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return Status{
+		Name:         w.name,
+		Interval:     w.interval,
+		LastRun:      w.lastRun,
+		LastDuration: w.lastDuration,
+		LastError:    w.lastError,
+		Runs:         w.runs,
+		Failures:     w.failures,
+		Due:          w.due,
+		Stalled:      w.due != nil && now.After(*w.due),
+	}
+}
@@ -0,0 +1,815 @@
+// gRPC API of the PostgreSQL HA/DR API. It is served alongside the REST
+// API on GRPC_PORT and answers from the same handlers, so a field here has
+// the meaning of the JSON field of the same name.
+//
+// Regenerate the Go code with `make proto` after editing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: pgha/v1/pgha.proto
+
+package pghav1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	MonitoringService_GetHealth_FullMethodName    = "/pgha.v1.MonitoringService/GetHealth"
+	MonitoringService_GetMetrics_FullMethodName   = "/pgha.v1.MonitoringService/GetMetrics"
+	MonitoringService_WatchMetrics_FullMethodName = "/pgha.v1.MonitoringService/WatchMetrics"
+)
+
+// MonitoringServiceClient is the client API for MonitoringService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MonitoringService reports the health of the API and the metrics of the
+// database, as GET /health and GET /metrics do.
+type MonitoringServiceClient interface {
+	GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error)
+	GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*Metrics, error)
+	// WatchMetrics sends a metrics snapshot right away and then on every
+	// interval until the call is cancelled.
+	WatchMetrics(ctx context.Context, in *WatchMetricsRequest, opts ...grpc.CallOption) (MonitoringService_WatchMetricsClient, error)
+}
+
+type monitoringServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMonitoringServiceClient(cc grpc.ClientConnInterface) MonitoringServiceClient {
+	return &monitoringServiceClient{cc}
+}
+
+func (c *monitoringServiceClient) GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Health)
+	err := c.cc.Invoke(ctx, MonitoringService_GetHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitoringServiceClient) GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*Metrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Metrics)
+	err := c.cc.Invoke(ctx, MonitoringService_GetMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitoringServiceClient) WatchMetrics(ctx context.Context, in *WatchMetricsRequest, opts ...grpc.CallOption) (MonitoringService_WatchMetricsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonitoringService_ServiceDesc.Streams[0], MonitoringService_WatchMetrics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &monitoringServiceWatchMetricsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MonitoringService_WatchMetricsClient interface {
+	Recv() (*Metrics, error)
+	grpc.ClientStream
+}
+
+type monitoringServiceWatchMetricsClient struct {
+	grpc.ClientStream
+}
+
+func (x *monitoringServiceWatchMetricsClient) Recv() (*Metrics, error) {
+	m := new(Metrics)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MonitoringServiceServer is the server API for MonitoringService service.
+// All implementations must embed UnimplementedMonitoringServiceServer
+// for forward compatibility
+//
+// MonitoringService reports the health of the API and the metrics of the
+// database, as GET /health and GET /metrics do.
+type MonitoringServiceServer interface {
+	GetHealth(context.Context, *GetHealthRequest) (*Health, error)
+	GetMetrics(context.Context, *GetMetricsRequest) (*Metrics, error)
+	// WatchMetrics sends a metrics snapshot right away and then on every
+	// interval until the call is cancelled.
+	WatchMetrics(*WatchMetricsRequest, MonitoringService_WatchMetricsServer) error
+	mustEmbedUnimplementedMonitoringServiceServer()
+}
+
+// UnimplementedMonitoringServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMonitoringServiceServer struct {
+}
+
+func (UnimplementedMonitoringServiceServer) GetHealth(context.Context, *GetHealthRequest) (*Health, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedMonitoringServiceServer) GetMetrics(context.Context, *GetMetricsRequest) (*Metrics, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
+}
+func (UnimplementedMonitoringServiceServer) WatchMetrics(*WatchMetricsRequest, MonitoringService_WatchMetricsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMetrics not implemented")
+}
+func (UnimplementedMonitoringServiceServer) mustEmbedUnimplementedMonitoringServiceServer() {}
+
+// UnsafeMonitoringServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonitoringServiceServer will
+// result in compilation errors.
+type UnsafeMonitoringServiceServer interface {
+	mustEmbedUnimplementedMonitoringServiceServer()
+}
+
+func RegisterMonitoringServiceServer(s grpc.ServiceRegistrar, srv MonitoringServiceServer) {
+	s.RegisterService(&MonitoringService_ServiceDesc, srv)
+}
+
+func _MonitoringService_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitoringServiceServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitoringService_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitoringServiceServer).GetHealth(ctx, req.(*GetHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitoringService_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitoringServiceServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitoringService_GetMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitoringServiceServer).GetMetrics(ctx, req.(*GetMetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitoringService_WatchMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchMetricsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonitoringServiceServer).WatchMetrics(m, &monitoringServiceWatchMetricsServer{ServerStream: stream})
+}
+
+type MonitoringService_WatchMetricsServer interface {
+	Send(*Metrics) error
+	grpc.ServerStream
+}
+
+type monitoringServiceWatchMetricsServer struct {
+	grpc.ServerStream
+}
+
+func (x *monitoringServiceWatchMetricsServer) Send(m *Metrics) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// MonitoringService_ServiceDesc is the grpc.ServiceDesc for MonitoringService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MonitoringService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgha.v1.MonitoringService",
+	HandlerType: (*MonitoringServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHealth",
+			Handler:    _MonitoringService_GetHealth_Handler,
+		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _MonitoringService_GetMetrics_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchMetrics",
+			Handler:       _MonitoringService_WatchMetrics_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pgha/v1/pgha.proto",
+}
+
+const (
+	ItemsService_GetItem_FullMethodName   = "/pgha.v1.ItemsService/GetItem"
+	ItemsService_ListItems_FullMethodName = "/pgha.v1.ItemsService/ListItems"
+)
+
+// ItemsServiceClient is the client API for ItemsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ItemsService reads the items table, falling back to a replica while the
+// primary is unreachable.
+type ItemsServiceClient interface {
+	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error)
+	// ListItems streams items ordered by id, read as fast as the client
+	// takes them.
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (ItemsService_ListItemsClient, error)
+}
+
+type itemsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewItemsServiceClient(cc grpc.ClientConnInterface) ItemsServiceClient {
+	return &itemsServiceClient{cc}
+}
+
+func (c *itemsServiceClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, ItemsService_GetItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *itemsServiceClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (ItemsService_ListItemsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ItemsService_ServiceDesc.Streams[0], ItemsService_ListItems_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &itemsServiceListItemsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ItemsService_ListItemsClient interface {
+	Recv() (*Item, error)
+	grpc.ClientStream
+}
+
+type itemsServiceListItemsClient struct {
+	grpc.ClientStream
+}
+
+func (x *itemsServiceListItemsClient) Recv() (*Item, error) {
+	m := new(Item)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ItemsServiceServer is the server API for ItemsService service.
+// All implementations must embed UnimplementedItemsServiceServer
+// for forward compatibility
+//
+// ItemsService reads the items table, falling back to a replica while the
+// primary is unreachable.
+type ItemsServiceServer interface {
+	GetItem(context.Context, *GetItemRequest) (*Item, error)
+	// ListItems streams items ordered by id, read as fast as the client
+	// takes them.
+	ListItems(*ListItemsRequest, ItemsService_ListItemsServer) error
+	mustEmbedUnimplementedItemsServiceServer()
+}
+
+// UnimplementedItemsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedItemsServiceServer struct {
+}
+
+func (UnimplementedItemsServiceServer) GetItem(context.Context, *GetItemRequest) (*Item, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetItem not implemented")
+}
+func (UnimplementedItemsServiceServer) ListItems(*ListItemsRequest, ItemsService_ListItemsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListItems not implemented")
+}
+func (UnimplementedItemsServiceServer) mustEmbedUnimplementedItemsServiceServer() {}
+
+// UnsafeItemsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ItemsServiceServer will
+// result in compilation errors.
+type UnsafeItemsServiceServer interface {
+	mustEmbedUnimplementedItemsServiceServer()
+}
+
+func RegisterItemsServiceServer(s grpc.ServiceRegistrar, srv ItemsServiceServer) {
+	s.RegisterService(&ItemsService_ServiceDesc, srv)
+}
+
+func _ItemsService_GetItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemsServiceServer).GetItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ItemsService_GetItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemsServiceServer).GetItem(ctx, req.(*GetItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ItemsService_ListItems_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListItemsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ItemsServiceServer).ListItems(m, &itemsServiceListItemsServer{ServerStream: stream})
+}
+
+type ItemsService_ListItemsServer interface {
+	Send(*Item) error
+	grpc.ServerStream
+}
+
+type itemsServiceListItemsServer struct {
+	grpc.ServerStream
+}
+
+func (x *itemsServiceListItemsServer) Send(m *Item) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ItemsService_ServiceDesc is the grpc.ServiceDesc for ItemsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ItemsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgha.v1.ItemsService",
+	HandlerType: (*ItemsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetItem",
+			Handler:    _ItemsService_GetItem_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListItems",
+			Handler:       _ItemsService_ListItems_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pgha/v1/pgha.proto",
+}
+
+const (
+	BackupsService_ListBackups_FullMethodName     = "/pgha.v1.BackupsService/ListBackups"
+	BackupsService_GetBackupStatus_FullMethodName = "/pgha.v1.BackupsService/GetBackupStatus"
+)
+
+// BackupsServiceClient is the client API for BackupsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BackupsService reports the backups of every configured stanza, as GET
+// /backups does.
+type BackupsServiceClient interface {
+	ListBackups(ctx context.Context, in *ListBackupsRequest, opts ...grpc.CallOption) (*ListBackupsResponse, error)
+	GetBackupStatus(ctx context.Context, in *GetBackupStatusRequest, opts ...grpc.CallOption) (*BackupStatus, error)
+}
+
+type backupsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBackupsServiceClient(cc grpc.ClientConnInterface) BackupsServiceClient {
+	return &backupsServiceClient{cc}
+}
+
+func (c *backupsServiceClient) ListBackups(ctx context.Context, in *ListBackupsRequest, opts ...grpc.CallOption) (*ListBackupsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBackupsResponse)
+	err := c.cc.Invoke(ctx, BackupsService_ListBackups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backupsServiceClient) GetBackupStatus(ctx context.Context, in *GetBackupStatusRequest, opts ...grpc.CallOption) (*BackupStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BackupStatus)
+	err := c.cc.Invoke(ctx, BackupsService_GetBackupStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BackupsServiceServer is the server API for BackupsService service.
+// All implementations must embed UnimplementedBackupsServiceServer
+// for forward compatibility
+//
+// BackupsService reports the backups of every configured stanza, as GET
+// /backups does.
+type BackupsServiceServer interface {
+	ListBackups(context.Context, *ListBackupsRequest) (*ListBackupsResponse, error)
+	GetBackupStatus(context.Context, *GetBackupStatusRequest) (*BackupStatus, error)
+	mustEmbedUnimplementedBackupsServiceServer()
+}
+
+// UnimplementedBackupsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBackupsServiceServer struct {
+}
+
+func (UnimplementedBackupsServiceServer) ListBackups(context.Context, *ListBackupsRequest) (*ListBackupsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBackups not implemented")
+}
+func (UnimplementedBackupsServiceServer) GetBackupStatus(context.Context, *GetBackupStatusRequest) (*BackupStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBackupStatus not implemented")
+}
+func (UnimplementedBackupsServiceServer) mustEmbedUnimplementedBackupsServiceServer() {}
+
+// UnsafeBackupsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BackupsServiceServer will
+// result in compilation errors.
+type UnsafeBackupsServiceServer interface {
+	mustEmbedUnimplementedBackupsServiceServer()
+}
+
+func RegisterBackupsServiceServer(s grpc.ServiceRegistrar, srv BackupsServiceServer) {
+	s.RegisterService(&BackupsService_ServiceDesc, srv)
+}
+
+func _BackupsService_ListBackups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBackupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackupsServiceServer).ListBackups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackupsService_ListBackups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackupsServiceServer).ListBackups(ctx, req.(*ListBackupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackupsService_GetBackupStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBackupStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackupsServiceServer).GetBackupStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackupsService_GetBackupStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackupsServiceServer).GetBackupStatus(ctx, req.(*GetBackupStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BackupsService_ServiceDesc is the grpc.ServiceDesc for BackupsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BackupsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgha.v1.BackupsService",
+	HandlerType: (*BackupsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBackups",
+			Handler:    _BackupsService_ListBackups_Handler,
+		},
+		{
+			MethodName: "GetBackupStatus",
+			Handler:    _BackupsService_GetBackupStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pgha/v1/pgha.proto",
+}
+
+const (
+	ClusterService_GetCluster_FullMethodName  = "/pgha.v1.ClusterService/GetCluster"
+	ClusterService_Switchover_FullMethodName  = "/pgha.v1.ClusterService/Switchover"
+	ClusterService_Failover_FullMethodName    = "/pgha.v1.ClusterService/Failover"
+	ClusterService_GetJob_FullMethodName      = "/pgha.v1.ClusterService/GetJob"
+	ClusterService_WatchEvents_FullMethodName = "/pgha.v1.ClusterService/WatchEvents"
+)
+
+// ClusterServiceClient is the client API for ClusterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ClusterService reports the HA cluster and changes its leader. Switchover
+// and Failover take an API key with the admin role and return the async
+// job, with the checks of POST /cluster/switchover and /cluster/failover.
+type ClusterServiceClient interface {
+	GetCluster(ctx context.Context, in *GetClusterRequest, opts ...grpc.CallOption) (*ClusterStatus, error)
+	Switchover(ctx context.Context, in *SwitchoverRequest, opts ...grpc.CallOption) (*Job, error)
+	Failover(ctx context.Context, in *FailoverRequest, opts ...grpc.CallOption) (*Job, error)
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// WatchEvents sends the retained events after after_id, then each new
+	// one as it is published, until the call is cancelled.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ClusterService_WatchEventsClient, error)
+}
+
+type clusterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClusterServiceClient(cc grpc.ClientConnInterface) ClusterServiceClient {
+	return &clusterServiceClient{cc}
+}
+
+func (c *clusterServiceClient) GetCluster(ctx context.Context, in *GetClusterRequest, opts ...grpc.CallOption) (*ClusterStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterStatus)
+	err := c.cc.Invoke(ctx, ClusterService_GetCluster_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) Switchover(ctx context.Context, in *SwitchoverRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, ClusterService_Switchover_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) Failover(ctx context.Context, in *FailoverRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, ClusterService_Failover_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, ClusterService_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (ClusterService_WatchEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ClusterService_ServiceDesc.Streams[0], ClusterService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clusterServiceWatchEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClusterService_WatchEventsClient interface {
+	Recv() (*ClusterEvent, error)
+	grpc.ClientStream
+}
+
+type clusterServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *clusterServiceWatchEventsClient) Recv() (*ClusterEvent, error) {
+	m := new(ClusterEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClusterServiceServer is the server API for ClusterService service.
+// All implementations must embed UnimplementedClusterServiceServer
+// for forward compatibility
+//
+// ClusterService reports the HA cluster and changes its leader. Switchover
+// and Failover take an API key with the admin role and return the async
+// job, with the checks of POST /cluster/switchover and /cluster/failover.
+type ClusterServiceServer interface {
+	GetCluster(context.Context, *GetClusterRequest) (*ClusterStatus, error)
+	Switchover(context.Context, *SwitchoverRequest) (*Job, error)
+	Failover(context.Context, *FailoverRequest) (*Job, error)
+	GetJob(context.Context, *GetJobRequest) (*Job, error)
+	// WatchEvents sends the retained events after after_id, then each new
+	// one as it is published, until the call is cancelled.
+	WatchEvents(*WatchEventsRequest, ClusterService_WatchEventsServer) error
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+// UnimplementedClusterServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedClusterServiceServer struct {
+}
+
+func (UnimplementedClusterServiceServer) GetCluster(context.Context, *GetClusterRequest) (*ClusterStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCluster not implemented")
+}
+func (UnimplementedClusterServiceServer) Switchover(context.Context, *SwitchoverRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Switchover not implemented")
+}
+func (UnimplementedClusterServiceServer) Failover(context.Context, *FailoverRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Failover not implemented")
+}
+func (UnimplementedClusterServiceServer) GetJob(context.Context, *GetJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedClusterServiceServer) WatchEvents(*WatchEventsRequest, ClusterService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedClusterServiceServer) mustEmbedUnimplementedClusterServiceServer() {}
+
+// UnsafeClusterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClusterServiceServer will
+// result in compilation errors.
+type UnsafeClusterServiceServer interface {
+	mustEmbedUnimplementedClusterServiceServer()
+}
+
+func RegisterClusterServiceServer(s grpc.ServiceRegistrar, srv ClusterServiceServer) {
+	s.RegisterService(&ClusterService_ServiceDesc, srv)
+}
+
+func _ClusterService_GetCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).GetCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_GetCluster_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).GetCluster(ctx, req.(*GetClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_Switchover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwitchoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).Switchover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_Switchover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).Switchover(ctx, req.(*SwitchoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_Failover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FailoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).Failover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_Failover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).Failover(ctx, req.(*FailoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServiceServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClusterService_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServiceServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClusterServiceServer).WatchEvents(m, &clusterServiceWatchEventsServer{ServerStream: stream})
+}
+
+type ClusterService_WatchEventsServer interface {
+	Send(*ClusterEvent) error
+	grpc.ServerStream
+}
+
+type clusterServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *clusterServiceWatchEventsServer) Send(m *ClusterEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ClusterService_ServiceDesc is the grpc.ServiceDesc for ClusterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ClusterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgha.v1.ClusterService",
+	HandlerType: (*ClusterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCluster",
+			Handler:    _ClusterService_GetCluster_Handler,
+		},
+		{
+			MethodName: "Switchover",
+			Handler:    _ClusterService_Switchover_Handler,
+		},
+		{
+			MethodName: "Failover",
+			Handler:    _ClusterService_Failover_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _ClusterService_GetJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _ClusterService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pgha/v1/pgha.proto",
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/alerts"
+)
+
+func TestAlertTrackerKeepsStartTime(t *testing.T) {
+	tracker := alerts.NewTracker()
+
+	tracker.Update([]alerts.Breach{{Rule: "cache_hit_ratio", Severity: alerts.SeverityWarning}})
+	first := tracker.Active()
+	if len(first) != 1 {
+		t.Fatalf("Expected 1 active alert, got %d", len(first))
+	}
+
+	time.Sleep(time.Millisecond)
+	tracker.Update([]alerts.Breach{
+		{Rule: "cache_hit_ratio", Severity: alerts.SeverityWarning, Message: "updated"},
+		{Rule: "backup_age", Severity: alerts.SeverityCritical},
+	})
+
+	active := tracker.Active()
+	if len(active) != 2 {
+		t.Fatalf("Expected 2 active alerts, got %d", len(active))
+	}
+	if active[0].Rule != "cache_hit_ratio" {
+		t.Errorf("Expected oldest alert first, got '%s'", active[0].Rule)
+	}
+	if !active[0].Since.Equal(first[0].Since) {
+		t.Errorf("Expected start time to be kept, got %v (was %v)", active[0].Since, first[0].Since)
+	}
+	if active[0].Message != "updated" {
+		t.Errorf("Expected message to be updated, got '%s'", active[0].Message)
+	}
+}
+
+func TestAlertTrackerResolves(t *testing.T) {
+	tracker := alerts.NewTracker()
+
+	fired, _ := tracker.Update([]alerts.Breach{{Rule: "connection_usage"}})
+	if len(fired) != 1 || fired[0].Rule != "connection_usage" {
+		t.Errorf("Expected connection_usage to fire, got %+v", fired)
+	}
+	if fired, _ := tracker.Update([]alerts.Breach{{Rule: "connection_usage"}}); len(fired) != 0 {
+		t.Errorf("Expected a firing alert not to fire again, got %+v", fired)
+	}
+	_, resolved := tracker.Update(nil)
+	if len(resolved) != 1 || resolved[0].Rule != "connection_usage" {
+		t.Errorf("Expected connection_usage to be resolved, got %+v", resolved)
+	}
+
+	if n := len(tracker.Active()); n != 0 {
+		t.Errorf("Expected resolved alert to be removed, got %d active", n)
+	}
+	if tracker.LastEvaluated().IsZero() {
+		t.Error("Expected last evaluation time to be set")
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+)
+
+func TestAPIVersionStripPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/v1/items":     "/items",
+		"/v1":           "/",
+		"/v12/admin/x":  "/admin/x",
+		"/items":        "/items",
+		"/v1items":      "/v1items",
+		"/v0/items":     "/v0/items",
+		"/versions/v1/": "/versions/v1/",
+	}
+	for path, want := range tests {
+		if got := apiversion.StripPrefix(path); got != want {
+			t.Errorf("Expected %s for %s, got %s", want, path, got)
+		}
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := []config.APIKey{{Name: "ops", Key: "k1", Role: config.RoleOperator}}
+	rules := []middleware.AccessRule{{Path: "/admin/", Role: config.RoleAdmin}}
+
+	router := gin.New()
+	router.Use(middleware.Authorize(keys, rules))
+	for _, api := range []*gin.RouterGroup{
+		router.Group("", middleware.APIVersion(apiversion.Legacy)),
+		router.Group(apiversion.V1.Prefix(), middleware.APIVersion(apiversion.V1)),
+	} {
+		api.GET("/items", func(c *gin.Context) {
+			c.String(http.StatusOK, strconv.Itoa(int(apiversion.Get(c))))
+		})
+		api.POST("/admin/migrate", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+
+	tests := []struct {
+		method, path string
+		code         int
+		body, link   string
+	}{
+		{"GET", "/items", http.StatusOK, "0", `</v1/items>; rel="successor-version"`},
+		{"GET", "/v1/items", http.StatusOK, "1", ""},
+		// Access rules hold on every version
+		{"POST", "/admin/migrate", http.StatusForbidden, "", ""},
+		{"POST", "/v1/admin/migrate", http.StatusForbidden, "", ""},
+		{"GET", "/v2/items", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("X-API-Key", "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s %s, got %d", tt.code, tt.method, tt.path, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("Expected version %s for %s, got %s", tt.body, tt.path, w.Body.String())
+		}
+		if tt.code == http.StatusOK && w.Header().Get("Link") != tt.link {
+			t.Errorf("Expected Link %q for %s, got %q", tt.link, tt.path, w.Header().Get("Link"))
+		}
+	}
+}
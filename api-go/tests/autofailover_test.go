@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/autofailover"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+)
+
+func TestFormationState(t *testing.T) {
+	f := &autofailover.Formation{Nodes: []autofailover.Node{
+		{ID: 1, Name: "node1", AssignedState: "primary", ReportedState: "primary", LSN: "0/3000000"},
+		{ID: 2, Name: "node2", AssignedState: "secondary", ReportedState: "secondary", LSN: "0/2FFF000"},
+		{ID: 3, Name: "node3", AssignedState: "secondary", ReportedState: "catchingup", LSN: "0/1000000"},
+	}}
+
+	primary, ok := f.Primary(0)
+	if !ok || primary.Name != "node1" {
+		t.Fatalf("Expected node1 as primary, got %+v", primary)
+	}
+	if role := f.Nodes[1].Role(); role != autofailover.RoleReplica {
+		t.Errorf("Expected node2 to be a replica, got %s", role)
+	}
+	if lag, ok := f.LagBytes(f.Nodes[1]); !ok || lag != 0x1000 {
+		t.Errorf("Expected node2 to lag 4096 bytes, got %d (%t)", lag, ok)
+	}
+	if _, ok := f.LagBytes(f.Nodes[0]); ok {
+		t.Error("Expected no lag for the primary")
+	}
+	if f.Nodes[1].Transitioning() || !f.Nodes[2].Transitioning() {
+		t.Error("Expected only node3 to be transitioning")
+	}
+
+	// During a failover the old primary drains and no node is primary yet
+	f.Nodes[0].ReportedState = "draining"
+	f.Nodes[1].ReportedState = "prepare_promotion"
+	if _, ok := f.Primary(0); ok {
+		t.Error("Expected no primary during a failover")
+	}
+	f.Nodes[1].ReportedState = "wait_primary"
+	if primary, ok := f.Primary(0); !ok || primary.Name != "node2" {
+		t.Errorf("Expected node2 as primary once in wait_primary, got %+v", primary)
+	}
+}
+
+func TestClusterMonitorUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		Database:     config.DatabaseConfig{ConnectTimeout: time.Second},
+		AutoFailover: config.AutoFailoverConfig{MonitorURI: "postgres://autoctl_node@127.0.0.1:1/pg_auto_failover", Formation: "default"},
+	}
+	m := jobs.NewManager(1, 1, 1, "")
+	h := handlers.NewClusterHandler(cfg, handlers.NewBackupsHandler(cfg, nil, m), m)
+	r := gin.New()
+	r.GET("/cluster", h.Status)
+	r.POST("/cluster/switchover", h.Switchover)
+
+	if w := clusterRequest(r, "GET", "/cluster", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502 for an unreachable monitor, got %d", w.Code)
+	}
+	if w := clusterRequest(r, "POST", "/cluster/switchover", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for a switchover without Patroni, got %d", w.Code)
+	}
+}
+
+func TestLoadAutoFailoverExclusive(t *testing.T) {
+	t.Setenv("PG_AUTOCTL_MONITOR", "postgres://autoctl_node@monitor/pg_auto_failover")
+	t.Setenv("PATRONI_URLS", "http://10.0.1.10:8008")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected an error with both PATRONI_URLS and PG_AUTOCTL_MONITOR")
+	}
+}
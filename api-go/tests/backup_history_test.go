@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func backupAt(label string, start time.Time, duration time.Duration, size, repoSize int64) models.BackupInfo {
+	stop := start.Add(duration)
+	return models.BackupInfo{
+		Label:             label,
+		Type:              "full",
+		StartTime:         &start,
+		StopTime:          &stop,
+		SizeBytes:         &size,
+		DatabaseSizeBytes: &repoSize,
+	}
+}
+
+func TestBackupHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now().UTC()
+
+	history, err := backup.NewHistory(path, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create history: %v", err)
+	}
+
+	failed := true
+	broken := backupAt("broken", now.Add(-time.Hour), time.Minute, 1, 1)
+	broken.Error = &failed
+	err = history.Record("main", []models.BackupInfo{
+		backupAt("expired", now.Add(-60*24*time.Hour), time.Minute, 100, 50),
+		backupAt("second", now.Add(-24*time.Hour), 2*time.Minute, 300, 100),
+		backupAt("first", now.Add(-48*time.Hour), time.Minute, 200, 100),
+		broken,
+	})
+	if err != nil {
+		t.Fatalf("Failed to record backups: %v", err)
+	}
+
+	reloaded, err := backup.NewHistory(path, 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to reload history: %v", err)
+	}
+	points := reloaded.Points("main", "full")
+	if len(points) != 2 {
+		t.Fatalf("Expected 2 points, got %d", len(points))
+	}
+	if points[0].Label != "first" || points[1].Label != "second" {
+		t.Errorf("Expected points ordered first, second; got %s, %s", points[0].Label, points[1].Label)
+	}
+	if points[1].DurationSeconds != 120 {
+		t.Errorf("Expected duration 120, got %v", points[1].DurationSeconds)
+	}
+	if points[1].CompressionRatio == nil || *points[1].CompressionRatio != 3 {
+		t.Errorf("Expected compression ratio 3, got %v", points[1].CompressionRatio)
+	}
+
+	// Backups expired from the repository stay in the history
+	if err := reloaded.Record("main", nil); err != nil {
+		t.Fatalf("Failed to record backups: %v", err)
+	}
+	if n := len(reloaded.Points("main", "")); n != 2 {
+		t.Errorf("Expected history to keep 2 points, got %d", n)
+	}
+}
+
+func TestBackupTrendsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The provider only reports dumps when pg_dump is installed
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "pg_dump"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	dumpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dumpDir, "app"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i, size := range []int{1000, 2000, 3000} {
+		label := now.Add(time.Duration(i-3) * 24 * time.Hour).Format("20060102T150405Z")
+		if err := os.WriteFile(filepath.Join(dumpDir, "app", label+".dump"), make([]byte, size), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Name: "app"},
+		Backup: config.BackupConfig{
+			Provider:       "pg_dump",
+			DumpDir:        dumpDir,
+			HistoryFile:    filepath.Join(t.TempDir(), "history.json"),
+			CommandTimeout: time.Second,
+		},
+	}
+	backupsHandler := handlers.NewBackupsHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	prometheusHandler := handlers.NewPrometheusHandler(backupsHandler.Collector())
+
+	router := gin.New()
+	router.GET("/backups", backupsHandler.Backups)
+	router.GET("/backups/trends", backupsHandler.Trends)
+	router.GET("/metrics/prometheus", prometheusHandler.Metrics)
+
+	// Listing the backups records them in the history
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/backups", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/backups/trends", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.BackupTrendsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Stanza != "app" || response.Type != "full" {
+		t.Errorf("Expected app/full, got %s/%s", response.Stanza, response.Type)
+	}
+	if response.Summary.Count != 3 {
+		t.Fatalf("Expected 3 points, got %d", response.Summary.Count)
+	}
+	growth := response.Summary.SizeGrowthBytesPerDay
+	if growth == nil || *growth < 999 || *growth > 1001 {
+		t.Errorf("Expected growth of about 1000 bytes/day, got %v", growth)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/backups/trends?type=incr", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for incr with pg_dump, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/metrics/prometheus", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `pgha_backup_size_bytes{stanza="app",type="full"} 3000`) {
+		t.Errorf("Expected backup size metric, got:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestStanzaCreateJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	binDir := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	script := "#!/bin/sh\necho \"$@\" >> " + argsFile + "\necho 'stanza-create command end: completed successfully'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pgbackrest"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			Binary:         "pgbackrest",
+			Stanza:         "main",
+			Stanzas:        []string{"reporting"},
+			Repo:           2,
+			CommandTimeout: time.Second,
+		},
+	}
+	h := handlers.NewBackupsHandler(cfg, nil, m)
+	router := gin.New()
+	router.POST("/backups/stanza", h.StanzaCreate)
+	router.POST("/backups/stanza/upgrade", h.StanzaUpgrade)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/backups/stanza", strings.NewReader(`{"stanza": "other"}`))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unconfigured stanza, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/backups/stanza", strings.NewReader(`{"stanza": "reporting"}`))
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if job.Type != handlers.JobTypeStanzaCreate {
+		t.Errorf("Expected job type '%s', got '%s'", handlers.JobTypeStanzaCreate, job.Type)
+	}
+
+	done := waitForJob(t, m, job.ID)
+	if done.Status != jobs.StatusSucceeded {
+		t.Fatalf("Expected job to succeed, got '%s': %s", done.Status, done.Error)
+	}
+
+	// Without a body the default stanza is upgraded
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/backups/stanza/upgrade", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &job)
+	waitForJob(t, m, job.ID)
+
+	// Each job also refreshes the stanza info afterwards
+	args, _ := os.ReadFile(argsFile)
+	var create, upgrade string
+	for _, call := range strings.Split(string(args), "\n") {
+		switch {
+		case strings.Contains(call, "stanza-create"):
+			create = call
+		case strings.Contains(call, "stanza-upgrade"):
+			upgrade = call
+		}
+	}
+	if !strings.HasSuffix(create, "--stanza=reporting stanza-create") || strings.Contains(create, "--repo") {
+		t.Errorf("Unexpected stanza-create arguments %q", create)
+	}
+	if !strings.HasSuffix(upgrade, "--stanza=main stanza-upgrade") {
+		t.Errorf("Unexpected stanza-upgrade arguments %q", upgrade)
+	}
+}
+
+func TestStanzaCreateUnsupported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Name: "app"},
+		Backup:   config.BackupConfig{Provider: "pg_dump"},
+	}
+	h := handlers.NewBackupsHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	router := gin.New()
+	router.POST("/backups/stanza", h.StanzaCreate)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/backups/stanza", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
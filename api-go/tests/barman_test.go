@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+const fakeBarman = `#!/bin/sh
+case "$3" in
+list-backup)
+	echo '{"pg": [
+		{"backup_id": "20240102T010000", "status": "DONE", "end_time_timestamp": "1704160000", "size_bytes": 2048, "wal_size_bytes": 0},
+		{"backup_id": "20240103T010000", "status": "STARTED"},
+		{"backup_id": "20240101T010000", "status": "DONE", "end_time_timestamp": "1704070000", "size_bytes": 1024, "wal_size_bytes": 16}
+	]}'
+	;;
+status)
+	echo '{"pg": {"last_archived_wal": {"description": "Last archived WAL", "message": "000000010000000000000010, at Wed Jan  3 01:00:00 2024"}}}'
+	;;
+esac
+`
+
+func barmanConfig() *config.Config {
+	return &config.Config{
+		Backup: config.BackupConfig{
+			Provider:               "barman",
+			BarmanServers:          []string{"pg"},
+			BarmanRemoteSSHCommand: "ssh postgres@db1",
+			DataDir:                "/var/lib/postgresql/data",
+		},
+	}
+}
+
+func TestBarmanInfo(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "barman"), []byte(fakeBarman), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	provider := backup.New(barmanConfig())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := provider.Info(ctx, "pg")
+	if status.Status != "ok" {
+		t.Fatalf("Expected status 'ok', got '%s' (%v)", status.Status, status.StatusMessage)
+	}
+	if status.Provider != "barman" {
+		t.Errorf("Expected provider 'barman', got '%s'", status.Provider)
+	}
+	if len(status.Backups) != 2 {
+		t.Fatalf("Expected 2 completed backups, got %d", len(status.Backups))
+	}
+	if status.Backups[0].Label != "20240101T010000" || status.Backups[1].Label != "20240102T010000" {
+		t.Errorf("Expected backups oldest first, got %s, %s", status.Backups[0].Label, status.Backups[1].Label)
+	}
+	if status.LastFullBackup == nil || status.LastFullBackup.Unix() != 1704160000 {
+		t.Errorf("Unexpected last full backup %v", status.LastFullBackup)
+	}
+	if status.WALArchive == nil || *status.WALArchive.MaxWAL != "000000010000000000000010" {
+		t.Errorf("Expected last archived WAL from barman status, got %+v", status.WALArchive)
+	}
+}
+
+func TestBarmanRestoreCommand(t *testing.T) {
+	provider := backup.New(barmanConfig())
+
+	cmd, err := provider.RestoreCommand("pg", models.RestoreRequest{Target: models.RestoreTargetLSN, LSN: "0/3000060"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "barman recover --target-lsn 0/3000060 --target-action promote --remote-ssh-command ssh postgres@db1 pg latest /var/lib/postgresql/data"
+	if got := strings.Join(cmd.Argv, " "); got != expected {
+		t.Errorf("Expected command %q, got %q", expected, got)
+	}
+
+	if _, err := provider.RestoreCommand("pg", models.RestoreRequest{Target: models.RestoreTargetLatest, Delta: true}); err == nil {
+		t.Error("Expected delta restore to be rejected")
+	}
+	if _, err := provider.RestoreCommand("pg", models.RestoreRequest{Target: models.RestoreTargetBackup, BackupLabel: "20240101-010000F"}); err == nil {
+		t.Error("Expected a pgbackrest label to be rejected")
+	}
+}
@@ -0,0 +1,308 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// fakePatroni serves /cluster and moves leadership on /switchover and
+// /failover.
+type fakePatroni struct {
+	mu       sync.Mutex
+	leader   string
+	lag      map[string]interface{}
+	paused   bool
+	requests []string
+}
+
+func (f *fakePatroni) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/cluster":
+		var members []map[string]interface{}
+		for _, name := range []string{"pg1", "pg2", "pg3"} {
+			m := map[string]interface{}{"name": name, "role": "replica", "state": "streaming", "timeline": 2}
+			if name == f.leader {
+				m["role"], m["state"], m["timeline"] = "leader", "running", 3
+			} else {
+				m["lag"] = f.lag[name]
+			}
+			members = append(members, m)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"members": members, "pause": f.paused})
+	case "/config":
+		var body map[string]bool
+		json.NewDecoder(r.Body).Decode(&body)
+		f.paused = body["pause"]
+		json.NewEncoder(w).Encode(body)
+	case "/switchover", "/failover":
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		f.requests = append(f.requests, r.URL.Path+" "+body["leader"]+">"+body["candidate"])
+		f.leader = body["candidate"]
+		w.Write([]byte("Successfully switched over"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func setupClusterRouter(t *testing.T, patroniURL string, maxBackupAge time.Duration) (*gin.Engine, *jobs.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PATH", t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{Binary: "pgbackrest", Stanza: "main", CommandTimeout: time.Second},
+		Patroni: config.PatroniConfig{
+			URLs:              []string{"http://127.0.0.1:1", patroniURL},
+			Timeout:           time.Second,
+			MaxLagBytes:       1024,
+			MaxBackupAge:      maxBackupAge,
+			SwitchoverTimeout: 5 * time.Second,
+		},
+	}
+	h := handlers.NewClusterHandler(cfg, handlers.NewBackupsHandler(cfg, nil, m), m)
+
+	router := gin.New()
+	router.GET("/cluster", h.Status)
+	router.POST("/cluster/switchover", h.Switchover)
+	router.POST("/cluster/failover", h.Failover)
+	return router, m
+}
+
+func clusterRequest(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestClusterStatus(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1", lag: map[string]interface{}{"pg2": 0, "pg3": "unknown"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	router, _ := setupClusterRouter(t, server.URL, 0)
+
+	w := clusterRequest(router, "GET", "/cluster", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.ClusterResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Leader == nil || *response.Leader != "pg1" || len(response.Members) != 3 {
+		t.Fatalf("Unexpected cluster %+v", response)
+	}
+	if lag := response.Members[1].LagBytes; lag == nil || *lag != 0 {
+		t.Errorf("Expected pg2 lag 0, got %v", lag)
+	}
+	if lag := response.Members[2].LagBytes; lag != nil {
+		t.Errorf("Expected unknown pg3 lag to be omitted, got %d", *lag)
+	}
+}
+
+func TestClusterNotConfigured(t *testing.T) {
+	router, _ := setupClusterRouter(t, "", 0)
+	// Only the unreachable URL remains
+	if w := clusterRequest(router, "GET", "/cluster", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502 for an unreachable Patroni, got %d", w.Code)
+	}
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Patroni: config.PatroniConfig{Timeout: time.Second}}
+	m := jobs.NewManager(1, 1, 1, "")
+	h := handlers.NewClusterHandler(cfg, handlers.NewBackupsHandler(cfg, nil, m), m)
+	r := gin.New()
+	r.GET("/cluster", h.Status)
+	if w := clusterRequest(r, "GET", "/cluster", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without PATRONI_URLS, got %d", w.Code)
+	}
+}
+
+func TestSwitchoverPreconditions(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1", lag: map[string]interface{}{"pg2": 4096, "pg3": "unknown"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	router, _ := setupClusterRouter(t, server.URL, 24*time.Hour)
+
+	w := clusterRequest(router, "POST", "/cluster/switchover", `{"candidate": "pg2"}`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected status 412, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.PreconditionFailedResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Failures) != 2 ||
+		!strings.Contains(response.Failures[0], "pg2 lags 4096 bytes") ||
+		!strings.Contains(response.Failures[1], "no backup of main") {
+		t.Errorf("Unexpected failures %q", response.Failures)
+	}
+
+	// Forced failovers skip the lag and backup checks but not membership
+	w = clusterRequest(router, "POST", "/cluster/failover", `{"candidate": "pg1", "force": true}`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for failing over to the leader, got %d", w.Code)
+	}
+	w = clusterRequest(router, "POST", "/cluster/failover", `{}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a candidate, got %d", w.Code)
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("Expected no request to reach Patroni, got %v", fake.requests)
+	}
+}
+
+func TestSwitchoverJob(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1", lag: map[string]interface{}{"pg2": 0, "pg3": 100}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	router, m := setupClusterRouter(t, server.URL, 0)
+
+	w := clusterRequest(router, "POST", "/cluster/switchover", `{"candidate": "pg3"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	done := waitForJob(t, m, job.ID)
+	if done.Status != jobs.StatusSucceeded {
+		t.Fatalf("Expected job to succeed, got '%s': %s", done.Status, done.Error)
+	}
+	result, _ := done.Result.(map[string]interface{})
+	if result["old_leader"] != "pg1" || result["new_leader"] != "pg3" {
+		t.Errorf("Unexpected result %v", done.Result)
+	}
+	if len(fake.requests) != 1 || fake.requests[0] != "/switchover pg1>pg3" {
+		t.Errorf("Unexpected Patroni requests %v", fake.requests)
+	}
+}
+
+func TestClusterDCSHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	patroniServer := httptest.NewServer(&fakePatroni{leader: "pg1"})
+	defer patroniServer.Close()
+
+	etcd := func(healthy bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				http.NotFound(w, r)
+				return
+			}
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"health":"false","reason":"RAFT NO LEADER"}`))
+				return
+			}
+			w.Write([]byte(`{"health":"true","reason":""}`))
+		}))
+	}
+	good, bad := etcd(true), etcd(false)
+	defer good.Close()
+	defer bad.Close()
+
+	status := func(urls ...string) models.ClusterResponse {
+		cfg := &config.Config{Patroni: config.PatroniConfig{
+			URLs:    []string{patroniServer.URL},
+			Timeout: time.Second,
+			DCSType: "etcd",
+			DCSURLs: urls,
+		}}
+		m := jobs.NewManager(1, 1, 1, "")
+		h := handlers.NewClusterHandler(cfg, handlers.NewBackupsHandler(cfg, nil, m), m)
+		r := gin.New()
+		r.GET("/cluster", h.Status)
+
+		var response models.ClusterResponse
+		json.Unmarshal(clusterRequest(r, "GET", "/cluster", "").Body.Bytes(), &response)
+		return response
+	}
+
+	response := status(good.URL, bad.URL, "http://127.0.0.1:1")
+	if response.Status != "dcs_degraded" || response.DCS == nil {
+		t.Fatalf("Expected status dcs_degraded, got %s", response.Status)
+	}
+	if !response.DCS.Quorum || response.DCS.Healthy || len(response.DCS.Endpoints) != 3 {
+		t.Errorf("Unexpected DCS status %+v", response.DCS)
+	}
+	if e := response.DCS.Endpoints[1]; e.Healthy || !strings.Contains(e.Error, "RAFT NO LEADER") {
+		t.Errorf("Expected the second endpoint to report no leader, got %+v", e)
+	}
+
+	if response = status(bad.URL); response.Status != "dcs_no_quorum" {
+		t.Errorf("Expected status dcs_no_quorum, got %s", response.Status)
+	}
+	if response = status(good.URL); response.Status != "ok" || !response.DCS.Healthy {
+		t.Errorf("Expected a healthy DCS, got %s %+v", response.Status, response.DCS)
+	}
+}
+
+func TestClusterMaintenance(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Patroni: config.PatroniConfig{URLs: []string{server.URL}, Timeout: time.Second}}
+	m := jobs.NewManager(1, 1, 1, "")
+	backups := handlers.NewBackupsHandler(cfg, nil, m)
+	h := handlers.NewClusterHandler(cfg, backups, m)
+	health := handlers.NewHealthHandler(cfg, nil, backups)
+	health.UseMaintenance(h.Maintenance())
+
+	router := gin.New()
+	router.GET("/ready", health.Ready)
+	router.GET("/cluster/maintenance", h.GetMaintenance)
+	router.POST("/cluster/maintenance", h.SetMaintenance)
+
+	if w := clusterRequest(router, "POST", "/cluster/maintenance", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without enabled, got %d", w.Code)
+	}
+
+	w := clusterRequest(router, "POST", "/cluster/maintenance", `{"enabled": true, "reason": "kernel patching"}`)
+	var response models.MaintenanceResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || !response.Enabled || response.Since == nil {
+		t.Fatalf("Expected maintenance to be enabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if !fake.paused || response.PatroniPaused == nil || !*response.PatroniPaused {
+		t.Errorf("Expected Patroni to be paused")
+	}
+
+	w = clusterRequest(router, "GET", "/ready", "")
+	var ready models.ReadyResponse
+	json.Unmarshal(w.Body.Bytes(), &ready)
+	if w.Code != http.StatusServiceUnavailable || ready.Status != "maintenance" || !strings.Contains(ready.Reason, "kernel patching") {
+		t.Errorf("Expected /ready to report maintenance, got %d: %s", w.Code, w.Body.String())
+	}
+
+	clusterRequest(router, "POST", "/cluster/maintenance", `{"enabled": false}`)
+	w = clusterRequest(router, "GET", "/cluster/maintenance", "")
+	response = models.MaintenanceResponse{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Enabled || fake.paused || response.PatroniPaused == nil || *response.PatroniPaused {
+		t.Errorf("Expected maintenance to be off, got %s", w.Body.String())
+	}
+	json.Unmarshal(clusterRequest(router, "GET", "/ready", "").Body.Bytes(), &ready)
+	if ready.Status == "maintenance" {
+		t.Errorf("Expected /ready to leave maintenance")
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestClustersWithoutConnection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PATH", t.TempDir())
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Host: "pg1", Port: 5432},
+		Backup:   config.BackupConfig{Binary: "pgbackrest", Stanza: "main", CommandTimeout: time.Second},
+		Metrics:  config.MetricsConfig{HistoryInterval: time.Second, HistoryWindow: time.Minute},
+	}
+	m := jobs.NewManager(1, 1, 1, "")
+	h := handlers.NewClustersHandler(
+		handlers.NewMonitoredCluster("default", cfg, nil, m),
+		handlers.NewMonitoredCluster("reporting", cfg.ForCluster(config.ClusterTarget{Name: "reporting", Host: "pg-rep", Port: 5433, Stanza: "rep"}), nil, m),
+	)
+	router := gin.New()
+	router.GET("/clusters", h.List)
+	h.Routes(router.Group("/clusters/:name"))
+
+	w := clusterRequest(router, "GET", "/clusters", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response models.ClustersResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 2 || !response.Clusters[0].Default || response.Clusters[1].Name != "reporting" {
+		t.Fatalf("Unexpected clusters %+v", response.Clusters)
+	}
+	if r := response.Clusters[1]; r.Reachable || r.Host != "pg-rep" || r.Port != 5433 || r.Stanza != "rep" {
+		t.Errorf("Unexpected reporting cluster %+v", r)
+	}
+
+	if w := clusterRequest(router, "GET", "/clusters/reporting/locks", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a pool, got %d", w.Code)
+	}
+	w = clusterRequest(router, "GET", "/clusters/missing/metrics", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown cluster, got %d", w.Code)
+	}
+}
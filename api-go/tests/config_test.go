@@ -0,0 +1,283 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Database.PoolMaxSize != 20 {
+		t.Errorf("Expected pool max size 20, got %d", cfg.Database.PoolMaxSize)
+	}
+
+	if cfg.Backup.CommandTimeout != 30*time.Second {
+		t.Errorf("Expected command timeout 30s, got %s", cfg.Backup.CommandTimeout)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Setenv("APP_PROFILE", "prod-dr")
+	t.Setenv("DB_POOL_MIN_SIZE", "8")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Database.PoolMaxSize != 50 {
+		t.Errorf("Expected profile pool max size 50, got %d", cfg.Database.PoolMaxSize)
+	}
+
+	// Explicit environment variables override the profile
+	if cfg.Database.PoolMinSize != 8 {
+		t.Errorf("Expected pool min size 8 from env, got %d", cfg.Database.PoolMinSize)
+	}
+
+	if cfg.Health.ReadyRolePolicy != config.RolePolicyRequirePrimary {
+		t.Errorf("Expected role policy '%s', got '%s'", config.RolePolicyRequirePrimary, cfg.Health.ReadyRolePolicy)
+	}
+}
+
+func TestLoadUnknownProfile(t *testing.T) {
+	t.Setenv("APP_PROFILE", "qa")
+
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
+
+func TestLoadRejectsNonPositiveInterval(t *testing.T) {
+	t.Setenv("METRICS_HISTORY_INTERVAL", "0s")
+
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+}
+
+func TestLoadGRPCPort(t *testing.T) {
+	t.Setenv("GRPC_PORT", "9090")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.GRPC.Port != 9090 {
+		t.Errorf("Expected gRPC port 9090, got %d", cfg.GRPC.Port)
+	}
+
+	// The REST API cannot share its port
+	t.Setenv("PORT", "9090")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for a gRPC port equal to PORT")
+	}
+}
+
+func TestStanzaList(t *testing.T) {
+	t.Setenv("PGBACKREST_STANZA", "main")
+	t.Setenv("PGBACKREST_STANZAS", "reporting, main,archive")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	got := cfg.Backup.StanzaList()
+	want := []string{"main", "reporting", "archive"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected stanzas %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected stanzas %v, got %v", want, got)
+			break
+		}
+	}
+
+	if cfg.Backup.HasStanza("other") {
+		t.Error("Expected unconfigured stanza to be rejected")
+	}
+}
+
+func TestLoadTLSRepoHostRequiresCertificate(t *testing.T) {
+	t.Setenv("PGBACKREST_REPO1_HOST", "repo1")
+	t.Setenv("PGBACKREST_REPO1_HOST_TYPE", "tls")
+
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for TLS repo host without client certificate")
+	}
+
+	t.Setenv("PGBACKREST_REPO1_HOST_CERT_FILE", "/etc/pgbackrest/client.crt")
+	t.Setenv("PGBACKREST_REPO1_HOST_KEY_FILE", "/etc/pgbackrest/client.key")
+
+	if _, err := config.Load(); err != nil {
+		t.Errorf("Expected TLS repo host config to load, got %v", err)
+	}
+}
+
+func TestLoadPgBackRestInvocation(t *testing.T) {
+	t.Setenv("PGBACKREST_EXTRA_ARGS", "--process-max=4,--log-level-console=info")
+	t.Setenv("PGBACKREST_REPO", "2")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected config to load, got %v", err)
+	}
+	if len(cfg.Backup.ExtraArgs) != 2 || cfg.Backup.ExtraArgs[1] != "--log-level-console=info" {
+		t.Errorf("Unexpected extra args %v", cfg.Backup.ExtraArgs)
+	}
+
+	t.Setenv("PGBACKREST_EXTRA_ARGS", "process-max=4")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for an extra argument without --")
+	}
+
+	t.Setenv("PGBACKREST_EXTRA_ARGS", "")
+	t.Setenv("PGBACKREST_BIN", "/nonexistent/pgbackrest")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected error for a missing pgbackrest binary")
+	}
+}
+
+func TestLoadClusters(t *testing.T) {
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("CLUSTERS", "reporting=pg-rep:5433/rep,analytics=pg-an")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	targets, err := cfg.Clusters.Targets(&cfg.Database)
+	if err != nil {
+		t.Fatalf("Expected valid clusters, got %v", err)
+	}
+	want := []config.ClusterTarget{
+		{Name: "reporting", Host: "pg-rep", Port: 5433, Stanza: "rep"},
+		{Name: "analytics", Host: "pg-an", Port: 5432, Stanza: "analytics"},
+	}
+	if len(targets) != len(want) || targets[0] != want[0] || targets[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, targets)
+	}
+
+	clusterCfg := cfg.ForCluster(targets[0])
+	if clusterCfg.Database.Host != "pg-rep" || clusterCfg.Backup.Stanza != "rep" || clusterCfg.Backup.Schedule.Enabled {
+		t.Errorf("Unexpected cluster config %+v %+v", clusterCfg.Database, clusterCfg.Backup)
+	}
+	if cfg.Database.Host == "pg-rep" {
+		t.Error("Expected the default config to be left unchanged")
+	}
+
+	for _, clusters := range []string{"default=pg2", "Reporting=pg2", "a=pg2,a=pg3", "pg2"} {
+		t.Setenv("CLUSTERS", clusters)
+		if _, err := config.Load(); err == nil {
+			t.Errorf("Expected CLUSTERS=%s to be rejected", clusters)
+		}
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api-keys")
+	if err := os.WriteFile(file, []byte("# operators\nops=k3\n\nbackup-cron=k4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ADMIN_API_KEY", "k1")
+	t.Setenv("ADMIN_API_KEYS", "ci=k2")
+	t.Setenv("ADMIN_API_KEYS_FILE", file)
+	t.Setenv("ADMIN_API_KEY_ROLES", "ci=viewer,backup-cron=operator")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	keys, err := cfg.Admin.Keys()
+	if err != nil {
+		t.Fatalf("Expected valid API keys, got %v", err)
+	}
+	want := []config.APIKey{
+		{Name: "admin", Key: "k1", Role: config.RoleAdmin},
+		{Name: "ci", Key: "k2", Role: config.RoleViewer},
+		{Name: "ops", Key: "k3", Role: config.RoleAdmin},
+		{Name: "backup-cron", Key: "k4", Role: config.RoleOperator},
+	}
+	if len(keys) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], keys[i])
+		}
+	}
+
+	for _, roles := range []string{"ci=root", "ci", "nobody=viewer"} {
+		t.Setenv("ADMIN_API_KEY_ROLES", roles)
+		if _, err := config.Load(); err == nil {
+			t.Errorf("Expected ADMIN_API_KEY_ROLES=%s to be rejected", roles)
+		}
+	}
+	t.Setenv("ADMIN_API_KEY_ROLES", "")
+
+	for _, entries := range []string{"ci", "ci=", "CI=k2", "admin=k2", "ci=k1", "ci=k2,ops=k5"} {
+		t.Setenv("ADMIN_API_KEYS", entries)
+		if _, err := config.Load(); err == nil {
+			t.Errorf("Expected ADMIN_API_KEYS=%s to be rejected", entries)
+		}
+	}
+
+	t.Setenv("ADMIN_API_KEYS", "")
+	t.Setenv("ADMIN_API_KEYS_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected a missing ADMIN_API_KEYS_FILE to be rejected")
+	}
+}
+
+func TestLoadLog(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Log.Level != "info" || cfg.Log.Format != "json" || cfg.Log.RequestSampleRate != 1 {
+		t.Errorf("Expected info, json and 1, got %+v", cfg.Log)
+	}
+
+	for env, value := range map[string]string{
+		"LOG_LEVEL":               "verbose",
+		"LOG_FORMAT":              "xml",
+		"LOG_REQUEST_SAMPLE_RATE": "1.5",
+	} {
+		t.Setenv(env, value)
+		if _, err := config.Load(); err == nil {
+			t.Errorf("Expected %s=%s to be rejected", env, value)
+		}
+		t.Setenv(env, "")
+	}
+}
+
+func TestLoadTracing(t *testing.T) {
+	t.Setenv("TRACING_ENABLED", "true")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Tracing.Endpoint != "http://localhost:4318" || cfg.Tracing.SampleRatio != 1 {
+		t.Errorf("Expected the default endpoint and ratio, got %+v", cfg.Tracing)
+	}
+
+	for env, value := range map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "localhost:4318",
+		"TRACING_SAMPLE_RATIO":        "-0.1",
+	} {
+		t.Setenv(env, value)
+		if _, err := config.Load(); err == nil {
+			t.Errorf("Expected %s=%s to be rejected", env, value)
+		}
+		t.Setenv(env, "")
+	}
+}
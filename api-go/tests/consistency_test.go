@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+)
+
+func TestReaderPreference(t *testing.T) {
+	primary, replica := &db.Pool{}, &db.Pool{}
+
+	c := db.NewCluster(primary, []*db.Pool{replica}, time.Second)
+	if pool, fromReplica, err := c.Reader(); err != nil || fromReplica || pool != primary {
+		t.Errorf("Expected reads from the primary by default, got replica=%t err=%v", fromReplica, err)
+	}
+
+	c.PreferReplicas()
+	if pool, fromReplica, err := c.Reader(); err != nil || !fromReplica || pool != replica {
+		t.Errorf("Expected reads from the replica when preferred, got replica=%t err=%v", fromReplica, err)
+	}
+
+	// A read with a token prefers the primary over a replica that may lag
+	pool, fromReplica, err := c.ReaderAfter(context.Background(), "0/3000060", true, time.Second)
+	if err != nil || fromReplica || pool != primary {
+		t.Errorf("Expected a read after a write from the primary, got replica=%t err=%v", fromReplica, err)
+	}
+
+	// Without replicas, the preference falls back to the primary
+	c = db.NewCluster(primary, nil, time.Second)
+	c.PreferReplicas()
+	if pool, fromReplica, err := c.Reader(); err != nil || fromReplica || pool != primary {
+		t.Errorf("Expected the primary without replicas, got replica=%t err=%v", fromReplica, err)
+	}
+}
+
+func TestConsistencyToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		ReadYourWrites:        config.ReadYourWritesWait,
+		ReadYourWritesTimeout: time.Second,
+	}}
+	h := handlers.NewItemsHandler(cfg, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.GET("/items/:id", h.Get)
+
+	tests := []struct {
+		token string
+		code  int
+	}{
+		{"not-an-lsn", http.StatusBadRequest},
+		{"0/3000060", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/items/1", nil)
+		req.Header.Set("X-Consistency-Token", tt.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for token %q, got %d", tt.code, tt.token, w.Code)
+		}
+	}
+}
+
+func TestLoadReadYourWrites(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Database.ReadPreference != config.ReadPreferencePrimary || cfg.Database.ReadYourWrites != config.ReadYourWritesPrimary {
+		t.Errorf("Expected primary defaults, got %q and %q", cfg.Database.ReadPreference, cfg.Database.ReadYourWrites)
+	}
+
+	t.Setenv("DB_READ_YOUR_WRITES", "eventually")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected an error for an unknown DB_READ_YOUR_WRITES")
+	}
+}
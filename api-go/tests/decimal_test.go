@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{`0.1`, `0.1`},
+		{`"19.99"`, `19.99`},
+		{`12.50`, `12.50`},
+		{`123456789.123456789`, `123456789.123456789`},
+	}
+	for _, tt := range tests {
+		var d models.Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Expected %s to parse, got %v", tt.in, err)
+			continue
+		}
+		data, err := json.Marshal(d)
+		if err != nil || string(data) != tt.out {
+			t.Errorf("Expected %s for %s, got %s, %v", tt.out, tt.in, data, err)
+		}
+	}
+
+	for _, in := range []string{`"abc"`, `"1,5"`, `"NaN"`, `true`, `"01"`} {
+		var d models.Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Expected an error for %s, got %q", in, d)
+		}
+	}
+
+	data, _ := json.Marshal(models.Order{Total: "0.30"})
+	if !strings.Contains(string(data), `"total":0.30`) {
+		t.Errorf("Expected the total without float artifacts, got %s", data)
+	}
+}
+
+func TestDecimalValidation(t *testing.T) {
+	// The handlers package registers how decimals are validated
+	_ = handlers.NewItemsHandler
+
+	tests := []struct {
+		price models.Decimal
+		valid bool
+	}{
+		{"-0.01", false},
+		{"0", false}, // required
+		{"0.10", true},
+		{"19.99", true},
+	}
+	for _, tt := range tests {
+		err := binding.Validator.ValidateStruct(&models.ItemCreate{Name: "a", Price: tt.price})
+		if (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for price %s, got %v", tt.valid, tt.price, err)
+		}
+	}
+
+	negative := models.Decimal("-1")
+	if err := binding.Validator.ValidateStruct(&models.ItemUpdate{Price: &negative}); err == nil {
+		t.Errorf("Expected a negative price update to be rejected")
+	}
+
+	err := binding.Validator.ValidateStruct(&models.TransferRequest{From: "alice", To: "bob", Amount: "-5"})
+	if err == nil {
+		t.Errorf("Expected a negative transfer amount to be rejected")
+	}
+}
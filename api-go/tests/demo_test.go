@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+)
+
+func TestTransferValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Database: config.DatabaseConfig{FailoverEstimate: 30 * time.Second}}
+	h := handlers.NewDemoHandler(handlers.NewItemsHandler(cfg, db.NewCluster(nil, nil, time.Second)))
+	r := gin.New()
+	r.POST("/demo/transfer", h.Transfer)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"from":"alice","to":"alice","amount":10}`, http.StatusBadRequest},
+		{`{"from":"alice","to":"bob","amount":0}`, http.StatusBadRequest},
+		{`{"from":"alice","to":"bob","amount":10,"fail_at":"halfway"}`, http.StatusBadRequest},
+		{`{"from":"alice","to":"bob","amount":10,"pause_ms":120000}`, http.StatusBadRequest},
+		// Valid, but there is no primary to write to
+		{`{"from":"alice","to":"bob","amount":10,"fail_at":"after_debit"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/demo/transfer", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s, got %d", tt.code, tt.body, w.Code)
+		}
+	}
+}
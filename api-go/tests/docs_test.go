@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+)
+
+func TestDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{App: config.AppConfig{Name: "pgha-api", Version: "1.2.3"}}
+	itemsHandler := handlers.NewItemsHandler(cfg, db.NewCluster(nil, nil, time.Second))
+	rules := []middleware.AccessRule{{Method: http.MethodPost, Path: "/restore", Role: config.RoleAdmin}}
+
+	router := gin.New()
+	docsHandler := handlers.NewDocsHandler(cfg.App, router.Routes, func(method, path string) string {
+		return middleware.RequiredRole(rules, method, path)
+	})
+	router.GET("/docs", docsHandler.Docs)
+	router.GET("/openapi.json", docsHandler.OpenAPI)
+	router.GET("/items/:id", itemsHandler.Get)
+	router.POST("/items", itemsHandler.Create)
+	router.POST("/restore", func(c *gin.Context) {})
+	router.GET("/undocumented", func(c *gin.Context) {})
+	router.GET("/items", itemsHandler.List)
+	router.GET("/v1/items", itemsHandler.List)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary    string                   `json:"summary"`
+			Parameters []map[string]interface{} `json:"parameters"`
+			Security   []map[string][]string    `json:"security"`
+			Responses  map[string]interface{}   `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || spec.Info.Version != "1.2.3" {
+		t.Errorf("Expected OpenAPI 3 and version 1.2.3, got %s and %s", spec.OpenAPI, spec.Info.Version)
+	}
+	for _, path := range []string{"/docs", "/openapi.json", "/items/{id}", "/items", "/restore", "/undocumented"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("Expected path %s", path)
+		}
+	}
+
+	get := spec.Paths["/items/{id}"]["get"]
+	if len(get.Parameters) == 0 || get.Parameters[0]["name"] != "id" || get.Parameters[0]["in"] != "path" {
+		t.Errorf("Expected the id path parameter, got %v", get.Parameters)
+	}
+	if _, ok := spec.Paths["/items"]["post"].Responses["201"]; !ok {
+		t.Errorf("Expected a 201 response for POST /items, got %v", spec.Paths["/items"]["post"].Responses)
+	}
+	if len(get.Security) != 0 || len(spec.Paths["/restore"]["post"].Security) == 0 {
+		t.Error("Expected only POST /restore to require an API key")
+	}
+
+	// /v1 always answers GET /items with a page
+	for path, want := range map[string]string{"/items": "array", "/v1/items": "#/components/schemas/ItemsPage"} {
+		content, _ := spec.Paths[path]["get"].Responses["200"].(map[string]interface{})["content"].(map[string]interface{})
+		schema, _ := content["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+		if schema["type"] != want && schema["$ref"] != want {
+			t.Errorf("Expected %s for GET %s, got %v", want, path, schema)
+		}
+	}
+
+	create := spec.Components.Schemas["ItemCreate"]
+	if !slices.Contains(create.Required, "name") {
+		t.Errorf("Expected name to be required, got %v", create.Required)
+	}
+	if price := create.Properties["price"]; price["type"] != "number" || price["minimum"] != float64(0) {
+		t.Errorf("Expected price to be a number of at least 0, got %v", price)
+	}
+	if _, ok := spec.Components.Schemas["ErrorResponse"]; !ok {
+		t.Error("Expected the ErrorResponse schema")
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "SwaggerUIBundle") || !strings.Contains(w.Body.String(), `"/openapi.json"`) {
+		t.Errorf("Expected Swagger UI pointed at /openapi.json, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func drStatus(t *testing.T, cfg *config.Config) (int, models.DRStatusResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewDRHandler(cfg, nil)
+	router := gin.New()
+	router.GET("/dr/status", h.Status)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/dr/status", nil)
+	router.ServeHTTP(w, req)
+
+	var response models.DRStatusResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestDRStatusNotConfigured(t *testing.T) {
+	code, _ := drStatus(t, &config.Config{DR: config.DRConfig{Timeout: time.Second}})
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a DR site, got %d", code)
+	}
+}
+
+func TestDRStatusFromRemoteAPI(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dr/position" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(models.DRPosition{
+			InRecovery:       true,
+			SystemIdentifier: "7300000000000000001",
+			LSN:              strPtr("0/3000060"),
+			Timestamp:        time.Now().UTC(),
+		})
+	}))
+	defer remote.Close()
+
+	code, response := drStatus(t, &config.Config{DR: config.DRConfig{Site: "eu-west", APIURL: remote.URL + "/", Timeout: time.Second}})
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if response.Site != "eu-west" || response.Source != "api" {
+		t.Errorf("Expected site eu-west from api, got %s from %s", response.Site, response.Source)
+	}
+	// No local database in tests: the standby is still reported
+	if response.Status != handlers.DRStatusPrimaryUnavailable {
+		t.Errorf("Expected status %s, got %s", handlers.DRStatusPrimaryUnavailable, response.Status)
+	}
+	if response.Standby == nil || response.Standby.LSN == nil || *response.Standby.LSN != "0/3000060" {
+		t.Errorf("Expected the remote standby position, got %+v", response.Standby)
+	}
+	if response.LagBytes != nil {
+		t.Errorf("Expected no lag without a primary, got %d", *response.LagBytes)
+	}
+
+	remote.Close()
+	_, response = drStatus(t, &config.Config{DR: config.DRConfig{APIURL: remote.URL, Timeout: time.Second}})
+	if response.Standby != nil {
+		t.Errorf("Expected no standby once the DR API is down, got %+v", response.Standby)
+	}
+}
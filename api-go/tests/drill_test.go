@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func setupDrillRouter(t *testing.T, patroniURL string, maxBackupAge time.Duration) (*gin.Engine, *jobs.Manager) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PATH", t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{Binary: "pgbackrest", Stanza: "main", CommandTimeout: time.Second},
+		Patroni: config.PatroniConfig{
+			URLs:              []string{patroniURL},
+			Timeout:           time.Second,
+			MaxLagBytes:       1024,
+			MaxBackupAge:      maxBackupAge,
+			SwitchoverTimeout: time.Second,
+		},
+	}
+	cluster := handlers.NewClusterHandler(cfg, handlers.NewBackupsHandler(cfg, nil, m), m)
+	probe := handlers.NewProbeHandler(db.NewCluster(nil, nil, time.Second))
+	h := handlers.NewDrillHandler(cluster, probe, m)
+
+	router := gin.New()
+	router.POST("/drills", h.Run)
+	return router, m
+}
+
+func TestDrillPreconditions(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1", lag: map[string]interface{}{"pg2": 0, "pg3": 0}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	router, _ := setupDrillRouter(t, server.URL, 0)
+
+	w := clusterRequest(router, "POST", "/drills", `{"candidate": "pg1"}`)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for a drill to the leader, got %d: %s", w.Code, w.Body.String())
+	}
+	w = clusterRequest(router, "POST", "/drills", `{"workload_writes": 0.5}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid body, got %d", w.Code)
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("Expected no request to reach Patroni, got %v", fake.requests)
+	}
+}
+
+func TestDrillStopsWithoutBackup(t *testing.T) {
+	fake := &fakePatroni{leader: "pg1", lag: map[string]interface{}{"pg2": 0, "pg3": 0}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	router, m := setupDrillRouter(t, server.URL, 24*time.Hour)
+
+	w := clusterRequest(router, "POST", "/drills", `{"candidate": "pg2"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if job.Type != handlers.JobTypeDrill {
+		t.Errorf("Expected job type '%s', got '%s'", handlers.JobTypeDrill, job.Type)
+	}
+
+	done := waitForJob(t, m, job.ID)
+	if done.Status != jobs.StatusFailed {
+		t.Fatalf("Expected drill to fail without a backup, got '%s'", done.Status)
+	}
+	report, ok := done.Result.(*models.DrillReport)
+	if !ok {
+		t.Fatalf("Expected a drill report, got %T", done.Result)
+	}
+	if report.Succeeded || report.OriginalLeader != "pg1" || len(report.Steps) != 4 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	want := []string{models.DrillStepFailed, models.DrillStepSkipped, models.DrillStepSkipped, models.DrillStepSkipped}
+	for i, s := range report.Steps {
+		if s.Status != want[i] {
+			t.Errorf("Expected step %s to be %s, got %s", s.Name, want[i], s.Status)
+		}
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("Expected no switchover, got %v", fake.requests)
+	}
+}
@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+)
+
+// fakeReply is how a fakePG answers the statements containing match:
+// with rows of values of the types oids, or failing with SQLSTATE code.
+// params are the types of the parameters, which pgx otherwise infers
+// from the values; slices need the array type.
+// Values given as strings are in their text form, such as "9.90" for a
+// numeric. tag is the command tag, such as "DELETE 1", and defaults to
+// "SELECT n".
+type fakeReply struct {
+	match  string
+	params []uint32
+	oids   []uint32
+	rows   [][]any
+	tag    string
+	code   string
+}
+
+// fakePG is a PostgreSQL server answering from a script, so handlers can
+// be tested down to their database paths without a database. Statements
+// no reply matches fail, except SELECT 1 and transaction control.
+type fakePG struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	replies []fakeReply
+	queries []string
+}
+
+// newFakePG starts a server answering with replies, tried in order.
+func newFakePG(t *testing.T, replies ...fakeReply) *fakePG {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &fakePG{ln: ln, replies: replies}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// pool returns a pool connected to the server.
+func (s *fakePG) pool(t *testing.T) *db.Pool {
+	t.Helper()
+	addr := s.ln.Addr().(*net.TCPAddr)
+	cfg := &config.DatabaseConfig{User: "postgres", Name: "postgres", PoolMaxSize: 2, ConnectTimeout: time.Second}
+	pool, err := db.NewLazyPoolForHost(cfg, "127.0.0.1", addr.Port)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// executed returns the statements run so far that contain match.
+func (s *fakePG) executed(match string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, q := range s.queries {
+		if strings.Contains(q, match) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// paramPattern matches the parameters of a statement.
+var paramPattern = regexp.MustCompile(`\$(\d+)`)
+
+// reply returns the reply to sql.
+func (s *fakePG) reply(sql string) fakeReply {
+	for _, r := range s.replies {
+		if strings.Contains(sql, r.match) {
+			return r
+		}
+	}
+	switch strings.ToLower(strings.Fields(sql + " ;")[0]) {
+	case "select":
+		if strings.TrimSpace(sql) == "SELECT 1" {
+			return fakeReply{oids: []uint32{pgtype.Int4OID}, rows: [][]any{{1}}}
+		}
+	case "begin", "commit", "rollback":
+		return fakeReply{tag: strings.ToUpper(strings.Fields(sql)[0])}
+	case ";", "--":
+		return fakeReply{tag: "SELECT 0"}
+	}
+	return fakeReply{code: "XX000"}
+}
+
+// statement is a parsed statement of a connection.
+type statement struct {
+	sql   string
+	reply fakeReply
+}
+
+// serve speaks the protocol on conn: the simple query protocol for
+// statements without parameters and the extended one for the others.
+func (s *fakePG) serve(conn net.Conn) {
+	defer conn.Close()
+	backend := pgproto3.NewBackend(conn, conn)
+
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ParameterStatus{Name: "server_version", Value: "16.0"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if backend.Flush() != nil {
+		return
+	}
+
+	// Each connection encodes with its own map, which is not safe for
+	// concurrent use
+	types := pgtype.NewMap()
+	statements := map[string]statement{}
+	var portal statement
+	var formats []int16
+	failed := false
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		if failed {
+			// After an error everything up to Sync is skipped
+			if _, ok := msg.(*pgproto3.Sync); !ok {
+				continue
+			}
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			st := statement{sql: msg.String, reply: s.reply(msg.String)}
+			s.record(st.sql)
+			if st.reply.code == "" && len(st.reply.oids) > 0 {
+				backend.Send(rowDescription(st.reply, nil))
+			}
+			s.execute(backend, types, st, nil)
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Parse:
+			statements[msg.Name] = statement{sql: msg.Query, reply: s.reply(msg.Query)}
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			st, described := statements[msg.Name], []int16(nil)
+			if msg.ObjectType == 'P' {
+				// A portal is described with the formats of its results
+				st, described = portal, formats
+			} else {
+				params := 0
+				for _, m := range paramPattern.FindAllStringSubmatch(st.sql, -1) {
+					if n, _ := strconv.Atoi(m[1]); n > params {
+						params = n
+					}
+				}
+				// Unknown parameter types leave pgx to pick them from
+				// the Go values
+				oids := make([]uint32, params)
+				copy(oids, st.reply.params)
+				backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: oids})
+			}
+			if len(st.reply.oids) > 0 {
+				backend.Send(rowDescription(st.reply, described))
+			} else {
+				backend.Send(&pgproto3.NoData{})
+			}
+		case *pgproto3.Bind:
+			portal = statements[msg.PreparedStatement]
+			formats = msg.ResultFormatCodes
+			backend.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			s.record(portal.sql)
+			failed = !s.execute(backend, types, portal, formats)
+		case *pgproto3.Sync:
+			failed = false
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		case *pgproto3.Terminate:
+			return
+		}
+		if backend.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *fakePG) record(sql string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, sql)
+}
+
+// rowDescription describes the columns of r, sent in formats.
+func rowDescription(r fakeReply, formats []int16) *pgproto3.RowDescription {
+	fields := make([]pgproto3.FieldDescription, len(r.oids))
+	for i, oid := range r.oids {
+		fields[i] = pgproto3.FieldDescription{
+			Name:         []byte("column" + strconv.Itoa(i+1)),
+			DataTypeOID:  oid,
+			DataTypeSize: -1,
+			TypeModifier: -1,
+			Format:       resultFormat(formats, i),
+		}
+	}
+	return &pgproto3.RowDescription{Fields: fields}
+}
+
+// resultFormat returns the format of column i from the format codes of a
+// Bind: none for all text, one for all columns, or one per column.
+func resultFormat(formats []int16, i int) int16 {
+	switch {
+	case len(formats) == 1:
+		return formats[0]
+	case i < len(formats):
+		return formats[i]
+	}
+	return pgtype.TextFormatCode
+}
+
+// execute sends the rows of st in formats and its command tag, or its
+// error. It reports whether the statement succeeded.
+func (s *fakePG) execute(backend *pgproto3.Backend, types *pgtype.Map, st statement, formats []int16) bool {
+	r := st.reply
+	if r.code != "" {
+		message := "fake error"
+		if r.match == "" {
+			message = "unexpected statement: " + st.sql
+		}
+		backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: r.code, Message: message})
+		return false
+	}
+
+	for _, row := range r.rows {
+		values := make([][]byte, len(row))
+		for i, v := range row {
+			buf, err := encodeValue(types, r.oids[i], resultFormat(formats, i), v)
+			if err != nil {
+				backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "XX000", Message: err.Error()})
+				return false
+			}
+			values[i] = buf
+		}
+		backend.Send(&pgproto3.DataRow{Values: values})
+	}
+
+	tag := r.tag
+	if tag == "" {
+		tag = "SELECT " + strconv.Itoa(len(r.rows))
+	}
+	backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(tag)})
+	return true
+}
+
+// encodeValue encodes v as a value of type oid. A string is the text form
+// of the value, as PostgreSQL would print it.
+func encodeValue(types *pgtype.Map, oid uint32, format int16, v any) ([]byte, error) {
+	if text, ok := v.(string); ok && format == pgtype.BinaryFormatCode {
+		if err := types.Scan(oid, pgtype.TextFormatCode, []byte(text), &v); err != nil {
+			return nil, err
+		}
+	}
+	return types.Encode(oid, format, v, nil)
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/events"
+	"github.com/postgresql-ha-dr/api-go/internal/grpcapi"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	pghav1 "github.com/postgresql-ha-dr/api-go/proto/pgha/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupGRPC serves the gRPC API over an in-memory listener, without a
+// database or Patroni, and returns a connection to it.
+func setupGRPC(t *testing.T, keys []config.APIKey) (*grpc.ClientConn, *events.Log) {
+	t.Helper()
+	cfg := &config.Config{
+		Patroni: config.PatroniConfig{Timeout: time.Second},
+		Metrics: config.MetricsConfig{HistoryInterval: time.Second},
+	}
+	m := jobs.NewManager(1, 1, 1, "")
+	backups := handlers.NewBackupsHandler(cfg, nil, m)
+	log := events.NewLog(10)
+	rules := []middleware.AccessRule{{Method: http.MethodPost, Path: "/cluster/", Role: config.RoleAdmin}}
+
+	server, err := grpcapi.NewServer(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), keys,
+		func(method, path string) string { return middleware.RequiredRole(rules, method, path) },
+		grpcapi.Services{
+			Health:  handlers.NewHealthHandler(cfg, nil, backups),
+			Metrics: handlers.NewMetricsHandler(cfg, nil),
+			Items:   handlers.NewItemsHandler(cfg, db.NewCluster(nil, nil, time.Second)),
+			Backups: backups,
+			Cluster: handlers.NewClusterHandler(cfg, backups, m),
+			Jobs:    handlers.NewJobsHandler(m),
+			Events:  log,
+		})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, log
+}
+
+// errorReason returns the reason of the ErrorInfo detail of err.
+func errorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestGRPCHealth(t *testing.T) {
+	conn, _ := setupGRPC(t, nil)
+	client := pghav1.NewMonitoringServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-test-1")
+	var header metadata.MD
+	health, err := client.GetHealth(ctx, &pghav1.GetHealthRequest{}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("GetHealth failed: %v", err)
+	}
+	if health.Status == "" || health.Runtime == nil {
+		t.Errorf("Unexpected health %v", health)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "grpc-test-1" {
+		t.Errorf("Expected the request ID to be echoed, got %v", got)
+	}
+
+	_, err = client.GetMetrics(context.Background(), &pghav1.GetMetricsRequest{})
+	if status.Code(err) != codes.Unavailable || errorReason(err) != "database_unavailable" {
+		t.Errorf("Expected Unavailable database_unavailable without a database, got %v", err)
+	}
+}
+
+func TestGRPCItems(t *testing.T) {
+	conn, _ := setupGRPC(t, nil)
+	client := pghav1.NewItemsServiceClient(conn)
+
+	_, err := client.GetItem(context.Background(), &pghav1.GetItemRequest{Id: "abc"})
+	if status.Code(err) != codes.InvalidArgument || errorReason(err) != "invalid_id" {
+		t.Errorf("Expected InvalidArgument invalid_id, got %v", err)
+	}
+	_, err = client.GetItem(context.Background(), &pghav1.GetItemRequest{Id: "1"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable without a database, got %v", err)
+	}
+}
+
+func TestGRPCAuthorization(t *testing.T) {
+	req := &pghav1.SwitchoverRequest{Candidate: "pg2"}
+
+	conn, _ := setupGRPC(t, nil)
+	_, err := pghav1.NewClusterServiceClient(conn).Switchover(context.Background(), req)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied without API keys, got %v", err)
+	}
+
+	conn, _ = setupGRPC(t, []config.APIKey{
+		{Name: "ops", Key: "admin-secret", Role: config.RoleAdmin},
+		{Name: "dash", Key: "viewer-secret", Role: config.RoleViewer},
+	})
+	client := pghav1.NewClusterServiceClient(conn)
+	if _, err := client.Switchover(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a key, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "viewer-secret")
+	if _, err := client.Switchover(ctx, req); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a viewer key, got %v", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin-secret")
+	_, err = client.Switchover(ctx, req)
+	if status.Code(err) != codes.Unavailable || errorReason(err) != "not_configured" {
+		t.Errorf("Expected Unavailable not_configured without PATRONI_URLS, got %v", err)
+	}
+
+	// Reads stay open
+	if _, err := client.GetJob(context.Background(), &pghav1.GetJobRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown job, got %v", err)
+	}
+	backups := pghav1.NewBackupsServiceClient(conn)
+	if _, err := backups.GetBackupStatus(context.Background(), &pghav1.GetBackupStatusRequest{Stanza: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown stanza, got %v", err)
+	}
+	// Health checks need no key
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Expected the health check to be open, got %v", err)
+	}
+}
+
+func TestGRPCWatchEvents(t *testing.T) {
+	conn, log := setupGRPC(t, nil)
+	log.Publish("switchover", "Leader changed", map[string]interface{}{"new_leader": "pg2"})
+	log.Publish("backup", "Backup finished", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pghav1.NewClusterServiceClient(conn).WatchEvents(ctx, &pghav1.WatchEventsRequest{Type: "switchover"})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+	if event.Message != "Leader changed" || event.Data.AsMap()["new_leader"] != "pg2" {
+		t.Errorf("Unexpected event %v", event)
+	}
+
+	// Events published later are sent as they come
+	log.Publish("backup", "Backup finished", nil)
+	log.Publish("switchover", "Leader changed back", nil)
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+	if event.Message != "Leader changed back" || event.Id != 4 {
+		t.Errorf("Unexpected event %v", event)
+	}
+}
@@ -2,13 +2,22 @@ package tests
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
 	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
 	"github.com/postgresql-ha-dr/api-go/internal/models"
 )
 
@@ -25,10 +34,11 @@ func setupRouter() *gin.Engine {
 		},
 	}
 
-	healthHandler := handlers.NewHealthHandler(cfg, nil)
+	healthHandler := handlers.NewHealthHandler(cfg, nil, handlers.NewBackupsHandler(cfg, nil, jobs.NewManager(1, 1, 1, "")))
 
 	router.GET("/", healthHandler.Root)
 	router.GET("/health", healthHandler.Health)
+	router.GET("/health/deep", healthHandler.Deep)
 	router.GET("/ready", healthHandler.Ready)
 
 	return router
@@ -57,6 +67,14 @@ func TestHealthEndpoint(t *testing.T) {
 	if response.Version != "1.0.0" {
 		t.Errorf("Expected version '1.0.0', got '%s'", response.Version)
 	}
+
+	if response.Runtime.GoVersion == "" {
+		t.Error("Expected runtime go_version in response")
+	}
+
+	if response.ServerVersion != nil {
+		t.Errorf("Expected no server_version without DB, got '%s'", *response.ServerVersion)
+	}
 }
 
 func TestRootEndpoint(t *testing.T) {
@@ -105,3 +123,195 @@ func TestReadyEndpointNoDB(t *testing.T) {
 		t.Errorf("Expected status 'not_ready', got '%s'", response.Status)
 	}
 }
+
+func TestDeepHealthEndpointNoDB(t *testing.T) {
+	router := setupRouter()
+
+	req, _ := http.NewRequest("GET", "/health/deep", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Without DB pool, the database component is unhealthy
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var response models.DeepHealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Failed to parse response: %v", err)
+	}
+
+	if response.Status != models.ComponentUnhealthy {
+		t.Errorf("Expected status 'unhealthy', got '%s'", response.Status)
+	}
+
+	for _, name := range []string{"database", "replication", "backups", "disk"} {
+		if _, ok := response.Components[name]; !ok {
+			t.Errorf("Expected '%s' component in response", name)
+		}
+	}
+
+	if response.Components["database"].Status != models.ComponentUnhealthy {
+		t.Errorf("Expected database 'unhealthy', got '%s'", response.Components["database"].Status)
+	}
+}
+
+func TestReadyEndpointInvalidPolicy(t *testing.T) {
+	router := setupRouter()
+
+	req, _ := http.NewRequest("GET", "/ready?policy=leader", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestWriteProbeWithoutPrimary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewProbeHandler(db.NewCluster(nil, nil, time.Second))
+	router := gin.New()
+	router.GET("/probe/write", h.Write)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/probe/write", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	var response models.WriteProbeResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Writable || response.Status != "failed" || response.Error == "" {
+		t.Errorf("Expected a failed probe with an error, got %+v", response)
+	}
+}
+
+func TestRoleUnreachable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Host: "127.0.0.1", Port: 1, User: "postgres", Name: "postgres", ConnectTimeout: time.Second,
+	}}
+	h := handlers.NewRoleHandler(cfg)
+	defer h.Close()
+	router := gin.New()
+	router.GET("/role", h.Role)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/role", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	var response models.RoleResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Role != "unknown" || response.Error == "" {
+		t.Errorf("Expected an unknown role with an error, got %+v", response)
+	}
+}
+
+func TestRoleSilentNode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Accepts connections and never answers, as a node cut off mid-way
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer lis.Close()
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			defer conn.Close()
+		}
+	}()
+
+	addr := lis.Addr().(*net.TCPAddr)
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Host: "127.0.0.1", Port: addr.Port, User: "postgres", Name: "postgres", ConnectTimeout: 5 * time.Second,
+	}}
+	h := handlers.NewRoleHandler(cfg)
+	defer h.Close()
+	router := gin.New()
+	router.GET("/role", h.Role)
+
+	// Concurrent checks share one bounded attempt rather than queueing
+	// behind each other
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/role", nil)
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected status 503, got %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the checks to give up within the connect timeout of the role check, took %s", elapsed)
+	}
+	if n := accepted.Load(); n != 1 {
+		t.Errorf("Expected 1 connection attempt, got %d", n)
+	}
+}
+
+func TestDeepHealthBackupsEveryStanza(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	binDir := t.TempDir()
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + callsFile + "\necho 'stanza not found' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(binDir, "pgbackrest"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			Binary:         "pgbackrest",
+			Stanza:         "main",
+			Stanzas:        []string{"reporting"},
+			CommandTimeout: time.Second,
+			CacheMaxAge:    time.Minute,
+		},
+	}
+	h := handlers.NewHealthHandler(cfg, nil, handlers.NewBackupsHandler(cfg, nil, jobs.NewManager(1, 1, 1, "")))
+	router := gin.New()
+	router.GET("/health/deep", h.Deep)
+
+	var response models.DeepHealthResponse
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/health/deep", nil)
+		router.ServeHTTP(w, req)
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+	}
+
+	backups := response.Components["backups"]
+	if backups.Status != models.ComponentUnhealthy {
+		t.Errorf("Expected backups 'unhealthy', got '%s'", backups.Status)
+	}
+	stanzas, _ := backups.Details["stanzas"].(map[string]interface{})
+	if len(stanzas) != 2 || stanzas["main"] == nil || stanzas["reporting"] == nil {
+		t.Errorf("Expected both stanzas in the backups component, got %v", backups.Details)
+	}
+
+	calls, _ := os.ReadFile(callsFile)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 2 || !strings.Contains(string(calls), "--stanza main") || !strings.Contains(string(calls), "--stanza reporting") {
+		t.Errorf("Expected one cached info call per stanza across both requests, got:\n%s", calls)
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/history"
+)
+
+func TestBufferKeepsMostRecent(t *testing.T) {
+	buf := history.NewBuffer[int](3)
+
+	for i := 1; i <= 5; i++ {
+		buf.Add(i)
+	}
+
+	if got := buf.Values(); !reflect.DeepEqual(got, []int{3, 4, 5}) {
+		t.Errorf("Expected [3 4 5], got %v", got)
+	}
+
+	if buf.Len() != 3 {
+		t.Errorf("Expected length 3, got %d", buf.Len())
+	}
+}
+
+func TestBufferFilter(t *testing.T) {
+	buf := history.NewBuffer[int](10)
+
+	for i := 1; i <= 4; i++ {
+		buf.Add(i)
+	}
+
+	got := buf.Filter(func(v int) bool { return v%2 == 0 })
+	if !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Errorf("Expected [2 4], got %v", got)
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	metrics := middleware.NewHTTPMetrics()
+	prometheusHandler := handlers.NewPrometheusHandler(metrics)
+
+	router := gin.New()
+	router.Use(metrics.Handler())
+	router.GET("/items/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	router.GET("/metrics/prometheus", prometheusHandler.Metrics)
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/0", "/nowhere"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics/prometheus", nil)
+	router.ServeHTTP(w, req)
+	body := w.Body.String()
+
+	for _, want := range []string{
+		`pgha_http_requests_total{method="GET",route="/items/:id",status="200"} 2`,
+		`pgha_http_requests_total{method="GET",route="/items/:id",status="404"} 1`,
+		`pgha_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`pgha_http_request_duration_seconds_count{method="GET",route="/items/:id",status="200"} 2`,
+		`pgha_http_requests_in_flight{method="GET",route="/items/:id"} 0`,
+		// The scrape itself is in flight
+		`pgha_http_requests_in_flight{method="GET",route="/metrics/prometheus"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+}
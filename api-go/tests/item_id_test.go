@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemIDJSON(t *testing.T) {
+	tests := []struct {
+		id   models.ItemID
+		json string
+	}{
+		{"42", `42`},
+		{"0190a6c4-8f3e-7abc-8def-0123456789ab", `"0190a6c4-8f3e-7abc-8def-0123456789ab"`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.id)
+		if err != nil || string(data) != tt.json {
+			t.Errorf("Expected %s, got %s (%v)", tt.json, data, err)
+		}
+		var id models.ItemID
+		if err := json.Unmarshal(data, &id); err != nil || id != tt.id {
+			t.Errorf("Expected %s back, got %s (%v)", tt.id, id, err)
+		}
+	}
+
+	// Clients may send numeric IDs as strings too
+	var req models.ItemsBulkDeleteRequest
+	if err := json.Unmarshal([]byte(`{"ids":[1,"2"]}`), &req); err != nil || len(req.IDs) != 2 || req.IDs[1] != "2" {
+		t.Errorf("Expected IDs 1 and 2, got %v (%v)", req.IDs, err)
+	}
+	if err := json.Unmarshal([]byte(`{"ids":[true]}`), &req); err == nil {
+		t.Error("Expected an error for a boolean ID")
+	}
+}
+
+func TestLoadItemsKeyType(t *testing.T) {
+	t.Setenv("ITEMS_KEY_TYPE", "uuidv7")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Items.KeyType != config.ItemKeyUUIDv7 {
+		t.Errorf("Expected uuidv7 keys, got %q", cfg.Items.KeyType)
+	}
+
+	t.Setenv("ITEMS_KEY_TYPE", "bigserial")
+	if _, err := config.Load(); err == nil {
+		t.Error("Expected an error for an unknown ITEMS_KEY_TYPE")
+	}
+}
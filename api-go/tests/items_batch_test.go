@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemsBatchGetValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.GET("/items", h.List)
+	r.POST("/items/batch-get", h.BatchGet)
+
+	tests := []struct {
+		method, path, body string
+		code               int
+	}{
+		{"GET", "/items?ids=", "", http.StatusBadRequest},
+		{"GET", "/items?ids=1,two", "", http.StatusBadRequest},
+		{"POST", "/items/batch-get", `{"ids":[]}`, http.StatusBadRequest},
+		// Valid, but there is no database to read from
+		{"GET", "/items?ids=1,2,2", "", http.StatusServiceUnavailable},
+		{"POST", "/items/batch-get", `{"ids":[1,"2"]}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s %s %s, got %d", tt.code, tt.method, tt.path, tt.body, w.Code)
+		}
+	}
+}
+
+func TestItemsBatchResponseJSON(t *testing.T) {
+	response := models.ItemsBatchResponse{
+		Items:   map[models.ItemID]models.Item{"1": {ID: "1", Name: "a"}},
+		Missing: []models.ItemID{"2"},
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"items":{"1":{"id":1,`) || !strings.Contains(string(data), `"missing":[2]`) {
+		t.Errorf("Expected items keyed by ID and numeric missing IDs, got %s", data)
+	}
+}
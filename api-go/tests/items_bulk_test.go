@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/itemload"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemsBulkCreateValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.POST("/items/bulk", h.BulkCreate)
+
+	tests := []struct {
+		query, contentType, body string
+		code                     int
+	}{
+		{"?batch_size=0", "application/json", `[]`, http.StatusBadRequest},
+		{"?batch_size=10001", "application/json", `[]`, http.StatusBadRequest},
+		{"", "application/json", `{"name":"a","price":1}`, http.StatusBadRequest},
+		{"", "application/json", ``, http.StatusBadRequest},
+		// Readable, but there is no database to load into
+		{"", "application/json", `[{"name":"a","price":1}]`, http.StatusServiceUnavailable},
+		{"?batch_size=10", "application/x-ndjson", `{"name":"a","price":1}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/items/bulk"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s %s, got %d", tt.code, tt.query, tt.body, w.Code)
+		}
+	}
+}
+
+// bulkLoad loads body in batches of batchSize, recording the names of
+// each loaded batch. Batches holding an item named "fail" fail to load.
+func bulkLoad(t *testing.T, body string, ndjson bool, batchSize int) (models.ItemsBulkResponse, [][]string) {
+	t.Helper()
+	next, err := itemload.NewDecoder(strings.NewReader(body), ndjson)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", body, err)
+	}
+	var loaded [][]string
+	response := itemload.Bulk(next, batchSize, func(rows [][]interface{}) (int64, error) {
+		var names []string
+		for _, row := range rows {
+			if row[0] == "fail" {
+				return 0, errors.New("constraint violated")
+			}
+			names = append(names, row[0].(string))
+		}
+		loaded = append(loaded, names)
+		return int64(len(rows)), nil
+	})
+	return response, loaded
+}
+
+func TestItemsBulkBatches(t *testing.T) {
+	response, loaded := bulkLoad(t, `[
+		{"name":"a","price":1},{"name":"b","price":2},
+		{"name":"c","price":3},{"name":"d","price":"4.50"},
+		{"name":"e","price":5}]`, false, 2)
+	if want := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("Expected batches %v, got %v", want, loaded)
+	}
+	want := []models.ItemsBulkBatch{
+		{Index: 0, First: 0, Count: 2, Inserted: 2},
+		{Index: 1, First: 2, Count: 2, Inserted: 2},
+		{Index: 2, First: 4, Count: 1, Inserted: 1},
+	}
+	if !reflect.DeepEqual(response.Batches, want) {
+		t.Errorf("Expected batches %+v, got %+v", want, response.Batches)
+	}
+	if response.Received != 5 || response.Inserted != 5 || itemload.BulkStatus(response) != http.StatusCreated {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	// NDJSON gives the same batches
+	_, loaded = bulkLoad(t, "{\"name\":\"a\",\"price\":1}\n{\"name\":\"b\",\"price\":2}\n{\"name\":\"c\",\"price\":3}\n", true, 2)
+	if want := [][]string{{"a", "b"}, {"c"}}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("Expected NDJSON batches %v, got %v", want, loaded)
+	}
+}
+
+func TestItemsBulkFailedBatches(t *testing.T) {
+	// An invalid item skips its batch; a load error fails its own
+	response, loaded := bulkLoad(t, `[
+		{"name":"a","price":1},{"name":"","price":2},
+		{"name":"c","price":3},{"name":"d","price":4},
+		{"name":"fail","price":5},{"name":"f","price":6}]`, false, 2)
+	if want := [][]string{{"c", "d"}}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("Expected only the valid batch to load, got %v", loaded)
+	}
+	if response.Received != 6 || response.Inserted != 2 || response.Failed != 2 {
+		t.Errorf("Unexpected response %+v", response)
+	}
+	if !strings.HasPrefix(response.Batches[0].Error, "item 1:") || !strings.HasPrefix(response.Batches[2].Error, "Failed to load batch") {
+		t.Errorf("Unexpected batch errors %+v", response.Batches)
+	}
+	if status := itemload.BulkStatus(response); status != http.StatusMultiStatus {
+		t.Errorf("Expected status 207, got %d", status)
+	}
+}
+
+func TestItemsBulkTruncated(t *testing.T) {
+	// The batches read in full are loaded; the one broken off is not
+	response, loaded := bulkLoad(t, `[{"name":"a","price":1},{"name":"b","price":2},{"name":"c","price":3}`, false, 2)
+	if want := [][]string{{"a", "b"}}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("Expected the first batch only, got %v", loaded)
+	}
+	if response.Error == "" || response.Inserted != 2 || len(response.Batches) != 1 {
+		t.Errorf("Unexpected response %+v", response)
+	}
+	if status := itemload.BulkStatus(response); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", status)
+	}
+
+	response, _ = bulkLoad(t, `[{"name":"a","price":1},{"name":`, false, 10)
+	if response.Error == "" || response.Inserted != 0 || itemload.BulkStatus(response) != http.StatusBadRequest {
+		t.Errorf("Expected nothing loaded from a broken item, got %+v", response)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/etag"
+)
+
+func TestETagVersions(t *testing.T) {
+	tests := []struct {
+		header   string
+		weak     bool
+		versions []int64
+	}{
+		{"", false, []int64{}},
+		{`"3"`, false, []int64{3}},
+		{` "3" , "12"`, false, []int64{3, 12}},
+		// If-Match only compares strong tags
+		{`W/"3", "4"`, false, []int64{4}},
+		{`W/"3", "4"`, true, []int64{3, 4}},
+		{`3, "x", "", "5`, true, []int64{}},
+		{"*", true, []int64{}},
+	}
+	for _, tt := range tests {
+		if got := etag.Versions(tt.header, tt.weak); !reflect.DeepEqual(got, tt.versions) {
+			t.Errorf("Expected %v for %q (weak %v), got %v", tt.versions, tt.header, tt.weak, got)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"*", true},
+		{" * ", true},
+		{`"7"`, true},
+		{`W/"7"`, true},
+		{`"6", "7"`, true},
+		{`"6"`, false},
+		{`"07x"`, false},
+	}
+	for _, tt := range tests {
+		if got := etag.Matches(tt.header, 7); got != tt.want {
+			t.Errorf("Expected %v for %q, got %v", tt.want, tt.header, got)
+		}
+	}
+	if got := etag.Format(7); got != `"7"` || !etag.Matches(got, 7) {
+		t.Errorf("Expected a tag matching its version, got %s", got)
+	}
+}
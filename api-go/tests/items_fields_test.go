@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemFieldsValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.GET("/items/export", h.Export)
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"?fields=", http.StatusBadRequest},
+		{"?fields=id,secret", http.StatusBadRequest},
+		{"?fields=id,,name", http.StatusBadRequest},
+		// Valid, but there is no database to read from
+		{"?fields=name,%20price", http.StatusServiceUnavailable},
+		{"?format=ndjson&fields=id", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/items/export"+tt.query, nil))
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s, got %d", tt.code, tt.query, w.Code)
+		}
+	}
+}
+
+func TestItemFieldsJSON(t *testing.T) {
+	description := "d"
+	item := models.Item{ID: "1", Name: "a", Description: &description, Price: "2.5", IsActive: true}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"id":1,"name":"a","description":"d","price":2.5,"is_active":true,"created_at":`) {
+		t.Errorf("Expected every field without Fields, got %s", data)
+	}
+
+	item.Fields = []string{"name", "price"}
+	data, err = json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != `{"name":"a","price":2.5}` {
+		t.Errorf("Expected only name and price, got %s", data)
+	}
+
+	var decoded models.Item
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Name != "a" || decoded.Fields != nil {
+		t.Errorf("Expected the partial item to decode, got %+v, %v", decoded, err)
+	}
+}
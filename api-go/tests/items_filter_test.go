@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestParseItemsFilter(t *testing.T) {
+	created, _ := time.Parse(time.RFC3339, "2024-05-01T00:00:00Z")
+	tests := []struct {
+		query  string
+		clause string
+		args   []interface{}
+	}{
+		{"", "WHERE deleted_at IS NULL", nil},
+		{"name=lamp", "WHERE deleted_at IS NULL AND name ILIKE $1", []interface{}{"%lamp%"}},
+		{"price_min=1.50&price_max=10", "WHERE deleted_at IS NULL AND price >= $1 AND price <= $2",
+			[]interface{}{models.Decimal("1.50"), models.Decimal("10")}},
+		{"created_after=2024-05-01T00:00:00Z&name=a", "WHERE deleted_at IS NULL AND name ILIKE $1 AND created_at >= $2",
+			[]interface{}{"%a%", created}},
+		// An empty name does not filter
+		{"name=", "WHERE deleted_at IS NULL", nil},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		filter, err := itemquery.ParseFilter(query)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tt.query, err)
+			continue
+		}
+		clause, args := filter.Clause()
+		if clause != tt.clause || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("Expected %q %v for %q, got %q %v", tt.clause, tt.args, tt.query, clause, args)
+		}
+	}
+}
+
+func TestParseItemsFilterInvalid(t *testing.T) {
+	for _, query := range []string{
+		"price_min=abc",
+		"price_max=",
+		"created_after=2024-05-01",
+		"created_before=yesterday",
+	} {
+		values, _ := url.ParseQuery(query)
+		if _, err := itemquery.ParseFilter(values); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
+
+func TestItemsFilterClause(t *testing.T) {
+	var filter itemquery.Filter
+	if clause, args := filter.Clause(); clause != "" || len(args) != 0 {
+		t.Errorf("Expected no clause for an empty filter, got %q %v", clause, args)
+	}
+
+	filter.Where("is_active = TRUE")
+	filter.Where("id > " + filter.Arg(7))
+	clause, args := filter.Clause()
+	if clause != "WHERE is_active = TRUE AND id > $1" {
+		t.Errorf("Unexpected clause %q", clause)
+	}
+	// The parameters returned are a copy
+	args[0] = 8
+	if _, again := filter.Clause(); again[0] != 7 {
+		t.Errorf("Expected the filter to keep its parameter, got %v", again[0])
+	}
+	if next := filter.Arg(100); next != "$2" {
+		t.Errorf("Expected the next placeholder to be $2, got %s", next)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"lamp":       "lamp",
+		"50%":        `50\%`,
+		"snake_case": `snake\_case`,
+		`back\slash`: `back\\slash`,
+		`\%_`:        `\\\%\_`,
+	}
+	for in, want := range tests {
+		if got := itemquery.EscapeLike(in); got != want {
+			t.Errorf("Expected %s for %s, got %s", want, in, got)
+		}
+	}
+}
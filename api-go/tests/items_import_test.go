@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/itemload"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemsImportValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.POST("/items/import", h.Import)
+
+	tests := []struct {
+		query, contentType, body string
+		code                     int
+	}{
+		{"", "application/json", `{"name":"a","price":1}`, http.StatusBadRequest},
+		{"?format=xml", "text/csv", "name,price\na,1\n", http.StatusBadRequest},
+		{"", "text/csv", "name,colour\na,red\n", http.StatusBadRequest},
+		{"", "text/csv", "", http.StatusBadRequest},
+		{"", "multipart/form-data; boundary=x", "", http.StatusBadRequest},
+		// Readable, but there is no database to load into
+		{"", "text/csv", "name,price\na,1\n", http.StatusServiceUnavailable},
+		{"?format=ndjson", "text/plain", `{"name":"a","price":1}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/items/import"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s %q, got %d", tt.code, tt.query, tt.body, w.Code)
+		}
+	}
+}
+
+func TestItemsImportFormat(t *testing.T) {
+	tests := []struct {
+		format, contentType, filename, want string
+	}{
+		{"ndjson", "text/csv", "items.csv", "ndjson"},
+		{"", "text/csv", "", "csv"},
+		{"", "application/x-ndjson", "", "ndjson"},
+		{"", "application/vnd.items+ndjson", "", "ndjson"},
+		{"", "application/octet-stream", "items.CSV", "csv"},
+		{"", "", "items.jsonl", "ndjson"},
+		{"", "application/json", "items.json", ""},
+	}
+	for _, tt := range tests {
+		if got := itemload.Format(tt.format, tt.contentType, tt.filename); got != tt.want {
+			t.Errorf("Expected %q for %+v, got %q", tt.want, tt, got)
+		}
+	}
+}
+
+// importedRow is a row read from an upload, or the rejection of it.
+type importedRow struct {
+	line     int
+	name     string
+	price    models.Decimal
+	rejected string
+}
+
+// readAll reads every row of read.
+func readAll(t *testing.T, read itemload.Reader) []importedRow {
+	t.Helper()
+	var rows []importedRow
+	for {
+		item, line, err := read()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		var rejected *itemload.Rejected
+		switch {
+		case errors.As(err, &rejected):
+			rows = append(rows, importedRow{line: line, rejected: rejected.Reason})
+		case err != nil:
+			t.Fatalf("Failed to read line %d: %v", line, err)
+		default:
+			rows = append(rows, importedRow{line: line, name: item.Name, price: item.Price})
+		}
+	}
+}
+
+func TestItemsImportCSV(t *testing.T) {
+	// Columns are found by name, whatever their order and case; the
+	// export columns that are not loaded are ignored
+	read, err := itemload.CSVReader(strings.NewReader("ID, Price ,name,created_at,is_active,description\n" +
+		"7,1.50,lamp,2024-01-01T00:00:00Z,false,\n" +
+		"8,2,\"desk\nwith a drawer\",,true,oak\n" +
+		"9,cheap,chair,,,\n" +
+		"10,3,stool,,maybe,\n" +
+		"11,4,\"bad\"quote,,,\n" +
+		"12,5,shelf\n"))
+	if err != nil {
+		t.Fatalf("Failed to read the header: %v", err)
+	}
+	want := []importedRow{
+		{line: 2, name: "lamp", price: "1.50"},
+		{line: 3, name: "desk\nwith a drawer", price: "2"},
+		{line: 5, rejected: `price "cheap" is not a number`},
+		{line: 6, rejected: `is_active "maybe" is not a boolean`},
+		{line: 7, rejected: `extraneous or missing " in quoted-field`},
+		{line: 8, name: "shelf", price: "5"},
+	}
+	if got := readAll(t, read); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected rows %+v, got %+v", want, got)
+	}
+
+	for header, message := range map[string]string{
+		"name,price,colour\n": `unknown CSV column "colour"`,
+		"name,description\n":  "the CSV header has no price column",
+		"id,price\n":          "the CSV header has no name column",
+	} {
+		if _, err := itemload.CSVReader(strings.NewReader(header)); err == nil || err.Error() != message {
+			t.Errorf("Expected %q for header %q, got %v", message, header, err)
+		}
+	}
+}
+
+func TestItemsImportNDJSON(t *testing.T) {
+	read := itemload.NDJSONReader(strings.NewReader("{\"name\":\"lamp\",\"price\":1}\n\n" +
+		"  \n{\"name\":\"desk\",\"price\":\"2.50\"}\n{\"name\":\n{\"name\":\"chair\",\"price\":3}"))
+	got := readAll(t, read)
+	if len(got) != 4 || got[0] != (importedRow{line: 1, name: "lamp", price: "1"}) ||
+		got[1] != (importedRow{line: 4, name: "desk", price: "2.50"}) ||
+		got[2].line != 5 || got[2].rejected == "" ||
+		got[3] != (importedRow{line: 6, name: "chair", price: "3"}) {
+		t.Errorf("Unexpected rows %+v", got)
+	}
+}
+
+// copyAll drains src as COPY would, returning the names loaded.
+func copyAll(src pgx.CopyFromSource, names *[]string) (int64, error) {
+	var n int64
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return 0, err
+		}
+		*names = append(*names, values[0].(string))
+		n++
+	}
+	return n, src.Err()
+}
+
+func TestItemsImportReport(t *testing.T) {
+	read := itemload.NDJSONReader(strings.NewReader("{\"name\":\"lamp\",\"price\":1}\n" +
+		"{\"name\":\"\",\"price\":1}\nnot json\n{\"name\":\"desk\",\"price\":2}\n"))
+	var names []string
+	response, err := itemload.Import(read, func(src pgx.CopyFromSource) (int64, error) { return copyAll(src, &names) })
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"lamp", "desk"}) {
+		t.Errorf("Expected lamp and desk to be loaded, got %v", names)
+	}
+	if response.Received != 4 || response.Imported != 2 || response.Rejected != 2 || response.Truncated {
+		t.Errorf("Unexpected report %+v", response)
+	}
+	if len(response.Rejections) != 2 || response.Rejections[0].Line != 2 || response.Rejections[1].Line != 3 {
+		t.Errorf("Unexpected rejections %+v", response.Rejections)
+	}
+
+	// Every rejection is counted; only the first ones are listed
+	var body strings.Builder
+	body.WriteString("name,price\n")
+	for i := 0; i < itemload.MaxRejections+5; i++ {
+		fmt.Fprintf(&body, "item %d,free\n", i)
+	}
+	body.WriteString("lamp,1\n")
+	read, _ = itemload.CSVReader(strings.NewReader(body.String()))
+	names = nil
+	response, err = itemload.Import(read, func(src pgx.CopyFromSource) (int64, error) { return copyAll(src, &names) })
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if response.Rejected != itemload.MaxRejections+5 || len(response.Rejections) != itemload.MaxRejections ||
+		!response.Truncated || response.Imported != 1 {
+		t.Errorf("Unexpected report: %d rejected, %d listed, truncated %v, %d imported",
+			response.Rejected, len(response.Rejections), response.Truncated, response.Imported)
+	}
+}
+
+func TestItemsImportReadError(t *testing.T) {
+	read := itemload.NDJSONReader(io.MultiReader(
+		strings.NewReader("{\"name\":\"lamp\",\"price\":1}\n"),
+		strings.NewReader(strings.Repeat("x", 2*1024*1024)),
+	))
+	var names []string
+	_, err := itemload.Import(read, func(src pgx.CopyFromSource) (int64, error) { return copyAll(src, &names) })
+	var readErr *itemload.ReadError
+	if !errors.As(err, &readErr) || readErr.Line != 2 {
+		t.Errorf("Expected a read error on line 2, got %v", err)
+	}
+}
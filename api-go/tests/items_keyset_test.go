@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestItemsPaging(t *testing.T) {
+	tests := []struct {
+		query       string
+		skip, limit int
+	}{
+		{"", 0, itemquery.DefaultLimit},
+		{"skip=20&limit=10", 20, 10},
+		{"limit=5000", 0, itemquery.MaxLimit},
+		// Out of range values are clamped rather than reaching the slices
+		{"limit=0", 0, 1},
+		{"limit=-3", 0, 1},
+		{"skip=-1", 0, itemquery.DefaultLimit},
+		{"skip=abc&limit=abc", 0, 1},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		skip, limit := itemquery.ParsePaging(query)
+		if skip != tt.skip || limit != tt.limit {
+			t.Errorf("Expected skip %d and limit %d for %q, got %d and %d", tt.skip, tt.limit, tt.query, skip, limit)
+		}
+	}
+}
+
+func TestItemsAfter(t *testing.T) {
+	const name = "(SELECT name FROM items WHERE id = $1)"
+	const price = "(SELECT price FROM items WHERE id = $1)"
+	tests := []struct {
+		sort   string
+		clause string
+	}{
+		{"", "WHERE (id > $1)"},
+		{"-id", "WHERE (id < $1)"},
+		{"name", "WHERE (name > " + name + " OR name = " + name + " AND id > $1)"},
+		{"-price,name", "WHERE (price < " + price + " OR price = " + price + " AND name > " + name +
+			" OR price = " + price + " AND name = " + name + " AND id > $1)"},
+	}
+	for _, tt := range tests {
+		order, err := itemquery.ParseSort(tt.sort)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.sort, err)
+		}
+		var filter itemquery.Filter
+		filter.After(order, models.ItemID("42"))
+		clause, args := filter.Clause()
+		if clause != tt.clause {
+			t.Errorf("Expected %q for %q, got %q", tt.clause, tt.sort, clause)
+		}
+		if !reflect.DeepEqual(args, []interface{}{models.ItemID("42")}) {
+			t.Errorf("Expected the key as only parameter for %q, got %v", tt.sort, args)
+		}
+	}
+
+	// The key follows the parameters of the filters
+	filter, _ := itemquery.ParseFilter(url.Values{"name": {"lamp"}})
+	filter.After(itemquery.Order{{Column: "id"}}, models.ItemID("7"))
+	if clause, _ := filter.Clause(); clause != "WHERE deleted_at IS NULL AND name ILIKE $1 AND (id > $2)" {
+		t.Errorf("Unexpected clause %q", clause)
+	}
+}
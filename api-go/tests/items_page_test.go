@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/apiversion"
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+)
+
+func TestItemsWantsPage(t *testing.T) {
+	tests := []struct {
+		version apiversion.Version
+		query   string
+		accept  string
+		want    bool
+	}{
+		{apiversion.Legacy, "", "", false},
+		{apiversion.Legacy, "", "application/json", false},
+		{apiversion.Legacy, "envelope=true", "", true},
+		{apiversion.Legacy, "envelope=false", "", false},
+		{apiversion.Legacy, "", itemquery.PageMediaType, true},
+		{apiversion.Legacy, "", "application/json, " + itemquery.PageMediaType + ";q=0.9", true},
+		// /v1 always pages
+		{apiversion.V1, "", "", true},
+		{apiversion.V1, "envelope=false", "application/json", true},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		if got := itemquery.WantsPage(tt.version, query, tt.accept); got != tt.want {
+			t.Errorf("Expected %v for version %d, %q and Accept %q, got %v", tt.want, tt.version, tt.query, tt.accept, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestItemsPatch(t *testing.T) {
+	updated := fakeReply{match: "UPDATE items", oids: itemOIDs, rows: [][]any{itemRow(1, 4)}}
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		ifMatch string
+		replies []fakeReply
+		code    int
+		etag    string
+	}{
+		{"invalid id", "/items/abc", `{"name":"b"}`, `"3"`, []fakeReply{updated}, http.StatusBadRequest, ""},
+		{"empty name", "/items/1", `{"name":""}`, `"3"`, []fakeReply{updated}, http.StatusBadRequest, ""},
+		{"negative price", "/items/1", `{"price":-1}`, `"3"`, []fakeReply{updated}, http.StatusBadRequest, ""},
+		{"not json", "/items/1", `name=b`, `"3"`, []fakeReply{updated}, http.StatusBadRequest, ""},
+		{"without If-Match", "/items/1", `{"name":"b"}`, "", []fakeReply{updated}, http.StatusPreconditionRequired, ""},
+		{"updated", "/items/1", `{"name":"b"}`, `"3"`, []fakeReply{updated}, http.StatusOK, `"4"`},
+		{"any version", "/items/1", `{"name":"b"}`, "*", []fakeReply{updated}, http.StatusOK, `"4"`},
+		{"modified since", "/items/1", `{"name":"b"}`, `"3"`, []fakeReply{
+			{match: "UPDATE items", oids: itemOIDs},
+			{match: "SELECT version FROM items", oids: []uint32{itemOIDs[7]}, rows: [][]any{{int64(5)}}},
+		}, http.StatusPreconditionFailed, `"5"`},
+		{"missing", "/items/1", `{"name":"b"}`, `"3"`, []fakeReply{
+			{match: "UPDATE items", oids: itemOIDs},
+			{match: "SELECT version FROM items", oids: []uint32{itemOIDs[7]}},
+		}, http.StatusNotFound, ""},
+		{"failure", "/items/1", `{"name":"b"}`, `"3"`, []fakeReply{{match: "UPDATE items", code: "XX000"}}, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, append(tt.replies, lsnReply)...)
+			header := http.Header{}
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+			w := itemsRequest(itemsRouter(t, fake), "PATCH", tt.path, tt.body, header)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("Expected ETag %q, got %q", tt.etag, got)
+			}
+			refused := tt.code == http.StatusBadRequest || tt.code == http.StatusPreconditionRequired
+			if refused && len(fake.executed("UPDATE items")) != 0 {
+				t.Error("Expected a refused request not to update the item")
+			}
+		})
+	}
+}
+
+func TestItemsPatchSetsOnlySuppliedFields(t *testing.T) {
+	tests := []struct {
+		body    string
+		ifMatch string
+		set     string
+		where   string
+	}{
+		{`{"name":"b"}`, `"3"`, "SET name = $1, updated_at = $2, version = version + 1",
+			"WHERE id = $3 AND deleted_at IS NULL AND version = ANY($4)"},
+		{`{"price":"2.50","is_active":false}`, `"3", W/"4"`, "SET price = $1, is_active = $2, updated_at = $3, version = version + 1",
+			"WHERE id = $4 AND deleted_at IS NULL AND version = ANY($5)"},
+		{`{"description":"d","name":"b"}`, "*", "SET name = $1, description = $2, updated_at = $3, version = version + 1",
+			"WHERE id = $4 AND deleted_at IS NULL\n"},
+		{`{}`, "*", "SET updated_at = $1, version = version + 1", "WHERE id = $2 AND deleted_at IS NULL\n"},
+	}
+	for _, tt := range tests {
+		fake := newFakePG(t, fakeReply{match: "UPDATE items", oids: itemOIDs, rows: [][]any{itemRow(1, 4)}}, lsnReply)
+		header := http.Header{}
+		header.Set("If-Match", tt.ifMatch)
+		w := itemsRequest(itemsRouter(t, fake), "PATCH", "/items/1", tt.body, header)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", tt.body, w.Code, w.Body.String())
+		}
+		updates := fake.executed("UPDATE items")
+		if len(updates) != 1 {
+			t.Fatalf("Expected one UPDATE for %s, got %d", tt.body, len(updates))
+		}
+		if !strings.Contains(updates[0], tt.set) || !strings.Contains(updates[0], tt.where) {
+			t.Errorf("Expected %q and %q for %s, got %s", tt.set, tt.where, tt.body, updates[0])
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/itemquery"
+)
+
+func TestParseItemsSort(t *testing.T) {
+	tests := []struct {
+		sort  string
+		order itemquery.Order
+		sql   string
+	}{
+		{"", itemquery.Order{{Column: "id"}}, "id"},
+		{"name", itemquery.Order{{Column: "name"}, {Column: "id"}}, "name, id"},
+		{"-price, name", itemquery.Order{{Column: "price", Desc: true}, {Column: "name"}, {Column: "id"}}, "price DESC, name, id"},
+		{"-created_at,,", itemquery.Order{{Column: "created_at", Desc: true}, {Column: "id"}}, "created_at DESC, id"},
+		// id ends the order; the columns after it are never compared
+		{"-id,name", itemquery.Order{{Column: "id", Desc: true}}, "id DESC"},
+		{"updated_at,id", itemquery.Order{{Column: "updated_at"}, {Column: "id"}}, "updated_at, id"},
+	}
+	for _, tt := range tests {
+		order, err := itemquery.ParseSort(tt.sort)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tt.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(order, tt.order) {
+			t.Errorf("Expected %v for %q, got %v", tt.order, tt.sort, order)
+		}
+		if got := order.String(); got != tt.sql {
+			t.Errorf("Expected ORDER BY %q for %q, got %q", tt.sql, tt.sort, got)
+		}
+	}
+}
+
+func TestParseItemsSortInvalid(t *testing.T) {
+	for _, sort := range []string{
+		"description",
+		"name;DROP TABLE items",
+		"name,-name",
+		"--price",
+	} {
+		if _, err := itemquery.ParseSort(sort); err == nil {
+			t.Errorf("Expected an error for %q", sort)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemOIDs are the types of the item columns handlers read: id, name,
+// description, price, is_active, created_at, updated_at and then version
+// or deleted_at.
+var itemOIDs = []uint32{
+	pgtype.Int8OID, pgtype.TextOID, pgtype.TextOID, pgtype.NumericOID, pgtype.BoolOID,
+	pgtype.TimestamptzOID, pgtype.TimestamptzOID, pgtype.Int8OID,
+}
+
+var deletedItemOIDs = append(append([]uint32{}, itemOIDs[:7]...), pgtype.TimestamptzOID)
+
+// itemRow is a row of itemOIDs.
+func itemRow(id, version int64) []any {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []any{id, "Widget", nil, "9.90", true, at, at, version}
+}
+
+// lsnReply answers the WAL position read after writes.
+var lsnReply = fakeReply{match: "pg_current_wal_insert_lsn", oids: []uint32{pgtype.TextOID}, rows: [][]any{{"0/3000060"}}}
+
+// itemsRouter serves the item routes with fake as the primary.
+func itemsRouter(t *testing.T, fake *fakePG) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(fake.pool(t), nil, time.Second))
+	r := gin.New()
+	r.GET("/items/trash", h.Trash)
+	r.GET("/items/:id/versions", h.Versions)
+	r.GET("/items/:id/versions/:n", h.Version)
+	r.PATCH("/items/:id", h.Patch)
+	r.DELETE("/items/:id", h.Delete)
+	r.POST("/items/:id/restore", h.Restore)
+	return r
+}
+
+func itemsRequest(r *gin.Engine, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestItemsTrashWithoutPrimary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.GET("/items/trash", h.Trash)
+	r.DELETE("/items/:id", h.Delete)
+	r.POST("/items/:id/restore", h.Restore)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/items/trash"},
+		{"DELETE", "/items/1"},
+		{"DELETE", "/items/1?permanent=true"},
+		{"POST", "/items/1/restore"},
+	}
+	for _, tt := range tests {
+		w := itemsRequest(r, tt.method, tt.path, "", nil)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503 for %s %s, got %d", tt.method, tt.path, w.Code)
+		}
+	}
+}
+
+func TestItemsDelete(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		reply fakeReply
+		code  int
+		sql   string
+	}{
+		{"soft", "/items/1", fakeReply{match: "UPDATE items", tag: "UPDATE 1"}, http.StatusNoContent, "SET deleted_at = NOW()"},
+		{"soft missing", "/items/1", fakeReply{match: "UPDATE items", tag: "UPDATE 0"}, http.StatusNotFound, "SET deleted_at = NOW()"},
+		{"permanent", "/items/1?permanent=true", fakeReply{match: "DELETE FROM items", tag: "DELETE 1"}, http.StatusNoContent, "DELETE FROM items"},
+		{"permanent missing", "/items/1?permanent=true", fakeReply{match: "DELETE FROM items", tag: "DELETE 0"}, http.StatusNotFound, "DELETE FROM items"},
+		{"permanent on order", "/items/1?permanent=true", fakeReply{match: "DELETE FROM items", code: "23503"}, http.StatusConflict, "DELETE FROM items"},
+		{"failure", "/items/1", fakeReply{match: "UPDATE items", code: "XX000"}, http.StatusInternalServerError, "SET deleted_at = NOW()"},
+		{"invalid id", "/items/abc", fakeReply{match: "UPDATE items", tag: "UPDATE 1"}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.reply, lsnReply)
+			w := itemsRequest(itemsRouter(t, fake), "DELETE", tt.path, "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.sql != "" && len(fake.executed(tt.sql)) != 1 {
+				t.Errorf("Expected one statement containing %q", tt.sql)
+			}
+			if tt.code == http.StatusConflict && !strings.Contains(w.Body.String(), "item_referenced") {
+				t.Errorf("Expected item_referenced, got %s", w.Body.String())
+			}
+			if got := w.Header().Get("X-Consistency-Token"); (tt.code == http.StatusNoContent) != (got != "") {
+				t.Errorf("Expected a consistency token only after a delete, got %q", got)
+			}
+		})
+	}
+}
+
+func TestItemsRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		reply fakeReply
+		code  int
+	}{
+		{"restored", "/items/1/restore", fakeReply{match: "UPDATE items", oids: itemOIDs, rows: [][]any{itemRow(1, 3)}}, http.StatusOK},
+		{"not in trash", "/items/1/restore", fakeReply{match: "UPDATE items", oids: itemOIDs}, http.StatusNotFound},
+		{"failure", "/items/1/restore", fakeReply{match: "UPDATE items", code: "XX000"}, http.StatusInternalServerError},
+		{"invalid id", "/items/-1/restore", fakeReply{match: "UPDATE items", oids: itemOIDs, rows: [][]any{itemRow(1, 3)}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.reply, lsnReply)
+			w := itemsRequest(itemsRouter(t, fake), "POST", tt.path, "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("ETag"); got != `"3"` {
+				t.Errorf("Expected ETag \"3\", got %q", got)
+			}
+			if got := w.Header().Get("X-Consistency-Token"); got != "0/3000060" {
+				t.Errorf("Expected the consistency token, got %q", got)
+			}
+			if len(fake.executed("SET deleted_at = NULL")) != 1 {
+				t.Error("Expected the item to be taken out of the trash")
+			}
+		})
+	}
+}
+
+func TestItemsTrash(t *testing.T) {
+	deletedAt := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	row := itemRow(7, 0)
+	row[7] = deletedAt
+	fake := newFakePG(t, fakeReply{match: "WHERE deleted_at IS NOT NULL", oids: deletedItemOIDs, rows: [][]any{row}})
+	r := itemsRouter(t, fake)
+
+	w := itemsRequest(r, "GET", "/items/trash?skip=5&limit=10", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var items []models.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "7" || items[0].DeletedAt == nil || !items[0].DeletedAt.Equal(deletedAt) {
+		t.Errorf("Expected item 7 deleted at %v, got %+v", deletedAt, items)
+	}
+
+	failing := newFakePG(t, fakeReply{match: "WHERE deleted_at IS NOT NULL", code: "XX000"})
+	if w := itemsRequest(itemsRouter(t, failing), "GET", "/items/trash", "", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
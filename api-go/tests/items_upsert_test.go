@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+)
+
+func TestItemsUpsertValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.PUT("/items", h.Upsert)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"items":[]}`, http.StatusBadRequest},
+		{`{"items":[{"name":"a","price":1}]}`, http.StatusBadRequest},
+		{`{"items":[{"id":"x","name":"a","price":1}]}`, http.StatusBadRequest},
+		{`{"items":[{"id":1,"name":"a","price":1},{"id":"1","name":"b","price":2}]}`, http.StatusBadRequest},
+		{`{"items":[{"id":1,"name":"a","price":1}],"on_conflict":"merge"}`, http.StatusBadRequest},
+		// Valid, but there is no database to write to
+		{`{"items":[{"id":1,"name":"a","price":1}]}`, http.StatusServiceUnavailable},
+		{`{"items":[{"id":1,"name":"a","price":1,"updated_at":"2024-01-02T03:04:05Z"}],"on_conflict":"newer"}`, http.StatusServiceUnavailable},
+		{`{"items":[{"id":2,"name":"b","price":"2.50"}],"on_conflict":"skip"}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/items", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s, got %d", tt.code, tt.body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// itemVersionOIDs are the types of the item_versions columns handlers
+// read.
+var itemVersionOIDs = []uint32{
+	pgtype.Int8OID, pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID, pgtype.TextOID, pgtype.NumericOID,
+	pgtype.BoolOID, pgtype.TimestamptzOID, pgtype.TimestamptzOID, pgtype.Int8OID,
+}
+
+// itemVersionRow is a row of itemVersionOIDs.
+func itemVersionRow(version int32, operation string) []any {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []any{int64(1), version, operation, "Widget", nil, "9.90", true, nil, at, int64(740 + version)}
+}
+
+func TestItemsVersions(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		reply fakeReply
+		code  int
+		count int
+	}{
+		{"history", "/items/1/versions", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs, rows: [][]any{
+			itemVersionRow(1, "insert"), itemVersionRow(2, "update"), itemVersionRow(3, "delete"),
+		}}, http.StatusOK, 3},
+		{"no history", "/items/1/versions", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusNotFound, 0},
+		{"failure", "/items/1/versions", fakeReply{match: "FROM item_versions", code: "XX000"}, http.StatusInternalServerError, 0},
+		{"invalid id", "/items/abc/versions", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.reply)
+			w := itemsRequest(itemsRouter(t, fake), "GET", tt.path, "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var versions []models.ItemVersion
+			if err := json.Unmarshal(w.Body.Bytes(), &versions); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(versions) != tt.count || versions[2].Operation != "delete" || versions[2].XID != 743 {
+				t.Errorf("Expected %d versions ending with the delete, got %+v", tt.count, versions)
+			}
+		})
+	}
+}
+
+func TestItemsVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		reply fakeReply
+		code  int
+	}{
+		{"found", "/items/1/versions/2", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs, rows: [][]any{itemVersionRow(2, "update")}}, http.StatusOK},
+		{"not found", "/items/1/versions/9", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusNotFound},
+		{"failure", "/items/1/versions/2", fakeReply{match: "FROM item_versions", code: "XX000"}, http.StatusInternalServerError},
+		{"invalid id", "/items/abc/versions/2", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusBadRequest},
+		{"version zero", "/items/1/versions/0", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusBadRequest},
+		{"version not a number", "/items/1/versions/latest", fakeReply{match: "FROM item_versions", oids: itemVersionOIDs}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.reply)
+			w := itemsRequest(itemsRouter(t, fake), "GET", tt.path, "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var v models.ItemVersion
+			if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if v.ItemID != "1" || v.Version != 2 || v.Operation != "update" || v.Price != "9.90" {
+				t.Errorf("Expected version 2 of item 1, got %+v", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/catalog"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestJobHistoryValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cluster := db.NewCluster(nil, nil, time.Second)
+	h := handlers.NewJobHistoryHandler(catalog.New(cluster), jobs.NewManager(1, 1, 1, ""))
+	router := gin.New()
+	router.GET("/backups/history", h.History)
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"?since=yesterday", http.StatusBadRequest},
+		{"?until=2024-01-01", http.StatusBadRequest},
+		{"?offset=-1", http.StatusBadRequest},
+		{"?since=2024-01-01T00:00:00Z&type=backup", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/backups/history"+tt.query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.code, w.Code)
+		}
+	}
+}
+
+func catalogEntry(id, jobType, stanza string, createdAt time.Time) catalog.Entry {
+	return catalog.Entry{
+		ID:        id,
+		Type:      jobType,
+		Trigger:   catalog.TriggerAPI,
+		Status:    "succeeded",
+		Params:    map[string]interface{}{"stanza": stanza},
+		CreatedAt: createdAt,
+	}
+}
+
+func TestCatalogMemory(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := catalog.NewMemory(3)
+	m.Record(ctx, catalogEntry("a", "backup", "main", base))
+	m.Record(ctx, catalogEntry("b", "restore", "main", base.Add(time.Hour)))
+	m.Record(ctx, catalogEntry("c", "backup", "other", base.Add(2*time.Hour)))
+	failed := catalogEntry("b", "restore", "main", base.Add(time.Hour))
+	failed.Status = "failed"
+	m.Record(ctx, failed)
+
+	entries, total, _ := m.List(ctx, catalog.Filter{})
+	if total != 3 || len(entries) != 3 || entries[0].ID != "c" || entries[2].ID != "a" {
+		t.Fatalf("Expected c, b and a newest first, got %d: %+v", total, entries)
+	}
+	if entries[1].Status != "failed" {
+		t.Errorf("Expected recording an ID again to replace the entry, got %+v", entries[1])
+	}
+
+	since := base.Add(time.Hour)
+	tests := []struct {
+		name   string
+		filter catalog.Filter
+		want   []string
+		total  int
+	}{
+		{"type", catalog.Filter{Type: "backup"}, []string{"c", "a"}, 2},
+		{"stanza", catalog.Filter{Stanza: "main"}, []string{"b", "a"}, 2},
+		{"status", catalog.Filter{Status: "failed"}, []string{"b"}, 1},
+		{"since", catalog.Filter{Since: &since}, []string{"c", "b"}, 2},
+		{"until", catalog.Filter{Until: &since}, []string{"a"}, 1},
+		{"page", catalog.Filter{Limit: 1, Offset: 1}, []string{"b"}, 3},
+		{"past the end", catalog.Filter{Offset: 5}, []string{}, 3},
+	}
+	for _, tt := range tests {
+		entries, total, _ := m.List(ctx, tt.filter)
+		ids := []string{}
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		if total != tt.total || len(ids) != len(tt.want) {
+			t.Errorf("%s: expected %v of %d, got %v of %d", tt.name, tt.want, tt.total, ids, total)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, ids)
+				break
+			}
+		}
+	}
+
+	m.Record(ctx, catalogEntry("d", "backup", "main", base.Add(3*time.Hour)))
+	if entries, total, _ := m.List(ctx, catalog.Filter{}); total != 3 || entries[2].ID != "b" {
+		t.Errorf("Expected the oldest recorded entry to be dropped, got %+v", entries)
+	}
+}
+
+// flakyStore is a catalog store that fails while down.
+type flakyStore struct {
+	down    bool
+	entries *catalog.Memory
+}
+
+func (s *flakyStore) Record(ctx context.Context, e catalog.Entry) error {
+	if s.down {
+		return errors.New("no primary")
+	}
+	return s.entries.Record(ctx, e)
+}
+
+func (s *flakyStore) List(ctx context.Context, f catalog.Filter) ([]catalog.Entry, int, error) {
+	if s.down {
+		return nil, 0, errors.New("no readable node")
+	}
+	return s.entries.List(ctx, f)
+}
+
+func TestCatalogFallback(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	primary := &flakyStore{down: true, entries: catalog.NewMemory(10)}
+	store := catalog.NewFallback(primary, 10)
+
+	if err := store.Record(ctx, catalogEntry("restore", "restore", "main", base)); err == nil {
+		t.Error("Expected the failure of the primary to be reported")
+	}
+	if store.Pending() != 1 {
+		t.Errorf("Expected 1 pending entry, got %d", store.Pending())
+	}
+	entries, total, err := store.List(ctx, catalog.Filter{})
+	if err != nil || total != 1 || entries[0].ID != "restore" {
+		t.Fatalf("Expected the restore from memory while the primary is down, got %+v, %v", entries, err)
+	}
+
+	primary.down = false
+	if err := store.Record(ctx, catalogEntry("backup", "backup", "main", base.Add(time.Hour))); err != nil {
+		t.Fatalf("Expected the entry to be recorded, got %v", err)
+	}
+	if store.Pending() != 0 {
+		t.Errorf("Expected the pending entry to be written, got %d pending", store.Pending())
+	}
+	if _, total, _ := primary.List(ctx, catalog.Filter{}); total != 2 {
+		t.Errorf("Expected both entries in the primary, got %d", total)
+	}
+}
+
+func TestJobHistoryFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := catalog.NewFallback(catalog.New(db.NewCluster(nil, nil, time.Second)), 10)
+	store.Record(context.Background(), catalogEntry("restore", "restore", "main", time.Now().UTC()))
+	h := handlers.NewJobHistoryHandler(store, jobs.NewManager(1, 1, 1, ""))
+	router := gin.New()
+	router.GET("/backups/history", h.History)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/backups/history?type=restore", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 without a database, got %d", w.Code)
+	}
+	var response models.JobHistoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Total != 1 || response.Entries[0].ID != "restore" {
+		t.Errorf("Expected the restore kept in memory, got %+v", response)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+)
+
+func TestJobLogsStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 1, t.TempDir())
+	m.Start(ctx)
+
+	release := make(chan struct{})
+	job, err := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		out.Report("starting")
+		fmt.Fprint(out, "line one\nline ")
+		<-release
+		fmt.Fprint(out, "two\npartial")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected submit to succeed, got %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/jobs/:id/logs", handlers.NewJobsHandler(m).Logs)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/jobs/"+job.ID+"/logs", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+
+	close(release)
+	body, _ := io.ReadAll(resp.Body)
+	stream := string(body)
+	for _, want := range []string{
+		"id:0\nevent:log\ndata:==> starting",
+		"data:line one",
+		"data:line two",
+		"id:3\nevent:log\ndata:partial",
+		"event:done",
+		`"status":"succeeded"`,
+	} {
+		if !strings.Contains(stream, want) {
+			t.Errorf("Expected stream to contain %q, got:\n%s", want, stream)
+		}
+	}
+
+	// The persisted log outlives the job history
+	next, _ := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) { return nil, nil })
+	waitForJob(t, m, next.ID)
+	if _, err := m.Get(job.ID); err == nil {
+		t.Fatal("Expected the first job to be pruned from the history")
+	}
+
+	w := httptest.NewRecorder()
+	plain, _ := http.NewRequest("GET", "/jobs/"+job.ID+"/logs", nil)
+	router.ServeHTTP(w, plain)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != "==> starting\nline one\nline two\npartial\n" {
+		t.Errorf("Unexpected persisted log %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	missing, _ := http.NewRequest("GET", "/jobs/unknown/logs", nil)
+	router.ServeHTTP(w, missing)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestJobLogsStreamOutlivesWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 1, t.TempDir())
+	m.Start(ctx)
+	job, err := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		fmt.Fprintln(out, "before")
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprintln(out, "after")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected submit to succeed, got %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/jobs/:id/logs", handlers.NewJobsHandler(m).Logs)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/jobs/"+job.ID+"/logs?follow=true", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected the stream to outlive the write timeout, got %v", err)
+	}
+	if !strings.Contains(string(body), "data:after") || !strings.Contains(string(body), "event:done") {
+		t.Errorf("Expected the whole log, got:\n%s", body)
+	}
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+)
+
+func waitForJob(t *testing.T, m *jobs.Manager, id string) jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Expected job %s to exist, got %v", id, err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not finish", id)
+	return jobs.Job{}
+}
+
+func TestJobSucceeds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	job, err := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		out.Report("working")
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatalf("Expected submit to succeed, got %v", err)
+	}
+	if job.Status != jobs.StatusQueued {
+		t.Errorf("Expected status '%s', got '%s'", jobs.StatusQueued, job.Status)
+	}
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != jobs.StatusSucceeded {
+		t.Errorf("Expected status '%s', got '%s'", jobs.StatusSucceeded, job.Status)
+	}
+	if job.Result != "done" || job.Message != "working" {
+		t.Errorf("Expected result and message to be recorded, got %v / %q", job.Result, job.Message)
+	}
+}
+
+func TestJobFails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	job, _ := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != jobs.StatusFailed || job.Error != "boom" {
+		t.Errorf("Expected failed job with error 'boom', got '%s' / '%s'", job.Status, job.Error)
+	}
+}
+
+func TestJobCancelRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 10, "")
+	m.Start(ctx)
+
+	started := make(chan struct{})
+	job, _ := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	if _, err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("Expected cancel to succeed, got %v", err)
+	}
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != jobs.StatusCanceled {
+		t.Errorf("Expected status '%s', got '%s'", jobs.StatusCanceled, job.Status)
+	}
+
+	// Deleting a finished job removes it.
+	if _, err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("Expected delete to succeed, got %v", err)
+	}
+	if _, err := m.Get(job.ID); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestJobQueueFull(t *testing.T) {
+	m := jobs.NewManager(1, 1, 10, "")
+
+	noop := func(ctx context.Context, out *jobs.Output) (interface{}, error) { return nil, nil }
+	if _, err := m.Submit("test", nil, noop); err != nil {
+		t.Fatalf("Expected first submit to succeed, got %v", err)
+	}
+	if _, err := m.Submit("test", nil, noop); !errors.Is(err, jobs.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestJobSubmitExclusive(t *testing.T) {
+	m := jobs.NewManager(2, 16, 10, "")
+	noop := func(ctx context.Context, out *jobs.Output) (interface{}, error) { return nil, nil }
+
+	// Of concurrent submissions only one gets through
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	queued := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.SubmitExclusive([]string{"restore"}, "restore", nil, noop)
+			if err == nil {
+				mu.Lock()
+				queued++
+				mu.Unlock()
+			} else if !errors.Is(err, jobs.ErrBusy) {
+				t.Errorf("Expected ErrBusy, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if queued != 1 {
+		t.Errorf("Expected 1 queued job, got %d", queued)
+	}
+
+	// Other types only conflict when listed
+	if _, err := m.SubmitExclusive([]string{"switchover"}, "failover", nil, noop); err != nil {
+		t.Errorf("Expected an unrelated job to be queued, got %v", err)
+	}
+	if _, err := m.SubmitExclusive([]string{"switchover", "failover"}, "switchover", nil, noop); !errors.Is(err, jobs.ErrBusy) {
+		t.Errorf("Expected ErrBusy while a failover is queued, got %v", err)
+	}
+}
+
+func TestJobOnFinish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := jobs.NewManager(1, 4, 10, "")
+	finished := make(chan jobs.Job, 2)
+	m.OnFinish(func(job jobs.Job) { finished <- job })
+
+	block := make(chan struct{})
+	first, _ := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+	second, _ := m.Submit("test", nil, func(ctx context.Context, out *jobs.Output) (interface{}, error) {
+		return nil, nil
+	})
+	if _, err := m.Cancel(second.ID); err != nil {
+		t.Fatalf("Expected cancel to succeed, got %v", err)
+	}
+
+	m.Start(ctx)
+	close(block)
+
+	for _, want := range []struct{ id, status string }{
+		{first.ID, jobs.StatusSucceeded},
+		{second.ID, jobs.StatusCanceled},
+	} {
+		select {
+		case job := <-finished:
+			if job.ID != want.id || job.Status != want.status {
+				t.Errorf("Expected job %s to finish as '%s', got %s as '%s'", want.id, want.status, job.ID, job.Status)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Job %s was not reported", want.id)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/logging"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+)
+
+func TestLoggingRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(config.LogConfig{Level: "info", Format: "json"}, &buf)
+
+	logger.DebugContext(context.Background(), "hidden")
+	logger.InfoContext(requestid.NewContext(context.Background(), "abc"), "shown", "key", "value")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "shown" || line["request_id"] != "abc" || line["key"] != "value" {
+		t.Errorf("Expected msg, request_id and key, got %v", line)
+	}
+}
+
+func TestRequestLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := logging.New(config.LogConfig{Level: "info", Format: "json"}, &buf)
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	// No successful request is sampled
+	router.Use(middleware.RequestLog(logger, 0))
+	router.GET("/items/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/items/1", "/items/0"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected only the failed request to be logged, got %q", buf.String())
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", lines[0], err)
+	}
+	want := map[string]interface{}{
+		"level":      "WARN",
+		"route":      "/items/:id",
+		"path":       "/items/0",
+		"status":     float64(404),
+		"db_queries": float64(0),
+		"request_id": "req-1",
+	}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, line[key])
+		}
+	}
+	if _, ok := line["latency_ms"]; !ok {
+		t.Error("Expected latency_ms")
+	}
+}
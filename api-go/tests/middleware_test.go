@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	"github.com/postgresql-ha-dr/api-go/internal/requestid"
+)
+
+func setupSecurityRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.Use(middleware.SecurityHeaders(config.ServerConfig{
+		HSTSMaxAge:   time.Hour,
+		FrameOptions: "DENY",
+	}))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	return router
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	router := setupSecurityRouter()
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("Expected X-Content-Type-Options 'nosniff', got '%s'", got)
+	}
+
+	if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("Expected X-Frame-Options 'DENY', got '%s'", got)
+	}
+
+	// Plain HTTP must not advertise HSTS
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("Expected no HSTS header over HTTP, got '%s'", got)
+	}
+}
+
+func TestSecurityHeadersHSTSOverTLS(t *testing.T) {
+	router := setupSecurityRouter()
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Errorf("Expected HSTS header, got '%s'", got)
+	}
+}
+
+func setupAPIKeyRouter(keys []config.APIKey) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.Use(middleware.Authorize(keys, []middleware.AccessRule{{Path: "/admin/", Role: config.RoleViewer}}))
+	router.GET("/admin/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	return router
+}
+
+func TestAuthorizeAPIKey(t *testing.T) {
+	router := setupAPIKeyRouter([]config.APIKey{{Name: "admin", Key: "s3cret", Role: config.RoleAdmin}})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"wrong key", "X-API-Key", "nope", http.StatusUnauthorized},
+		{"header key", "X-API-Key", "s3cret", http.StatusOK},
+		{"bearer key", "Authorization", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/admin/ping", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestAuthorizeDisabled(t *testing.T) {
+	router := setupAPIKeyRouter(nil)
+
+	req, _ := http.NewRequest("GET", "/admin/ping", nil)
+	req.Header.Set("X-API-Key", "")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestAuthorizeKeys(t *testing.T) {
+	router := setupAPIKeyRouter([]config.APIKey{
+		{Name: "ops", Key: "k1", Role: config.RoleAdmin},
+		{Name: "ci", Key: "a-longer-key", Role: config.RoleViewer},
+	})
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"k", http.StatusUnauthorized},
+		{"k1x", http.StatusUnauthorized},
+		{"k1", http.StatusOK},
+		{"a-longer-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/admin/ping", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("Key %q: expected status %d, got %d", tt.key, tt.want, w.Code)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	keys := []config.APIKey{
+		{Name: "dashboards", Key: "v", Role: config.RoleViewer},
+		{Name: "backup-cron", Key: "o", Role: config.RoleOperator},
+		{Name: "oncall", Key: "a", Role: config.RoleAdmin},
+	}
+	router.Use(middleware.Authorize(keys, []middleware.AccessRule{
+		{Method: http.MethodGet, Path: "/admin/", Role: config.RoleViewer},
+		{Path: "/admin/", Role: config.RoleAdmin},
+		{Method: http.MethodPost, Path: "/backups/", Role: config.RoleOperator},
+	}))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET("/admin/connections", ok)
+	router.POST("/admin/promote", ok)
+	router.POST("/backups/stanza", ok)
+	router.GET("/backups", ok)
+
+	tests := []struct {
+		method, path, key string
+		want              int
+	}{
+		{"GET", "/backups", "", http.StatusOK},
+		{"GET", "/admin/connections", "", http.StatusUnauthorized},
+		{"GET", "/admin/connections", "v", http.StatusOK},
+		{"POST", "/backups/stanza", "v", http.StatusForbidden},
+		{"POST", "/backups/stanza", "o", http.StatusOK},
+		{"POST", "/backups/stanza", "a", http.StatusOK},
+		{"POST", "/admin/promote", "o", http.StatusForbidden},
+		{"POST", "/admin/promote", "a", http.StatusOK},
+		{"POST", "/admin/promote", "x", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s %s with key %q: expected status %d, got %d", tt.method, tt.path, tt.key, tt.want, w.Code)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"none", "", false},
+		{"valid", "req-42:a.b_c", true},
+		{"invalid", "bad id\n", false},
+		{"too long", strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/ping", nil)
+		if tt.sent != "" {
+			req.Header.Set("X-Request-ID", tt.sent)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		id := w.Header().Get("X-Request-ID")
+		if w.Body.String() != id {
+			t.Errorf("%s: expected the context to carry %q, got %q", tt.name, id, w.Body.String())
+		}
+		if tt.kept && id != tt.sent {
+			t.Errorf("%s: expected %q to be kept, got %q", tt.name, tt.sent, id)
+		}
+		if !tt.kept && (id == tt.sent || !requestid.Valid(id)) {
+			t.Errorf("%s: expected a new ID, got %q", tt.name, id)
+		}
+	}
+}
+
+func TestRequestIDPropagate(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "abc")
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://patroni:8008/cluster", nil)
+	requestid.Propagate(req)
+	if got := req.Header.Get("X-Request-ID"); got != "abc" {
+		t.Errorf("Expected X-Request-ID abc, got '%s'", got)
+	}
+}
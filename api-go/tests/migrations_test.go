@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/lifecycle"
+	"github.com/postgresql-ha-dr/api-go/internal/migrations"
+)
+
+func TestMigrationsLoad(t *testing.T) {
+	m, err := migrations.New(config.ItemKeySerial)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	list := m.Migrations()
+	if len(list) < 7 {
+		t.Fatalf("Expected at least 7 migrations, got %d", len(list))
+	}
+	for i, migration := range list {
+		if migration.Version != i+1 {
+			t.Errorf("Expected version %d, got %d (%s)", i+1, migration.Version, migration.Name)
+		}
+	}
+	if !strings.Contains(list[0].SQL, "id SERIAL PRIMARY KEY") || strings.Contains(list[0].SQL, "items_uuidv7") {
+		t.Errorf("Expected serial item keys, got:\n%s", list[0].SQL)
+	}
+	if !strings.Contains(list[1].SQL, "item_id integer NOT NULL") {
+		t.Errorf("Expected integer order line keys, got:\n%s", list[1].SQL)
+	}
+
+	m, err = migrations.New(config.ItemKeyUUIDv7)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	list = m.Migrations()
+	if !strings.Contains(list[0].SQL, "id UUID PRIMARY KEY DEFAULT items_uuidv7()") || !strings.Contains(list[0].SQL, "CREATE OR REPLACE FUNCTION items_uuidv7()") {
+		t.Errorf("Expected UUIDv7 item keys, got:\n%s", list[0].SQL)
+	}
+	if !strings.Contains(list[1].SQL, "item_id uuid NOT NULL") {
+		t.Errorf("Expected UUID order line keys, got:\n%s", list[1].SQL)
+	}
+	if !strings.Contains(list[3].SQL, "item_id uuid NOT NULL") {
+		t.Errorf("Expected UUID item version keys, got:\n%s", list[3].SQL)
+	}
+}
+
+func TestMigrationsChecksum(t *testing.T) {
+	serial, err := migrations.New(config.ItemKeySerial)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	uuid, err := migrations.New(config.ItemKeyUUIDv7)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	list := serial.Migrations()
+	for i, migration := range list {
+		if len(migration.Checksum) != 64 {
+			t.Errorf("Expected a SHA-256 checksum for %s, got %q", migration.Name, migration.Checksum)
+		}
+		if other := uuid.Migrations()[i].Checksum; other != migration.Checksum {
+			t.Errorf("Expected the checksum of %s not to depend on the key type, got %s and %s", migration.Name, migration.Checksum, other)
+		}
+	}
+
+	applied := map[int]string{}
+	for _, migration := range list {
+		applied[migration.Version] = migration.Checksum
+	}
+	if err := serial.Compare(applied); err != nil {
+		t.Errorf("Expected matching checksums, got %v", err)
+	}
+
+	applied[1] = ""
+	if err := serial.Compare(applied); err != nil {
+		t.Errorf("Expected a missing checksum to be accepted, got %v", err)
+	}
+
+	var diverged *migrations.ChecksumError
+	applied[2] = strings.Repeat("0", 64)
+	err = serial.Compare(applied)
+	if !errors.As(err, &diverged) || len(diverged.Diverged) != 1 || !strings.HasPrefix(diverged.Diverged[0], "2_") {
+		t.Errorf("Expected migration 2 to diverge, got %v", err)
+	}
+
+	delete(applied, 2)
+	applied[len(list)+1] = strings.Repeat("f", 64)
+	err = serial.Compare(applied)
+	if !errors.As(err, &diverged) || len(diverged.Diverged) != 1 {
+		t.Errorf("Expected an unknown migration to diverge, got %v", err)
+	}
+}
+
+func TestMigrateWithoutPrimary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, err := migrations.New(config.ItemKeySerial)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	h := handlers.NewMigrationsHandler(m, db.NewCluster(nil, nil, time.Second), lifecycle.NewTracker())
+	r := gin.New()
+	r.GET("/admin/migrations", h.List)
+	r.POST("/admin/migrate", h.Migrate)
+
+	for _, method := range []string{"GET", "POST"} {
+		path := "/admin/migrations"
+		if method == "POST" {
+			path = "/admin/migrate"
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503 for %s %s without a database, got %d", method, path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/notify"
+)
+
+func TestNotifierPostsSignedPayload(t *testing.T) {
+	type received struct {
+		payload   notify.Payload
+		signature string
+		body      []byte
+	}
+	got := make(chan received, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var p notify.Payload
+		json.Unmarshal(body, &p)
+		got <- received{payload: p, signature: r.Header.Get(notify.SignatureHeader), body: body}
+	}))
+	defer server.Close()
+
+	n := notify.New([]string{server.URL}, "secret", time.Second)
+	n.Send("backup.succeeded", map[string]string{"label": "20240101-000000F"})
+
+	select {
+	case r := <-got:
+		if r.payload.Event != "backup.succeeded" {
+			t.Errorf("Expected event 'backup.succeeded', got '%s'", r.payload.Event)
+		}
+		if r.signature != notify.Sign("secret", r.body) {
+			t.Errorf("Expected valid signature, got '%s'", r.signature)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
+
+func TestNotifierDisabledWithoutURLs(t *testing.T) {
+	if notify.New(nil, "", time.Second).Enabled() {
+		t.Error("Expected notifier without URLs to be disabled")
+	}
+}
+
+func TestDispatcherNotifiesChatsAndWebhooks(t *testing.T) {
+	got := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		got <- body
+	}))
+	defer server.Close()
+
+	d, err := notify.NewDispatcher("pgha",
+		notify.New([]string{server.URL + "/hook"}, "", time.Second),
+		[]notify.Chat{{Kind: notify.ChatSlack, URL: server.URL + "/slack"}, {Kind: notify.ChatTeams}},
+		"{{.App}}: {{.Message}} ({{.Data.to}})", []string{"role_changed"})
+	if err != nil {
+		t.Fatalf("Expected dispatcher, got %v", err)
+	}
+	d.Dispatch("timeline_changed", "Timeline switched from 2 to 3", nil, time.Now())
+	d.Dispatch("role_changed", "Node was promoted to primary", map[string]interface{}{"to": "primary"}, time.Now())
+
+	bodies := map[string]map[string]interface{}{}
+	for len(bodies) < 2 {
+		select {
+		case b := <-got:
+			bodies[b["path"].(string)] = b
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 2 notifications, got %v", bodies)
+		}
+	}
+	want := "pgha: Node was promoted to primary (primary)"
+	if text := bodies["/slack"]["text"]; text != want {
+		t.Errorf("Expected Slack text %q, got %v", want, text)
+	}
+	if event := bodies["/hook"]["event"]; event != "cluster.role_changed" {
+		t.Errorf("Expected webhook event 'cluster.role_changed', got %v", event)
+	}
+	select {
+	case b := <-got:
+		t.Errorf("Expected the filtered event not to be sent, got %v", b)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcherRejectsInvalidTemplate(t *testing.T) {
+	if _, err := notify.NewDispatcher("pgha", notify.New(nil, "", time.Second), nil, "{{.Message", nil); err == nil {
+		t.Error("Expected an invalid template to be rejected")
+	}
+}
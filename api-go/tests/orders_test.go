@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// orderOIDs are the types of the order columns handlers read: id,
+// status, total, created_at and updated_at.
+var orderOIDs = []uint32{pgtype.Int8OID, pgtype.TextOID, pgtype.NumericOID, pgtype.TimestamptzOID, pgtype.TimestamptzOID}
+
+// orderRow is a row of orderOIDs.
+func orderRow(id int64, status, total string) []any {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []any{id, status, total, at, at}
+}
+
+// ordersRouter serves the order routes with cluster.
+func ordersRouter(cluster *db.Cluster) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewOrdersHandler(handlers.NewItemsHandler(&config.Config{}, cluster))
+	r := gin.New()
+	r.POST("/orders", h.Create)
+	r.GET("/orders", h.List)
+	r.GET("/orders/:id", h.Get)
+	r.POST("/orders/:id/cancel", h.Cancel)
+	return r
+}
+
+func TestOrdersValidation(t *testing.T) {
+	fake := newFakePG(t)
+	live := ordersRouter(db.NewCluster(fake.pool(t), nil, time.Second))
+	down := ordersRouter(db.NewCluster(nil, nil, time.Second))
+
+	tests := []struct {
+		router *gin.Engine
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{live, "POST", "/orders", `{}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[]}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[{"item_id":1}]}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[{"item_id":1,"quantity":0}]}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[{"item_id":1,"quantity":10001}]}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[{"quantity":1}]}`, http.StatusBadRequest},
+		{live, "POST", "/orders", `{"lines":[{"item_id":"abc","quantity":1}]}`, http.StatusBadRequest},
+		{live, "GET", "/orders/abc", "", http.StatusBadRequest},
+		{live, "POST", "/orders/abc/cancel", "", http.StatusBadRequest},
+		// Valid, but there is no database
+		{down, "POST", "/orders", `{"lines":[{"item_id":1,"quantity":1}]}`, http.StatusServiceUnavailable},
+		{down, "POST", "/orders/1/cancel", "", http.StatusServiceUnavailable},
+		{down, "GET", "/orders", "", http.StatusServiceUnavailable},
+		{down, "GET", "/orders/1", "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		w := itemsRequest(tt.router, tt.method, tt.path, tt.body, nil)
+		if w.Code != tt.code {
+			t.Errorf("Expected status %d for %s %s %s, got %d", tt.code, tt.method, tt.path, tt.body, w.Code)
+		}
+	}
+	if queries := fake.executed(""); len(queries) != 0 {
+		t.Errorf("Expected invalid requests not to reach the database, got %q", queries)
+	}
+}
+
+func TestOrdersCreate(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	priceOIDs := []uint32{pgtype.Int8OID, pgtype.NumericOID}
+	lockParams := []uint32{pgtype.Int8ArrayOID}
+	bothItems := fakeReply{match: "FOR SHARE", params: lockParams, oids: priceOIDs, rows: [][]any{{int64(1), "9.90"}, {int64(2), "0.50"}}}
+	orderInserted := fakeReply{match: "INSERT INTO orders", oids: []uint32{pgtype.Int8OID, pgtype.TimestamptzOID, pgtype.TimestamptzOID}, rows: [][]any{{int64(42), at, at}}}
+	lineParams := []uint32{pgtype.Int8OID, pgtype.Int8ArrayOID, pgtype.Int4ArrayOID, pgtype.NumericArrayOID}
+	linesInserted := fakeReply{match: "INSERT INTO order_lines", params: lineParams, tag: "INSERT 0 2"}
+	totalSet := fakeReply{match: "SET total", oids: []uint32{pgtype.NumericOID}, rows: [][]any{{"30.20"}}}
+	body := `{"lines":[{"item_id":1,"quantity":2},{"item_id":2,"quantity":1},{"item_id":"1","quantity":1}]}`
+
+	tests := []struct {
+		name    string
+		replies []fakeReply
+		code    int
+		end     string
+	}{
+		{"placed", []fakeReply{bothItems, orderInserted, linesInserted, totalSet, lsnReply}, http.StatusCreated, "commit"},
+		{"unavailable item", []fakeReply{
+			{match: "FOR SHARE", params: lockParams, oids: priceOIDs, rows: [][]any{{int64(1), "9.90"}}},
+			orderInserted, linesInserted, totalSet,
+		}, http.StatusConflict, "rollback"},
+		{"items unreadable", []fakeReply{{match: "FOR SHARE", params: lockParams, code: "XX000"}}, http.StatusInternalServerError, "rollback"},
+		{"lines refused", []fakeReply{bothItems, orderInserted, {match: "INSERT INTO order_lines", params: lineParams, code: "23503"}}, http.StatusInternalServerError, "rollback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.replies...)
+			w := itemsRequest(ordersRouter(db.NewCluster(fake.pool(t), nil, time.Second)), "POST", "/orders", body, nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if len(fake.executed(tt.end)) != 1 {
+				t.Errorf("Expected the transaction to end with %s, got %q", tt.end, fake.executed(""))
+			}
+			if tt.code == http.StatusConflict && len(fake.executed("INSERT INTO orders")) != 0 {
+				t.Error("Expected no order for unavailable items")
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+
+			var order models.Order
+			if err := json.Unmarshal(w.Body.Bytes(), &order); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if order.ID != 42 || order.Status != models.OrderStatusPlaced || order.Total != "30.20" {
+				t.Errorf("Expected placed order 42 of 30.20, got %+v", order)
+			}
+			// Lines for the same item are merged, in item order
+			want := []models.OrderLine{{ItemID: "1", Quantity: 3, UnitPrice: "9.90"}, {ItemID: "2", Quantity: 1, UnitPrice: "0.50"}}
+			if len(order.Lines) != len(want) || order.Lines[0] != want[0] || order.Lines[1] != want[1] {
+				t.Errorf("Expected lines %+v, got %+v", want, order.Lines)
+			}
+			if got := w.Header().Get("X-Consistency-Token"); got != "0/3000060" {
+				t.Errorf("Expected the consistency token, got %q", got)
+			}
+		})
+	}
+}
+
+func TestOrdersGet(t *testing.T) {
+	lineOIDs := []uint32{pgtype.Int8OID, pgtype.Int4OID, pgtype.NumericOID}
+	order := fakeReply{match: "FROM orders", oids: orderOIDs, rows: [][]any{orderRow(42, models.OrderStatusPlaced, "30.20")}}
+	tests := []struct {
+		name    string
+		replies []fakeReply
+		code    int
+	}{
+		{"found", []fakeReply{order, {match: "FROM order_lines", oids: lineOIDs, rows: [][]any{{int64(1), int32(3), "9.90"}, {int64(2), int32(1), "0.50"}}}}, http.StatusOK},
+		{"not found", []fakeReply{{match: "FROM orders", oids: orderOIDs}}, http.StatusNotFound},
+		{"order unreadable", []fakeReply{{match: "FROM orders", code: "XX000"}}, http.StatusInternalServerError},
+		{"lines unreadable", []fakeReply{order, {match: "FROM order_lines", code: "XX000"}}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.replies...)
+			w := itemsRequest(ordersRouter(db.NewCluster(fake.pool(t), nil, time.Second)), "GET", "/orders/42", "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if len(fake.executed("isolation level repeatable read read only")) != 1 {
+				t.Errorf("Expected one read-only snapshot, got %q", fake.executed(""))
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got models.Order
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if got.ID != 42 || len(got.Lines) != 2 || got.Lines[0].Quantity != 3 || got.Lines[1].UnitPrice != "0.50" {
+				t.Errorf("Expected order 42 with its 2 lines, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestOrdersList(t *testing.T) {
+	fake := newFakePG(t, fakeReply{match: "FROM orders", oids: orderOIDs, rows: [][]any{
+		orderRow(43, models.OrderStatusCancelled, "1.00"), orderRow(42, models.OrderStatusPlaced, "30.20"),
+	}})
+	w := itemsRequest(ordersRouter(db.NewCluster(fake.pool(t), nil, time.Second)), "GET", "/orders", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var orders []models.Order
+	if err := json.Unmarshal(w.Body.Bytes(), &orders); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(orders) != 2 || orders[0].ID != 43 || orders[0].Lines != nil {
+		t.Errorf("Expected orders 43 and 42 without lines, got %+v", orders)
+	}
+
+	failing := newFakePG(t, fakeReply{match: "FROM orders", code: "XX000"})
+	w = itemsRequest(ordersRouter(db.NewCluster(failing.pool(t), nil, time.Second)), "GET", "/orders", "", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+func TestOrdersCancel(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply fakeReply
+		code  int
+	}{
+		{"cancelled", fakeReply{match: "UPDATE orders", oids: orderOIDs, rows: [][]any{orderRow(42, models.OrderStatusCancelled, "30.20")}}, http.StatusOK},
+		{"not placed", fakeReply{match: "UPDATE orders", oids: orderOIDs}, http.StatusNotFound},
+		{"failure", fakeReply{match: "UPDATE orders", code: "XX000"}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakePG(t, tt.reply, lsnReply)
+			w := itemsRequest(ordersRouter(db.NewCluster(fake.pool(t), nil, time.Second)), "POST", "/orders/42/cancel", "", nil)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var order models.Order
+			if err := json.Unmarshal(w.Body.Bytes(), &order); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if order.Status != models.OrderStatusCancelled || w.Header().Get("X-Consistency-Token") == "" {
+				t.Errorf("Expected a cancelled order and a consistency token, got %+v", order)
+			}
+		})
+	}
+}
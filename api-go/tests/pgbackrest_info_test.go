@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+)
+
+const fakePgBackRestInfo = `#!/bin/sh
+echo '[{"name": "main", "status": {"code": 2, "message": "no valid backups"}, "backup": [], "archive": [],
+	"repo": [
+		{"key": 1, "cipher": "aes-256-cbc", "status": {"code": 2, "message": "no valid backups"}},
+		{"key": 2, "cipher": "none", "status": {"code": 2, "message": "no valid backups"}}
+	]}]'
+`
+
+const pgBackRestConf = `[global]
+repo1-type=s3
+repo1-cipher-type=aes-256-cbc
+repo1-bundle=y
+compress-type=lz4
+
+[global:backup]
+compress-type=zst
+
+[main]
+repo1-block=y
+
+[other]
+repo2-block=y
+`
+
+func TestPgBackRestInfoReportsEncryptionAndCompression(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "pgbackrest"), []byte(fakePgBackRestInfo), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	confDir := t.TempDir()
+	configFile := filepath.Join(confDir, "pgbackrest.conf")
+	if err := os.WriteFile(configFile, []byte(pgBackRestConf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	includeDir := filepath.Join(confDir, "conf.d")
+	os.MkdirAll(includeDir, 0o700)
+	if err := os.WriteFile(filepath.Join(includeDir, "level.conf"), []byte("[global]\ncompress-level=9\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Backup: config.BackupConfig{
+		Binary:            "pgbackrest",
+		Stanza:            "main",
+		ConfigFile:        configFile,
+		ConfigIncludePath: includeDir,
+		ExtraArgs:         []string{"--no-repo1-bundle"},
+	}}
+	info := backup.New(cfg).Info(context.Background(), "main")
+	if len(info.Repositories) != 2 {
+		t.Fatalf("Expected 2 repositories, got %d (%s)", len(info.Repositories), info.Status)
+	}
+
+	repo1, repo2 := info.Repositories[0], info.Repositories[1]
+	if !repo1.Encrypted || repo2.Encrypted {
+		t.Errorf("Expected only repo1 to be encrypted, got %v / %v", repo1.Encrypted, repo2.Encrypted)
+	}
+	if repo1.Type == nil || *repo1.Type != "s3" || repo2.Type == nil || *repo2.Type != "posix" {
+		t.Errorf("Unexpected repository types %v / %v", repo1.Type, repo2.Type)
+	}
+	if repo1.CompressType == nil || *repo1.CompressType != "zst" {
+		t.Errorf("Expected compress type 'zst' from [global:backup], got %v", repo1.CompressType)
+	}
+	if repo1.CompressLevel == nil || *repo1.CompressLevel != 9 {
+		t.Errorf("Expected compress level 9 from the include path, got %v", repo1.CompressLevel)
+	}
+	if repo1.BlockIncremental == nil || !*repo1.BlockIncremental {
+		t.Error("Expected block incremental on repo1 from the stanza section")
+	}
+	if repo2.BlockIncremental == nil || *repo2.BlockIncremental {
+		t.Error("Expected block incremental off on repo2; [other] does not apply")
+	}
+	if repo1.Bundle == nil || *repo1.Bundle {
+		t.Error("Expected --no-repo1-bundle to override the configuration")
+	}
+
+	// Without a readable configuration nothing is assumed
+	cfg.Backup.ConfigFile = filepath.Join(confDir, "missing.conf")
+	cfg.Backup.ConfigIncludePath = ""
+	cfg.Backup.ExtraArgs = nil
+	info = backup.New(cfg).Info(context.Background(), "main")
+	if repo := info.Repositories[0]; repo.CompressType != nil || repo.Bundle != nil {
+		t.Errorf("Expected unknown settings to be omitted, got %v / %v", repo.CompressType, repo.Bundle)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestPromoteRefusals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		body   string
+		status int
+		code   string
+	}{
+		{"missing confirmation", &config.Config{}, `{}`, http.StatusBadRequest, "validation_error"},
+		{"wait too long", &config.Config{}, `{"confirm":"1","wait_seconds":7200}`, http.StatusBadRequest, "validation_error"},
+		{"patroni managed", &config.Config{Patroni: config.PatroniConfig{URLs: []string{"http://patroni:8008"}}},
+			`{"confirm":"1"}`, http.StatusConflict, "managed_by_patroni"},
+		{"no database", &config.Config{}, `{"confirm":"1"}`, http.StatusServiceUnavailable, "database_unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlers.NewPromoteHandler(tt.cfg, nil, nil)
+			router := gin.New()
+			router.POST("/admin/promote", h.Promote)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admin/promote", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			var response models.ErrorResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Error != tt.code {
+				t.Errorf("Expected error %s, got %s", tt.code, response.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+func planStatus() models.BackupResponse {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 1, d, 2, 0, 0, 0, time.UTC)
+		return &t
+	}
+	timeline := uint32(1)
+	return models.BackupResponse{
+		Status: "ok",
+		Backups: []models.BackupInfo{
+			{
+				Label: "20240101-010000F", Type: "full", StopTime: day(1), Timeline: &timeline,
+				StartWAL: strPtr("000000010000000000000002"), StopWAL: strPtr("000000010000000000000003"),
+				StopLSN: strPtr("0/3000100"),
+			},
+			{
+				Label: "20240101-010000F_20240102-010000I", Type: "incr", StopTime: day(2), Timeline: &timeline,
+				StartWAL: strPtr("000000010000000000000008"), StopWAL: strPtr("000000010000000000000008"),
+				StopLSN:   strPtr("0/8000100"),
+				Reference: []string{"20240101-010000F"},
+			},
+		},
+		WALArchive: &models.WALArchiveInfo{
+			MinWAL: strPtr("000000010000000000000002"),
+			MaxWAL: strPtr("000000010000000000000010"),
+		},
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestPlanRestoreLatest(t *testing.T) {
+	plan := backup.PlanRestore(planStatus(), models.RestoreRequest{Target: models.RestoreTargetLatest}, wal.DefaultSegmentSize)
+
+	if !plan.Feasible {
+		t.Fatalf("Expected a feasible plan, got warnings %v", plan.Warnings)
+	}
+	if plan.Backup == nil || plan.Backup.Type != "incr" {
+		t.Fatalf("Expected the incremental backup, got %+v", plan.Backup)
+	}
+	if strings.Join(plan.BackupChain, ",") != "20240101-010000F,20240101-010000F_20240102-010000I" {
+		t.Errorf("Unexpected backup chain %v", plan.BackupChain)
+	}
+	if *plan.WALStart != "000000010000000000000008" || *plan.WALStop != "000000010000000000000010" {
+		t.Errorf("Unexpected WAL range %s-%s", *plan.WALStart, *plan.WALStop)
+	}
+}
+
+func TestPlanRestoreTimeSelectsEarlierBackup(t *testing.T) {
+	target := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	plan := backup.PlanRestore(planStatus(), models.RestoreRequest{Target: models.RestoreTargetTime, Timestamp: &target}, wal.DefaultSegmentSize)
+
+	if plan.Backup == nil || plan.Backup.Label != "20240101-010000F" {
+		t.Fatalf("Expected the full backup, got %+v", plan.Backup)
+	}
+
+	before := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	plan = backup.PlanRestore(planStatus(), models.RestoreRequest{Target: models.RestoreTargetTime, Timestamp: &before}, wal.DefaultSegmentSize)
+	if plan.Feasible || plan.Backup != nil {
+		t.Error("Expected no feasible plan before the first backup")
+	}
+}
+
+func TestPlanRestoreLSN(t *testing.T) {
+	plan := backup.PlanRestore(planStatus(), models.RestoreRequest{Target: models.RestoreTargetLSN, LSN: "0/5000000"}, wal.DefaultSegmentSize)
+	if !plan.Feasible || plan.Backup.Label != "20240101-010000F" {
+		t.Fatalf("Expected a feasible plan from the full backup, got %+v", plan)
+	}
+	if *plan.WALStop != "000000010000000000000005" {
+		t.Errorf("Expected WAL stop 000000010000000000000005, got %s", *plan.WALStop)
+	}
+
+	plan = backup.PlanRestore(planStatus(), models.RestoreRequest{Target: models.RestoreTargetLSN, LSN: "0/20000000"}, wal.DefaultSegmentSize)
+	if plan.Feasible {
+		t.Error("Expected a target beyond the archive to be infeasible")
+	}
+}
+
+func TestPlanRestoreTimelineAndExpiredWAL(t *testing.T) {
+	status := planStatus()
+	status.WALArchive.MinWAL = strPtr("000000010000000000000005")
+	status.WALArchive.MaxWAL = strPtr("000000020000000000000010")
+
+	plan := backup.PlanRestore(status, models.RestoreRequest{Target: models.RestoreTargetBackup, BackupLabel: "20240101-010000F"}, wal.DefaultSegmentSize)
+	if plan.Feasible {
+		t.Error("Expected the plan to be infeasible once the backup's WAL expired")
+	}
+
+	plan = backup.PlanRestore(status, models.RestoreRequest{Target: models.RestoreTargetLatest}, wal.DefaultSegmentSize)
+	if !plan.Feasible {
+		t.Fatalf("Expected a feasible plan, got warnings %v", plan.Warnings)
+	}
+	if plan.ArchiveTimeline == nil || *plan.ArchiveTimeline != 2 {
+		t.Errorf("Expected archive timeline 2, got %v", plan.ArchiveTimeline)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], "timeline 2") {
+		t.Errorf("Expected a timeline warning, got %v", plan.Warnings)
+	}
+}
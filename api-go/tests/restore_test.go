@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func setupRestoreRouter(dataDir string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			Stanza:         "test",
+			CommandTimeout: time.Second,
+			DataDir:        dataDir,
+			RestoreTimeout: time.Minute,
+		},
+	}
+
+	restoreHandler := handlers.NewRestoreHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	router.POST("/restore", restoreHandler.Restore)
+
+	return router
+}
+
+func postRestore(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/restore", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRestoreDryRunLSN(t *testing.T) {
+	router := setupRestoreRouter(t.TempDir())
+
+	w := postRestore(router, `{"target":"lsn","lsn":"0/3000060","dry_run":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.RestorePlanResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	command := strings.Join(response.Command, " ")
+	want := "pgbackrest --stanza=test --type=lsn --target=0/3000060 --target-action=promote restore"
+	if command != want {
+		t.Errorf("Expected command '%s', got '%s'", want, command)
+	}
+}
+
+func TestRestoreInvalidTarget(t *testing.T) {
+	router := setupRestoreRouter(t.TempDir())
+
+	for _, body := range []string{
+		`{"target":"yesterday"}`,
+		`{"target":"lsn","lsn":"bogus"}`,
+		`{"target":"time"}`,
+		`{"target":"backup","backup_label":"../etc"}`,
+	} {
+		if w := postRestore(router, body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestRestoreRefusedWhileRunning(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "postmaster.pid"), []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router := setupRestoreRouter(dir)
+
+	w := postRestore(router, `{"target":"latest","dry_run":true}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestRestoreDryRunOverSSH(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			Stanza:         "test",
+			CommandTimeout: time.Second,
+			DataDir:        t.TempDir(),
+			RepoHost:       "repo1",
+			SSHHost:        "db1",
+			SSHUser:        "postgres",
+			SSHPort:        22,
+		},
+	}
+	restoreHandler := handlers.NewRestoreHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	router.POST("/restore", restoreHandler.Restore)
+
+	w := postRestore(router, `{"target":"time","timestamp":"2024-01-02T03:04:05Z","dry_run":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.RestorePlanResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	command := strings.Join(response.Command, " ")
+	want := "ssh -o BatchMode=yes postgres@db1 -- pgbackrest --repo1-host=repo1 --stanza=test --type=time " +
+		"'--target=2024-01-02 03:04:05.000000+00' --target-action=promote restore"
+	if command != want {
+		t.Errorf("Expected command '%s', got '%s'", want, command)
+	}
+}
+
+func TestRestoreDryRunPgDump(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	dumpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dumpDir, "app"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []string{"20240101T010000Z", "20240102T010000Z"} {
+		if err := os.WriteFile(filepath.Join(dumpDir, "app", label+".dump"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{Host: "db", Port: 5432, Name: "app", User: "postgres", Password: "secret"},
+		Backup: config.BackupConfig{
+			Provider:       "pg_dump",
+			DumpDir:        dumpDir,
+			CommandTimeout: time.Second,
+			RestoreTimeout: time.Minute,
+		},
+	}
+	restoreHandler := handlers.NewRestoreHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	router.POST("/restore", restoreHandler.Restore)
+
+	w := postRestore(router, `{"target":"latest","dry_run":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.RestorePlanResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	command := strings.Join(response.Command, " ")
+	expected := "pg_restore --clean --if-exists --no-owner --host=db --port=5432 --username=postgres --dbname=app " +
+		filepath.Join(dumpDir, "app", "20240102T010000Z.dump")
+	if command != expected {
+		t.Errorf("Expected command %q, got %q", expected, command)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("Expected the password to stay out of the plan")
+	}
+
+	w = postRestore(router, `{"target":"lsn","lsn":"0/3000060","dry_run":true}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for lsn target, got %d", w.Code)
+	}
+}
+
+func TestRestoreDryRunInvocationOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	cfg := &config.Config{
+		Backup: config.BackupConfig{
+			Stanza:            "test",
+			Binary:            "/usr/local/bin/pgbackrest",
+			ConfigFile:        "/etc/pgbackrest/custom.conf",
+			ConfigIncludePath: "/etc/pgbackrest/conf.d",
+			Repo:              2,
+			ExtraArgs:         []string{"--process-max=4"},
+			CommandTimeout:    time.Second,
+			DataDir:           t.TempDir(),
+			RestoreTimeout:    time.Minute,
+		},
+	}
+	restoreHandler := handlers.NewRestoreHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	router.POST("/restore", restoreHandler.Restore)
+
+	w := postRestore(router, `{"target":"lsn","lsn":"0/3000060","dry_run":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.RestorePlanResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	command := strings.Join(response.Command, " ")
+	expected := "/usr/local/bin/pgbackrest --config=/etc/pgbackrest/custom.conf --config-include-path=/etc/pgbackrest/conf.d " +
+		"--process-max=4 --repo=2 --stanza=test --type=lsn --target=0/3000060 --target-action=promote restore"
+	if command != expected {
+		t.Errorf("Expected command %q, got %q", expected, command)
+	}
+}
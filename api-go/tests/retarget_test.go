@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+)
+
+// fakePool is a write pool that moves wherever it is told.
+type fakePool struct {
+	mu        sync.Mutex
+	host      string
+	port      int
+	retargets int
+	readOnly  atomic.Int64
+}
+
+func (p *fakePool) Addr() (string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.host, p.port
+}
+
+func (p *fakePool) Retarget(_ context.Context, host string, port int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.host, p.port = host, port
+	p.retargets++
+	p.readOnly.Store(0)
+	return nil
+}
+
+func (p *fakePool) ReadOnlyErrors() int64 {
+	return p.readOnly.Load()
+}
+
+func (p *fakePool) moves() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.retargets
+}
+
+// fakeLocator returns a locator answering with host:5432, or err, and
+// counting its calls.
+func fakeLocator(name string, host *atomic.Value, err error, calls *atomic.Int64) db.Locator {
+	return db.Locator{Name: name, Locate: func(context.Context) (string, int, error) {
+		calls.Add(1)
+		if err != nil {
+			return "", 0, err
+		}
+		return host.Load().(string), 5432, nil
+	}}
+}
+
+// waitFor polls cond for up to a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRetargeterTrigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := &fakePool{host: "pg1", port: 5432}
+	var host atomic.Value
+	host.Store("pg2")
+	var down, calls atomic.Int64
+	r := db.NewRetargeter(pool, 0, time.Hour,
+		fakeLocator("patroni", &host, errors.New("no leader"), &down),
+		fakeLocator("topology", &host, nil, &calls))
+	moved := make(chan [3]string, 1)
+	r.OnRetarget(func(from, to, reason string) { moved <- [3]string{from, to, reason} })
+	r.Start(ctx)
+
+	// The first locator fails; the next one is asked
+	r.Trigger("role changed")
+	select {
+	case got := <-moved:
+		if got != [3]string{"pg1:5432", "pg2:5432", "role changed"} {
+			t.Errorf("Unexpected retarget %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the pool to move")
+	}
+	if down.Load() != 1 || calls.Load() != 1 {
+		t.Errorf("Expected each locator to be asked once, got %d and %d", down.Load(), calls.Load())
+	}
+
+	// The pool already is on the primary
+	r.Trigger("role changed again")
+	waitFor(t, "the second lookup", func() bool { return calls.Load() == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if n := pool.moves(); n != 1 {
+		t.Errorf("Expected no move to the same address, got %d moves", n)
+	}
+}
+
+func TestRetargeterLocateFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := &fakePool{host: "pg1", port: 5432}
+	var host atomic.Value
+	var calls atomic.Int64
+	r := db.NewRetargeter(pool, 0, time.Hour, fakeLocator("patroni", &host, errors.New("unreachable"), &calls))
+	r.Start(ctx)
+
+	r.Trigger("role changed")
+	waitFor(t, "the lookup", func() bool { return calls.Load() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if h, p := pool.Addr(); pool.moves() != 0 || h != "pg1" || p != 5432 {
+		t.Errorf("Expected the pool to stay on pg1:5432, got %s:%d", h, p)
+	}
+}
+
+func TestRetargeterReadOnlyErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := &fakePool{host: "pg1", port: 5432}
+	var host atomic.Value
+	host.Store("pg1")
+	var calls atomic.Int64
+	r := db.NewRetargeter(pool, 3, 10*time.Millisecond, fakeLocator("patroni", &host, nil, &calls))
+	r.Start(ctx)
+
+	// Below the threshold nothing is looked up
+	pool.readOnly.Store(2)
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() != 0 {
+		t.Fatalf("Expected no lookup below the threshold, got %d", calls.Load())
+	}
+
+	// Three more errors look the primary up once, even when it has not
+	// moved: the same errors do not count again on the next checks
+	pool.readOnly.Store(5)
+	waitFor(t, "the lookup", func() bool { return calls.Load() == 1 })
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single lookup for the same errors, got %d", calls.Load())
+	}
+
+	// Three more since then move the pool
+	host.Store("pg2")
+	pool.readOnly.Store(8)
+	waitFor(t, "the move", func() bool { return pool.moves() == 1 })
+	if h, _ := pool.Addr(); h != "pg2" {
+		t.Errorf("Expected the pool on pg2, got %s", h)
+	}
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+// unreachablePool returns a pool of a node nothing listens on.
+func unreachablePool(t *testing.T) *db.Pool {
+	t.Helper()
+	cfg := &config.DatabaseConfig{User: "postgres", Name: "postgres", PoolMaxSize: 2, ConnectTimeout: time.Second}
+	pool, err := db.NewLazyPoolForHost(cfg, "127.0.0.1", 1)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// waitForStatus polls the cluster until ok accepts its status.
+func waitForStatus(t *testing.T, c *db.Cluster, ok func(db.ClusterStatus) bool) db.ClusterStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := c.Status()
+		if ok(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the health check, got %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClusterWriterAndReader(t *testing.T) {
+	primary := unreachablePool(t)
+	replicas := []*db.Pool{unreachablePool(t), unreachablePool(t)}
+	c := db.NewCluster(primary, replicas, 20*time.Millisecond)
+
+	// Every node counts as up until the first health check
+	if pool, err := c.Writer(); err != nil || pool != primary {
+		t.Errorf("Expected writes to the primary, got %v", err)
+	}
+	if pool, fromReplica, err := c.Reader(); err != nil || fromReplica || pool != primary {
+		t.Errorf("Expected reads from the primary, got replica=%t err=%v", fromReplica, err)
+	}
+	if _, down := c.PrimaryDownSince(); down {
+		t.Error("Expected the primary to be up")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	before := time.Now().UTC()
+	c.Start(ctx)
+	status := waitForStatus(t, c, func(s db.ClusterStatus) bool {
+		return !s.PrimaryUp && s.ReplicasAvailable == 0
+	})
+	if status.ReplicasTotal != 2 || status.PrimaryDownSince == nil || status.PrimaryDownSince.Before(before) {
+		t.Errorf("Expected 2 replicas down and the primary down since the check, got %+v", status)
+	}
+
+	if _, err := c.Writer(); !errors.Is(err, db.ErrPrimaryUnavailable) {
+		t.Errorf("Expected ErrPrimaryUnavailable, got %v", err)
+	}
+	if _, _, err := c.Reader(); !errors.Is(err, db.ErrNoReadableNode) {
+		t.Errorf("Expected ErrNoReadableNode, got %v", err)
+	}
+}
+
+func TestClusterReadFallback(t *testing.T) {
+	replicas := []*db.Pool{unreachablePool(t), unreachablePool(t)}
+	c := db.NewCluster(nil, replicas, time.Second)
+
+	if _, err := c.Writer(); !errors.Is(err, db.ErrPrimaryUnavailable) {
+		t.Errorf("Expected writes to fail without a primary, got %v", err)
+	}
+	since, down := c.PrimaryDownSince()
+	if !down || since.IsZero() {
+		t.Errorf("Expected the primary to be down since startup, got %v, %t", since, down)
+	}
+
+	// Reads go round-robin to the replicas while the primary is down
+	for i := 0; i < 4; i++ {
+		pool, fromReplica, err := c.Reader()
+		if err != nil || !fromReplica || pool != replicas[i%2] {
+			t.Errorf("Read %d: expected replica %d, got replica=%t err=%v", i, i%2, fromReplica, err)
+		}
+	}
+}
+
+func TestItemsReadOnlyFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		FailoverEstimate:    30 * time.Second,
+		HealthCheckInterval: 5 * time.Second,
+	}}
+	h := handlers.NewItemsHandler(cfg, db.NewCluster(nil, []*db.Pool{unreachablePool(t)}, time.Second))
+	r := gin.New()
+	r.GET("/items/:id", h.Get)
+	r.POST("/items", h.Create)
+
+	// Writes get a structured 503 with the expected recovery time
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"a","price":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", w.Code)
+	}
+	var response models.PrimaryUnavailableResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 30 {
+		t.Errorf("Expected Retry-After within the failover estimate, got %q", w.Header().Get("Retry-After"))
+	}
+	if response.Error != "primary_unavailable" || !response.ReadOnly || response.PrimaryDownSince == nil || response.EstimatedRecoverySeconds != retryAfter {
+		t.Errorf("Expected a read-only 503 matching Retry-After, got %+v", response)
+	}
+
+	// Reads are sent to the replica, which fails without hiding the item
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/items/1", nil))
+	if w.Header().Get("X-Database-Role") != "replica" {
+		t.Errorf("Expected the read to be served by the replica, got %q", w.Header().Get("X-Database-Role"))
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 from the unreachable replica, got %d", w.Code)
+	}
+}
+
+func TestItemsRetryAfterPastFailoverEstimate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		FailoverEstimate:    time.Nanosecond,
+		HealthCheckInterval: 5 * time.Second,
+	}}
+	h := handlers.NewItemsHandler(cfg, db.NewCluster(nil, nil, time.Second))
+	r := gin.New()
+	r.DELETE("/items/:id", h.Delete)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/items/1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", w.Code)
+	}
+	// Once the failover should have happened, retry at the next health check
+	if got := w.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Expected Retry-After of the health check interval, got %q", got)
+	}
+}
+
+func TestItemsStalenessHeaders(t *testing.T) {
+	lastReplay := time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	lagOIDs := []uint32{pgtype.Int8OID, pgtype.Float8OID, pgtype.TimestamptzOID}
+	trash := fakeReply{match: "WHERE deleted_at IS NOT NULL", oids: deletedItemOIDs}
+	tests := []struct {
+		name    string
+		lag     fakeReply
+		headers map[string]string
+	}{
+		{"lagging", fakeReply{match: "pg_last_wal_receive_lsn", oids: lagOIDs, rows: [][]any{{int64(8192), 1.5, lastReplay}}}, map[string]string{
+			"X-Replica-Lag-Bytes":   "8192",
+			"X-Replica-Lag-Seconds": "1.500",
+			"X-Replica-Last-Replay": "2024-01-02T03:04:05.123Z",
+		}},
+		// Before the first replay PostgreSQL knows none of them
+		{"unknown", fakeReply{match: "pg_last_wal_receive_lsn", oids: lagOIDs, rows: [][]any{{nil, nil, nil}}}, map[string]string{
+			"X-Replica-Lag-Bytes":   "",
+			"X-Replica-Lag-Seconds": "",
+			"X-Replica-Last-Replay": "",
+		}},
+		{"caught up", fakeReply{match: "pg_last_wal_receive_lsn", oids: lagOIDs, rows: [][]any{{int64(0), 0.0, nil}}}, map[string]string{
+			"X-Replica-Lag-Bytes":   "0",
+			"X-Replica-Lag-Seconds": "0.000",
+			"X-Replica-Last-Replay": "",
+		}},
+		{"unreadable", fakeReply{match: "pg_last_wal_receive_lsn", code: "XX000"}, map[string]string{
+			"X-Replica-Lag-Bytes":   "",
+			"X-Replica-Lag-Seconds": "",
+			"X-Replica-Last-Replay": "",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			replica := newFakePG(t, tt.lag, trash)
+			h := handlers.NewItemsHandler(&config.Config{}, db.NewCluster(nil, []*db.Pool{replica.pool(t)}, time.Second))
+			r := gin.New()
+			r.GET("/items/trash", h.Trash)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/items/trash", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("X-Database-Role"); got != "replica" {
+				t.Errorf("Expected X-Database-Role replica, got %q", got)
+			}
+			for name, want := range tt.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("Expected %s %q, got %q", name, want, got)
+				}
+			}
+		})
+	}
+
+	// Reads from the primary carry none of them
+	primary := newFakePG(t, fakeReply{match: "pg_last_wal_receive_lsn", oids: lagOIDs, rows: [][]any{{int64(1), 1.0, lastReplay}}}, trash)
+	w := itemsRequest(itemsRouter(t, primary), "GET", "/items/trash", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-Database-Role") != "" || w.Header().Get("X-Replica-Lag-Bytes") != "" {
+		t.Errorf("Expected no replica headers from the primary, got %d %v", w.Code, w.Header())
+	}
+	if len(primary.executed("pg_last_wal_receive_lsn")) != 0 {
+		t.Error("Expected no lag query on the primary")
+	}
+}
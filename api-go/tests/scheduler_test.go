@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/scheduler"
+)
+
+func TestSchedulerStatus(t *testing.T) {
+	s, err := scheduler.New(map[string]string{
+		"full": "0 0 1 1 *",
+		"incr": "* * * * *",
+		"diff": "",
+	}, func(name string) (string, error) { return "", nil })
+	if err != nil {
+		t.Fatalf("Expected valid schedule, got %v", err)
+	}
+
+	status := s.Status()
+	if len(status) != 2 {
+		t.Fatalf("Expected 2 scheduled entries, got %d", len(status))
+	}
+	// The every-minute entry always runs before the yearly one.
+	if status[0].Name != "incr" {
+		t.Errorf("Expected 'incr' to run next, got '%s'", status[0].Name)
+	}
+	if !status[0].Next.After(time.Now()) {
+		t.Errorf("Expected next run in the future, got %v", status[0].Next)
+	}
+	if status[0].LastRun != nil {
+		t.Error("Expected no last run before the scheduler started")
+	}
+	// Status was taken first, so at most a minute may have passed
+	if next := s.Next(); next.Before(status[0].Next) || next.After(status[0].Next.Add(time.Minute)) {
+		t.Errorf("Expected the next run to be %v, got %v", status[0].Next, next)
+	}
+}
+
+func TestSchedulerInvalidSpec(t *testing.T) {
+	_, err := scheduler.New(map[string]string{"full": "not a cron"}, nil)
+	if err == nil {
+		t.Error("Expected error for invalid cron expression")
+	}
+}
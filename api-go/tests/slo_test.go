@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/heartbeat"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestHeartbeatTracker(t *testing.T) {
+	tracker := heartbeat.NewTracker()
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker.Written(1, t0)
+	tracker.Written(2, t0.Add(5*time.Second))
+	tracker.Observed("pg2", 2, t0.Add(5*time.Second), t0.Add(6*time.Second), nil)
+	tracker.Observed("pg3", 1, t0, t0.Add(6*time.Second), nil)
+
+	snap := tracker.Snapshot()
+	if len(snap.Targets) != 2 || snap.Targets[0].Lag != 0 || snap.Targets[1].Lag != 6*time.Second {
+		t.Fatalf("Expected pg2 current and pg3 6s behind, got %+v", snap.Targets)
+	}
+
+	// pg3 stops answering; its lag keeps growing from the last heartbeat
+	tracker.Observed("pg3", 0, time.Time{}, t0.Add(20*time.Second), errors.New("connection refused"))
+	if o := tracker.Snapshot().Targets[1]; o.Seq != 1 || o.Lag != 20*time.Second || o.Error == "" {
+		t.Errorf("Expected pg3 to keep seq 1 with 20s lag, got %+v", o)
+	}
+
+	tracker.WriteFailed(errors.New("primary down"), t0.Add(10*time.Second))
+	tracker.WriteFailed(errors.New("primary down"), t0.Add(15*time.Second))
+	if snap = tracker.Snapshot(); snap.OutageStart == nil || !snap.OutageStart.Equal(t0.Add(10*time.Second)) || snap.Outages != 1 {
+		t.Fatalf("Expected one outage since 10s, got %+v", snap)
+	}
+	tracker.Written(3, t0.Add(40*time.Second))
+	snap = tracker.Snapshot()
+	if snap.OutageStart != nil || snap.LastOutage == nil || snap.LastOutage.End.Sub(snap.LastOutage.Start) != 30*time.Second {
+		t.Errorf("Expected a finished 30s outage, got %+v", snap.LastOutage)
+	}
+}
+
+func TestSLOEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{SLO: config.SLOConfig{Interval: time.Second, RPOTarget: 10 * time.Second, RTOTarget: time.Minute}}
+	h := handlers.NewSLOHandler(cfg, db.NewCluster(nil, nil, time.Second))
+	router := gin.New()
+	router.GET("/slo", h.SLO)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/slo", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while disabled, got %d", w.Code)
+	}
+
+	cfg.SLO.Heartbeat = true
+	now := time.Now().UTC()
+	h.Tracker().Written(7, now.Add(-30*time.Second))
+	h.Tracker().Observed("pg2", 5, now.Add(-40*time.Second), now.Add(-10*time.Second), nil)
+	h.Tracker().WriteFailed(errors.New("primary down"), now.Add(-20*time.Second))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response models.SLOResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.RPO.Seconds == nil || *response.RPO.Seconds != 30 || response.RPO.Met == nil || *response.RPO.Met {
+		t.Errorf("Expected a missed RPO of 30s, got %+v", response.RPO)
+	}
+	if response.RTO.CurrentOutage == nil || response.RTO.Seconds == nil || *response.RTO.Seconds < 20 ||
+		response.RTO.Met == nil || !*response.RTO.Met {
+		t.Errorf("Expected a met RTO during a 20s outage, got %+v", response.RTO)
+	}
+}
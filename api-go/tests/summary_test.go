@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/db"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/jobs"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func TestSummaryAlerts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PATH", t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &config.Config{
+		Backup:  config.BackupConfig{Binary: "pgbackrest", Stanza: "main", CommandTimeout: time.Second},
+		Summary: config.SummaryConfig{RefreshInterval: 20 * time.Millisecond, BackupRefreshInterval: time.Second},
+		Alerts:  config.AlertsConfig{EvaluationInterval: 20 * time.Millisecond},
+		Metrics: config.MetricsConfig{HistoryInterval: time.Second},
+	}
+	backups := handlers.NewBackupsHandler(cfg, nil, jobs.NewManager(1, 1, 1, ""))
+	alerts := handlers.NewAlertsHandler(cfg, handlers.NewMetricsHandler(cfg, nil), backups)
+	summary := handlers.NewSummaryHandler(cfg, db.NewCluster(nil, nil, time.Second), backups)
+	summary.UseAlerts(alerts)
+	alerts.Start(ctx)
+	summary.Start(ctx)
+
+	router := gin.New()
+	router.GET("/summary", summary.Summary)
+
+	// Without a database the metrics alert fires
+	deadline := time.Now().Add(2 * time.Second)
+	var response models.SummaryResponse
+	for time.Now().Before(deadline) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/summary", nil)
+		router.ServeHTTP(w, req)
+		response = models.SummaryResponse{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Alerts != nil && response.Alerts.Count > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if response.Alerts == nil || response.Alerts.Critical == 0 {
+		t.Fatalf("Expected critical alerts in the summary, got %+v", response.Alerts)
+	}
+	found := false
+	for _, a := range response.Alerts.Active {
+		if a.Rule == handlers.RuleMetricsUnavailable && a.Severity == "critical" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected %s among %+v", handlers.RuleMetricsUnavailable, response.Alerts.Active)
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/syncrep"
+)
+
+func TestParseSynchronousStandbyNames(t *testing.T) {
+	cases := []struct {
+		value string
+		want  syncrep.Config
+	}{
+		{"", syncrep.Config{}},
+		{"pg2", syncrep.Config{Method: "first", Num: 1, Names: []string{"pg2"}}},
+		{"pg2, pg3", syncrep.Config{Method: "first", Num: 1, Names: []string{"pg2", "pg3"}}},
+		{"2 (pg2, pg3, pg4)", syncrep.Config{Method: "first", Num: 2, Names: []string{"pg2", "pg3", "pg4"}}},
+		{"FIRST 1 (pg2, \"Site \"\"B\"\"\")", syncrep.Config{Method: "first", Num: 1, Names: []string{"pg2", `Site "B"`}}},
+		{"ANY 2 (*)", syncrep.Config{Method: "any", Num: 2, Names: []string{"*"}}},
+		{"first", syncrep.Config{Method: "first", Num: 1, Names: []string{"first"}}},
+	}
+	for _, tc := range cases {
+		got, err := syncrep.Parse(tc.value)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expected %q to parse as %+v, got %+v", tc.value, tc.want, got)
+		}
+	}
+
+	for _, value := range []string{"ANY 0 (pg2)", "2 (pg2", "pg2,", "\"pg2", "pg2 pg3"} {
+		if _, err := syncrep.Parse(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestSyncCandidates(t *testing.T) {
+	cfg, _ := syncrep.Parse("ANY 2 (PG2, pg3, pg4)")
+	standbys := []syncrep.Standby{
+		{ApplicationName: "pg2", State: "streaming", SyncState: "quorum"},
+		{ApplicationName: "pg3", State: "catchup", SyncState: "quorum"},
+		{ApplicationName: "pg5", State: "streaming", SyncState: "async"},
+	}
+	candidates := cfg.Candidates(standbys)
+	if len(candidates) != 1 || candidates[0].ApplicationName != "pg2" {
+		t.Errorf("Expected only pg2 to be a candidate, got %+v", candidates)
+	}
+}
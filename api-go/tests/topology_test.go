@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/topology"
+)
+
+func TestTopologyDiscovery(t *testing.T) {
+	lag := int64(128)
+	// pg1 is the primary with a sync standby pg2 (known by hostname, seen
+	// by pg1 as 10.0.0.2) and an async standby pg3, which cascades to pg4.
+	// pg5 is down.
+	reports := map[string]topology.Report{
+		"pg1:5432": {SystemIdentifier: "73", Timeline: 3, ServerAddr: "10.0.0.1", Downstreams: []topology.Downstream{
+			{ApplicationName: "pg2", ClientAddr: "10.0.0.2", State: "streaming", SyncState: "sync", LagBytes: &lag},
+			{ApplicationName: "pg3", ClientAddr: "10.0.0.3", State: "streaming", SyncState: "async"},
+		}},
+		"pg2:5432": {InRecovery: true, SystemIdentifier: "73", Timeline: 3, ServerAddr: "10.0.0.2",
+			Upstream: &topology.Address{Host: "10.0.0.1", Port: 5432}, UpstreamStatus: "streaming"},
+		"10.0.0.3:5432": {InRecovery: true, SystemIdentifier: "73", Timeline: 3, ServerAddr: "10.0.0.3",
+			Upstream: &topology.Address{Host: "pg1", Port: 5432}, UpstreamStatus: "streaming",
+			Downstreams: []topology.Downstream{
+				{ApplicationName: "pg4", ClientAddr: "10.0.0.4", State: "streaming", SyncState: "async"},
+			}},
+		"10.0.0.4:5432": {InRecovery: true, SystemIdentifier: "73", Timeline: 3, ServerAddr: "10.0.0.4",
+			Upstream: &topology.Address{Host: "10.0.0.3", Port: 5432}, UpstreamStatus: "streaming"},
+	}
+	probed := map[string]int{}
+	probe := func(ctx context.Context, addr topology.Address) (topology.Report, error) {
+		probed[addr.String()]++
+		report, ok := reports[addr.String()]
+		if !ok {
+			return topology.Report{}, errors.New("connection refused")
+		}
+		return report, nil
+	}
+
+	seeds := []topology.Address{{Host: "pg1", Port: 5432}, {Host: "pg2", Port: 5432}, {Host: "pg5", Port: 5432}}
+	g := topology.Discover(context.Background(), seeds, 5432, 10, serialize(probe))
+
+	if g.Primary != "pg1:5432" {
+		t.Errorf("Expected primary pg1:5432, got %q (warnings %v)", g.Primary, g.Warnings)
+	}
+	roles := map[string]string{}
+	for _, n := range g.Nodes {
+		roles[n.ID] = n.Role
+	}
+	want := map[string]string{
+		"pg1:5432":      topology.RolePrimary,
+		"pg2:5432":      topology.RoleStandby,
+		"10.0.0.3:5432": topology.RoleStandby,
+		"10.0.0.4:5432": topology.RoleCascading,
+		"pg5:5432":      topology.RoleUnknown,
+	}
+	for id, role := range want {
+		if roles[id] != role {
+			t.Errorf("Expected %s to be %s, got %q", id, role, roles[id])
+		}
+	}
+	if len(g.Nodes) != len(want) {
+		t.Errorf("Expected %d nodes, got %+v", len(want), g.Nodes)
+	}
+	if probed["10.0.0.2:5432"] != 0 || probed["10.0.0.1:5432"] != 0 {
+		t.Errorf("Expected aliases of known nodes not to be probed, got %v", probed)
+	}
+
+	var syncEdge *topology.Edge
+	for i, e := range g.Edges {
+		if e.To == "pg2:5432" {
+			syncEdge = &g.Edges[i]
+		}
+	}
+	if syncEdge == nil || syncEdge.From != "pg1:5432" || syncEdge.SyncState != "sync" || syncEdge.LagBytes == nil || *syncEdge.LagBytes != 128 {
+		t.Errorf("Expected a sync edge pg1 -> pg2, got %+v", syncEdge)
+	}
+	if len(g.Edges) != 3 {
+		t.Errorf("Expected 3 edges, got %+v", g.Edges)
+	}
+}
+
+func TestTopologySplitBrain(t *testing.T) {
+	probe := func(ctx context.Context, addr topology.Address) (topology.Report, error) {
+		return topology.Report{SystemIdentifier: addr.Host, Timeline: 2}, nil
+	}
+	seeds := []topology.Address{{Host: "a", Port: 5432}, {Host: "b", Port: 5432}}
+	g := topology.Discover(context.Background(), seeds, 5432, 10, serialize(probe))
+
+	if g.Primary != "" || len(g.Warnings) != 2 {
+		t.Errorf("Expected split brain and identifier warnings, got primary %q, warnings %v", g.Primary, g.Warnings)
+	}
+}
+
+// serialize makes a fake probe safe for the concurrent probes of one
+// discovery level.
+func serialize(probe topology.ProbeFunc) topology.ProbeFunc {
+	var mu sync.Mutex
+	return func(ctx context.Context, addr topology.Address) (topology.Report, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return probe(ctx, addr)
+	}
+}
+
+func TestTopologyDOT(t *testing.T) {
+	lag := int64(64)
+	g := &topology.Graph{
+		Primary: "pg1:5432",
+		Nodes: []topology.Node{
+			{ID: "pg1:5432", Role: topology.RolePrimary, Reachable: true, Timeline: 2},
+			{ID: "pg2:5432", Role: topology.RoleStandby, Reachable: true, Timeline: 2},
+			{ID: "pg\"3", Role: topology.RoleUnknown, Error: "connection refused"},
+		},
+		Edges: []topology.Edge{
+			{From: "pg1:5432", To: "pg2:5432", State: "streaming", SyncState: "sync", LagBytes: &lag},
+			{From: "pg1:5432", To: "pg\"3", State: "catchup", SyncState: "async"},
+		},
+	}
+
+	dot := g.DOT()
+	for _, want := range []string{
+		"digraph replication {",
+		`"pg1:5432" [label="pg1:5432\nprimary, timeline 2"`,
+		`"pg\"3" [label="pg\"3\nunreachable", style="rounded,dashed"`,
+		`"pg1:5432" -> "pg2:5432" [label="sync, lag 64 B", style=bold];`,
+		`"pg1:5432" -> "pg\"3" [label="catchup", style=dashed, color=red];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT output to contain %s, got:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/middleware"
+	"github.com/postgresql-ha-dr/api-go/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), config.TracingConfig{}, config.AppConfig{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing())
+	router.GET("/items/:id", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "child", trace.SpanKindInternal)
+		span.End()
+		if c.Param("id") == "0" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, path := range []string{"/items/1", "/items/0"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		req.Header.Set("X-Request-ID", "req-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name() != "GET /items/:id" {
+		t.Errorf("Expected span GET /items/:id, got %s", server.Name())
+	}
+	if server.SpanContext().TraceID().String() != traceID {
+		t.Errorf("Expected the trace of the caller, got %s", server.SpanContext().TraceID())
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("Expected the handler's span to be a child of the request span")
+	}
+	attrs := attribute.NewSet(server.Attributes()...)
+	if v, _ := attrs.Value("http.response.status_code"); v.AsInt64() != 200 {
+		t.Errorf("Expected status 200, got %v", v.Emit())
+	}
+	if v, _ := attrs.Value("request_id"); v.AsString() != "req-1" {
+		t.Errorf("Expected request_id req-1, got %v", v.Emit())
+	}
+	if server.Status().Code != codes.Unset {
+		t.Errorf("Expected an unset status, got %v", server.Status())
+	}
+	if failed := spans[3]; failed.Status().Code != codes.Error {
+		t.Errorf("Expected the 500 to mark its span failed, got %v", failed.Status())
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/postgresql-ha-dr/api-go/internal/wal"
+)
+
+const segmentSize = 16 * 1024 * 1024
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := wal.ParseLSN("16/B374D848")
+	if err != nil {
+		t.Fatalf("Failed to parse LSN: %v", err)
+	}
+
+	if uint64(lsn) != 0x16B374D848 {
+		t.Errorf("Expected 0x16B374D848, got %#x", uint64(lsn))
+	}
+
+	if lsn.String() != "16/B374D848" {
+		t.Errorf("Expected '16/B374D848', got '%s'", lsn.String())
+	}
+
+	if _, err := wal.ParseLSN("not-an-lsn"); err == nil {
+		t.Error("Expected error for invalid LSN")
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	seg, err := wal.ParseFileName("000000020000001600000083", segmentSize)
+	if err != nil {
+		t.Fatalf("Failed to parse WAL file name: %v", err)
+	}
+
+	if seg.Timeline != 2 {
+		t.Errorf("Expected timeline 2, got %d", seg.Timeline)
+	}
+
+	lsn, _ := wal.ParseLSN("16/83000000")
+	if seg.StartLSN(segmentSize) != lsn {
+		t.Errorf("Expected start LSN %s, got %s", lsn, seg.StartLSN(segmentSize))
+	}
+
+	if seg.FileName(segmentSize) != "000000020000001600000083" {
+		t.Errorf("Expected round-trip file name, got '%s'", seg.FileName(segmentSize))
+	}
+
+	if _, err := wal.ParseFileName("00000002.history", segmentSize); err == nil {
+		t.Error("Expected error for history file name")
+	}
+}
+
+func TestParseTimelineHistory(t *testing.T) {
+	content := "1\t0/3000158\tno recovery target specified\n\n" +
+		"2\t0/5000060\tbefore 2024-01-01 12:00:00+00\n"
+	switches, err := wal.ParseTimelineHistory(content)
+	if err != nil {
+		t.Fatalf("Failed to parse history: %v", err)
+	}
+
+	if len(switches) != 2 {
+		t.Fatalf("Expected 2 switches, got %d", len(switches))
+	}
+	if switches[1].Timeline != 2 || switches[1].SwitchLSN.String() != "0/5000060" {
+		t.Errorf("Unexpected second switch %+v", switches[1])
+	}
+	if switches[0].Reason != "no recovery target specified" {
+		t.Errorf("Expected the reason to be kept, got '%s'", switches[0].Reason)
+	}
+
+	if wal.HistoryFileName(3) != "00000003.history" {
+		t.Errorf("Expected '00000003.history', got '%s'", wal.HistoryFileName(3))
+	}
+
+	if _, err := wal.ParseTimelineHistory("x\t0/1\n"); err == nil {
+		t.Error("Expected error for invalid history line")
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postgresql-ha-dr/api-go/internal/backup"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+const fakeWALG = `#!/bin/sh
+case "$1" in
+backup-list)
+	echo '[
+		{"backup_name": "base_000000010000000000000005_D_000000010000000000000002", "wal_file_name": "000000010000000000000005",
+		 "start_time": "2024-01-02T01:00:00Z", "finish_time": "2024-01-02T01:05:00Z", "start_lsn": 83886120, "finish_lsn": 83886376,
+		 "uncompressed_size": 4096, "compressed_size": 1024},
+		{"backup_name": "base_000000010000000000000002", "wal_file_name": "000000010000000000000002",
+		 "start_time": "2024-01-01T01:00:00Z", "finish_time": "2024-01-01T01:10:00Z", "start_lsn": 33554472, "finish_lsn": 33554728,
+		 "uncompressed_size": 8192, "compressed_size": 2048, "is_permanent": true}
+	]'
+	;;
+wal-show)
+	echo '[{"id": 1, "start_segment": "000000010000000000000002", "end_segment": "000000010000000000000009", "status": "OK"}]'
+	;;
+esac
+`
+
+func walgConfig() *config.Config {
+	return &config.Config{
+		Backup: config.BackupConfig{
+			Provider: "wal-g",
+			DataDir:  "/var/lib/postgresql/data",
+		},
+	}
+}
+
+func TestWALGInfo(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "wal-g"), []byte(fakeWALG), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	provider := backup.New(walgConfig())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status := provider.Info(ctx, backup.WALGTarget)
+	if status.Status != "ok" {
+		t.Fatalf("Expected status 'ok', got '%s' (%v)", status.Status, status.StatusMessage)
+	}
+	if len(status.Backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %d", len(status.Backups))
+	}
+
+	full, delta := status.Backups[0], status.Backups[1]
+	if full.Type != "full" || delta.Type != "incr" {
+		t.Errorf("Expected full then incr, got %s then %s", full.Type, delta.Type)
+	}
+	if delta.Prior == nil || *delta.Prior != "base_000000010000000000000002" {
+		t.Errorf("Expected delta to be based on the full backup, got %v", delta.Prior)
+	}
+	if full.StartLSN == nil || *full.StartLSN != "0/2000028" {
+		t.Errorf("Expected start LSN 0/2000028, got %v", full.StartLSN)
+	}
+	if status.LastFullBackup == nil || status.LastIncrBackup == nil {
+		t.Error("Expected last full and incr backup times")
+	}
+	if status.WALArchive == nil || *status.WALArchive.MaxWAL != "000000010000000000000009" {
+		t.Errorf("Expected archive range from wal-show, got %+v", status.WALArchive)
+	}
+}
+
+func TestWALGRestoreCommand(t *testing.T) {
+	provider := backup.New(walgConfig())
+
+	cmd, err := provider.RestoreCommand(backup.WALGTarget, models.RestoreRequest{Target: models.RestoreTargetLatest})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(cmd.Argv, " "); got != "wal-g backup-fetch /var/lib/postgresql/data LATEST" {
+		t.Errorf("Unexpected command %q", got)
+	}
+
+	if _, err := provider.RestoreCommand(backup.WALGTarget, models.RestoreRequest{Target: models.RestoreTargetLSN, LSN: "0/3000060"}); err == nil {
+		t.Error("Expected LSN targets to be rejected")
+	}
+}
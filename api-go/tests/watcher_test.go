@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/config"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+)
+
+func nodeState(role string, timeline int) models.NodeState {
+	return models.NodeState{
+		Role:             role,
+		InRecovery:       role == handlers.RoleReplica,
+		SystemIdentifier: "7300000000000000001",
+		Timeline:         timeline,
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Watcher: config.WatcherConfig{Interval: time.Second, EventHistory: 10}}
+	h := handlers.NewWatcherHandler(cfg, nil)
+
+	h.Observe(nodeState(handlers.RoleReplica, 1), nil)
+	h.Observe(nodeState(handlers.RoleReplica, 1), nil)
+	h.Observe(models.NodeState{}, errors.New("connection refused"))
+	h.Observe(models.NodeState{}, errors.New("connection refused"))
+	h.Observe(nodeState(handlers.RolePrimary, 2), nil)
+
+	router := gin.New()
+	router.GET("/events", h.List)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	router.ServeHTTP(w, req)
+
+	var response models.EventsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	var types []string
+	for _, e := range response.Events {
+		types = append(types, e.Type)
+	}
+	want := []string{
+		handlers.EventWatchStarted,
+		handlers.EventNodeUnreachable,
+		handlers.EventNodeReachable,
+		handlers.EventRoleChanged,
+		handlers.EventTimelineChanged,
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected events %v, got %v", want, types)
+	}
+	if response.Current == nil || response.Current.Role != handlers.RolePrimary || response.Current.Timeline != 2 {
+		t.Errorf("Unexpected current state %+v", response.Current)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/events?type=role_changed", nil)
+	router.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Count != 1 || response.Events[0].Data["to"] != handlers.RolePrimary {
+		t.Errorf("Expected one role change to primary, got %+v", response.Events)
+	}
+}
+
+func TestWatcherEventStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Watcher: config.WatcherConfig{Interval: time.Second, EventHistory: 10}}
+	h := handlers.NewWatcherHandler(cfg, nil)
+	h.Observe(nodeState(handlers.RolePrimary, 1), nil)
+
+	router := gin.New()
+	router.GET("/events", h.List)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/events?follow=true", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Published after the stream started, and after the write timeout of
+	// the server; the event before Last-Event-ID is not repeated
+	time.Sleep(200 * time.Millisecond)
+	h.Observe(nodeState(handlers.RoleReplica, 1), nil)
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for len(lines) < 3 && scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 3 || lines[0] != "id:2" || lines[1] != "event:role_changed" || !strings.Contains(lines[2], `"to":"replica"`) {
+		t.Errorf("Unexpected stream %q", lines)
+	}
+}
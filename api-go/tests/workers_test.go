@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/postgresql-ha-dr/api-go/internal/handlers"
+	"github.com/postgresql-ha-dr/api-go/internal/models"
+	"github.com/postgresql-ha-dr/api-go/internal/notify"
+	"github.com/postgresql-ha-dr/api-go/internal/workers"
+)
+
+func TestWorkerRegistry(t *testing.T) {
+	registry := workers.NewRegistry()
+	sampler := registry.Register("sampler", time.Minute)
+	notifier := registry.Register("notifier", 0)
+	if registry.Register("sampler", time.Hour) != sampler {
+		t.Error("Expected registering a name again to return the same worker")
+	}
+
+	now := time.Now()
+	status := registry.Status(now)
+	if len(status) != 2 || status[0].Name != "sampler" || status[1].Name != "notifier" {
+		t.Fatalf("Expected the workers in registration order, got %+v", status)
+	}
+	if status[0].Stalled || status[0].Due == nil || status[0].LastRun != nil {
+		t.Errorf("Expected a new worker to be due but not stalled, got %+v", status[0])
+	}
+	if stalled := registry.Status(now.Add(workers.StallIntervals*time.Minute + time.Second))[0]; !stalled.Stalled {
+		t.Errorf("Expected a worker without runs to stall after %d intervals, got %+v", workers.StallIntervals, stalled)
+	}
+
+	sampler.Record(now.Add(-2*time.Second), errors.New("connection refused"))
+	sampler.Record(now.Add(-time.Second), nil)
+	notifier.Record(now, errors.New("unexpected status 500"))
+
+	status = registry.Status(time.Now())
+	if s := status[0]; s.Runs != 2 || s.Failures != 1 || s.LastError != "" || s.LastDuration < time.Second {
+		t.Errorf("Expected 2 runs, 1 failure and the last one to succeed after a second, got %+v", s)
+	}
+	if s := status[1]; s.Runs != 1 || s.Failures != 1 || s.LastError != "unexpected status 500" {
+		t.Errorf("Expected the failed delivery to be recorded, got %+v", s)
+	}
+	if s := registry.Status(time.Now().Add(24 * time.Hour))[1]; s.Stalled || s.Due != nil {
+		t.Errorf("Expected a worker driven by events never to stall, got %+v", s)
+	}
+
+	due := time.Now().Add(time.Hour)
+	sampler.Expect(due)
+	if s := registry.Status(due.Add(time.Second))[0]; !s.Stalled {
+		t.Errorf("Expected the worker to stall after the expected run, got %+v", s)
+	}
+
+	var none *workers.Registry
+	w := none.Register("sampler", time.Minute)
+	w.Record(time.Now(), nil)
+	w.Expect(time.Now())
+	if w != nil || none.Status(time.Now()) != nil {
+		t.Error("Expected a nil registry to record nothing")
+	}
+}
+
+func TestWorkersHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := workers.NewRegistry()
+	registry.Register("watcher", time.Minute).Record(time.Now(), errors.New("timeout"))
+	registry.Register("stuck", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	h := handlers.NewWorkersHandler(registry)
+	router := gin.New()
+	router.GET("/admin/workers", h.List)
+	router.GET("/metrics/prometheus", handlers.NewPrometheusHandler(h.Collector()).Metrics)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/workers", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response models.WorkersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Count != 2 || response.Stalled != 1 {
+		t.Fatalf("Expected 2 workers with 1 stalled, got %+v", response)
+	}
+	if watcher := response.Workers[0]; watcher.LastRun == nil || watcher.LastError != "timeout" || watcher.IntervalSeconds != 60 || watcher.Stalled {
+		t.Errorf("Expected the failed watcher run, got %+v", watcher)
+	}
+	if !response.Workers[1].Stalled {
+		t.Errorf("Expected the worker without runs to be stalled, got %+v", response.Workers[1])
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`pgha_worker_stalled{worker="stuck"} 1`,
+		`pgha_worker_stalled{worker="watcher"} 0`,
+		`pgha_worker_failures_total{worker="watcher"} 1`,
+		`pgha_worker_last_run_timestamp_seconds{worker="watcher"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the metrics, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `pgha_worker_last_run_timestamp_seconds{worker="stuck"}`) {
+		t.Error("Expected no last run time for a worker that never ran")
+	}
+}
+
+func TestNotifierRecordsDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	registry := workers.NewRegistry()
+	notify.New(nil, "", time.Second).UseWorkers(registry)
+	if len(registry.Status(time.Now())) != 0 {
+		t.Fatal("Expected a disabled notifier not to register a worker")
+	}
+
+	n := notify.New([]string{server.URL}, "", time.Second)
+	n.UseWorkers(registry)
+	n.Send("backup.failed", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := registry.Status(time.Now())
+		if len(status) == 1 && status[0].Runs == 1 {
+			if status[0].Name != notify.WorkerName || status[0].Failures != 1 || status[0].LastError != "unexpected status 502" {
+				t.Errorf("Expected the failed delivery to be recorded, got %+v", status[0])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the delivery to be recorded, got %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}